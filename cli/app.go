@@ -3,38 +3,129 @@ package cli
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/btcsuite/btcutil"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/abiregistry"
+	"github.com/qtumproject/janus/pkg/alerting"
 	"github.com/qtumproject/janus/pkg/analytics"
+	"github.com/qtumproject/janus/pkg/cache"
+	"github.com/qtumproject/janus/pkg/config"
+	"github.com/qtumproject/janus/pkg/filterstore"
+	"github.com/qtumproject/janus/pkg/journal"
+	"github.com/qtumproject/janus/pkg/logindex"
+	"github.com/qtumproject/janus/pkg/names"
 	"github.com/qtumproject/janus/pkg/notifier"
 	"github.com/qtumproject/janus/pkg/params"
+	"github.com/qtumproject/janus/pkg/pubsub"
 	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/replay"
+	"github.com/qtumproject/janus/pkg/secrets"
 	"github.com/qtumproject/janus/pkg/server"
 	"github.com/qtumproject/janus/pkg/transformer"
+	"github.com/qtumproject/janus/pkg/verification"
+	"github.com/shopspring/decimal"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
 	app = kingpin.New("janus", "Qtum adapter to Ethereum JSON RPC")
 
-	accountsFile = app.Flag("accounts", "account private keys (in WIF) returned by eth_accounts").Envar("ACCOUNTS").File()
+	configPath = app.Flag("config", "YAML (.yaml, .yml) or TOML (.toml) file of the upstream, account, cache, database, rate limit and logging settings, flags and environment variables set override it. SIGHUP reloads its log level and rate limits").Envar("CONFIG").Default("").String()
+
+	accountsFile = app.Flag("accounts", "account private keys (in WIF) returned by eth_accounts, one per line optionally followed by a label").Envar("ACCOUNTS").File()
 
 	qtumRPC             = app.Flag("qtum-rpc", "URL of qtum RPC service").Envar("QTUM_RPC").Default("").String()
+	qtumRPCUpstreams    = app.Flag("qtum-rpc-upstreams", "comma separated URLs of more qtumd nodes serving the same chain as --qtum-rpc, calls fail over to them and can be balanced with --upstream-balancing").Envar("QTUM_RPC_UPSTREAMS").Default("").String()
+	upstreamBalancing   = app.Flag("upstream-balancing", "how calls are spread over --qtum-rpc and --qtum-rpc-upstreams: failover, round-robin or least-latency").Envar("UPSTREAM_BALANCING").Default(qtum.BalanceFailover).String()
+	qtumRPCUserAgent    = app.Flag("qtum-rpc-user-agent", "User-Agent of requests to qtum RPC service (Janus/<version> by default)").Envar("QTUM_RPC_USER_AGENT").Default("").String()
+	qtumRPCHeaders      = app.Flag("qtum-rpc-header", "header added to requests to qtum RPC service as name=value, like the tenant header of an API gateway in front of qtumd (repeatable)").StringMap()
+	qtumRPCUser         = app.Flag("qtum-rpc-user", "user of qtum RPC service, instead of putting it in --qtum-rpc").Envar("QTUM_RPC_USER").Default("").String()
+	qtumRPCPassword     = app.Flag("qtum-rpc-password", "password of qtum RPC service, instead of putting it in --qtum-rpc").Envar("QTUM_RPC_PASSWORD").Default("").String()
+	qtumRPCToken        = app.Flag("qtum-rpc-token", "bearer token sent to qtum RPC service instead of a user and password, for qtumd behind an authenticating proxy").Envar("QTUM_RPC_TOKEN").Default("").String()
 	qtumNetwork         = app.Flag("qtum-network", "if 'regtest' (or connected to a regtest node with 'auto') Janus will generate blocks").Envar("QTUM_NETWORK").Default("auto").String()
+	dnsRefresh          = app.Flag("dns-refresh", "look up the qtum RPC hostname again at this interval and move connections to its new addresses, for DNS based failover (0 disables it, SRV names like _qtum._tcp.example.com default to 30s)").Envar("DNS_REFRESH").Default("0s").Duration()
+	dialFamily          = app.Flag("dial-family", "address families used to connect to qtumd: auto, prefer-ipv4, prefer-ipv6, ipv4 or ipv6 (only)").Envar("DIAL_FAMILY").Default(qtum.DialFamilyAuto).Enum(qtum.DialFamilies...)
+	dialFallbackDelay   = app.Flag("dial-fallback-delay", "how long the preferred address family gets to connect to qtumd before the other one is tried as well").Envar("DIAL_FALLBACK_DELAY").Default("300ms").Duration()
+	dialTimeoutIPv4     = app.Flag("dial-timeout-ipv4", "timeout connecting to a qtumd IPv4 address").Envar("DIAL_TIMEOUT_IPV4").Default("60s").Duration()
+	dialTimeoutIPv6     = app.Flag("dial-timeout-ipv6", "timeout connecting to a qtumd IPv6 address").Envar("DIAL_TIMEOUT_IPV6").Default("60s").Duration()
+	devAccounts         = app.Flag("dev-accounts", "[regtest only] number of deterministic dev accounts to derive, fund and print at startup").Envar("DEV_ACCOUNTS").Default("0").Int()
+	devAccountsSeed     = app.Flag("dev-accounts-seed", "[regtest only] phrase the dev accounts are derived from").Envar("DEV_ACCOUNTS_SEED").Default(qtum.DefaultDevAccountsSeed).String()
+	devAccountsBalance  = app.Flag("dev-accounts-balance", "[regtest only] QTUM each dev account is funded with").Envar("DEV_ACCOUNTS_BALANCE").Default("10000").String()
+	mineInterval        = app.Flag("mine-interval", "[regtest only] mine blocks at this interval, on top of the block mined after each transaction").Envar("MINE_INTERVAL").Default("0").Duration()
+	mineBlocks          = app.Flag("mine-blocks", "[regtest only] blocks mined every --mine-interval").Envar("MINE_BLOCKS").Default("1").Int()
 	generateToAddressTo = app.Flag("generateToAddressTo", "[regtest only] configure address to mine blocks to when mining new transactions in blocks").Envar("GENERATE_TO_ADDRESS").Default("").String()
 	bind                = app.Flag("bind", "network interface to bind to (e.g. 0.0.0.0) ").Default("localhost").String()
 	port                = app.Flag("port", "port to serve proxy").Default("23889").Int()
 	httpsKey            = app.Flag("https-key", "https keyfile").Default("").String()
 	httpsCert           = app.Flag("https-cert", "https certificate").Default("").String()
 	logFile             = app.Flag("log-file", "write logs to a file").Envar("LOG_FILE").Default("").String()
+	logLevel            = app.Flag("log-level", "least severe logs written: debug, info, warn or error (warn by default, debug with --dev)").Envar("LOG_LEVEL").Default("").Enum(append([]string{""}, config.Levels...)...)
 	matureBlockHeight   = app.Flag("mature-block-height-override", "override how old a coinbase/coinstake needs to be to be considered mature enough for spending (QTUM uses 2000 blocks after the 32s block fork) - if this value is incorrect transactions can be rejected").Int()
+	latestConfirmations = app.Flag("latest-confirmations", "treat \"latest\" as this many blocks below the chain tip for reading balances, logs and receipts").Envar("LATEST_CONFIRMATIONS").Default("0").Int()
+	balanceMode         = app.Flag("balance-mode", "what eth_getBalance returns for accounts: 'total' includes immature staking rewards, 'spendable' excludes them").Envar("BALANCE_MODE").Default(qtum.BalanceModeTotal).Enum(qtum.BalanceModeTotal, qtum.BalanceModeSpendable)
+	blockGas            = app.Flag("block-gas", "gas limit and gas used of blocks: 'constant' for a 40M gas limit and the gas used by the receipts of blocks asked for with their transactions, 'weight' for qtumd's maximum block weight and the weight of the block").Envar("BLOCK_GAS").Default(qtum.BlockGasConstant).Enum(qtum.BlockGasConstant, qtum.BlockGasWeight)
+	blockDifficulty     = app.Flag("block-difficulty", "difficulty of blocks: 'qtum' for qtumd's proof of stake difficulty, 'geth' for the zero difficulty of geth's proof of stake blocks").Envar("BLOCK_DIFFICULTY").Default(qtum.BlockDifficultyQtum).Enum(qtum.BlockDifficultyQtum, qtum.BlockDifficultyGeth)
+	blockTimestamp      = app.Flag("block-timestamp", "timestamp of blocks: 'time' for the time of their header, 'mediantime' for the median time of the blocks before them, which never decreases").Envar("BLOCK_TIMESTAMP").Default(qtum.BlockTimestampTime).Enum(qtum.BlockTimestampTime, qtum.BlockTimestampMedian)
+	mempoolPrecheck     = app.Flag("mempool-precheck", "run testmempoolaccept before broadcasting eth_sendRawTransaction and return the rejection reason").Envar("MEMPOOL_PRECHECK").Default("false").Bool()
+	simulateBeforeSend  = app.Flag("simulate-before-send", "simulate eth_sendTransaction contract calls with callcontract and reject them with the revert reason instead of sending").Envar("SIMULATE_BEFORE_SEND").Default("false").Bool()
+	localEVM            = app.Flag("local-evm", "run eth_call with state overrides, debug_traceCall and eth_createAccessList in an embedded EVM fed with state from qtumd").Envar("LOCAL_EVM").Default("false").Bool()
+	multicallAddress    = app.Flag("multicall-address", "hex address of a contract with Multicall3's aggregate3, eth_call runs its aggregate3 calls as batched callcontract calls").Envar("MULTICALL_ADDRESS").Default("").String()
+	deployMulticall     = app.Flag("deploy-multicall", "[regtest and testnet only] deploy a multicall contract from qtumd's wallet at startup when --multicall-address isn't set").Envar("DEPLOY_MULTICALL").Default("false").Bool()
+	networkContracts    = app.Flag("network-contracts", "JSON file of well-known contract addresses returned by janus_getNetworkContracts, as {\"main\": {\"wqtum\": \"0x...\"}, \"test\": {...}}").Envar("NETWORK_CONTRACTS").File()
+	namesFile           = app.Flag("names", "JSON file of names accepted instead of addresses as {\"alice.qtum\": \"0x...\"}, looked up before --name-registry").Envar("NAMES").File()
+	nameRegistry        = app.Flag("name-registry", "hex address of an ENS compatible registry contract resolving names accepted instead of addresses").Envar("NAME_REGISTRY").Default("").String()
+	logsBlockRange      = app.Flag("logs-block-range", "how many blocks a single searchlogs call of eth_getLogs covers, wider ranges are searched in parts (0 uses the default of 1000)").Envar("LOGS_BLOCK_RANGE").Default("0").Int()
+	logsMaxResults      = app.Flag("logs-max-results", "how many logs eth_getLogs returns at most, queries matching more fail (0 uses the default of 10000)").Envar("LOGS_MAX_RESULTS").Default("0").Int()
+	txLookupBlocks      = app.Flag("tx-lookup-blocks", "how many of the latest blocks are searched for transactions qtumd can't find without -txindex (0 uses the default of 20)").Envar("TX_LOOKUP_BLOCKS").Default("0").Int()
+	traceConcurrency    = app.Flag("trace-concurrency", "how many transactions of a block debug_traceBlockByNumber and debug_traceBlockByHash trace at the same time (0 uses the default of 4)").Envar("TRACE_CONCURRENCY").Default("0").Int()
+	keystoreDir         = app.Flag("keystore", "directory of geth style encrypted key files whose accounts are unlocked with personal_unlockAccount, their address is the Qtum hex address").Envar("KEYSTORE").Default("").String()
+	keystoreReload      = app.Flag("keystore-reload", "rescan --keystore for added, changed and removed key files this often (0 reads it once)").Envar("KEYSTORE_RELOAD").Default("10s").Duration()
+	keystoreUnlock      = app.Flag("keystore-unlock-timeout", "how long personal_unlockAccount unlocks an account for without a duration (0 unlocks it until Janus exits)").Envar("KEYSTORE_UNLOCK_TIMEOUT").Default("300s").Duration()
+	allowInsecureUnlock = app.Flag("allow-insecure-unlock", "let personal_unlockAccount unlock keystore accounts, which then sign for anyone reaching Janus until they are locked again").Envar("ALLOW_INSECURE_UNLOCK").Default("false").Bool()
+	walletAccounts      = app.Flag("wallet-accounts", "add the addresses of qtumd's wallet to eth_accounts").Envar("WALLET_ACCOUNTS").Default("false").Bool()
 	healthCheckPercent  = app.Flag("health-check-healthy-request-amount", "configure the minimum request success rate for healthcheck").Envar("HEALTH_CHECK_REQUEST_PERCENT").Default("80").Int()
+	diffReference       = app.Flag("diff-reference", "[Diagnostic] URL of an Ethereum node with equivalent state to mirror read requests to, differences between the responses are logged").Envar("DIFF_REFERENCE").Default("").String()
+	responseExtensions  = app.Flag("response-extensions", "add the Qtum and Janus specific fields, like qtumFee of receipts, to the responses of methods in the Ethereum spec (--no-response-extensions gives strict consumers the spec's fields only)").Envar("RESPONSE_EXTENSIONS").Default("true").Bool()
+	timings             = app.Flag("timings", "[Debug] add a janus_timings object with the Qtum RPC calls made and time spent to responses").Envar("TIMINGS").Default("false").Bool()
+	metrics             = app.Flag("metrics", "serve Prometheus metrics of eth requests, qtumd calls, the cache, retries and websocket connections at /metrics").Envar("METRICS").Default("false").Bool()
+	qrc20API            = app.Flag("qrc20-api", "serve the name, symbol, decimals, total supply and balances of QRC20 tokens over REST at /qrc20/{contract}/info and /qrc20/{contract}/balanceOf/{address}").Envar("QRC20_API").Default("false").Bool()
+	qrc721API           = app.Flag("qrc721-api", "serve the tokens an address owns and the owner and tokenURI of tokens of QRC721 contracts over REST at /qrc721/{contract}/tokensOf/{address} and /qrc721/{contract}/token/{tokenId}").Envar("QRC721_API").Default("false").Bool()
+	keepAliveInterval   = app.Flag("keepalive-interval", "ping qtumd with getblockcount at this interval to keep idle connections to it alive and fail the liveness check when it stops answering (0 disables it)").Envar("KEEPALIVE_INTERVAL").Default("0s").Duration()
+	shutdownGracePeriod = app.Flag("shutdown-grace-period", "on SIGTERM or SIGINT stop accepting connections and give requests in flight this long to complete before closing the connections left").Envar("SHUTDOWN_GRACE_PERIOD").Default("30s").Duration()
+	requestTimeout      = app.Flag("request-timeout", "give up on requests that take longer than this, clients can ask for less with the X-Janus-Timeout header (0 waits indefinitely)").Envar("REQUEST_TIMEOUT").Default("0s").Duration()
+	methodTimeouts      = app.Flag("method-timeout", "timeout for a method as method=duration, overriding --request-timeout when shorter (repeatable)").StringMap()
+	rateLimit           = app.Flag("rate-limit", "requests per second Janus serves, over it requests fail with -32005 limit exceeded (0 leaves them unlimited)").Envar("RATE_LIMIT").Default("0").Float64()
+	methodRateLimits    = app.Flag("method-rate-limit", "requests per second of a method as method=rate, also counted against --rate-limit (repeatable)").StringMap()
+	rateLimitBurst      = app.Flag("rate-limit-burst", "requests served at once before --rate-limit and --method-rate-limit apply").Envar("RATE_LIMIT_BURST").Default("10").Int()
+	rateLimitPerIP      = app.Flag("rate-limit-per-ip", "apply --rate-limit and --method-rate-limit to each client IP instead of all clients together").Envar("RATE_LIMIT_PER_IP").Default("false").Bool()
+	rateLimitProxies    = app.Flag("rate-limit-trusted-proxy", "IP or CIDR of a proxy whose X-Forwarded-For header tells the client IP for --rate-limit-per-ip, other clients are told apart by their address (repeatable)").Strings()
+	diffMethods         = app.Flag("diff-methods", "[Diagnostic] comma separated methods mirrored to --diff-reference").Envar("DIFF_METHODS").Default("").String()
+
+	wsCompression          = app.Flag("ws-compression", "compress websocket messages with permessage-deflate for clients supporting it").Envar("WS_COMPRESSION").Default("false").Bool()
+	wsCompressionLevel     = app.Flag("ws-compression-level", "flate level of websocket compression, from 1 (fastest) to 9 (smallest)").Envar("WS_COMPRESSION_LEVEL").Default("1").Int()
+	wsCompressionThreshold = app.Flag("ws-compression-threshold", "send websocket messages smaller than this many bytes uncompressed").Envar("WS_COMPRESSION_THRESHOLD").Default("512").Int()
+	wsMaxFrameSize         = app.Flag("ws-max-frame-size", "split outgoing websocket messages into frames of at most this many bytes").Envar("WS_MAX_FRAME_SIZE").Default("4096").Int()
+	wsMaxMessageSize       = app.Flag("ws-max-message-size", "close websocket connections sending messages larger than this many bytes (0 accepts any size)").Envar("WS_MAX_MESSAGE_SIZE").Default("0").Int64()
+	notificationRate       = app.Flag("notification-rate", "notifications per second of a websocket subscription, logs over it are coalesced into arrays and newHeads skip to the latest head (0 leaves them unlimited)").Envar("NOTIFICATION_RATE").Default("0").Float64()
+	notificationBurst      = app.Flag("notification-burst", "notifications a subscription can send at once before --notification-rate applies").Envar("NOTIFICATION_BURST").Default("10").Int()
+	batchLogs              = app.Flag("batch-logs", "send the logs a subscription matches in a block as one notification with an array of logs").Envar("BATCH_LOGS").Default("false").Bool()
+
+	pendingTransactionsInterval = app.Flag("pending-transactions-interval", "how often qtumd's mempool is polled for newPendingTransactions subscriptions").Envar("PENDING_TRANSACTIONS_INTERVAL").Default("2s").Duration()
+	wsMaxConcurrentRequests     = app.Flag("ws-max-concurrent-requests", "requests of a websocket connection to /ws processed at the same time").Envar("WS_MAX_CONCURRENT_REQUESTS").Default("16").Int()
+
+	zmqHashBlock = app.Flag("zmq-hashblock", "qtumd's -zmqpubhashblock address, like tcp://127.0.0.1:28332, to be notified of new blocks instead of polling for them").Envar("ZMQ_HASHBLOCK").Default("").String()
+	zmqRawTx     = app.Flag("zmq-rawtx", "qtumd's -zmqpubrawtx address to be notified of the transactions entering its mempool instead of polling for them").Envar("ZMQ_RAWTX").Default("").String()
 
 	sqlHost     = app.Flag("sql-host", "database hostname").Envar("SQL_HOST").Default("127.0.0.1").String()
 	sqlPort     = app.Flag("sql-port", "database port").Envar("SQL_PORT").Default("5432").Int()
@@ -44,6 +135,30 @@ var (
 	sqlDbname   = app.Flag("sql-dbname", "database name").Envar("SQL_DBNAME").Default("postgres").String()
 
 	dbConnectionString = app.Flag("dbstring", "database connection string").String()
+	sharedFilters      = app.Flag("shared-filters", "keep eth_newFilter and eth_newBlockFilter filters in the database so every Janus instance sharing it can serve them").Envar("SHARED_FILTERS").Default("false").Bool()
+	logIndex           = app.Flag("log-index", "index the logs of qtumd's blocks in the database and serve eth_getLogs and eth_getFilterLogs from it for the indexed blocks").Envar("LOG_INDEX").Default("false").Bool()
+	logIndexFrom       = app.Flag("log-index-from", "block the log index starts at, changing it rebuilds the index").Envar("LOG_INDEX_FROM").Default("0").Int64()
+	txJournal          = app.Flag("tx-journal", "record failed eth_sendRawTransaction broadcasts in the database, to be listed and broadcast again with janus_listFailedTransactions and janus_rebroadcastTransaction").Envar("TX_JOURNAL").Default("false").Bool()
+	persistEthereumTxs = app.Flag("persist-ethereum-transactions", "keep the hashes of the Ethereum transactions eth_sendRawTransaction sent in the database, so they can't be sent again after a restart or through another Janus instance").Envar("PERSIST_ETHEREUM_TRANSACTIONS").Default("false").Bool()
+	verifyContracts    = app.Flag("verify-contracts", "enable janus_verifyContract, keeping the sources of verified contracts in the database for janus_getVerifiedContract").Envar("VERIFY_CONTRACTS").Default("false").Bool()
+	solcPath           = app.Flag("solc", "solc binary janus_verifyContract compiles with").Envar("SOLC").Default("solc").String()
+	solcAPI            = app.Flag("solc-api", "URL of a compilation service janus_verifyContract compiles with instead of --solc, offering every solc version").Envar("SOLC_API").Default("").String()
+	abiRegistry        = app.Flag("abi-registry", "keep contract ABIs registered with janus_registerABI in the database").Envar("ABI_REGISTRY").Default("false").Bool()
+
+	persistAnalytics  = app.Flag("persist-analytics", "keep the request success rates behind the health checks in the database, so they survive restarts").Envar("PERSIST_ANALYTICS").Default("false").Bool()
+	analyticsFile     = app.Flag("analytics-file", "keep the request success rates behind the health checks in this state file instead of the database").Envar("ANALYTICS_FILE").Default("").String()
+	analyticsInterval = app.Flag("analytics-interval", "how often the request success rates are saved with --persist-analytics or --analytics-file").Envar("ANALYTICS_INTERVAL").Default("30s").Duration()
+
+	alertWebhooks            = app.Flag("alert-webhook", "URL to post alerts to as JSON with a Slack compatible text field (repeatable)").Strings()
+	alertPagerDutyKey        = app.Flag("alert-pagerduty-key", "PagerDuty Events API v2 routing key to trigger and resolve incidents with").Envar("ALERT_PAGERDUTY_KEY").Default("").String()
+	alertWindow              = app.Flag("alert-window", "how long a condition has to last before it is alerted on").Envar("ALERT_WINDOW").Default("5m").Duration()
+	alertErrorRate           = app.Flag("alert-error-rate", "alert when more than this percentage of the last qtumd or client requests fail (0 disables it)").Envar("ALERT_ERROR_RATE").Default("0").Float64()
+	alertBlockLag            = app.Flag("alert-block-lag", "alert when qtumd is more than this many blocks behind the headers it knows of (0 disables it)").Envar("ALERT_BLOCK_LAG").Default("0").Int64()
+	alertSubscriptionBacklog = app.Flag("alert-subscription-backlog", "alert when more than this many websocket notifications are queued or held back (0 disables it)").Envar("ALERT_SUBSCRIPTION_BACKLOG").Default("0").Int()
+
+	staleChainAfter     = app.Flag("stale-chain-after", "report the chain as stale in /health when qtumd's tip is older than this (0 disables it)").Envar("STALE_CHAIN_AFTER").Default("0s").Duration()
+	staleChainReadiness = app.Flag("stale-chain-readiness", "fail the readiness check while the chain is stale").Envar("STALE_CHAIN_READINESS").Default("false").Bool()
+	blockInterval       = app.Flag("block-interval", "expected time between blocks, qtumd's tip is checked this often with --stale-chain-after").Envar("BLOCK_INTERVAL").Default("32s").Duration()
 
 	devMode        = app.Flag("dev", "[Insecure] Developer mode").Envar("DEV").Default("false").Bool()
 	singleThreaded = app.Flag("singleThreaded", "[Non-production] Process RPC requests in a single thread").Envar("SINGLE_THREADED").Default("false").Bool()
@@ -51,23 +166,171 @@ var (
 	ignoreUnknownTransactions = app.Flag("ignoreTransactions", "[Development] Ignore transactions inside blocks we can't fetch and return responses instead of failing").Default("false").Bool()
 	disableSnipping           = app.Flag("disableSnipping", "[Development] Disable ...snip... in logs").Default("false").Bool()
 	hideQtumdLogs             = app.Flag("hideQtumdLogs", "[Development] Hide QTUMD debug logs").Envar("HIDE_QTUMD_LOGS").Default("false").Bool()
+
+	pubsubRedis      = app.Flag("pubsub-redis", "URL of a Redis server (redis://host:6379/0) shared by Janus instances, websocket subscribers on any of them get the notifications one instance produces").Envar("PUBSUB_REDIS").Default("").String()
+	cacheRedis       = app.Flag("cache-redis", "URL of a Redis server (redis://host:6379/0) shared by Janus instances, keeping the qtumd responses any of them cached for the others").Envar("CACHE_REDIS").Default("").String()
+	replicationToken = app.Flag("replication-token", "serve filters and cached responses to a standby at /replication/state, to requests with this bearer token").Envar("REPLICATION_TOKEN").Default("").String()
+	standbyOf        = app.Flag("standby-of", "run as the standby of the Janus instance at this URL, copying its state with --replication-token until the first request arrives here").Envar("STANDBY_OF").Default("").String()
+	adminToken       = app.Flag("admin-token", "serve the admin API listing and closing websocket connections at /admin/connections, to requests with this bearer token").Envar("ADMIN_TOKEN").Default("").String()
+
+	responseSigningKey       = app.Flag("response-signing-key", "sign HTTP response bodies in the X-Janus-Signature header with this HMAC secret or Ed25519 private key, can be a secret reference").Envar("RESPONSE_SIGNING_KEY").Default("").String()
+	responseSigningAlgorithm = app.Flag("response-signing-algorithm", "hmac-sha256 or ed25519").Envar("RESPONSE_SIGNING_ALGORITHM").Default("hmac-sha256").Enum("hmac-sha256", "ed25519")
+
+	requestSigningKey    = app.Flag("request-signing-key", "serve the methods mining blocks, changing what Janus keeps or signing with its accounts to requests signed with this HMAC secret, can be a secret reference").Envar("REQUEST_SIGNING_KEY").Default("").String()
+	trustLocalRequests   = app.Flag("trust-local-requests", "serve the methods mining blocks, changing what Janus keeps or signing with its accounts to every client connecting from the host Janus runs on, without a signature").Envar("TRUST_LOCAL_REQUESTS").Default("false").Bool()
+	requestSigningWindow = app.Flag("request-signing-window", "how far the timestamp of a signed request can be from the clock of Janus").Envar("REQUEST_SIGNING_WINDOW").Default("5m").Duration()
+
+	tokenGracePeriod = app.Flag("token-grace-period", "how long rotated out admin and replication tokens keep working").Envar("TOKEN_GRACE_PERIOD").Default("5m").Duration()
+
+	walletPassphrase     = app.Flag("wallet-passphrase", "unlock qtumd's wallet with this passphrase when signing fails because it is locked, better set through the environment").Envar("WALLET_PASSPHRASE").Default("").String()
+	walletPassphraseFile = app.Flag("wallet-passphrase-file", "unlock qtumd's wallet with the passphrase in this file, read on every unlock so rotated secrets are picked up").Envar("WALLET_PASSPHRASE_FILE").Default("").String()
+	walletUnlockTimeout  = app.Flag("wallet-unlock-timeout", "how long qtumd keeps its wallet unlocked after Janus unlocks it").Envar("WALLET_UNLOCK_TIMEOUT").Default("60s").Duration()
+
+	vaultAddress   = app.Flag("vault-addr", "address of the HashiCorp Vault server resolving vault: secret references").Envar("VAULT_ADDR").Default("").String()
+	vaultToken     = app.Flag("vault-token", "token authenticating to Vault").Envar("VAULT_TOKEN").Default("").String()
+	vaultNamespace = app.Flag("vault-namespace", "Vault Enterprise namespace of the secrets").Envar("VAULT_NAMESPACE").Default("").String()
+	secretsRefresh = app.Flag("secrets-refresh", "resolve the secret references of --qtum-rpc, --admin-token and --replication-token again this often to pick up rotated credentials (0 resolves them once)").Envar("SECRETS_REFRESH").Default("0").Duration()
+	accountsSecret = app.Flag("accounts-secret", "secret reference to account private keys in the format of --accounts, like vault:secret/data/janus#accounts").Envar("ACCOUNTS_SECRET").Default("").String()
+
+	networks        = app.Flag("network", "additional network to serve from this process as name=qtum-rpc-url, requests are routed to it by the /name path prefix (repeatable)").StringMap()
+	networkChain    = app.Flag("network-chain", "chain (main, test or regtest) of an additional network as name=chain (repeatable), detected from its qtumd when left out").StringMap()
+	networkAccounts = app.Flag("network-accounts", "account private keys file (in WIF) for an additional network as name=path (repeatable)").StringMap()
+	networkKeystore = app.Flag("network-keystore", "directory of encrypted key files for an additional network as name=path (repeatable)").StringMap()
+	networkHosts    = app.Flag("network-host", "Host header to route to an additional network as name=host (repeatable)").StringMap()
+	networkRegistry = app.Flag("network-name-registry", "hex address of the ENS compatible registry contract resolving names on an additional network as name=address (repeatable)").StringMap()
 )
 
-func loadAccounts(r io.Reader, l log.Logger) qtum.Accounts {
-	var accounts qtum.Accounts
+func alertingConfig() alerting.Config {
+	var senders []alerting.Sender
+	for _, url := range *alertWebhooks {
+		senders = append(senders, &alerting.Webhook{URL: url})
+	}
+	if *alertPagerDutyKey != "" {
+		senders = append(senders, &alerting.PagerDuty{RoutingKey: *alertPagerDutyKey})
+	}
+	source, _ := os.Hostname()
+	return alerting.Config{
+		Senders: senders,
+		Window:  *alertWindow,
+		Source:  source,
+	}
+}
+
+// secretsResolver resolves the secret references flags can be set to, like vault:secret/data/janus#rpc-url
+func secretsResolver() *secrets.Resolver {
+	resolver := secrets.NewResolver()
+	resolver.Register("vault", &secrets.Vault{
+		Address:   *vaultAddress,
+		Token:     *vaultToken,
+		Namespace: *vaultNamespace,
+	})
+	resolver.Register("gcp", &secrets.GCPSecretManager{})
+	return resolver
+}
+
+// resolveSecrets replaces the flags set to secret references with the secrets
+func resolveSecrets(ctx context.Context, resolver *secrets.Resolver) error {
+	flags := map[string]*string{
+		"qtum-rpc":             qtumRPC,
+		"qtum-rpc-upstreams":   qtumRPCUpstreams,
+		"qtum-rpc-password":    qtumRPCPassword,
+		"qtum-rpc-token":       qtumRPCToken,
+		"sql-password":         sqlPassword,
+		"dbstring":             dbConnectionString,
+		"admin-token":          adminToken,
+		"cache-redis":          cacheRedis,
+		"replication-token":    replicationToken,
+		"response-signing-key": responseSigningKey,
+		"request-signing-key":  requestSigningKey,
+	}
+	for flag, value := range flags {
+		secret, err := resolver.Resolve(ctx, *value)
+		if err != nil {
+			return errors.WithMessagef(err, "--%s", flag)
+		}
+		*value = secret
+	}
+	for name, rpcURL := range *networks {
+		secret, err := resolver.Resolve(ctx, rpcURL)
+		if err != nil {
+			return errors.WithMessagef(err, "--network %s", name)
+		}
+		(*networks)[name] = secret
+	}
+	for name, value := range *qtumRPCHeaders {
+		secret, err := resolver.Resolve(ctx, value)
+		if err != nil {
+			return errors.WithMessagef(err, "--qtum-rpc-header %s", name)
+		}
+		(*qtumRPCHeaders)[name] = secret
+	}
+	return nil
+}
+
+// rotateQtumCredentials switches to the user and password of a rotated qtumd RPC URL
+func rotateQtumCredentials(client *qtum.Client, logger log.Logger) func(string) {
+	return func(rpcURL string) {
+		u, err := url.Parse(rpcURL)
+		if err != nil || u.User == nil {
+			level.Warn(logger).Log("msg", "Ignoring rotated qtumd RPC URL without user and password")
+			return
+		}
+		password, _ := u.User.Password()
+		client.SetCredentials(u.User.Username(), password)
+	}
+}
 
-	if accountsFile != nil {
-		s := bufio.NewScanner(*accountsFile)
-		for s.Scan() {
-			line := s.Text()
+// rotateToken switches the server to a rotated admin or replication token
+func rotateToken(rotate func(string) error, logger log.Logger) func(string) {
+	return func(token string) {
+		if err := rotate(token); err != nil {
+			level.Warn(logger).Log("msg", "Ignoring rotated token", "error", err)
+		}
+	}
+}
 
-			wif, err := btcutil.DecodeWIF(line)
+// walletPassphraseSource reads the wallet passphrase from --wallet-passphrase-file or --wallet-passphrase, which may be
+// a secret reference, nil when neither is set
+func walletPassphraseSource(resolver *secrets.Resolver) qtum.PassphraseSource {
+	if *walletPassphraseFile != "" {
+		return func(ctx context.Context) (string, error) {
+			passphrase, err := os.ReadFile(*walletPassphraseFile)
 			if err != nil {
-				level.Error(l).Log("msg", "Failed to parse account", "err", err.Error())
-				continue
+				return "", err
 			}
+			return strings.TrimRight(string(passphrase), "\r\n"), nil
+		}
+	}
+	if *walletPassphrase != "" {
+		return func(ctx context.Context) (string, error) {
+			return resolver.Resolve(ctx, *walletPassphrase)
+		}
+	}
+	return nil
+}
+
+// loadAccounts reads a private key in WIF per line, anything after the key is the account's label
+func loadAccounts(r io.Reader, l log.Logger) (qtum.Accounts, map[string]string) {
+	var accounts qtum.Accounts
+	labels := map[string]string{}
 
-			accounts = append(accounts, wif)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		key, label := line, ""
+		if i := strings.IndexAny(line, " \t"); i != -1 {
+			key, label = line[:i], strings.TrimSpace(line[i:])
+		}
+
+		wif, err := btcutil.DecodeWIF(key)
+		if err != nil {
+			level.Error(l).Log("msg", "Failed to parse account", "err", err.Error())
+			continue
+		}
+
+		accounts = append(accounts, wif)
+		if label != "" {
+			labels[(&qtum.Account{WIF: wif}).ToHexAddress()] = label
 		}
 	}
 
@@ -77,7 +340,7 @@ func loadAccounts(r io.Reader, l log.Logger) qtum.Accounts {
 		level.Warn(l).Log("msg", "No accounts loaded from account file")
 	}
 
-	return accounts
+	return accounts, labels
 }
 
 func action(pc *kingpin.ParseContext) error {
@@ -104,24 +367,67 @@ func action(pc *kingpin.ParseContext) error {
 	}
 
 	logWriter := io.MultiWriter(writers...)
-	logger := log.NewLogfmtLogger(logWriter)
-
-	if !*devMode {
-		logger = level.NewFilter(logger, level.AllowWarn())
+	levels, err := config.NewLevelLogger(log.NewLogfmtLogger(logWriter), effectiveLogLevel(*logLevel))
+	if err != nil {
+		return err
 	}
+	var logger log.Logger = levels
 
 	var accounts qtum.Accounts
+	var accountLabels map[string]string
 	if *accountsFile != nil {
-		accounts = loadAccounts(*accountsFile, logger)
+		accounts, accountLabels = loadAccounts(*accountsFile, logger)
 		(*accountsFile).Close()
 	}
 
+	var contracts map[string]map[string]string
+	if *networkContracts != nil {
+		err := json.NewDecoder(*networkContracts).Decode(&contracts)
+		(*networkContracts).Close()
+		if err != nil {
+			return errors.Wrap(err, "Failed to parse --network-contracts")
+		}
+	}
+
+	if *timings && !*responseExtensions {
+		return errors.New("--timings adds the janus_timings extension to responses, which --no-response-extensions leaves out")
+	}
+
 	isMain := *qtumNetwork == qtum.ChainMain
 
 	ctx, shutdownQtum := context.WithCancel(context.Background())
 	defer shutdownQtum()
 
+	resolver := secretsResolver()
+	qtumRPCReference, qtumRPCPasswordReference, qtumRPCTokenReference := *qtumRPC, *qtumRPCPassword, *qtumRPCToken
+	adminTokenReference, replicationTokenReference := *adminToken, *replicationToken
+	if err := resolveSecrets(ctx, resolver); err != nil {
+		return err
+	}
+	if *accountsSecret != "" {
+		if *accountsFile != nil {
+			return errors.New("Use either --accounts or --accounts-secret")
+		}
+		secret, err := resolver.Resolve(ctx, *accountsSecret)
+		if err != nil {
+			return errors.WithMessage(err, "--accounts-secret")
+		}
+		accounts, accountLabels = loadAccounts(strings.NewReader(secret), logger)
+	}
+
 	qtumRequestAnalytics := analytics.NewAnalytics(50)
+	ethRequestAnalytics := analytics.NewAnalytics(50)
+
+	var cacheBackend qtum.CacheBackend
+	var redisCache *cache.RedisCache
+	if *cacheRedis != "" {
+		redisCache, err = cache.NewRedisCache(ctx, *cacheRedis)
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup response cache")
+		}
+		defer redisCache.Close()
+		cacheBackend = redisCache
+	}
 
 	qtumJSONRPC, err := qtum.NewClient(
 		isMain,
@@ -130,12 +436,40 @@ func action(pc *kingpin.ParseContext) error {
 		qtum.SetLogWriter(logWriter),
 		qtum.SetLogger(logger),
 		qtum.SetAccounts(accounts),
+		qtum.SetAccountLabels(accountLabels),
+		qtum.SetKeystore(*keystoreDir, *keystoreReload, *keystoreUnlock),
+		qtum.SetAllowInsecureUnlock(*allowInsecureUnlock),
 		qtum.SetGenerateToAddress(*generateToAddressTo),
+		qtum.SetIntervalMining(*mineInterval, *mineBlocks),
 		qtum.SetIgnoreUnknownTransactions(*ignoreUnknownTransactions),
 		qtum.SetDisableSnippingQtumRpcOutput(*disableSnipping),
 		qtum.SetHideQtumdLogs(*hideQtumdLogs),
 		qtum.SetMatureBlockHeight(matureBlockHeight),
+		qtum.SetLatestConfirmations(*latestConfirmations),
+		qtum.SetBalanceMode(*balanceMode),
+		qtum.SetBlockFields(*blockGas, *blockDifficulty, *blockTimestamp),
+		qtum.SetResponseExtensions(*responseExtensions),
+		qtum.SetMempoolPrecheck(*mempoolPrecheck),
+		qtum.SetSimulateBeforeSend(*simulateBeforeSend),
+		qtum.SetLocalEVM(*localEVM),
+		qtum.SetMulticallAddress(*multicallAddress),
+		qtum.SetNetworkContracts(contracts),
+		qtum.SetWalletAccounts(*walletAccounts),
+		qtum.SetTraceConcurrency(*traceConcurrency),
+		qtum.SetLogsBlockRange(*logsBlockRange),
+		qtum.SetLogsMaxResults(*logsMaxResults),
+		qtum.SetTxLookupBlocks(*txLookupBlocks),
+		qtum.SetWalletPassphrase(walletPassphraseSource(resolver), *walletUnlockTimeout),
+		qtum.SetRPCAuth(*qtumRPCUser, *qtumRPCPassword, *qtumRPCToken),
+		qtum.SetUpstreams(strings.Fields(strings.ReplaceAll(*qtumRPCUpstreams, ",", " "))),
+		qtum.SetUserAgent(*qtumRPCUserAgent),
+		qtum.SetHeaders(*qtumRPCHeaders),
+		qtum.SetUpstreamBalancing(*upstreamBalancing),
+		qtum.SetCacheBackend(cacheBackend),
 		qtum.SetContext(ctx),
+		qtum.SetDNSRefresh(*dnsRefresh),
+		qtum.SetZMQ(*zmqHashBlock, *zmqRawTx),
+		qtum.SetDialConfig(dialConfig()),
 		qtum.SetSqlHost(*sqlHost),
 		qtum.SetSqlPort(*sqlPort),
 		qtum.SetSqlUser(*sqlUser),
@@ -148,24 +482,176 @@ func action(pc *kingpin.ParseContext) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to setup QTUM client")
 	}
+	go resolver.Watch(ctx, qtumRPCReference, *qtumRPC, *secretsRefresh, logger, rotateQtumCredentials(qtumJSONRPC, logger))
+	go resolver.Watch(ctx, qtumRPCPasswordReference, *qtumRPCPassword, *secretsRefresh, logger, func(password string) {
+		qtumJSONRPC.SetCredentials(*qtumRPCUser, password)
+	})
+	go resolver.Watch(ctx, qtumRPCTokenReference, *qtumRPCToken, *secretsRefresh, logger, qtumJSONRPC.SetBearerToken)
+
+	if *txJournal {
+		failedTransactions, err := journal.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup transaction journal")
+		}
+		defer failedTransactions.Close()
+		qtumJSONRPC.SetJournal(failedTransactions)
+	}
+
+	if *persistEthereumTxs {
+		ethereumTransactions, err := replay.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup sent Ethereum transactions")
+		}
+		defer ethereumTransactions.Close()
+		qtumJSONRPC.SetEthereumTransactions(ethereumTransactions)
+	}
+
+	if *verifyContracts {
+		verifiedContracts, err := verification.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup contract verification")
+		}
+		defer verifiedContracts.Close()
+		var compiler verification.Compiler = &verification.SolcBinary{Path: *solcPath}
+		if *solcAPI != "" {
+			compiler = &verification.SolcAPI{URL: *solcAPI}
+		}
+		qtumJSONRPC.SetVerifier(&verification.Verifier{Compiler: compiler, Store: verifiedContracts})
+	}
+
+	if *abiRegistry {
+		registry, err := abiregistry.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup ABI registry")
+		}
+		defer registry.Close()
+		qtumJSONRPC.SetABIRegistry(registry)
+	}
+
+	var indexedLogs *logindex.SQLIndex
+	if *logIndex {
+		indexedLogs, err = logindex.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup log index")
+		}
+		defer indexedLogs.Close()
+		qtumJSONRPC.SetLogIndex(indexedLogs)
+	}
+
+	if *sharedFilters {
+		filters, err := filterstore.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup shared filters")
+		}
+		defer filters.Close()
+		qtumJSONRPC.SetFilterStore(filters)
+	}
+
+	var analyticsStore analytics.Store
+	if *analyticsFile != "" {
+		analyticsStore = &analytics.FileStore{Path: *analyticsFile}
+	} else if *persistAnalytics {
+		store, err := analytics.Open(ctx, qtumJSONRPC.DbConfig.String())
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup analytics persistence")
+		}
+		defer store.Close()
+		analyticsStore = store
+	}
+	if analyticsStore != nil {
+		persister, err := analytics.NewPersister(analyticsStore, *analyticsInterval, logger)
+		if err != nil {
+			return err
+		}
+		persister.Track("qtum", qtumRequestAnalytics)
+		persister.Track("eth", ethRequestAnalytics)
+		if err := persister.Restore(ctx); err != nil {
+			// the success rates start over, like without persistence
+			level.Warn(logger).Log("msg", "Failed to restore analytics", "error", err)
+		}
+		go persister.Run(ctx)
+		defer func() {
+			if err := persister.Save(context.Background()); err != nil {
+				level.Warn(logger).Log("msg", "Failed to persist analytics", "error", err)
+			}
+		}()
+	}
 
 	qtumClient, err := qtum.New(qtumJSONRPC, *qtumNetwork)
 	if err != nil {
 		return errors.Wrap(err, "Failed to setup QTUM chain")
 	}
+	if redisCache != nil {
+		// the chain is detected in the background, responses are cached once it is known
+		go func() {
+			redisCache.SetChainID(qtumClient.ChainId())
+		}()
+	}
 
-	agent := notifier.NewAgent(context.Background(), qtumClient, nil)
-	proxies := transformer.DefaultProxies(qtumClient, agent)
-	t, err := transformer.New(
-		qtumClient,
-		proxies,
-		transformer.SetDebug(*devMode),
-		transformer.SetLogger(logger),
-	)
+	if indexedLogs != nil {
+		go indexedLogs.Run(ctx, qtumClient, *logIndexFrom, logger)
+	}
+
+	if *devAccounts > 0 {
+		if err := setupDevAccounts(ctx, qtumClient, logger); err != nil {
+			return err
+		}
+	}
+
+	if *deployMulticall && *multicallAddress == "" {
+		if err := setupMulticall(ctx, qtumClient, logger); err != nil {
+			return err
+		}
+	}
+
+	var backbone notifier.Backbone
+	if *pubsubRedis != "" {
+		redisBackbone, err := pubsub.NewRedisBackbone(*pubsubRedis)
+		if err != nil {
+			return errors.Wrap(err, "Failed to setup notification backbone")
+		}
+		defer redisBackbone.Close()
+		backbone = redisBackbone
+	}
+
+	static, err := staticNames()
 	if err != nil {
-		return errors.Wrap(err, "transformer#New")
+		return err
+	}
+	namesResolver, err := nameResolver(qtumClient, static, *nameRegistry)
+	if err != nil {
+		return err
+	}
+
+	t, err := newTransformer(qtumClient, logger, backbone, namesResolver)
+	if err != nil {
+		return err
+	}
+
+	additionalNetworks, err := loadNetworks(ctx, logWriter, logger, contracts, static)
+	if err != nil {
+		return err
+	}
+
+	timeouts, err := parseMethodTimeouts(*methodTimeouts)
+	if err != nil {
+		return err
+	}
+	rateLimits, err := parseMethodRateLimits(*methodRateLimits)
+	if err != nil {
+		return err
+	}
+
+	var signer server.ResponseSigner
+	if *responseSigningKey != "" {
+		signer, err = server.NewResponseSigner(*responseSigningAlgorithm, *responseSigningKey)
+		if err != nil {
+			return errors.WithMessage(err, "--response-signing-key")
+		}
+		if publicKey, ok := signer.(interface{ PublicKey() string }); ok {
+			level.Info(logger).Log("msg", "Signing responses", "algorithm", signer.Algorithm(), "publicKey", publicKey.PublicKey())
+		}
 	}
-	agent.SetTransformer(t)
 
 	httpsKeyFile := getEmptyStringIfFileDoesntExist(*httpsKey, logger)
 	httpsCertFile := getEmptyStringIfFileDoesntExist(*httpsCert, logger)
@@ -180,15 +666,310 @@ func action(pc *kingpin.ParseContext) error {
 		server.SetSingleThreaded(*singleThreaded),
 		server.SetHttps(httpsKeyFile, httpsCertFile),
 		server.SetQtumAnalytics(qtumRequestAnalytics),
+		server.SetEthAnalytics(ethRequestAnalytics),
 		server.SetHealthCheckPercent(healthCheckPercent),
+		server.SetNetworks(additionalNetworks...),
+		server.SetTimings(*timings),
+		server.SetMetrics(*metrics),
+		server.SetQRC20API(*qrc20API),
+		server.SetQRC721API(*qrc721API),
+		server.SetTimeouts(*requestTimeout, timeouts),
+		server.SetRateLimits(server.RateLimits{
+			Global:         *rateLimit,
+			Methods:        rateLimits,
+			Burst:          *rateLimitBurst,
+			PerIP:          *rateLimitPerIP,
+			TrustedProxies: *rateLimitProxies,
+		}),
+		server.SetKeepAlive(*keepAliveInterval),
+		server.SetChainWatchdog(*blockInterval, *staleChainAfter, *staleChainReadiness),
+		server.SetWebsocketConfig(server.WebsocketConfig{
+			Compression:           *wsCompression,
+			CompressionLevel:      *wsCompressionLevel,
+			CompressionThreshold:  *wsCompressionThreshold,
+			MaxFrameSize:          *wsMaxFrameSize,
+			MaxMessageSize:        *wsMaxMessageSize,
+			MaxConcurrentRequests: *wsMaxConcurrentRequests,
+		}),
+		server.SetDifferentialReference(*diffReference, splitMethods(*diffMethods)),
+		server.SetReplicationToken(*replicationToken),
+		server.SetStandbyOf(*standbyOf, *replicationToken),
+		server.SetAdminToken(*adminToken),
+		server.SetTokenGracePeriod(*tokenGracePeriod),
+		server.SetShutdownGracePeriod(*shutdownGracePeriod),
+		server.SetResponseSigner(signer),
+		server.SetRequestSigningKey(*requestSigningKey, *requestSigningWindow),
+		server.SetTrustLocalRequests(*trustLocalRequests),
+		server.SetAlerting(alertingConfig(), server.AlertThresholds{
+			ErrorRate:           *alertErrorRate,
+			BlockLag:            *alertBlockLag,
+			SubscriptionBacklog: *alertSubscriptionBacklog,
+		}),
 	)
 	if err != nil {
 		return errors.Wrap(err, "server#New")
 	}
+	go resolver.Watch(ctx, adminTokenReference, *adminToken, *secretsRefresh, logger, rotateToken(s.RotateAdminToken, logger))
+	go resolver.Watch(ctx, replicationTokenReference, *replicationToken, *secretsRefresh, logger, rotateToken(s.RotateReplicationToken, logger))
+	if loadedConfig != nil {
+		go loadedConfig.reloadOnHangup(ctx, levels, s, logger)
+	}
 
 	return s.Start()
 }
 
+// setupDevAccounts adds the dev accounts to the ones eth_accounts returns, funds them and prints their keys
+func setupDevAccounts(ctx context.Context, qtumClient *qtum.Qtum, logger log.Logger) error {
+	if !qtumClient.CanGenerate() {
+		level.Warn(logger).Log("msg", "Dev accounts are only available on regtest, ignoring --dev-accounts")
+		return nil
+	}
+
+	balance, err := decimal.NewFromString(*devAccountsBalance)
+	if err != nil || !balance.IsPositive() {
+		return errors.Errorf("Invalid dev account balance %q", *devAccountsBalance)
+	}
+	accounts, err := qtum.DeriveDevAccounts(*devAccountsSeed, *devAccounts)
+	if err != nil {
+		return err
+	}
+
+	for i, wif := range accounts {
+		address := (&qtum.Account{WIF: wif}).ToHexAddress()
+		if _, ok := qtumClient.AccountLabels[address]; !ok {
+			qtumClient.AccountLabels[address] = fmt.Sprintf("dev-%d", i)
+		}
+	}
+	qtumClient.Keystore.Add(accounts...)
+
+	level.Info(logger).Log("msg", "Funding dev accounts", "accounts", len(accounts), "balance", balance)
+	if err := qtumClient.FundDevAccounts(ctx, accounts, balance.Shift(8).IntPart()); err != nil {
+		return errors.Wrap(err, "Failed to fund dev accounts")
+	}
+
+	fmt.Println("Available Accounts")
+	fmt.Println("==================")
+	for i, wif := range accounts {
+		acc := &qtum.Account{WIF: wif}
+		base58Address, err := acc.ToBase58Address(qtumClient.IsMain())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("(%d) 0x%s (%s) (%s QTUM)\n", i, acc.ToHexAddress(), base58Address, balance)
+	}
+	fmt.Println()
+	fmt.Println("Private Keys")
+	fmt.Println("==================")
+	for i, wif := range accounts {
+		fmt.Printf("(%d) %s\n", i, wif.String())
+	}
+	fmt.Println()
+	return nil
+}
+
+// setupMulticall deploys a multicall contract for eth_call to batch the calls of its aggregate3
+func setupMulticall(ctx context.Context, qtumClient *qtum.Qtum, logger log.Logger) error {
+	if qtumClient.Chain() == qtum.ChainMain {
+		level.Warn(logger).Log("msg", "Deploying a multicall contract is only available on regtest and testnet, ignoring --deploy-multicall")
+		return nil
+	}
+	address, err := transformer.DeployMulticall(ctx, qtumClient)
+	if err != nil {
+		return errors.Wrap(err, "Failed to deploy multicall contract")
+	}
+	qtumClient.SetFlag(qtum.FLAG_MULTICALL_ADDRESS, address)
+	level.Info(logger).Log("msg", "Deployed multicall contract, pass its address to --multicall-address to reuse it", "address", "0x"+address)
+	return nil
+}
+
+// staticNames are the names of --names, shared by every network, nil when it isn't set
+func staticNames() (names.Static, error) {
+	if *namesFile == nil {
+		return nil, nil
+	}
+	var registry map[string]string
+	err := json.NewDecoder(*namesFile).Decode(&registry)
+	(*namesFile).Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse --names")
+	}
+	static, err := names.NewStatic(registry)
+	if err != nil {
+		return nil, errors.WithMessage(err, "--names")
+	}
+	return static, nil
+}
+
+// nameResolver resolves the names of static then those of the registry contract at registryAddress on the chain of
+// qtumClient, nil when neither is set
+func nameResolver(qtumClient *qtum.Qtum, static names.Static, registryAddress string) (names.Resolver, error) {
+	var resolvers names.Resolvers
+	if static != nil {
+		resolvers = append(resolvers, static)
+	}
+	if registryAddress != "" {
+		registry, err := names.NewRegistry(qtumClient, registryAddress)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, registry)
+	}
+	if len(resolvers) == 0 {
+		return nil, nil
+	}
+	return resolvers, nil
+}
+
+// newTransformer sets up the proxies of a network, backbone is nil unless its notifications are shared and resolver
+// unless names are accepted instead of addresses
+func newTransformer(qtumClient *qtum.Qtum, logger log.Logger, backbone notifier.Backbone, resolver names.Resolver) (*transformer.Transformer, error) {
+	agent := notifier.NewAgent(context.Background(), qtumClient, nil)
+	err := agent.SetNotificationLimits(notifier.NotificationLimits{
+		Rate:      *notificationRate,
+		Burst:     *notificationBurst,
+		BatchLogs: *batchLogs,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid notification limits")
+	}
+	if err := agent.SetPendingTransactionsInterval(*pendingTransactionsInterval); err != nil {
+		return nil, err
+	}
+	proxies := transformer.DefaultProxies(qtumClient, agent)
+	t, err := transformer.New(
+		qtumClient,
+		proxies,
+		transformer.SetDebug(*devMode),
+		transformer.SetLogger(logger),
+		transformer.SetNameResolver(resolver),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "transformer#New")
+	}
+	agent.SetTransformer(t)
+	if backbone != nil {
+		agent.SetBackbone(backbone)
+	}
+
+	return t, nil
+}
+
+// loadNetworks sets up the additional networks served next to the default one
+// the chain of each network is taken from --network-chain or detected from its qtumd, static names are resolved on each of them
+func loadNetworks(ctx context.Context, logWriter io.Writer, logger log.Logger, contracts map[string]map[string]string, static names.Static) ([]*server.Network, error) {
+	var result []*server.Network
+
+	for name, rpcURL := range *networks {
+		networkLogger := log.With(logger, "network", name)
+
+		var accounts qtum.Accounts
+		var accountLabels map[string]string
+		if accountsPath, ok := (*networkAccounts)[name]; ok {
+			file, err := os.Open(accountsPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to open accounts file for network %s", name)
+			}
+			accounts, accountLabels = loadAccounts(file, networkLogger)
+			file.Close()
+		}
+
+		chain, err := loadNetworkChain(ctx, name, rpcURL)
+		if err != nil {
+			return nil, err
+		}
+
+		qtumJSONRPC, err := qtum.NewClient(
+			chain == qtum.ChainMain,
+			rpcURL,
+			qtum.SetDebug(*devMode),
+			qtum.SetLogWriter(logWriter),
+			qtum.SetLogger(networkLogger),
+			qtum.SetAccounts(accounts),
+			qtum.SetAccountLabels(accountLabels),
+			qtum.SetKeystore((*networkKeystore)[name], *keystoreReload, *keystoreUnlock),
+			qtum.SetAllowInsecureUnlock(*allowInsecureUnlock),
+			qtum.SetIgnoreUnknownTransactions(*ignoreUnknownTransactions),
+			qtum.SetDisableSnippingQtumRpcOutput(*disableSnipping),
+			qtum.SetHideQtumdLogs(*hideQtumdLogs),
+			qtum.SetMatureBlockHeight(matureBlockHeight),
+			qtum.SetLatestConfirmations(*latestConfirmations),
+			qtum.SetBalanceMode(*balanceMode),
+			qtum.SetBlockFields(*blockGas, *blockDifficulty, *blockTimestamp),
+			qtum.SetResponseExtensions(*responseExtensions),
+			qtum.SetMempoolPrecheck(*mempoolPrecheck),
+			qtum.SetSimulateBeforeSend(*simulateBeforeSend),
+			qtum.SetLocalEVM(*localEVM),
+			qtum.SetNetworkContracts(contracts),
+			qtum.SetWalletAccounts(*walletAccounts),
+			qtum.SetTraceConcurrency(*traceConcurrency),
+			qtum.SetLogsBlockRange(*logsBlockRange),
+			qtum.SetLogsMaxResults(*logsMaxResults),
+			qtum.SetTxLookupBlocks(*txLookupBlocks),
+			qtum.SetContext(ctx),
+			qtum.SetDNSRefresh(*dnsRefresh),
+			qtum.SetDialConfig(dialConfig()),
+			qtum.SetUserAgent(*qtumRPCUserAgent),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to setup QTUM client for network %s", name)
+		}
+
+		qtumClient, err := qtum.New(qtumJSONRPC, chain)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to setup QTUM chain for network %s", name)
+		}
+
+		resolver, err := nameResolver(qtumClient, static, (*networkRegistry)[name])
+		if err != nil {
+			return nil, errors.WithMessagef(err, "network %s", name)
+		}
+		t, err := newTransformer(qtumClient, networkLogger, nil, resolver)
+		if err != nil {
+			return nil, err
+		}
+
+		network := &server.Network{
+			Name:        name,
+			QtumClient:  qtumClient,
+			Transformer: t,
+		}
+		if host, ok := (*networkHosts)[name]; ok {
+			network.Hosts = []string{host}
+		}
+
+		result = append(result, network)
+	}
+
+	return result, nil
+}
+
+// loadNetworkChain returns the chain of an additional network, which decides its base58 address and key prefixes,
+// so it has to be known before its client is set up
+func loadNetworkChain(ctx context.Context, name string, rpcURL string) (string, error) {
+	if chain, ok := (*networkChain)[name]; ok {
+		if chain != qtum.ChainMain && chain != qtum.ChainTest && chain != qtum.ChainRegTest {
+			return "", errors.Errorf("Invalid chain %q for network %s, use main, test or regtest", chain, name)
+		}
+		return chain, nil
+	}
+
+	client, err := qtum.NewClient(
+		false,
+		rpcURL,
+		qtum.SetContext(ctx),
+		qtum.SetDialConfig(dialConfig()),
+		qtum.SetUserAgent(*qtumRPCUserAgent),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to setup QTUM client for network %s", name)
+	}
+	info, err := (&qtum.Method{Client: client}).GetBlockChainInfo(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to detect the chain of network %s, set it with --network-chain", name)
+	}
+	return strings.ToLower(info.Chain), nil
+}
+
 func getEmptyStringIfFileDoesntExist(file string, l log.Logger) string {
 	_, err := os.Stat(file)
 	if os.IsNotExist(err) {
@@ -198,8 +979,57 @@ func getEmptyStringIfFileDoesntExist(file string, l log.Logger) string {
 	return file
 }
 
+func splitMethods(methods string) []string {
+	var result []string
+	for _, method := range strings.Split(methods, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			result = append(result, method)
+		}
+	}
+	return result
+}
+
+func dialConfig() qtum.DialConfig {
+	return qtum.DialConfig{
+		Family:        *dialFamily,
+		FallbackDelay: *dialFallbackDelay,
+		IPv4Timeout:   *dialTimeoutIPv4,
+		IPv6Timeout:   *dialTimeoutIPv6,
+	}
+}
+
+func parseMethodTimeouts(methods map[string]string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(methods))
+	for method, value := range methods {
+		timeout, err := server.ParseTimeout(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "--method-timeout %s", method)
+		}
+		timeouts[method] = timeout
+	}
+	return timeouts, nil
+}
+
+func parseMethodRateLimits(methods map[string]string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(methods))
+	for method, value := range methods {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "--method-rate-limit %s", method)
+		}
+		rates[method] = rate
+	}
+	return rates, nil
+}
+
+// loadedConfig is the --config file, nil without one
+var loadedConfig *configFile
+
 func Run() {
 	app.Version(params.VersionWithGitSha)
+	var err error
+	loadedConfig, err = loadConfig(os.Args[1:])
+	app.FatalIfError(err, "--config")
 	kingpin.MustParse(app.Parse(os.Args[1:]))
 }
 
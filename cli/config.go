@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/config"
+	"github.com/qtumproject/janus/pkg/server"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// configFile is the --config file the flags not set on the command line or in the environment default to
+type configFile struct {
+	path string
+	// the flag values of the file at startup
+	values map[string][]string
+	// the defaults of the flags before the file replaced them
+	defaults map[string][]string
+	// the flags set on the command line or in the environment, the file doesn't change them
+	overridden map[string]bool
+}
+
+// loadConfig makes the settings of the --config file the defaults of their flags, nil without a config file
+func loadConfig(args []string) (*configFile, error) {
+	path := os.Getenv("CONFIG")
+	overridden := map[string]bool{}
+	// parse errors are reported by parsing the arguments for good
+	if context, _ := app.ParseContext(args); context != nil {
+		for _, element := range context.Elements {
+			flag, ok := element.Clause.(*kingpin.FlagClause)
+			if !ok {
+				continue
+			}
+			name := flag.Model().Name
+			overridden[name] = true
+			if name == "config" && element.Value != nil {
+				path = *element.Value
+			}
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &configFile{
+		path:       path,
+		values:     cfg.Flags(),
+		defaults:   map[string][]string{},
+		overridden: overridden,
+	}
+	for name, values := range file.values {
+		flag := app.GetFlag(name)
+		if flag == nil {
+			return nil, errors.Errorf("config file setting for unknown flag --%s", name)
+		}
+		if flag.HasEnvarValue() {
+			file.overridden[name] = true
+		}
+		file.defaults[name] = flag.Model().Default
+		flag.Default(values...)
+	}
+	return file, nil
+}
+
+// setting is the value of a reloadable flag in a reloaded file, falling back on the default of the flag when the file
+// leaves it out. Flags set on the command line or in the environment aren't changed
+func (f *configFile) setting(values map[string][]string, name string) ([]string, bool) {
+	if f.overridden[name] {
+		return nil, false
+	}
+	if value, ok := values[name]; ok {
+		return value, true
+	}
+	if value, ok := f.defaults[name]; ok {
+		return value, true
+	}
+	return app.GetFlag(name).Model().Default, true
+}
+
+// reload reads the config file again and applies its log level and rate limits, the other settings changed are
+// logged as taking a restart
+func (f *configFile) reload(levels *config.LevelLogger, s *server.Server, logger log.Logger) error {
+	cfg, err := config.Load(f.path)
+	if err != nil {
+		return err
+	}
+	values := cfg.Flags()
+
+	var restart []string
+	for _, name := range config.Changes(f.values, values) {
+		if !config.IsReloadable(name) && !f.overridden[name] {
+			restart = append(restart, "--"+name)
+		}
+	}
+	if len(restart) > 0 {
+		level.Warn(logger).Log("msg", "Ignoring changed settings of the config file until restarted", "flags", strings.Join(restart, " "))
+	}
+
+	logLevel := *logLevel
+	if setting, ok := f.setting(values, "log-level"); ok {
+		logLevel = first(setting)
+	}
+	limits, err := f.rateLimits(values)
+	if err != nil {
+		return err
+	}
+	if err := levels.SetLevel(effectiveLogLevel(logLevel)); err != nil {
+		return err
+	}
+	if err := s.UpdateRateLimits(limits); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "Reloaded config file", "file", f.path, "logLevel", effectiveLogLevel(logLevel))
+	return nil
+}
+
+// rateLimits are the rate limits of a reloaded file
+func (f *configFile) rateLimits(values map[string][]string) (server.RateLimits, error) {
+	methods := *methodRateLimits
+	if setting, ok := f.setting(values, "method-rate-limit"); ok {
+		methods = map[string]string{}
+		for _, value := range setting {
+			parts := strings.SplitN(value, "=", 2)
+			if len(parts) != 2 {
+				return server.RateLimits{}, errors.Errorf("invalid method rate limit %q, expected method=rate", value)
+			}
+			methods[parts[0]] = parts[1]
+		}
+	}
+	rates, err := parseMethodRateLimits(methods)
+	if err != nil {
+		return server.RateLimits{}, err
+	}
+	limits := server.RateLimits{
+		Global:         *rateLimit,
+		Methods:        rates,
+		Burst:          *rateLimitBurst,
+		PerIP:          *rateLimitPerIP,
+		TrustedProxies: *rateLimitProxies,
+	}
+	if setting, ok := f.setting(values, "rate-limit"); ok {
+		if limits.Global, err = strconv.ParseFloat(first(setting), 64); err != nil {
+			return server.RateLimits{}, errors.Wrap(err, "--rate-limit")
+		}
+	}
+	if setting, ok := f.setting(values, "rate-limit-burst"); ok {
+		if limits.Burst, err = strconv.Atoi(first(setting)); err != nil {
+			return server.RateLimits{}, errors.Wrap(err, "--rate-limit-burst")
+		}
+	}
+	if setting, ok := f.setting(values, "rate-limit-per-ip"); ok {
+		if limits.PerIP, err = strconv.ParseBool(first(setting)); err != nil {
+			return server.RateLimits{}, errors.Wrap(err, "--rate-limit-per-ip")
+		}
+	}
+	if setting, ok := f.setting(values, "rate-limit-trusted-proxy"); ok {
+		limits.TrustedProxies = setting
+	}
+	return limits, nil
+}
+
+// reloadOnHangup reloads the config file on SIGHUP until ctx is done
+func (f *configFile) reloadOnHangup(ctx context.Context, levels *config.LevelLogger, s *server.Server, logger log.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-signals:
+			if err := f.reload(levels, s, logger); err != nil {
+				level.Error(logger).Log("msg", "Failed to reload config file, keeping the previous settings", "file", f.path, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// effectiveLogLevel is the level of --log-level, warn by default or debug in developer mode
+func effectiveLogLevel(logLevel string) string {
+	if logLevel != "" {
+		return logLevel
+	}
+	if *devMode {
+		return "debug"
+	}
+	return "warn"
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
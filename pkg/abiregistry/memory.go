@@ -0,0 +1,61 @@
+package abiregistry
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRegistry keeps ABIs in memory, for tests and embedders without a database
+type MemoryRegistry struct {
+	mutex   sync.Mutex
+	entries map[string]Entry
+}
+
+var _ Registry = (*MemoryRegistry)(nil)
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{entries: make(map[string]Entry)}
+}
+
+func (r *MemoryRegistry) Register(ctx context.Context, address string, name string, abi json.RawMessage) (*Entry, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	address = strings.ToLower(address)
+	now := time.Now().UTC()
+	entry, ok := r.entries[address]
+	if !ok {
+		entry = Entry{Address: address, RegisteredAt: now}
+	}
+	entry.Name = name
+	entry.ABI = abi
+	entry.UpdatedAt = now
+	r.entries[address] = entry
+	return &entry, nil
+}
+
+func (r *MemoryRegistry) Get(ctx context.Context, address string) (*Entry, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.entries[strings.ToLower(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+func (r *MemoryRegistry) Remove(ctx context.Context, address string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	address = strings.ToLower(address)
+	if _, ok := r.entries[address]; !ok {
+		return ErrNotFound
+	}
+	delete(r.entries, address)
+	return nil
+}
@@ -0,0 +1,29 @@
+package abiregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("no ABI registered for the address")
+
+// Entry is the ABI of a contract
+type Entry struct {
+	// hex address without 0x, lowercase
+	Address string `json:"address"`
+	// optional, such as the contract's name
+	Name         string          `json:"name"`
+	ABI          json.RawMessage `json:"abi"`
+	RegisteredAt time.Time       `json:"registeredAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
+
+// Registry keeps contract ABIs by address, so features decoding calldata and logs can find them across restarts
+type Registry interface {
+	// Register adds the ABI of a contract, replacing the one registered before
+	Register(ctx context.Context, address string, name string, abi json.RawMessage) (*Entry, error)
+	Get(ctx context.Context, address string) (*Entry, error)
+	Remove(ctx context.Context, address string) error
+}
@@ -0,0 +1,120 @@
+package abiregistry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS janus_contract_abis (
+	address TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	abi TEXT NOT NULL,
+	registered_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+)`
+
+// SQLRegistry keeps ABIs in the postgres database Janus is configured with
+type SQLRegistry struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ Registry = (*SQLRegistry)(nil)
+
+// Open sets up the registry, the table is created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLRegistry, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open ABI registry database")
+	}
+	r := &SQLRegistry{db: db}
+	// failing here is fine, the next use tries again
+	r.migrate(ctx)
+	return r, nil
+}
+
+func (r *SQLRegistry) migrate(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.migrated {
+		return nil
+	}
+	if _, err := r.db.ExecContext(ctx, createTable); err != nil {
+		return errors.Wrap(err, "couldn't create ABI registry table")
+	}
+	r.migrated = true
+	return nil
+}
+
+func (r *SQLRegistry) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLRegistry) Register(ctx context.Context, address string, name string, abi json.RawMessage) (*Entry, error) {
+	if err := r.migrate(ctx); err != nil {
+		return nil, err
+	}
+	entry, err := scanEntry(r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO janus_contract_abis (address, name, abi, registered_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (address) DO UPDATE SET
+			name = EXCLUDED.name,
+			abi = EXCLUDED.abi,
+			updated_at = EXCLUDED.updated_at
+		RETURNING address, name, abi, registered_at, updated_at`,
+		strings.ToLower(address), name, string(abi), time.Now().UTC(),
+	))
+	return entry, errors.Wrap(err, "couldn't register ABI")
+}
+
+func (r *SQLRegistry) Get(ctx context.Context, address string) (*Entry, error) {
+	if err := r.migrate(ctx); err != nil {
+		return nil, err
+	}
+	entry, err := scanEntry(r.db.QueryRowContext(
+		ctx,
+		"SELECT address, name, abi, registered_at, updated_at FROM janus_contract_abis WHERE address = $1",
+		strings.ToLower(address),
+	))
+	if errors.Cause(err) == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return entry, err
+}
+
+func (r *SQLRegistry) Remove(ctx context.Context, address string) error {
+	if err := r.migrate(ctx); err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, "DELETE FROM janus_contract_abis WHERE address = $1", strings.ToLower(address))
+	if err != nil {
+		return errors.Wrap(err, "couldn't remove ABI")
+	}
+	if removed, err := result.RowsAffected(); err == nil && removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanEntry(row *sql.Row) (*Entry, error) {
+	var (
+		entry Entry
+		abi   string
+	)
+	if err := row.Scan(&entry.Address, &entry.Name, &abi, &entry.RegisteredAt, &entry.UpdatedAt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	entry.ABI = json.RawMessage(abi)
+	return &entry, nil
+}
@@ -0,0 +1,156 @@
+// Package alerting fires webhooks when a condition stays over its threshold for a sustained window, and again once
+// it recovers
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// DefaultInterval is how often rules are evaluated unless configured otherwise
+const DefaultInterval = 15 * time.Second
+
+// Rule is a condition alerted on while its value exceeds the threshold
+type Rule struct {
+	Name        string
+	Description string
+	Threshold   float64
+	// measures the condition, rules failing to are skipped until they measure again
+	Value func(ctx context.Context) (float64, error)
+}
+
+// Alert is sent when a rule starts or stops firing
+type Alert struct {
+	Rule      string    `json:"alert"`
+	Status    string    `json:"status"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Since     time.Time `json:"since"`
+	Source    string    `json:"source"`
+	Summary   string    `json:"-"`
+}
+
+// Sender delivers alerts
+type Sender interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+type Config struct {
+	Senders []Sender
+	// how long a rule has to be breached before it fires
+	Window time.Duration
+	// how often rules are evaluated, DefaultInterval when zero
+	Interval time.Duration
+	// names the Janus instance in alerts
+	Source string
+}
+
+type ruleState struct {
+	breachedSince time.Time
+	firing        bool
+}
+
+// Alerter evaluates rules periodically
+type Alerter struct {
+	config Config
+	rules  []Rule
+	logger func() log.Logger
+	states map[string]*ruleState
+}
+
+// New sets up an alerter, logger is called whenever it logs so the logger can be configured later
+func New(config Config, rules []Rule, logger func() log.Logger) (*Alerter, error) {
+	if config.Window < 0 {
+		return nil, errors.New("alert window can't be negative")
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.Interval < 0 {
+		return nil, errors.New("alert interval can't be negative")
+	}
+	if config.Source == "" {
+		config.Source = "janus"
+	}
+	states := make(map[string]*ruleState, len(rules))
+	for _, rule := range rules {
+		if _, ok := states[rule.Name]; ok {
+			return nil, errors.Errorf("duplicate alert rule %s", rule.Name)
+		}
+		states[rule.Name] = &ruleState{}
+	}
+	return &Alerter{config: config, rules: rules, logger: logger, states: states}, nil
+}
+
+// Run evaluates the rules every interval until ctx is done
+func (a *Alerter) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.evaluate(ctx, now)
+		}
+	}
+}
+
+func (a *Alerter) evaluate(ctx context.Context, now time.Time) {
+	for _, rule := range a.rules {
+		value, err := rule.Value(ctx)
+		if err != nil {
+			level.Debug(a.logger()).Log("msg", "Couldn't evaluate alert rule", "alert", rule.Name, "error", err)
+			continue
+		}
+		state := a.states[rule.Name]
+		alert := Alert{
+			Rule:      rule.Name,
+			Value:     value,
+			Threshold: rule.Threshold,
+			Source:    a.config.Source,
+		}
+		if value > rule.Threshold {
+			if state.breachedSince.IsZero() {
+				state.breachedSince = now
+			}
+			if state.firing || now.Sub(state.breachedSince) < a.config.Window {
+				continue
+			}
+			state.firing = true
+			alert.Status = StatusFiring
+			alert.Since = state.breachedSince
+			alert.Summary = fmt.Sprintf("%s: %s is %g, over %g since %s", a.config.Source, rule.Description, value, rule.Threshold, state.breachedSince.UTC().Format(time.RFC3339))
+		} else {
+			firing := state.firing
+			since := state.breachedSince
+			*state = ruleState{}
+			if !firing {
+				continue
+			}
+			alert.Status = StatusResolved
+			alert.Since = since
+			alert.Summary = fmt.Sprintf("%s: %s is back to %g, at most %g", a.config.Source, rule.Description, value, rule.Threshold)
+		}
+		level.Warn(a.logger()).Log("msg", "Alert "+alert.Status, "alert", alert.Rule, "value", value, "threshold", rule.Threshold)
+		a.send(ctx, alert)
+	}
+}
+
+func (a *Alerter) send(ctx context.Context, alert Alert) {
+	for _, sender := range a.config.Senders {
+		if err := sender.Send(ctx, alert); err != nil {
+			level.Error(a.logger()).Log("msg", "Failed to send alert", "alert", alert.Rule, "error", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestAlerterSustainedWindow(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	value := 0.0
+	alerter, err := New(
+		Config{Senders: []Sender{&Webhook{URL: server.URL}}, Window: time.Minute, Source: "test"},
+		[]Rule{{
+			Name:        "error-rate",
+			Description: "error rate",
+			Threshold:   10,
+			Value: func(ctx context.Context) (float64, error) {
+				return value, nil
+			},
+		}},
+		log.NewNopLogger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	value = 20
+	alerter.evaluate(ctx, start)
+	alerter.evaluate(ctx, start.Add(30*time.Second))
+	if len(received) != 0 {
+		t.Fatalf("expected no alert within the window, got %v", received)
+	}
+	alerter.evaluate(ctx, start.Add(time.Minute))
+	alerter.evaluate(ctx, start.Add(2*time.Minute))
+	if len(received) != 1 || received[0]["status"] != StatusFiring || received[0]["alert"] != "error-rate" || received[0]["text"] == "" {
+		t.Fatalf("expected one firing alert, got %v", received)
+	}
+	value = 5
+	alerter.evaluate(ctx, start.Add(3*time.Minute))
+	alerter.evaluate(ctx, start.Add(4*time.Minute))
+	if len(received) != 2 || received[1]["status"] != StatusResolved {
+		t.Fatalf("expected the alert to be resolved once, got %v", received)
+	}
+}
+
+func TestPagerDutyEvents(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	pagerDuty := &PagerDuty{RoutingKey: "key", URL: server.URL}
+	ctx := context.Background()
+	if err := pagerDuty.Send(ctx, Alert{Rule: "block-lag", Status: StatusFiring, Source: "test", Summary: "lagging"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pagerDuty.Send(ctx, Alert{Rule: "block-lag", Status: StatusResolved, Source: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].EventAction != "trigger" || events[0].Payload.Summary != "lagging" || events[1].EventAction != "resolve" || events[0].DedupKey != events[1].DedupKey {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
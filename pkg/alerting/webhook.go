@@ -0,0 +1,108 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Webhook posts alerts as JSON with a text field, which Slack incoming webhooks and most chat integrations display,
+// along with the alert's fields
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+var _ Sender = (*Webhook)(nil)
+
+type webhookPayload struct {
+	Text string `json:"text"`
+	Alert
+}
+
+func (w *Webhook) Send(ctx context.Context, alert Alert) error {
+	return post(ctx, w.Client, w.URL, webhookPayload{Text: alert.Summary, Alert: alert})
+}
+
+// PagerDuty triggers and resolves PagerDuty incidents through the Events API v2
+type PagerDuty struct {
+	RoutingKey string
+	// PagerDutyEventsURL when empty
+	URL    string
+	Client *http.Client
+}
+
+var _ Sender = (*PagerDuty)(nil)
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string    `json:"summary"`
+	Source        string    `json:"source"`
+	Severity      string    `json:"severity"`
+	Timestamp     time.Time `json:"timestamp"`
+	CustomDetails Alert     `json:"custom_details"`
+}
+
+func (p *PagerDuty) Send(ctx context.Context, alert Alert) error {
+	url := p.URL
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+	event := pagerDutyEvent{
+		RoutingKey: p.RoutingKey,
+		// resolving the same key closes the incident the trigger opened
+		DedupKey: alert.Source + "/" + alert.Rule,
+	}
+	if alert.Status == StatusFiring {
+		event.EventAction = "trigger"
+		event.Payload = &pagerDutyPayload{
+			Summary:       alert.Summary,
+			Source:        alert.Source,
+			Severity:      "critical",
+			Timestamp:     alert.Since,
+			CustomDetails: alert,
+		}
+	} else {
+		event.EventAction = "resolve"
+	}
+	return post(ctx, p.Client, url, event)
+}
+
+func post(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	if client == nil {
+		client = defaultClient
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "couldn't send alert")
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("alert webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -50,6 +50,11 @@ func (a *Analytics) bump(success bool) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
+	a.push(success)
+}
+
+// push adds a request to the window of the last requests
+func (a *Analytics) push(success bool) {
 	total := a.success + a.failures
 
 	if success {
@@ -78,3 +83,45 @@ func (a *Analytics) bump(success bool) {
 	a.lastRequests[a.lastRequest] = success
 	a.lastRequest = (a.lastRequest + 1) % a.totalRequests
 }
+
+// Snapshot is the window of the last requests, to carry the success rate over a restart
+type Snapshot struct {
+	// outcome of the last requests, oldest first
+	Window []bool `json:"window"`
+}
+
+func (a *Analytics) Snapshot() Snapshot {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	count := a.success + a.failures
+	window := make([]bool, 0, count)
+	// until the window is full it starts at index 0, afterwards at the oldest request which is overwritten next
+	start := 0
+	if count == a.totalRequests {
+		start = a.lastRequest
+	}
+	for i := 0; i < count; i++ {
+		window = append(window, a.lastRequests[(start+i)%a.totalRequests])
+	}
+
+	return Snapshot{Window: window}
+}
+
+// Restore replaces the counters with a snapshot, a window larger than this one's keeps its latest requests
+func (a *Analytics) Restore(snapshot Snapshot) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.success = 0
+	a.failures = 0
+	a.lastRequest = 0
+	a.lastRequests = make([]bool, a.totalRequests)
+	window := snapshot.Window
+	if len(window) > a.totalRequests {
+		window = window[len(window)-a.totalRequests:]
+	}
+	for _, success := range window {
+		a.push(success)
+	}
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	a := NewAnalytics(4)
+	for _, success := range []bool{false, true, true, false, true, true} {
+		if success {
+			a.Success()
+		} else {
+			a.Failure()
+		}
+	}
+
+	snapshot := a.Snapshot()
+	if want := []bool{true, false, true, true}; !reflect.DeepEqual(snapshot.Window, want) {
+		t.Fatalf("expected window %v, got %v", want, snapshot.Window)
+	}
+
+	restored := NewAnalytics(4)
+	restored.Restore(snapshot)
+	if rate := restored.GetSuccessRate(); rate != 0.75 {
+		t.Errorf("expected the restored success rate to be 0.75, got %f", rate)
+	}
+	restored.Failure()
+	if rate := restored.GetSuccessRate(); rate != 0.5 {
+		t.Errorf("expected the oldest restored request to be pushed off, got %f", rate)
+	}
+
+	smaller := NewAnalytics(2)
+	smaller.Restore(snapshot)
+	if rate := smaller.GetSuccessRate(); rate != 1 {
+		t.Errorf("expected the latest requests to be kept, got %f", rate)
+	}
+}
+
+func TestPersisterFileStore(t *testing.T) {
+	ctx := context.Background()
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "analytics.json")}
+
+	persister, err := NewPersister(store, time.Minute, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing was saved yet
+	if err := persister.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before := NewAnalytics(2)
+	before.Success()
+	before.Failure()
+	persister.Track("qtum", before)
+	if err := persister.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	persister, err = NewPersister(store, time.Minute, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := NewAnalytics(2)
+	persister.Track("qtum", after)
+	if err := persister.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rate := after.GetSuccessRate(); rate != 0.5 {
+		t.Errorf("expected the success rate to survive a restart, got %f", rate)
+	}
+}
@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FileStore keeps the snapshots in a JSON state file
+type FileStore struct {
+	Path string
+}
+
+var _ Store = (*FileStore)(nil)
+
+func (f *FileStore) Load(ctx context.Context) (map[string]Snapshot, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read analytics state file")
+	}
+	var snapshots map[string]Snapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse analytics state file")
+	}
+	return snapshots, nil
+}
+
+// Save replaces the state file at once, a crash while saving leaves the previous state
+func (f *FileStore) Save(ctx context.Context, snapshots map[string]Snapshot) error {
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "couldn't write analytics state file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "couldn't write analytics state file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "couldn't write analytics state file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), f.Path), "couldn't write analytics state file")
+}
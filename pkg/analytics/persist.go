@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// Store keeps snapshots of named analytics
+type Store interface {
+	// Load returns the saved snapshots by name, none when nothing was saved yet
+	Load(ctx context.Context) (map[string]Snapshot, error)
+	Save(ctx context.Context, snapshots map[string]Snapshot) error
+}
+
+// Persister saves analytics to a store periodically, so success rates and the health checks derived from them
+// survive restarts instead of starting over
+type Persister struct {
+	store    Store
+	interval time.Duration
+	logger   log.Logger
+
+	mutex     sync.Mutex
+	analytics map[string]*Analytics
+}
+
+func NewPersister(store Store, interval time.Duration, logger log.Logger) (*Persister, error) {
+	if interval <= 0 {
+		return nil, errors.New("analytics persist interval must be positive")
+	}
+	return &Persister{
+		store:     store,
+		interval:  interval,
+		logger:    logger,
+		analytics: make(map[string]*Analytics),
+	}, nil
+}
+
+// Track persists analytics under name
+func (p *Persister) Track(name string, analytics *Analytics) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.analytics[name] = analytics
+}
+
+// Restore loads the saved snapshots into the tracked analytics
+func (p *Persister) Restore(ctx context.Context) error {
+	snapshots, err := p.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for name, analytics := range p.analytics {
+		if snapshot, ok := snapshots[name]; ok {
+			analytics.Restore(snapshot)
+		}
+	}
+	return nil
+}
+
+// Save stores snapshots of the tracked analytics
+func (p *Persister) Save(ctx context.Context) error {
+	p.mutex.Lock()
+	snapshots := make(map[string]Snapshot, len(p.analytics))
+	for name, analytics := range p.analytics {
+		snapshots[name] = analytics.Snapshot()
+	}
+	p.mutex.Unlock()
+	return p.store.Save(ctx, snapshots)
+}
+
+// Run saves the analytics every interval until ctx is done
+func (p *Persister) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Save(ctx); err != nil {
+				level.Warn(p.logger).Log("msg", "Failed to persist analytics", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS janus_analytics (
+	name TEXT PRIMARY KEY,
+	snapshot JSONB NOT NULL,
+	saved_at TIMESTAMPTZ NOT NULL
+)`
+
+// SQLStore keeps the snapshots in the postgres database Janus is configured with
+type SQLStore struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// Open sets up the store, the table is created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open analytics database")
+	}
+	s := &SQLStore{db: db}
+	// failing here is fine, the next use tries again
+	s.migrate(ctx)
+	return s, nil
+}
+
+func (s *SQLStore) migrate(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.migrated {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, createTable); err != nil {
+		return errors.Wrap(err, "couldn't create analytics table")
+	}
+	s.migrated = true
+	return nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Load(ctx context.Context) (map[string]Snapshot, error) {
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT name, snapshot FROM janus_analytics`)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load analytics")
+	}
+	defer rows.Close()
+
+	snapshots := make(map[string]Snapshot)
+	for rows.Next() {
+		var name string
+		var data []byte
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, errors.Wrap(err, "couldn't load analytics")
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse %s analytics", name)
+		}
+		snapshots[name] = snapshot
+	}
+	return snapshots, errors.Wrap(rows.Err(), "couldn't load analytics")
+}
+
+func (s *SQLStore) Save(ctx context.Context, snapshots map[string]Snapshot) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "couldn't save analytics")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	for name, snapshot := range snapshots {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO janus_analytics (name, snapshot, saved_at) VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO UPDATE SET snapshot = EXCLUDED.snapshot, saved_at = EXCLUDED.saved_at`,
+			name, data, now,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't save %s analytics", name)
+		}
+	}
+	return errors.Wrap(tx.Commit(), "couldn't save analytics")
+}
@@ -93,6 +93,13 @@ func (bh *BlockHash) GetQtumBlockHashContext(ctx context.Context, ethereumBlockH
 	}
 }
 
+// Connected reports whether Start connected the database and it hasn't shut down since
+func (bh *BlockHash) Connected() bool {
+	bh.mutex.RLock()
+	defer bh.mutex.RUnlock()
+	return bh.qtumDB != nil
+}
+
 func (bh *BlockHash) Start(databaseConfig *DatabaseConfig, chainIdChan <-chan int) error {
 	numWorkers := runtime.NumCPU() * 2
 	bh.chainIdMutex.Lock()
@@ -0,0 +1,171 @@
+// Package cache keeps cached qtumd responses in Redis, so Janus instances behind a load balancer share them
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "janus:cache:"
+
+// instances tell each other about flushed methods on this channel, as "chainid:method generation"
+const flushChannel = keyPrefix + "flush"
+
+// RedisCache is a qtum.CacheBackend on Redis. Flushing a method moves it to a new generation, which is part of the
+// keys of its responses, so the responses of the previous one are left to expire instead of being looked for. Keys start
+// with the chain id, so instances of different networks can share a Redis server, and nothing is cached until it is set
+type RedisCache struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+
+	mutex sync.RWMutex
+	// generations of the methods known to this instance by chain id and method, kept up to date by flushChannel
+	generations map[string]int64
+	// chain id of the qtumd responses are cached for, empty until it is known
+	chain string
+}
+
+var _ qtum.CacheBackend = (*RedisCache)(nil)
+
+// NewRedisCache connects to the Redis server at url, e.g. redis://localhost:6379/0
+func NewRedisCache(ctx context.Context, url string) (*RedisCache, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid redis url")
+	}
+	client := redis.NewClient(options)
+	pubsub := client.Subscribe(ctx, flushChannel)
+	// the subscription has to be in place before flushes can be missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		client.Close()
+		return nil, errors.Wrap(err, "couldn't subscribe to cache flushes")
+	}
+	c := &RedisCache{client: client, pubsub: pubsub, generations: make(map[string]int64)}
+	go c.listen(pubsub.Channel())
+	return c, nil
+}
+
+func (c *RedisCache) Close() error {
+	c.pubsub.Close()
+	return c.client.Close()
+}
+
+// Ping checks that the Redis server answers
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// SetChainID scopes the cached responses to the chain with chainID
+func (c *RedisCache) SetChainID(chainID int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.chain = strconv.Itoa(chainID)
+}
+
+// scope is method prefixed with the chain id, false while the chain isn't known
+func (c *RedisCache) scope(method string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.chain == "" {
+		return "", false
+	}
+	return c.chain + ":" + method, true
+}
+
+// listen applies the flushes of other instances
+func (c *RedisCache) listen(messages <-chan *redis.Message) {
+	for message := range messages {
+		fields := strings.Fields(message.Payload)
+		if len(fields) != 2 {
+			continue
+		}
+		generation, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		c.setGeneration(fields[0], generation)
+	}
+}
+
+// setGeneration moves the scoped method to generation, flushes can arrive out of order
+func (c *RedisCache) setGeneration(scoped string, generation int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation > c.generations[scoped] {
+		c.generations[scoped] = generation
+	}
+}
+
+func (c *RedisCache) generation(ctx context.Context, scoped string) (int64, error) {
+	c.mutex.RLock()
+	generation, ok := c.generations[scoped]
+	c.mutex.RUnlock()
+	if ok {
+		return generation, nil
+	}
+	generation, err := c.client.Get(ctx, keyPrefix+"generation:"+scoped).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, errors.Wrapf(err, "couldn't get the cache generation of %s", scoped)
+	}
+	c.setGeneration(scoped, generation)
+	return generation, nil
+}
+
+// key is where the response to method and params is cached, empty while the chain isn't known
+func (c *RedisCache) key(ctx context.Context, method string, params string) (string, error) {
+	scoped, ok := c.scope(method)
+	if !ok {
+		return "", nil
+	}
+	generation, err := c.generation(ctx, scoped)
+	if err != nil {
+		return "", err
+	}
+	return keyPrefix + scoped + ":" + strconv.FormatInt(generation, 10) + ":" + params, nil
+}
+
+func (c *RedisCache) Get(ctx context.Context, method string, params string) ([]byte, error) {
+	key, err := c.key(ctx, method, params)
+	if err != nil || key == "" {
+		return nil, err
+	}
+	response, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return response, errors.Wrapf(err, "couldn't get cached %s", method)
+}
+
+func (c *RedisCache) Set(ctx context.Context, method string, params string, response []byte, ttl time.Duration) error {
+	key, err := c.key(ctx, method, params)
+	if err != nil || key == "" {
+		return err
+	}
+	return errors.Wrapf(c.client.Set(ctx, key, response, ttl).Err(), "couldn't cache %s", method)
+}
+
+func (c *RedisCache) Flush(ctx context.Context, methods []string) error {
+	for _, method := range methods {
+		scoped, ok := c.scope(method)
+		if !ok {
+			// nothing was cached yet
+			return nil
+		}
+		generation, err := c.client.Incr(ctx, keyPrefix+"generation:"+scoped).Result()
+		if err != nil {
+			return errors.Wrapf(err, "couldn't flush %s", method)
+		}
+		c.setGeneration(scoped, generation)
+		if err := c.client.Publish(ctx, flushChannel, scoped+" "+strconv.FormatInt(generation, 10)).Err(); err != nil {
+			return errors.Wrapf(err, "couldn't announce the flush of %s", method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRedisCacheKeysStartWithChainID(t *testing.T) {
+	// no Redis server is needed while generations are known and the chain isn't
+	c := &RedisCache{generations: map[string]int64{"81:getblock": 3, "8889:getblock": 5}}
+
+	if response, err := c.Get(context.Background(), "getblock", `["aa"]`); response != nil || err != nil {
+		t.Errorf("expected a miss while the chain isn't known, got %s, %v", response, err)
+	}
+	if err := c.Flush(context.Background(), []string{"getblock"}); err != nil {
+		t.Errorf("expected nothing to flush while the chain isn't known, got %v", err)
+	}
+
+	for chainID, want := range map[int]string{
+		81:   `janus:cache:81:getblock:3:["aa"]`,
+		8889: `janus:cache:8889:getblock:5:["aa"]`,
+	} {
+		c.SetChainID(chainID)
+		key, err := c.key(context.Background(), "getblock", `["aa"]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != want {
+			t.Errorf("expected key %s, got %s", want, key)
+		}
+	}
+}
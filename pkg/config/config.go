@@ -0,0 +1,270 @@
+// Package config reads the settings of Janus from a YAML or TOML file instead of flags and environment variables. Each
+// setting of the file stands for a flag, the flags and environment variables set take precedence over it
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a settings file, settings left out keep the defaults of their flags
+type Config struct {
+	Upstreams  Upstreams  `yaml:"upstreams" toml:"upstreams"`
+	Accounts   Accounts   `yaml:"accounts" toml:"accounts"`
+	Cache      Cache      `yaml:"cache" toml:"cache"`
+	Database   Database   `yaml:"database" toml:"database"`
+	RateLimits RateLimits `yaml:"rateLimits" toml:"rateLimits"`
+	Logging    Logging    `yaml:"logging" toml:"logging"`
+}
+
+// Upstreams are the qtumd nodes requests are served from
+type Upstreams struct {
+	// --qtum-rpc
+	RPC *string `yaml:"rpc" toml:"rpc"`
+	// --qtum-rpc-upstreams
+	URLs []string `yaml:"urls" toml:"urls"`
+	// --upstream-balancing
+	Balancing *string `yaml:"balancing" toml:"balancing"`
+	// --qtum-rpc-user
+	User *string `yaml:"user" toml:"user"`
+	// --qtum-rpc-password
+	Password *string `yaml:"password" toml:"password"`
+	// --qtum-rpc-token
+	Token *string `yaml:"token" toml:"token"`
+	// --qtum-rpc-user-agent
+	UserAgent *string `yaml:"userAgent" toml:"userAgent"`
+	// --qtum-rpc-header
+	Headers map[string]string `yaml:"headers" toml:"headers"`
+	// --qtum-network
+	Network *string `yaml:"network" toml:"network"`
+}
+
+// Accounts are the accounts eth_accounts returns and Janus signs for
+type Accounts struct {
+	// --accounts
+	File *string `yaml:"file" toml:"file"`
+	// --accounts-secret
+	Secret *string `yaml:"secret" toml:"secret"`
+	// --wallet-accounts
+	Wallet *bool `yaml:"wallet" toml:"wallet"`
+	// --keystore
+	Keystore *string `yaml:"keystore" toml:"keystore"`
+	// --keystore-unlock-timeout
+	UnlockTimeout *string `yaml:"unlockTimeout" toml:"unlockTimeout"`
+}
+
+// Cache is where qtumd responses are cached
+type Cache struct {
+	// --cache-redis
+	Redis *string `yaml:"redis" toml:"redis"`
+}
+
+// Database is the database of the block hashes, filters, log index and the other persisted state
+type Database struct {
+	// --sql-host
+	Host *string `yaml:"host" toml:"host"`
+	// --sql-port
+	Port *int `yaml:"port" toml:"port"`
+	// --sql-user
+	User *string `yaml:"user" toml:"user"`
+	// --sql-password
+	Password *string `yaml:"password" toml:"password"`
+	// --sql-dbname
+	Name *string `yaml:"name" toml:"name"`
+	// --sql-ssl
+	SSL *bool `yaml:"ssl" toml:"ssl"`
+	// --dbstring
+	ConnectionString *string `yaml:"connectionString" toml:"connectionString"`
+}
+
+// RateLimits cap the requests served per second
+type RateLimits struct {
+	// --rate-limit
+	Global *float64 `yaml:"global" toml:"global"`
+	// --method-rate-limit
+	Methods map[string]float64 `yaml:"methods" toml:"methods"`
+	// --rate-limit-burst
+	Burst *int `yaml:"burst" toml:"burst"`
+	// --rate-limit-per-ip
+	PerIP *bool `yaml:"perIP" toml:"perIP"`
+	// --rate-limit-trusted-proxy
+	TrustedProxies []string `yaml:"trustedProxies" toml:"trustedProxies"`
+}
+
+// Logging is what is logged where
+type Logging struct {
+	// --log-level
+	Level *string `yaml:"level" toml:"level"`
+	// --log-file
+	File *string `yaml:"file" toml:"file"`
+}
+
+// reloadable are the flags a reloaded file changes while Janus runs, the others take a restart
+var reloadable = map[string]bool{
+	"log-level":                true,
+	"rate-limit":               true,
+	"method-rate-limit":        true,
+	"rate-limit-burst":         true,
+	"rate-limit-per-ip":        true,
+	"rate-limit-trusted-proxy": true,
+}
+
+// IsReloadable reports whether a reloaded file changes flag while Janus runs
+func IsReloadable(flag string) bool {
+	return reloadable[flag]
+}
+
+// Load reads a settings file, YAML with a .yaml or .yml extension and TOML with a .toml extension. Unknown settings
+// are refused, they are most likely misspelled
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read config file")
+	}
+
+	config := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && err != io.EOF {
+			return nil, errors.Wrapf(err, "invalid config file %s", path)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(data), config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid config file %s", path)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return nil, errors.Errorf("invalid config file %s: unknown setting %s", path, undecoded[0])
+		}
+	default:
+		return nil, errors.Errorf("unsupported config file %s, expected a .yaml, .yml or .toml file", path)
+	}
+	return config, nil
+}
+
+// Flags returns the values of the flags the file sets by flag name, several for the repeatable flags
+func (c *Config) Flags() map[string][]string {
+	flags := flagValues{}
+
+	flags.set("qtum-rpc", c.Upstreams.RPC)
+	if len(c.Upstreams.URLs) > 0 {
+		flags["qtum-rpc-upstreams"] = []string{strings.Join(c.Upstreams.URLs, ",")}
+	}
+	flags.set("upstream-balancing", c.Upstreams.Balancing)
+	flags.set("qtum-rpc-user", c.Upstreams.User)
+	flags.set("qtum-rpc-password", c.Upstreams.Password)
+	flags.set("qtum-rpc-token", c.Upstreams.Token)
+	flags.set("qtum-rpc-user-agent", c.Upstreams.UserAgent)
+	for name, value := range c.Upstreams.Headers {
+		flags["qtum-rpc-header"] = append(flags["qtum-rpc-header"], name+"="+value)
+	}
+	flags.set("qtum-network", c.Upstreams.Network)
+
+	flags.set("accounts", c.Accounts.File)
+	flags.set("accounts-secret", c.Accounts.Secret)
+	flags.set("wallet-accounts", c.Accounts.Wallet)
+	flags.set("keystore", c.Accounts.Keystore)
+	flags.set("keystore-unlock-timeout", c.Accounts.UnlockTimeout)
+
+	flags.set("cache-redis", c.Cache.Redis)
+
+	flags.set("sql-host", c.Database.Host)
+	flags.set("sql-port", c.Database.Port)
+	flags.set("sql-user", c.Database.User)
+	flags.set("sql-password", c.Database.Password)
+	flags.set("sql-dbname", c.Database.Name)
+	flags.set("sql-ssl", c.Database.SSL)
+	flags.set("dbstring", c.Database.ConnectionString)
+
+	flags.set("rate-limit", c.RateLimits.Global)
+	for method, rate := range c.RateLimits.Methods {
+		flags["method-rate-limit"] = append(flags["method-rate-limit"], method+"="+strconv.FormatFloat(rate, 'f', -1, 64))
+	}
+	flags.set("rate-limit-burst", c.RateLimits.Burst)
+	flags.set("rate-limit-per-ip", c.RateLimits.PerIP)
+	if len(c.RateLimits.TrustedProxies) > 0 {
+		flags["rate-limit-trusted-proxy"] = append(flags["rate-limit-trusted-proxy"], c.RateLimits.TrustedProxies...)
+	}
+
+	flags.set("log-level", c.Logging.Level)
+	flags.set("log-file", c.Logging.File)
+
+	// the values of repeatable flags come out of maps in random order
+	for _, values := range flags {
+		sort.Strings(values)
+	}
+	return flags
+}
+
+// Changes lists the flags set differently by two files, sorted
+func Changes(previous map[string][]string, current map[string][]string) []string {
+	var changes []string
+	for flag, values := range previous {
+		if !equal(values, current[flag]) {
+			changes = append(changes, flag)
+		}
+	}
+	for flag := range current {
+		if _, ok := previous[flag]; !ok {
+			changes = append(changes, flag)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type flagValues map[string][]string
+
+// set adds the value of a flag the file sets, nil values are left out
+func (f flagValues) set(flag string, value interface{}) {
+	var formatted string
+	switch value := value.(type) {
+	case *string:
+		if value == nil {
+			return
+		}
+		formatted = *value
+	case *int:
+		if value == nil {
+			return
+		}
+		formatted = strconv.Itoa(*value)
+	case *float64:
+		if value == nil {
+			return
+		}
+		formatted = strconv.FormatFloat(*value, 'f', -1, 64)
+	case *bool:
+		if value == nil {
+			return
+		}
+		formatted = strconv.FormatBool(*value)
+	default:
+		panic(fmt.Sprintf("unsupported setting type %T of --%s", value, flag))
+	}
+	f[flag] = []string{formatted}
+}
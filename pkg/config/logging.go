@@ -0,0 +1,49 @@
+package config
+
+import (
+	"sync/atomic"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// Levels are the log levels from the most to the least verbose, logs below the level set are dropped
+var Levels = []string{"debug", "info", "warn", "error"}
+
+var levelOptions = map[string]level.Option{
+	"debug": level.AllowDebug(),
+	"info":  level.AllowInfo(),
+	"warn":  level.AllowWarn(),
+	"error": level.AllowError(),
+}
+
+// LevelLogger drops the logs below a level that can be changed while logging, so a reloaded config file changes it
+// for the loggers already derived from it
+type LevelLogger struct {
+	next     log.Logger
+	filtered atomic.Value
+}
+
+// NewLevelLogger passes the logs of logLevel and above to next
+func NewLevelLogger(next log.Logger, logLevel string) (*LevelLogger, error) {
+	l := &LevelLogger{next: next}
+	if err := l.SetLevel(logLevel); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetLevel changes the level of the logs passed on
+func (l *LevelLogger) SetLevel(logLevel string) error {
+	option, ok := levelOptions[logLevel]
+	if !ok {
+		return errors.Errorf("invalid log level %q, expected one of %v", logLevel, Levels)
+	}
+	l.filtered.Store(level.NewFilter(l.next, option))
+	return nil
+}
+
+func (l *LevelLogger) Log(keyvals ...interface{}) error {
+	return l.filtered.Load().(log.Logger).Log(keyvals...)
+}
@@ -0,0 +1,123 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// compareResponses checks a Janus response against the one in a vector
+// Clients word errors differently, so an expected error only requires Janus to return one
+func compareResponses(mode string, wantRaw, gotRaw json.RawMessage) (string, bool) {
+	var want, got response
+	if err := json.Unmarshal(wantRaw, &want); err != nil {
+		return "invalid response in vector: " + err.Error(), false
+	}
+	if err := json.Unmarshal(gotRaw, &got); err != nil {
+		return "invalid response from Janus: " + err.Error(), false
+	}
+
+	if want.Error != nil {
+		if got.Error == nil {
+			return fmt.Sprintf("expected an error (%d %s), got %s", want.Error.Code, want.Error.Message, string(got.Result)), false
+		}
+		return "", true
+	}
+	if got.Error != nil {
+		return fmt.Sprintf("unexpected error %d %s", got.Error.Code, got.Error.Message), false
+	}
+
+	var wantResult, gotResult interface{}
+	if err := unmarshalResult(want.Result, &wantResult); err != nil {
+		return "invalid result in vector: " + err.Error(), false
+	}
+	if err := unmarshalResult(got.Result, &gotResult); err != nil {
+		return "invalid result from Janus: " + err.Error(), false
+	}
+
+	switch mode {
+	case CompareExact:
+		if !reflect.DeepEqual(wantResult, gotResult) {
+			return fmt.Sprintf("expected %s, got %s", string(want.Result), string(got.Result)), false
+		}
+		return "", true
+	case CompareShape:
+		return compareShape("result", wantResult, gotResult)
+	}
+	return "unknown compare mode " + mode, false
+}
+
+func unmarshalResult(raw json.RawMessage, v *interface{}) error {
+	if len(raw) == 0 {
+		*v = nil
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// compareShape checks got has every field of want, with the same JSON types and hex encodings
+func compareShape(path string, want, got interface{}) (string, bool) {
+	switch want := want.(type) {
+	case nil:
+		if got != nil {
+			return fmt.Sprintf("%s: expected null, got %v", path, got), false
+		}
+	case map[string]interface{}:
+		got, ok := got.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("%s: expected an object, got %v", path, got), false
+		}
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			gotValue, ok := got[key]
+			if !ok {
+				return fmt.Sprintf("%s: missing field %s", path, key), false
+			}
+			if detail, ok := compareShape(path+"."+key, want[key], gotValue); !ok {
+				return detail, false
+			}
+		}
+	case []interface{}:
+		got, ok := got.([]interface{})
+		if !ok {
+			return fmt.Sprintf("%s: expected an array, got %v", path, got), false
+		}
+		// the items of chain dependent arrays can't be matched up, only compare the first ones
+		if len(want) != 0 && len(got) != 0 {
+			return compareShape(path+"[0]", want[0], got[0])
+		}
+	case string:
+		got, ok := got.(string)
+		if !ok {
+			return fmt.Sprintf("%s: expected a string, got %v", path, got), false
+		}
+		if strings.HasPrefix(want, "0x") {
+			if !strings.HasPrefix(got, "0x") {
+				return fmt.Sprintf("%s: expected a hex string, got %s", path, got), false
+			}
+			// addresses and hashes have a fixed length, unlike quantities
+			if n := len(want); (n == 42 || n == 66) && len(got) != n {
+				return fmt.Sprintf("%s: expected %d characters, got %s", path, n, got), false
+			}
+		}
+	default:
+		if reflect.TypeOf(want) != reflect.TypeOf(got) {
+			return fmt.Sprintf("%s: expected %T, got %T", path, want, got), false
+		}
+	}
+	return "", true
+}
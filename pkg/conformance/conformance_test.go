@@ -0,0 +1,121 @@
+package conformance
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	// the vectors of Janus itself, next to their fixtures
+	janusDir = "testdata/janus"
+	// the tests directory of execution-apis vendored by make execution-apis
+	executionAPIsDir = "testdata/execution-apis"
+	// the fixtures of the execution-apis vectors
+	qtumFixturesDir = "testdata/qtum"
+)
+
+// Runs the vectors of Janus and the vendored execution-apis vectors, or the tests directory of an execution-apis
+// checkout set in JANUS_EXECUTION_APIS. execution-apis vectors without a fixture in testdata/qtum are skipped.
+// JANUS_CONFORMANCE_REPORT writes the report to a file.
+func TestExecutionAPIs(t *testing.T) {
+	vectorsDir := os.Getenv("JANUS_EXECUTION_APIS")
+	if vectorsDir == "" {
+		vectorsDir = executionAPIsDir
+		if _, err := os.Stat(vectorsDir); os.IsNotExist(err) {
+			t.Logf("%s is missing, run make execution-apis to vendor the execution-apis vectors", vectorsDir)
+			vectorsDir = ""
+		}
+	}
+
+	report := &Report{}
+	t.Run("janus", func(t *testing.T) {
+		runVectors(t, janusDir, janusDir, report)
+	})
+	if vectorsDir != "" {
+		t.Run("execution-apis", func(t *testing.T) {
+			runVectors(t, vectorsDir, qtumFixturesDir, report)
+		})
+	}
+
+	if path := os.Getenv("JANUS_CONFORMANCE_REPORT"); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if err := report.WriteMarkdown(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Logf("%d passed, %d failed, %d skipped", report.Count(StatusPass), report.Count(StatusFail), report.Count(StatusSkip))
+}
+
+func runVectors(t *testing.T, vectorsDir string, fixturesDir string, report *Report) {
+	vectors, err := LoadVectors(vectorsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatalf("no vectors in %s", vectorsDir)
+	}
+
+	runner := &Runner{FixturesDir: fixturesDir}
+	for _, vector := range vectors {
+		vector := vector
+		t.Run(vector.Name, func(t *testing.T) {
+			result := runner.RunVector(context.Background(), vector)
+			report.Results = append(report.Results, result)
+			switch result.Status {
+			case StatusFail:
+				t.Errorf("%s\n>> %s\n<< %s", result.Detail, vector.Request, vector.Response)
+			case StatusSkip:
+				t.Skip(result.Detail)
+			}
+		})
+	}
+}
+
+func TestParseVector(t *testing.T) {
+	io := `// retrieves the client's current chain id
+>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+<< {"jsonrpc":"2.0","id":1,"result":"0xc72dd9d5e883e"}
+`
+	vector, err := ParseVector("eth_chainId/get-chain-id", strings.NewReader(io))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vector.Method != "eth_chainId" || vector.Comment != "retrieves the client's current chain id" {
+		t.Errorf("unexpected vector %+v", vector)
+	}
+
+	if _, err := ParseVector("broken", strings.NewReader(">> {}\n")); err == nil {
+		t.Error("expected an error for a vector without a response")
+	}
+}
+
+func TestCompareResponses(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+		got  string
+		ok   bool
+	}{
+		{CompareExact, `{"result":null}`, `{"result":null}`, true},
+		{CompareExact, `{"result":"0x1"}`, `{"result":"0x2"}`, false},
+		{CompareExact, `{"error":{"code":-32000,"message":"not found"}}`, `{"error":{"code":-32602,"message":"invalid"}}`, true},
+		{CompareExact, `{"error":{"code":-32000,"message":"not found"}}`, `{"result":null}`, false},
+		{CompareShape, `{"result":"0x2d"}`, `{"result":"0xf8f"}`, true},
+		{CompareShape, `{"result":"0x2d"}`, `{"result":3983}`, false},
+		{CompareShape, `{"result":{"hash":"0x` + strings.Repeat("ab", 32) + `","number":"0x1"}}`, `{"result":{"hash":"0x` + strings.Repeat("cd", 32) + `","number":"0xf8f","extra":true}}`, true},
+		{CompareShape, `{"result":{"hash":"0x` + strings.Repeat("ab", 32) + `"}}`, `{"result":{"hash":"0xabcd"}}`, false},
+		{CompareShape, `{"result":{"hash":"0x1"}}`, `{"result":{"number":"0x1"}}`, false},
+	}
+
+	for _, test := range tests {
+		if detail, ok := compareResponses(test.mode, []byte(test.want), []byte(test.got)); ok != test.ok {
+			t.Errorf("%s %s against %s: got %v (%s), want %v", test.mode, test.got, test.want, ok, detail, test.ok)
+		}
+	}
+}
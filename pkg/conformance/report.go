@@ -0,0 +1,66 @@
+package conformance
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+type Result struct {
+	Vector *Vector
+	Status Status
+	// why a vector failed or was skipped
+	Detail string
+}
+
+// Report is the compatibility report of a run
+type Report struct {
+	Results []Result
+}
+
+func (r *Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// WriteMarkdown writes a summary and a table of every vector
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	_, err := fmt.Fprintf(
+		w,
+		"# execution-apis conformance\n\n%d passed, %d failed, %d skipped\n\n| Test | Status | Detail |\n| --- | --- | --- |\n",
+		r.Count(StatusPass), r.Count(StatusFail), r.Count(StatusSkip),
+	)
+	if err != nil {
+		return err
+	}
+	for _, result := range r.Results {
+		detail := strings.ReplaceAll(result.Detail, "|", "\\|")
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s |\n", result.Vector.Name, result.Status, detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+const (
+	// the result has to equal the one in the vector
+	CompareExact = "exact"
+	// the result has to have the same structure and encodings as the one in the vector,
+	// for vectors whose values depend on the chain they were generated from
+	CompareShape = "shape"
+)
+
+// Fixture is what the mocked Qtum node replies while running a vector, stored as <method>/<name>.qtum.json
+// The execution-apis vectors are generated from a geth chain, so a vector only applies to Janus when a fixture describes
+// an equivalent Qtum chain state. The vectors of Janus itself are stored next to their fixtures
+type Fixture struct {
+	// why the vector doesn't apply to Qtum, the vector is skipped when set
+	Skip string `json:"skip,omitempty"`
+	// CompareExact (the default) or CompareShape
+	Compare string `json:"compare,omitempty"`
+	// responses of the Qtum node by RPC method, in the order they are returned
+	Responses map[string][]FixtureResponse `json:"responses"`
+}
+
+type FixtureResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *FixtureError   `json:"error,omitempty"`
+}
+
+type FixtureError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// LoadFixture returns nil when there is no fixture for the vector
+func LoadFixture(dir string, vector *Vector) (*Fixture, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(vector.Name)+".qtum.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, errors.Wrapf(err, "%s: invalid fixture", vector.Name)
+	}
+	return &fixture, nil
+}
+
+// Runner runs vectors through a transformer whose Qtum node is mocked with the vectors' fixtures
+type Runner struct {
+	FixturesDir string
+	// the proxies under test, transformer.DefaultProxies when nil
+	Proxies func(*qtum.Qtum) []transformer.ETHProxy
+}
+
+func (r *Runner) Run(ctx context.Context, vectors []*Vector) *Report {
+	report := &Report{}
+	for _, vector := range vectors {
+		report.Results = append(report.Results, r.RunVector(ctx, vector))
+	}
+	return report
+}
+
+func (r *Runner) RunVector(ctx context.Context, vector *Vector) Result {
+	result := Result{Vector: vector}
+
+	fixture, err := LoadFixture(r.FixturesDir, vector)
+	if err != nil {
+		result.Status, result.Detail = StatusFail, err.Error()
+		return result
+	}
+	if fixture == nil {
+		result.Status, result.Detail = StatusSkip, "no Qtum fixture"
+		return result
+	}
+	if fixture.Skip != "" {
+		result.Status, result.Detail = StatusSkip, fixture.Skip
+		return result
+	}
+
+	got, err := r.call(ctx, vector, fixture)
+	if err != nil {
+		result.Status, result.Detail = StatusFail, err.Error()
+		return result
+	}
+
+	mode := fixture.Compare
+	if mode == "" {
+		mode = CompareExact
+	}
+	if detail, ok := compareResponses(mode, vector.Response, got); !ok {
+		result.Status, result.Detail = StatusFail, detail
+		return result
+	}
+
+	result.Status = StatusPass
+	return result
+}
+
+// call sends the vector's request through a transformer and returns the response JSON
+func (r *Runner) call(ctx context.Context, vector *Vector, fixture *Fixture) (json.RawMessage, error) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	for method, responses := range fixture.Responses {
+		for _, response := range responses {
+			var err error
+			if response.Error != nil {
+				err = mockedClientDoer.AddError(method, eth.NewJSONRPCError(response.Error.Code, response.Error.Message, nil))
+			} else {
+				err = mockedClientDoer.AddResponse(method, response.Result)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies := r.Proxies
+	if proxies == nil {
+		proxies = func(q *qtum.Qtum) []transformer.ETHProxy {
+			return transformer.DefaultProxies(q, nil)
+		}
+	}
+	t, err := transformer.New(qtumClient, proxies(qtumClient))
+	if err != nil {
+		return nil, err
+	}
+
+	var request eth.JSONRPCRequest
+	if err := json.Unmarshal(vector.Request, &request); err != nil {
+		return nil, errors.Wrap(err, "invalid request")
+	}
+
+	result, jsonErr := t.Transform(ctx, &request, internal.NewEchoWithContext(ctx))
+	if jsonErr != nil {
+		return json.Marshal(eth.JSONRPCResult{
+			JSONRPC: eth.RPCVersion,
+			ID:      request.ID,
+			Error:   jsonErr,
+		})
+	}
+	response, err := eth.NewJSONRPCResult(request.ID, result)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
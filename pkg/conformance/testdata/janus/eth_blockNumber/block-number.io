@@ -0,0 +1,3 @@
+// retrieves the client's current block number
+>> {"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}
+<< {"jsonrpc":"2.0","id":1,"result":"0xf8f"}
@@ -0,0 +1,3 @@
+// gets a non-existent block
+>> {"jsonrpc":"2.0","id":1,"method":"eth_getBlockByHash","params":["0x00000000000000000000000000000000000000000000000000000000deadbeef",true]}
+<< {"jsonrpc":"2.0","id":1,"result":null}
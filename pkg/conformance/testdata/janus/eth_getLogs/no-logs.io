@@ -0,0 +1,3 @@
+// queries a block range without logs
+>> {"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"address":["0x7dcd17433742f4c0ca53122ab541d0ba67fc27df"],"fromBlock":"0x1","toBlock":"0x3","topics":[["0x4ee1ae7da4bc6a0f0dbdba7e9e5b6ba3e2a5e7e2fef8e1e6a0d3fbc9b3b1c0d4"]]}]}
+<< {"jsonrpc":"2.0","id":1,"result":[]}
@@ -0,0 +1,124 @@
+package conformance
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Vector is a test from the tests directory of https://github.com/ethereum/execution-apis, or one of Janus written
+// in the same format
+//
+// The tests are stored as <method>/<name>.io files of comments, requests and responses:
+//
+//	// retrieves the client's current chain id
+//	>> {"jsonrpc":"2.0","id":1,"method":"eth_chainId"}
+//	<< {"jsonrpc":"2.0","id":1,"result":"0xc72dd9d5e883e"}
+type Vector struct {
+	// <method>/<name>
+	Name     string
+	Method   string
+	Comment  string
+	Request  json.RawMessage
+	Response json.RawMessage
+	// path of the .io file
+	Path string
+}
+
+// ParseVector reads a single request/response exchange, exchanges after the first are ignored
+func ParseVector(name string, r io.Reader) (*Vector, error) {
+	vector := &Vector{Name: name}
+	var comments []string
+
+	scanner := bufio.NewScanner(r)
+	// responses with full blocks are long lines
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "//"):
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(line, "//")))
+		case strings.HasPrefix(line, ">>"):
+			if vector.Request != nil {
+				continue
+			}
+			vector.Request = json.RawMessage(strings.TrimSpace(strings.TrimPrefix(line, ">>")))
+		case strings.HasPrefix(line, "<<"):
+			if vector.Response != nil {
+				continue
+			}
+			vector.Response = json.RawMessage(strings.TrimSpace(strings.TrimPrefix(line, "<<")))
+		default:
+			return nil, errors.Errorf("%s: unexpected line %q", name, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if vector.Request == nil || vector.Response == nil {
+		return nil, errors.Errorf("%s: missing request or response", name)
+	}
+
+	var request struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(vector.Request, &request); err != nil {
+		return nil, errors.Wrapf(err, "%s: invalid request", name)
+	}
+	if !json.Valid(vector.Response) {
+		return nil, errors.Errorf("%s: invalid response", name)
+	}
+
+	vector.Method = request.Method
+	vector.Comment = strings.Join(comments, " ")
+	return vector, nil
+}
+
+// LoadVectors reads every <method>/<name>.io file under dir, sorted by name
+func LoadVectors(dir string) ([]*Vector, error) {
+	var vectors []*Vector
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".io" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(strings.TrimSuffix(rel, ".io"))
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		vector, err := ParseVector(name, file)
+		if err != nil {
+			return err
+		}
+		vector.Path = path
+		vectors = append(vectors, vector)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(vectors, func(i, j int) bool {
+		return vectors[i].Name < vectors[j].Name
+	})
+	return vectors, nil
+}
@@ -2,7 +2,6 @@ package conversion
 
 import (
 	"context"
-	"strings"
 
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -54,15 +53,10 @@ func SearchLogsAndFilterExtraTopics(ctx context.Context, q *qtum.Qtum, req *qtum
 	hasAddresses := len(req.Addresses) != 0
 
 	if !hasTopics && !hasAddresses {
-		return receipts, nil
+		return indexLogs(receipts), nil
 	}
 
-	if !hasTopics && !hasAddresses {
-		// no actual string topics or addresses, probably weird inputs
-		return receipts, nil
-	}
-
-	requestedAddressesMap := populateLoopUpMapWithToLower(req.Addresses)
+	topics := searchLogsTopicsToFilter(req.Topics)
 
 	var filteredReceipts qtum.SearchLogsResponse
 
@@ -70,11 +64,7 @@ func SearchLogsAndFilterExtraTopics(ctx context.Context, q *qtum.Qtum, req *qtum
 		var logs []qtum.Log
 		for index, log := range receipt.Log {
 			log.Index = index
-			if hasAddresses && !requestedAddressesMap[strings.ToLower(log.Address)] {
-				continue
-			}
-
-			if DoFiltersMatch(req.Topics, log.Topics) {
+			if eth.MatchLogAddress(req.Addresses, log.Address) && eth.MatchLogTopics(topics, log.Topics) {
 				logs = append(logs, log)
 			}
 		}
@@ -87,31 +77,32 @@ func SearchLogsAndFilterExtraTopics(ctx context.Context, q *qtum.Qtum, req *qtum
 	return filteredReceipts, nil
 }
 
-func FilterQtumLogs(addresses []string, filters []qtum.SearchLogsTopic, logs []qtum.Log) []qtum.Log {
-	hasTopics := len(filters) != 0
-	hasAddresses := len(addresses) != 0
-
-	if !hasTopics && !hasAddresses {
-		return logs
-	}
-
-	if !hasTopics && !hasAddresses {
-		// no actual string topics or addresses, probably weird inputs
-		return logs
+// indexLogs copies the logs of receipts returned whole with their index within the receipt set, like the logs of
+// filtered receipts
+func indexLogs(receipts qtum.SearchLogsResponse) qtum.SearchLogsResponse {
+	indexed := make(qtum.SearchLogsResponse, len(receipts))
+	for i, receipt := range receipts {
+		logs := make([]qtum.Log, len(receipt.Log))
+		for index, log := range receipt.Log {
+			log.Index = index
+			logs[index] = log
+		}
+		receipt.Log = logs
+		indexed[i] = receipt
 	}
+	return indexed
+}
 
-	requestedAddressesMap := populateLoopUpMapWithToLower(addresses)
+// FilterQtumLogs returns the logs of a receipt that match addresses and filters, setting their index within the receipt
+func FilterQtumLogs(addresses []string, filters []qtum.SearchLogsTopic, logs []qtum.Log) []qtum.Log {
+	topics := searchLogsTopicsToFilter(filters)
 
 	filteredLogs := []qtum.Log{}
 
-	for _, log := range logs {
-		if hasAddresses && !requestedAddressesMap[strings.ToLower(strings.TrimPrefix(log.Address, "0x"))] {
-			continue
-		}
-
-		if DoFiltersMatch(filters, log.Topics) {
+	for index, log := range logs {
+		log.Index = index
+		if eth.MatchLogAddress(addresses, log.Address) && eth.MatchLogTopics(topics, log.Topics) {
 			filteredLogs = append(filteredLogs, log)
-			break
 		}
 	}
 
@@ -119,53 +110,13 @@ func FilterQtumLogs(addresses []string, filters []qtum.SearchLogsTopic, logs []q
 }
 
 func DoFiltersMatch(filters []qtum.SearchLogsTopic, topics []string) bool {
-	filterCount := len(filters)
-	for i, topic := range topics {
-		if i >= filterCount {
-			break
-		}
-
-		filter := filters[i]
-
-		if len(filter) == 0 {
-			// nil, accept all
-			continue
-		} else if len(filter) == 1 {
-			if strings.ToLower(filter[0]) == strings.ToLower(topic) {
-				// match
-				continue
-			} else {
-				// not a match
-				return false
-			}
-		} else {
-			// or
-			match := false
-
-			for _, orFilter := range filter {
-				match = strings.ToLower(orFilter) == strings.ToLower(topic)
-				if match {
-					break
-				}
-			}
-
-			if match {
-				continue
-			} else {
-				return false
-			}
-		}
-	}
-
-	return true
+	return eth.MatchLogTopics(searchLogsTopicsToFilter(filters), topics)
 }
 
-func populateLoopUpMapWithToLower(inputs []string) map[string]bool {
-	lookupMap := make(map[string]bool)
-
-	for _, input := range inputs {
-		lookupMap[strings.ToLower(input)] = true
+func searchLogsTopicsToFilter(filters []qtum.SearchLogsTopic) [][]string {
+	topics := make([][]string, len(filters))
+	for i, filter := range filters {
+		topics[i] = filter
 	}
-
-	return lookupMap
+	return topics
 }
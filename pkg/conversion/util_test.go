@@ -0,0 +1,31 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestFilterQtumLogsKeepsEveryMatch(t *testing.T) {
+	transfer := "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	approval := "8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+	logs := []qtum.Log{
+		{Address: "b406040d9e1a9bbb19fcc803a7a808b038ae45ce", Topics: []string{transfer}},
+		{Address: "b406040d9e1a9bbb19fcc803a7a808b038ae45ce", Topics: []string{approval}},
+		{Address: "e7e5caae57b34b93c57af9478a5130f62e3d2827", Topics: []string{transfer}},
+		{Address: "B406040D9E1A9BBB19FCC803A7A808B038AE45CE", Topics: []string{transfer}},
+	}
+
+	filtered := FilterQtumLogs(
+		[]string{"b406040d9e1a9bbb19fcc803a7a808b038ae45ce"},
+		qtum.NewSearchLogsTopics([][]string{{transfer}}),
+		logs,
+	)
+
+	if len(filtered) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(filtered))
+	}
+	if filtered[0].Index != 0 || filtered[1].Index != 3 {
+		t.Errorf("expected log indexes 0 and 3, got %d and %d", filtered[0].Index, filtered[1].Index)
+	}
+}
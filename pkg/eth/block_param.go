@@ -0,0 +1,156 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+const (
+	BlockTagLatest    = "latest"
+	BlockTagEarliest  = "earliest"
+	BlockTagPending   = "pending"
+	BlockTagSafe      = "safe"
+	BlockTagFinalized = "finalized"
+)
+
+// BlockParam is a block specifier, as taken by eth_getBlockByNumber, eth_getLogs, eth_getStorageAt, etc
+// It is a block number, a tag, a block hash, or an EIP-1898 object of one of
+// {"blockNumber": "0x1"} and {"blockHash": "0x...", "requireCanonical": true}
+//
+// At most one of Tag, Number and Hash is set, none is set when the parameter was omitted
+type BlockParam struct {
+	Tag              string
+	Number           *big.Int
+	Hash             string // lower case without 0x prefix
+	RequireCanonical bool
+}
+
+func (b *BlockParam) IsEmpty() bool {
+	return b.Tag == "" && b.Number == nil && b.Hash == ""
+}
+
+func (b *BlockParam) String() string {
+	switch {
+	case b.Tag != "":
+		return b.Tag
+	case b.Number != nil:
+		return b.Number.String()
+	case b.Hash != "":
+		return utils.AddHexPrefix(b.Hash)
+	}
+	return ""
+}
+
+// ParseBlockParam parses a raw block specifier, an empty or null raw value is an omitted parameter
+func ParseBlockParam(raw json.RawMessage) (*BlockParam, error) {
+	var param BlockParam
+	if err := param.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return &param, nil
+}
+
+func (b *BlockParam) UnmarshalJSON(data []byte) error {
+	*b = BlockParam{}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	switch data[0] {
+	case '"':
+		var param string
+		if err := json.Unmarshal(data, &param); err != nil {
+			return err
+		}
+		return b.parseString(param)
+
+	case '{':
+		var object struct {
+			BlockNumber      *string `json:"blockNumber"`
+			BlockHash        *string `json:"blockHash"`
+			RequireCanonical bool    `json:"requireCanonical"`
+		}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		if object.BlockNumber != nil && object.BlockHash != nil {
+			return errors.New("cannot specify both blockHash and blockNumber, choose one or the other")
+		}
+		if object.BlockHash != nil {
+			hash, err := parseBlockHash(*object.BlockHash)
+			if err != nil {
+				return err
+			}
+			b.Hash = hash
+			b.RequireCanonical = object.RequireCanonical
+			return nil
+		}
+		if object.BlockNumber != nil {
+			if err := b.parseString(*object.BlockNumber); err != nil {
+				return err
+			}
+			if b.IsEmpty() {
+				return errors.New("empty blockNumber")
+			}
+			return nil
+		}
+		return errors.New("either blockHash or blockNumber has to be set")
+
+	default:
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return errors.New("invalid block parameter - a string, integer or object is expected")
+		}
+		n, ok := new(big.Int).SetString(number.String(), 10)
+		if !ok || n.Sign() < 0 {
+			return errors.Errorf("invalid block number %s", number)
+		}
+		b.Number = n
+		return nil
+	}
+}
+
+func (b *BlockParam) parseString(param string) error {
+	switch param {
+	case "":
+		return nil
+	case BlockTagLatest, BlockTagEarliest, BlockTagPending, BlockTagSafe, BlockTagFinalized:
+		b.Tag = param
+		return nil
+	}
+
+	if !strings.HasPrefix(param, "0x") {
+		return errors.New("quantity values must start with 0x")
+	}
+	// a 32 byte value is a block hash, a block number is never that large
+	if len(param) == 66 {
+		hash, err := parseBlockHash(param)
+		if err != nil {
+			return err
+		}
+		b.Hash = hash
+		return nil
+	}
+	n, err := utils.DecodeBig(param)
+	if err != nil {
+		return errors.Errorf("couldn't decode hex number %s", param)
+	}
+	b.Number = n
+	return nil
+}
+
+func parseBlockHash(hash string) (string, error) {
+	hash = strings.ToLower(utils.RemoveHexPrefix(hash))
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 {
+		return "", errors.Errorf("invalid block hash %q", hash)
+	}
+	return hash, nil
+}
@@ -0,0 +1,192 @@
+package eth
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// response fields holding addresses, emitted with EIP-55 checksums
+var addressFields = map[string]bool{
+	"address":         true,
+	"contractAddress": true,
+	"from":            true,
+	"to":              true,
+	"miner":           true,
+}
+
+func isHexAddress(address string) bool {
+	return len(address) == 42 && strings.HasPrefix(address, "0x") && common.IsHexAddress(address)
+}
+
+// ChecksumAddress returns an address with its EIP-55 checksum, anything that isn't a 0x prefixed address is returned
+// unchanged
+func ChecksumAddress(address string) string {
+	if !isHexAddress(address) {
+		return address
+	}
+	return common.HexToAddress(address).Hex()
+}
+
+// ValidateAddressChecksum rejects mixed case addresses whose EIP-55 checksum is wrong, all lowercase and all
+// uppercase addresses don't carry a checksum and are accepted
+func ValidateAddressChecksum(address string) error {
+	if !isHexAddress(address) {
+		return nil
+	}
+	hex := address[2:]
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return nil
+	}
+	if address != ChecksumAddress(address) {
+		return errors.Errorf("invalid address checksum %s", address)
+	}
+	return nil
+}
+
+// ValidateParamsChecksums checks the checksum of every address in a request param, only params holding addresses are
+// to be checked, data like the message eth_sign signs can look like an address
+func ValidateParamsChecksums(params json.RawMessage) error {
+	if len(params) == 0 {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(params, &decoded); err != nil {
+		// malformed params are reported by the method
+		return nil
+	}
+	return validateChecksums(decoded)
+}
+
+func validateChecksums(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		return ValidateAddressChecksum(value)
+	case []interface{}:
+		for _, item := range value {
+			if err := validateChecksums(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			if err := validateChecksums(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ChecksumResultAddresses checksums the address fields of a response, or every address in it when all is set, for
+// methods like eth_accounts that return nothing but addresses. The parts of the response holding addresses are copied,
+// results can be shared with caches and other requests, the rest is shared with the copy
+func ChecksumResultAddresses(result interface{}, all bool) interface{} {
+	if result == nil {
+		return nil
+	}
+	v := reflect.ValueOf(result)
+	if !all && !hasAddressFields(v.Type()) {
+		return result
+	}
+	return checksumCopy(v, all).Interface()
+}
+
+// types known to hold address fields or not, by reflect.Type
+var addressFieldTypes sync.Map
+
+// hasAddressFields reports whether values of a type can hold address fields, interfaces can hold anything
+func hasAddressFields(t reflect.Type) bool {
+	if known, ok := addressFieldTypes.Load(t); ok {
+		return known.(bool)
+	}
+	// recursive types are assumed to hold addresses while they are looked at
+	addressFieldTypes.Store(t, true)
+	has := false
+	switch t.Kind() {
+	case reflect.Interface:
+		has = true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		has = t.Elem().Kind() != reflect.Uint8 && hasAddressFields(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !has; i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			has = field.PkgPath == "" && ((addressFields[name] && containsStrings(field.Type)) || hasAddressFields(field.Type))
+		}
+	}
+	addressFieldTypes.Store(t, has)
+	return has
+}
+
+// containsStrings reports whether an address field of a type can hold a string address
+func containsStrings(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsStrings(t.Elem())
+	}
+	return false
+}
+
+// checksumCopy returns v with its addresses checksummed. What v points to or shares with other values, pointers,
+// slices and interfaces, is copied rather than changed, unless it holds no addresses
+func checksumCopy(v reflect.Value, isAddress bool) reflect.Value {
+	if !isAddress && !hasAddressFields(v.Type()) {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if isAddress {
+			copied := reflect.New(v.Type()).Elem()
+			copied.SetString(ChecksumAddress(v.String()))
+			return copied
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			copied := reflect.New(v.Type().Elem())
+			copied.Elem().Set(checksumCopy(v.Elem(), isAddress))
+			return copied
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			copied := reflect.New(v.Type()).Elem()
+			copied.Set(checksumCopy(v.Elem(), isAddress))
+			return copied
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 || (v.Kind() == reflect.Slice && v.IsNil()) {
+			// raw JSON and byte data
+			return v
+		}
+		var copied reflect.Value
+		if v.Kind() == reflect.Slice {
+			copied = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		} else {
+			copied = reflect.New(v.Type()).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(checksumCopy(v.Index(i), isAddress))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !copied.Field(i).CanSet() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			copied.Field(i).Set(checksumCopy(v.Field(i), isAddress || addressFields[name]))
+		}
+		return copied
+	}
+	return v
+}
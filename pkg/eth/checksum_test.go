@@ -0,0 +1,82 @@
+package eth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateAddressChecksum(t *testing.T) {
+	valid := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		// not addresses
+		"latest",
+		"0x1",
+	}
+	for _, address := range valid {
+		if err := ValidateAddressChecksum(address); err != nil {
+			t.Errorf("%s: %v", address, err)
+		}
+	}
+	if err := ValidateAddressChecksum("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"); err == nil {
+		t.Error("expected a bad checksum to be rejected")
+	}
+
+	params := json.RawMessage(`[{"from":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","to":"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},"latest"]`)
+	if err := ValidateParamsChecksums(params); err != nil {
+		t.Error(err)
+	}
+	params = json.RawMessage(`[{"address":["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","0xfb6916095ca1df60bB79Ce92cE3Ea74c37c5d359"]}]`)
+	if err := ValidateParamsChecksums(params); err == nil {
+		t.Error("expected a nested bad checksum to be rejected")
+	}
+}
+
+func TestChecksumResultAddresses(t *testing.T) {
+	receipt := &GetTransactionReceiptResponse{
+		TransactionHash: "0xab8f4d2a1b1ec2d7c3b0b8fc4d2b2f4a8d4f2a2e1c0b5d7e4f3a2b1c0d9e8f7a",
+		From:            "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		To:              "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+		Logs: []Log{{
+			Address: "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+			Data:    "0x0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		}},
+	}
+	got := ChecksumResultAddresses(receipt, false).(*GetTransactionReceiptResponse)
+	if got.From != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" || got.To != "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359" {
+		t.Errorf("addresses weren't checksummed %s %s", got.From, got.To)
+	}
+	if got.Logs[0].Address != "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359" {
+		t.Errorf("log address wasn't checksummed %s", got.Logs[0].Address)
+	}
+	if got.TransactionHash != receipt.TransactionHash || got.Logs[0].Data != "0x0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Error("only addresses should change")
+	}
+
+	// the result may be cached, it is left as it is
+	if receipt.From != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" || receipt.Logs[0].Address != "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359" {
+		t.Errorf("the result was changed %s %s", receipt.From, receipt.Logs[0].Address)
+	}
+
+	// parts without addresses are shared rather than copied
+	if &got.Logs[0] == &receipt.Logs[0] {
+		t.Error("the logs holding addresses should be copied")
+	}
+	logs := []Log{{Address: "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", Topics: []string{"0x1234"}}}
+	checksummed := ChecksumResultAddresses(logs, false).([]Log)
+	if &checksummed[0].Topics[0] != &logs[0].Topics[0] {
+		t.Error("topics hold no addresses and should be shared")
+	}
+	hashes := []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	if shared := ChecksumResultAddresses(hashes, false).([]string); &shared[0] != &hashes[0] {
+		t.Error("a result without address fields should be returned as it is")
+	}
+
+	lowercase := []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	accounts := ChecksumResultAddresses(lowercase, true).([]string)
+	if accounts[0] != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" || lowercase[0] != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Errorf("account wasn't checksummed in a copy %s %s", accounts[0], lowercase[0])
+	}
+}
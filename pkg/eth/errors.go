@@ -3,6 +3,7 @@ package eth
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // unknown service
@@ -17,6 +18,27 @@ var InvalidParamsErrorCode = -32602
 // logic error
 var CallbackErrorCode = -32000
 
+// transaction rejected, https://eips.ethereum.org/EIPS/eip-1474#error-codes
+var TransactionRejectedErrorCode = -32003
+
+// resource unavailable, https://eips.ethereum.org/EIPS/eip-1474#error-codes
+var ResourceUnavailableErrorCode = -32002
+
+// limit exceeded, https://eips.ethereum.org/EIPS/eip-1474#error-codes
+var LimitExceededErrorCode = -32005
+
+// unauthorized, https://eips.ethereum.org/EIPS/eip-1193#provider-errors
+var UnauthorizedErrorCode = 4100
+
+// request timed out, same code geth uses when a call runs past its deadline
+var TimeoutErrorCode = -32002
+
+// execution reverted, same code geth uses so clients decode the revert data
+var ExecutionRevertedErrorCode = 3
+
+// pruned history unavailable, the code proposed for EIP-4444 nodes
+var BlockPrunedErrorCode = 4444
+
 // shutdown error
 // "server is shutting down"
 var ShutdownErrorCode = -32000
@@ -46,6 +68,132 @@ func NewCallbackError(message string) JSONRPCError {
 	return NewJSONRPCError(CallbackErrorCode, message, nil)
 }
 
+// TransactionRejectedData explains why qtumd won't accept a transaction into its mempool
+type TransactionRejectedData struct {
+	TxID string `json:"txid"`
+	// reject reason reported by qtumd, such as "min relay fee not met"
+	Reason string `json:"reason"`
+	// one of fee, script, size, inputs, conflict or other
+	Category string `json:"category"`
+}
+
+func NewTransactionRejectedError(data TransactionRejectedData) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		TransactionRejectedErrorCode,
+		fmt.Sprintf("transaction rejected: %s", data.Reason),
+		data,
+	)
+}
+
+// UnavailableData names the optional dependency a method needs that is down
+type UnavailableData struct {
+	Capability string `json:"capability"`
+	Reason     string `json:"reason"`
+}
+
+// NewUnavailableError reports that a method can't be served right now because a dependency is down,
+// other methods keep working
+func NewUnavailableError(data UnavailableData) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		ResourceUnavailableErrorCode,
+		fmt.Sprintf("temporarily unavailable: %s is down", data.Capability),
+		data,
+	)
+}
+
+// LimitExceededData tells clients when to try again
+type LimitExceededData struct {
+	RetryAfterMs int64 `json:"retryAfterMs"`
+}
+
+// NewLimitExceededError reports that qtumd is too busy to serve the request, clients should wait retryAfter before
+// sending it again
+func NewLimitExceededError(retryAfter time.Duration) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		LimitExceededErrorCode,
+		fmt.Sprintf("limit exceeded: qtumd is busy, retry after %s", retryAfter),
+		LimitExceededData{RetryAfterMs: retryAfter.Milliseconds()},
+	)
+}
+
+// NewRateLimitedError reports that the client went over the request rate Janus allows it, clients should wait
+// retryAfter before sending more
+func NewRateLimitedError(retryAfter time.Duration) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		LimitExceededErrorCode,
+		fmt.Sprintf("limit exceeded: too many requests, retry after %s", retryAfter),
+		LimitExceededData{RetryAfterMs: retryAfter.Milliseconds()},
+	)
+}
+
+// NewTooManyResultsError reports an eth_getLogs query matching more logs than Janus returns at once, clients should
+// narrow its block range
+func NewTooManyResultsError(maxResults int) JSONRPCError {
+	return NewJSONRPCError(LimitExceededErrorCode, fmt.Sprintf("query returned more than %d results", maxResults), nil)
+}
+
+// StartingData tells clients what qtumd is doing while it starts up and when to try again
+type StartingData struct {
+	Status       string `json:"status"`
+	RetryAfterMs int64  `json:"retryAfterMs"`
+}
+
+// NewStartingError reports that qtumd is still loading, e.g. reindexing after a restart, and can't serve requests yet
+func NewStartingError(status string, retryAfter time.Duration) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		ResourceUnavailableErrorCode,
+		fmt.Sprintf("upstream is starting up (%s), retry after %s", status, retryAfter),
+		StartingData{Status: status, RetryAfterMs: retryAfter.Milliseconds()},
+	)
+}
+
+// BlockPrunedData tells indexers from which height qtumd still has blocks
+type BlockPrunedData struct {
+	PruneHeight int64 `json:"pruneHeight"`
+}
+
+// NewBlockPrunedError reports a block qtumd deleted with -prune, it exists but has to be fetched from an archive node
+func NewBlockPrunedError(pruneHeight int64) JSONRPCError {
+	return NewJSONRPCErrorWithData(
+		BlockPrunedErrorCode,
+		fmt.Sprintf("block pruned on upstream, blocks are kept from height %d", pruneHeight),
+		BlockPrunedData{PruneHeight: pruneHeight},
+	)
+}
+
+// NewWalletLockedError reports that qtumd's wallet must be unlocked with its passphrase before it can sign
+func NewWalletLockedError() JSONRPCError {
+	return NewJSONRPCError(UnauthorizedErrorCode, "authentication needed: qtumd's wallet is locked", nil)
+}
+
+// NewAccountLockedError reports that an encrypted account must be unlocked with personal_unlockAccount before it can
+// sign
+func NewAccountLockedError() JSONRPCError {
+	return NewJSONRPCError(UnauthorizedErrorCode, "authentication needed: password or unlock", nil)
+}
+
+// NewUnauthorizedError reports that the request wasn't authenticated well enough for the method it called
+func NewUnauthorizedError(message string) JSONRPCError {
+	return NewJSONRPCError(UnauthorizedErrorCode, "unauthorized: "+message, nil)
+}
+
+// NewTimeoutError reports that a request didn't finish before the deadline the client or server set for it
+func NewTimeoutError(timeout time.Duration) JSONRPCError {
+	return NewJSONRPCError(TimeoutErrorCode, fmt.Sprintf("request timed out after %s", timeout), nil)
+}
+
+// NewExecutionRevertedError reports a reverted call, data is the hex encoded revert output
+func NewExecutionRevertedError(reason string, data string) JSONRPCError {
+	message := "execution reverted"
+	if reason != "" {
+		message += ": " + reason
+	}
+	if data == "" || data == "0x" {
+		return NewJSONRPCError(ExecutionRevertedErrorCode, message, nil)
+	}
+	return NewJSONRPCErrorWithData(ExecutionRevertedErrorCode, message, data)
+}
+
 type JSONRPCError interface {
 	Code() int
 	Message() string
@@ -60,11 +208,21 @@ func NewJSONRPCError(code int, message string, err error) JSONRPCError {
 	}
 }
 
+// NewJSONRPCErrorWithData creates an error with structured details in the data field
+func NewJSONRPCErrorWithData(code int, message string, data interface{}) JSONRPCError {
+	return &GenericJSONRPCError{
+		code:    code,
+		message: message,
+		data:    data,
+	}
+}
+
 // JSONRPCError contains the message and code for an ETH RPC error
 type GenericJSONRPCError struct {
 	code    int
 	message string
 	err     error
+	data    interface{}
 }
 
 func (err *GenericJSONRPCError) Code() int {
@@ -79,12 +237,33 @@ func (err *GenericJSONRPCError) Error() error {
 	return err.err
 }
 
+func (err *GenericJSONRPCError) Data() interface{} {
+	return err.data
+}
+
 func (err *GenericJSONRPCError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
+		Code    int         `json:"code"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data,omitempty"`
 	}{
 		Code:    err.code,
 		Message: err.message,
+		Data:    err.data,
 	})
 }
+
+func (err *GenericJSONRPCError) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		Code    int         `json:"code"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data,omitempty"`
+	}
+	if e := json.Unmarshal(data, &decoded); e != nil {
+		return e
+	}
+	err.code = decoded.Code
+	err.message = decoded.Message
+	err.data = decoded.Data
+	return nil
+}
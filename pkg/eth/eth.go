@@ -16,6 +16,8 @@ type JSONRPCRequest struct {
 	Method  string          `json:"method"`
 	ID      json.RawMessage `json:"id"`
 	Params  json.RawMessage `json:"params"`
+	// janus extension, a deadline for this request such as "1.5s" or milliseconds
+	Timeout json.RawMessage `json:"janus_timeout,omitempty"`
 }
 
 type JSONRPCResult struct {
@@ -25,6 +27,22 @@ type JSONRPCResult struct {
 	ID        json.RawMessage `json:"id,omitempty"`
 }
 
+// UnmarshalJSON decodes the error into a GenericJSONRPCError, so results of batch items can be read back
+func (r *JSONRPCResult) UnmarshalJSON(data []byte) error {
+	type jsonRPCResult JSONRPCResult
+	decoded := struct {
+		*jsonRPCResult
+		Error *GenericJSONRPCError `json:"error,omitempty"`
+	}{jsonRPCResult: (*jsonRPCResult)(r)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Error != nil {
+		r.Error = decoded.Error
+	}
+	return nil
+}
+
 func NewJSONRPCResult(id json.RawMessage, res interface{}) (*JSONRPCResult, error) {
 	rawResult, err := json.Marshal(res)
 	if err != nil {
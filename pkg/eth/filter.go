@@ -1,9 +1,13 @@
 package eth
 
 import (
+	"context"
+	"encoding/json"
 	"math/big"
 	"sync"
 	"sync/atomic"
+
+	"github.com/pkg/errors"
 )
 
 type FilterType int
@@ -25,6 +29,18 @@ type Filter struct {
 type FilterSimulator struct {
 	filters     sync.Map
 	maxFilterID *uint64
+	// when set filters are kept here instead, so any Janus instance sharing it can serve them
+	store FilterStore
+}
+
+// FilterStore keeps filters outside of the process, for Janus instances behind a load balancer
+type FilterStore interface {
+	// NextFilterID returns an ID no instance sharing the store has handed out
+	NextFilterID(ctx context.Context) (uint64, error)
+	SaveFilter(ctx context.Context, state FilterState) error
+	// LoadFilter returns nil when the filter isn't installed
+	LoadFilter(ctx context.Context, id uint64) (*FilterState, error)
+	DeleteFilter(ctx context.Context, id uint64) error
 }
 
 func NewFilterSimulator() *FilterSimulator {
@@ -34,22 +50,205 @@ func NewFilterSimulator() *FilterSimulator {
 	}
 }
 
-func (f *FilterSimulator) New(ty FilterType, req ...interface{}) *Filter {
-	id := atomic.AddUint64(f.maxFilterID, 1)
+// NewSharedFilterSimulator keeps filters in store
+func NewSharedFilterSimulator(store FilterStore) *FilterSimulator {
+	f := NewFilterSimulator()
+	f.store = store
+	return f
+}
+
+// Shared reports whether filters are kept in a FilterStore
+func (f *FilterSimulator) Shared() bool {
+	return f.store != nil
+}
+
+// New creates a filter with a new ID, it is installed once it's saved with Save
+func (f *FilterSimulator) New(ctx context.Context, ty FilterType, req ...interface{}) (*Filter, error) {
+	var id uint64
+	if f.store != nil {
+		var err error
+		if id, err = f.store.NextFilterID(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		id = atomic.AddUint64(f.maxFilterID, 1)
+	}
+
 	filter := &Filter{ID: id, Type: ty}
 	if ty == NewFilterTy {
 		filter.Request = req[0]
 	}
 
-	f.filters.Store(id, filter)
+	return filter, nil
+}
 
-	return filter
+// Save installs a filter or stores the changes to its Data
+func (f *FilterSimulator) Save(ctx context.Context, filter *Filter) error {
+	if f.store == nil {
+		f.filters.Store(filter.ID, filter)
+		return nil
+	}
+	state, err := filter.state()
+	if err != nil {
+		return err
+	}
+	return f.store.SaveFilter(ctx, state)
 }
 
-func (f *FilterSimulator) Uninstall(filterID uint64) {
+func (f *FilterSimulator) Uninstall(ctx context.Context, filterID uint64) error {
+	if f.store != nil {
+		return f.store.DeleteFilter(ctx, filterID)
+	}
 	f.filters.Delete(filterID)
+	return nil
+}
+
+// Filter returns an installed filter, changes to a shared filter need to be saved with Save
+func (f *FilterSimulator) Filter(ctx context.Context, filterID uint64) (*Filter, bool, error) {
+	if f.store == nil {
+		value, ok := f.filters.Load(filterID)
+		if !ok {
+			return nil, false, nil
+		}
+		return value.(*Filter), true, nil
+	}
+
+	state, err := f.store.LoadFilter(ctx, filterID)
+	if err != nil || state == nil {
+		return nil, false, err
+	}
+	filter, err := newFilterFromState(*state)
+	if err != nil {
+		return nil, false, err
+	}
+	return filter, true, nil
+}
+
+// FilterSnapshot is the state of every installed filter, used to hand filters over to another Janus instance
+type FilterSnapshot struct {
+	MaxFilterID uint64        `json:"maxFilterId"`
+	Filters     []FilterState `json:"filters"`
+}
+
+type FilterState struct {
+	ID   uint64     `json:"id"`
+	Type FilterType `json:"type"`
+	// the eth_newFilter params of log filters
+	Request json.RawMessage `json:"request,omitempty"`
+	// the uint64 values in Filter.Data, like lastBlockNumber and toBlock
+	Data map[string]uint64 `json:"data,omitempty"`
 }
 
-func (f *FilterSimulator) Filter(filterID uint64) (value interface{}, ok bool) {
-	return f.filters.Load(filterID)
+func (filter *Filter) state() (FilterState, error) {
+	state := FilterState{ID: filter.ID, Type: filter.Type, Data: make(map[string]uint64)}
+	if request, ok := filter.Request.(*NewFilterRequest); ok {
+		var err error
+		if state.Request, err = json.Marshal([]interface{}{request}); err != nil {
+			return state, errors.Wrapf(err, "couldn't marshal filter %d", filter.ID)
+		}
+	}
+	filter.Data.Range(func(key, value interface{}) bool {
+		name, isString := key.(string)
+		number, isNumber := value.(uint64)
+		if isString && isNumber {
+			state.Data[name] = number
+		}
+		return true
+	})
+	return state, nil
+}
+
+func newFilterFromState(state FilterState) (*Filter, error) {
+	filter := &Filter{ID: state.ID, Type: state.Type}
+	if state.Type == NewFilterTy {
+		var request NewFilterRequest
+		if err := json.Unmarshal(state.Request, &request); err != nil {
+			return nil, errors.Wrapf(err, "filter %d has an invalid request", state.ID)
+		}
+		filter.Request = &request
+	}
+	for name, number := range state.Data {
+		filter.Data.Store(name, number)
+	}
+	return filter, nil
+}
+
+// Snapshot returns the installed filters, filters kept in a FilterStore are already shared and can't be snapshotted
+func (f *FilterSimulator) Snapshot() (FilterSnapshot, error) {
+	snapshot := FilterSnapshot{
+		MaxFilterID: atomic.LoadUint64(f.maxFilterID),
+		Filters:     []FilterState{},
+	}
+	if f.store != nil {
+		return snapshot, errors.New("shared filters can't be snapshotted")
+	}
+	var err error
+	f.filters.Range(func(_, value interface{}) bool {
+		var state FilterState
+		if state, err = value.(*Filter).state(); err != nil {
+			return false
+		}
+		snapshot.Filters = append(snapshot.Filters, state)
+		return true
+	})
+	return snapshot, err
+}
+
+// Restore replaces the installed filters with a snapshot, filter IDs handed out afterwards continue from the snapshot's
+func (f *FilterSimulator) Restore(snapshot FilterSnapshot) error {
+	if f.store != nil {
+		return errors.New("shared filters can't be restored")
+	}
+	filters := make([]*Filter, 0, len(snapshot.Filters))
+	for _, state := range snapshot.Filters {
+		if state.ID > snapshot.MaxFilterID {
+			return errors.Errorf("filter %d is past the max filter id %d", state.ID, snapshot.MaxFilterID)
+		}
+		filter, err := newFilterFromState(state)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+
+	f.filters.Range(func(key, _ interface{}) bool {
+		f.filters.Delete(key)
+		return true
+	})
+	for _, filter := range filters {
+		f.filters.Store(filter.ID, filter)
+	}
+	atomic.StoreUint64(f.maxFilterID, snapshot.MaxFilterID)
+	return nil
+}
+
+// MarshalJSON keeps snapshots readable when filters are handed over between Janus versions
+func (ty FilterType) MarshalJSON() ([]byte, error) {
+	switch ty {
+	case NewFilterTy:
+		return json.Marshal("logs")
+	case NewBlockFilterTy:
+		return json.Marshal("blocks")
+	case NewPendingTransactionFilterTy:
+		return json.Marshal("pendingTransactions")
+	}
+	return nil, errors.Errorf("unknown filter type %d", int(ty))
+}
+
+func (ty *FilterType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	switch name {
+	case "logs":
+		*ty = NewFilterTy
+	case "blocks":
+		*ty = NewBlockFilterTy
+	case "pendingTransactions":
+		*ty = NewPendingTransactionFilterTy
+	default:
+		return errors.Errorf("unknown filter type %q", name)
+	}
+	return nil
 }
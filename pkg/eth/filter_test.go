@@ -0,0 +1,102 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+type mapFilterStore struct {
+	mutex   sync.Mutex
+	lastID  uint64
+	filters map[uint64][]byte
+}
+
+func (s *mapFilterStore) NextFilterID(ctx context.Context) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastID++
+	return s.lastID, nil
+}
+
+func (s *mapFilterStore) SaveFilter(ctx context.Context, state FilterState) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.filters[state.ID] = encoded
+	return nil
+}
+
+func (s *mapFilterStore) LoadFilter(ctx context.Context, id uint64) (*FilterState, error) {
+	s.mutex.Lock()
+	encoded, ok := s.filters[id]
+	s.mutex.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	var state FilterState
+	return &state, json.Unmarshal(encoded, &state)
+}
+
+func (s *mapFilterStore) DeleteFilter(ctx context.Context, id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.filters, id)
+	return nil
+}
+
+func TestSharedFilters(t *testing.T) {
+	ctx := context.Background()
+	store := &mapFilterStore{filters: make(map[uint64][]byte)}
+	first := NewSharedFilterSimulator(store)
+	second := NewSharedFilterSimulator(store)
+
+	request := &NewFilterRequest{
+		Address: json.RawMessage(`"0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"`),
+		Topics:  []interface{}{nil, []interface{}{"0xaa", "0xbb"}},
+	}
+	created, err := first.New(ctx, NewFilterTy, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := second.Filter(ctx, created.ID); ok {
+		t.Fatal("filter is installed before being saved")
+	}
+	created.Data.Store("lastBlockNumber", uint64(10))
+	if err := first.Save(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, ok, err := second.Filter(ctx, created.ID)
+	if err != nil || !ok {
+		t.Fatalf("filter created on another instance not found: %v", err)
+	}
+	got := filter.Request.(*NewFilterRequest)
+	if string(got.Address) != string(request.Address) || len(got.Topics) != 2 {
+		t.Errorf("unexpected request %+v", got)
+	}
+	filter.Data.Store("lastBlockNumber", uint64(12))
+	if err := second.Save(ctx, filter); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, _, _ = first.Filter(ctx, created.ID)
+	if lastBlockNumber, _ := filter.Data.Load("lastBlockNumber"); lastBlockNumber != uint64(12) {
+		t.Errorf("cursor moved on another instance not shared, got %v", lastBlockNumber)
+	}
+
+	if other, _ := second.New(ctx, NewBlockFilterTy); other.ID == created.ID {
+		t.Errorf("instances handed out the same filter id %d", other.ID)
+	}
+
+	if err := second.Uninstall(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := first.Filter(ctx, created.ID); ok {
+		t.Error("filter uninstalled on another instance is still installed")
+	}
+}
@@ -0,0 +1,174 @@
+package eth
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// a log has at most 4 topics, geth rejects filters with more positions
+const MaxFilterTopics = 4
+
+var ErrTooManyTopics = errors.New("too many topics, a log has at most 4")
+
+// ParseFilterTopics parses the topics of eth_getLogs, eth_newFilter and logs subscriptions the way geth does
+//
+// Each position is null (anything), a topic, or a list of topics one of which has to be in that position.
+// An empty list or a null inside a list also accepts anything in that position.
+// The result is lower case hex without 0x prefix, nil positions accept anything.
+func ParseFilterTopics(ethTopics []interface{}) ([][]string, error) {
+	if len(ethTopics) > MaxFilterTopics {
+		return nil, ErrTooManyTopics
+	}
+
+	topics := make([][]string, len(ethTopics))
+	for i, position := range ethTopics {
+		switch position := position.(type) {
+		case nil:
+		case string:
+			topic, err := parseFilterTopic(position)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = []string{topic}
+		case []string:
+			or, err := parseFilterTopicList(stringsToInterfaces(position))
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = or
+		case []interface{}:
+			or, err := parseFilterTopicList(position)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = or
+		default:
+			return nil, errors.Wrapf(ErrInvalidTopics, "unexpected %T in position %d", position, i)
+		}
+	}
+
+	return topics, nil
+}
+
+func parseFilterTopicList(list []interface{}) ([]string, error) {
+	var or []string
+	for _, item := range list {
+		if item == nil {
+			// null component, match all
+			return nil, nil
+		}
+		str, ok := item.(string)
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidTopics, "unexpected %T in topic list", item)
+		}
+		topic, err := parseFilterTopic(str)
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, topic)
+	}
+	return or, nil
+}
+
+func parseFilterTopic(topic string) (string, error) {
+	topic = strings.ToLower(utils.RemoveHexPrefix(topic))
+	if b, err := hex.DecodeString(topic); err != nil || len(b) != 32 {
+		return "", errors.Wrapf(ErrInvalidTopics, "%q is not a 32 byte hex string", topic)
+	}
+	return topic, nil
+}
+
+func stringsToInterfaces(strs []string) []interface{} {
+	result := make([]interface{}, len(strs))
+	for i, str := range strs {
+		result[i] = str
+	}
+	return result
+}
+
+// ParseFilterAddresses parses the address field of a log filter, which is null, an address or a list of addresses
+// The result is lower case hex without 0x prefix, an empty result accepts any address.
+func ParseFilterAddresses(address interface{}) ([]string, error) {
+	var list []interface{}
+	switch address := address.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		list = []interface{}{address}
+	case []string:
+		list = stringsToInterfaces(address)
+	case []interface{}:
+		list = address
+	default:
+		return nil, ErrInvalidAddresses
+	}
+
+	addresses := make([]string, 0, len(list))
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, ErrInvalidAddresses
+		}
+		str = strings.ToLower(utils.RemoveHexPrefix(str))
+		if b, err := hex.DecodeString(str); err != nil || len(b) != 20 {
+			return nil, errors.Wrapf(ErrInvalidAddresses, "%q is not a 20 byte hex string", str)
+		}
+		addresses = append(addresses, str)
+	}
+	return addresses, nil
+}
+
+// ParseFilterAddressesJSON is ParseFilterAddresses for a raw address field
+func ParseFilterAddressesJSON(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var address interface{}
+	if err := json.Unmarshal(raw, &address); err != nil {
+		return nil, err
+	}
+	return ParseFilterAddresses(address)
+}
+
+// MatchLogTopics reports whether a log's topics match parsed filter topics, following geth's rules:
+// the log needs at least as many topics as the filter has positions and every position that isn't a wildcard has to match
+func MatchLogTopics(filter [][]string, topics []string) bool {
+	if len(filter) > len(topics) {
+		return false
+	}
+	for i, or := range filter {
+		if len(or) == 0 {
+			continue
+		}
+		topic := strings.ToLower(utils.RemoveHexPrefix(topics[i]))
+		match := false
+		for _, want := range or {
+			if strings.ToLower(utils.RemoveHexPrefix(want)) == topic {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLogAddress reports whether a log's address is one of the parsed filter addresses, no addresses accepts anything
+func MatchLogAddress(addresses []string, address string) bool {
+	if len(addresses) == 0 {
+		return true
+	}
+	address = strings.ToLower(utils.RemoveHexPrefix(address))
+	for _, want := range addresses {
+		if strings.ToLower(utils.RemoveHexPrefix(want)) == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package eth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	topicA = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	topicB = "0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712"
+	topicC = "0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3713"
+)
+
+// the topic filter examples from the eth_newFilter spec plus the edge cases geth handles, for a log with topics [A, B]
+func TestMatchLogTopicsConformance(t *testing.T) {
+	log := []string{topicA[2:], topicB[2:]}
+
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{`[]`, true},
+		{`null`, true},
+		{`["` + topicA + `"]`, true},
+		{`["` + topicB + `"]`, false},
+		{`[null, "` + topicB + `"]`, true},
+		{`[null, "` + topicC + `"]`, false},
+		{`["` + topicA + `", "` + topicB + `"]`, true},
+		{`[["` + topicA + `", "` + topicB + `"], ["` + topicA + `", "` + topicB + `"]]`, true},
+		{`[["` + topicB + `", "` + topicC + `"]]`, false},
+		{`[["` + topicC + `", null], "` + topicB + `"]`, true},
+		{`[[], "` + topicB + `"]`, true},
+		{`[null, null]`, true},
+		// the log needs at least as many topics as there are positions
+		{`[null, null, null]`, false},
+		{`["` + topicA + `", "` + topicB + `", null]`, false},
+		// topics are compared without case or 0x prefix
+		{`["0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"]`, true},
+		{`["` + topicA[2:] + `"]`, true},
+	}
+
+	for _, test := range tests {
+		var topics []interface{}
+		if err := json.Unmarshal([]byte(test.filter), &topics); err != nil {
+			t.Fatal(err)
+		}
+		filter, err := ParseFilterTopics(topics)
+		if err != nil {
+			t.Fatalf("%s: %v", test.filter, err)
+		}
+		if got := MatchLogTopics(filter, log); got != test.match {
+			t.Errorf("%s: got %v, want %v", test.filter, got, test.match)
+		}
+	}
+}
+
+func TestParseFilterTopicsRejectsInvalidTopics(t *testing.T) {
+	tests := map[string]error{
+		`["a topic"]`:                         ErrInvalidTopics,
+		`["0xddf252ad"]`:                      ErrInvalidTopics,
+		`[1]`:                                 ErrInvalidTopics,
+		`[[1]]`:                               ErrInvalidTopics,
+		`[[["` + topicA + `"]]]`:              ErrInvalidTopics,
+		`[null, null, null, null, null]`:      ErrTooManyTopics,
+		`[{"topic": "` + topicA + `"}, null]`: ErrInvalidTopics,
+	}
+
+	for filter, want := range tests {
+		var topics []interface{}
+		if err := json.Unmarshal([]byte(filter), &topics); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseFilterTopics(topics); errors.Cause(err) != want {
+			t.Errorf("%s: got %v, want %v", filter, err, want)
+		}
+	}
+}
+
+func TestParseFilterAddresses(t *testing.T) {
+	tests := []struct {
+		address string
+		want    []string
+	}{
+		{`null`, nil},
+		{`"0xDB46F738BF32CDAFB9A4A70EB8B44C76646BCAF0"`, []string{"db46f738bf32cdafb9a4a70eb8b44c76646bcaf0"}},
+		{`["0xdb46f738bf32cdafb9a4a70eb8b44c76646bcaf0", "6b22910b1e302cf74803ffd1691c2ecb858d3712"]`, []string{"db46f738bf32cdafb9a4a70eb8b44c76646bcaf0", "6b22910b1e302cf74803ffd1691c2ecb858d3712"}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseFilterAddressesJSON(json.RawMessage(test.address))
+		if err != nil {
+			t.Fatalf("%s: %v", test.address, err)
+		}
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: got %v, want %v", test.address, got, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.address, got, test.want)
+			}
+		}
+		if !MatchLogAddress(got, "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0") {
+			t.Errorf("%s: expected a match", test.address)
+		}
+	}
+
+	for _, address := range []string{`"0xdb46f738"`, `[1]`, `["not an address"]`, `1`} {
+		if _, err := ParseFilterAddressesJSON(json.RawMessage(address)); errors.Cause(err) != ErrInvalidAddresses {
+			t.Errorf("%s: got %v, want %v", address, err, ErrInvalidAddresses)
+		}
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/pkg/errors"
 	"github.com/qtumproject/janus/pkg/utils"
 	"github.com/shopspring/decimal"
@@ -107,6 +110,33 @@ type CallRequest struct {
 	GasPrice *ETHInt `json:"gasPrice"` // optional
 	Value    string  `json:"value"`    // optional
 	Data     string  `json:"data"`     // optional
+
+	// the second parameter, optional
+	Block json.RawMessage `json:"-"`
+	// the third parameter, optional
+	Overrides StateOverride `json:"-"`
+}
+
+// StateOverride replaces parts of the state of accounts, by address, for a single call
+type StateOverride map[string]OverrideAccount
+
+// OverrideAccount is the state of an account geth lets a call override, nil fields are left as they are. State
+// replaces the whole storage, StateDiff only the given slots
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64   `json:"nonce"`
+	Code      *hexutil.Bytes    `json:"code"`
+	Balance   *hexutil.Big      `json:"balance"`
+	State     map[string]string `json:"state"`
+	StateDiff map[string]string `json:"stateDiff"`
+}
+
+func (o StateOverride) validate() error {
+	for address, account := range o {
+		if account.State != nil && account.StateDiff != nil {
+			return errors.Errorf("account %s has both 'state' and 'stateDiff'", address)
+		}
+	}
+	return nil
 }
 
 func (t *CallRequest) GasHex() string {
@@ -141,6 +171,17 @@ func (t *CallRequest) UnmarshalJSON(data []byte) error {
 	}
 
 	cr := CallRequest(obj)
+	if len(params) > 1 {
+		cr.Block = params[1]
+	}
+	if len(params) > 2 && string(params[2]) != "null" {
+		if err = json.Unmarshal(params[2], &cr.Overrides); err != nil {
+			return errors.Wrap(err, "invalid state override")
+		}
+		if err = cr.Overrides.validate(); err != nil {
+			return err
+		}
+	}
 	*t = cr
 	return nil
 }
@@ -153,6 +194,103 @@ type (
 	MiningResponse                bool
 )
 
+// ========== personal_unlockAccount ============= //
+
+// PersonalUnlockAccountRequest unlocks an account for Duration seconds, the keystore's unlock timeout when nil and
+// until Janus exits when 0
+type PersonalUnlockAccountRequest struct {
+	Account    string
+	Passphrase string
+	Duration   *uint64
+}
+
+func (r *PersonalUnlockAccountRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 2 || len(params) > 3 {
+		return errors.New("expects 2 or 3 arguments")
+	}
+	if err := json.Unmarshal(params[0], &r.Account); err != nil {
+		return errors.New("account address should be a hex string")
+	}
+	if err := json.Unmarshal(params[1], &r.Passphrase); err != nil {
+		return errors.New("passphrase should be a string")
+	}
+	if len(params) == 3 {
+		if err := json.Unmarshal(params[2], &r.Duration); err != nil {
+			return errors.New("duration should be a number of seconds")
+		}
+	}
+	return nil
+}
+
+// ========== personal_sign ============= //
+
+// PersonalSignRequest signs Message with the key of Account decrypted with Passphrase, the order of the message and
+// account is the reverse of eth_sign
+type PersonalSignRequest struct {
+	SignRequest
+	Passphrase string
+}
+
+func (r *PersonalSignRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 2 || len(params) > 3 {
+		return errors.New("expects 2 or 3 arguments")
+	}
+	// the message and account are decoded as eth_sign decodes them
+	signParams, err := json.Marshal([]json.RawMessage{params[1], params[0]})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(signParams, &r.SignRequest); err != nil {
+		return err
+	}
+	if len(params) == 3 {
+		if err := json.Unmarshal(params[2], &r.Passphrase); err != nil {
+			return errors.New("passphrase should be a string")
+		}
+	}
+	return nil
+}
+
+type PersonalListAccountsResponse []string
+
+// ========== personal_sendTransaction ============= //
+
+// PersonalSendTransactionRequest sends Transaction from an account whose key is decrypted with Passphrase
+type PersonalSendTransactionRequest struct {
+	Transaction SendTransactionRequest
+	Passphrase  string
+}
+
+func (r *PersonalSendTransactionRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 2 {
+		return errors.New("expects 2 arguments")
+	}
+	// the transaction is decoded as eth_sendTransaction decodes it
+	transactionParams, err := json.Marshal(params[:1])
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(transactionParams, &r.Transaction); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(params[1], &r.Passphrase); err != nil {
+		return errors.New("passphrase should be a string")
+	}
+	return nil
+}
+
 // ========== eth_sign ============= //
 
 type (
@@ -200,6 +338,56 @@ func (t *SignRequest) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// ========== eth_signTypedData_v4 ============= //
+
+// SignTypedDataRequest signs the EIP-712 hash of TypedData with the key of Account, the typed data can be given as an
+// object or as its JSON encoded in a string like MetaMask does
+type SignTypedDataRequest struct {
+	Account   string
+	TypedData apitypes.TypedData
+}
+
+func (r *SignTypedDataRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 2 {
+		return errors.New("expects 2 arguments")
+	}
+	if err := json.Unmarshal(params[0], &r.Account); err != nil {
+		return errors.New("account address should be a hex string")
+	}
+
+	typedData := params[1]
+	var encoded string
+	if err := json.Unmarshal(typedData, &encoded); err == nil {
+		typedData = json.RawMessage(encoded)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(typedData, &fields); err != nil {
+		return errors.New("typed data should be an object")
+	}
+	// clients send the chain id as a number, which the domain only decodes from a string
+	var domain map[string]json.RawMessage
+	if err := json.Unmarshal(fields["domain"], &domain); err == nil {
+		if chainId, ok := domain["chainId"]; ok && len(chainId) > 0 && chainId[0] != '"' && string(chainId) != "null" {
+			domain["chainId"], _ = json.Marshal(string(chainId))
+			if fields["domain"], err = json.Marshal(domain); err != nil {
+				return err
+			}
+		}
+	}
+	typedData, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(typedData, &r.TypedData); err != nil {
+		return errors.Wrap(err, "invalid typed data")
+	}
+	return nil
+}
+
 // ========== GetLogs ============= //
 
 type (
@@ -275,6 +463,28 @@ type (
 	}
 )
 
+// MarshalJSON writes the block fields of a pending transaction as null like geth does, wallets tell pending transactions
+// apart by them
+func (r GetTransactionByHashResponse) MarshalJSON() ([]byte, error) {
+	type response GetTransactionByHashResponse
+	tx := struct {
+		response
+		BlockHash        *string `json:"blockHash"`
+		BlockNumber      *string `json:"blockNumber"`
+		TransactionIndex *string `json:"transactionIndex"`
+	}{response: response(r)}
+	if r.BlockHash != "" {
+		tx.BlockHash = &r.BlockHash
+	}
+	if r.BlockNumber != "" {
+		tx.BlockNumber = &r.BlockNumber
+	}
+	if r.TransactionIndex != "" {
+		tx.TransactionIndex = &r.TransactionIndex
+	}
+	return json.Marshal(tx)
+}
+
 func (r *GetTransactionByHashRequest) UnmarshalJSON(data []byte) error {
 	var params []interface{}
 	if err := json.Unmarshal(data, &params); err != nil {
@@ -332,7 +542,7 @@ func (r *GetTransactionByBlockHashAndIndex) UnmarshalJSON(data []byte) error {
 // ========== GetTransactionByBlockNumberAndIndex ========== //
 
 type GetTransactionByBlockNumberAndIndex struct {
-	BlockNumber      string
+	BlockNumber      json.RawMessage
 	TransactionIndex string
 }
 
@@ -350,9 +560,9 @@ func (r *GetTransactionByBlockNumberAndIndex) UnmarshalJSON(data []byte) error {
 		return errors.Errorf("too many arguments, want at most 2")
 	}
 
-	blockNumber, ok := params[0].(string)
-	if !ok {
-		return newErrInvalidParameterType(1, params[0], "")
+	blockNumber, err := json.Marshal(params[0])
+	if err != nil {
+		return err
 	}
 	r.BlockNumber = blockNumber
 
@@ -378,7 +588,7 @@ type (
 		From             string `json:"from,omitempty"`   // DATA, 20 Bytes - address of the sender.
 		// NOTE: must be null if it's a contract creation transaction
 		To                string `json:"to,omitempty"` // DATA, 20 Bytes - address of the receiver. null when its a contract creation transaction.
-		EffectiveGasPrice string `json:"effectiveGasPrice"`
+		EffectiveGasPrice string `json:"effectiveGasPrice"` // QUANTITY - The gas price paid per unit of gas.
 		CumulativeGasUsed string `json:"cumulativeGasUsed"` // QUANTITY - The total amount of gas used when this transaction was executed in the block.
 		GasUsed           string `json:"gasUsed"`           // QUANTITY - The amount of gas used by this specific transaction alone.
 		// NOTE: must be null if it's NOT a contract creation transaction
@@ -386,6 +596,13 @@ type (
 		Logs            []Log  `json:"logs"`                      // Array - Array of log objects, which this transaction generated.
 		LogsBloom       string `json:"logsBloom"`                 // DATA, 256 Bytes - Bloom filter for light clients to quickly retrieve related logs.
 		Status          string `json:"status"`                    // QUANTITY either 1 (success) or 0 (failure)
+		Type            string `json:"type"`                      // QUANTITY - The transaction type, 0x0 for legacy transactions.
+
+		// Qtum extensions for contract transactions, amounts in wei
+		// the fee is paid up front for the whole gas limit, unused gas is refunded to the sender by the block's coinstake
+		QtumFee    string `json:"qtumFee,omitempty"`    // QUANTITY - inputs minus outputs of the transaction
+		QtumRefund string `json:"qtumRefund,omitempty"` // QUANTITY - refund of unused gas
+		QtumCost   string `json:"qtumCost,omitempty"`   // QUANTITY - fee minus refund
 
 		// TODO: researching
 		// ? Do we need this value
@@ -428,14 +645,14 @@ type AccountsResponse []string
 type (
 	GetCodeRequest struct {
 		Address     string
-		BlockNumber string
+		BlockNumber json.RawMessage
 	}
 	// the code from the given address.
 	GetCodeResponse string
 )
 
 func (r *GetCodeRequest) UnmarshalJSON(data []byte) error {
-	var params []string
+	var params []json.RawMessage
 	err := json.Unmarshal(data, &params)
 	if err != nil {
 		return errors.Wrap(err, "json unmarshalling")
@@ -445,7 +662,9 @@ func (r *GetCodeRequest) UnmarshalJSON(data []byte) error {
 		return errors.New("params must be set")
 	}
 
-	r.Address = params[0]
+	if err := json.Unmarshal(params[0], &r.Address); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
 	if len(params) > 1 {
 		r.BlockNumber = params[1]
 	}
@@ -600,7 +819,7 @@ type (
 )
 
 func (r *GetBlockByNumberRequest) UnmarshalJSON(data []byte) error {
-	var params []interface{}
+	var params []json.RawMessage
 	if err := json.Unmarshal(data, &params); err != nil {
 		return errors.Wrap(err, "couldn't unmarhsal data")
 	}
@@ -608,18 +827,12 @@ func (r *GetBlockByNumberRequest) UnmarshalJSON(data []byte) error {
 		return errors.Errorf("invalid parameters number - %d/2", paramsNum)
 	}
 
-	blockNumber, ok := params[0].(string)
-	if !ok {
-		return newErrInvalidParameterType(1, params[0], "")
-	}
-	// TODO: think of changing []byte type to string type
-	r.BlockNumber = json.RawMessage(fmt.Sprintf("\"%s\"", blockNumber))
+	// resolved by the proxy, which accepts anything eth.BlockParam does
+	r.BlockNumber = params[0]
 
-	fullTxWanted, ok := params[1].(bool)
-	if !ok {
-		return newErrInvalidParameterType(2, params[1], false)
+	if err := json.Unmarshal(params[1], &r.FullTransaction); err != nil {
+		return errors.Wrap(err, "invalid argument 1")
 	}
-	r.FullTransaction = fullTxWanted
 
 	return nil
 }
@@ -868,27 +1081,264 @@ func (r *GetBalanceRequest) UnmarshalJSON(data []byte) error {
 
 type GetBalanceResponse string
 
+// ======= janus_getBalanceDetail ======= //
+type (
+	GetBalanceDetailRequest = GetBalanceRequest
+
+	// amounts are in wei
+	GetBalanceDetailResponse struct {
+		// confirmed balance, including immature staking rewards
+		Total string `json:"total"`
+		// confirmed balance that can be spent now
+		Spendable string `json:"spendable"`
+		// staking and coinbase rewards that are not mature yet
+		Immature string `json:"immature"`
+		// received by transactions in the mempool, change outputs included
+		UnconfirmedIncoming string `json:"unconfirmedIncoming"`
+		// spent by transactions in the mempool
+		UnconfirmedOutgoing string `json:"unconfirmedOutgoing"`
+	}
+)
+
+// ======= janus_listAccountsDetailed ======= //
+type (
+	AccountDetail struct {
+		Address       string `json:"address"`
+		Base58Address string `json:"base58Address"`
+		// assigned by the operator in the accounts file, empty when there is none
+		Label string `json:"label"`
+		// sum of the account's unspent outputs in wei
+		Balance   string `json:"balance"`
+		UTXOCount string `json:"utxoCount"`
+	}
+
+	ListAccountsDetailedResponse []AccountDetail
+)
+
+// ======= dev_setIntervalMining ======= //
+type MiningStatusResponse struct {
+	// seconds between mined blocks, 0 when blocks are only mined after transactions
+	Interval float64 `json:"interval"`
+	Blocks   int     `json:"blocks"`
+	Paused   bool    `json:"paused"`
+}
+
+// ======= dev_setBlockTime ======= //
+type SetBlockTimeResponse struct {
+	BlockHash   string `json:"blockHash"`
+	BlockNumber string `json:"blockNumber"`
+	// the block's timestamp, later than requested when the chain's median time past is
+	Timestamp string `json:"timestamp"`
+}
+
+// ======= dev_dumpState / dev_loadState ======= //
+type (
+	// StateFixture is the part of a regtest chain's state dev_loadState can recreate on another chain
+	StateFixture struct {
+		ChainID     string          `json:"chainId"`
+		BlockNumber string          `json:"blockNumber"`
+		Accounts    []StateAccount  `json:"accounts"`
+		Contracts   []StateContract `json:"contracts"`
+	}
+
+	StateAccount struct {
+		Address string `json:"address"`
+		// in wei
+		Balance string `json:"balance"`
+	}
+
+	StateContract struct {
+		Address string `json:"address"`
+		// in wei
+		Balance string `json:"balance"`
+		// runtime code
+		Code string `json:"code"`
+		// non zero storage slots, by slot
+		Storage map[string]string `json:"storage"`
+	}
+
+	LoadStateRequest struct {
+		Fixture StateFixture
+	}
+
+	LoadStateResponse struct {
+		BlockNumber string `json:"blockNumber"`
+		// accounts mined to until they had their balance
+		FundedAccounts []string `json:"fundedAccounts"`
+		// accounts Janus doesn't have the keys of, they are not funded
+		SkippedAccounts []string         `json:"skippedAccounts"`
+		Contracts       []LoadedContract `json:"contracts"`
+	}
+
+	// LoadedContract maps a contract of the fixture to its copy, contract addresses depend on the creating transaction
+	// on Qtum so copies can't keep their address
+	LoadedContract struct {
+		Address         string `json:"address"`
+		NewAddress      string `json:"newAddress"`
+		TransactionHash string `json:"transactionHash"`
+		// the transaction restoring the contract's balance, empty when it has none
+		BalanceTransactionHash string `json:"balanceTransactionHash,omitempty"`
+	}
+)
+
+func (r *LoadStateRequest) UnmarshalJSON(data []byte) error {
+	tmp := []interface{}{&r.Fixture}
+	return json.Unmarshal(data, &tmp)
+}
+
+// ======= janus_getTransactionCost ======= //
+type (
+	GetTransactionCostRequest = GetTransactionReceiptRequest
+
+	// amounts are in wei
+	GetTransactionCostResponse struct {
+		TransactionHash string `json:"transactionHash"`
+		BlockNumber     string `json:"blockNumber"`
+		// gas limit and price of the contract call, zero when the transaction doesn't call a contract
+		GasLimit string `json:"gasLimit"`
+		GasPrice string `json:"gasPrice"`
+		GasUsed  string `json:"gasUsed"`
+		// gas limit minus gas used
+		GasRefunded string `json:"gasRefunded"`
+		// inputs minus outputs, this covers the whole gas limit
+		Fee string `json:"fee"`
+		// paid back to the sender for the unused gas by an output of the block's coinstake
+		Refund string `json:"refund"`
+		// what the transaction actually cost the sender, fee minus refund
+		Cost string `json:"cost"`
+	}
+)
+
+// ======= janus_deployContract ======= //
+type (
+	// [bytecode, abi, args, options], everything but the bytecode is optional
+	DeployContractRequest struct {
+		Bytecode string
+		// the contract's ABI as a JSON array, or a string containing it
+		ABI json.RawMessage
+		// constructor arguments, numbers can be given as JSON numbers or as decimal or hex strings
+		Args    []json.RawMessage
+		Options DeployContractOptions
+	}
+
+	DeployContractOptions struct {
+		From     string  `json:"from"`
+		Gas      *ETHInt `json:"gas"`
+		GasPrice *ETHInt `json:"gasPrice"`
+	}
+
+	DeployContractResponse struct {
+		TransactionHash string `json:"transactionHash"`
+		// address the contract is created at once the transaction is mined
+		ContractAddress string `json:"contractAddress"`
+		// gas limit the contract was sent with
+		Gas string `json:"gas"`
+	}
+)
+
+func (r *DeployContractRequest) UnmarshalJSON(data []byte) error {
+	tmp := []interface{}{&r.Bytecode, &r.ABI, &r.Args, &r.Options}
+
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	if r.Bytecode == "" {
+		return errors.New("bytecode cannot be empty")
+	}
+	return nil
+}
+
+// ======= janus_computeContractAddress ======= //
+type (
+	// [txid, vout], vout is the index of the OP_CREATE output and defaults to 0 which is what createcontract uses
+	ComputeContractAddressRequest struct {
+		TxID string
+		Vout uint32
+	}
+
+	ComputeContractAddressResponse string
+)
+
+func (r *ComputeContractAddressRequest) UnmarshalJSON(data []byte) error {
+	var vout json.RawMessage
+	tmp := []interface{}{&r.TxID, &vout}
+
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	if len(vout) == 0 || string(vout) == "null" {
+		return nil
+	}
+
+	var voutHex string
+	if err := json.Unmarshal(vout, &voutHex); err == nil {
+		n, err := hexutil.DecodeUint64(voutHex)
+		if err != nil || n > math.MaxUint32 {
+			return errors.Errorf("invalid vout %s", voutHex)
+		}
+		r.Vout = uint32(n)
+		return nil
+	}
+	return json.Unmarshal(vout, &r.Vout)
+}
+
 // =======GetTransactionCount ============= //
 type (
 	GetTransactionCountRequest struct {
 		Address string
-		Tag     string
+		// resolved by the proxy, which accepts anything eth.BlockParam does
+		Block json.RawMessage
 	}
 )
 
+func (r *GetTransactionCountRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) > 2 {
+		return errors.Errorf("invalid parameters number - %d/2", len(params))
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params[0], &r.Address); err != nil {
+			return errors.Wrap(err, "invalid address")
+		}
+	}
+	if len(params) > 1 {
+		r.Block = params[1]
+	}
+	return nil
+}
+
 // ========== getstorage ============= //
 type (
 	GetStorageRequest struct {
 		Address     string
-		Index       string
-		BlockNumber string
+		Index       string // hex, positions given as JSON numbers are decimal and converted
+		BlockNumber json.RawMessage
 	}
 	GetStorageResponse string
 )
 
 func (r *GetStorageRequest) UnmarshalJSON(data []byte) error {
-	tmp := []interface{}{&r.Address, &r.Index, &r.BlockNumber}
-	return json.Unmarshal(data, &tmp)
+	var index json.RawMessage
+	tmp := []interface{}{&r.Address, &index, &r.BlockNumber}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	var position json.Number
+	if err := json.Unmarshal(index, &r.Index); err == nil {
+		return nil
+	} else if json.Unmarshal(index, &position) != nil {
+		return err
+	}
+	decimal, ok := new(big.Int).SetString(position.String(), 10)
+	if !ok || decimal.Sign() < 0 {
+		return fmt.Errorf("invalid storage position %s", position)
+	}
+	r.Index = hexutil.EncodeBig(decimal)
+	return nil
 }
 
 // ======= eth_chainId ============= //
@@ -1137,3 +1587,561 @@ func (r *Web3Sha3Request) UnmarshalJSON(data []byte) error {
 }
 
 type NetPeerCountResponse string
+
+// ======= janus_peers / admin_peers ======= //
+type (
+	// Peer is geth's admin_peers peer object without enode fields, qtumd peers don't have an enode
+	Peer struct {
+		// qtumd peer index
+		ID string `json:"id"`
+		// user agent, such as /Satoshi:0.20.1/
+		Name      string        `json:"name"`
+		Network   PeerNetwork   `json:"network"`
+		Protocols PeerProtocols `json:"protocols"`
+	}
+
+	PeerNetwork struct {
+		LocalAddress  string `json:"localAddress"`
+		RemoteAddress string `json:"remoteAddress"`
+		Inbound       bool   `json:"inbound"`
+		// connected with addnode/-connect
+		Static bool `json:"static"`
+		// whitelisted
+		Trusted bool `json:"trusted"`
+	}
+
+	PeerProtocols struct {
+		Qtum PeerQtumProtocol `json:"qtum"`
+	}
+
+	PeerQtumProtocol struct {
+		// protocol version, such as 70017
+		Version        int64  `json:"version"`
+		StartingHeight uint64 `json:"startingHeight"`
+		SyncedHeaders  int64  `json:"syncedHeaders"`
+		SyncedBlocks   int64  `json:"syncedBlocks"`
+		// last ping round trip in milliseconds, 0 if unknown
+		Latency        int64  `json:"latency"`
+		ConnectionTime uint64 `json:"connectionTime"`
+		BytesSent      uint64 `json:"bytesSent"`
+		BytesReceived  uint64 `json:"bytesReceived"`
+	}
+
+	PeersResponse []Peer
+)
+
+// ======= janus_getNetworkContracts ======= //
+type GetNetworkContractsResponse struct {
+	// the chain of qtumd, main, test or regtest
+	Chain string `json:"chain"`
+	// hex addresses by name
+	Contracts map[string]string `json:"contracts"`
+}
+
+// ======= janus_listFailedTransactions ======= //
+type (
+	// [all], all includes the transactions that have been broadcast since
+	ListFailedTransactionsRequest struct {
+		All bool
+	}
+
+	FailedTransaction struct {
+		ID             int64  `json:"id"`
+		RawTransaction string `json:"rawTransaction"`
+		// the error of the last failed attempt
+		Error         string `json:"error"`
+		Attempts      int    `json:"attempts"`
+		FirstFailedAt string `json:"firstFailedAt"`
+		LastFailedAt  string `json:"lastFailedAt"`
+		// set once the transaction has been broadcast
+		BroadcastAt     string `json:"broadcastAt,omitempty"`
+		TransactionHash string `json:"transactionHash,omitempty"`
+	}
+
+	ListFailedTransactionsResponse []FailedTransaction
+)
+
+func (r *ListFailedTransactionsRequest) UnmarshalJSON(data []byte) error {
+	var params []bool
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) > 1 {
+		return errors.Errorf("too many arguments, want at most 1")
+	}
+	if len(params) == 1 {
+		r.All = params[0]
+	}
+	return nil
+}
+
+// ======= janus_rebroadcastTransaction ======= //
+type (
+	// [id], the id of a janus_listFailedTransactions entry
+	RebroadcastTransactionRequest int64
+
+	RebroadcastTransactionResponse = SendRawTransactionResponse
+)
+
+func (r *RebroadcastTransactionRequest) UnmarshalJSON(data []byte) error {
+	var params []int64
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) != 1 {
+		return errors.Errorf("expected 1 argument, got %d", len(params))
+	}
+	*r = RebroadcastTransactionRequest(params[0])
+	return nil
+}
+
+// ======= janus_verifyContract ======= //
+type (
+	// [address, {source | sources, contractName, compilerVersion, optimize, runs, evmVersion}]
+	VerifyContractRequest struct {
+		Address string
+		Options VerifyContractOptions
+	}
+
+	VerifyContractOptions struct {
+		// a single source file, or sources by file name for contracts importing other files
+		Source  string            `json:"source"`
+		Sources map[string]string `json:"sources"`
+		// "Name", or "file.sol:Name" when several files declare the name
+		ContractName    string `json:"contractName"`
+		CompilerVersion string `json:"compilerVersion"`
+		Optimize        bool   `json:"optimize"`
+		Runs            int    `json:"runs"`
+		EVMVersion      string `json:"evmVersion"`
+	}
+
+	VerifyContractResponse struct {
+		Address  string `json:"address"`
+		Verified bool   `json:"verified"`
+		// the metadata hash Solidity appends to the code matched as well
+		ExactMatch      bool            `json:"exactMatch"`
+		ContractName    string          `json:"contractName"`
+		CompilerVersion string          `json:"compilerVersion"`
+		ABI             json.RawMessage `json:"abi,omitempty"`
+	}
+)
+
+func (r *VerifyContractRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) != 2 {
+		return errors.Errorf("expected 2 arguments, got %d", len(params))
+	}
+	if err := json.Unmarshal(params[0], &r.Address); err != nil {
+		return errors.Wrap(err, "couldn't unmarshal address")
+	}
+	if err := json.Unmarshal(params[1], &r.Options); err != nil {
+		return errors.Wrap(err, "couldn't unmarshal options")
+	}
+	return nil
+}
+
+// ======= janus_getVerifiedContract ======= //
+type (
+	// [address]
+	GetVerifiedContractRequest string
+
+	// nil for contracts that haven't been verified
+	GetVerifiedContractResponse *VerifiedContract
+
+	VerifiedContract struct {
+		Address         string            `json:"address"`
+		ContractName    string            `json:"contractName"`
+		CompilerVersion string            `json:"compilerVersion"`
+		Optimize        bool              `json:"optimize"`
+		Runs            int               `json:"runs"`
+		EVMVersion      string            `json:"evmVersion,omitempty"`
+		Sources         map[string]string `json:"sources"`
+		ABI             json.RawMessage   `json:"abi"`
+		Metadata        string            `json:"metadata,omitempty"`
+		ExactMatch      bool              `json:"exactMatch"`
+		VerifiedAt      string            `json:"verifiedAt"`
+	}
+)
+
+func (r *GetVerifiedContractRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) != 1 {
+		return errors.Errorf("expected 1 argument, got %d", len(params))
+	}
+	*r = GetVerifiedContractRequest(params[0])
+	return nil
+}
+
+// ======= janus_registerABI ======= //
+type (
+	// [address, abi, name], the ABI as a JSON array or a string containing one, name is optional
+	RegisterABIRequest struct {
+		Address string
+		ABI     json.RawMessage
+		Name    string
+	}
+
+	RegisterABIResponse = ContractABI
+
+	ContractABI struct {
+		Address string          `json:"address"`
+		Name    string          `json:"name"`
+		ABI     json.RawMessage `json:"abi"`
+		// "registry" for ABIs registered with janus_registerABI, "verification" for the ABIs of verified contracts
+		Source       string `json:"source"`
+		RegisteredAt string `json:"registeredAt"`
+		UpdatedAt    string `json:"updatedAt,omitempty"`
+	}
+)
+
+func (r *RegisterABIRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	if len(params) != 2 && len(params) != 3 {
+		return errors.Errorf("expected 2 or 3 arguments, got %d", len(params))
+	}
+	if err := json.Unmarshal(params[0], &r.Address); err != nil {
+		return errors.Wrap(err, "couldn't unmarshal address")
+	}
+	r.ABI = params[1]
+	if len(params) == 3 {
+		if err := json.Unmarshal(params[2], &r.Name); err != nil {
+			return errors.Wrap(err, "couldn't unmarshal name")
+		}
+	}
+	return nil
+}
+
+// ======= janus_getABI ======= //
+type (
+	// [address]
+	GetABIRequest = GetVerifiedContractRequest
+
+	// nil when no ABI is known for the address
+	GetABIResponse *ContractABI
+)
+
+// ======= janus_removeABI ======= //
+type (
+	// [address]
+	RemoveABIRequest = GetVerifiedContractRequest
+
+	// false when no ABI was registered for the address
+	RemoveABIResponse bool
+)
+
+// ======= eth_getBlockReceipts ======= //
+type (
+	// [block], a number, tag or hash like eth.BlockParam
+	GetBlockReceiptsRequest struct {
+		Block json.RawMessage
+	}
+
+	// nil when the block doesn't exist
+	GetBlockReceiptsResponse []*GetTransactionReceiptResponse
+)
+
+func (r *GetBlockReceiptsRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarshal data")
+	}
+	if len(params) != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", len(params))
+	}
+	// resolved by the proxy, which accepts anything eth.BlockParam does
+	r.Block = params[0]
+	return nil
+}
+
+// ======= eth_feeHistory ======= //
+type (
+	// [blockCount, newestBlock, rewardPercentiles]
+	FeeHistoryRequest struct {
+		BlockCount uint64
+		// resolved by the proxy, which accepts anything eth.BlockParam does
+		NewestBlock       json.RawMessage
+		RewardPercentiles []float64
+	}
+
+	FeeHistoryResponse struct {
+		OldestBlock   string     `json:"oldestBlock"`
+		BaseFeePerGas []string   `json:"baseFeePerGas"`
+		GasUsedRatio  []float64  `json:"gasUsedRatio"`
+		Reward        [][]string `json:"reward,omitempty"`
+	}
+)
+
+func (r *FeeHistoryRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarshal data")
+	}
+	if len(params) < 2 || len(params) > 3 {
+		return errors.Errorf("invalid parameters number - %d/3", len(params))
+	}
+
+	// a quantity, though some clients send a plain number
+	var blockCount hexutil.Uint64
+	if err := json.Unmarshal(params[0], &blockCount); err != nil {
+		var number uint64
+		if json.Unmarshal(params[0], &number) != nil {
+			return errors.Wrap(err, "invalid block count")
+		}
+		blockCount = hexutil.Uint64(number)
+	}
+	r.BlockCount = uint64(blockCount)
+	r.NewestBlock = params[1]
+
+	if len(params) == 3 && string(params[2]) != "null" {
+		if err := json.Unmarshal(params[2], &r.RewardPercentiles); err != nil {
+			return errors.Wrap(err, "invalid reward percentiles")
+		}
+		for i, percentile := range r.RewardPercentiles {
+			if percentile < 0 || percentile > 100 {
+				return errors.Errorf("invalid reward percentile %g, must be between 0 and 100", percentile)
+			}
+			if i > 0 && percentile < r.RewardPercentiles[i-1] {
+				return errors.New("reward percentiles must be in ascending order")
+			}
+		}
+	}
+	return nil
+}
+
+// ========== eth_getProof ============= //
+type (
+	GetProofRequest struct {
+		Address     string
+		StorageKeys []string
+		BlockNumber json.RawMessage
+	}
+	// the account and storage values of EIP-1186, qtumd keeps no Merkle Patricia trie so there are no proofs
+	GetProofResponse struct {
+		Address      string         `json:"address"`
+		AccountProof []string       `json:"accountProof"`
+		Balance      string         `json:"balance"`
+		CodeHash     string         `json:"codeHash"`
+		Nonce        string         `json:"nonce"`
+		StorageHash  string         `json:"storageHash"`
+		StorageProof []StorageProof `json:"storageProof"`
+		// always false, the empty proofs can't be verified against a state root. Left out without response extensions
+		ProofsSupported *bool `json:"proofsSupported,omitempty"`
+	}
+	StorageProof struct {
+		Key   string   `json:"key"`
+		Value string   `json:"value"`
+		Proof []string `json:"proof"`
+	}
+)
+
+func (r *GetProofRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 2 || len(params) > 3 {
+		return errors.Errorf("invalid parameters number - %d/3", len(params))
+	}
+	if err := json.Unmarshal(params[0], &r.Address); err != nil {
+		return errors.Wrap(err, "invalid address")
+	}
+	if err := json.Unmarshal(params[1], &r.StorageKeys); err != nil {
+		return errors.Wrap(err, "invalid storage keys")
+	}
+	if len(params) == 3 {
+		r.BlockNumber = params[2]
+	}
+	return nil
+}
+
+// ========== debug_traceTransaction ============= //
+type (
+	TraceTransactionRequest struct {
+		Hash   string
+		Config TraceConfig
+	}
+	TraceConfig struct {
+		Tracer       string           `json:"tracer"`
+		TracerConfig CallTracerConfig `json:"tracerConfig"`
+	}
+	CallTracerConfig struct {
+		OnlyTopCall bool `json:"onlyTopCall"`
+	}
+	// CallFrame is a call as geth's callTracer reports it
+	CallFrame struct {
+		Type    string      `json:"type"`
+		From    string      `json:"from"`
+		To      string      `json:"to,omitempty"`
+		Value   string      `json:"value,omitempty"`
+		Gas     string      `json:"gas"`
+		GasUsed string      `json:"gasUsed"`
+		Input   string      `json:"input"`
+		Output  string      `json:"output,omitempty"`
+		Error   string      `json:"error,omitempty"`
+		Calls   []CallFrame `json:"calls,omitempty"`
+	}
+)
+
+func (r *TraceTransactionRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 1 || len(params) > 2 {
+		return errors.Errorf("invalid parameters number - %d/2", len(params))
+	}
+	if err := json.Unmarshal(params[0], &r.Hash); err != nil {
+		return errors.Wrap(err, "invalid transaction hash")
+	}
+	if len(params) == 2 && string(params[1]) != "null" {
+		if err := json.Unmarshal(params[1], &r.Config); err != nil {
+			return errors.Wrap(err, "invalid tracer config")
+		}
+	}
+	return nil
+}
+
+// ========== debug_traceCall ============= //
+type (
+	TraceCallRequest struct {
+		// with the block of the second parameter
+		Call   CallRequest
+		Config TraceCallConfig
+	}
+	// TraceCallConfig is the tracer config of debug_traceCall, which overrides state like the third parameter of eth_call
+	TraceCallConfig struct {
+		TraceConfig
+		StateOverrides StateOverride `json:"stateOverrides"`
+	}
+)
+
+func (r *TraceCallRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 1 || len(params) > 3 {
+		return errors.Errorf("invalid parameters number - %d/3", len(params))
+	}
+	call := params
+	if len(call) > 2 {
+		call = call[:2]
+	}
+	callData, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(callData, &r.Call); err != nil {
+		return err
+	}
+	if len(params) == 3 && string(params[2]) != "null" {
+		if err := json.Unmarshal(params[2], &r.Config); err != nil {
+			return errors.Wrap(err, "invalid tracer config")
+		}
+		if err := r.Config.StateOverrides.validate(); err != nil {
+			return err
+		}
+	}
+	r.Call.Overrides = r.Config.StateOverrides
+	return nil
+}
+
+// ========== eth_createAccessList ============= //
+type (
+	// the request is a CallRequest without state overrides
+	AccessListEntry struct {
+		Address     string   `json:"address"`
+		StorageKeys []string `json:"storageKeys"`
+	}
+	CreateAccessListResponse struct {
+		AccessList []AccessListEntry `json:"accessList"`
+		GasUsed    string            `json:"gasUsed"`
+		Error      string            `json:"error,omitempty"`
+	}
+)
+
+// ========== debug_storageRangeAt ============= //
+type (
+	StorageRangeAtRequest struct {
+		// resolved by the proxy, which accepts anything eth.BlockParam does
+		Block   json.RawMessage
+		TxIndex int
+		Address string
+		// the hashed key to start at, hex
+		KeyStart  string
+		MaxResult int
+	}
+	StorageRangeAtResponse struct {
+		// by the keccak256 hash of each key with 0x prefix, in the order of the hashes
+		Storage map[string]StorageEntry `json:"storage"`
+		// the hashed key of the next entry, null when there are no more
+		NextKey *string `json:"nextKey"`
+	}
+	StorageEntry struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+)
+
+func (r *StorageRangeAtRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 5 {
+		return errors.Errorf("invalid parameters number - %d/5", len(params))
+	}
+	r.Block = params[0]
+	fields := []interface{}{&r.TxIndex, &r.Address, &r.KeyStart, &r.MaxResult}
+	for i, field := range fields {
+		if err := json.Unmarshal(params[i+1], field); err != nil {
+			return errors.Wrapf(err, "invalid parameter %d", i+1)
+		}
+	}
+	if r.TxIndex < 0 || r.MaxResult < 0 {
+		return errors.New("txIndex and maxResult can't be negative")
+	}
+	return nil
+}
+
+// ========== debug_traceBlockByNumber, debug_traceBlockByHash ============= //
+type (
+	TraceBlockRequest struct {
+		// resolved by the proxy, which accepts anything eth.BlockParam does
+		Block  json.RawMessage
+		Config TraceConfig
+	}
+	// the traces of a block's transactions in block order, nil when the block doesn't exist
+	TraceBlockResponse []TraceResult
+	TraceResult        struct {
+		TxHash string     `json:"txHash"`
+		Result *CallFrame `json:"result,omitempty"`
+		Error  string     `json:"error,omitempty"`
+	}
+)
+
+func (r *TraceBlockRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 1 || len(params) > 2 {
+		return errors.Errorf("invalid parameters number - %d/2", len(params))
+	}
+	r.Block = params[0]
+	if len(params) == 2 && string(params[1]) != "null" {
+		if err := json.Unmarshal(params[1], &r.Config); err != nil {
+			return errors.Wrap(err, "invalid tracer config")
+		}
+	}
+	return nil
+}
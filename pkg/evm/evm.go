@@ -0,0 +1,153 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+)
+
+var ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+// Message is a call to run, like the transaction object of eth_call
+type Message struct {
+	From common.Address
+	// nil deploys Data as the code of a new contract
+	To         *common.Address
+	Gas        uint64
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+// Block is the block a call runs in
+type Block struct {
+	Number   *big.Int
+	Time     uint64
+	Coinbase common.Address
+	GasLimit uint64
+	// returns the hash of a block by number for BLOCKHASH
+	GetHash vm.GetHashFunc
+}
+
+// Result is the outcome of a call, Err is the error of the interpreter, vm.ErrExecutionReverted for a revert
+type Result struct {
+	ReturnData []byte
+	GasUsed    uint64
+	Err        error
+}
+
+// ChainConfig has every fork up to London active from the genesis block, which is the EVM Qtum's contracts run in
+func ChainConfig(chainID *big.Int) *params.ChainConfig {
+	return &params.ChainConfig{
+		ChainID:             chainID,
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		MuirGlacierBlock:    big.NewInt(0),
+		BerlinBlock:         big.NewInt(0),
+		LondonBlock:         big.NewInt(0),
+	}
+}
+
+// IntrinsicGas is the gas a call pays before any code runs
+func IntrinsicGas(msg Message) uint64 {
+	gas := params.TxGas
+	if msg.To == nil {
+		gas = params.TxGasContractCreation
+	}
+	for _, b := range msg.Data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	gas += uint64(len(msg.AccessList)) * params.TxAccessListAddressGas
+	gas += uint64(msg.AccessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	return gas
+}
+
+// Run runs msg in block against state, tracer is optional. The error is only set when the call couldn't run at all,
+// a call that failed in the interpreter has the reason in the Err of the result
+func Run(state *StateDB, chainID *big.Int, block Block, msg Message, tracer vm.EVMLogger) (*Result, error) {
+	intrinsicGas := IntrinsicGas(msg)
+	if msg.Gas < intrinsicGas {
+		return nil, errors.Wrapf(ErrIntrinsicGas, "have %d, want %d", msg.Gas, intrinsicGas)
+	}
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	// the value a Qtum contract is sent comes from the inputs of the transaction rather than the balance of the
+	// sender, so the sender is given it first
+	state.AddBalance(msg.From, value)
+
+	chainConfig := ChainConfig(chainID)
+	getHash := block.GetHash
+	if getHash == nil {
+		getHash = func(uint64) common.Hash { return common.Hash{} }
+	}
+	blockContext := vm.BlockContext{
+		CanTransfer: canTransfer,
+		Transfer:    transfer,
+		GetHash:     getHash,
+		Coinbase:    block.Coinbase,
+		GasLimit:    block.GasLimit,
+		BlockNumber: block.Number,
+		Time:        new(big.Int).SetUint64(block.Time),
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int),
+	}
+	config := vm.Config{NoBaseFee: true}
+	if tracer != nil {
+		config.Debug = true
+		config.Tracer = tracer
+	}
+	interpreter := vm.NewEVM(blockContext, vm.TxContext{Origin: msg.From, GasPrice: new(big.Int)}, state, chainConfig, config)
+
+	rules := chainConfig.Rules(block.Number, false)
+	if rules.IsBerlin {
+		state.PrepareAccessList(msg.From, msg.To, vm.ActivePrecompiles(rules), msg.AccessList)
+	}
+
+	sender := vm.AccountRef(msg.From)
+	gas := msg.Gas - intrinsicGas
+	var (
+		ret []byte
+		err error
+	)
+	if msg.To == nil {
+		ret, _, gas, err = interpreter.Create(sender, msg.Data, gas, value)
+	} else {
+		state.SetNonce(msg.From, state.GetNonce(msg.From)+1)
+		ret, gas, err = interpreter.Call(sender, *msg.To, msg.Data, gas, value)
+	}
+	if state.Error() != nil {
+		return nil, state.Error()
+	}
+
+	gasUsed := msg.Gas - gas
+	refund := gasUsed / params.RefundQuotientEIP3529
+	if refund > state.GetRefund() {
+		refund = state.GetRefund()
+	}
+	return &Result{ReturnData: ret, GasUsed: gasUsed - refund, Err: err}, nil
+}
+
+func canTransfer(db vm.StateDB, address common.Address, amount *big.Int) bool {
+	return db.GetBalance(address).Cmp(amount) >= 0
+}
+
+func transfer(db vm.StateDB, sender, recipient common.Address, amount *big.Int) {
+	db.SubBalance(sender, amount)
+	db.AddBalance(recipient, amount)
+}
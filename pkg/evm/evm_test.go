@@ -0,0 +1,141 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+var (
+	caller   = common.HexToAddress("0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960")
+	contract = common.HexToAddress("0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c")
+	callee   = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+type testReader struct {
+	code    map[common.Address][]byte
+	storage map[common.Address]map[common.Hash]common.Hash
+	// the times storage was fetched
+	storageReads int
+}
+
+func (r *testReader) Account(ctx context.Context, address common.Address) (*big.Int, []byte, error) {
+	return new(big.Int), r.code[address], nil
+}
+
+func (r *testReader) Storage(ctx context.Context, address common.Address) (map[common.Hash]common.Hash, error) {
+	r.storageReads++
+	return r.storage[address], nil
+}
+
+func run(t *testing.T, reader *testReader, overrides eth.StateOverride, tracer vm.EVMLogger) *Result {
+	state := NewStateDB(context.Background(), reader)
+	if err := state.Override(overrides); err != nil {
+		t.Fatal(err)
+	}
+	to := contract
+	block := Block{Number: big.NewInt(100), Time: 1600000000, GasLimit: 40000000}
+	result, err := Run(state, big.NewInt(8890), block, Message{From: caller, To: &to, Gas: 100000}, tracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRunReturnsValue(t *testing.T) {
+	reader := &testReader{code: map[common.Address][]byte{
+		// returns 42
+		contract: common.FromHex("602a60005260206000f3"),
+	}}
+	result := run(t, reader, nil, nil)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 42 {
+		t.Errorf("expected 42, got %x", result.ReturnData)
+	}
+	if result.GasUsed <= 21000 {
+		t.Errorf("expected more than the intrinsic gas to be used, got %d", result.GasUsed)
+	}
+}
+
+func TestRunReverts(t *testing.T) {
+	reader := &testReader{code: map[common.Address][]byte{
+		contract: common.FromHex("60006000fd"),
+	}}
+	result := run(t, reader, nil, nil)
+	if result.Err != vm.ErrExecutionReverted {
+		t.Errorf("expected a revert, got %v", result.Err)
+	}
+}
+
+func TestRunStorageOverrides(t *testing.T) {
+	// returns slot 0
+	code := common.FromHex("60005460005260206000f3")
+	reader := &testReader{
+		code:    map[common.Address][]byte{contract: code},
+		storage: map[common.Address]map[common.Hash]common.Hash{contract: {{}: common.BigToHash(big.NewInt(7))}},
+	}
+	result := run(t, reader, nil, nil)
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 7 {
+		t.Errorf("expected the fetched slot, got %x", result.ReturnData)
+	}
+
+	reader.storageReads = 0
+	result = run(t, reader, eth.StateOverride{contract.Hex(): {State: map[string]string{"0x0": "0x9"}}}, nil)
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 9 {
+		t.Errorf("expected the overridden slot, got %x", result.ReturnData)
+	}
+	if reader.storageReads != 0 {
+		t.Errorf("expected storage replaced by the override not to be fetched")
+	}
+
+	// the code is overridden too, stateDiff keeps the other slots
+	reader = &testReader{storage: reader.storage}
+	overriddenCode := hexutil.Bytes(code)
+	result = run(t, reader, eth.StateOverride{contract.Hex(): {Code: &overriddenCode, StateDiff: map[string]string{"0x1": "0x9"}}}, nil)
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 7 {
+		t.Errorf("expected the fetched slot, got %x", result.ReturnData)
+	}
+}
+
+func TestRunCallTracer(t *testing.T) {
+	reader := &testReader{code: map[common.Address][]byte{
+		// calls callee and returns what it returned
+		contract: common.FromHex("60206000600060006000730000000000000000000000000000000000000b0b5af15060206000f3"),
+		callee:   common.FromHex("602a60005260206000f3"),
+	}}
+	tracer := NewCallTracer(false)
+	result := run(t, reader, nil, tracer)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	frame := tracer.Frame()
+	if frame.Type != "CALL" || frame.To != "0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c" || len(frame.Calls) != 1 {
+		t.Fatalf("unexpected top call %+v", frame)
+	}
+	if frame.Calls[0].To != "0x0000000000000000000000000000000000000b0b" || frame.Calls[0].Output != hexutil.Encode(common.BigToHash(big.NewInt(42)).Bytes()) {
+		t.Errorf("unexpected nested call %+v", frame.Calls[0])
+	}
+
+	tracer = NewCallTracer(true)
+	run(t, reader, nil, tracer)
+	if len(tracer.Frame().Calls) != 0 {
+		t.Errorf("expected only the top call, got %+v", tracer.Frame().Calls)
+	}
+}
+
+func TestRunIntrinsicGas(t *testing.T) {
+	state := NewStateDB(context.Background(), &testReader{})
+	to := contract
+	_, err := Run(state, big.NewInt(8890), Block{Number: big.NewInt(1)}, Message{From: caller, To: &to, Gas: 20000}, nil)
+	if err == nil {
+		t.Error("expected a call without the intrinsic gas to fail")
+	}
+}
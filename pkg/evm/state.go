@@ -0,0 +1,395 @@
+// Package evm runs calls in go-ethereum's interpreter against state fetched from qtumd, for the simulations qtumd's
+// callcontract can't do: calls with overridden code or storage, opcode level traces and access lists
+package evm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// StateReader fetches the state of accounts, it is only asked for each account once
+type StateReader interface {
+	// Account returns the balance in wei and the code of address, an account that doesn't exist has neither
+	Account(ctx context.Context, address common.Address) (*big.Int, []byte, error)
+	// Storage returns every storage slot of address
+	Storage(ctx context.Context, address common.Address) (map[common.Hash]common.Hash, error)
+}
+
+type account struct {
+	balance  *big.Int
+	nonce    uint64
+	code     []byte
+	codeHash common.Hash
+	// the storage as fetched or overridden, nil until it is needed
+	committed map[common.Hash]common.Hash
+	dirty     map[common.Hash]common.Hash
+	suicided  bool
+	exists    bool
+}
+
+// StateDB implements go-ethereum's vm.StateDB over a StateReader. Accounts are fetched when the interpreter first
+// touches them and changes are only kept in memory, nothing is ever written back
+type StateDB struct {
+	ctx    context.Context
+	reader StateReader
+
+	accounts   map[common.Address]*account
+	refund     uint64
+	logs       []*types.Log
+	accessList map[common.Address]map[common.Hash]bool
+
+	// undoing the changes in reverse order reverts to a snapshot
+	journal []func()
+	// the first error of the reader, the interpreter has no way to fail on one
+	err error
+}
+
+func NewStateDB(ctx context.Context, reader StateReader) *StateDB {
+	return &StateDB{
+		ctx:        ctx,
+		reader:     reader,
+		accounts:   make(map[common.Address]*account),
+		accessList: make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// Error returns the first error fetching state, the result of a run that hit one can't be trusted
+func (s *StateDB) Error() error {
+	return s.err
+}
+
+// Logs returns the logs of the run
+func (s *StateDB) Logs() []*types.Log {
+	return s.logs
+}
+
+func (s *StateDB) setError(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *StateDB) getAccount(address common.Address) *account {
+	if a, ok := s.accounts[address]; ok {
+		return a
+	}
+	a := &account{balance: new(big.Int), codeHash: crypto.Keccak256Hash(nil)}
+	balance, code, err := s.reader.Account(s.ctx, address)
+	if err != nil {
+		s.setError(errors.Wrapf(err, "couldn't get account %s", address.Hex()))
+	} else {
+		if balance != nil {
+			a.balance = new(big.Int).Set(balance)
+		}
+		a.code = code
+		a.codeHash = crypto.Keccak256Hash(code)
+		a.exists = balance != nil && balance.Sign() != 0 || len(code) != 0
+	}
+	s.accounts[address] = a
+	return a
+}
+
+func (s *StateDB) storage(address common.Address, a *account) map[common.Hash]common.Hash {
+	if a.committed != nil {
+		return a.committed
+	}
+	a.committed = map[common.Hash]common.Hash{}
+	if len(a.code) == 0 {
+		// only contracts have storage
+		return a.committed
+	}
+	storage, err := s.reader.Storage(s.ctx, address)
+	if err != nil {
+		s.setError(errors.Wrapf(err, "couldn't get the storage of %s", address.Hex()))
+		return a.committed
+	}
+	for key, value := range storage {
+		a.committed[key] = value
+	}
+	return a.committed
+}
+
+// Override applies the state overrides of a call, before it runs
+func (s *StateDB) Override(overrides eth.StateOverride) error {
+	for hexAddress, override := range overrides {
+		if !common.IsHexAddress(hexAddress) {
+			return errors.Errorf("invalid address %s", hexAddress)
+		}
+		address := common.HexToAddress(hexAddress)
+		a := s.getAccount(address)
+		if override.Nonce != nil {
+			a.nonce = uint64(*override.Nonce)
+		}
+		if override.Code != nil {
+			a.code = *override.Code
+			a.codeHash = crypto.Keccak256Hash(a.code)
+		}
+		if override.Balance != nil {
+			a.balance = new(big.Int).Set(override.Balance.ToInt())
+		}
+		if override.State != nil {
+			// the whole storage is replaced, there's no need to fetch it
+			a.committed = map[common.Hash]common.Hash{}
+		}
+		for _, slots := range []map[string]string{override.State, override.StateDiff} {
+			if slots == nil {
+				continue
+			}
+			storage := s.storage(address, a)
+			for key, value := range slots {
+				k, err := parseHash(key)
+				if err != nil {
+					return errors.Wrapf(err, "invalid storage key of %s", hexAddress)
+				}
+				v, err := parseHash(value)
+				if err != nil {
+					return errors.Wrapf(err, "invalid storage value of %s", hexAddress)
+				}
+				storage[k] = v
+			}
+		}
+		a.exists = true
+	}
+	return s.err
+}
+
+func parseHash(value string) (common.Hash, error) {
+	hexValue := utils.RemoveHexPrefix(strings.TrimPrefix(value, "0X"))
+	if len(hexValue) > 64 {
+		return common.Hash{}, errors.Errorf("%s is longer than 32 bytes", value)
+	}
+	number, ok := new(big.Int).SetString(hexValue, 16)
+	if !ok && hexValue != "" {
+		return common.Hash{}, errors.Errorf("%s isn't hex", value)
+	}
+	if number == nil {
+		return common.Hash{}, nil
+	}
+	return common.BigToHash(number), nil
+}
+
+func (s *StateDB) CreateAccount(address common.Address) {
+	previous := s.getAccount(address)
+	// like geth, the balance survives the account being created again
+	a := &account{
+		balance:   new(big.Int).Set(previous.balance),
+		codeHash:  crypto.Keccak256Hash(nil),
+		committed: map[common.Hash]common.Hash{},
+		exists:    true,
+	}
+	s.accounts[address] = a
+	s.journal = append(s.journal, func() { s.accounts[address] = previous })
+}
+
+func (s *StateDB) SubBalance(address common.Address, amount *big.Int) {
+	s.setBalance(address, new(big.Int).Sub(s.GetBalance(address), amount))
+}
+
+func (s *StateDB) AddBalance(address common.Address, amount *big.Int) {
+	s.setBalance(address, new(big.Int).Add(s.GetBalance(address), amount))
+}
+
+func (s *StateDB) setBalance(address common.Address, balance *big.Int) {
+	a := s.getAccount(address)
+	previous, existed := a.balance, a.exists
+	a.balance, a.exists = balance, true
+	s.journal = append(s.journal, func() { a.balance, a.exists = previous, existed })
+}
+
+func (s *StateDB) GetBalance(address common.Address) *big.Int {
+	return new(big.Int).Set(s.getAccount(address).balance)
+}
+
+func (s *StateDB) GetNonce(address common.Address) uint64 {
+	return s.getAccount(address).nonce
+}
+
+func (s *StateDB) SetNonce(address common.Address, nonce uint64) {
+	a := s.getAccount(address)
+	previous, existed := a.nonce, a.exists
+	a.nonce, a.exists = nonce, true
+	s.journal = append(s.journal, func() { a.nonce, a.exists = previous, existed })
+}
+
+func (s *StateDB) GetCodeHash(address common.Address) common.Hash {
+	if !s.Exist(address) {
+		return common.Hash{}
+	}
+	return s.getAccount(address).codeHash
+}
+
+func (s *StateDB) GetCode(address common.Address) []byte {
+	return s.getAccount(address).code
+}
+
+func (s *StateDB) SetCode(address common.Address, code []byte) {
+	a := s.getAccount(address)
+	previousCode, previousHash, existed := a.code, a.codeHash, a.exists
+	a.code, a.codeHash, a.exists = code, crypto.Keccak256Hash(code), true
+	s.journal = append(s.journal, func() { a.code, a.codeHash, a.exists = previousCode, previousHash, existed })
+}
+
+func (s *StateDB) GetCodeSize(address common.Address) int {
+	return len(s.getAccount(address).code)
+}
+
+func (s *StateDB) AddRefund(gas uint64) {
+	previous := s.refund
+	s.refund += gas
+	s.journal = append(s.journal, func() { s.refund = previous })
+}
+
+func (s *StateDB) SubRefund(gas uint64) {
+	previous := s.refund
+	if gas > s.refund {
+		// geth panics, the refund counter can't go below zero
+		gas = s.refund
+	}
+	s.refund -= gas
+	s.journal = append(s.journal, func() { s.refund = previous })
+}
+
+func (s *StateDB) GetRefund() uint64 {
+	return s.refund
+}
+
+func (s *StateDB) GetCommittedState(address common.Address, key common.Hash) common.Hash {
+	a := s.getAccount(address)
+	return s.storage(address, a)[key]
+}
+
+func (s *StateDB) GetState(address common.Address, key common.Hash) common.Hash {
+	a := s.getAccount(address)
+	if value, ok := a.dirty[key]; ok {
+		return value
+	}
+	return s.storage(address, a)[key]
+}
+
+func (s *StateDB) SetState(address common.Address, key common.Hash, value common.Hash) {
+	a := s.getAccount(address)
+	if a.dirty == nil {
+		a.dirty = map[common.Hash]common.Hash{}
+	}
+	previous, had := a.dirty[key]
+	a.dirty[key] = value
+	s.journal = append(s.journal, func() {
+		if had {
+			a.dirty[key] = previous
+		} else {
+			delete(a.dirty, key)
+		}
+	})
+}
+
+func (s *StateDB) Suicide(address common.Address) bool {
+	if !s.Exist(address) {
+		return false
+	}
+	a := s.getAccount(address)
+	previous, previousBalance := a.suicided, a.balance
+	a.suicided, a.balance = true, new(big.Int)
+	s.journal = append(s.journal, func() { a.suicided, a.balance = previous, previousBalance })
+	return true
+}
+
+func (s *StateDB) HasSuicided(address common.Address) bool {
+	return s.getAccount(address).suicided
+}
+
+func (s *StateDB) Exist(address common.Address) bool {
+	return s.getAccount(address).exists
+}
+
+func (s *StateDB) Empty(address common.Address) bool {
+	a := s.getAccount(address)
+	return a.balance.Sign() == 0 && a.nonce == 0 && len(a.code) == 0
+}
+
+func (s *StateDB) PrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	s.AddAddressToAccessList(sender)
+	if dest != nil {
+		s.AddAddressToAccessList(*dest)
+	}
+	for _, address := range precompiles {
+		s.AddAddressToAccessList(address)
+	}
+	for _, tuple := range txAccesses {
+		s.AddAddressToAccessList(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			s.AddSlotToAccessList(tuple.Address, key)
+		}
+	}
+}
+
+func (s *StateDB) AddressInAccessList(address common.Address) bool {
+	_, ok := s.accessList[address]
+	return ok
+}
+
+func (s *StateDB) SlotInAccessList(address common.Address, slot common.Hash) (addressOk bool, slotOk bool) {
+	slots, ok := s.accessList[address]
+	return ok, ok && slots[slot]
+}
+
+func (s *StateDB) AddAddressToAccessList(address common.Address) {
+	if s.AddressInAccessList(address) {
+		return
+	}
+	s.accessList[address] = map[common.Hash]bool{}
+	s.journal = append(s.journal, func() { delete(s.accessList, address) })
+}
+
+func (s *StateDB) AddSlotToAccessList(address common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(address)
+	if s.accessList[address][slot] {
+		return
+	}
+	s.accessList[address][slot] = true
+	s.journal = append(s.journal, func() { delete(s.accessList[address], slot) })
+}
+
+func (s *StateDB) RevertToSnapshot(snapshot int) {
+	for i := len(s.journal) - 1; i >= snapshot; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:snapshot]
+}
+
+func (s *StateDB) Snapshot() int {
+	return len(s.journal)
+}
+
+func (s *StateDB) AddLog(log *types.Log) {
+	log.Index = uint(len(s.logs))
+	s.logs = append(s.logs, log)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:len(s.logs)-1] })
+}
+
+func (s *StateDB) AddPreimage(common.Hash, []byte) {}
+
+func (s *StateDB) ForEachStorage(address common.Address, cb func(common.Hash, common.Hash) bool) error {
+	a := s.getAccount(address)
+	for key := range s.storage(address, a) {
+		if !cb(key, s.GetState(address, key)) {
+			return nil
+		}
+	}
+	for key, value := range a.dirty {
+		if _, ok := a.committed[key]; ok {
+			continue
+		}
+		if !cb(key, value) {
+			return nil
+		}
+	}
+	return s.err
+}
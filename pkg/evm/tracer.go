@@ -0,0 +1,93 @@
+package evm
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// CallTracer builds the frames of geth's callTracer as a call runs
+type CallTracer struct {
+	onlyTopCall bool
+	// the top call first, then the calls still running
+	callstack []eth.CallFrame
+}
+
+func NewCallTracer(onlyTopCall bool) *CallTracer {
+	return &CallTracer{onlyTopCall: onlyTopCall, callstack: make([]eth.CallFrame, 1)}
+}
+
+// Frame returns the top call, once the run is over
+func (t *CallTracer) Frame() *eth.CallFrame {
+	return &t.callstack[0]
+}
+
+func (t *CallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.callstack[0] = newFrame(vm.CALL, from, to, input, gas, value)
+	if create {
+		t.callstack[0].Type = vm.CREATE.String()
+	}
+}
+
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	endFrame(&t.callstack[0], output, gasUsed, err)
+}
+
+func (t *CallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *CallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *CallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.onlyTopCall {
+		return
+	}
+	t.callstack = append(t.callstack, newFrame(typ, from, to, input, gas, value))
+}
+
+func (t *CallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if t.onlyTopCall || size <= 1 {
+		return
+	}
+	call := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+	endFrame(&call, output, gasUsed, err)
+	if err != nil && (call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String()) {
+		// no contract was created
+		call.To = ""
+	}
+	t.callstack[size-2].Calls = append(t.callstack[size-2].Calls, call)
+}
+
+func newFrame(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) eth.CallFrame {
+	frame := eth.CallFrame{
+		Type:  typ.String(),
+		From:  strings.ToLower(from.Hex()),
+		To:    strings.ToLower(to.Hex()),
+		Input: hexutil.Encode(input),
+		Gas:   hexutil.EncodeUint64(gas),
+	}
+	if value != nil {
+		frame.Value = hexutil.EncodeBig(value)
+	}
+	return frame
+}
+
+func endFrame(frame *eth.CallFrame, output []byte, gasUsed uint64, err error) {
+	frame.GasUsed = hexutil.EncodeUint64(gasUsed)
+	if err == nil {
+		frame.Output = hexutil.Encode(output)
+		return
+	}
+	frame.Error = err.Error()
+	if err == vm.ErrExecutionReverted && len(output) > 0 {
+		frame.Output = hexutil.Encode(output)
+	}
+}
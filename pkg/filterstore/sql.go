@@ -0,0 +1,119 @@
+// Package filterstore keeps eth_newFilter and eth_newBlockFilter filters in the database, so every Janus instance
+// behind a load balancer can serve a filter no matter which one created it
+package filterstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+var createTables = []string{
+	`CREATE SEQUENCE IF NOT EXISTS janus_filter_ids`,
+	`CREATE TABLE IF NOT EXISTS janus_filters (
+		id BIGINT PRIMARY KEY,
+		state TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+}
+
+// SQLStore keeps filters in the postgres database Janus is configured with
+type SQLStore struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ eth.FilterStore = (*SQLStore)(nil)
+
+// Open sets up the store, the tables are created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open filter database")
+	}
+	s := &SQLStore{db: db}
+	// failing here is fine, the next use tries again
+	s.migrate(ctx)
+	return s, nil
+}
+
+func (s *SQLStore) migrate(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.migrated {
+		return nil
+	}
+	for _, statement := range createTables {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return errors.Wrap(err, "couldn't create filter tables")
+		}
+	}
+	s.migrated = true
+	return nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) NextFilterID(ctx context.Context) (uint64, error) {
+	if err := s.migrate(ctx); err != nil {
+		return 0, err
+	}
+	var id int64
+	if err := s.db.QueryRowContext(ctx, "SELECT nextval('janus_filter_ids')").Scan(&id); err != nil {
+		return 0, errors.Wrap(err, "couldn't allocate filter id")
+	}
+	return uint64(id), nil
+}
+
+func (s *SQLStore) SaveFilter(ctx context.Context, state eth.FilterState) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't marshal filter %d", state.ID)
+	}
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO janus_filters (id, state) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET state = EXCLUDED.state, updated_at = now()`,
+		int64(state.ID), string(encoded),
+	)
+	return errors.Wrap(err, "couldn't save filter")
+}
+
+func (s *SQLStore) LoadFilter(ctx context.Context, id uint64) (*eth.FilterState, error) {
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+	var encoded string
+	err := s.db.QueryRowContext(ctx, "SELECT state FROM janus_filters WHERE id = $1", int64(id)).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load filter")
+	}
+	var state eth.FilterState
+	if err := json.Unmarshal([]byte(encoded), &state); err != nil {
+		return nil, errors.Wrapf(err, "couldn't unmarshal filter %d", id)
+	}
+	return &state, nil
+}
+
+func (s *SQLStore) DeleteFilter(ctx context.Context, id uint64) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "DELETE FROM janus_filters WHERE id = $1", int64(id))
+	return errors.Wrap(err, "couldn't uninstall filter")
+}
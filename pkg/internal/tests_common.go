@@ -66,13 +66,33 @@ func (d *doerMappedMock) Do(request *http.Request) (*http.Response, error) {
 		log.Printf("No mocked response for %s\n", requestJSON.Method)
 	}
 
-	responseWriter := ioutil.NopCloser(bytes.NewReader(d.popResponse(requestJSON.Method)))
+	response := d.popResponse(requestJSON.Method)
+	status := http.StatusOK
+	if string(response) == qtum.ErrQtumWorkQueueDepth.Error() {
+		// qtumd turns calls away with this body when its work queue is full
+		status = http.StatusServiceUnavailable
+	}
+	responseWriter := ioutil.NopCloser(bytes.NewReader(echoRequestID(response, requestJSON.ID)))
 	return &http.Response{
-		StatusCode: 200,
+		StatusCode: status,
 		Body:       responseWriter,
 	}, nil
 }
 
+// echoRequestID answers with the id of the request like qtumd does, responses that aren't JSON objects are left as they are
+func echoRequestID(response []byte, id json.RawMessage) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(response, &fields); err != nil || fields == nil {
+		return response
+	}
+	fields["id"] = id
+	echoed, err := json.Marshal(fields)
+	if err != nil {
+		return response
+	}
+	return echoed
+}
+
 func PrepareEthRPCRequest(id int, params []json.RawMessage) (*eth.JSONRPCRequest, error) {
 	requestID, err := json.Marshal(1)
 	if err != nil {
@@ -1,13 +1,14 @@
 package internal
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 	"github.com/qtumproject/janus/pkg/eth"
 )
 
 type ETHProxy interface {
-	Request(*eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError)
+	Request(context.Context, *eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError)
 	Method() string
 }
 
@@ -15,12 +16,12 @@ type mockTransformer struct {
 	proxies map[string]ETHProxy
 }
 
-func (t *mockTransformer) Transform(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (t *mockTransformer) Transform(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	proxy, ok := t.proxies[req.Method]
 	if !ok {
 		return nil, eth.NewCallbackError("couldn't get proxy")
 	}
-	resp, err := proxy.Request(req, c)
+	resp, err := proxy.Request(ctx, req, c)
 	if err != nil {
 		return nil, eth.NewCallbackError(errors.WithMessagef(err.Error(), "couldn't proxy %s request", req.Method).Error())
 	}
@@ -55,7 +56,7 @@ func NewMockETHProxy(method string, response interface{}) ETHProxy {
 	}
 }
 
-func (e *mockETHProxy) Request(*eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError) {
+func (e *mockETHProxy) Request(context.Context, *eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError) {
 	return e.response, nil
 }
 
@@ -0,0 +1,44 @@
+package journal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("transaction not found in journal")
+
+// MaxEntries bounds the journal, the oldest entries are dropped first. Anyone can send raw transactions that fail
+const MaxEntries = 1000
+
+// Entry is a raw transaction that qtumd failed to broadcast
+type Entry struct {
+	ID             int64  `json:"id"`
+	RawTransaction string `json:"rawTransaction"`
+	// the error of the last failed attempt
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"firstFailedAt"`
+	LastFailedAt  time.Time `json:"lastFailedAt"`
+	// set once the transaction has been broadcast
+	BroadcastAt     *time.Time `json:"broadcastAt,omitempty"`
+	TransactionHash string     `json:"transactionHash,omitempty"`
+}
+
+// Journal records failed sendrawtransaction attempts so they can be broadcast again after an upstream incident
+type Journal interface {
+	// Record adds a failed attempt, attempts of the same raw transaction are counted in one entry
+	Record(ctx context.Context, rawTransaction string, reason string) error
+	// List returns the entries oldest first, only the ones that haven't been broadcast since unless all is set
+	List(ctx context.Context, all bool) ([]Entry, error)
+	Get(ctx context.Context, id int64) (*Entry, error)
+	MarkBroadcast(ctx context.Context, id int64, transactionHash string) error
+}
+
+// identifies a raw transaction without indexing the raw transaction itself, which can be too large for an index
+func rawTransactionHash(rawTransaction string) string {
+	hash := sha256.Sum256([]byte(rawTransaction))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,104 @@
+package journal
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryJournal keeps the journal in memory, for tests and embedders without a database
+type MemoryJournal struct {
+	mutex sync.Mutex
+	// oldest first
+	entries []*Entry
+	byHash  map[string]*Entry
+	lastID  int64
+}
+
+var _ Journal = (*MemoryJournal)(nil)
+
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{byHash: make(map[string]*Entry)}
+}
+
+func (j *MemoryJournal) Record(ctx context.Context, rawTransaction string, reason string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	now := time.Now().UTC()
+	hash := rawTransactionHash(rawTransaction)
+	if entry, ok := j.byHash[hash]; ok {
+		entry.Error = reason
+		entry.Attempts++
+		entry.LastFailedAt = now
+		entry.BroadcastAt = nil
+		entry.TransactionHash = ""
+		return nil
+	}
+
+	j.lastID++
+	entry := &Entry{
+		ID:             j.lastID,
+		RawTransaction: rawTransaction,
+		Error:          reason,
+		Attempts:       1,
+		FirstFailedAt:  now,
+		LastFailedAt:   now,
+	}
+	j.entries = append(j.entries, entry)
+	j.byHash[hash] = entry
+	if len(j.entries) > MaxEntries {
+		delete(j.byHash, rawTransactionHash(j.entries[0].RawTransaction))
+		j.entries = j.entries[1:]
+	}
+	return nil
+}
+
+func (j *MemoryJournal) List(ctx context.Context, all bool) ([]Entry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entries := []Entry{}
+	for _, entry := range j.entries {
+		if all || entry.BroadcastAt == nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+func (j *MemoryJournal) Get(ctx context.Context, id int64) (*Entry, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	found := j.find(id)
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	entry := *found
+	return &entry, nil
+}
+
+func (j *MemoryJournal) MarkBroadcast(ctx context.Context, id int64, transactionHash string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entry := j.find(id)
+	if entry == nil {
+		return ErrNotFound
+	}
+	now := time.Now().UTC()
+	entry.BroadcastAt = &now
+	entry.TransactionHash = transactionHash
+	return nil
+}
+
+// find returns the entry with id, ids go up with the entries
+func (j *MemoryJournal) find(id int64) *Entry {
+	i := sort.Search(len(j.entries), func(i int) bool { return j.entries[i].ID >= id })
+	if i == len(j.entries) || j.entries[i].ID != id {
+		return nil
+	}
+	return j.entries[i]
+}
@@ -0,0 +1,172 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS janus_failed_transactions (
+	id BIGSERIAL PRIMARY KEY,
+	raw_transaction_hash TEXT NOT NULL UNIQUE,
+	raw_transaction TEXT NOT NULL,
+	error TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 1,
+	first_failed_at TIMESTAMPTZ NOT NULL,
+	last_failed_at TIMESTAMPTZ NOT NULL,
+	broadcast_at TIMESTAMPTZ,
+	transaction_hash TEXT
+)`
+
+const selectEntries = `
+SELECT id, raw_transaction, error, attempts, first_failed_at, last_failed_at, broadcast_at, transaction_hash
+FROM janus_failed_transactions`
+
+// SQLJournal keeps the journal in the postgres database Janus is configured with
+type SQLJournal struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ Journal = (*SQLJournal)(nil)
+
+// Open sets up the journal, the table is created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLJournal, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open transaction journal database")
+	}
+	j := &SQLJournal{db: db}
+	// failing here is fine, the next use tries again
+	j.migrate(ctx)
+	return j, nil
+}
+
+func (j *SQLJournal) migrate(ctx context.Context) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.migrated {
+		return nil
+	}
+	if _, err := j.db.ExecContext(ctx, createTable); err != nil {
+		return errors.Wrap(err, "couldn't create transaction journal table")
+	}
+	j.migrated = true
+	return nil
+}
+
+func (j *SQLJournal) Close() error {
+	return j.db.Close()
+}
+
+func (j *SQLJournal) Record(ctx context.Context, rawTransaction string, reason string) error {
+	if err := j.migrate(ctx); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err := j.db.ExecContext(
+		ctx,
+		`INSERT INTO janus_failed_transactions (raw_transaction_hash, raw_transaction, error, first_failed_at, last_failed_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (raw_transaction_hash) DO UPDATE SET
+			error = EXCLUDED.error,
+			attempts = janus_failed_transactions.attempts + 1,
+			last_failed_at = EXCLUDED.last_failed_at,
+			broadcast_at = NULL,
+			transaction_hash = NULL`,
+		rawTransactionHash(rawTransaction), rawTransaction, reason, now,
+	)
+	if err != nil {
+		return errors.Wrap(err, "couldn't record failed transaction")
+	}
+	_, err = j.db.ExecContext(
+		ctx,
+		`DELETE FROM janus_failed_transactions WHERE id <= (
+			SELECT id FROM janus_failed_transactions ORDER BY id DESC OFFSET $1 LIMIT 1
+		)`,
+		MaxEntries,
+	)
+	return errors.Wrap(err, "couldn't drop old failed transactions")
+}
+
+func (j *SQLJournal) List(ctx context.Context, all bool) ([]Entry, error) {
+	if err := j.migrate(ctx); err != nil {
+		return nil, err
+	}
+	query := selectEntries
+	if !all {
+		query += " WHERE broadcast_at IS NULL"
+	}
+	rows, err := j.db.QueryContext(ctx, query+" ORDER BY id")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't list failed transactions")
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+func (j *SQLJournal) Get(ctx context.Context, id int64) (*Entry, error) {
+	if err := j.migrate(ctx); err != nil {
+		return nil, err
+	}
+	entry, err := scanEntry(j.db.QueryRowContext(ctx, selectEntries+" WHERE id = $1", id))
+	if errors.Cause(err) == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return entry, err
+}
+
+func (j *SQLJournal) MarkBroadcast(ctx context.Context, id int64, transactionHash string) error {
+	if err := j.migrate(ctx); err != nil {
+		return err
+	}
+	result, err := j.db.ExecContext(
+		ctx,
+		"UPDATE janus_failed_transactions SET broadcast_at = $1, transaction_hash = $2 WHERE id = $3",
+		time.Now().UTC(), transactionHash, id,
+	)
+	if err != nil {
+		return errors.Wrap(err, "couldn't update failed transaction")
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntry(row scanner) (*Entry, error) {
+	var (
+		entry           Entry
+		broadcastAt     sql.NullTime
+		transactionHash sql.NullString
+	)
+	err := row.Scan(&entry.ID, &entry.RawTransaction, &entry.Error, &entry.Attempts, &entry.FirstFailedAt, &entry.LastFailedAt, &broadcastAt, &transactionHash)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if broadcastAt.Valid {
+		entry.BroadcastAt = &broadcastAt.Time
+	}
+	entry.TransactionHash = transactionHash.String
+	return &entry, nil
+}
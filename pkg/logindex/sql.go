@@ -0,0 +1,423 @@
+// Package logindex keeps the logs of qtumd's blocks in the database, indexed by address and first topic, so eth_getLogs
+// and eth_getFilterLogs over wide block ranges don't have to wait for searchlogs to scan every block
+package logindex
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// new blocks are indexed this often
+const indexInterval = 5 * time.Second
+
+// blocks searched with one searchlogs call while catching up
+const indexBatchBlocks = 100
+
+// the hashes of the blocks this close to the tip are kept to notice reorganizations, Qtum doesn't reorganize deeper
+const reorgDepth = 500
+
+var createTables = []string{
+	// the index covers the blocks from from_block to to_block
+	`CREATE TABLE IF NOT EXISTS janus_log_index (
+		id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+		from_block BIGINT NOT NULL,
+		to_block BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS janus_log_blocks (
+		block_number BIGINT PRIMARY KEY,
+		block_hash TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS janus_log_receipts (
+		block_number BIGINT NOT NULL,
+		transaction_index BIGINT NOT NULL,
+		transaction_hash TEXT NOT NULL,
+		output_index BIGINT NOT NULL,
+		receipt TEXT NOT NULL,
+		PRIMARY KEY (block_number, transaction_hash, output_index)
+	)`,
+	`CREATE TABLE IF NOT EXISTS janus_logs (
+		block_number BIGINT NOT NULL,
+		transaction_hash TEXT NOT NULL,
+		output_index BIGINT NOT NULL,
+		address TEXT NOT NULL,
+		topic0 TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS janus_logs_address ON janus_logs (address, block_number)`,
+	`CREATE INDEX IF NOT EXISTS janus_logs_topic0 ON janus_logs (topic0, block_number)`,
+	`CREATE INDEX IF NOT EXISTS janus_logs_block ON janus_logs (block_number)`,
+	`CREATE INDEX IF NOT EXISTS janus_log_receipts_transaction ON janus_log_receipts (transaction_hash)`,
+}
+
+// SQLIndex keeps the log index in the postgres database Janus is configured with
+type SQLIndex struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ qtum.LogIndex = (*SQLIndex)(nil)
+
+// errIndexMoved is returned when another instance indexed or rewound the blocks this one was about to store
+var errIndexMoved = errors.New("log index moved on")
+
+// Open sets up the index, the tables are created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLIndex, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open log index database")
+	}
+	s := &SQLIndex{db: db}
+	// failing here is fine, the next use tries again
+	s.migrate(ctx)
+	return s, nil
+}
+
+func (s *SQLIndex) migrate(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.migrated {
+		return nil
+	}
+	for _, statement := range createTables {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return errors.Wrap(err, "couldn't create log index tables")
+		}
+	}
+	s.migrated = true
+	return nil
+}
+
+func (s *SQLIndex) Close() error {
+	return s.db.Close()
+}
+
+// normalize writes hex the way the index stores it
+func normalize(hex string) string {
+	return strings.ToLower(utils.RemoveHexPrefix(hex))
+}
+
+func (s *SQLIndex) SearchLogs(ctx context.Context, req *qtum.SearchLogsRequest) (qtum.SearchLogsResponse, bool, error) {
+	if err := s.migrate(ctx); err != nil {
+		return nil, false, err
+	}
+	// the coverage and the logs are read from the same snapshot, in case blocks are rewound in between
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "couldn't search log index")
+	}
+	defer tx.Rollback()
+
+	var fromBlock, toBlock int64
+	err = tx.QueryRowContext(ctx, "SELECT from_block, to_block FROM janus_log_index").Scan(&fromBlock, &toBlock)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "couldn't get log index coverage")
+	}
+	if req.FromBlock.Int64() < fromBlock || req.ToBlock.Int64() > toBlock {
+		return nil, false, nil
+	}
+
+	query := "SELECT r.receipt FROM janus_log_receipts r WHERE r.block_number BETWEEN $1 AND $2"
+	args := []interface{}{req.FromBlock.Int64(), req.ToBlock.Int64()}
+	var addresses, topics []string
+	for _, address := range req.Addresses {
+		addresses = append(addresses, normalize(address))
+	}
+	if len(req.Topics) > 0 {
+		for _, topic := range req.Topics[0] {
+			topics = append(topics, normalize(topic))
+		}
+	}
+	if len(addresses) > 0 || len(topics) > 0 {
+		query += " AND EXISTS (SELECT 1 FROM janus_logs l WHERE l.block_number = r.block_number AND l.transaction_hash = r.transaction_hash AND l.output_index = r.output_index"
+		if len(addresses) > 0 {
+			args = append(args, pq.Array(addresses))
+			query += " AND l.address = ANY($3)"
+		}
+		if len(topics) > 0 {
+			args = append(args, pq.Array(topics))
+			query += " AND l.topic0 = ANY($" + strconv.Itoa(len(args)) + ")"
+		}
+		query += ")"
+	}
+	rows, err := tx.QueryContext(ctx, query+" ORDER BY r.block_number, r.transaction_index, r.output_index", args...)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "couldn't search log index")
+	}
+	defer rows.Close()
+
+	receipts := qtum.SearchLogsResponse{}
+	for rows.Next() {
+		var encoded string
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		var receipt qtum.TransactionReceipt
+		if err := json.Unmarshal([]byte(encoded), &receipt); err != nil {
+			return nil, false, errors.Wrap(err, "couldn't unmarshal indexed receipt")
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, true, rows.Err()
+}
+
+func (s *SQLIndex) TransactionBlockHash(ctx context.Context, txID string) (string, error) {
+	if err := s.migrate(ctx); err != nil {
+		return "", err
+	}
+	var encoded string
+	err := s.db.QueryRowContext(ctx, "SELECT receipt FROM janus_log_receipts WHERE transaction_hash = $1 LIMIT 1", normalize(txID)).Scan(&encoded)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't look up transaction in log index")
+	}
+	var receipt qtum.TransactionReceipt
+	if err := json.Unmarshal([]byte(encoded), &receipt); err != nil {
+		return "", errors.Wrap(err, "couldn't unmarshal indexed receipt")
+	}
+	return receipt.BlockHash, nil
+}
+
+// Run indexes qtumd's blocks from fromBlock on and keeps up with new ones until ctx is done. Instances sharing the
+// database can all run it, blocks another instance indexed first are skipped
+func (s *SQLIndex) Run(ctx context.Context, q *qtum.Qtum, fromBlock int64, logger log.Logger) {
+	ticker := time.NewTicker(indexInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.index(ctx, q, fromBlock); err != nil && errors.Cause(err) != errIndexMoved && ctx.Err() == nil {
+			level.Warn(logger).Log("msg", "Failed to index logs", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// index catches up with qtumd's tip, after rewinding the blocks a reorganization replaced
+func (s *SQLIndex) index(ctx context.Context, q *qtum.Qtum, fromBlock int64) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	var indexedFrom, indexedTo int64
+	err := s.db.QueryRowContext(ctx, "SELECT from_block, to_block FROM janus_log_index").Scan(&indexedFrom, &indexedTo)
+	switch {
+	case err == sql.ErrNoRows:
+		indexedTo = fromBlock - 1
+	case err != nil:
+		return errors.Wrap(err, "couldn't get log index coverage")
+	case indexedFrom != fromBlock:
+		// indexed from another block before, start over
+		return s.rewind(ctx, indexedTo, -1)
+	default:
+		if indexedTo, err = s.checkReorganization(ctx, q, indexedTo); err != nil {
+			return err
+		}
+	}
+
+	count, err := q.GetBlockCount(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "couldn't get block count")
+	}
+	tip := count.Int64()
+	for next := indexedTo + 1; next <= tip; next += indexBatchBlocks {
+		end := next + indexBatchBlocks - 1
+		if end > tip {
+			end = tip
+		}
+		if err := s.indexBlocks(ctx, q, fromBlock, next, end, tip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexBlocks stores the logs of the blocks from start to end
+func (s *SQLIndex) indexBlocks(ctx context.Context, q *qtum.Qtum, fromBlock, start, end, tip int64) error {
+	// hashes are taken before the logs, a reorganization in between leaves a hash the next round doesn't find
+	hashes := map[int64]string{}
+	for number := start; number <= end; number++ {
+		if number <= tip-reorgDepth {
+			continue
+		}
+		hash, err := q.GetBlockHash(ctx, big.NewInt(number))
+		if err != nil {
+			return errors.WithMessagef(err, "couldn't get hash of block %d", number)
+		}
+		hashes[number] = string(hash)
+	}
+	var receipts qtum.SearchLogsResponse
+	// the index itself doesn't cover these blocks yet, so qtumd is asked directly
+	req := &qtum.SearchLogsRequest{FromBlock: big.NewInt(start), ToBlock: big.NewInt(end)}
+	if err := q.RequestWithContext(ctx, qtum.MethodSearchLogs, req, &receipts); err != nil {
+		return errors.WithMessagef(err, "couldn't search logs of blocks %d to %d", start, end)
+	}
+	for _, receipt := range receipts {
+		if hash, ok := hashes[int64(receipt.BlockNumber)]; ok && hash != receipt.BlockHash {
+			return errors.Errorf("block %d was replaced while indexing it", receipt.BlockNumber)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "couldn't store logs")
+	}
+	defer tx.Rollback()
+
+	// locks the coverage against other instances until the logs are stored
+	var indexedTo int64
+	err = tx.QueryRowContext(ctx, "SELECT to_block FROM janus_log_index FOR UPDATE").Scan(&indexedTo)
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.ExecContext(ctx, "INSERT INTO janus_log_index (from_block, to_block) VALUES ($1, $2) ON CONFLICT DO NOTHING", fromBlock, end)
+		if err != nil {
+			return errors.Wrap(err, "couldn't store log index coverage")
+		}
+		if inserted, err := result.RowsAffected(); err != nil || inserted == 0 || start != fromBlock {
+			return errIndexMoved
+		}
+	case err != nil:
+		return errors.Wrap(err, "couldn't get log index coverage")
+	case indexedTo != start-1:
+		return errIndexMoved
+	default:
+		if _, err := tx.ExecContext(ctx, "UPDATE janus_log_index SET to_block = $1", end); err != nil {
+			return errors.Wrap(err, "couldn't store log index coverage")
+		}
+	}
+
+	for _, receipt := range receipts {
+		encoded, err := json.Marshal(receipt)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't marshal receipt of %s", receipt.TransactionHash)
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO janus_log_receipts (block_number, transaction_index, transaction_hash, output_index, receipt) VALUES ($1, $2, $3, $4, $5)",
+			int64(receipt.BlockNumber), int64(receipt.TransactionIndex), receipt.TransactionHash, receipt.OutputIndex, string(encoded),
+		)
+		if err != nil {
+			return errors.Wrap(err, "couldn't store receipt")
+		}
+		for _, entry := range receipt.Log {
+			var topic0 sql.NullString
+			if len(entry.Topics) > 0 {
+				topic0 = sql.NullString{String: normalize(entry.Topics[0]), Valid: true}
+			}
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO janus_logs (block_number, transaction_hash, output_index, address, topic0) VALUES ($1, $2, $3, $4, $5)",
+				int64(receipt.BlockNumber), receipt.TransactionHash, receipt.OutputIndex, normalize(entry.Address), topic0,
+			)
+			if err != nil {
+				return errors.Wrap(err, "couldn't store log")
+			}
+		}
+	}
+	for number, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO janus_log_blocks (block_number, block_hash) VALUES ($1, $2)", number, hash); err != nil {
+			return errors.Wrap(err, "couldn't store block hash")
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM janus_log_blocks WHERE block_number <= $1", end-reorgDepth); err != nil {
+		return errors.Wrap(err, "couldn't prune block hashes")
+	}
+	return errors.Wrap(tx.Commit(), "couldn't store logs")
+}
+
+// checkReorganization finds the last indexed block qtumd still has and rewinds the index to it
+func (s *SQLIndex) checkReorganization(ctx context.Context, q *qtum.Qtum, indexedTo int64) (int64, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT block_number, block_hash FROM janus_log_blocks ORDER BY block_number DESC")
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't get indexed block hashes")
+	}
+	type block struct {
+		number int64
+		hash   string
+	}
+	var blocks []block
+	for rows.Next() {
+		var b block
+		if err := rows.Scan(&b.number, &b.hash); err != nil {
+			rows.Close()
+			return 0, errors.WithStack(err)
+		}
+		blocks = append(blocks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if len(blocks) == 0 {
+		return indexedTo, nil
+	}
+
+	for _, b := range blocks {
+		hash, err := q.GetBlockHash(ctx, big.NewInt(b.number))
+		if err != nil && err != qtum.ErrInvalidParameter {
+			return 0, errors.WithMessagef(err, "couldn't get hash of block %d", b.number)
+		}
+		if err == nil && string(hash) == b.hash {
+			if b.number == indexedTo {
+				return indexedTo, nil
+			}
+			return b.number, s.rewind(ctx, indexedTo, b.number)
+		}
+	}
+	// deeper than the hashes kept
+	to := blocks[len(blocks)-1].number - 1
+	return to, s.rewind(ctx, indexedTo, to)
+}
+
+// rewind drops the logs of the blocks after to, -1 drops the whole index
+func (s *SQLIndex) rewind(ctx context.Context, indexedTo int64, to int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "couldn't rewind log index")
+	}
+	defer tx.Rollback()
+
+	var current, from int64
+	if err := tx.QueryRowContext(ctx, "SELECT to_block, from_block FROM janus_log_index FOR UPDATE").Scan(&current, &from); err != nil {
+		if err == sql.ErrNoRows {
+			return errIndexMoved
+		}
+		return errors.Wrap(err, "couldn't get log index coverage")
+	}
+	if current != indexedTo {
+		return errIndexMoved
+	}
+	for _, table := range []string{"janus_logs", "janus_log_receipts", "janus_log_blocks"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE block_number > $1", to); err != nil {
+			return errors.Wrap(err, "couldn't rewind log index")
+		}
+	}
+	if to < from {
+		_, err = tx.ExecContext(ctx, "DELETE FROM janus_log_index")
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE janus_log_index SET to_block = $1", to)
+	}
+	if err != nil {
+		return errors.Wrap(err, "couldn't rewind log index")
+	}
+	return errors.Wrap(tx.Commit(), "couldn't rewind log index")
+}
@@ -0,0 +1,337 @@
+// Package multicall is a contract with Multicall3's aggregate3, for Qtum networks without a multicall contract, and the
+// encoding of its calls so Janus can run them itself. The canonical Multicall3 address can't exist on Qtum, where the
+// address of a contract comes from the transaction that created it
+package multicall
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/pkg/errors"
+)
+
+// Aggregate3Selector is the selector of aggregate3((address,bool,bytes)[])
+var Aggregate3Selector = common.FromHex("82ad56cb")
+
+// CallFailed is the revert reason of aggregate3 when a call that isn't allowed to fail fails
+const CallFailed = "Multicall3: call failed"
+
+// the selector of Error(string)
+var errorSelector = common.FromHex("08c379a0")
+
+// source of the contract, it only has aggregate3 and reverts everything else. Memory holds the index of the call at 0x00,
+// the number of calls at 0x20, where the next result goes at 0x40, where the calls start in the calldata at 0x60 and the
+// returned (bool,bytes)[] from 0x80
+const source = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	PUSH 0x82ad56cb
+	EQ
+	JUMPI @aggregate3
+	PUSH 0
+	PUSH 0
+	REVERT
+
+aggregate3:
+	;; the array of calls and the number of them
+	PUSH 4
+	CALLDATALOAD
+	PUSH 4
+	ADD
+	DUP1
+	CALLDATALOAD
+	PUSH 0x20
+	MSTORE
+	PUSH 0x20
+	ADD
+	PUSH 0x60
+	MSTORE
+
+	;; the head of the result, then the offsets of the results and the results after them
+	PUSH 0x20
+	PUSH 0x80
+	MSTORE
+	PUSH 0x20
+	MLOAD
+	PUSH 0xa0
+	MSTORE
+	PUSH 0x20
+	MLOAD
+	PUSH 5
+	SHL
+	PUSH 0xc0
+	ADD
+	PUSH 0x40
+	MSTORE
+
+loop:
+	PUSH 0x20
+	MLOAD
+	PUSH 0
+	MLOAD
+	LT
+	ISZERO
+	JUMPI @end
+
+	;; the call tuple, then its calldata
+	PUSH 0x60
+	MLOAD
+	DUP1
+	PUSH 0
+	MLOAD
+	PUSH 5
+	SHL
+	ADD
+	CALLDATALOAD
+	ADD
+	DUP1
+	PUSH 0x40
+	ADD
+	CALLDATALOAD
+	DUP2
+	ADD
+	DUP1
+	CALLDATALOAD
+	DUP1
+	DUP3
+	PUSH 0x20
+	ADD
+	PUSH 0x40
+	MLOAD
+	PUSH 0x60
+	ADD
+	CALLDATACOPY
+	SWAP1
+	POP
+
+	;; call the target with the calldata copied where its result goes
+	PUSH 0
+	PUSH 0
+	DUP3
+	PUSH 0x40
+	MLOAD
+	PUSH 0x60
+	ADD
+	PUSH 0
+	DUP7
+	CALLDATALOAD
+	GAS
+	CALL
+	SWAP1
+	POP
+
+	;; a failed call reverts unless it allows failure
+	DUP1
+	DUP3
+	PUSH 0x20
+	ADD
+	CALLDATALOAD
+	OR
+	JUMPI @store
+	PUSH 0x08c379a0
+	PUSH 0xe0
+	SHL
+	PUSH 0
+	MSTORE
+	PUSH 0x20
+	PUSH 4
+	MSTORE
+	PUSH 23
+	PUSH 0x24
+	MSTORE
+	PUSH "Multicall3: call failed"
+	PUSH 0x48
+	SHL
+	PUSH 0x44
+	MSTORE
+	PUSH 0x64
+	PUSH 0
+	REVERT
+
+store:
+	;; the result, its offset and where the next one goes
+	PUSH 0x40
+	MLOAD
+	MSTORE
+	POP
+	PUSH 0x40
+	PUSH 0x40
+	MLOAD
+	PUSH 0x20
+	ADD
+	MSTORE
+	RETURNDATASIZE
+	PUSH 0x40
+	MLOAD
+	PUSH 0x40
+	ADD
+	MSTORE
+	RETURNDATASIZE
+	PUSH 0
+	PUSH 0x40
+	MLOAD
+	PUSH 0x60
+	ADD
+	RETURNDATACOPY
+	PUSH 0
+	RETURNDATASIZE
+	PUSH 0x40
+	MLOAD
+	PUSH 0x60
+	ADD
+	ADD
+	MSTORE
+	PUSH 0xc0
+	PUSH 0x40
+	MLOAD
+	SUB
+	PUSH 0
+	MLOAD
+	PUSH 5
+	SHL
+	PUSH 0xc0
+	ADD
+	MSTORE
+	PUSH 0x1f
+	RETURNDATASIZE
+	ADD
+	PUSH 5
+	SHR
+	PUSH 5
+	SHL
+	PUSH 0x60
+	ADD
+	PUSH 0x40
+	MLOAD
+	ADD
+	PUSH 0x40
+	MSTORE
+	PUSH 1
+	PUSH 0
+	MLOAD
+	ADD
+	PUSH 0
+	MSTORE
+	JUMP @loop
+
+end:
+	PUSH 0x80
+	PUSH 0x40
+	MLOAD
+	SUB
+	PUSH 0x80
+	RETURN
+`
+
+var runtimeCode = func() []byte {
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex([]byte(source), false))
+	code, errs := compiler.Compile()
+	if len(errs) != 0 {
+		panic(fmt.Sprintf("multicall: %v", errs))
+	}
+	return common.FromHex(code)
+}()
+
+var (
+	call3Arguments   abi.Arguments
+	resultsArguments abi.Arguments
+	errorArguments   abi.Arguments
+)
+
+func init() {
+	call3Type, err := abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "target", Type: "address"},
+		{Name: "allowFailure", Type: "bool"},
+		{Name: "callData", Type: "bytes"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	resultType, err := abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "success", Type: "bool"},
+		{Name: "returnData", Type: "bytes"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	call3Arguments = abi.Arguments{{Type: call3Type}}
+	resultsArguments = abi.Arguments{{Type: resultType}}
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		panic(err)
+	}
+	errorArguments = abi.Arguments{{Type: stringType}}
+}
+
+// Call3 is a call of aggregate3, a failure reverts the whole aggregate3 unless it is allowed
+type Call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result is what a call of aggregate3 returned, or the revert data of a failed call
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// RuntimeCode is the code of the contract
+func RuntimeCode() []byte {
+	return common.CopyBytes(runtimeCode)
+}
+
+// CreationCode deploys the contract, it has no constructor
+func CreationCode() []byte {
+	code := []byte{0x61, byte(len(runtimeCode) >> 8), byte(len(runtimeCode)), 0x80, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(code, runtimeCode...)
+}
+
+// IsAggregate3 reports whether data calls aggregate3
+func IsAggregate3(data []byte) bool {
+	return len(data) >= 4 && string(data[:4]) == string(Aggregate3Selector)
+}
+
+// DecodeAggregate3 decodes the calls of the calldata of aggregate3
+func DecodeAggregate3(data []byte) ([]Call3, error) {
+	if !IsAggregate3(data) {
+		return nil, errors.New("not a call of aggregate3")
+	}
+	values, err := call3Arguments.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var calls []Call3
+	if err := call3Arguments.Copy(&calls, values); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+// EncodeAggregate3 encodes the calldata of aggregate3
+func EncodeAggregate3(calls []Call3) ([]byte, error) {
+	arguments, err := call3Arguments.Pack(calls)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(Aggregate3Selector), arguments...), nil
+}
+
+// EncodeResults encodes the results like aggregate3 returns them
+func EncodeResults(results []Result) ([]byte, error) {
+	return resultsArguments.Pack(results)
+}
+
+// CallFailedRevert is what aggregate3 reverts with when a call that isn't allowed to fail fails
+func CallFailedRevert() []byte {
+	reason, err := errorArguments.Pack(CallFailed)
+	if err != nil {
+		panic(err)
+	}
+	return append(common.CopyBytes(errorSelector), reason...)
+}
@@ -0,0 +1,115 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/qtumproject/janus/pkg/evm"
+)
+
+var (
+	caller    = common.HexToAddress("0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960")
+	multicall = common.HexToAddress("0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c")
+	// returns 42
+	answer = common.HexToAddress("0x0000000000000000000000000000000000000a0a")
+	// reverts with the calldata it was called with
+	reverter = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+type testReader map[common.Address][]byte
+
+func (r testReader) Account(ctx context.Context, address common.Address) (*big.Int, []byte, error) {
+	return new(big.Int), r[address], nil
+}
+
+func (r testReader) Storage(ctx context.Context, address common.Address) (map[common.Hash]common.Hash, error) {
+	return nil, nil
+}
+
+func run(t *testing.T, to *common.Address, data []byte) *evm.Result {
+	state := evm.NewStateDB(context.Background(), testReader{
+		multicall: RuntimeCode(),
+		answer:    common.FromHex("602a60005260206000f3"),
+		reverter:  common.FromHex("366000600037366000fd"),
+	})
+	block := evm.Block{Number: big.NewInt(100), Time: 1600000000, GasLimit: 40000000}
+	result, err := evm.Run(state, big.NewInt(8890), block, evm.Message{From: caller, To: to, Gas: 1000000, Data: data}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCreationCode(t *testing.T) {
+	result := run(t, nil, CreationCode())
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if !bytes.Equal(result.ReturnData, RuntimeCode()) {
+		t.Errorf("expected the runtime code to be deployed, got %x", result.ReturnData)
+	}
+}
+
+func TestAggregate3(t *testing.T) {
+	calls := []Call3{
+		{Target: answer, CallData: common.FromHex("01020304")},
+		{Target: reverter, AllowFailure: true, CallData: common.FromHex("0badc0de")},
+		{Target: answer},
+	}
+	data, err := EncodeAggregate3(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeAggregate3(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 3 || decoded[1].Target != reverter || !decoded[1].AllowFailure || !bytes.Equal(decoded[1].CallData, calls[1].CallData) {
+		t.Errorf("unexpected calls %+v", decoded)
+	}
+
+	to := multicall
+	result := run(t, &to, data)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	want, err := EncodeResults([]Result{
+		{Success: true, ReturnData: common.BigToHash(big.NewInt(42)).Bytes()},
+		{Success: false, ReturnData: common.FromHex("0badc0de")},
+		{Success: true, ReturnData: common.BigToHash(big.NewInt(42)).Bytes()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.ReturnData, want) {
+		t.Errorf("expected the contract to return what Janus encodes\n%x\n%x", want, result.ReturnData)
+	}
+
+	// a call that isn't allowed to fail reverts everything
+	calls[1].AllowFailure = false
+	data, err = EncodeAggregate3(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = run(t, &to, data)
+	if result.Err != vm.ErrExecutionReverted {
+		t.Fatalf("expected a revert, got %v", result.Err)
+	}
+	if !bytes.Equal(result.ReturnData, CallFailedRevert()) {
+		t.Errorf("expected Multicall3's revert reason, got %x", result.ReturnData)
+	}
+}
+
+func TestDecodeAggregate3NotAggregate3(t *testing.T) {
+	if _, err := DecodeAggregate3(common.FromHex("70a08231")); err == nil {
+		t.Error("expected calldata of another function to be rejected")
+	}
+	to := multicall
+	if result := run(t, &to, common.FromHex("70a08231")); result.Err != vm.ErrExecutionReverted {
+		t.Errorf("expected the contract to revert other functions, got %v", result.Err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package names resolves human-readable names like alice.qtum to hex addresses, so clients can pass names wherever
+// Janus takes an address, like they do with ENS names on Ethereum
+package names
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by resolvers for names they don't know
+var ErrNotFound = errors.New("name not found")
+
+// Resolver turns names into hex addresses
+type Resolver interface {
+	// Resolve returns the 0x prefixed hex address of name, ErrNotFound when the resolver doesn't know it
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// IsName reports whether value is a name rather than an address, dot separated labels with a top level label that
+// isn't a number
+func IsName(value string) bool {
+	if common.IsHexAddress(value) || !strings.Contains(value, ".") {
+		return false
+	}
+	labels := strings.Split(value, ".")
+	for _, label := range labels {
+		if label == "" || strings.ContainsAny(label, " \t\n/:") {
+			return false
+		}
+	}
+	return strings.TrimLeft(labels[len(labels)-1], "0123456789") != ""
+}
+
+// Normalize lower cases a name, names are case insensitive
+func Normalize(name string) string {
+	return strings.ToLower(name)
+}
+
+// Namehash is the ENS node of name
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(Normalize(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// Static resolves the names of an operator defined registry, by normalized name
+type Static map[string]string
+
+var _ Resolver = Static(nil)
+
+// NewStatic checks the addresses of a registry and normalizes its names
+func NewStatic(registry map[string]string) (Static, error) {
+	static := make(Static, len(registry))
+	for name, address := range registry {
+		if !common.IsHexAddress(address) {
+			return nil, errors.Errorf("invalid address %q of %s, expected a hex address", address, name)
+		}
+		static[Normalize(name)] = strings.ToLower(common.HexToAddress(address).Hex())
+	}
+	return static, nil
+}
+
+func (s Static) Resolve(ctx context.Context, name string) (string, error) {
+	address, ok := s[Normalize(name)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return address, nil
+}
+
+// Resolvers asks each resolver in turn, the first that knows a name resolves it
+type Resolvers []Resolver
+
+var _ Resolver = Resolvers(nil)
+
+func (r Resolvers) Resolve(ctx context.Context, name string) (string, error) {
+	for _, resolver := range r {
+		address, err := resolver.Resolve(ctx, name)
+		if err != ErrNotFound {
+			return address, err
+		}
+	}
+	return "", ErrNotFound
+}
@@ -0,0 +1,97 @@
+package names
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestNamehash(t *testing.T) {
+	for name, want := range map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+		"Foo.ETH": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	} {
+		if got := Namehash(name).Hex(); got != want {
+			t.Errorf("namehash of %q: got %s, expected %s", name, got, want)
+		}
+	}
+}
+
+func TestIsName(t *testing.T) {
+	for value, want := range map[string]bool{
+		"alice.qtum": true,
+		"a.b.qtum":   true,
+		"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960": false,
+		"latest":        false,
+		"1.5":           false,
+		"alice..qtum":   false,
+		"http://a.qtum": false,
+	} {
+		if got := IsName(value); got != want {
+			t.Errorf("IsName(%q): got %v, expected %v", value, got, want)
+		}
+	}
+}
+
+func TestResolvers(t *testing.T) {
+	first, err := NewStatic(map[string]string{"alice.qtum": "0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewStatic(map[string]string{"alice.qtum": "0x0000000000000000000000000000000000000001", "bob.qtum": "0x0000000000000000000000000000000000000002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolvers := Resolvers{first, second}
+	if address, err := resolvers.Resolve(context.Background(), "ALICE.qtum"); err != nil || address != "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960" {
+		t.Errorf("expected the first resolver to resolve alice.qtum, got %s %v", address, err)
+	}
+	if address, err := resolvers.Resolve(context.Background(), "bob.qtum"); err != nil || address != "0x0000000000000000000000000000000000000002" {
+		t.Errorf("expected the second resolver to resolve bob.qtum, got %s %v", address, err)
+	}
+	if _, err := resolvers.Resolve(context.Background(), "carol.qtum"); err != ErrNotFound {
+		t.Errorf("expected carol.qtum to be unknown, got %v", err)
+	}
+	if _, err := NewStatic(map[string]string{"alice.qtum": "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"}); err == nil {
+		t.Error("expected a base58 address to be rejected")
+	}
+}
+
+func callContractOutput(output string) json.RawMessage {
+	return json.RawMessage(`{"address": "", "executionResult": {"excepted": "None", "output": "` + output + `"}}`)
+}
+
+func TestRegistry(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := NewRegistry(qtumClient, "0x00000000000c2e074ec69a0dfb2997ba6c7d2e1e")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the resolver of the name, then its address
+	for _, output := range []string{
+		"0000000000000000000000004976fb03c32e5b8cfe2b6ccb31c09ba78ebaba41",
+		"0000000000000000000000001e6f89d7399081b4f8f8aa1ae2805a5efff2f960",
+		// a name without a resolver
+		"0000000000000000000000000000000000000000000000000000000000000000",
+	} {
+		if err := mockedClientDoer.AddResponse(qtum.MethodCallContract, callContractOutput(output)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if address, err := registry.Resolve(context.Background(), "alice.qtum"); err != nil || address != "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960" {
+		t.Errorf("got %s %v, expected alice.qtum's address", address, err)
+	}
+	if _, err := registry.Resolve(context.Background(), "bob.qtum"); err != ErrNotFound {
+		t.Errorf("expected a name without a resolver to be unknown, got %v", err)
+	}
+}
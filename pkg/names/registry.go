@@ -0,0 +1,78 @@
+package names
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// the selectors of ENS's registry resolver(bytes32) and resolver addr(bytes32)
+const (
+	resolverSelector = "0178b8bf"
+	addrSelector     = "3b3b57de"
+)
+
+// Registry resolves names through an ENS compatible registry contract on the chain, asking the registry for the
+// resolver of a name then the resolver for its address
+type Registry struct {
+	qtum *qtum.Qtum
+	// hex address of the registry, lower case without 0x
+	address string
+}
+
+var _ Resolver = (*Registry)(nil)
+
+// NewRegistry resolves names through the registry at the hex address
+func NewRegistry(client *qtum.Qtum, address string) (*Registry, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.Errorf("invalid name registry address %q, expected a hex address", address)
+	}
+	return &Registry{qtum: client, address: strings.ToLower(utils.RemoveHexPrefix(address))}, nil
+}
+
+func (r *Registry) Resolve(ctx context.Context, name string) (string, error) {
+	node := Namehash(name)
+	resolver, err := r.callAddress(ctx, r.address, resolverSelector, node)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't get the resolver of "+name)
+	}
+	if resolver == (common.Address{}) {
+		return "", ErrNotFound
+	}
+	address, err := r.callAddress(ctx, strings.ToLower(utils.RemoveHexPrefix(resolver.Hex())), addrSelector, node)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't get the address of "+name)
+	}
+	if address == (common.Address{}) {
+		return "", ErrNotFound
+	}
+	return strings.ToLower(address.Hex()), nil
+}
+
+// callAddress calls a function of contract taking a node and returning an address
+func (r *Registry) callAddress(ctx context.Context, contract string, selector string, node common.Hash) (common.Address, error) {
+	resp, err := r.qtum.CallContract(ctx, &qtum.CallContractRequest{
+		To:   contract,
+		Data: fmt.Sprintf("%s%x", selector, node.Bytes()),
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+	if resp.ExecutionResult.Excepted != "None" {
+		return common.Address{}, errors.Errorf("call reverted: %s", resp.ExecutionResult.Excepted)
+	}
+	output, err := hex.DecodeString(resp.ExecutionResult.Output)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid output")
+	}
+	if len(output) < 32 {
+		return common.Address{}, errors.Errorf("expected an address, got %d bytes", len(output))
+	}
+	return common.BytesToAddress(output[12:32]), nil
+}
@@ -20,7 +20,7 @@ var agentConfigNewHeadsInterval = 10 * time.Second
 
 // Allows dependency injection of eth rpc calls as the transformer package imports this package
 type Transformer interface {
-	Transform(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError)
+	Transform(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError)
 }
 
 func NewAgent(ctx context.Context, qtum *qtum.Qtum, transformer Transformer) *Agent {
@@ -90,23 +90,7 @@ func (s *subscriptionRegistry) SendAll(message interface{}) {
 	send := func(s *subscriptionInformation) {
 		// send writes to a queue that can block when full if a client has a lot of responses queued up
 		// that could potentially affect other clients so we run this in a goroutine
-		// params := struct {
-		// 	Subscription string      `json:"subscription"`
-		// 	Result       interface{} `json:"result"`
-		// }{
-		// 	Subscription: s.Subscription.id,
-		// 	Result:       message,
-		// }
-		params := eth.EthSubscriptionParams{
-			SubscriptionID: s.Subscription.id,
-			Result:         message,
-		}
-		subscription := &eth.EthSubscription{
-			Version: "2.0",
-			Method:  "eth_subscription",
-			Params:  params,
-		}
-		go s.Send(subscription)
+		go s.notifyHead(message)
 	}
 	s.forEach(send)
 }
@@ -123,6 +107,11 @@ type Agent struct {
 	logs          *subscriptionRegistry
 	newPendingTxs *subscriptionRegistry
 	syncing       *subscriptionRegistry
+	// shares notifications with other Janus instances, nil when this instance polls qtumd for its own subscriptions
+	backbone Backbone
+	limits   NotificationLimits
+	// set while qtumd's mempool is polled for newPendingTransactions subscriptions
+	pendingRunning bool
 }
 
 func (a *Agent) SetTransformer(transformer Transformer) {
@@ -255,7 +244,30 @@ func (a *Agent) NewSubscription(notifier *Notifier, params *eth.EthSubscriptionR
 		cancel,
 		false,
 		a.qtum,
+		a.getBackbone() != nil,
+		a.getNotificationLimits(),
+		nil,
+	}
+	if wrappedSubscription.limits.Rate != 0 {
+		// held back logs and pending transactions are sent together, held back heads are replaced by the latest one
+		send, latestOnly := wrappedSubscription.sendHead, true
+		switch strings.ToLower(params.Method) {
+		case "logs":
+			send, latestOnly = wrappedSubscription.sendLogs, false
+		case "newpendingtransactions":
+			send, latestOnly = wrappedSubscription.sendPendingTransactions, false
+		}
+		wrappedSubscription.limiter = newNotificationLimiter(wrappedContext, wrappedSubscription.limits, latestOnly, send)
+	}
+	var backlog func() int
+	if wrappedSubscription.limiter != nil {
+		backlog = wrappedSubscription.limiter.backlog
 	}
+	var filter interface{}
+	if params.Params != nil {
+		filter = params.Params
+	}
+	subscription.describe(params.Method, filter, backlog)
 
 	switch strings.ToLower(params.Method) {
 	case "logs":
@@ -264,6 +276,8 @@ func (a *Agent) NewSubscription(notifier *Notifier, params *eth.EthSubscriptionR
 		addSubscription(wrappedSubscription, a.newHeads)
 	case "newpendingtransactions":
 		addSubscription(wrappedSubscription, a.newPendingTxs)
+		// each instance polls its own qtumd's mempool, it isn't shared through the backbone
+		go a.runPendingTransactions()
 	case "syncing":
 		addSubscription(wrappedSubscription, a.syncing)
 	default:
@@ -271,7 +285,7 @@ func (a *Agent) NewSubscription(notifier *Notifier, params *eth.EthSubscriptionR
 	}
 
 	a.mutex.RLock()
-	if !a.running {
+	if !a.running && a.backbone == nil {
 		// start processing subscriptions if nothing is running
 		// only one routine will run at once so if multiple startup they will exit so only one runs
 		go a.run()
@@ -281,6 +295,15 @@ func (a *Agent) NewSubscription(notifier *Notifier, params *eth.EthSubscriptionR
 	return subscription.id, nil
 }
 
+// waitForBlock returns a channel closed once qtumd announces its next block over ZMQ, and how long to wait for it
+// before polling qtumd anyway. Without ZMQ the channel is nil and qtumd is polled every interval
+func waitForBlock(q *qtum.Qtum, interval time.Duration) (<-chan struct{}, time.Duration) {
+	if zmq := q.ZMQ(); zmq != nil && zmq.NotifiesBlocks() {
+		return zmq.NextBlock(), qtum.ZMQPollInterval
+	}
+	return nil, interval
+}
+
 func (a *Agent) isRunning() bool {
 	a.mutex.RLock()
 	defer a.mutex.RUnlock()
@@ -289,7 +312,7 @@ func (a *Agent) isRunning() bool {
 
 func (a *Agent) run() {
 	newHeadsSubscriptions := a.newHeads.Count()
-	if newHeadsSubscriptions == 0 {
+	if newHeadsSubscriptions == 0 || a.getBackbone() != nil {
 		return
 	}
 
@@ -330,7 +353,6 @@ func (a *Agent) run() {
 		panic(fmt.Sprintf("Unexpected %s type", agentConfigNewHeadsKey))
 	}
 
-	// TODO: newPendingTransactions
 	a.qtum.GetDebugLogger().Log("msg", "Agent started subscription processing thread")
 
 	for {
@@ -339,6 +361,8 @@ func (a *Agent) run() {
 		if newHeadsSubscriptions == 0 {
 			return
 		}
+		// before polling, so a block announced while polling isn't missed
+		nextBlock, interval := waitForBlock(a.qtum, newHeadsInterval)
 
 		a.mutex.RLock()
 		transformer := a.transformer
@@ -365,7 +389,7 @@ func (a *Agent) run() {
 					if err != nil {
 						panic(fmt.Sprintf("Failed to serialize eth_getBlockByHash request parameters: %s", err))
 					}
-					result, jsonErr := transformer.Transform(&eth.JSONRPCRequest{
+					result, jsonErr := transformer.Transform(a.ctx, &eth.JSONRPCRequest{
 						JSONRPC: "2.0",
 						Method:  "eth_getBlockByHash",
 						Params:  params,
@@ -390,7 +414,8 @@ func (a *Agent) run() {
 		}
 
 		select {
-		case <-time.After(newHeadsInterval):
+		case <-nextBlock:
+		case <-time.After(interval):
 			// continue
 		case <-a.ctx.Done():
 			return
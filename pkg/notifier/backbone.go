@@ -0,0 +1,205 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dcb9/go-ethereum/common/hexutil"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// Backbone carries notifications between Janus instances, so websocket clients can subscribe on any instance behind a
+// load balancer while only one of them polls qtumd for new blocks
+type Backbone interface {
+	// Publish sends payload to every instance listening on topic, including this one
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Listen calls deliver with the payloads published on topic after it was called, until ctx is done
+	Listen(ctx context.Context, topic string, deliver func(payload []byte)) error
+	// Lead reports whether this instance produces the notifications, at most one instance leads at a time and an
+	// instance keeps leading by calling Lead regularly
+	Lead(ctx context.Context) (bool, error)
+}
+
+const (
+	backboneNewHeadsTopic = "newHeads"
+	// the logs of one block, every instance filters them for its own subscriptions
+	backboneLogsTopic = "logs"
+)
+
+// how many blocks the leader publishes at most when it falls behind, older ones are skipped
+var backboneMaxCatchUp = int64(10)
+
+// how long to wait before listening again after the backbone failed
+var backboneRetryInterval = time.Second
+
+// SetBackbone makes the agent deliver notifications received through backbone to its subscribers and publish them
+// while it leads, instead of polling qtumd for each of its own subscriptions
+func (a *Agent) SetBackbone(backbone Backbone) {
+	a.mutex.Lock()
+	a.backbone = backbone
+	a.mutex.Unlock()
+
+	go a.listen(backbone, backboneNewHeadsTopic, a.deliverNewHeads)
+	go a.listen(backbone, backboneLogsTopic, a.deliverLogs)
+	go a.produce(backbone)
+}
+
+func (a *Agent) getBackbone() Backbone {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.backbone
+}
+
+func (a *Agent) listen(backbone Backbone, topic string, deliver func([]byte)) {
+	for {
+		err := backbone.Listen(a.ctx, topic, deliver)
+		select {
+		case <-a.ctx.Done():
+			return
+		default:
+		}
+		a.qtum.GetErrorLogger().Log("msg", "Lost notification backbone, listening again", "topic", topic, "err", err)
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(backboneRetryInterval):
+		}
+	}
+}
+
+func (a *Agent) deliverNewHeads(payload []byte) {
+	a.newHeads.SendAll(json.RawMessage(payload))
+}
+
+func (a *Agent) deliverLogs(payload []byte) {
+	var logs []eth.Log
+	if err := json.Unmarshal(payload, &logs); err != nil {
+		a.qtum.GetErrorLogger().Log("msg", "Received invalid logs from the notification backbone", "err", err)
+		return
+	}
+	a.logs.forEach(func(s *subscriptionInformation) {
+		s.deliverLogs(logs)
+	})
+}
+
+// produce publishes new heads and their logs while this instance leads
+func (a *Agent) produce(backbone Backbone) {
+	newHeadsIntervalValue := a.getConfigValue(agentConfigNewHeadsKey, agentConfigNewHeadsInterval)
+	newHeadsInterval, ok := newHeadsIntervalValue.(time.Duration)
+	if !ok {
+		panic(fmt.Sprintf("Unexpected %s type", agentConfigNewHeadsKey))
+	}
+
+	lastBlock := int64(0)
+	for {
+		nextBlock, interval := waitForBlock(a.qtum, newHeadsInterval)
+		leading, err := backbone.Lead(a.ctx)
+		if err != nil {
+			a.qtum.GetErrorLogger().Log("msg", "Failed to check notification backbone leadership", "err", err)
+		}
+		if leading {
+			lastBlock = a.publishNewBlocks(backbone, lastBlock)
+		} else {
+			// start from the tip when leading again, the leader in between published the blocks since
+			lastBlock = 0
+		}
+
+		select {
+		case <-nextBlock:
+		case <-time.After(interval):
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// publishNewBlocks publishes the blocks after lastBlock, returning the last one published
+func (a *Agent) publishNewBlocks(backbone Backbone, lastBlock int64) int64 {
+	a.mutex.RLock()
+	transformer := a.transformer
+	a.mutex.RUnlock()
+	if transformer == nil {
+		a.qtum.GetErrorLogger().Log("msg", "Agent does not have access to eth transformer, cannot publish notifications")
+		return lastBlock
+	}
+
+	blockchainInfo, err := a.qtum.GetBlockChainInfo(a.ctx)
+	if err != nil {
+		a.qtum.GetErrorLogger().Log("msg", "Failure getting blockchaininfo", "err", err)
+		return lastBlock
+	}
+	latestBlock := blockchainInfo.Blocks
+	if lastBlock == 0 {
+		// like newHeads without a backbone, the current head isn't sent
+		return latestBlock
+	}
+	if latestBlock-lastBlock > backboneMaxCatchUp {
+		lastBlock = latestBlock - backboneMaxCatchUp
+	}
+
+	for number := lastBlock + 1; number <= latestBlock; number++ {
+		if err := a.publishBlock(backbone, transformer, number); err != nil {
+			a.qtum.GetErrorLogger().Log("msg", "Failed to publish block notifications", "block", number, "err", err)
+			return number - 1
+		}
+	}
+	return latestBlock
+}
+
+func (a *Agent) publishBlock(backbone Backbone, transformer Transformer, number int64) error {
+	hash, err := a.qtum.GetBlockHash(a.ctx, big.NewInt(number))
+	if err != nil {
+		return err
+	}
+	result, err := a.transform(transformer, "eth_getBlockByHash", utils.AddHexPrefix(string(hash)), false)
+	if err != nil {
+		return err
+	}
+	block, ok := result.(*eth.GetBlockByHashResponse)
+	if !ok {
+		return fmt.Errorf("unexpected eth_getBlockByHash response type %T", result)
+	}
+
+	// logs go out first so a client reacting to a new head finds its logs delivered
+	hexNumber := hexutil.EncodeUint64(uint64(number))
+	result, err = a.transform(transformer, "eth_getLogs", map[string]string{"fromBlock": hexNumber, "toBlock": hexNumber})
+	if err != nil {
+		return err
+	}
+	if logs, ok := result.(*eth.GetLogsResponse); ok && logs != nil && len(*logs) > 0 {
+		if err := a.publish(backbone, backboneLogsTopic, *logs); err != nil {
+			return err
+		}
+	}
+
+	a.qtum.GetDebugLogger().Log("msg", "Publishing new head", "block", number)
+	return a.publish(backbone, backboneNewHeadsTopic, eth.NewEthSubscriptionNewHeadResponse(block))
+}
+
+func (a *Agent) transform(transformer Transformer, method string, params ...interface{}) (interface{}, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	result, jsonErr := transformer.Transform(a.ctx, &eth.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  rawParams,
+	}, nil)
+	if jsonErr != nil {
+		return nil, fmt.Errorf("%s failed: %s", method, jsonErr.Message())
+	}
+	return result, nil
+}
+
+func (a *Agent) publish(backbone Backbone, topic string, value interface{}) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return backbone.Publish(a.ctx, topic, payload)
+}
@@ -0,0 +1,176 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+type memoryBackbone struct {
+	mutex     sync.Mutex
+	leading   bool
+	listeners map[string][]func([]byte)
+	published chan string
+}
+
+func newMemoryBackbone(leading bool) *memoryBackbone {
+	return &memoryBackbone{
+		leading:   leading,
+		listeners: make(map[string][]func([]byte)),
+		published: make(chan string, 10),
+	}
+}
+
+func (b *memoryBackbone) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mutex.Lock()
+	listeners := b.listeners[topic]
+	b.mutex.Unlock()
+	for _, deliver := range listeners {
+		deliver(payload)
+	}
+	b.published <- topic
+	return nil
+}
+
+func (b *memoryBackbone) Listen(ctx context.Context, topic string, deliver func([]byte)) error {
+	b.mutex.Lock()
+	b.listeners[topic] = append(b.listeners[topic], deliver)
+	b.mutex.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *memoryBackbone) Lead(ctx context.Context) (bool, error) {
+	return b.leading, nil
+}
+
+type backboneTestTransformer struct{}
+
+func (backboneTestTransformer) Transform(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	switch req.Method {
+	case "eth_getBlockByHash":
+		return &eth.GetBlockByHashResponse{Number: "0x2", Hash: "0xbba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"}, nil
+	case "eth_getLogs":
+		return &eth.GetLogsResponse{{BlockNumber: "0x2", Address: "0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"}}, nil
+	}
+	return nil, eth.NewMethodNotFoundError(req.Method)
+}
+
+func TestBackboneDeliversMatchingLogs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockedClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+	backbone := newMemoryBackbone(false)
+	agent.SetBackbone(backbone)
+
+	sent := make(chan []byte, 10)
+	notifierContext, cancelNotifierContext := context.WithCancel(ctx)
+	notifier := NewNotifier(notifierContext, cancelNotifierContext, func(v []byte) error {
+		sent <- v
+		return nil
+	}, log.NewLogfmtLogger(os.Stdout))
+
+	id, err := agent.NewSubscription(notifier, &eth.EthSubscriptionRequest{
+		Method: "logs",
+		Params: &eth.EthLogSubscriptionParameter{Address: "0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.ResponseSent()
+
+	// wait for the agent to listen
+	for deadline := time.Now().Add(time.Second); ; {
+		backbone.mutex.Lock()
+		listening := len(backbone.listeners[backboneLogsTopic]) > 0
+		backbone.mutex.Unlock()
+		if listening {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("agent isn't listening to the backbone")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	logs, err := json.Marshal([]eth.Log{
+		{BlockNumber: "0x2", LogIndex: "0x0", Address: "0x0000000000000000000000000000000000000001"},
+		{BlockNumber: "0x2", LogIndex: "0x1", Address: "0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backbone.Publish(ctx, backboneLogsTopic, logs)
+
+	select {
+	case got := <-sent:
+		var notification eth.JSONRPCNotification
+		if err := json.Unmarshal(got, &notification); err != nil {
+			t.Fatal(err)
+		}
+		var subscription struct {
+			SubscriptionID string  `json:"subscription"`
+			Result         eth.Log `json:"result"`
+		}
+		if err := json.Unmarshal(notification.Params, &subscription); err != nil {
+			t.Fatal(err)
+		}
+		if subscription.SubscriptionID != id || subscription.Result.LogIndex != "0x1" {
+			t.Errorf("unexpected notification %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the log")
+	}
+
+	select {
+	case got := <-sent:
+		t.Errorf("log from another address delivered %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBackbonePublishesNewBlocksWhileLeading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	doer := internal.NewDoerMappedMock()
+	for i := int64(1); i <= 2; i++ {
+		doer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: i})
+	}
+	doer.AddResponse(qtum.MethodGetBlockHash, "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5")
+	mockedClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{agentConfigNewHeadsKey: 50 * time.Millisecond}
+	agent := newAgentWithConfiguration(ctx, mockedClient, backboneTestTransformer{}, config)
+	backbone := newMemoryBackbone(true)
+	agent.SetBackbone(backbone)
+
+	// the head when it started leading isn't published, block 2 is
+	for _, want := range []string{backboneLogsTopic, backboneNewHeadsTopic} {
+		select {
+		case topic := <-backbone.published:
+			if topic != want {
+				t.Fatalf("published %s, want %s", topic, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
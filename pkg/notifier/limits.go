@@ -0,0 +1,178 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NotificationLimits caps the notifications of every subscription, so a subscription matching a busy contract doesn't
+// flood a client on a slow connection
+type NotificationLimits struct {
+	// notifications per second of a subscription, 0 leaves them unlimited. Logs over the rate are coalesced into one
+	// notification with an array of logs, newHeads over the rate skip to the latest head
+	Rate float64
+	// notifications sent right away before the rate applies
+	Burst int
+	// send the logs of a block in one notification whose result is an array of logs, even under the rate
+	BatchLogs bool
+}
+
+func (limits NotificationLimits) validate() error {
+	if limits.Rate < 0 {
+		return errors.New("notification rate can't be negative")
+	}
+	if limits.Burst < 0 {
+		return errors.New("notification burst can't be negative")
+	}
+	return nil
+}
+
+// SetNotificationLimits applies limits to the subscriptions made from now on
+func (a *Agent) SetNotificationLimits(limits NotificationLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	a.mutex.Lock()
+	a.limits = limits
+	a.mutex.Unlock()
+	return nil
+}
+
+func (a *Agent) getNotificationLimits() NotificationLimits {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.limits
+}
+
+// notificationLimiter is a token bucket in front of a subscription, what it can't send right away is coalesced and
+// sent in one notification once the bucket has a token again
+type notificationLimiter struct {
+	ctx   context.Context
+	rate  float64
+	burst float64
+	// keep only the latest pending result instead of all of them, for newHeads where only the tip matters
+	latestOnly bool
+	// sends one notification, with every result given at once
+	send func(results []interface{})
+
+	// serializes sending so coalesced results don't overtake or get overtaken
+	sendMutex sync.Mutex
+	mutex     sync.Mutex
+	tokens    float64
+	updated   time.Time
+	pending   []interface{}
+	timer     *time.Timer
+}
+
+func newNotificationLimiter(ctx context.Context, limits NotificationLimits, latestOnly bool, send func([]interface{})) *notificationLimiter {
+	burst := float64(limits.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &notificationLimiter{
+		ctx:        ctx,
+		rate:       limits.Rate,
+		burst:      burst,
+		latestOnly: latestOnly,
+		send:       send,
+		tokens:     burst,
+		updated:    time.Now(),
+	}
+}
+
+// notify sends each result in its own notification, or all of them in one when batch is set, as far as the rate
+// allows. The rest waits for the next token
+func (l *notificationLimiter) notify(results []interface{}, batch bool) {
+	l.sendMutex.Lock()
+	defer l.sendMutex.Unlock()
+
+	var notifications [][]interface{}
+	l.mutex.Lock()
+	l.refill()
+	// pending results go first
+	if len(l.pending) == 0 {
+		if batch {
+			if l.take() {
+				notifications = append(notifications, results)
+				results = nil
+			}
+		} else {
+			for len(results) != 0 && l.take() {
+				notifications = append(notifications, results[:1])
+				results = results[1:]
+			}
+		}
+	}
+	if len(results) != 0 {
+		if l.latestOnly {
+			l.pending = results[len(results)-1:]
+		} else {
+			l.pending = append(l.pending, results...)
+		}
+		l.schedule()
+	}
+	l.mutex.Unlock()
+
+	for _, notification := range notifications {
+		l.send(notification)
+	}
+}
+
+// backlog is the number of results held back
+func (l *notificationLimiter) backlog() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.pending)
+}
+
+func (l *notificationLimiter) flush() {
+	l.sendMutex.Lock()
+	defer l.sendMutex.Unlock()
+
+	l.mutex.Lock()
+	l.timer = nil
+	if l.ctx.Err() != nil || len(l.pending) == 0 {
+		l.mutex.Unlock()
+		return
+	}
+	l.refill()
+	if !l.take() {
+		l.schedule()
+		l.mutex.Unlock()
+		return
+	}
+	pending := l.pending
+	l.pending = nil
+	l.mutex.Unlock()
+
+	l.send(pending)
+}
+
+func (l *notificationLimiter) refill() {
+	now := time.Now()
+	l.tokens += now.Sub(l.updated).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.updated = now
+}
+
+func (l *notificationLimiter) take() bool {
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// schedule flushes the pending results once the next token is there
+func (l *notificationLimiter) schedule() {
+	if l.timer != nil {
+		return
+	}
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	l.timer = time.AfterFunc(wait, l.flush)
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedNotifications struct {
+	mutex         sync.Mutex
+	notifications [][]interface{}
+	sent          chan struct{}
+}
+
+func (r *recordedNotifications) send(results []interface{}) {
+	r.mutex.Lock()
+	r.notifications = append(r.notifications, results)
+	r.mutex.Unlock()
+	r.sent <- struct{}{}
+}
+
+func (r *recordedNotifications) wait(t *testing.T, count int) [][]interface{} {
+	for i := 0; i < count; i++ {
+		select {
+		case <-r.sent:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d notifications", count)
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.notifications
+}
+
+func TestNotificationLimiterCoalescesLogs(t *testing.T) {
+	recorded := &recordedNotifications{sent: make(chan struct{}, 10)}
+	limiter := newNotificationLimiter(context.Background(), NotificationLimits{Rate: 20, Burst: 1}, false, recorded.send)
+
+	start := time.Now()
+	limiter.notify([]interface{}{"a", "b", "c"}, false)
+	limiter.notify([]interface{}{"d"}, false)
+
+	notifications := recorded.wait(t, 2)
+	want := [][]interface{}{{"a"}, {"b", "c", "d"}}
+	if !reflect.DeepEqual(notifications, want) {
+		t.Errorf("expected %v, got %v", want, notifications)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("coalesced logs were sent after %s, before the next token", elapsed)
+	}
+}
+
+func TestNotificationLimiterKeepsLatestHead(t *testing.T) {
+	recorded := &recordedNotifications{sent: make(chan struct{}, 10)}
+	limiter := newNotificationLimiter(context.Background(), NotificationLimits{Rate: 20, Burst: 1}, true, recorded.send)
+
+	for _, head := range []string{"0x1", "0x2", "0x3"} {
+		limiter.notify([]interface{}{head}, false)
+	}
+
+	notifications := recorded.wait(t, 2)
+	want := [][]interface{}{{"0x1"}, {"0x3"}}
+	if !reflect.DeepEqual(notifications, want) {
+		t.Errorf("expected %v, got %v", want, notifications)
+	}
+}
+
+func TestNotificationLimiterBatches(t *testing.T) {
+	recorded := &recordedNotifications{sent: make(chan struct{}, 10)}
+	limiter := newNotificationLimiter(context.Background(), NotificationLimits{Rate: 20, Burst: 2, BatchLogs: true}, false, recorded.send)
+
+	limiter.notify([]interface{}{"a", "b"}, true)
+	limiter.notify([]interface{}{"c", "d"}, true)
+
+	notifications := recorded.wait(t, 2)
+	want := [][]interface{}{{"a", "b"}, {"c", "d"}}
+	if !reflect.DeepEqual(notifications, want) {
+		t.Errorf("expected %v, got %v", want, notifications)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -21,6 +23,22 @@ type Subscription struct {
 	id          string
 	once        sync.Once
 	unsubscribe UnsubscribeCallback
+	createdAt   time.Time
+
+	// set by the agent, for operators looking into subscriptions
+	kind    string
+	filter  interface{}
+	backlog func() int
+}
+
+// SubscriptionStatus describes a subscription to operators
+type SubscriptionStatus struct {
+	ID     string      `json:"id"`
+	Type   string      `json:"type"`
+	Filter interface{} `json:"filter,omitempty"`
+	// notifications held back by the notification rate limit
+	Backlog   int       `json:"backlog"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 func NewSubscription(notifier *Notifier, callback UnsubscribeCallback) (*Subscription, error) {
@@ -37,6 +55,10 @@ func NewSubscription(notifier *Notifier, callback UnsubscribeCallback) (*Subscri
 			// call in goroutine as this can be called from Unsubscribe and end in a deadlock
 			go notifier.Unsubscribe(id)
 		},
+		time.Now(),
+		"",
+		nil,
+		nil,
 	}, nil
 }
 
@@ -49,6 +71,14 @@ func getRandomSubscriptionId() (string, error) {
 	return "0x" + hex.EncodeToString(subid[:]), nil
 }
 
+func (s *Subscription) describe(kind string, filter interface{}, backlog func() int) {
+	s.Notifier.mutex.Lock()
+	defer s.Notifier.mutex.Unlock()
+	s.kind = kind
+	s.filter = filter
+	s.backlog = backlog
+}
+
 func (s *Subscription) Unsubscribe() {
 	s.once.Do(func() {
 		s.unsubscribe(s.id)
@@ -125,6 +155,35 @@ func (n *Notifier) Unsubscribe(id string) bool {
 	return ok
 }
 
+// Subscriptions lists the subscriptions of the connection, oldest first
+func (n *Notifier) Subscriptions() []SubscriptionStatus {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	statuses := make([]SubscriptionStatus, 0, len(n.subscriptions))
+	for _, subscription := range n.subscriptions {
+		status := SubscriptionStatus{
+			ID:        subscription.id,
+			Type:      subscription.kind,
+			Filter:    subscription.filter,
+			CreatedAt: subscription.createdAt,
+		}
+		if subscription.backlog != nil {
+			status.Backlog = subscription.backlog()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].CreatedAt.Before(statuses[j].CreatedAt)
+	})
+	return statuses
+}
+
+// Backlog is the number of notifications queued for the connection
+func (n *Notifier) Backlog() int {
+	return len(n.queue)
+}
+
 func (n *Notifier) ResponseSent() {
 	n.mutex.RLock()
 	subscriptionIdPending := n.subscriptionIdPending
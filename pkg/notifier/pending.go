@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+var agentConfigPendingTxsKey = "pendingTransactionsInterval"
+var agentConfigPendingTxsInterval = 2 * time.Second
+
+// transactions announced over ZMQ that are remembered, so they aren't notified again once they are mined
+const zmqRecentTransactions = 50000
+
+// transactions announced over ZMQ that wait to be looked up in the mempool, more are dropped rather than holding up ZMQ
+const zmqPendingQueue = 1000
+
+// SetPendingTransactionsInterval sets how often qtumd's mempool is polled for newPendingTransactions subscriptions
+func (a *Agent) SetPendingTransactionsInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return errors.Errorf("pending transactions interval must be positive, got %s", interval)
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.setConfigValue(agentConfigPendingTxsKey, interval)
+	return nil
+}
+
+func (a *Agent) pendingTransactionsInterval() time.Duration {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.getConfigValue(agentConfigPendingTxsKey, agentConfigPendingTxsInterval).(time.Duration)
+}
+
+// runPendingTransactions polls qtumd's mempool while there are newPendingTransactions subscriptions, notifying them of
+// the transactions that weren't there the last time. While qtumd pushes its transactions over ZMQ they are notified as
+// they arrive instead
+func (a *Agent) runPendingTransactions() {
+	a.mutex.Lock()
+	if a.pendingRunning {
+		a.mutex.Unlock()
+		return
+	}
+	a.pendingRunning = true
+	a.mutex.Unlock()
+
+	var stopListening func()
+	defer func() {
+		if stopListening != nil {
+			stopListening()
+		}
+	}()
+
+	// nil until the first poll, the transactions already in the mempool then aren't new to subscribers
+	var seen map[string]bool
+	for a.pendingTransactionsWanted() {
+		if zmq := a.qtum.ZMQ(); zmq != nil && zmq.NotifiesTransactions() {
+			if stopListening == nil {
+				listener, stopChecking := a.pendingTransactionListener()
+				stopZMQ := zmq.OnTransaction(listener)
+				stopListening = func() {
+					stopZMQ()
+					stopChecking()
+				}
+			}
+			// polling starts over if ZMQ disconnects
+			seen = nil
+		} else {
+			if stopListening != nil {
+				stopListening()
+				stopListening = nil
+			}
+			mempool, err := a.qtum.GetRawMempool(a.ctx)
+			if err != nil {
+				a.qtum.GetErrorLogger().Log("msg", "Failure getting mempool", "err", err)
+			} else {
+				seen = a.notifyPendingTransactions(seen, mempool)
+			}
+		}
+
+		select {
+		case <-time.After(a.pendingTransactionsInterval()):
+		case <-a.ctx.Done():
+			a.mutex.Lock()
+			a.pendingRunning = false
+			a.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// pendingTransactionsWanted reports whether there are newPendingTransactions subscriptions left to poll for, and stops
+// polling otherwise. Subscriptions are counted under the same lock runPendingTransactions checks, so a subscription
+// added while polling stops either keeps it going or starts it over
+func (a *Agent) pendingTransactionsWanted() bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.newPendingTxs.Count() != 0 {
+		return true
+	}
+	a.pendingRunning = false
+	return false
+}
+
+// notifyPendingTransactions notifies subscribers of the transactions of mempool that aren't in seen, and returns the
+// transactions to compare the next poll with. Transactions are forgotten once they leave the mempool, so each one is
+// notified once while it is pending
+func (a *Agent) notifyPendingTransactions(seen map[string]bool, mempool []string) map[string]bool {
+	current := make(map[string]bool, len(mempool))
+	var hashes []interface{}
+	for _, txid := range mempool {
+		current[txid] = true
+		if seen != nil && !seen[txid] {
+			hashes = append(hashes, utils.AddHexPrefix(txid))
+		}
+	}
+	a.sendPendingTransactions(hashes)
+	return current
+}
+
+// pendingTransactionListener notifies subscribers of the transactions qtumd announces over ZMQ, skipping the ones it
+// announces again when they are mined. qtumd announces the transactions of new blocks the same way as the ones entering
+// its mempool, so transactions that were never announced before are only notified while they are in the mempool.
+// The returned function stops looking transactions up
+func (a *Agent) pendingTransactionListener() (func(string), func()) {
+	var mutex sync.Mutex
+	recent := make(map[string]bool)
+	var order []string
+
+	queue := make(chan string, zmqPendingQueue)
+	done := make(chan struct{})
+	go a.checkPendingTransactions(queue, done)
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() { close(done) })
+	}
+
+	return func(txid string) {
+		mutex.Lock()
+		if recent[txid] {
+			mutex.Unlock()
+			return
+		}
+		recent[txid] = true
+		order = append(order, txid)
+		if len(order) > zmqRecentTransactions {
+			delete(recent, order[0])
+			order = order[1:]
+		}
+		mutex.Unlock()
+		// the listener is called while ZMQ notifications are read, so qtumd is asked by checkPendingTransactions
+		// without holding them up
+		select {
+		case queue <- txid:
+		default:
+			a.qtum.GetErrorLogger().Log("msg", "Too many pending transactions to look up, dropping one", "txid", txid)
+		}
+	}, stop
+}
+
+// checkPendingTransactions looks the queued transactions up in the mempool one at a time, so qtumd gets a single
+// request at a time and subscribers are notified in the order qtumd announced the transactions
+func (a *Agent) checkPendingTransactions(queue <-chan string, done <-chan struct{}) {
+	for {
+		select {
+		case txid := <-queue:
+			if _, err := a.qtum.GetMempoolEntry(a.ctx, txid); err != nil {
+				if errors.Cause(err) != qtum.ErrInvalidAddress {
+					a.qtum.GetErrorLogger().Log("msg", "Failure getting mempool entry", "txid", txid, "err", err)
+				}
+				continue
+			}
+			hashes := []interface{}{utils.AddHexPrefix(txid)}
+			a.newPendingTxs.forEach(func(s *subscriptionInformation) {
+				s.notifyPendingTransactions(hashes)
+			})
+		case <-done:
+			return
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *Agent) sendPendingTransactions(hashes []interface{}) {
+	if len(hashes) == 0 {
+		return
+	}
+	a.newPendingTxs.forEach(func(s *subscriptionInformation) {
+		go s.notifyPendingTransactions(hashes)
+	})
+}
@@ -0,0 +1,259 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestAgentPendingTransactions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	doer := internal.NewDoerMappedMock()
+	// the first poll only records the mempool, txid b is new to the second and c to the third
+	doer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{"aa"})
+	doer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{"aa", "bb"})
+	doer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{"bb", "cc"})
+	mockedClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+	if err := agent.SetPendingTransactionsInterval(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(chan []byte, 10)
+	notifierContext, cancelNotifierContext := context.WithCancel(ctx)
+	notifier := NewNotifier(notifierContext, cancelNotifierContext, func(v []byte) error {
+		sent <- v
+		return nil
+	}, log.NewNopLogger())
+
+	id, err := agent.NewSubscription(notifier, &eth.EthSubscriptionRequest{Method: "newPendingTransactions"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.ResponseSent()
+
+	for _, want := range []string{"0xbb", "0xcc"} {
+		select {
+		case notification := <-sent:
+			var got struct {
+				Params struct {
+					Subscription string `json:"subscription"`
+					Result       string `json:"result"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal(notification, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Params.Subscription != id || got.Params.Result != want {
+				t.Errorf("unexpected notification %s, want %s", notification, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no notification of %s", want)
+		}
+	}
+
+	// the mempool stays the same from now on
+	select {
+	case notification := <-sent:
+		t.Errorf("unexpected notification %s", notification)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAgentPendingTransactionsKeepsPollingForNewSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	doer := internal.NewDoerMappedMock()
+	doer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{})
+	mockedClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+	if err := agent.SetPendingTransactionsInterval(time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	notifierContext, cancelNotifierContext := context.WithCancel(ctx)
+	notifier := NewNotifier(notifierContext, cancelNotifierContext, func(v []byte) error {
+		return nil
+	}, log.NewNopLogger())
+
+	// subscribing while polling stops for the previous subscription must not leave the new one without a poller
+	for i := 0; i < 200; i++ {
+		id, err := agent.NewSubscription(notifier, &eth.EthSubscriptionRequest{Method: "newPendingTransactions"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 199 {
+			break
+		}
+		time.Sleep(time.Duration(i%3) * time.Millisecond)
+		agent.unsubscribe(id)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		agent.mutex.RLock()
+		running := agent.pendingRunning
+		agent.mutex.RUnlock()
+		if running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("mempool isn't polled for the remaining subscription")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// the poller stays up while the subscription remains
+	time.Sleep(20 * time.Millisecond)
+	agent.mutex.RLock()
+	defer agent.mutex.RUnlock()
+	if !agent.pendingRunning {
+		t.Error("mempool polling stopped with a subscription remaining")
+	}
+}
+
+func TestAgentPendingTransactionsWanted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockedClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+	agent.pendingRunning = true
+
+	agent.newPendingTxs.subscriptionCount = 1
+	if !agent.pendingTransactionsWanted() || !agent.pendingRunning {
+		t.Error("polling stopped with a subscription remaining")
+	}
+
+	// the poller gives up its flag in the same step it sees no subscriptions, a subscription added after that starts
+	// a new one
+	agent.newPendingTxs.subscriptionCount = 0
+	if agent.pendingTransactionsWanted() || agent.pendingRunning {
+		t.Error("polling kept going without subscriptions")
+	}
+}
+
+func TestAgentPendingTransactionListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	doer := internal.NewDoerMappedMock()
+	// aa entered the mempool, bb was announced when it was mined without ever being in it
+	doer.AddResponse(qtum.MethodGetMempoolEntry, qtum.GetMempoolEntryResponse{Vsize: 200})
+	doer.AddError(qtum.MethodGetMempoolEntry, eth.NewJSONRPCError(-5, "Transaction not in mempool", nil))
+	mockedClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+
+	sent := make(chan []byte, 10)
+	notifierContext, cancelNotifierContext := context.WithCancel(ctx)
+	notifier := NewNotifier(notifierContext, cancelNotifierContext, func(v []byte) error {
+		sent <- v
+		return nil
+	}, log.NewNopLogger())
+	if _, err := agent.NewSubscription(notifier, &eth.EthSubscriptionRequest{Method: "newPendingTransactions"}); err != nil {
+		t.Fatal(err)
+	}
+	notifier.ResponseSent()
+
+	listen, stop := agent.pendingTransactionListener()
+	defer stop()
+	listen("aa")
+	select {
+	case notification := <-sent:
+		var got struct {
+			Params struct {
+				Result string `json:"result"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(notification, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Params.Result != "0xaa" {
+			t.Errorf("unexpected notification %s", notification)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification of a transaction entering the mempool")
+	}
+
+	// announced again once mined, and a mined transaction that never was pending
+	listen("aa")
+	listen("bb")
+	select {
+	case notification := <-sent:
+		t.Errorf("unexpected notification %s", notification)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAgentPendingTransactionListenerKeepsOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	doer := internal.NewDoerMappedMock()
+	doer.AddResponse(qtum.MethodGetMempoolEntry, qtum.GetMempoolEntryResponse{Vsize: 200})
+	mockedClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewAgent(ctx, mockedClient, nil)
+
+	sent := make(chan []byte, 100)
+	notifierContext, cancelNotifierContext := context.WithCancel(ctx)
+	notifier := NewNotifier(notifierContext, cancelNotifierContext, func(v []byte) error {
+		sent <- v
+		return nil
+	}, log.NewNopLogger())
+	if _, err := agent.NewSubscription(notifier, &eth.EthSubscriptionRequest{Method: "newPendingTransactions"}); err != nil {
+		t.Fatal(err)
+	}
+	notifier.ResponseSent()
+
+	listen, stop := agent.pendingTransactionListener()
+	defer stop()
+	var want []string
+	for i := 0; i < 20; i++ {
+		txid := fmt.Sprintf("%02x", i)
+		want = append(want, "0x"+txid)
+		listen(txid)
+	}
+
+	for _, hash := range want {
+		select {
+		case notification := <-sent:
+			var got struct {
+				Params struct {
+					Result string `json:"result"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal(notification, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Params.Result != hash {
+				t.Fatalf("expected %s to be notified next, got %s", hash, got.Params.Result)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no notification of %s", hash)
+		}
+	}
+}
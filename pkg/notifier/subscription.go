@@ -24,10 +24,15 @@ type subscriptionInformation struct {
 	cancelFunc context.CancelFunc
 	running    bool
 	qtum       *qtum.Qtum
+	// logs come from the agent's backbone instead of polling qtumd
+	shared bool
+	limits NotificationLimits
+	// nil when notifications aren't rate limited
+	limiter *notificationLimiter
 }
 
 func (s *subscriptionInformation) run() {
-	if s.params == nil {
+	if s.params == nil || s.shared {
 		return
 	}
 
@@ -57,18 +62,13 @@ func (s *subscriptionInformation) run() {
 		s.qtum.GetDebugLogger().Log("msg", "Error translating logs topics", "error", err)
 		return
 	}
-	ethAddresses, err := s.params.Params.GetAddresses()
+	stringAddresses, err := eth.ParseFilterAddresses(s.params.Params.Address)
 	if err != nil {
 		s.qtum.GetDebugLogger().Log("msg", "Error translating logs addresses", "error", err)
 		return
 	}
-	stringAddresses := make([]string, len(ethAddresses))
-	for i, ethAddress := range ethAddresses {
-		if strings.HasPrefix(ethAddress.String(), "0x") {
-			stringAddresses[i] = strings.TrimPrefix(ethAddress.String(), "0x")
-		} else {
-			stringAddresses[i] = ethAddress.String()
-		}
+	if stringAddresses == nil {
+		stringAddresses = []string{}
 	}
 
 	qtumTopics := qtum.NewSearchLogsTopics(translatedTopics)
@@ -115,7 +115,7 @@ func (s *subscriptionInformation) run() {
 		req.FromBlock = nextBlock
 		timeBeforeCall := time.Now()
 		rolling.Push(&timeBeforeCall)
-		resp, err := s.qtum.WaitForLogs(s.ctx, req)
+		resp, err := s.waitForLogs(req)
 		timeAfterCall := time.Now()
 		if err == nil {
 			nextBlock = int(resp.NextBlock)
@@ -130,6 +130,7 @@ func (s *subscriptionInformation) run() {
 				s.qtum.GetErrorLogger().Log("msg", "Error calling searchLogs", "subscriptionId", s.id, "error", err)
 				return
 			}
+			var results []interface{}
 			for _, qtumLog := range receiptsSearchLogs {
 				qtumLogs := qtumLog.Log
 				logs := conversion.FilterQtumLogs(stringAddresses, qtumTopics, qtumLogs)
@@ -137,21 +138,19 @@ func (s *subscriptionInformation) run() {
 				for _, ethLog := range ethLogs {
 					subscription := &eth.EthSubscription{
 						SubscriptionID: s.Subscription.id,
-						Result:         ethLog,
+						Result:         eth.ChecksumResultAddresses(ethLog, false),
 					}
 					hash := computeHash(subscription)
 					if _, ok := sentHashes[hash]; !ok {
 						sentHashes[hash] = true
-						s.qtum.GetDebugLogger().Log("subscriptionId", s.id, "msg", "notifying of logs")
-						jsonRpcNotification, err := eth.NewJSONRPCNotification("eth_subscription", subscription)
-						if err != nil {
-							s.qtum.GetErrorLogger().Log("subscriptionId", s.id, "err", err)
-							return
-						}
-						s.Send(jsonRpcNotification)
+						results = append(results, subscription.Result)
 					}
 				}
 			}
+			if len(results) != 0 {
+				s.qtum.GetDebugLogger().Log("subscriptionId", s.id, "msg", "notifying of logs", "logs", len(results))
+				s.notifyLogs(results)
+			}
 			oldest := rolling.Oldest()
 			a := time.Now()
 			if oldest != nil && a.Sub(*oldest.(*time.Time)) < inYSeconds {
@@ -197,6 +196,149 @@ func (s *subscriptionInformation) run() {
 	}
 }
 
+// waitForLogs long polls qtumd with waitforlogs until a block has logs matching req. While qtumd announces blocks over
+// ZMQ, each new block is searched once it is announced instead, one block per call
+func (s *subscriptionInformation) waitForLogs(req *qtum.WaitForLogsRequest) (*qtum.WaitForLogsResponse, error) {
+	// new subscriptions start after the current tip
+	from, ok := req.FromBlock.(int)
+	for {
+		nextBlock, interval := waitForBlock(s.qtum, 0)
+		if nextBlock == nil {
+			return s.qtum.WaitForLogs(s.ctx, req)
+		}
+
+		blockCount, err := s.qtum.GetBlockCount(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+		latest := int(blockCount.Int64())
+		if !ok {
+			from, ok = latest+1, true
+		}
+		if from <= latest {
+			// the block is already there, so waitforlogs returns at once
+			bounded := *req
+			bounded.FromBlock = from
+			bounded.ToBlock = from
+			return s.qtum.WaitForLogs(s.ctx, &bounded)
+		}
+
+		select {
+		case <-nextBlock:
+		case <-time.After(interval):
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+// deliverLogs sends the logs received through the agent's backbone that match the subscription
+func (s *subscriptionInformation) deliverLogs(logs []eth.Log) {
+	if s.params == nil || s.params.Params == nil {
+		return
+	}
+	addresses, err := eth.ParseFilterAddresses(s.params.Params.Address)
+	if err != nil {
+		s.qtum.GetDebugLogger().Log("msg", "Error translating logs addresses", "error", err)
+		return
+	}
+	topics, err := eth.ParseFilterTopics(s.params.Params.Topics)
+	if err != nil {
+		s.qtum.GetDebugLogger().Log("msg", "Error translating logs topics", "error", err)
+		return
+	}
+
+	var results []interface{}
+	for _, ethLog := range logs {
+		if !eth.MatchLogAddress(addresses, ethLog.Address) || !eth.MatchLogTopics(topics, ethLog.Topics) {
+			continue
+		}
+		results = append(results, eth.ChecksumResultAddresses(ethLog, false))
+	}
+	s.notifyLogs(results)
+}
+
+// notifyLogs sends the matching logs of a block, one notification each unless they are batched
+func (s *subscriptionInformation) notifyLogs(results []interface{}) {
+	if len(results) == 0 {
+		return
+	}
+	if s.limiter != nil {
+		s.limiter.notify(results, s.limits.BatchLogs)
+		return
+	}
+	if s.limits.BatchLogs {
+		s.sendLogs(results)
+		return
+	}
+	for i := range results {
+		s.sendLogs(results[i : i+1])
+	}
+}
+
+func (s *subscriptionInformation) sendLogs(results []interface{}) {
+	var result interface{} = results
+	if len(results) == 1 && !s.limits.BatchLogs {
+		result = results[0]
+	}
+	jsonRpcNotification, err := eth.NewJSONRPCNotification("eth_subscription", &eth.EthSubscription{
+		SubscriptionID: s.Subscription.id,
+		Result:         result,
+	})
+	if err != nil {
+		s.qtum.GetErrorLogger().Log("subscriptionId", s.id, "err", err)
+		return
+	}
+	s.Send(jsonRpcNotification)
+}
+
+// notifyHead sends a new head, heads the rate limit holds back are replaced by newer ones
+func (s *subscriptionInformation) notifyHead(head interface{}) {
+	if s.limiter != nil {
+		s.limiter.notify([]interface{}{head}, false)
+		return
+	}
+	s.sendHead([]interface{}{head})
+}
+
+func (s *subscriptionInformation) sendHead(heads []interface{}) {
+	s.Send(&eth.EthSubscription{
+		Version: "2.0",
+		Method:  "eth_subscription",
+		Params: eth.EthSubscriptionParams{
+			SubscriptionID: s.Subscription.id,
+			Result:         heads[len(heads)-1],
+		},
+	})
+}
+
+// notifyPendingTransactions sends the hashes of new pending transactions, one notification each
+func (s *subscriptionInformation) notifyPendingTransactions(hashes []interface{}) {
+	if s.limiter != nil {
+		s.limiter.notify(hashes, false)
+		return
+	}
+	for i := range hashes {
+		s.sendPendingTransactions(hashes[i : i+1])
+	}
+}
+
+// sendPendingTransactions sends a hash, or an array of the hashes the rate limit held back
+func (s *subscriptionInformation) sendPendingTransactions(hashes []interface{}) {
+	var result interface{} = hashes
+	if len(hashes) == 1 {
+		result = hashes[0]
+	}
+	s.Send(&eth.EthSubscription{
+		Version: "2.0",
+		Method:  "eth_subscription",
+		Params: eth.EthSubscriptionParams{
+			SubscriptionID: s.Subscription.id,
+			Result:         result,
+		},
+	})
+}
+
 // Compute hash for the json serialization of the passed in argument
 func computeHash(value interface{}) string {
 	b, err := json.Marshal(value)
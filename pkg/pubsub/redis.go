@@ -0,0 +1,113 @@
+// Package pubsub connects Janus instances through Redis streams, so websocket subscribers on any instance receive the
+// notifications produced by the one instance polling qtumd
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/notifier"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "janus:notifications:"
+
+// how many notifications a stream keeps for instances that are slow to read them
+const streamLength = 1000
+
+// how long the leader keeps leading without renewing, another instance takes over after that
+var leaseDuration = 30 * time.Second
+
+// how long a read blocks waiting for notifications before checking if the listener is still wanted
+var readTimeout = 5 * time.Second
+
+// renews the lease only when this instance still holds it
+var renewLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// RedisBackbone is a notifier.Backbone on Redis streams
+type RedisBackbone struct {
+	client *redis.Client
+	// identifies this instance as the holder of the lease
+	id string
+}
+
+var _ notifier.Backbone = (*RedisBackbone)(nil)
+
+// NewRedisBackbone connects to the Redis server at url, e.g. redis://localhost:6379/0
+func NewRedisBackbone(url string) (*RedisBackbone, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid redis url")
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &RedisBackbone{client: redis.NewClient(options), id: hex.EncodeToString(id)}, nil
+}
+
+func (b *RedisBackbone) Close() error {
+	return b.client.Close()
+}
+
+func (b *RedisBackbone) Publish(ctx context.Context, topic string, payload []byte) error {
+	err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: keyPrefix + topic,
+		MaxLen: streamLength,
+		Approx: true,
+		Values: map[string]interface{}{"data": payload},
+	}).Err()
+	return errors.Wrapf(err, "couldn't publish %s", topic)
+}
+
+func (b *RedisBackbone) Listen(ctx context.Context, topic string, deliver func(payload []byte)) error {
+	stream := keyPrefix + topic
+	// only notifications published from now on
+	lastID := "$"
+	for {
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{stream, lastID},
+			Block:   readTimeout,
+		}).Result()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "couldn't read %s", topic)
+		}
+		for _, s := range streams {
+			for _, message := range s.Messages {
+				lastID = message.ID
+				if data, ok := message.Values["data"].(string); ok {
+					deliver([]byte(data))
+				}
+			}
+		}
+	}
+}
+
+func (b *RedisBackbone) Lead(ctx context.Context) (bool, error) {
+	key := keyPrefix + "leader"
+	acquired, err := b.client.SetNX(ctx, key, b.id, leaseDuration).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't acquire the notification lease")
+	}
+	if acquired {
+		return true, nil
+	}
+	renewed, err := renewLease.Run(ctx, b.client, []string{key}, b.id, leaseDuration.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't renew the notification lease")
+	}
+	return renewed == 1, nil
+}
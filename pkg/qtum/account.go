@@ -2,9 +2,12 @@ package qtum
 
 import (
 	"encoding/hex"
+	"strings"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/pkg/errors"
 )
 
 type Accounts []*btcutil.WIF
@@ -56,3 +59,34 @@ func (a *Account) ToBase58Address(isMain bool) (string, error) {
 
 	return addr.AddressPubKeyHash().String(), nil
 }
+
+// HexToBase58Address is the base58 address of a hex address, without asking qtumd. The accounts of a keystore are
+// known by hex address before they are unlocked
+func HexToBase58Address(hexAddress string, isMain bool) (string, error) {
+	params := &qtumMainNetParams
+	if !isMain {
+		params = &qtumTestNetParams
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(hexAddress, "0x"))
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid hex address %s", hexAddress)
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(hash, params)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// IsPubKeyHashAddress reports whether a base58 address pays to a public key hash on the chain, script hash and
+// segwit addresses have no hex account equivalent
+func IsPubKeyHashAddress(address string, isMain bool) bool {
+	params := &qtumMainNetParams
+	if !isMain {
+		params = &qtumTestNetParams
+	}
+
+	_, version, err := base58.CheckDecode(address)
+	return err == nil && version == params.PubKeyHashAddrID
+}
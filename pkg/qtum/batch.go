@@ -0,0 +1,109 @@
+package qtum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// batchMethod names batches in ResponseMismatchError
+const batchMethod = "batch"
+
+// BatchCall is one call of a batch sent to qtumd, Result is filled in when it succeeds and Err when it doesn't
+type BatchCall struct {
+	Method string
+	Params interface{}
+	Result interface{}
+	Err    error
+}
+
+// RequestBatch sends calls to qtumd as a single JSON-RPC batch. qtumd may answer a batch in any order, so responses are
+// matched to their calls by id. The returned error is for the batch as a whole, failures of single calls are in their Err
+func (c *Client) RequestBatch(ctx context.Context, calls []*BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = c.GetContext()
+	}
+
+	requests := make([]*JSONRPCRequest, len(calls))
+	methods := make([]string, len(calls))
+	pending := make(map[string]int, len(calls))
+	for i, call := range calls {
+		methods[i] = call.Method
+		req, err := c.NewRPCRequest(call.Method, call.Params)
+		if err != nil {
+			return errors.WithMessage(err, "couldn't make new rpc request")
+		}
+		requests[i] = req
+		pending[string(req.ID)] = i
+		c.stats.call(call.Method)
+	}
+
+	reqBody, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	respBody, err := c.do(ctx, bytes.NewReader(reqBody), methods...)
+	if err != nil {
+		defer c.failure()
+		return errors.Wrap(err, "Client#do")
+	}
+
+	var responses []*JSONRPCResult
+	if err := decodeResponse(respBody, &responses); err != nil {
+		defer c.failure()
+		if err == errGarbled {
+			return c.mismatch(&ResponseMismatchError{Method: batchMethod, Reason: err.Error()})
+		}
+		// qtumd answers a batch it can't handle at all with a single error
+		var res *JSONRPCResult
+		if json.Unmarshal(respBody, &res) == nil && res != nil && res.Error != nil {
+			return res.Error.TryGetKnownError()
+		}
+		return errors.Wrap(err, "couldn't unmarshal batch response")
+	}
+
+	answered := make(map[string]bool, len(calls))
+	for _, res := range responses {
+		if res == nil {
+			defer c.failure()
+			return c.mismatch(&ResponseMismatchError{Method: batchMethod, Reason: "a null response in the batch"})
+		}
+		id := string(bytes.TrimSpace(res.ID))
+		i, ok := pending[id]
+		if !ok {
+			// a response to nothing that was sent, or a second response to the same call, none of the batch can be trusted
+			defer c.failure()
+			reason := "the response to a request outside the batch"
+			if answered[id] {
+				reason = "a duplicate response in the batch"
+			}
+			return c.mismatch(&ResponseMismatchError{Method: batchMethod, ResponseID: res.ID, Reason: reason})
+		}
+		answered[id] = true
+		delete(pending, string(requests[i].ID))
+
+		call := calls[i]
+		result, err := c.result(requests[i], res)
+		if err != nil {
+			call.Err = err
+			continue
+		}
+		if call.Result == nil {
+			continue
+		}
+		if err := c.decodeResult(ctx, call.Method, result.RawResult, call.Result); err != nil {
+			call.Err = errors.Wrap(err, "couldn't unmarshal response result field")
+		}
+	}
+	for _, i := range pending {
+		calls[i].Err = errors.Errorf("qtumd didn't answer %s request %s of the batch", calls[i].Method, requests[i].ID)
+	}
+
+	c.success()
+	return nil
+}
@@ -0,0 +1,204 @@
+package qtum
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// the receipts of this many blocks are kept, indexers read a block with several methods right after each other
+const blockReceiptsCacheSize = 32
+
+// gettransactionreceipt calls sent in one batch
+const blockReceiptsBatchSize = 100
+
+// gettransactionreceipt calls in flight when qtumd can't take batches
+const blockReceiptsConcurrency = 8
+
+// BlockReceipts are the receipts of the transactions of a block by txid, transactions that didn't run a contract have
+// none and those with several contract outputs have one per output
+type BlockReceipts struct {
+	Hash     string
+	Height   int64
+	Receipts map[string][]TransactionReceipt
+}
+
+// Sorted returns the receipts in block and output order
+func (b *BlockReceipts) Sorted() []TransactionReceipt {
+	var receipts []TransactionReceipt
+	for _, txReceipts := range b.Receipts {
+		receipts = append(receipts, txReceipts...)
+	}
+	SearchLogsResponse(receipts).Sort()
+	return receipts
+}
+
+// Sort puts receipts in chain order, by block, transaction and output, so the logs taken from them in turn are ordered
+// by block number, transaction index and log index whichever source the receipts came from
+func (r SearchLogsResponse) Sort() {
+	sort.SliceStable(r, func(i, j int) bool {
+		if r[i].BlockNumber != r[j].BlockNumber {
+			return r[i].BlockNumber < r[j].BlockNumber
+		}
+		if r[i].TransactionIndex != r[j].TransactionIndex {
+			return r[i].TransactionIndex < r[j].TransactionIndex
+		}
+		return r[i].OutputIndex < r[j].OutputIndex
+	})
+}
+
+// blockReceiptsCache keeps the receipts of the latest blocks fetched, by block hash so a reorganization can't mix
+// blocks up. The oldest block is dropped first
+type blockReceiptsCache struct {
+	mutex  sync.Mutex
+	order  []string
+	blocks map[string]*BlockReceipts
+}
+
+func newBlockReceiptsCache() *blockReceiptsCache {
+	return &blockReceiptsCache{blocks: make(map[string]*BlockReceipts)}
+}
+
+func (c *blockReceiptsCache) get(blockHash string) *BlockReceipts {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.blocks[blockHash]
+}
+
+// atHeight is the cached block at height, it may have been replaced on chain since
+func (c *blockReceiptsCache) atHeight(height int64) *BlockReceipts {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := len(c.order) - 1; i >= 0; i-- {
+		if block := c.blocks[c.order[i]]; block.Height == height {
+			return block
+		}
+	}
+	return nil
+}
+
+func (c *blockReceiptsCache) put(block *BlockReceipts) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.blocks[block.Hash]; ok {
+		return
+	}
+	if len(c.order) == blockReceiptsCacheSize {
+		delete(c.blocks, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, block.Hash)
+	c.blocks[block.Hash] = block
+}
+
+// GetBlockReceipts fetches the receipts of every transaction of block with batched gettransactionreceipt calls, and
+// keeps them for the other methods reading the same block
+func (m *Method) GetBlockReceipts(ctx context.Context, block *GetBlockResponse) (*BlockReceipts, error) {
+	if cached := m.blockReceipts.get(block.Hash); cached != nil {
+		return cached, nil
+	}
+	receipts, err := m.GetTransactionReceipts(ctx, block.Hash, block.Txs)
+	if err != nil {
+		return nil, err
+	}
+	fetched := &BlockReceipts{Hash: block.Hash, Height: int64(block.Height), Receipts: receipts}
+	m.blockReceipts.put(fetched)
+	return fetched, nil
+}
+
+// CachedBlockReceipts are the receipts of the block with blockHash GetBlockReceipts fetched lately, nil when they
+// aren't at hand
+func (m *Method) CachedBlockReceipts(blockHash string) *BlockReceipts {
+	return m.blockReceipts.get(blockHash)
+}
+
+// GetTransactionReceipts fetches the receipts of the transactions txids of the block with blockHash by txid, with
+// batched gettransactionreceipt calls. Transactions that didn't run a contract have none
+func (m *Method) GetTransactionReceipts(ctx context.Context, blockHash string, txids []string) (map[string][]TransactionReceipt, error) {
+	receipts := make([][]TransactionReceipt, len(txids))
+	calls := make([]*BatchCall, len(txids))
+	for i, txid := range txids {
+		calls[i] = &BatchCall{Method: MethodGetTransactionReceipt, Params: GetTransactionReceiptRequest(txid), Result: &receipts[i]}
+	}
+	for start := 0; start < len(calls); start += blockReceiptsBatchSize {
+		end := start + blockReceiptsBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		if err := m.RequestBatch(ctx, calls[start:end]); err != nil {
+			m.GetDebugLogger().Log("function", "GetBlockReceipts", "msg", "batch failed, fetching receipts one by one", "err", err)
+			m.requestReceipts(ctx, calls[start:end])
+		}
+	}
+
+	fetched := make(map[string][]TransactionReceipt, len(txids))
+	for i, call := range calls {
+		if call.Err != nil {
+			return nil, errors.WithMessagef(call.Err, "couldn't get receipt of %s", txids[i])
+		}
+		for _, receipt := range receipts[i] {
+			if receipt.BlockHash != blockHash {
+				// qtumd dropped the block while its receipts were fetched, e.g. in a reorganization
+				return nil, errors.Errorf("receipt of %s is in block %s instead of %s", txids[i], receipt.BlockHash, blockHash)
+			}
+		}
+		if len(receipts[i]) > 0 {
+			fetched[txids[i]] = receipts[i]
+		}
+	}
+	return fetched, nil
+}
+
+// requestReceipts makes the calls of a batch one by one, for qtumd behind proxies that don't pass batches on
+func (m *Method) requestReceipts(ctx context.Context, calls []*BatchCall) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, blockReceiptsConcurrency)
+	for _, call := range calls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(call *BatchCall) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			call.Err = m.RequestWithContext(ctx, call.Method, call.Params, call.Result)
+		}(call)
+	}
+	wg.Wait()
+}
+
+// searchBlockReceipts answers searchlogs for a single block from the receipts GetBlockReceipts fetched lately, ok is
+// false when they aren't at hand
+func (m *Method) searchBlockReceipts(ctx context.Context, req *SearchLogsRequest) (receipts SearchLogsResponse, ok bool) {
+	if req.FromBlock == nil || req.ToBlock == nil || req.FromBlock.Cmp(req.ToBlock) != 0 || req.MinimumConfirmations != nil {
+		return nil, false
+	}
+	block := m.blockReceipts.atHeight(req.FromBlock.Int64())
+	if block == nil {
+		return nil, false
+	}
+	// the height may belong to another block after a reorganization
+	hash, err := m.GetBlockHash(ctx, req.FromBlock)
+	if err != nil || string(hash) != block.Hash {
+		return nil, false
+	}
+
+	topics := make([][]string, len(req.Topics))
+	for i, topic := range req.Topics {
+		topics[i] = topic
+	}
+	receipts = SearchLogsResponse{}
+	for _, receipt := range block.Sorted() {
+		// like qtumd, receipts with a matching log are returned whole
+		for _, log := range receipt.Log {
+			if eth.MatchLogAddress(req.Addresses, log.Address) && eth.MatchLogTopics(topics, log.Topics) {
+				receipts = append(receipts, receipt)
+				break
+			}
+		}
+	}
+	return receipts, true
+}
@@ -0,0 +1,173 @@
+package qtum
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Capability is an optional dependency of some methods, when it is down only those methods fail
+type Capability string
+
+const (
+	// qtumd started with -addrindex, needed by the getaddress* methods
+	CapabilityAddressIndex Capability = "addressindex"
+	// the SQL database mapping Ethereum block hashes to Qtum block hashes
+	CapabilityBlockHashDatabase Capability = "blockhash-database"
+	// the SQL database of the failed transaction journal
+	CapabilityTransactionJournal Capability = "transaction-journal"
+	// the SQL database of verified contracts
+	CapabilityContractVerification Capability = "contract-verification"
+	// the SQL database of the ABI registry
+	CapabilityABIRegistry Capability = "abi-registry"
+)
+
+// how long requests needing an unavailable capability fail without trying it
+var capabilityRetryInterval = 30 * time.Second
+
+// qtumd methods that need a capability, their outcome updates the capability
+var methodCapabilities = map[string]Capability{
+	MethodGetAddressBalance: CapabilityAddressIndex,
+	MethodGetAddressUTXOs:   CapabilityAddressIndex,
+	MethodGetAddressDeltas:  CapabilityAddressIndex,
+	MethodGetAddressMempool: CapabilityAddressIndex,
+}
+
+// CapabilityUnavailableError is returned instead of calling qtumd while a capability is down
+type CapabilityUnavailableError struct {
+	Capability Capability
+	Reason     string
+}
+
+func (err *CapabilityUnavailableError) Error() string {
+	return fmt.Sprintf("%s is temporarily unavailable: %s", err.Capability, err.Reason)
+}
+
+type CapabilityStatus struct {
+	Available bool      `json:"available"`
+	Reason    string    `json:"reason,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+type capabilityState struct {
+	CapabilityStatus
+	retryAt time.Time
+}
+
+// Capabilities tracks which optional dependencies are up, capabilities that were never reported are assumed to be
+type Capabilities struct {
+	mutex  sync.Mutex
+	states map[Capability]*capabilityState
+	// called when a capability goes down or comes back
+	onChange func(Capability, CapabilityStatus)
+}
+
+func NewCapabilities() *Capabilities {
+	return &Capabilities{states: make(map[Capability]*capabilityState)}
+}
+
+func (c *Capabilities) MarkAvailable(capability Capability) {
+	c.set(capability, true, "")
+}
+
+func (c *Capabilities) MarkUnavailable(capability Capability, reason string) {
+	c.set(capability, false, reason)
+}
+
+func (c *Capabilities) set(capability Capability, available bool, reason string) {
+	c.mutex.Lock()
+	state, ok := c.states[capability]
+	changed := !ok || state.Available != available
+	if changed {
+		state = &capabilityState{CapabilityStatus: CapabilityStatus{Since: time.Now()}}
+		c.states[capability] = state
+	}
+	state.Available = available
+	state.Reason = reason
+	if !available {
+		state.retryAt = time.Now().Add(capabilityRetryInterval)
+	}
+	status := state.CapabilityStatus
+	onChange := c.onChange
+	c.mutex.Unlock()
+
+	if changed && onChange != nil {
+		onChange(capability, status)
+	}
+}
+
+// Check returns an error while the capability is down, until it is due to be tried again
+func (c *Capabilities) Check(capability Capability) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state, ok := c.states[capability]
+	if !ok || state.Available || time.Now().After(state.retryAt) {
+		return nil
+	}
+	return &CapabilityUnavailableError{Capability: capability, Reason: state.Reason}
+}
+
+// acquire is Check for calls that use the capability, every capabilityRetryInterval one call is let through to find
+// out if the capability is back
+func (c *Capabilities) acquire(capability Capability) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state, ok := c.states[capability]
+	if !ok || state.Available {
+		return nil
+	}
+	if now := time.Now(); now.After(state.retryAt) {
+		state.retryAt = now.Add(capabilityRetryInterval)
+		return nil
+	}
+	return &CapabilityUnavailableError{Capability: capability, Reason: state.Reason}
+}
+
+// Statuses returns the capabilities that have been reported so far
+func (c *Capabilities) Statuses() map[Capability]CapabilityStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	statuses := make(map[Capability]CapabilityStatus, len(c.states))
+	for capability, state := range c.states {
+		statuses[capability] = state.CapabilityStatus
+	}
+	return statuses
+}
+
+// observe updates the capability a qtumd method needs from a response, returning an error when it shows the
+// capability is missing
+func (c *Capabilities) observe(method string, rpcErr *JSONRPCError) error {
+	capability, ok := methodCapabilities[method]
+	if !ok {
+		return nil
+	}
+	if rpcErr == nil {
+		c.MarkAvailable(capability)
+		return nil
+	}
+	if !isMissingCapabilityError(capability, rpcErr) {
+		return nil
+	}
+	c.MarkUnavailable(capability, rpcErr.Message)
+	return &CapabilityUnavailableError{Capability: capability, Reason: rpcErr.Message}
+}
+
+func isMissingCapabilityError(capability Capability, rpcErr *JSONRPCError) bool {
+	if rpcErr.Code == -32601 {
+		// qtumd built without the method
+		return true
+	}
+	message := strings.ToLower(rpcErr.Message)
+	switch capability {
+	case CapabilityAddressIndex:
+		// without -addrindex qtumd can't look up any address
+		return strings.Contains(message, "address index") ||
+			strings.Contains(message, "addrindex") ||
+			strings.Contains(message, "no information available for address")
+	}
+	return false
+}
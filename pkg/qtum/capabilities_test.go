@@ -0,0 +1,49 @@
+package qtum
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCapabilities(t *testing.T) {
+	capabilities := NewCapabilities()
+
+	if err := capabilities.Check(CapabilityAddressIndex); err != nil {
+		t.Fatalf("capabilities that were never reported should be available, got %v", err)
+	}
+
+	if err := capabilities.observe(MethodGetAddressBalance, &JSONRPCError{Code: -5, Message: "Invalid address"}); err != nil {
+		t.Errorf("an invalid address shouldn't mark the address index unavailable, got %v", err)
+	}
+	if err := capabilities.observe(MethodGetBlockCount, &JSONRPCError{Code: -32601, Message: "Method not found"}); err != nil {
+		t.Errorf("methods without a capability shouldn't be tracked, got %v", err)
+	}
+
+	err := capabilities.observe(MethodGetAddressUTXOs, &JSONRPCError{Code: -1, Message: "Address index not enabled"})
+	if _, ok := err.(*CapabilityUnavailableError); !ok {
+		t.Fatalf("expected a capability unavailable error, got %v", err)
+	}
+	if err := capabilities.Check(CapabilityAddressIndex); err == nil {
+		t.Error("expected the address index to be unavailable")
+	}
+	if err := capabilities.acquire(CapabilityAddressIndex); err == nil {
+		t.Error("expected calls to fail until the capability is due to be tried again")
+	}
+
+	// once the retry interval passed a single call is let through
+	capabilities.states[CapabilityAddressIndex].retryAt = time.Now().Add(-time.Second)
+	if err := capabilities.Check(CapabilityAddressIndex); err != nil {
+		t.Errorf("expected the capability to be tried again, got %v", err)
+	}
+	if err := capabilities.acquire(CapabilityAddressIndex); err != nil {
+		t.Errorf("expected the first call to be let through, got %v", err)
+	}
+	if err := capabilities.acquire(CapabilityAddressIndex); err == nil {
+		t.Error("expected only one call to be let through")
+	}
+
+	capabilities.observe(MethodGetAddressUTXOs, nil)
+	if err := capabilities.Check(CapabilityAddressIndex); err != nil {
+		t.Errorf("expected the address index to be available again, got %v", err)
+	}
+}
@@ -8,20 +8,28 @@ import (
 	"io"
 	"io/ioutil"
 	"math"
-	"math/big"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/abiregistry"
 	"github.com/qtumproject/janus/pkg/analytics"
 	"github.com/qtumproject/janus/pkg/blockhash"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/journal"
+	"github.com/qtumproject/janus/pkg/params"
+	"github.com/qtumproject/janus/pkg/replay"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/qtumproject/janus/pkg/verification"
 )
 
 var FLAG_GENERATE_ADDRESS_TO = "REGTEST_GENERATE_ADDRESS_TO"
@@ -29,6 +37,47 @@ var FLAG_IGNORE_UNKNOWN_TX = "IGNORE_UNKNOWN_TX"
 var FLAG_DISABLE_SNIPPING_LOGS = "DISABLE_SNIPPING_LOGS"
 var FLAG_HIDE_QTUMD_LOGS = "HIDE_QTUMD_LOGS"
 var FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE = "FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE"
+var FLAG_LATEST_CONFIRMATIONS = "LATEST_CONFIRMATIONS"
+var FLAG_BALANCE_MODE = "BALANCE_MODE"
+var FLAG_MEMPOOL_PRECHECK = "MEMPOOL_PRECHECK"
+var FLAG_SIMULATE_BEFORE_SEND = "SIMULATE_BEFORE_SEND"
+var FLAG_LOCAL_EVM = "LOCAL_EVM"
+var FLAG_MULTICALL_ADDRESS = "MULTICALL_ADDRESS"
+var FLAG_WALLET_ACCOUNTS = "WALLET_ACCOUNTS"
+var FLAG_TRACE_CONCURRENCY = "TRACE_CONCURRENCY"
+var FLAG_LOGS_BLOCK_RANGE = "LOGS_BLOCK_RANGE"
+var FLAG_LOGS_MAX_RESULTS = "LOGS_MAX_RESULTS"
+var FLAG_TX_LOOKUP_BLOCKS = "TX_LOOKUP_BLOCKS"
+var FLAG_BLOCK_GAS = "BLOCK_GAS"
+var FLAG_BLOCK_DIFFICULTY = "BLOCK_DIFFICULTY"
+var FLAG_BLOCK_TIMESTAMP = "BLOCK_TIMESTAMP"
+var FLAG_RESPONSE_EXTENSIONS = "RESPONSE_EXTENSIONS"
+var FLAG_ALLOW_INSECURE_UNLOCK = "ALLOW_INSECURE_UNLOCK"
+
+// eth_getBalance returns the whole confirmed balance, including immature staking rewards
+const BalanceModeTotal = "total"
+
+// eth_getBalance returns the confirmed balance that can be spent right away
+const BalanceModeSpendable = "spendable"
+
+// blocks have the default block gas limit and the gas used by their receipts, which is only known when the block is
+// asked for with its transactions
+const BlockGasConstant = "constant"
+
+// blocks have qtumd's maximum block weight as gas limit and their weight as gas used
+const BlockGasWeight = "weight"
+
+// blocks have qtumd's proof of stake difficulty
+const BlockDifficultyQtum = "qtum"
+
+// blocks have the zero difficulty and total difficulty of geth's proof of stake blocks
+const BlockDifficultyGeth = "geth"
+
+// blocks have the time of their header
+const BlockTimestampTime = "time"
+
+// blocks have the median time of the blocks before them, which never decreases from one block to the next
+const BlockTimestampMedian = "mediantime"
 
 var maximumRequestTime = 10000
 var maximumBackoff = (2 * time.Second).Milliseconds()
@@ -42,8 +91,12 @@ type Client struct {
 	ctx      context.Context
 	DbConfig blockhash.DatabaseConfig
 
-	// hex addresses to return for eth_accounts
-	Accounts Accounts
+	// accounts to return for eth_accounts and sign for
+	Keystore *Keystore
+	// operator assigned labels of the accounts, by hex address
+	AccountLabels map[string]string
+	// well-known contracts returned by janus_getNetworkContracts, as chain to name to hex address
+	NetworkContracts map[string]map[string]string
 
 	logWriter io.Writer
 	logger    log.Logger
@@ -52,17 +105,78 @@ type Client struct {
 	// is this client using the main network?
 	isMain bool
 
-	id      *big.Int
-	idStep  *big.Int
-	idMutex sync.Mutex
+	// last JSON-RPC id sent to qtumd, every request gets its own so responses can be checked against it
+	lastID uint64
+	// responses refused as not answering their request
+	responseMismatches uint64
+	// calls, cache use and retries reported by Stats
+	stats clientStats
 
 	mutex *sync.RWMutex
 	flags map[string]interface{}
 
 	cache *clientCache
+	// receipts of the latest blocks GetBlockReceipts fetched
+	blockReceipts *blockReceiptsCache
 
 	analytics    *analytics.Analytics
 	errorHandler ErrorHandler
+
+	// records failed sendrawtransaction attempts, nil when disabled
+	journal journal.Journal
+	// hashes of the Ethereum transactions sent with eth_sendRawTransaction, each is sent once
+	ethereumTransactions replay.Store
+	// compiles and keeps verified contracts, nil when disabled
+	verifier *verification.Verifier
+	// contract ABIs by address, nil when disabled
+	abiRegistry abiregistry.Registry
+	// keeps eth filters shared with other Janus instances, nil keeps them in memory
+	filterStore eth.FilterStore
+	// answers searchlogs for indexed blocks, nil sends every searchlogs to qtumd
+	logIndex LogIndex
+
+	capabilities *Capabilities
+
+	// version reported by qtumd, selects the migrations of its responses
+	nodeVersion        int64
+	nodeVersionMutex   sync.Mutex
+	nodeVersionRetryAt time.Time
+
+	// address families used to connect to qtumd
+	dialConfig DialConfig
+	// how often qtumd's hostname is looked up again, 0 keeps the connections' addresses
+	dnsRefresh time.Duration
+	// regtest blocks mined every miningInterval, 0 only mines after transactions
+	miningInterval time.Duration
+	miningBlocks   int
+
+	// consecutive calls qtumd turned away because its work queue was full, callers are told to back off accordingly
+	busyStreak int32
+	// what qtumd reported doing while it was starting up, empty once it answers normally
+	startingMutex  sync.RWMutex
+	startingStatus string
+
+	// unlocks qtumd's wallet when requests fail because it is locked, nil leaves it to operators
+	walletPassphrase    PassphraseSource
+	walletUnlockTimeout time.Duration
+	walletUnlockMutex   sync.Mutex
+	walletUnlockedAt    time.Time
+
+	// credentials replacing the ones in URL, given separately or rotated
+	credentialsMutex sync.RWMutex
+	credentials      *url.Userinfo
+	// sent as a bearer token instead of credentials
+	bearerToken string
+	// sent with every request to qtumd
+	userAgent string
+	headers   http.Header
+
+	// qtumd's ZMQ notifications, nil when Janus only polls
+	zmq *ZMQ
+
+	// qtumd nodes calls are balanced over, nil when there's only URL
+	upstreams         *upstreams
+	upstreamBalancing string
 }
 
 func ReformatJSON(input []byte) ([]byte, error) {
@@ -91,9 +205,6 @@ func NewClient(isMain bool, rpcURL string, opts ...func(*Client) error) (*Client
 		MaxConnsPerHost:     16,
 		IdleConnTimeout:     60 * time.Second,
 		DisableKeepAlives:   false,
-		DialContext: (&net.Dialer{
-			Timeout: 60 * time.Second,
-		}).DialContext,
 	}
 
 	httpClient := &http.Client{
@@ -108,11 +219,24 @@ func NewClient(isMain bool, rpcURL string, opts ...func(*Client) error) (*Client
 		url:    url,
 		logger: log.NewNopLogger(),
 		debug:  false,
-		id:     big.NewInt(0),
-		idStep: big.NewInt(1),
 		mutex:  &sync.RWMutex{},
 		flags:  make(map[string]interface{}),
 		cache:  newClientCache(),
+
+		blockReceipts:        newBlockReceiptsCache(),
+		ethereumTransactions: replay.NewMemoryStore(),
+		Keystore:             NewKeystore(isMain),
+
+		userAgent:  DefaultUserAgent,
+		dialConfig: DefaultDialConfig(),
+	}
+	c.capabilities = NewCapabilities()
+	c.capabilities.onChange = func(capability Capability, status CapabilityStatus) {
+		if status.Available {
+			level.Info(c.GetLogger()).Log("msg", "capability available again", "capability", capability)
+		} else {
+			level.Warn(c.GetLogger()).Log("msg", "capability unavailable, methods needing it will fail", "capability", capability, "reason", status.Reason)
+		}
 	}
 
 	for _, opt := range opts {
@@ -120,8 +244,43 @@ func NewClient(isMain bool, rpcURL string, opts ...func(*Client) error) (*Client
 			return nil, err
 		}
 	}
+	if !c.hasCredentials() {
+		return nil, errors.Errorf("QTUM_RPC URL (must specify user & password, or set them separately): %s", redactURL(url))
+	}
+
+	if err := c.Keystore.Reload(); err != nil {
+		return nil, err
+	}
+	if c.Keystore.dir != "" && c.Keystore.reloadInterval > 0 && c.ctx != nil {
+		go c.Keystore.watch(c.ctx, c.GetLogger)
+	}
 
 	c.cache.configLogger(c.logWriter, c.debug)
+	c.cache.errorLogger = c.GetErrorLogger
+
+	if c.doer == httpClient {
+		dialer := newUpstreamDialer(c.dialConfig)
+		tr.DialContext = dialer.dialContext
+		resolver := newUpstreamResolver(url, c.dnsRefresh, dialer.dialContext, tr.CloseIdleConnections, c.GetLogger)
+		if resolver != nil {
+			resolver.order = dialer.ordered
+			tr.DialContext = resolver.dialContext
+			go resolver.run(c.ctx)
+		}
+	}
+
+	if c.zmq != nil {
+		c.zmq.onBlock = append(c.zmq.onBlock, func(string) { c.cache.flushTip() })
+		go c.zmq.run(c.ctx)
+	}
+	if c.upstreams != nil {
+		if c.upstreamBalancing != "" {
+			c.upstreams.balancing = c.upstreamBalancing
+		}
+		if c.ctx != nil {
+			go c.checkUpstreams(c.ctx)
+		}
+	}
 
 	return c, nil
 }
@@ -134,10 +293,143 @@ func (c *Client) GetErrorHandler() ErrorHandler {
 	return c.errorHandler
 }
 
+func (c *Client) SetJournal(journal journal.Journal) {
+	c.journal = journal
+}
+
+func (c *Client) GetJournal() journal.Journal {
+	return c.journal
+}
+
+func (c *Client) SetEthereumTransactions(store replay.Store) {
+	c.ethereumTransactions = store
+}
+
+func (c *Client) GetEthereumTransactions() replay.Store {
+	return c.ethereumTransactions
+}
+
+func (c *Client) SetVerifier(verifier *verification.Verifier) {
+	c.verifier = verifier
+}
+
+func (c *Client) GetVerifier() *verification.Verifier {
+	return c.verifier
+}
+
+func (c *Client) SetABIRegistry(registry abiregistry.Registry) {
+	c.abiRegistry = registry
+}
+
+func (c *Client) GetABIRegistry() abiregistry.Registry {
+	return c.abiRegistry
+}
+
+func (c *Client) SetFilterStore(store eth.FilterStore) {
+	c.filterStore = store
+}
+
+func (c *Client) GetFilterStore() eth.FilterStore {
+	return c.filterStore
+}
+
+func (c *Client) SetLogIndex(index LogIndex) {
+	c.logIndex = index
+}
+
+func (c *Client) GetLogIndex() LogIndex {
+	return c.logIndex
+}
+
+// CachedResponses returns the qtumd responses currently cached
+func (c *Client) CachedResponses() []CacheEntry {
+	return c.cache.entries()
+}
+
+// CacheBackend returns where cached responses are kept, nil when they are kept in memory
+func (c *Client) CacheBackend() CacheBackend {
+	return c.cache.backend
+}
+
+// WarmCache adds responses cached by another Janus instance to the cache
+func (c *Client) WarmCache(entries []CacheEntry) {
+	c.cache.warm(entries)
+}
+
+// CloseIdleConnections drops the pooled connections to qtumd, for when they may have died silently
+func (c *Client) CloseIdleConnections() {
+	if closer, ok := c.doer.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+func (c *Client) GetCapabilities() *Capabilities {
+	return c.capabilities
+}
+
+// GetURL is qtumd's RPC URL with its credentials, use RedactedURL to show it
 func (c *Client) GetURL() *url.URL {
 	return c.url
 }
 
+// RedactedURL is qtumd's RPC URL without user and password, safe to log
+func (c *Client) RedactedURL() string {
+	return redactURL(c.url)
+}
+
+// String describes the client without its credentials, for clients formatted with %v by accident
+func (c *Client) String() string {
+	return "qtum.Client(" + c.RedactedURL() + ")"
+}
+
+// GoString keeps %#v from printing the credentials in URL
+func (c *Client) GoString() string {
+	return c.String()
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	return redacted.String()
+}
+
+// SetCredentials changes the user and password of qtumd's RPC, for when they are rotated while Janus runs
+func (c *Client) SetCredentials(user string, password string) {
+	c.credentialsMutex.Lock()
+	defer c.credentialsMutex.Unlock()
+	c.credentials = url.UserPassword(user, password)
+	c.bearerToken = ""
+}
+
+// SetBearerToken authenticates to qtumd's RPC with a bearer token instead of a user and password, for qtumd behind an
+// authenticating proxy. It can be called again when the token is rotated
+func (c *Client) SetBearerToken(token string) {
+	c.credentialsMutex.Lock()
+	defer c.credentialsMutex.Unlock()
+	c.credentials = nil
+	c.bearerToken = token
+}
+
+func (c *Client) hasCredentials() bool {
+	c.credentialsMutex.RLock()
+	defer c.credentialsMutex.RUnlock()
+	if c.credentials != nil || c.bearerToken != "" {
+		return true
+	}
+	if c.upstreams == nil {
+		return c.url.User != nil
+	}
+	for _, node := range c.upstreams.nodes {
+		if parsed, err := url.Parse(node.url); err != nil || parsed.User == nil {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Client) IsMain() bool {
 	return c.isMain
 }
@@ -151,14 +443,30 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 		ctx = c.GetContext()
 	}
 
+	if capability, ok := methodCapabilities[method]; ok {
+		if err := c.capabilities.acquire(capability); err != nil {
+			return err
+		}
+	}
+
+	cached := false
+	if timings := TimingsFromContext(ctx); timings != nil {
+		start := time.Now()
+		defer func() {
+			timings.recordCall(method, time.Since(start), cached)
+		}()
+	}
+
 	// check if method is cacheable first
 	if c.cache.isCachable(method) {
 		c.cache.setContext(ctx)
 		// check if we have a cached result
 		cachedResult, err := c.cache.getResponse(method, params)
+		c.stats.cache(cachedResult != nil && err == nil)
 		if cachedResult != nil && err == nil {
+			cached = true
 			// we have a cached result, return it
-			err := json.Unmarshal(cachedResult, result)
+			err := c.decodeResult(ctx, method, cachedResult, result)
 			if err != nil {
 				c.GetDebugLogger().Log("method", method, "params", params, "result", result, "error", err)
 				return errors.Wrap(err, "couldn't unmarshal response result field")
@@ -177,13 +485,29 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 	}
 
 	handledErrors := make(map[error]bool)
+	walletUnlocked := false
 
 	var resp *SuccessJSONRPCResult
 	max := int(math.Floor(math.Max(float64(maximumRequestTime/int(maximumBackoff)), 1)))
 	for i := 0; i < max; i++ {
+		sentAt := time.Now()
 		resp, err = c.Do(ctx, req)
+		if IsBusyError(err) {
+			atomic.AddInt32(&c.busyStreak, 1)
+		}
+		if err == ErrWalletUnlockNeeded && c.walletPassphrase != nil && !walletUnlocked && i != max-1 {
+			walletUnlocked = true
+			if unlockErr := c.unlockWallet(ctx, sentAt); unlockErr != nil {
+				c.GetLogger().Log("msg", "Failed to unlock the wallet", "method", method, "err", unlockErr)
+				return err
+			}
+			continue
+		}
 		if err != nil {
-			errorHandlerErr := c.errorHandler(ctx, err)
+			var errorHandlerErr error
+			if c.errorHandler != nil {
+				errorHandlerErr = c.errorHandler(ctx, err)
+			}
 			retry := false
 			if errorHandlerErr != nil && i != max-1 {
 				// only allow recovering from a specific error once
@@ -192,10 +516,10 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 					retry = true
 				}
 			}
-			if (retry || strings.Contains(err.Error(), ErrQtumWorkQueueDepth.Error())) && i != max-1 {
+			if (retry || IsRetryableError(err)) && i != max-1 {
 				requestString := marshalToString(req)
 				backoffTime := computeBackoff(i, true)
-				c.GetLogger().Log("msg", fmt.Sprintf("QTUM process busy, backing off for %f seconds", backoffTime.Seconds()), "request", requestString)
+				c.GetLogger().Log("msg", fmt.Sprintf("QTUM process busy, backing off for %f seconds", backoffTime.Seconds()), "request", requestString, "err", err)
 				// TODO check if this works as expected
 				var done <-chan struct{}
 				if c.ctx != nil {
@@ -206,8 +530,12 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 				select {
 				case <-time.After(backoffTime):
 				case <-done:
+					return errors.WithMessage(c.ctx.Err(), "context cancelled")
+				case <-ctx.Done():
+					// the request's deadline passed, don't keep retrying
 					return errors.WithMessage(ctx.Err(), "context cancelled")
 				}
+				c.stats.retry()
 				c.GetLogger().Log("msg", "Retrying QTUM command")
 			} else {
 				if i != 0 {
@@ -216,11 +544,12 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 				return err
 			}
 		} else {
+			atomic.StoreInt32(&c.busyStreak, 0)
 			break
 		}
 	}
 
-	err = json.Unmarshal(resp.RawResult, result)
+	err = c.decodeResult(ctx, method, resp.RawResult, result)
 	if err != nil {
 		c.GetDebugLogger().Log("method", method, "params", params, "result", result, "error", err)
 		return errors.Wrap(err, "couldn't unmarshal response result field")
@@ -243,12 +572,13 @@ func (c *Client) Do(ctx context.Context, req *JSONRPCRequest) (*SuccessJSONRPCRe
 	debugLogger := c.GetDebugLogger()
 
 	debugLogger.Log("method", req.Method)
+	c.stats.call(req.Method)
 
 	if c.IsDebugEnabled() && !c.GetFlagBool(FLAG_HIDE_QTUMD_LOGS) && c.logWriter != nil {
 		fmt.Fprintf(c.logWriter, "=> qtum RPC request\n%s\n", reqBody)
 	}
 
-	respBody, err := c.do(ctx, bytes.NewReader(reqBody))
+	respBody, err := c.do(ctx, bytes.NewReader(reqBody), req.Method)
 	if err != nil {
 		defer c.failure()
 		return nil, errors.Wrap(err, "Client#do")
@@ -269,7 +599,7 @@ func (c *Client) Do(ctx context.Context, req *JSONRPCRequest) (*SuccessJSONRPCRe
 		}
 	}
 
-	res, err := c.responseBodyToResult(respBody)
+	res, err := c.responseBodyToResult(req, respBody)
 	if err != nil {
 		defer c.failure()
 		if len(respBody) == 0 {
@@ -279,14 +609,12 @@ func (c *Client) Do(ctx context.Context, req *JSONRPCRequest) (*SuccessJSONRPCRe
 		if IsKnownError(err) {
 			return nil, err
 		}
-		if string(respBody) == ErrQtumWorkQueueDepth.Error() {
-			// QTUM http server queue depth reached, need to retry
-			return nil, ErrQtumWorkQueueDepth
+		if _, ok := err.(*CapabilityUnavailableError); ok {
+			return nil, err
 		}
-		if strings.Contains(string(respBody), "503 Service Unavailable") {
-			// server was shutdown
-			debugLogger.Log("msg", "Server responded with 503")
-			return nil, ErrInternalError
+		if starting, ok := err.(*StartingError); ok {
+			c.setStarting(starting.Status)
+			return nil, err
 		}
 		debugLogger.Log("msg", "Failed to parse response body", "body", string(respBody), "error", err)
 		return nil, err
@@ -314,31 +642,68 @@ func (c *Client) NewRPCRequest(method string, params interface{}) (*JSONRPCReque
 		return nil, err
 	}
 
-	c.idMutex.Lock()
-	c.id = c.id.Add(c.id, c.idStep)
-	c.idMutex.Unlock()
+	id := atomic.AddUint64(&c.lastID, 1)
 
 	return &JSONRPCRequest{
 		JSONRPC: RPCVersion,
-		ID:      json.RawMessage(`"` + c.id.String() + `"`),
+		ID:      json.RawMessage(`"` + strconv.FormatUint(id, 10) + `"`),
 		Method:  method,
 		Params:  paramsJSON,
 	}, nil
 }
 
-func (c *Client) do(ctx context.Context, body io.Reader) ([]byte, error) {
+// sameID compares JSON-RPC ids regardless of the whitespace around them, "1" and 1 are different ids
+func sameID(a json.RawMessage, b json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(a), bytes.TrimSpace(b))
+}
+
+func isNullID(id json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(id)
+	return len(trimmed) == 0 || string(trimmed) == "null"
+}
+
+// do sends body, calling methods, to qtumd
+func (c *Client) do(ctx context.Context, body io.Reader, methods ...string) ([]byte, error) {
+	if c.upstreams == nil {
+		return c.doURL(ctx, c.URL, body)
+	}
+	payload, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.doUpstreams(ctx, payload, isReadOnly(methods...))
+}
+
+// doURL posts body to the qtumd node at rpcURL
+func (c *Client) doURL(ctx context.Context, rpcURL string, body io.Reader) ([]byte, error) {
 	var req *http.Request
 	var err error
 	if ctx != nil {
-		req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.URL, body)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, body)
 	} else {
-		req, err = http.NewRequest(http.MethodPost, c.URL, body)
+		req, err = http.NewRequest(http.MethodPost, rpcURL, body)
 	}
 	if err != nil {
 		return nil, err
 	}
 
 	req.Close = false
+	for key, values := range c.headers {
+		if key == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	c.credentialsMutex.RLock()
+	if c.credentials != nil {
+		password, _ := c.credentials.Password()
+		req.SetBasicAuth(c.credentials.Username(), password)
+	} else if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	c.credentialsMutex.RUnlock()
 
 	resp, err := c.doer.Do(req)
 	if err != nil {
@@ -355,6 +720,9 @@ func (c *Client) do(ctx context.Context, body io.Reader) ([]byte, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "ioutil error in qtum client package")
 	}
+	if err := checkStatus(resp.StatusCode, reader); err != nil {
+		return nil, err
+	}
 	return reader, nil
 }
 
@@ -445,7 +813,30 @@ func SetLogger(l log.Logger) func(*Client) error {
 
 func SetAccounts(accounts Accounts) func(*Client) error {
 	return func(c *Client) error {
-		c.Accounts = accounts
+		c.Keystore.Add(accounts...)
+		return nil
+	}
+}
+
+// SetKeystore adds the encrypted key files of dir to the accounts, rescanning it every reloadInterval. Accounts are
+// unlocked for unlockTimeout when personal_unlockAccount gives no duration
+func SetKeystore(dir string, reloadInterval time.Duration, unlockTimeout time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if reloadInterval < 0 || unlockTimeout < 0 {
+			return errors.New("keystore reload interval and unlock timeout can't be negative")
+		}
+		c.Keystore.dir = dir
+		c.Keystore.reloadInterval = reloadInterval
+		c.Keystore.unlockTimeout = unlockTimeout
+		return nil
+	}
+}
+
+// SetAllowInsecureUnlock lets personal_unlockAccount unlock keystore accounts, which then sign for anyone reaching
+// Janus until they are locked again. Like geth's --allow-insecure-unlock it is off by default
+func SetAllowInsecureUnlock(allow bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_ALLOW_INSECURE_UNLOCK, allow)
 		return nil
 	}
 }
@@ -482,13 +873,214 @@ func SetHideQtumdLogs(hide bool) func(*Client) error {
 
 func SetMatureBlockHeight(height *int) func(*Client) error {
 	return func(c *Client) error {
-		if height != nil {
-			c.SetFlag(FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE, height)
+		// GetMatureBlockHeight reads the flag as an int, the flag is 0 when it isn't set
+		if height != nil && *height > 0 {
+			c.SetFlag(FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE, *height)
+		}
+		return nil
+	}
+}
+
+// SetLatestConfirmations makes "latest" refer to the block this many confirmations below the tip
+func SetLatestConfirmations(confirmations int) func(*Client) error {
+	return func(c *Client) error {
+		if confirmations < 0 {
+			return errors.New("latest confirmations cannot be negative")
+		}
+		if confirmations > 0 {
+			c.SetFlag(FLAG_LATEST_CONFIRMATIONS, confirmations)
+		}
+		return nil
+	}
+}
+
+func SetBalanceMode(mode string) func(*Client) error {
+	return func(c *Client) error {
+		switch mode {
+		case "", BalanceModeTotal:
+		case BalanceModeSpendable:
+			c.SetFlag(FLAG_BALANCE_MODE, mode)
+		default:
+			return errors.Errorf("unknown balance mode: %s", mode)
+		}
+		return nil
+	}
+}
+
+// SetBlockFields picks how the gas limit and gas used, the difficulty and the timestamp of blocks are synthesized,
+// empty modes keep the defaults
+func SetBlockFields(gas string, difficulty string, timestamp string) func(*Client) error {
+	return func(c *Client) error {
+		switch gas {
+		case "", BlockGasConstant:
+		case BlockGasWeight:
+			c.SetFlag(FLAG_BLOCK_GAS, gas)
+		default:
+			return errors.Errorf("unknown block gas mode: %s", gas)
+		}
+		switch difficulty {
+		case "", BlockDifficultyQtum:
+		case BlockDifficultyGeth:
+			c.SetFlag(FLAG_BLOCK_DIFFICULTY, difficulty)
+		default:
+			return errors.Errorf("unknown block difficulty mode: %s", difficulty)
+		}
+		switch timestamp {
+		case "", BlockTimestampTime:
+		case BlockTimestampMedian:
+			c.SetFlag(FLAG_BLOCK_TIMESTAMP, timestamp)
+		default:
+			return errors.Errorf("unknown block timestamp mode: %s", timestamp)
+		}
+		return nil
+	}
+}
+
+// SetResponseExtensions adds the Qtum and Janus specific fields to the responses of methods in the Ethereum spec, like
+// the qtumFee of receipts. Responses carry them unless disabled
+func SetResponseExtensions(enabled bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_RESPONSE_EXTENSIONS, enabled)
+		return nil
+	}
+}
+
+// ResponseExtensions reports whether the responses of methods in the Ethereum spec carry extension fields
+func (c *Client) ResponseExtensions() bool {
+	enabled, ok := c.GetFlag(FLAG_RESPONSE_EXTENSIONS).(bool)
+	return !ok || enabled
+}
+
+// SetMempoolPrecheck runs testmempoolaccept before broadcasting raw transactions
+func SetMempoolPrecheck(precheck bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_MEMPOOL_PRECHECK, precheck)
+		return nil
+	}
+}
+
+// SetSimulateBeforeSend runs eth_sendTransaction contract calls through callcontract before sending them
+func SetSimulateBeforeSend(simulate bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_SIMULATE_BEFORE_SEND, simulate)
+		return nil
+	}
+}
+
+// SetLocalEVM runs the calls qtumd can't simulate in an embedded EVM fed with state from qtumd
+func SetLocalEVM(enabled bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_LOCAL_EVM, enabled)
+		return nil
+	}
+}
+
+// SetMulticallAddress is the hex address of a contract with Multicall3's aggregate3, eth_call runs calls of its
+// aggregate3 as a batch of callcontract calls
+func SetMulticallAddress(address string) func(*Client) error {
+	return func(c *Client) error {
+		if address == "" {
+			return nil
+		}
+		if !common.IsHexAddress(address) {
+			return errors.Errorf("invalid multicall address %q, expected a hex address", address)
+		}
+		c.SetFlag(FLAG_MULTICALL_ADDRESS, strings.ToLower(utils.RemoveHexPrefix(address)))
+		return nil
+	}
+}
+
+// SetTraceConcurrency bounds the transactions of a block debug_traceBlockBy* traces at the same time, 0 keeps the default
+func SetTraceConcurrency(concurrency int) func(*Client) error {
+	return func(c *Client) error {
+		if concurrency < 0 {
+			return errors.New("trace concurrency cannot be negative")
+		}
+		if concurrency > 0 {
+			c.SetFlag(FLAG_TRACE_CONCURRENCY, concurrency)
+		}
+		return nil
+	}
+}
+
+// SetLogsBlockRange bounds the blocks a single searchlogs call of eth_getLogs covers, wider ranges are searched in parts.
+// 0 keeps the default
+func SetLogsBlockRange(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		if blocks < 0 {
+			return errors.New("logs block range cannot be negative")
+		}
+		if blocks > 0 {
+			c.SetFlag(FLAG_LOGS_BLOCK_RANGE, blocks)
+		}
+		return nil
+	}
+}
+
+// SetLogsMaxResults caps the logs eth_getLogs returns, requests matching more fail. 0 keeps the default
+func SetLogsMaxResults(results int) func(*Client) error {
+	return func(c *Client) error {
+		if results < 0 {
+			return errors.New("logs max results cannot be negative")
+		}
+		if results > 0 {
+			c.SetFlag(FLAG_LOGS_MAX_RESULTS, results)
+		}
+		return nil
+	}
+}
+
+// SetTxLookupBlocks sets how many of the latest blocks are searched for a transaction qtumd can't find without
+// -txindex and the log index doesn't know. 0 keeps the default
+func SetTxLookupBlocks(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		if blocks < 0 {
+			return errors.New("transaction lookup blocks cannot be negative")
+		}
+		if blocks > 0 {
+			c.SetFlag(FLAG_TX_LOOKUP_BLOCKS, blocks)
 		}
 		return nil
 	}
 }
 
+// SetAccountLabels names accounts for janus_listAccountsDetailed, labels are keyed by hex address
+func SetAccountLabels(labels map[string]string) func(*Client) error {
+	return func(c *Client) error {
+		c.AccountLabels = make(map[string]string, len(labels))
+		for address, label := range labels {
+			c.AccountLabels[strings.ToLower(utils.RemoveHexPrefix(address))] = label
+		}
+		return nil
+	}
+}
+
+// SetNetworkContracts registers well-known contracts like wrapped QTUM or routers by the chain they are on, main,
+// test or regtest, then by name
+func SetNetworkContracts(contracts map[string]map[string]string) func(*Client) error {
+	return func(c *Client) error {
+		c.NetworkContracts = make(map[string]map[string]string, len(contracts))
+		for chain, named := range contracts {
+			c.NetworkContracts[chain] = make(map[string]string, len(named))
+			for name, address := range named {
+				if !common.IsHexAddress(address) {
+					return errors.Errorf("invalid address %q of %s contract %s, expected a hex address", address, chain, name)
+				}
+				c.NetworkContracts[chain][name] = strings.ToLower(utils.RemoveHexPrefix(address))
+			}
+		}
+		return nil
+	}
+}
+
+// SetWalletAccounts adds the addresses of qtumd's own wallet to the accounts returned by eth_accounts
+func SetWalletAccounts(wallet bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_WALLET_ACCOUNTS, wallet)
+		return nil
+	}
+}
+
 func SetContext(ctx context.Context) func(*Client) error {
 	return func(c *Client) error {
 		c.ctx = ctx
@@ -496,6 +1088,60 @@ func SetContext(ctx context.Context) func(*Client) error {
 	}
 }
 
+// SetDialConfig chooses the address families used to connect to qtumd and their connect timeouts
+func SetDialConfig(config DialConfig) func(*Client) error {
+	return func(c *Client) error {
+		if err := config.validate(); err != nil {
+			return err
+		}
+		c.dialConfig = config
+		return nil
+	}
+}
+
+// SetDNSRefresh looks up qtumd's hostname again at this interval, so Janus follows DNS based failover, SRV names like
+// _qtum._tcp.example.com are refreshed every 30 seconds by default
+func SetDNSRefresh(interval time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		c.dnsRefresh = interval
+		return nil
+	}
+}
+
+// SetZMQ receives qtumd's new blocks and mempool transactions over ZMQ at the -zmqpubhashblock and -zmqpubrawtx
+// addresses instead of polling for them, either address can be empty
+func SetZMQ(hashBlockAddress string, rawTxAddress string) func(*Client) error {
+	return func(c *Client) error {
+		if hashBlockAddress == "" && rawTxAddress == "" {
+			c.zmq = nil
+			return nil
+		}
+		zmq, err := NewZMQ(hashBlockAddress, rawTxAddress, c.GetLogger)
+		if err != nil {
+			return err
+		}
+		c.zmq = zmq
+		return nil
+	}
+}
+
+// ZMQ returns the subscription to qtumd's ZMQ notifications, nil when they aren't configured
+func (c *Client) ZMQ() *ZMQ {
+	return c.zmq
+}
+
+// SetIntervalMining mines blocks on regtest every interval, on top of the block mined after each transaction
+func SetIntervalMining(interval time.Duration, blocks int) func(*Client) error {
+	return func(c *Client) error {
+		if interval < 0 || blocks < 1 {
+			return errors.Errorf("invalid interval mining of %d blocks every %s", blocks, interval)
+		}
+		c.miningInterval = interval
+		c.miningBlocks = blocks
+		return nil
+	}
+}
+
 func SetSqlHost(host string) func(*Client) error {
 	return func(c *Client) error {
 		c.DbConfig.Host = host
@@ -510,6 +1156,60 @@ func SetSqlPort(port int) func(*Client) error {
 	}
 }
 
+// DefaultUserAgent is sent to qtumd unless SetUserAgent says otherwise
+var DefaultUserAgent = "Janus/" + params.VersionWithGitSha
+
+// SetUserAgent sends userAgent to qtumd as the User-Agent of requests, empty keeps DefaultUserAgent
+func SetUserAgent(userAgent string) func(*Client) error {
+	return func(c *Client) error {
+		if userAgent != "" {
+			c.userAgent = userAgent
+		}
+		return nil
+	}
+}
+
+// SetHeaders adds headers to every request to qtumd, like the tenant or API key headers of a gateway in front of it.
+// Credentials set with SetRPCAuth win over an Authorization header
+func SetHeaders(headers map[string]string) func(*Client) error {
+	return func(c *Client) error {
+		c.headers = make(http.Header, len(headers))
+		for name, value := range headers {
+			if strings.TrimSpace(name) == "" {
+				return errors.New("header names can't be empty")
+			}
+			c.headers.Set(name, value)
+		}
+		return nil
+	}
+}
+
+// SetCacheBackend keeps cached responses in backend instead of memory, so Janus instances can share them
+func SetCacheBackend(backend CacheBackend) func(*Client) error {
+	return func(c *Client) error {
+		c.cache.backend = backend
+		return nil
+	}
+}
+
+// SetRPCAuth authenticates to qtumd with user and password, or with a bearer token, instead of credentials in the RPC
+// URL where they show up in logs and process listings. Empty values leave the URL's credentials
+func SetRPCAuth(user string, password string, token string) func(*Client) error {
+	return func(c *Client) error {
+		switch {
+		case token != "" && (user != "" || password != ""):
+			return errors.New("use either a user and password or a bearer token for qtumd's RPC")
+		case token != "":
+			c.SetBearerToken(token)
+		case user != "":
+			c.SetCredentials(user, password)
+		case password != "":
+			return errors.New("qtumd's RPC password needs a user")
+		}
+		return nil
+	}
+}
+
 func SetSqlUser(user string) func(*Client) error {
 	return func(c *Client) error {
 		c.DbConfig.User = user
@@ -579,11 +1279,43 @@ func (c *Client) IsDebugEnabled() bool {
 	return c.debug
 }
 
-func (c *Client) responseBodyToResult(body []byte) (*SuccessJSONRPCResult, error) {
+func (c *Client) responseBodyToResult(req *JSONRPCRequest, body []byte) (*SuccessJSONRPCResult, error) {
 	var res *JSONRPCResult
-	if err := json.Unmarshal(body, &res); err != nil {
+	if err := decodeResponse(body, &res); err != nil {
+		if err == errGarbled {
+			return nil, c.mismatch(&ResponseMismatchError{Method: req.Method, RequestID: req.ID, Reason: err.Error()})
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			return nil, c.mismatch(&ResponseMismatchError{Method: req.Method, RequestID: req.ID, Reason: "a batch response to a single request"})
+		}
 		return nil, err
 	}
+	if res == nil {
+		return nil, c.mismatch(&ResponseMismatchError{Method: req.Method, RequestID: req.ID, Reason: "a null response"})
+	}
+	// qtumd answers requests it can't parse with a null id, there is nothing to match those errors with
+	if !sameID(req.ID, res.ID) && !(isNullID(res.ID) && res.Error != nil) {
+		return nil, c.mismatch(&ResponseMismatchError{Method: req.Method, RequestID: req.ID, ResponseID: res.ID, Reason: "the response to another request"})
+	}
+	return c.result(req, res)
+}
+
+// result turns qtumd's response to req into its result or the error it maps to
+func (c *Client) result(req *JSONRPCRequest, res *JSONRPCResult) (*SuccessJSONRPCResult, error) {
+	if err := c.capabilities.observe(req.Method, res.Error); err != nil {
+		return nil, err
+	}
+	if starting := startingError(res.Error); starting != nil {
+		return nil, starting
+	}
+	// qtumd answered with something else than its loading status, errors too, so it has loaded
+	c.setStarting("")
+	if pruned := prunedError(res.Error); pruned != nil {
+		return nil, pruned
+	}
+	if txIndex := txIndexError(req.Method, res.Error); txIndex != nil {
+		return nil, txIndex
+	}
 	if res.Error != nil {
 		knownError := res.Error.TryGetKnownError()
 		if knownError != res.Error {
@@ -599,6 +1331,17 @@ func (c *Client) responseBodyToResult(body []byte) (*SuccessJSONRPCResult, error
 	}, nil
 }
 
+// IsBusyError reports whether a call failed because qtumd's work queue was full, even after retrying
+func IsBusyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), ErrQtumWorkQueueDepth.Error())
+}
+
+// RetryAfter is how long callers should wait before trying again while qtumd is busy, it grows with the backoff
+// of the calls qtumd turned away in a row
+func (c *Client) RetryAfter() time.Duration {
+	return computeBackoff(int(atomic.LoadInt32(&c.busyStreak)), false)
+}
+
 func computeBackoff(i int, random bool) time.Duration {
 	i = int(math.Min(float64(i), 10))
 	randomNumberMilliseconds := 0
@@ -616,13 +1359,12 @@ func checkRPCURL(u string) error {
 		return errors.New("RPC URL must be set")
 	}
 
-	qtumRPC, err := url.Parse(u)
-	if err != nil {
-		return errors.Errorf("QTUM_RPC URL: %s", u)
-	}
-
-	if qtumRPC.User == nil {
-		return errors.Errorf("QTUM_RPC URL (must specify user & password): %s", u)
+	if _, err := url.Parse(u); err != nil {
+		// url.Error quotes the whole URL, credentials included
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return errors.Wrap(err, "invalid QTUM_RPC URL")
 	}
 
 	return nil
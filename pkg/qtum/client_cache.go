@@ -25,6 +25,7 @@ const (
 	QtumMethodGettransaction       = "gettransaction"
 	QtumMethodGettxout             = "gettxout"
 	QtumMethodDecoderawtransaction = "decoderawtransaction"
+	QtumMethodGetstorage           = "getstorage"
 )
 
 var cachable_methods = []string{
@@ -37,6 +38,29 @@ var cachable_methods = []string{
 	// QtumMethodGettransaction,
 	QtumMethodGettxout,
 	QtumMethodDecoderawtransaction,
+	QtumMethodGetstorage,
+}
+
+// responses with confirmations or unspent outputs, which change with every block, and the storage of contracts which
+// changes with the blocks replaced by a reorganization
+var tip_dependent_methods = []string{
+	QtumMethodGetblock,
+	QtumMethodGetrawtransaction,
+	QtumMethodGettxout,
+	QtumMethodGetstorage,
+}
+
+// how long a call to a CacheBackend may take before it counts as a miss
+const CACHE_BACKEND_TIMEOUT = time.Millisecond * 500
+
+// CacheBackend keeps the cached responses outside of Janus, so instances behind a load balancer share them. Failing
+// calls are logged and count as cache misses
+type CacheBackend interface {
+	// Get returns the response cached for method and its marshaled params, nil when there is none
+	Get(ctx context.Context, method string, params string) ([]byte, error)
+	Set(ctx context.Context, method string, params string, response []byte, ttl time.Duration) error
+	// Flush drops the responses of methods on every instance, they are outdated once qtumd connects a new block
+	Flush(ctx context.Context, methods []string) error
 }
 
 // stores the rpc response for 'method' and 'params' in the cache
@@ -48,6 +72,9 @@ type clientCache struct {
 	logWriter io.Writer
 	debug     bool
 	methods   map[string]responses
+	// replaces methods when set
+	backend     CacheBackend
+	errorLogger func() log.Logger
 }
 
 // 'responses' is a map where keys are rpc param bytes, and values are response bytes (for the given method)
@@ -75,6 +102,15 @@ func (cache *clientCache) storeResponse(method string, params interface{}, respo
 	if err != nil {
 		return errors.New("failed to marshal params")
 	}
+	if cache.backend != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), CACHE_BACKEND_TIMEOUT)
+		defer cancel()
+		if err := cache.backend.Set(ctx, method, string(parambytes), response, CACHABLE_METHOD_CACHE_TIMEOUT); err != nil {
+			cache.getErrorLogger().Log("msg", "couldn't cache response", "method", method, "error", err)
+			return err
+		}
+		return nil
+	}
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
 	responses, ok := cache.methods[method]
@@ -95,6 +131,16 @@ func (cache *clientCache) getResponse(method string, params interface{}) ([]byte
 	if err != nil {
 		return nil, errors.New("failed to marshal param")
 	}
+	if cache.backend != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), CACHE_BACKEND_TIMEOUT)
+		defer cancel()
+		response, err := cache.backend.Get(ctx, method, string(parambytes))
+		if err != nil {
+			cache.getErrorLogger().Log("msg", "couldn't get cached response", "method", method, "error", err)
+			return nil, nil
+		}
+		return response, nil
+	}
 	cache.mu.RLock()
 	defer cache.mu.RUnlock()
 	if resp, ok := cache.methods[method]; ok {
@@ -105,6 +151,67 @@ func (cache *clientCache) getResponse(method string, params interface{}) ([]byte
 	return nil, nil
 }
 
+// flushes the cached responses that are outdated once qtumd connects a new block
+func (cache *clientCache) flushTip() {
+	if cache.backend != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), CACHE_BACKEND_TIMEOUT)
+		defer cancel()
+		if err := cache.backend.Flush(ctx, tip_dependent_methods); err != nil {
+			cache.getErrorLogger().Log("msg", "couldn't flush cache", "error", err)
+		}
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for _, method := range tip_dependent_methods {
+		delete(cache.methods, method)
+	}
+	cache.getDebugLogger().Log("msg", "flushing cache", "reason", "new block")
+}
+
+// CacheEntry is a cached qtumd response, used to warm the cache of a standby
+type CacheEntry struct {
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	Response json.RawMessage `json:"response"`
+}
+
+// returns every cached response, instances sharing a CacheBackend have nothing to pass on
+func (cache *clientCache) entries() []CacheEntry {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	entries := []CacheEntry{}
+	for method, responses := range cache.methods {
+		for params, response := range responses {
+			entries = append(entries, CacheEntry{Method: method, Params: json.RawMessage(params), Response: response})
+		}
+	}
+	return entries
+}
+
+// stores responses cached elsewhere, they expire CACHABLE_METHOD_CACHE_TIMEOUT after being stored here
+func (cache *clientCache) warm(entries []CacheEntry) {
+	if cache.backend != nil {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for _, entry := range entries {
+		if !cache.isCachable(entry.Method) {
+			continue
+		}
+		responses, ok := cache.methods[entry.Method]
+		if !ok {
+			responses = make(map[string][]byte)
+			cache.methods[entry.Method] = responses
+		}
+		if _, ok := responses[string(entry.Params)]; !ok {
+			responses[string(entry.Params)] = entry.Response
+			cache.setFlushResponseTimer(entry.Method, entry.Params)
+		}
+	}
+}
+
 // set a timer to flush the cached rpc response for 'method' and 'parambytes'
 func (cache *clientCache) setFlushResponseTimer(method string, parambytes []byte) {
 	go func() {
@@ -155,6 +262,14 @@ func (cache *clientCache) getDebugLogger() log.Logger {
 	return cache.logger
 }
 
+// failures of the backend are logged whether debug is enabled or not
+func (cache *clientCache) getErrorLogger() log.Logger {
+	if cache.errorLogger == nil {
+		return log.NewNopLogger()
+	}
+	return log.With(cache.errorLogger(), "component", "clientCache")
+}
+
 func (cache *clientCache) isDebugEnabled() bool {
 	return cache.debug
 }
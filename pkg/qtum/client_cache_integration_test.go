@@ -216,7 +216,12 @@ func TestCacheWithClient(t *testing.T) {
 func NewQtumMockServer(body []byte) *httptest.Server {
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write(body)
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		var res map[string]json.RawMessage
+		json.Unmarshal(body, &res)
+		res["id"] = req.ID
+		json.NewEncoder(w).Encode(res)
 	}))
 	return server
 }
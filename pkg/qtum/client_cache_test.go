@@ -1,6 +1,8 @@
 package qtum
 
 import (
+	"context"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -130,3 +132,47 @@ func TestClientCache(t *testing.T) {
 	})
 
 }
+
+type mapCacheBackend struct {
+	responses map[string][]byte
+	flushed   []string
+}
+
+func (b *mapCacheBackend) Get(ctx context.Context, method string, params string) ([]byte, error) {
+	return b.responses[method+params], nil
+}
+
+func (b *mapCacheBackend) Set(ctx context.Context, method string, params string, response []byte, ttl time.Duration) error {
+	b.responses[method+params] = response
+	return nil
+}
+
+func (b *mapCacheBackend) Flush(ctx context.Context, methods []string) error {
+	b.flushed = append(b.flushed, methods...)
+	return nil
+}
+
+func TestClientCacheBackend(t *testing.T) {
+	backend := &mapCacheBackend{responses: make(map[string][]byte)}
+	cache := newClientCache()
+	cache.backend = backend
+
+	if err := cache.storeResponse(test_method, test_params, test_expectedResult); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(backend.responses) != 1 || len(cache.methods) != 0 {
+		t.Fatalf("expected the response to be stored in the backend only, got %d in the backend and %d in memory", len(backend.responses), len(cache.methods))
+	}
+	cachedResp, err := cache.getResponse(test_method, test_params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(cachedResp) != string(test_expectedResult) {
+		t.Fatalf("expected to find %v, got %v", string(test_expectedResult), string(cachedResp))
+	}
+
+	cache.flushTip()
+	if !reflect.DeepEqual(backend.flushed, tip_dependent_methods) {
+		t.Fatalf("expected %v to be flushed, got %v", tip_dependent_methods, backend.flushed)
+	}
+}
@@ -0,0 +1,154 @@
+package qtum
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/pkg/errors"
+)
+
+// DefaultDevAccountsSeed derives the same dev accounts for every regtest Janus that doesn't pick its own seed
+const DefaultDevAccountsSeed = "janus"
+
+// blocks generated at a time while waiting for dev account rewards to mature
+var devAccountsMaturityStep = 100
+
+// DeriveDevAccounts derives count accounts from a seed phrase, at m/i' of the key derived from the phrase's sha256.
+// The keys are public knowledge for the default seed, they must never hold real funds
+func DeriveDevAccounts(seed string, count int) (Accounts, error) {
+	hash := sha256.Sum256([]byte(seed))
+	master, err := hdkeychain.NewMaster(hash[:], &chaincfg.TestNet3Params)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't derive dev accounts")
+	}
+
+	accounts := make(Accounts, 0, count)
+	for i := 0; i < count; i++ {
+		child, err := master.Derive(hdkeychain.HardenedKeyStart + uint32(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't derive dev account %d", i)
+		}
+		privateKey, err := child.ECPrivKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't derive dev account %d", i)
+		}
+		// testnet WIFs are what a regtest qtumd imports
+		wif, err := btcutil.NewWIF(privateKey, &chaincfg.TestNet3Params, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't derive dev account %d", i)
+		}
+		accounts = append(accounts, wif)
+	}
+	return accounts, nil
+}
+
+// FundDevAccounts mines blocks to each account until it can spend at least amount satoshis. Accounts funded by an
+// earlier run are left alone, so restarting Janus on the same chain doesn't mine again. The accounts are imported into
+// qtumd's wallet when it has one, so eth_sendTransaction can use them
+func (c *Qtum) FundDevAccounts(ctx context.Context, accounts Accounts, amount int64) error {
+	if !c.CanGenerate() {
+		return errors.New("dev accounts can only be funded on regtest")
+	}
+
+	amounts := make(map[string]int64, len(accounts))
+	for i, wif := range accounts {
+		address, err := (&Account{WIF: wif}).ToBase58Address(c.isMain)
+		if err != nil {
+			return err
+		}
+		amounts[address] = amount
+
+		if err := c.ImportPrivKey(ctx, &ImportPrivKeyRequest{PrivateKey: wif.String(), Label: fmt.Sprintf("dev-%d", i)}); err != nil {
+			c.GetDebugLogger().Log("function", "FundDevAccounts", "msg", "couldn't import dev account into qtumd's wallet", "address", address, "error", err)
+		}
+	}
+
+	return c.FundAccounts(ctx, amounts)
+}
+
+// FundAccounts mines blocks to base58 addresses until each can spend at least its amount in satoshis, addresses that
+// already have enough are left alone
+func (c *Qtum) FundAccounts(ctx context.Context, amounts map[string]int64) error {
+	if !c.CanGenerate() {
+		return errors.New("accounts can only be funded on regtest")
+	}
+
+	addresses := make([]string, 0, len(amounts))
+	for address := range amounts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		if err := c.mineTo(ctx, address, amounts[address]); err != nil {
+			return err
+		}
+	}
+
+	// coinbase rewards can't be spent until they mature
+	for mined := 0; ; mined += devAccountsMaturityStep {
+		spendable, err := c.accountsSpendable(ctx, amounts)
+		if err != nil {
+			return err
+		}
+		if spendable {
+			return nil
+		}
+		if mined >= c.GetMatureBlockHeight() {
+			return errors.Errorf("account rewards haven't matured after %d blocks", mined)
+		}
+		if _, err := c.Generate(ctx, devAccountsMaturityStep, nil); err != nil {
+			return errors.Wrap(err, "couldn't mature account rewards")
+		}
+	}
+}
+
+// mineTo mines blocks to an address until its balance, immature rewards included, reaches amount
+func (c *Qtum) mineTo(ctx context.Context, address string, amount int64) error {
+	before, err := c.GetAddressBalance(ctx, &GetAddressBalanceRequest{Address: address})
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get the balance of %s", address)
+	}
+	if int64(before.Balance) >= amount {
+		return nil
+	}
+
+	// the first block tells how much a block pays
+	if _, err := c.GenerateToAddress(ctx, 1, address); err != nil {
+		return err
+	}
+	after, err := c.GetAddressBalance(ctx, &GetAddressBalanceRequest{Address: address})
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get the balance of %s", address)
+	}
+	reward := int64(after.Balance) - int64(before.Balance)
+	if reward <= 0 {
+		return errors.Errorf("mining to %s didn't pay a reward", address)
+	}
+
+	missing := amount - int64(after.Balance)
+	if missing <= 0 {
+		return nil
+	}
+	blocks := int((missing + reward - 1) / reward)
+	_, err = c.GenerateToAddress(ctx, blocks, address)
+	return err
+}
+
+func (c *Qtum) accountsSpendable(ctx context.Context, amounts map[string]int64) (bool, error) {
+	for address, amount := range amounts {
+		balance, err := c.GetAddressBalance(ctx, &GetAddressBalanceRequest{Address: address})
+		if err != nil {
+			return false, errors.Wrapf(err, "couldn't get the balance of %s", address)
+		}
+		if int64(balance.Balance)-balance.Immature < amount {
+			return false, nil
+		}
+	}
+	return true, nil
+}
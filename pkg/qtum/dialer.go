@@ -0,0 +1,248 @@
+package qtum
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// race both families, starting with the one listed first in DNS, like Go's default dialer
+	DialFamilyAuto = "auto"
+	// race both families, giving IPv4 a head start
+	DialFamilyPreferIPv4 = "prefer-ipv4"
+	// race both families, giving IPv6 a head start
+	DialFamilyPreferIPv6 = "prefer-ipv6"
+	// only connect over IPv4
+	DialFamilyIPv4 = "ipv4"
+	// only connect over IPv6, for qtumd on IPv6 only hosts
+	DialFamilyIPv6 = "ipv6"
+)
+
+var DialFamilies = []string{DialFamilyAuto, DialFamilyPreferIPv4, DialFamilyPreferIPv6, DialFamilyIPv4, DialFamilyIPv6}
+
+// DialConfig controls how connections to qtumd are made on dual-stack hosts
+type DialConfig struct {
+	Family string
+	// head start of the preferred family before the other one is tried too, see RFC 6555
+	FallbackDelay time.Duration
+	// connect timeouts per address family
+	IPv4Timeout time.Duration
+	IPv6Timeout time.Duration
+}
+
+func DefaultDialConfig() DialConfig {
+	return DialConfig{
+		Family:        DialFamilyAuto,
+		FallbackDelay: 300 * time.Millisecond,
+		IPv4Timeout:   60 * time.Second,
+		IPv6Timeout:   60 * time.Second,
+	}
+}
+
+func (config DialConfig) validate() error {
+	for _, family := range DialFamilies {
+		if config.Family == family {
+			if config.FallbackDelay < 0 || config.IPv4Timeout <= 0 || config.IPv6Timeout <= 0 {
+				return errors.New("dial timeouts must be positive")
+			}
+			return nil
+		}
+	}
+	return errors.Errorf("unknown dial family %q", config.Family)
+}
+
+// upstreamDialer connects to qtumd following the configured address family preference, racing the families
+// ("happy eyeballs") unless only one is allowed
+type upstreamDialer struct {
+	config DialConfig
+	lookup lookuper
+	dial   dialFunc
+}
+
+func newUpstreamDialer(config DialConfig) *upstreamDialer {
+	return &upstreamDialer{
+		config: config,
+		lookup: net.DefaultResolver,
+		dial:   (&net.Dialer{}).DialContext,
+	}
+}
+
+func isIPv4(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() != nil
+}
+
+// order splits host:port addresses into the family to try first and the one to fall back to, leaving out
+// families that aren't allowed
+func (d *upstreamDialer) order(addresses []string) (primaries []string, fallbacks []string) {
+	var v4, v6 []string
+	for _, address := range addresses {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		if isIPv4(host) {
+			v4 = append(v4, address)
+		} else {
+			v6 = append(v6, address)
+		}
+	}
+
+	switch d.config.Family {
+	case DialFamilyIPv4:
+		return v4, nil
+	case DialFamilyIPv6:
+		return v6, nil
+	case DialFamilyPreferIPv6:
+		primaries, fallbacks = v6, v4
+	case DialFamilyPreferIPv4:
+		primaries, fallbacks = v4, v6
+	default:
+		primaries, fallbacks = v4, v6
+		if len(addresses) != 0 && !containsAddress(v4, addresses[0]) {
+			primaries, fallbacks = v6, v4
+		}
+	}
+	if len(primaries) == 0 {
+		return fallbacks, nil
+	}
+	return primaries, fallbacks
+}
+
+// ordered lists the allowed addresses in the order they are tried, for the upstream resolver
+func (d *upstreamDialer) ordered(addresses []string) []string {
+	for _, address := range addresses {
+		if host, _, _ := net.SplitHostPort(address); net.ParseIP(host) == nil {
+			// SRV targets are hostnames, the family is chosen when they are dialed
+			return addresses
+		}
+	}
+	primaries, fallbacks := d.order(addresses)
+	return append(append([]string{}, primaries...), fallbacks...)
+}
+
+func (d *upstreamDialer) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	if net.ParseIP(host) != nil {
+		addresses = []string{address}
+	} else {
+		hosts, err := d.lookup.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, resolved := range hosts {
+			addresses = append(addresses, net.JoinHostPort(resolved, port))
+		}
+	}
+
+	primaries, fallbacks := d.order(addresses)
+	if len(primaries) == 0 {
+		return nil, errors.Errorf("%s has no %s address", host, d.config.Family)
+	}
+	if len(fallbacks) == 0 {
+		return d.dialSerial(ctx, network, primaries)
+	}
+	return d.dialParallel(ctx, network, primaries, fallbacks)
+}
+
+type dialResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+}
+
+// dialParallel starts on the fallback family when the primary one fails or doesn't connect within the fallback
+// delay, the first connection made wins
+func (d *upstreamDialer) dialParallel(ctx context.Context, network string, primaries []string, fallbacks []string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	start := func(addresses []string, primary bool) {
+		go func() {
+			conn, err := d.dialSerial(ctx, network, addresses)
+			results <- dialResult{conn: conn, err: err, primary: primary}
+		}()
+	}
+
+	start(primaries, true)
+	pending := 1
+	fallbackTimer := time.NewTimer(d.config.FallbackDelay)
+	defer fallbackTimer.Stop()
+	fallbackStarted := false
+	var primaryErr, fallbackErr error
+
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				start(fallbacks, false)
+				pending++
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				// the losing attempt is cancelled, close it in case it connected anyway
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}
+				}(pending)
+				return result.conn, nil
+			}
+			if result.primary {
+				primaryErr = result.err
+			} else {
+				fallbackErr = result.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				start(fallbacks, false)
+				pending++
+			} else if pending == 0 {
+				if primaryErr != nil {
+					return nil, primaryErr
+				}
+				return nil, fallbackErr
+			}
+		}
+	}
+}
+
+// dialSerial tries the addresses of one family in turn, each within the family's timeout
+func (d *upstreamDialer) dialSerial(ctx context.Context, network string, addresses []string) (net.Conn, error) {
+	var lastErr error
+	for _, address := range addresses {
+		host, _, _ := net.SplitHostPort(address)
+		familyNetwork, timeout := network+"6", d.config.IPv6Timeout
+		if isIPv4(host) {
+			familyNetwork, timeout = network+"4", d.config.IPv4Timeout
+		}
+		if network != "tcp" {
+			familyNetwork = network
+		}
+
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		conn, err := d.dial(dialCtx, familyNetwork, address)
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
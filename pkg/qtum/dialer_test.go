@@ -0,0 +1,113 @@
+package qtum
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// scriptedDial connects after a delay per address, addresses without a delay refuse the connection
+type scriptedDial struct {
+	mutex  sync.Mutex
+	delays map[string]time.Duration
+	dialed []string
+}
+
+func (d *scriptedDial) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	d.mutex.Lock()
+	d.dialed = append(d.dialed, network+" "+address)
+	delay, ok := d.delays[address]
+	d.mutex.Unlock()
+	if !ok {
+		return nil, errors.Errorf("connection refused %s", address)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(delay):
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return &addressedConn{Conn: client, address: address}, nil
+}
+
+type addressedConn struct {
+	net.Conn
+	address string
+}
+
+func newTestDialer(family string, delays map[string]time.Duration) (*upstreamDialer, *scriptedDial) {
+	config := DefaultDialConfig()
+	config.Family = family
+	config.FallbackDelay = 50 * time.Millisecond
+	dialer := newUpstreamDialer(config)
+	dialer.lookup = &fakeLookup{hosts: map[string][]string{
+		"qtum": {"10.0.0.1", "fd00::1"},
+	}}
+	scripted := &scriptedDial{delays: delays}
+	dialer.dial = scripted.dial
+	return dialer, scripted
+}
+
+func TestUpstreamDialerFamilies(t *testing.T) {
+	tests := []struct {
+		family string
+		delays map[string]time.Duration
+		want   string
+	}{
+		// the preferred family wins when it connects within the fallback delay
+		{DialFamilyPreferIPv6, map[string]time.Duration{"10.0.0.1:3889": 0, "[fd00::1]:3889": 10 * time.Millisecond}, "[fd00::1]:3889"},
+		// a slow preferred family loses the race
+		{DialFamilyPreferIPv6, map[string]time.Duration{"10.0.0.1:3889": 0, "[fd00::1]:3889": time.Second}, "10.0.0.1:3889"},
+		// a failing preferred family falls back right away
+		{DialFamilyPreferIPv4, map[string]time.Duration{"[fd00::1]:3889": 0}, "[fd00::1]:3889"},
+		// DNS order decides in auto mode
+		{DialFamilyAuto, map[string]time.Duration{"10.0.0.1:3889": 10 * time.Millisecond, "[fd00::1]:3889": 0}, "10.0.0.1:3889"},
+		{DialFamilyIPv6, map[string]time.Duration{"10.0.0.1:3889": 0, "[fd00::1]:3889": 10 * time.Millisecond}, "[fd00::1]:3889"},
+	}
+
+	for _, test := range tests {
+		dialer, _ := newTestDialer(test.family, test.delays)
+		conn, err := dialer.dialContext(context.Background(), "tcp", "qtum:3889")
+		if err != nil {
+			t.Errorf("%s: %v", test.family, err)
+			continue
+		}
+		if got := conn.(*addressedConn).address; got != test.want {
+			t.Errorf("%s: connected to %s, expected %s", test.family, got, test.want)
+		}
+	}
+}
+
+func TestUpstreamDialerSingleFamily(t *testing.T) {
+	dialer, scripted := newTestDialer(DialFamilyIPv4, map[string]time.Duration{"[fd00::1]:3889": 0})
+	if _, err := dialer.dialContext(context.Background(), "tcp", "qtum:3889"); err == nil {
+		t.Error("expected IPv4 only dialing to fail")
+	}
+	if len(scripted.dialed) != 1 || scripted.dialed[0] != "tcp4 10.0.0.1:3889" {
+		t.Errorf("unexpected dials %v", scripted.dialed)
+	}
+
+	if _, err := dialer.dialContext(context.Background(), "tcp", "[fd00::1]:3889"); err == nil {
+		t.Error("expected IPv4 only dialing to refuse an IPv6 address")
+	}
+}
+
+func TestUpstreamDialerTimeoutPerFamily(t *testing.T) {
+	dialer, _ := newTestDialer(DialFamilyPreferIPv6, map[string]time.Duration{"10.0.0.1:3889": 0, "[fd00::1]:3889": time.Minute})
+	dialer.config.IPv6Timeout = 20 * time.Millisecond
+	dialer.config.FallbackDelay = time.Minute
+
+	start := time.Now()
+	conn, err := dialer.dialContext(context.Background(), "tcp", "qtum:3889")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.(*addressedConn).address != "10.0.0.1:3889" || time.Since(start) > time.Second {
+		t.Errorf("expected to fall back to IPv4 once IPv6 timed out")
+	}
+}
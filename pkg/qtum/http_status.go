@@ -0,0 +1,85 @@
+package qtum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnauthorized = errors.New("qtumd refused the RPC credentials")         // 401
+	ErrForbidden    = errors.New("qtumd doesn't accept RPC calls from Janus") // 403, e.g. missing from -rpcallowip
+	// a proxy in front of qtumd couldn't reach it
+	ErrUpstreamUnavailable = errors.New("qtumd is unreachable") // 502, 504
+	ErrHTTPStatus          = errors.New("qtumd answered with an HTTP error")
+)
+
+// HTTPStatusError is returned when qtumd answers with an error status and no JSON-RPC response, its cause tells what
+// went wrong
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	cause := e.Cause().Error()
+	body := strings.TrimSpace(e.Body)
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	if body == "" || strings.Contains(cause, body) {
+		return fmt.Sprintf("%s (HTTP %d)", cause, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (HTTP %d: %s)", cause, e.StatusCode, body)
+}
+
+func (e *HTTPStatusError) Cause() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// qtumd answers 503 when its work queue is full, rate limiting proxies 429
+		return ErrQtumWorkQueueDepth
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return ErrUpstreamUnavailable
+	default:
+		return ErrHTTPStatus
+	}
+}
+
+// Temporary reports whether the same request can succeed when it is retried later
+func (e *HTTPStatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// checkStatus turns error statuses into an HTTPStatusError. qtumd answers failed calls with 404 or 500 and a JSON-RPC
+// error in the body, those bodies are handled like any other response
+func checkStatus(statusCode int, body []byte) error {
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return nil
+	}
+	return &HTTPStatusError{StatusCode: statusCode, Body: string(body)}
+}
+
+// IsRetryableError reports whether a call failed in a way retrying it after a backoff can fix
+func IsRetryableError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return IsBusyError(err)
+}
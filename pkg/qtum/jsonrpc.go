@@ -41,14 +41,25 @@ const (
 	MethodCreateRawTx           = "createrawtransaction"
 	MethodSignRawTx             = "signrawtransactionwithwallet"
 	MethodSendRawTx             = "sendrawtransaction"
+	MethodTestMempoolAccept     = "testmempoolaccept"
+	MethodEstimateSmartFee      = "estimatesmartfee"
 	MethodGetStakingInfo        = "getstakinginfo"
 	MethodGetAddressBalance     = "getaddressbalance"
 	MethodGetAddressUTXOs       = "getaddressutxos"
+	MethodGetAddressDeltas      = "getaddressdeltas"
+	MethodGetAddressMempool     = "getaddressmempool"
+	MethodGetRawMempool         = "getrawmempool"
+	MethodGetMempoolEntry       = "getmempoolentry"
 	MethodCreateWallet          = "createwallet"
 	MethodLoadWallet            = "loadwallet"
 	MethodUnloadWallet          = "unloadwallet"
 	MethodListWallets           = "listwallets"
 	MethodListWalletDir         = "listwalletdir"
+	MethodWalletPassphrase      = "walletpassphrase"
+	MethodListReceivedByAddress = "listreceivedbyaddress"
+	MethodImportPrivKey         = "importprivkey"
+	MethodSetMockTime           = "setmocktime"
+	MethodListContracts         = "listcontracts"
 )
 
 type JSONRPCRequest struct {
@@ -0,0 +1,512 @@
+package qtum
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/utils"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// DefaultUnlockTimeout is how long personal_unlockAccount unlocks an account for without a duration, as in geth
+const DefaultUnlockTimeout = 300 * time.Second
+
+var ErrUnknownAccount = errors.New("unknown account")
+
+// ErrAccountLocked is returned for encrypted accounts that have to be unlocked with personal_unlockAccount first
+var ErrAccountLocked = errors.New("authentication needed: password or unlock")
+
+var ErrInvalidPassphrase = errors.New("could not decrypt key with given password")
+
+// ErrTooManyAttempts is returned while an account refuses passphrases after maxPassphraseAttempts wrong ones in a row
+var ErrTooManyAttempts = errors.New("too many wrong passphrases, try again later")
+
+// maxPassphraseAttempts wrong passphrases in a row make an account refuse passphrases for passphraseLockout, which
+// keeps them from being guessed through personal_unlockAccount and personal_sendTransaction
+const maxPassphraseAttempts = 5
+
+const passphraseLockout = time.Minute
+
+// Keystore holds the accounts returned by eth_accounts and signed for. The private keys given as WIF are always
+// unlocked, the geth style encrypted key files of a directory are listed locked and can sign once unlocked with their
+// passphrase
+type Keystore struct {
+	mutex sync.RWMutex
+	// keys given as WIF, in the order given
+	plain Accounts
+	// encrypted key files by hex address
+	files map[string]*keyFile
+
+	dir string
+	// how long accounts are unlocked for when no duration is given, zero unlocks them until Janus exits
+	unlockTimeout time.Duration
+	// how often dir is scanned for added, changed and removed key files, zero only reads it at startup
+	reloadInterval time.Duration
+	params         *chaincfg.Params
+}
+
+type keyFile struct {
+	path     string
+	modified time.Time
+	key      encryptedKey
+	// the decrypted key while unlocked
+	unlocked *btcutil.WIF
+	// relocks the key once the unlock duration is over, nil when unlocked indefinitely
+	relock *time.Timer
+	// wrong passphrases in a row, and until when passphrases are refused after too many
+	failedAttempts int
+	lockedOutUntil time.Time
+}
+
+// encryptedKey is the version 3 key file format of geth's keystore. Its address is the hex address of the Qtum
+// account, the hash160 of the compressed public key, not the Ethereum address of the key
+type encryptedKey struct {
+	Address string `json:"address"`
+	Crypto  struct {
+		Cipher       string `json:"cipher"`
+		CipherText   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		KDF       string          `json:"kdf"`
+		KDFParams json.RawMessage `json:"kdfparams"`
+		MAC       string          `json:"mac"`
+	} `json:"crypto"`
+	Version int `json:"version"`
+}
+
+type scryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+type pbkdf2Params struct {
+	C     int    `json:"c"`
+	DKLen int    `json:"dklen"`
+	PRF   string `json:"prf"`
+	Salt  string `json:"salt"`
+}
+
+func NewKeystore(isMain bool) *Keystore {
+	params := &chaincfg.MainNetParams
+	if !isMain {
+		params = &chaincfg.TestNet3Params
+	}
+	return &Keystore{
+		files:         map[string]*keyFile{},
+		unlockTimeout: DefaultUnlockTimeout,
+		params:        params,
+	}
+}
+
+// Add adds always unlocked accounts, after the ones added before
+func (k *Keystore) Add(accounts ...*btcutil.WIF) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.plain = append(k.plain, accounts...)
+}
+
+// Addresses are the hex addresses of every account, locked or not: the WIF accounts in the order added followed by
+// the key files sorted by address
+func (k *Keystore) Addresses() []string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	addresses := make([]string, 0, len(k.plain)+len(k.files))
+	for _, wif := range k.plain {
+		addresses = append(addresses, (&Account{wif}).ToHexAddress())
+	}
+	files := make([]string, 0, len(k.files))
+	for address := range k.files {
+		if k.findPlain(address) == nil {
+			files = append(files, address)
+		}
+	}
+	sort.Strings(files)
+	return append(addresses, files...)
+}
+
+// Has reports whether address is an account of the keystore, locked or not
+func (k *Keystore) Has(address string) bool {
+	address = normalizeHexAddress(address)
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.findPlain(address) != nil || k.files[address] != nil
+}
+
+// Find returns the private key of an unlocked account, ErrAccountLocked for a locked one
+func (k *Keystore) Find(address string) (*btcutil.WIF, error) {
+	address = normalizeHexAddress(address)
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if wif := k.findPlain(address); wif != nil {
+		return wif, nil
+	}
+	file, ok := k.files[address]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	if file.unlocked == nil {
+		return nil, ErrAccountLocked
+	}
+	return file.unlocked, nil
+}
+
+func (k *Keystore) findPlain(address string) *btcutil.WIF {
+	return k.plain.FindByHexAddress(address)
+}
+
+// Unlock decrypts the key file of address with passphrase for duration, or for the unlock timeout of the keystore when
+// duration is nil. A zero duration unlocks it until Janus exits. Unlocking an unlocked account again starts its
+// duration over
+func (k *Keystore) Unlock(address string, passphrase string, duration *time.Duration) error {
+	address = normalizeHexAddress(address)
+	wif, file, err := k.decrypt(address, passphrase)
+	if err != nil || file == nil {
+		return err
+	}
+
+	timeout := k.unlockTimeout
+	if duration != nil {
+		timeout = *duration
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	// the file may have been reloaded meanwhile
+	if k.files[address] != file {
+		return errors.Errorf("key file %s changed while unlocking", file.path)
+	}
+	file.stopRelock()
+	file.unlocked = wif
+	if timeout > 0 {
+		var relock *time.Timer
+		relock = time.AfterFunc(timeout, func() {
+			k.mutex.Lock()
+			defer k.mutex.Unlock()
+			// unlocked again meanwhile
+			if file.relock == relock {
+				file.unlocked = nil
+				file.relock = nil
+			}
+		})
+		file.relock = relock
+	}
+	return nil
+}
+
+// Decrypt returns the private key of an account for a single use without unlocking it, the passphrase is checked
+// whether the account is unlocked or not. Accounts given as WIF need no passphrase
+func (k *Keystore) Decrypt(address string, passphrase string) (*btcutil.WIF, error) {
+	wif, _, err := k.decrypt(normalizeHexAddress(address), passphrase)
+	return wif, err
+}
+
+// IsEncrypted reports whether address is the account of a key file, which takes a passphrase
+func (k *Keystore) IsEncrypted(address string) bool {
+	address = normalizeHexAddress(address)
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.findPlain(address) == nil && k.files[address] != nil
+}
+
+// decrypt returns the private key of address and the key file it was decrypted from, nil for WIF accounts
+func (k *Keystore) decrypt(address string, passphrase string) (*btcutil.WIF, *keyFile, error) {
+	k.mutex.RLock()
+	file, ok := k.files[address]
+	plain := k.findPlain(address)
+	k.mutex.RUnlock()
+	if plain != nil {
+		return plain, nil, nil
+	}
+	if !ok {
+		return nil, nil, ErrUnknownAccount
+	}
+	if err := k.attempt(file); err != nil {
+		return nil, nil, err
+	}
+
+	// scrypt takes a while, the keystore isn't held meanwhile
+	privateKey, err := file.key.decrypt(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	k.resetAttempts(file)
+	wif, err := keyOfAddress(privateKey, address, k.params)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "key file %s", file.path)
+	}
+	return wif, file, nil
+}
+
+// attempt counts an attempt to decrypt file, ErrTooManyAttempts while it refuses passphrases. The attempt counts as
+// failed until resetAttempts says otherwise, so concurrent guesses can't get around the limit
+func (k *Keystore) attempt(file *keyFile) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if time.Now().Before(file.lockedOutUntil) {
+		return ErrTooManyAttempts
+	}
+	file.failedAttempts++
+	if file.failedAttempts >= maxPassphraseAttempts {
+		file.failedAttempts = 0
+		file.lockedOutUntil = time.Now().Add(passphraseLockout)
+	}
+	return nil
+}
+
+// resetAttempts forgets the failed attempts of file after the right passphrase
+func (k *Keystore) resetAttempts(file *keyFile) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	file.failedAttempts = 0
+	file.lockedOutUntil = time.Time{}
+}
+
+// Lock locks an unlocked key file account, the WIF accounts can't be locked
+func (k *Keystore) Lock(address string) error {
+	address = normalizeHexAddress(address)
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	file, ok := k.files[address]
+	if !ok {
+		if k.findPlain(address) != nil {
+			return errors.New("accounts given as private keys can't be locked")
+		}
+		return ErrUnknownAccount
+	}
+	file.stopRelock()
+	file.unlocked = nil
+	return nil
+}
+
+func (f *keyFile) stopRelock() {
+	if f.relock != nil {
+		f.relock.Stop()
+		f.relock = nil
+	}
+}
+
+// Reload reads the key files of the directory again. Changed files are locked, removed files are forgotten with their
+// keys. Files that can't be read are skipped and reported in the error
+func (k *Keystore) Reload() error {
+	if k.dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(k.dir)
+	if err != nil {
+		return errors.Wrap(err, "couldn't read keystore")
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	byPath := make(map[string]*keyFile, len(k.files))
+	for _, file := range k.files {
+		byPath[file.path] = file
+	}
+
+	var failed []string
+	files := make(map[string]*keyFile, len(entries))
+	for _, entry := range entries {
+		// skip editor backups and dot files, as geth does
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(k.dir, name)
+		info, err := entry.Info()
+		if err != nil {
+			failed = append(failed, name+": "+err.Error())
+			continue
+		}
+
+		file := byPath[path]
+		if file == nil || !file.modified.Equal(info.ModTime()) {
+			read, err := readKeyFile(path, info.ModTime())
+			if err != nil {
+				failed = append(failed, name+": "+err.Error())
+				continue
+			}
+			file = read
+		}
+		address := normalizeHexAddress(file.key.Address)
+		if other, ok := files[address]; ok {
+			failed = append(failed, name+": same account as "+filepath.Base(other.path))
+			continue
+		}
+		files[address] = file
+	}
+
+	for _, file := range k.files {
+		if files[normalizeHexAddress(file.key.Address)] != file {
+			file.stopRelock()
+			file.unlocked = nil
+		}
+	}
+	k.files = files
+
+	if len(failed) > 0 {
+		return errors.Errorf("skipped key files %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// watch reloads the directory every reload interval until ctx is done
+func (k *Keystore) watch(ctx context.Context, logger func() log.Logger) {
+	ticker := time.NewTicker(k.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				level.Warn(logger()).Log("msg", "Failed to reload keystore", "dir", k.dir, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func readKeyFile(path string, modified time.Time) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var key encryptedKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, errors.Wrap(err, "invalid key file")
+	}
+	if key.Version != 3 {
+		return nil, errors.Errorf("unsupported key file version %d", key.Version)
+	}
+	if !utils.IsEthHexAddress(key.Address) {
+		return nil, errors.Errorf("invalid key file address %q", key.Address)
+	}
+	return &keyFile{path: path, modified: modified, key: key}, nil
+}
+
+// keyOfAddress is the WIF of privateKey whose public key, compressed as Qtum uses them or uncompressed, hashes to
+// address
+func keyOfAddress(privateKey *btcec.PrivateKey, address string, params *chaincfg.Params) (*btcutil.WIF, error) {
+	var compressed string
+	for _, compress := range []bool{true, false} {
+		wif, err := btcutil.NewWIF(privateKey, params, compress)
+		if err != nil {
+			return nil, err
+		}
+		hexAddress := (&Account{wif}).ToHexAddress()
+		if hexAddress == address {
+			return wif, nil
+		}
+		if compress {
+			compressed = hexAddress
+		}
+	}
+	return nil, errors.Errorf("key is not for account %s, set the address of the file to the Qtum hex address %s", address, compressed)
+}
+
+// decrypt returns the private key of a key file, as geth decrypts it
+func (k *encryptedKey) decrypt(passphrase string) (*btcec.PrivateKey, error) {
+	if k.Crypto.Cipher != "aes-128-ctr" {
+		return nil, errors.Errorf("unsupported key file cipher %q", k.Crypto.Cipher)
+	}
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key file ciphertext")
+	}
+	iv, err := hex.DecodeString(k.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key file iv")
+	}
+	mac, err := hex.DecodeString(k.Crypto.MAC)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key file mac")
+	}
+
+	derivedKey, err := k.derivedKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(derivedKey) < 32 {
+		return nil, errors.New("key file derived key is too short")
+	}
+	if !bytes.Equal(crypto.Keccak256(derivedKey[16:32], cipherText), mac) {
+		return nil, ErrInvalidPassphrase
+	}
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("invalid key file iv")
+	}
+	privateKey := make([]byte, len(cipherText))
+	cipher.NewCTR(block, iv).XORKeyStream(privateKey, cipherText)
+	if len(privateKey) != 32 {
+		return nil, errors.New("invalid key file private key")
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), privateKey)
+	return key, nil
+}
+
+func (k *encryptedKey) derivedKey(passphrase string) ([]byte, error) {
+	switch k.Crypto.KDF {
+	case "scrypt":
+		var params scryptParams
+		if err := json.Unmarshal(k.Crypto.KDFParams, &params); err != nil {
+			return nil, errors.Wrap(err, "invalid key file kdfparams")
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid key file salt")
+		}
+		return scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DKLen)
+	case "pbkdf2":
+		var params pbkdf2Params
+		if err := json.Unmarshal(k.Crypto.KDFParams, &params); err != nil {
+			return nil, errors.Wrap(err, "invalid key file kdfparams")
+		}
+		if params.PRF != "hmac-sha256" {
+			return nil, errors.Errorf("unsupported key file prf %q", params.PRF)
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid key file salt")
+		}
+		return pbkdf2.Key([]byte(passphrase), salt, params.C, params.DKLen, sha256.New), nil
+	default:
+		return nil, errors.Errorf("unsupported key file kdf %q", k.Crypto.KDF)
+	}
+}
+
+func normalizeHexAddress(address string) string {
+	return strings.ToLower(utils.RemoveHexPrefix(address))
+}
@@ -0,0 +1,252 @@
+package qtum
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const testKeyWIF = "5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89"
+const testKeyAddress = "6d358cf96533189dd5a602d0937fddf0888ad3ae"
+
+// encryptTestKey writes a key file the way geth does, with cheap scrypt or pbkdf2 parameters
+func encryptTestKey(t *testing.T, path string, wif string, address string, passphrase string, kdf string) {
+	key, err := btcutil.DecodeWIF(wif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := []byte("0123456789abcdef0123456789abcdef")
+	iv := []byte("0123456789abcdef")
+
+	var derivedKey []byte
+	var params interface{}
+	switch kdf {
+	case "scrypt":
+		derivedKey, err = scrypt.Key([]byte(passphrase), salt, 16, 8, 1, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params = scryptParams{N: 16, R: 8, P: 1, DKLen: 32, Salt: hex.EncodeToString(salt)}
+	case "pbkdf2":
+		derivedKey = pbkdf2.Key([]byte(passphrase), salt, 16, 32, sha256.New)
+		params = pbkdf2Params{C: 16, DKLen: 32, PRF: "hmac-sha256", Salt: hex.EncodeToString(salt)}
+	}
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherText := make([]byte, 32)
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, key.PrivKey.Serialize())
+
+	var file encryptedKey
+	file.Address = address
+	file.Version = 3
+	file.Crypto.Cipher = "aes-128-ctr"
+	file.Crypto.CipherText = hex.EncodeToString(cipherText)
+	file.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	file.Crypto.KDF = kdf
+	if file.Crypto.KDFParams, err = json.Marshal(params); err != nil {
+		t.Fatal(err)
+	}
+	file.Crypto.MAC = hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], cipherText))
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestKeystore(t *testing.T, dir string) *Keystore {
+	keystore := NewKeystore(true)
+	keystore.dir = dir
+	if err := keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return keystore
+}
+
+func TestKeystoreUnlock(t *testing.T) {
+	for _, kdf := range []string{"scrypt", "pbkdf2"} {
+		dir := t.TempDir()
+		encryptTestKey(t, filepath.Join(dir, "key.json"), testKeyWIF, testKeyAddress, "janus", kdf)
+		keystore := newTestKeystore(t, dir)
+
+		if addresses := keystore.Addresses(); len(addresses) != 1 || addresses[0] != testKeyAddress {
+			t.Fatalf("%s: expected the locked account to be listed, got %v", kdf, addresses)
+		}
+		if _, err := keystore.Find(testKeyAddress); err != ErrAccountLocked {
+			t.Errorf("%s: expected the account to be locked, got %v", kdf, err)
+		}
+		if err := keystore.Unlock(testKeyAddress, "wrong", nil); err != ErrInvalidPassphrase {
+			t.Errorf("%s: expected a wrong passphrase to be refused, got %v", kdf, err)
+		}
+
+		duration := 50 * time.Millisecond
+		if err := keystore.Unlock("0x"+testKeyAddress, "janus", &duration); err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		key, err := keystore.Find(testKeyAddress)
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if key.String() != testKeyWIF {
+			t.Errorf("%s: expected the decrypted key %s, got %s", kdf, testKeyWIF, key.String())
+		}
+		time.Sleep(100 * time.Millisecond)
+		if _, err := keystore.Find(testKeyAddress); err != ErrAccountLocked {
+			t.Errorf("%s: expected the account to be locked after its duration, got %v", kdf, err)
+		}
+	}
+}
+
+func TestKeystorePassphraseAttempts(t *testing.T) {
+	dir := t.TempDir()
+	encryptTestKey(t, filepath.Join(dir, "key.json"), testKeyWIF, testKeyAddress, "janus", "pbkdf2")
+	keystore := newTestKeystore(t, dir)
+
+	for i := 1; i < maxPassphraseAttempts; i++ {
+		if _, err := keystore.Decrypt(testKeyAddress, "wrong"); err != ErrInvalidPassphrase {
+			t.Fatalf("expected a wrong passphrase to be refused, got %v", err)
+		}
+	}
+	// the right passphrase starts the count over
+	if err := keystore.Unlock(testKeyAddress, "janus", nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxPassphraseAttempts; i++ {
+		if err := keystore.Unlock(testKeyAddress, "wrong", nil); err != ErrInvalidPassphrase {
+			t.Fatalf("expected a wrong passphrase to be refused, got %v", err)
+		}
+	}
+	if err := keystore.Unlock(testKeyAddress, "janus", nil); err != ErrTooManyAttempts {
+		t.Errorf("expected passphrases to be refused after %d wrong ones, got %v", maxPassphraseAttempts, err)
+	}
+	if _, err := keystore.Decrypt(testKeyAddress, "janus"); err != ErrTooManyAttempts {
+		t.Errorf("expected passphrases to be refused after %d wrong ones, got %v", maxPassphraseAttempts, err)
+	}
+}
+
+func TestKeystoreUnlockIndefinitely(t *testing.T) {
+	dir := t.TempDir()
+	encryptTestKey(t, filepath.Join(dir, "key.json"), testKeyWIF, testKeyAddress, "janus", "scrypt")
+	keystore := newTestKeystore(t, dir)
+
+	short := 10 * time.Millisecond
+	if err := keystore.Unlock(testKeyAddress, "janus", &short); err != nil {
+		t.Fatal(err)
+	}
+	// unlocking again replaces the duration
+	var indefinitely time.Duration
+	if err := keystore.Unlock(testKeyAddress, "janus", &indefinitely); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := keystore.Find(testKeyAddress); err != nil {
+		t.Errorf("expected the account to stay unlocked, got %v", err)
+	}
+
+	if err := keystore.Lock(testKeyAddress); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.Find(testKeyAddress); err != ErrAccountLocked {
+		t.Errorf("expected the account to be locked, got %v", err)
+	}
+	if err := keystore.Unlock("6d358cf96533189dd5a602d0937fddf0888ad3af", "janus", nil); err != ErrUnknownAccount {
+		t.Errorf("expected an unknown account to be refused, got %v", err)
+	}
+}
+
+func TestKeystoreRefusesEthereumAddress(t *testing.T) {
+	dir := t.TempDir()
+	// the address geth writes is the Ethereum address of the key
+	encryptTestKey(t, filepath.Join(dir, "key.json"), testKeyWIF, "7926223070547d2d15b2ef5e7383e541c338ffe9", "janus", "scrypt")
+	keystore := newTestKeystore(t, dir)
+
+	if err := keystore.Unlock("7926223070547d2d15b2ef5e7383e541c338ffe9", "janus", nil); err == nil {
+		t.Error("expected a key file with another address than its key to be refused")
+	}
+}
+
+func TestKeystoreReload(t *testing.T) {
+	dir := t.TempDir()
+	keystore := newTestKeystore(t, dir)
+	wif, err := btcutil.DecodeWIF("5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystore.Add(wif)
+
+	path := filepath.Join(dir, "key.json")
+	encryptTestKey(t, path, testKeyWIF, testKeyAddress, "janus", "scrypt")
+	if err := os.WriteFile(filepath.Join(dir, "key.json~"), []byte("backup"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"7e22630f90e6db16283af2c6b04f688117a55db4", testKeyAddress}
+	if addresses := keystore.Addresses(); len(addresses) != 2 || addresses[0] != expected[0] || addresses[1] != expected[1] {
+		t.Fatalf("expected accounts %v, got %v", expected, addresses)
+	}
+
+	if err := keystore.Unlock(testKeyAddress, "janus", nil); err != nil {
+		t.Fatal(err)
+	}
+	// reloading an unchanged file keeps it unlocked
+	if err := keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.Find(testKeyAddress); err != nil {
+		t.Errorf("expected the unchanged account to stay unlocked, got %v", err)
+	}
+
+	// a changed file takes its new passphrase
+	encryptTestKey(t, path, testKeyWIF, testKeyAddress, "changed", "scrypt")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.Find(testKeyAddress); err != ErrAccountLocked {
+		t.Errorf("expected the changed account to be locked, got %v", err)
+	}
+	if err := keystore.Unlock(testKeyAddress, "changed", nil); err != nil {
+		t.Errorf("expected the changed file to be unlocked with its passphrase, got %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if keystore.Has(testKeyAddress) {
+		t.Error("expected the account of a removed file to be forgotten")
+	}
+	if _, err := keystore.Find(expected[0]); err != nil {
+		t.Errorf("expected the private key account to stay, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keystore.Reload(); err == nil {
+		t.Error("expected an invalid key file to be reported")
+	}
+}
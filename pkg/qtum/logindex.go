@@ -0,0 +1,29 @@
+package qtum
+
+import (
+	"context"
+)
+
+// LogIndex answers searchlogs from a database of the logs of the blocks it indexed, which is much faster than qtumd
+// for wide block ranges
+type LogIndex interface {
+	// SearchLogs returns the receipts of req's blocks with a log matching its addresses and first topic, in block and
+	// transaction order. covered is false when some of the blocks aren't indexed yet, qtumd is asked instead
+	SearchLogs(ctx context.Context, req *SearchLogsRequest) (receipts SearchLogsResponse, covered bool, err error)
+	// TransactionBlockHash is the block of an indexed transaction with logs, empty for transactions it doesn't know.
+	// It lets getrawtransaction find them when qtumd has no -txindex
+	TransactionBlockHash(ctx context.Context, txID string) (string, error)
+}
+
+// searchLogIndex tries the log index for req, ok is false when qtumd has to be asked
+func (m *Method) searchLogIndex(ctx context.Context, req *SearchLogsRequest) (receipts SearchLogsResponse, ok bool) {
+	if m.logIndex == nil || req.FromBlock == nil || req.ToBlock == nil || req.MinimumConfirmations != nil {
+		return nil, false
+	}
+	receipts, covered, err := m.logIndex.SearchLogs(ctx, req)
+	if err != nil {
+		m.GetErrorLogger().Log("msg", "couldn't search the log index, asking qtumd", "err", err)
+		return nil, false
+	}
+	return receipts, covered
+}
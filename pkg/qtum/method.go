@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"math/big"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/qtumproject/janus/pkg/utils"
 )
 
@@ -94,6 +96,18 @@ func (m *Method) GetRawTransaction(ctx context.Context, txID string, hexEncoded
 		resp = new(GetRawTransactionResponse)
 	)
 	err := m.RequestWithContext(ctx, MethodGetRawTransaction, &req, resp)
+	var txIndexErr *TxIndexError
+	if errors.As(err, &txIndexErr) {
+		// without -txindex qtumd needs to be told the block of the transaction
+		err = ErrInvalidAddress
+		blockHash, lookupErr := m.transactionBlockHash(ctx, txID)
+		if lookupErr != nil {
+			err = lookupErr
+		} else if blockHash != "" {
+			req.BlockHash = blockHash
+			err = m.RequestWithContext(ctx, MethodGetRawTransaction, &req, resp)
+		}
+	}
 	if err != nil {
 		if m.IsDebugEnabled() {
 			m.GetDebugLogger().Log("function", "GetRawTransaction", "Transaction ID", txID, "Hex Encoded", hexEncoded, "error", err)
@@ -194,12 +208,29 @@ func (m *Method) GetGasPrice(ctx context.Context) (*big.Int, error) {
 	return minimumGas, nil
 }
 
-// hard coded 0x1 due to the unique nature of Qtums UTXO system, might
+// GetTransactionCount counts the mined transactions spending from the hex address, QTUM has no nonces so this is what
+// comes closest. It takes -addressindex, without it every address has a count of 1
 func (m *Method) GetTransactionCount(ctx context.Context, address string, status string) (*big.Int, error) {
-	// eventually might work this out to see if there's any transactions pending for an address in the mempool
-	// for now just always return 1
-	m.GetDebugLogger().Log("Message", "GetTransactionCount is hardcoded to one")
-	return big.NewInt(0x1), nil
+	if !utils.IsEthHexAddress(address) || m.GetCapabilities().Check(CapabilityAddressIndex) != nil {
+		m.GetDebugLogger().Log("Message", "GetTransactionCount is hardcoded to one without -addressindex")
+		return big.NewInt(0x1), nil
+	}
+	base58Addr, err := m.FromHexAddress(utils.RemoveHexPrefix(address))
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't convert address")
+	}
+	deltas, err := m.GetAddressDeltas(ctx, &GetAddressDeltasRequest{Addresses: []string{base58Addr}})
+	if err != nil {
+		return nil, err
+	}
+	// a transaction spends several outputs of its sender at times
+	spending := make(map[string]bool)
+	for _, delta := range deltas {
+		if delta.Satoshis < 0 {
+			spending[strings.ToLower(delta.TXID)] = true
+		}
+	}
+	return big.NewInt(int64(len(spending))), nil
 }
 
 func (m *Method) GetBlockHash(ctx context.Context, b *big.Int) (resp GetBlockHashResponse, err error) {
@@ -232,6 +263,38 @@ func (m *Method) GetBlockHeader(ctx context.Context, hash string) (resp *GetBloc
 	return
 }
 
+// GetBlockWithTransactions gets a block along with its decoded transactions in a single call
+func (m *Method) GetBlockWithTransactions(ctx context.Context, hash string) (resp *GetBlockWithTransactionsResponse, err error) {
+	verbosity := 2
+	req := GetBlockRequest{
+		Hash:      hash,
+		Verbosity: &verbosity,
+	}
+	err = m.RequestWithContext(ctx, MethodGetBlock, &req, &resp)
+	if err != nil && m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetBlockWithTransactions", "Hash", hash, "error", err)
+	}
+	return
+}
+
+// GetRawMempool lists the txids of the transactions in qtumd's mempool
+func (m *Method) GetRawMempool(ctx context.Context) (resp GetRawMempoolResponse, err error) {
+	err = m.RequestWithContext(ctx, MethodGetRawMempool, []interface{}{}, &resp)
+	if err != nil && m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetRawMempool", "error", err)
+	}
+	return
+}
+
+// GetMempoolEntry returns the mempool entry of txid, ErrInvalidAddress when it isn't in qtumd's mempool
+func (m *Method) GetMempoolEntry(ctx context.Context, txid string) (resp *GetMempoolEntryResponse, err error) {
+	err = m.RequestWithContext(ctx, MethodGetMempoolEntry, []interface{}{txid}, &resp)
+	if err != nil && m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetMempoolEntry", "txid", txid, "error", err)
+	}
+	return
+}
+
 func (m *Method) GetBlock(ctx context.Context, hash string) (resp *GetBlockResponse, err error) {
 	req := GetBlockRequest{
 		Hash: hash,
@@ -247,14 +310,13 @@ func (m *Method) Generate(ctx context.Context, blockNum int, maxTries *int) (res
 	generateToAccount := m.GetFlagString(FLAG_GENERATE_ADDRESS_TO)
 	var qAddress string
 
-	if len(m.Accounts) == 0 && generateToAccount == nil {
+	addresses := m.Keystore.Addresses()
+	if len(addresses) == 0 && generateToAccount == nil {
 		// return nil, errors.New("you must specify QTUM accounts")
 		qAddress = "qW28njWueNpBXYWj2KDmtFG2gbLeALeHfV"
 	} else {
 		if generateToAccount == nil {
-			acc := Account{m.Accounts[0]}
-
-			qAddress, err = acc.ToBase58Address(m.isMain)
+			qAddress, err = HexToBase58Address(addresses[0], m.isMain)
 			if err != nil {
 				if m.IsDebugEnabled() {
 					m.GetDebugLogger().Log("function", "Generate", "msg", "Error getting address for account", "error", err)
@@ -291,8 +353,18 @@ func (m *Method) Generate(ctx context.Context, blockNum int, maxTries *int) (res
 /**
  * Note that QTUM searchlogs api returns all logs in a transaction receipt if any log matches a topic
  * While Ethereum behaves differently and will only return logs where topics match
+ *
+ * The receipts are returned in chain order whether they come from the block receipts cache, the log index or qtumd,
+ * which makes no promise about the order of the transactions of a block
  */
 func (m *Method) SearchLogs(ctx context.Context, req *SearchLogsRequest) (receipts SearchLogsResponse, err error) {
+	if cached, ok := m.searchBlockReceipts(ctx, req); ok {
+		return cached, nil
+	}
+	if indexed, ok := m.searchLogIndex(ctx, req); ok {
+		indexed.Sort()
+		return indexed, nil
+	}
 	if err := m.RequestWithContext(ctx, MethodSearchLogs, req, &receipts); err != nil {
 		if m.IsDebugEnabled() {
 			m.GetDebugLogger().Log("function", "SearchLogs", "erorr", err)
@@ -302,6 +374,7 @@ func (m *Method) SearchLogs(ctx context.Context, req *SearchLogsRequest) (receip
 	if m.IsDebugEnabled() {
 		m.GetDebugLogger().Log("function", "SearchLogs", "request", marshalToString(req), "msg", "Successfully searched logs")
 	}
+	receipts.Sort()
 	return
 }
 
@@ -384,6 +457,141 @@ func (m *Method) GetAddressBalance(ctx context.Context, req *GetAddressBalanceRe
 	return
 }
 
+func (m *Method) GetAddressDeltas(ctx context.Context, req *GetAddressDeltasRequest) (resp GetAddressDeltasResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodGetAddressDeltas, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GetAddressDeltas", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetAddressDeltas", "request", marshalToString(req), "msg", "Successfully got address deltas")
+	}
+	return
+}
+
+func (m *Method) GetAddressMempool(ctx context.Context, req *GetAddressMempoolRequest) (resp GetAddressMempoolResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodGetAddressMempool, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GetAddressMempool", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetAddressMempool", "request", marshalToString(req), "msg", "Successfully got address mempool")
+	}
+	return
+}
+
+func (m *Method) TestMempoolAccept(ctx context.Context, req *TestMempoolAcceptRequest) (resp TestMempoolAcceptResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodTestMempoolAccept, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "TestMempoolAccept", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "TestMempoolAccept", "request", marshalToString(req), "msg", "Successfully tested mempool acceptance")
+	}
+	return
+}
+
+func (m *Method) ListReceivedByAddress(ctx context.Context, req *ListReceivedByAddressRequest) (resp ListReceivedByAddressResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodListReceivedByAddress, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "ListReceivedByAddress", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "ListReceivedByAddress", "request", marshalToString(req), "msg", "Successfully listed wallet addresses")
+	}
+	return
+}
+
+func (m *Method) ImportPrivKey(ctx context.Context, req *ImportPrivKeyRequest) error {
+	var resp interface{}
+	if err := m.RequestWithContext(ctx, MethodImportPrivKey, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "ImportPrivKey", "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Method) ListContracts(ctx context.Context, req *ListContractsRequest) (resp ListContractsResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodListContracts, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "ListContracts", "error", err)
+		}
+		return nil, err
+	}
+	return
+}
+
+// SetMockTime sets the time qtumd uses in place of the clock on regtest, 0 goes back to the clock
+func (m *Method) SetMockTime(ctx context.Context, timestamp int64) error {
+	var resp interface{}
+	if err := m.RequestWithContext(ctx, MethodSetMockTime, []interface{}{timestamp}, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "SetMockTime", "timestamp", timestamp, "error", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// GenerateToAddress mines blocks with their rewards paid to a base58 address
+func (m *Method) GenerateToAddress(ctx context.Context, blockNum int, address string) (resp GenerateResponse, err error) {
+	req := GenerateRequest{
+		BlockNum: blockNum,
+		Address:  address,
+	}
+	if err := m.RequestWithContext(ctx, MethodGenerateToAddress, &req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GenerateToAddress", "address", address, "error", err)
+		}
+		return nil, err
+	}
+	return
+}
+
+// EstimateSmartFee asks qtumd for the fee rate getting a transaction mined within confTarget blocks
+func (m *Method) EstimateSmartFee(ctx context.Context, confTarget int64) (resp *EstimateSmartFeeResponse, err error) {
+	req := EstimateSmartFeeRequest{ConfTarget: confTarget}
+	if err := m.RequestWithContext(ctx, MethodEstimateSmartFee, &req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "EstimateSmartFee", "confTarget", confTarget, "error", err)
+		}
+		return nil, err
+	}
+	return
+}
+
+// CreateRawTransaction returns the hex of an unsigned transaction spending the inputs to the outputs
+func (m *Method) CreateRawTransaction(ctx context.Context, req *CreateRawTransactionRequest) (rawTx string, err error) {
+	if err := m.RequestWithContext(ctx, MethodCreateRawTx, req, &rawTx); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "CreateRawTransaction", "error", err)
+		}
+		return "", err
+	}
+	return
+}
+
+// SignRawTransactionWithWallet signs the inputs of a raw transaction the wallet has the keys of
+func (m *Method) SignRawTransactionWithWallet(ctx context.Context, rawTx string) (resp *SignRawTxResponse, err error) {
+	req := SignRawTxRequest{rawTx}
+	if err := m.RequestWithContext(ctx, MethodSignRawTx, &req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "SignRawTransactionWithWallet", "error", err)
+		}
+		return nil, err
+	}
+	return
+}
+
 func (m *Method) SendRawTransaction(ctx context.Context, req *SendRawTransactionRequest) (resp *SendRawTransactionResponse, err error) {
 	if err := m.RequestWithContext(ctx, MethodSendRawTx, req, &resp); err != nil {
 		if m.IsDebugEnabled() {
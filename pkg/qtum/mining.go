@@ -0,0 +1,135 @@
+package qtum
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// miningSchedule is how Janus mines regtest blocks on its own, test suites change it at runtime to control how the
+// chain progresses
+type miningSchedule struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	blocks   int
+	paused   bool
+
+	start   sync.Once
+	changed chan struct{}
+}
+
+// MiningStatus describes the mining schedule
+type MiningStatus struct {
+	// 0 when blocks are only mined after transactions
+	Interval time.Duration
+	Blocks   int
+	// neither interval mining nor mining after transactions happens while paused
+	Paused bool
+}
+
+func newMiningSchedule(interval time.Duration, blocks int) *miningSchedule {
+	if blocks < 1 {
+		blocks = 1
+	}
+	return &miningSchedule{
+		interval: interval,
+		blocks:   blocks,
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+func (m *miningSchedule) status() MiningStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return MiningStatus{Interval: m.interval, Blocks: m.blocks, Paused: m.paused}
+}
+
+func (m *miningSchedule) isPaused() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.paused
+}
+
+func (m *miningSchedule) update(change func()) MiningStatus {
+	m.mutex.Lock()
+	change()
+	status := MiningStatus{Interval: m.interval, Blocks: m.blocks, Paused: m.paused}
+	m.mutex.Unlock()
+
+	select {
+	case m.changed <- struct{}{}:
+	default:
+	}
+	return status
+}
+
+// SetIntervalMining mines blocks every interval, an interval of 0 stops interval mining
+func (c *Qtum) SetIntervalMining(interval time.Duration, blocks int) (MiningStatus, error) {
+	if interval < 0 || blocks < 1 {
+		return MiningStatus{}, errors.Errorf("invalid interval mining of %d blocks every %s", blocks, interval)
+	}
+	status := c.mining.update(func() {
+		c.mining.interval = interval
+		c.mining.blocks = blocks
+	})
+	c.startMining()
+	return status, nil
+}
+
+// PauseMining stops Janus from mining blocks, both on an interval and after transactions, blocks can still be mined
+// on request
+func (c *Qtum) PauseMining() MiningStatus {
+	return c.mining.update(func() { c.mining.paused = true })
+}
+
+func (c *Qtum) ResumeMining() MiningStatus {
+	return c.mining.update(func() { c.mining.paused = false })
+}
+
+func (c *Qtum) MiningStatus() MiningStatus {
+	return c.mining.status()
+}
+
+func (c *Qtum) startMining() {
+	c.mining.start.Do(func() {
+		go c.mineOnSchedule()
+	})
+}
+
+func (c *Qtum) mineOnSchedule() {
+	ctx := c.GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		status := c.mining.status()
+		var timer *time.Timer
+		var tick <-chan time.Time
+		if status.Interval > 0 && !status.Paused {
+			timer = time.NewTimer(status.Interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-c.mining.changed:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-tick:
+			if !c.CanGenerate() {
+				c.GetDebugLogger().Log("function", "mineOnSchedule", "msg", "not mining, blocks can only be generated on regtest")
+				continue
+			}
+			if _, err := c.Generate(ctx, status.Blocks, nil); err != nil {
+				c.GetErrorLogger().Log("msg", "Error mining scheduled blocks", "error", err)
+			}
+		}
+	}
+}
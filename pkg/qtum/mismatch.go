@@ -0,0 +1,58 @@
+package qtum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// ErrResponseMismatch is the cause of ResponseMismatchError
+var ErrResponseMismatch = errors.New("qtumd's response doesn't belong to the request")
+
+// ResponseMismatchError is returned instead of a result when qtumd's response can't be trusted to answer the request,
+// like the response to another request or a body duplicated by a misbehaving proxy in front of qtumd
+type ResponseMismatchError struct {
+	Method     string
+	RequestID  json.RawMessage
+	ResponseID json.RawMessage
+	Reason     string
+}
+
+func (e *ResponseMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s request %s got %s (%s)", ErrResponseMismatch, e.Method, e.RequestID, e.ResponseID, e.Reason)
+}
+
+func (e *ResponseMismatchError) Cause() error {
+	return ErrResponseMismatch
+}
+
+// ResponseMismatches counts the responses of qtumd that were refused with a ResponseMismatchError
+func (c *Client) ResponseMismatches() uint64 {
+	return atomic.LoadUint64(&c.responseMismatches)
+}
+
+func (c *Client) mismatch(err *ResponseMismatchError) error {
+	atomic.AddUint64(&c.responseMismatches, 1)
+	c.GetErrorLogger().Log("msg", "Refused qtumd's response", "method", err.Method, "request", string(err.RequestID), "response", string(err.ResponseID), "reason", err.Reason)
+	return err
+}
+
+// errGarbled is returned by decodeResponse for bodies that are valid JSON followed by more data
+var errGarbled = errors.New("more data after the response, it was duplicated or mangled on the way")
+
+// decodeResponse unmarshals body into v, refusing anything after the first JSON value. Bodies that aren't JSON fail
+// with the json error so callers can still recognize qtumd's plain text errors
+func decodeResponse(body []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errGarbled
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package qtum
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBlockPruned is the cause of PrunedError
+var ErrBlockPruned = errors.New("block pruned on upstream")
+
+// qtumd running with -prune answers getblock for blocks it deleted with "Block not available (pruned data)"
+const prunedDataMessage = "pruned data"
+
+// PrunedError is returned for blocks qtumd deleted to save space, they exist but it can't serve them anymore
+type PrunedError struct {
+	// what qtumd answered
+	Message string
+}
+
+func (e *PrunedError) Error() string {
+	return ErrBlockPruned.Error() + ": " + e.Message
+}
+
+func (e *PrunedError) Cause() error {
+	return ErrBlockPruned
+}
+
+// prunedError recognizes the errors qtumd answers with for pruned blocks
+func prunedError(err *JSONRPCError) *PrunedError {
+	if err == nil || !strings.Contains(err.Message, prunedDataMessage) {
+		return nil
+	}
+	return &PrunedError{Message: err.Message}
+}
@@ -23,6 +23,8 @@ type Qtum struct {
 	chain            string
 
 	errorState *errorState
+	mining     *miningSchedule
+	sent       *sentTransactions
 }
 
 const (
@@ -45,6 +47,8 @@ func New(c *Client, chain string) (*Qtum, error) {
 		Method:     &Method{Client: c},
 		chain:      chain,
 		errorState: newErrorState(),
+		mining:     newMiningSchedule(c.miningInterval, c.miningBlocks),
+		sent:       newSentTransactions(),
 	}
 
 	c.SetErrorHandler(func(ctx context.Context, err error) error {
@@ -55,6 +59,9 @@ func New(c *Client, chain string) (*Qtum, error) {
 	})
 
 	qtum.detectChain()
+	if c.miningInterval > 0 {
+		qtum.startMining()
+	}
 
 	return qtum, nil
 }
@@ -165,7 +172,7 @@ func (c *Qtum) CanGenerate() bool {
 }
 
 func (c *Qtum) GenerateIfPossible() {
-	if !c.CanGenerate() {
+	if !c.CanGenerate() || c.mining.isPaused() {
 		return
 	}
 
@@ -0,0 +1,235 @@
+package qtum
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// how often an SRV record is looked up again when no refresh interval is configured
+var defaultSRVRefreshInterval = 30 * time.Second
+
+type lookuper interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// upstreamResolver looks up qtumd's address again periodically so Janus follows DNS based failover without
+// restarting, kept alive connections to addresses that dropped out of DNS are closed once they are idle
+type upstreamResolver struct {
+	// host as it appears in the RPC URL, an SRV name such as _qtum._tcp.example.com or a hostname
+	host     string
+	port     string
+	srv      bool
+	interval time.Duration
+	lookup   lookuper
+	dial     dialFunc
+	// puts the preferred address family first
+	order     func([]string) []string
+	closeIdle func()
+	logger    func() log.Logger
+
+	mutex     sync.Mutex
+	addresses []string
+	conns     map[*upstreamConn]struct{}
+}
+
+// isSRVName reports whether a host is an SRV record name, like _qtum._tcp.example.com
+func isSRVName(host string) bool {
+	return strings.HasPrefix(host, "_") && (strings.Contains(host, "._tcp.") || strings.Contains(host, "._udp."))
+}
+
+// newUpstreamResolver returns nil when there is nothing to resolve again, for IP addresses or when refreshing is
+// disabled for a hostname
+func newUpstreamResolver(u *url.URL, interval time.Duration, dial dialFunc, closeIdle func(), logger func() log.Logger) *upstreamResolver {
+	host := u.Hostname()
+	srv := isSRVName(host)
+	if net.ParseIP(host) != nil || (interval <= 0 && !srv) {
+		return nil
+	}
+	if interval <= 0 {
+		interval = defaultSRVRefreshInterval
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return &upstreamResolver{
+		host:      host,
+		port:      port,
+		srv:       srv,
+		interval:  interval,
+		lookup:    net.DefaultResolver,
+		dial:      dial,
+		closeIdle: closeIdle,
+		logger:    logger,
+		conns:     make(map[*upstreamConn]struct{}),
+	}
+}
+
+func (r *upstreamResolver) resolve(ctx context.Context) ([]string, error) {
+	var addresses []string
+	if r.srv {
+		_, records, err := r.lookup.LookupSRV(ctx, "", "", r.host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't look up SRV record %s", r.host)
+		}
+		// records come sorted by priority and shuffled by weight
+		for _, record := range records {
+			addresses = append(addresses, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))))
+		}
+	} else {
+		hosts, err := r.lookup.LookupHost(ctx, r.host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't look up %s", r.host)
+		}
+		for _, host := range hosts {
+			addresses = append(addresses, net.JoinHostPort(host, r.port))
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, errors.Errorf("%s didn't resolve to any address", r.host)
+	}
+	return addresses, nil
+}
+
+// refresh looks the upstream up again, keeping the previous addresses when that fails
+func (r *upstreamResolver) refresh(ctx context.Context) error {
+	addresses, err := r.resolve(ctx)
+	if err != nil {
+		level.Warn(r.logger()).Log("msg", "couldn't refresh qtumd address, keeping the previous one", "error", err)
+		return err
+	}
+
+	r.mutex.Lock()
+	changed := !sameAddresses(r.addresses, addresses)
+	previous := r.addresses
+	r.addresses = addresses
+	stale := false
+	for conn := range r.conns {
+		if !containsAddress(addresses, conn.address) {
+			stale = true
+			break
+		}
+	}
+	r.mutex.Unlock()
+
+	if changed && previous != nil {
+		level.Info(r.logger()).Log("msg", "qtumd address changed", "host", r.host, "from", strings.Join(previous, ","), "to", strings.Join(addresses, ","))
+	}
+	if stale {
+		r.closeIdle()
+	}
+	return nil
+}
+
+func (r *upstreamResolver) run(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+func (r *upstreamResolver) current(ctx context.Context) ([]string, error) {
+	r.mutex.Lock()
+	addresses := r.addresses
+	r.mutex.Unlock()
+	if addresses != nil {
+		return addresses, nil
+	}
+	if err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.addresses, nil
+}
+
+// dialContext connects to the first reachable address qtumd resolved to, in place of http.Transport's own lookup
+func (r *upstreamResolver) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if host, _, err := net.SplitHostPort(address); err != nil || host != r.host {
+		return r.dial(ctx, network, address)
+	}
+	addresses, err := r.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.order != nil {
+		addresses = r.order(addresses)
+	}
+
+	dialErr := errors.Errorf("%s has no address to connect to", r.host)
+	for _, upstream := range addresses {
+		conn, err := r.dial(ctx, network, upstream)
+		if err != nil {
+			dialErr = err
+			continue
+		}
+		tracked := &upstreamConn{Conn: conn, address: upstream, resolver: r}
+		r.mutex.Lock()
+		r.conns[tracked] = struct{}{}
+		r.mutex.Unlock()
+		return tracked, nil
+	}
+	return nil, dialErr
+}
+
+// upstreamConn remembers which address a connection went to, to close it when that address goes away
+type upstreamConn struct {
+	net.Conn
+	address  string
+	resolver *upstreamResolver
+	once     sync.Once
+}
+
+func (c *upstreamConn) Close() error {
+	c.once.Do(func() {
+		c.resolver.mutex.Lock()
+		delete(c.resolver.conns, c)
+		c.resolver.mutex.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+func sameAddresses(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, address := range a {
+		if !containsAddress(b, address) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addresses []string, address string) bool {
+	for _, candidate := range addresses {
+		if candidate == address {
+			return true
+		}
+	}
+	return false
+}
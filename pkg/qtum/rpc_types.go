@@ -2,6 +2,7 @@ package qtum
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
@@ -20,6 +21,9 @@ const (
 	// Is hex representation of 40M value, which is the block gas limit, 20M is tx gas limit
 	DefaultBlockGasLimit = "2625A00"
 
+	// Is qtumd's maximum block weight, the block gas limit of the weight block gas mode
+	MaxBlockWeight = 8000000
+
 	// Is a zero wallet address, which is used as a stub, when
 	// original value cannot be defined in such cases as generated
 	// transaction
@@ -40,14 +44,14 @@ type SendToContractRawRequest struct {
 	Amount          decimal.Decimal `json:"amount"`
 	GasLimit        *big.Int        `json:"gasLimit"`
 	GasPrice        string          `json:"gasPrice"`
-	SenderAddress   string          `json:"senderaddress"`
+	SenderAddress   string          `json:"senderaddress,omitempty"`
 }
 
 type CreateContractRawRequest struct {
 	ByteCode      string   `json:"bytecode"`
 	GasLimit      *big.Int `json:"gasLimit"`
 	GasPrice      string   `json:"gasPrice"`
-	SenderAddress string   `json:"senderaddress"`
+	SenderAddress string   `json:"senderaddress,omitempty"`
 }
 
 type (
@@ -138,7 +142,9 @@ type (
 		Headers    int64   `json:"headers"`
 		Mediantime int64   `json:"mediantime"`
 		Pruned     bool    `json:"pruned"`
-		Softforks  map[string]struct {
+		// lowest height of the blocks kept, only set when Pruned
+		PruneHeight int64 `json:"pruneheight,omitempty"`
+		Softforks   map[string]struct {
 			Type   string `json:"type"`
 			Active bool   `json:"active"`
 			Height int64  `json:"height"`
@@ -150,6 +156,8 @@ type (
 			} `json:"bip9"`
 		} `json:"softforks"`
 		Verificationprogress float64 `json:"verificationprogress"`
+		// set while qtumd catches up with the network after starting
+		Initialblockdownload bool `json:"initialblockdownload"`
 	}
 )
 
@@ -347,12 +355,15 @@ type (
 			DepositSize     int    `json:"depositSize"`
 			GasForDeposit   int    `json:"gasForDeposit"`
 		} `json:"executionResult"`
-		TransactionReceipt struct {
-			StateRoot string        `json:"stateRoot"`
-			GasUsed   int           `json:"gasUsed"`
-			Bloom     string        `json:"bloom"`
-			Log       []interface{} `json:"log"`
-		} `json:"transactionReceipt"`
+		TransactionReceipt CallContractTransactionReceipt `json:"transactionReceipt"`
+	}
+
+	CallContractTransactionReceipt struct {
+		StateRoot string `json:"stateRoot"`
+		UTXORoot  string `json:"utxoRoot"`
+		GasUsed   int    `json:"gasUsed"`
+		Bloom     string `json:"bloom"`
+		Log       []Log  `json:"log"`
 	}
 )
 
@@ -489,14 +500,25 @@ type (
 	}
 
 	DecodedRawTransactionScriptPubKey struct {
-		ASM       string   `json:"asm"`
-		Hex       string   `json:"hex"`
-		ReqSigs   int64    `json:"reqSigs"`
-		Type      string   `json:"type"`
+		ASM     string `json:"asm"`
+		Hex     string `json:"hex"`
+		ReqSigs int64  `json:"reqSigs"`
+		Type    string `json:"type"`
+		// qtumd 22 and later return the single address and the output descriptor instead of reqSigs and addresses
+		Address   string   `json:"address"`
 		Addresses []string `json:"addresses"`
+		Desc      string   `json:"desc"`
 	}
 )
 
+// GetAddresses returns the addresses the output pays, whichever way qtumd returned them
+func (k *DecodedRawTransactionScriptPubKey) GetAddresses() []string {
+	if len(k.Address) != 0 {
+		return []string{k.Address}
+	}
+	return k.Addresses
+}
+
 // Calculates transaction total amount of Qtum
 func (resp *DecodedRawTransactionResponse) CalcAmount() decimal.Decimal {
 	var amount decimal.Decimal
@@ -759,10 +781,18 @@ type (
 		ContractAddress string `json:"contractAddress"`
 
 		// May has "None" value, which means, that transaction is not executed
-		Excepted string `json:"excepted"`
+		Excepted        string `json:"excepted"`
+		ExceptedMessage string `json:"exceptedMessage"`
 
 		Log         []Log `json:"log"`
 		OutputIndex int64 `json:"outputIndex"`
+
+		Bloom     string `json:"bloom"`
+		StateRoot string `json:"stateRoot"`
+		UTXORoot  string `json:"utxoRoot"`
+		// contracts created and self destructed by the transaction, from qtumd 22
+		CreatedContracts    map[string]string `json:"createdContracts,omitempty"`
+		DestructedContracts map[string]string `json:"destructedContracts,omitempty"`
 	}
 )
 
@@ -856,7 +886,7 @@ func (resp *GetHashrateResponse) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	resp.Difficulty = stakingInfo.Difficulty
+	*resp = GetHashrateResponse(stakingInfo)
 	return nil
 }
 
@@ -867,7 +897,7 @@ func (resp *GetMiningResponse) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	resp.Staking = stakingInfo.Staking
+	*resp = GetMiningResponse(stakingInfo)
 	return nil
 }
 
@@ -877,6 +907,8 @@ type (
 	GetRawTransactionRequest struct {
 		TxID    string
 		Verbose bool
+		// only needed when qtumd has no -txindex
+		BlockHash string
 	}
 	GetRawTransactionResponse struct {
 		Hex     string `json:"hex"`
@@ -897,10 +929,9 @@ type (
 		Vins  []RawTransactionVin  `json:"vin"`
 		Vouts []RawTransactionVout `json:"vout"`
 
-		// Unused fields:
-		// - "in_active_chain"
-		// - "locktime"
-
+		Locktime int64 `json:"locktime"`
+		// only set when the transaction is looked up in a given block
+		InActiveChain *bool `json:"in_active_chain,omitempty"`
 	}
 	RawTransactionVin struct {
 		ID            string  `json:"txid"`
@@ -911,19 +942,15 @@ type (
 		// TODO: temporary solution
 		ScriptSig DecodedRawTransactionScriptSig `json:"scriptSig"`
 
-		// Additional fields:
-		// - "scriptSig"
-		// - "sequence"
-		// - "txinwitness"
+		Sequence    int64    `json:"sequence"`
+		Txinwitness []string `json:"txinwitness,omitempty"`
 	}
 	// TODO: Make details into a separate struct (or use generic scriptPubKey?) for ease of use?
 	RawTransactionVout struct {
 		Amount        float64                   `json:"value"`
 		AmountSatoshi int64                     `json:"valueSat"`
 		Details       RawTransactionVoutDetails `json:"scriptPubKey"`
-
-		// Additional fields:
-		// - "n"
+		N             int64                     `json:"n"`
 	}
 
 	RawTransactionVoutDetails struct {
@@ -949,10 +976,14 @@ func (r *GetRawTransactionRequest) MarshalJSON() ([]byte, error) {
 		2. verbose     (bool, optional, default=false) If false, return a string, otherwise return a json object
 		3. "blockhash" (string, optional) The block in which to look for the transaction
 	*/
-	return json.Marshal([]interface{}{
+	params := []interface{}{
 		r.TxID,
 		r.Verbose,
-	})
+	}
+	if r.BlockHash != "" {
+		params = append(params, r.BlockHash)
+	}
+	return json.Marshal(params)
 }
 
 func (r *GetRawTransactionResponse) IsPending() bool {
@@ -973,6 +1004,21 @@ func (r *GetRawTransactionResponse) GetMiningFeeInQTUM() float64 {
 	return vinsTotals - voutsTotals
 }
 
+// GetMiningFeeInSatoshis is the fee paid by the sender, the inputs need to include their amounts (verbose getrawtransaction)
+func (r *GetRawTransactionResponse) GetMiningFeeInSatoshis() int64 {
+	var vinsTotals int64
+	var voutsTotals int64
+
+	for _, in := range r.Vins {
+		vinsTotals += in.AmountSatoshi
+	}
+	for _, out := range r.Vouts {
+		voutsTotals += out.AmountSatoshi
+	}
+
+	return vinsTotals - voutsTotals
+}
+
 // ========== GetTransaction ============= //
 
 type (
@@ -1323,6 +1369,14 @@ type (
 		Flags             string  `json:"flags"`
 		Proofhash         string  `json:"proofhash"`
 		Modifier          string  `json:"modifier"`
+
+		NTx               int    `json:"nTx"`
+		Nextblockhash     string `json:"nextblockhash"`
+		PrevoutStakeHash  string `json:"prevoutStakeHash"`
+		PrevoutStakeVoutN int    `json:"prevoutStakeVoutN"`
+		Signature         string `json:"signature"`
+		// set on blocks staked by a super staker for a delegate
+		ProofOfDelegation string `json:"proofOfDelegation,omitempty"`
 	}
 )
 
@@ -1400,9 +1454,25 @@ type (
 		Proofhash         string   `json:"proofhash"`
 		Modifier          string   `json:"modifier"`
 		Signature         string   `json:"signature"`
+
+		NTx               int    `json:"nTx"`
+		PrevoutStakeHash  string `json:"prevoutStakeHash"`
+		PrevoutStakeVoutN int    `json:"prevoutStakeVoutN"`
+		// set on blocks staked by a super staker for a delegate
+		ProofOfDelegation string `json:"proofOfDelegation,omitempty"`
 	}
 )
 
+// GetBlockWithTransactionsResponse is getblock with verbosity 2, the transactions are decoded like by
+// decoderawtransaction
+type GetBlockWithTransactionsResponse struct {
+	Hash   string                           `json:"hash"`
+	Height int                              `json:"height"`
+	Time   int                              `json:"time"`
+	Weight int                              `json:"weight"`
+	Txs    []*DecodedRawTransactionResponse `json:"tx"`
+}
+
 func (r *GetBlockRequest) MarshalJSON() ([]byte, error) {
 	verbosity := 1
 	if r.Verbosity != nil {
@@ -1464,8 +1534,49 @@ type (
 		TxID string `json:"txid"`
 		Vout uint   `json:"vout"`
 	}
+
+	CreateRawTransactionRequest struct {
+		Inputs  []RawTxInputs
+		Outputs []RawTxOutput
+	}
+
+	// RawTxOutput is one of the outputs of createrawtransaction, a payment of Amount to Address, a contract call or a
+	// contract creation
+	RawTxOutput struct {
+		Address string
+		Amount  decimal.Decimal
+
+		Call   *SendToContractRawRequest
+		Create *CreateContractRawRequest
+	}
 )
 
+func (r *CreateRawTransactionRequest) MarshalJSON() ([]byte, error) {
+	outputs := r.Outputs
+	if outputs == nil {
+		outputs = []RawTxOutput{}
+	}
+	inputs := r.Inputs
+	if inputs == nil {
+		inputs = []RawTxInputs{}
+	}
+	return json.Marshal([]interface{}{
+		inputs,
+		outputs,
+	})
+}
+
+func (o RawTxOutput) MarshalJSON() ([]byte, error) {
+	switch {
+	case o.Call != nil:
+		return json.Marshal(map[string]*SendToContractRawRequest{"contract": o.Call})
+	case o.Create != nil:
+		return json.Marshal(map[string]*CreateContractRawRequest{"contract": o.Create})
+	default:
+		return json.Marshal(map[string]decimal.Decimal{o.Address: o.Amount})
+	}
+}
+
 // ========SignRawTransactionWithKey=========//
 type (
 	/*
@@ -1485,9 +1596,12 @@ type (
 		  ]
 		}
 	*/
+	SignRawTxRequest [1]string
+
 	SignRawTxResponse struct {
-		Hex      string `json:"hex"`
-		Complete bool   `json:"complete"`
+		Hex      string         `json:"hex"`
+		Complete bool           `json:"complete"`
+		Errors   []SigningError `json:"errors,omitempty"`
 	}
 
 	SigningError struct {
@@ -1495,10 +1609,25 @@ type (
 		Vout      uint   `json:"vout"`
 		ScriptSig string `json:"scriptSig"`
 		Sequence  uint   `json:"sequence"`
-		Error     error  `json:"error"`
+		Error     string `json:"error"`
 	}
 )
 
+// Err describes why the transaction couldn't be signed, or is nil when it is
+func (r *SignRawTxResponse) Err() error {
+	if r.Complete {
+		return nil
+	}
+	if len(r.Errors) == 0 {
+		return errors.New("transaction incomplete")
+	}
+	messages := make([]string, 0, len(r.Errors))
+	for _, e := range r.Errors {
+		messages = append(messages, fmt.Sprintf("input %s:%d: %s", e.Txid, e.Vout, e.Error))
+	}
+	return errors.New("transaction incomplete: " + strings.Join(messages, "; "))
+}
+
 // ======== sendrawtransaction ========= //
 
 type (
@@ -1510,6 +1639,78 @@ type (
 	}
 )
 
+// ========== TestMempoolAccept ============= //
+type (
+	/*
+		Arguments:
+		1. rawtxs          (json array, required) An array of hex strings of raw transactions
+		Result:
+		[
+			{
+				"txid": "hex",          (string) The transaction hash in hex
+				"allowed": true|false,  (boolean) If the mempool allows this tx to be inserted
+				"vsize": n,             (numeric) Virtual transaction size
+				"fees": {
+					"base": n           (numeric) transaction fee in QTUM
+				},
+				"reject-reason": "str"  (string) Rejection string (only present when 'allowed' is false)
+			}
+		]
+	*/
+	TestMempoolAcceptRequest struct {
+		RawTransactions []string
+	}
+
+	TestMempoolAcceptResult struct {
+		TXID         string                 `json:"txid"`
+		Allowed      bool                   `json:"allowed"`
+		VSize        int64                  `json:"vsize,omitempty"`
+		Fees         *TestMempoolAcceptFees `json:"fees,omitempty"`
+		RejectReason string                 `json:"reject-reason,omitempty"`
+	}
+
+	TestMempoolAcceptFees struct {
+		Base decimal.Decimal `json:"base"`
+	}
+
+	TestMempoolAcceptResponse []TestMempoolAcceptResult
+)
+
+func (r *TestMempoolAcceptRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.RawTransactions})
+}
+
+// ========== EstimateSmartFee ============= //
+
+type (
+	/*
+		Arguments:
+		1. conf_target      (numeric, required) Confirmation target in blocks (1 - 1008)
+		2. estimate_mode    (string, optional, default=CONSERVATIVE) The fee estimate mode.
+
+		Result:
+		{
+		  "feerate" : x.x,     (numeric, optional) estimate fee rate in QTUM/kB
+		  "errors": [ str... ] (json array of strings, optional) Errors encountered during processing
+		  "blocks" : n         (numeric) block number where estimate was found
+		}
+	*/
+	EstimateSmartFeeRequest struct {
+		ConfTarget int64
+	}
+
+	EstimateSmartFeeResponse struct {
+		// nil when qtumd hasn't seen enough transactions to estimate, Errors says why
+		FeeRate *decimal.Decimal `json:"feerate,omitempty"`
+		Errors  []string         `json:"errors,omitempty"`
+		Blocks  int64            `json:"blocks"`
+	}
+)
+
+func (r *EstimateSmartFeeRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.ConfTarget})
+}
+
 func (r *SendRawTransactionResponse) UnmarshalJSON(data []byte) error {
 	var result string
 	err := json.Unmarshal(data, &result)
@@ -1770,6 +1971,111 @@ func (req *GetAddressBalanceRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(params)
 }
 
+// ======== getaddressdeltas ========= //
+type (
+
+	/*
+		Arguments:
+		1. (json object)
+			{
+				"addresses": [		(json array, required) The qtum addresses
+					"address",
+					...
+				],
+				"start": n,		(numeric, optional) The start block height
+				"end": n,		(numeric, optional) The end block height
+			}
+		Result:
+		[
+			{
+				"satoshis": n,		(numeric) The difference of satoshis
+				"txid": "hash",		(string) The related txid
+				"index": n,		(numeric) The related input or output index
+				"blockindex": n,	(numeric) The related block index
+				"height": n,		(numeric) The block height
+				"address": "str"	(string) The qtum address
+			}
+		]
+	*/
+	GetAddressDeltasRequest struct {
+		Addresses []string
+		Start     int64
+		End       int64
+	}
+
+	AddressDelta struct {
+		Satoshis   int64  `json:"satoshis"`
+		TXID       string `json:"txid"`
+		Index      uint   `json:"index"`
+		BlockIndex uint   `json:"blockindex"`
+		Height     int64  `json:"height"`
+		Address    string `json:"address"`
+	}
+
+	GetAddressDeltasResponse []AddressDelta
+)
+
+func (req *GetAddressDeltasRequest) MarshalJSON() ([]byte, error) {
+	params := map[string]interface{}{
+		"addresses": req.Addresses,
+	}
+	// qtumd requires both start and end or neither
+	if req.Start > 0 && req.End > 0 {
+		params["start"] = req.Start
+		params["end"] = req.End
+	}
+	return json.Marshal([]interface{}{params})
+}
+
+// ======== getaddressmempool ========= //
+type (
+
+	/*
+		Arguments:
+		1. (json object)
+			{
+				"addresses": [		(json array, required) The qtum addresses
+					"address",
+					...
+				]
+			}
+		Result:
+		[
+			{
+				"address": "str",	(string) The qtum address
+				"txid": "hash",		(string) The related txid
+				"index": n,		(numeric) The related input or output index
+				"satoshis": n,		(numeric) The difference of satoshis
+				"timestamp": n,		(numeric) The time the transaction entered the mempool (seconds)
+				"prevtxid": "hash",	(string) The previous txid (if spending)
+				"prevout": n		(numeric) The previous transaction output index (if spending)
+			}
+		]
+	*/
+	GetAddressMempoolRequest struct {
+		Addresses []string
+	}
+
+	AddressMempoolDelta struct {
+		Address   string `json:"address"`
+		TXID      string `json:"txid"`
+		Index     uint   `json:"index"`
+		Satoshis  int64  `json:"satoshis"`
+		Timestamp int64  `json:"timestamp"`
+		PrevTXID  string `json:"prevtxid,omitempty"`
+		PrevOut   uint   `json:"prevout,omitempty"`
+	}
+
+	GetAddressMempoolResponse []AddressMempoolDelta
+)
+
+func (req *GetAddressMempoolRequest) MarshalJSON() ([]byte, error) {
+	params := map[string]interface{}{
+		"addresses": req.Addresses,
+	}
+	return json.Marshal([]interface{}{params})
+}
+
 // ======== getpeerinfo ========= //
 type (
 	GetPeerInfoResponse struct {
@@ -2023,3 +2329,99 @@ type (
 		Wallets []ListWalletDirWallet `json:"wallets"`
 	}
 )
+
+// ======== listreceivedbyaddress ======== //
+type (
+	/*
+		Arguments:
+		1. minconf            (numeric, optional, default=1) The minimum number of confirmations before payments are included
+		2. include_empty      (boolean, optional, default=false) Whether to include addresses that haven't received any payments
+		3. include_watchonly  (boolean, optional, default=true for watch-only wallets, otherwise false) Whether to include watch-only addresses
+		Result:
+		[
+			{
+				"involvesWatchonly": true,  (boolean) Only returns true if imported addresses were involved in transaction
+				"address": "str",           (string) The receiving address
+				"amount": n,                (numeric) The total amount in QTUM received by the address
+				"confirmations": n,         (numeric) The number of confirmations of the most recent transaction included
+				"label": "str",             (string) The label of the receiving address
+				"txids": [ "hex", ... ]     (json array) The ids of transactions received with the address
+			}
+		]
+	*/
+	ListReceivedByAddressRequest struct {
+		MinimumConfirmations int64
+		IncludeEmpty         bool
+		IncludeWatchOnly     bool
+	}
+
+	ListReceivedByAddressResult struct {
+		InvolvesWatchOnly bool            `json:"involvesWatchonly,omitempty"`
+		Address           string          `json:"address"`
+		Amount            decimal.Decimal `json:"amount"`
+		Confirmations     int64           `json:"confirmations"`
+		Label             string          `json:"label"`
+		TxIDs             []string        `json:"txids"`
+	}
+
+	ListReceivedByAddressResponse []ListReceivedByAddressResult
+)
+
+func (r *ListReceivedByAddressRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.MinimumConfirmations, r.IncludeEmpty, r.IncludeWatchOnly})
+}
+
+// ======== importprivkey ======== //
+type (
+	/*
+		Arguments:
+		1. privkey  (string, required) The private key (see dumpprivkey)
+		2. label    (string, optional, default=current label if address exists, otherwise "") An optional label
+		3. rescan   (boolean, optional, default=true) Rescan the wallet for transactions
+	*/
+	ImportPrivKeyRequest struct {
+		PrivateKey string
+		Label      string
+		Rescan     bool
+	}
+)
+
+func (r *ImportPrivKeyRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.PrivateKey, r.Label, r.Rescan})
+}
+
+// ======== listcontracts ======== //
+type (
+	/*
+		Arguments:
+		1. start       (numeric, optional, default=1) The starting account index
+		2. maxDisplay  (numeric, optional, default=20) Max accounts to list
+		Result:
+		{
+			"address": balance,  (numeric) The balance of the contract in QTUM
+			...
+		}
+	*/
+	ListContractsRequest struct {
+		Start      int
+		MaxDisplay int
+	}
+
+	ListContractsResponse map[string]decimal.Decimal
+)
+
+func (r *ListContractsRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.Start, r.MaxDisplay})
+}
+
+// ======== getrawmempool ======== //
+type GetRawMempoolResponse []string
+
+// ======== getmempoolentry ======== //
+type GetMempoolEntryResponse struct {
+	Vsize  int64  `json:"vsize"`
+	Weight int64  `json:"weight"`
+	Time   int64  `json:"time"`
+	Height int64  `json:"height"`
+	Wtxid  string `json:"wtxid"`
+}
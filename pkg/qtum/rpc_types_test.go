@@ -204,3 +204,80 @@ func TestExtractContractInfo(t *testing.T) {
 		)
 	}
 }
+
+func TestCreateRawTransactionRequest(t *testing.T) {
+	expected := `[[{"txid":"aa","vout":1}],[{"contract":{"contractAddress":"bb","data":"cc","amount":"0","gasLimit":250000,"gasPrice":"0.0000004","senderaddress":"qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"}},{"qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW":"0.5"}]]`
+	request := &CreateRawTransactionRequest{
+		Inputs: []RawTxInputs{{TxID: "aa", Vout: 1}},
+		Outputs: []RawTxOutput{
+			{Call: &SendToContractRawRequest{
+				ContractAddress: "bb",
+				Datahex:         "cc",
+				Amount:          decimal.Zero,
+				GasLimit:        big.NewInt(250000),
+				GasPrice:        "0.0000004",
+				SenderAddress:   "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW",
+			}},
+			{Address: "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW", Amount: decimal.NewFromFloat(0.5)},
+		},
+	}
+
+	result, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != expected {
+		t.Errorf("error\nwant: %s\ngot: %s", expected, string(result))
+	}
+}
+
+func TestSignRawTxResponseErr(t *testing.T) {
+	var resp SignRawTxResponse
+	if err := json.Unmarshal([]byte(`{"hex":"00","complete":false,"errors":[{"txid":"aa","vout":1,"scriptSig":"","sequence":4294967295,"error":"Input not found or already spent"}]}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	err := resp.Err()
+	if err == nil || err.Error() != "transaction incomplete: input aa:1: Input not found or already spent" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	resp = SignRawTxResponse{Complete: true}
+	if err := resp.Err(); err != nil {
+		t.Errorf("expected no error for a complete transaction, got %v", err)
+	}
+}
+
+func TestDecodeRecentQtumdFields(t *testing.T) {
+	var receipts SearchLogsResponse
+	err := json.Unmarshal([]byte(`[{
+		"blockHash": "aa", "blockNumber": 10, "transactionHash": "bb", "transactionIndex": 2,
+		"from": "cc", "to": "dd", "cumulativeGasUsed": 100, "gasUsed": 100, "contractAddress": "dd",
+		"excepted": "Revert", "exceptedMessage": "not owner", "bloom": "00", "stateRoot": "ee", "utxoRoot": "ff",
+		"createdContracts": {"1111": "create"}, "log": []
+	}]`), &receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := receipts[0]
+	if receipt.ExceptedMessage != "not owner" || receipt.StateRoot != "ee" || receipt.UTXORoot != "ff" || receipt.CreatedContracts["1111"] != "create" {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+
+	var block GetBlockResponse
+	err = json.Unmarshal([]byte(`{"hash": "aa", "nTx": 3, "prevoutStakeHash": "bb", "prevoutStakeVoutN": 1, "proofOfDelegation": "cc"}`), &block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.NTx != 3 || block.PrevoutStakeHash != "bb" || block.PrevoutStakeVoutN != 1 || block.ProofOfDelegation != "cc" {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	var scriptPubKey DecodedRawTransactionScriptPubKey
+	err = json.Unmarshal([]byte(`{"asm": "", "hex": "", "address": "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW", "type": "pubkeyhash", "desc": "addr(qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW)#abc"}`), &scriptPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addresses := scriptPubKey.GetAddresses(); !reflect.DeepEqual(addresses, []string{"qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"}) {
+		t.Errorf("unexpected addresses %v", addresses)
+	}
+}
@@ -0,0 +1,82 @@
+package qtum
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// maxSentTransactions bounds the transactions remembered for an address, the oldest are forgotten first. qtumd keeps
+// far fewer unconfirmed descendants of a transaction in its mempool
+const maxSentTransactions = 1000
+
+// sentTransactions are the transactions sent through Janus by the hex address of their sender, until they are seen to
+// have left the mempool. eth_getTransactionCount counts them for the "pending" block tag, an address sending several
+// transactions in a row gets increasing nonces before they are mined
+type sentTransactions struct {
+	mutex     sync.Mutex
+	byAddress map[string][]string
+}
+
+func newSentTransactions() *sentTransactions {
+	return &sentTransactions{byAddress: make(map[string][]string)}
+}
+
+func sentTransactionKey(address string) string {
+	return strings.ToLower(utils.RemoveHexPrefix(address))
+}
+
+// TrackSentTransaction remembers that the hex address from sent txid
+func (q *Qtum) TrackSentTransaction(from string, txid string) {
+	if from == "" || txid == "" {
+		return
+	}
+	s := q.sent
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key := sentTransactionKey(from)
+	txids := append(s.byAddress[key], strings.ToLower(utils.RemoveHexPrefix(txid)))
+	if len(txids) > maxSentTransactions {
+		txids = txids[len(txids)-maxSentTransactions:]
+	}
+	s.byAddress[key] = txids
+}
+
+// SentTransactions returns the transactions sent through Janus by the hex address from that are still in mempool, the
+// txids of qtumd's mempool. The others are forgotten
+func (q *Qtum) SentTransactions(from string, mempool []string) []string {
+	s := q.sent
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key := sentTransactionKey(from)
+	if len(s.byAddress[key]) == 0 {
+		return nil
+	}
+
+	inMempool := make(map[string]bool, len(mempool))
+	for _, txid := range mempool {
+		inMempool[strings.ToLower(txid)] = true
+	}
+	var pending []string
+	for _, txid := range s.byAddress[key] {
+		if inMempool[txid] {
+			pending = append(pending, txid)
+		}
+	}
+	if len(pending) == 0 {
+		delete(s.byAddress, key)
+	} else {
+		s.byAddress[key] = pending
+	}
+	return pending
+}
+
+// HasSentTransactions tells whether Janus remembers transactions sent by the hex address from, the mempool only needs
+// to be looked at for those
+func (q *Qtum) HasSentTransactions(from string) bool {
+	s := q.sent
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.byAddress[sentTransactionKey(from)]) != 0
+}
@@ -0,0 +1,72 @@
+package qtum
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQtumStarting is the cause of StartingError
+var ErrQtumStarting = errors.New("qtumd is starting up")
+
+// StartingRetryAfter is how long clients are told to wait while qtumd is starting up
+const StartingRetryAfter = 10 * time.Second
+
+// warmupStatuses are the messages qtumd answers with while it loads, e.g. after a restart with -reindex
+var warmupStatuses = []string{
+	"Loading block index",
+	"Verifying blocks",
+	"Verifying wallet",
+	"Loading wallet",
+	"Rescanning",
+	"Activating best chain",
+	"Loading P2P addresses",
+	"Loading banlist",
+	"Pruning blockstore",
+	"Starting network threads",
+}
+
+// StartingError is returned while qtumd warms up, it answers normally once it has loaded
+type StartingError struct {
+	// what qtumd reports doing, like "Loading block index..."
+	Status string
+}
+
+func (e *StartingError) Error() string {
+	return ErrQtumStarting.Error() + ": " + e.Status
+}
+
+func (e *StartingError) Cause() error {
+	return ErrQtumStarting
+}
+
+// startingError recognizes the errors qtumd answers with while it warms up
+func startingError(err *JSONRPCError) *StartingError {
+	if err == nil {
+		return nil
+	}
+	if err.Code == errorToCodeMap[ErrInWarmup] {
+		return &StartingError{Status: err.Message}
+	}
+	for _, status := range warmupStatuses {
+		if strings.HasPrefix(err.Message, status) {
+			return &StartingError{Status: err.Message}
+		}
+	}
+	return nil
+}
+
+func (c *Client) setStarting(status string) {
+	c.startingMutex.Lock()
+	defer c.startingMutex.Unlock()
+	c.startingStatus = status
+}
+
+// Starting reports what qtumd was doing when it last answered that it is starting up, until it answers a request
+// normally again
+func (c *Client) Starting() (status string, starting bool) {
+	c.startingMutex.RLock()
+	defer c.startingMutex.RUnlock()
+	return c.startingStatus, c.startingStatus != ""
+}
@@ -0,0 +1,65 @@
+package qtum
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Stats counts what the client did since it started, for monitoring
+type Stats struct {
+	// calls sent to qtumd by method, retries included and cached responses left out
+	Calls map[string]uint64
+	// responses of cachable methods served from the cache or fetched from qtumd
+	CacheHits   uint64
+	CacheMisses uint64
+	// calls sent again after backing off
+	Retries uint64
+	// responses refused with a ResponseMismatchError
+	ResponseMismatches uint64
+}
+
+type clientStats struct {
+	mutex       sync.Mutex
+	calls       map[string]uint64
+	cacheHits   uint64
+	cacheMisses uint64
+	retries     uint64
+}
+
+func (s *clientStats) call(method string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.calls == nil {
+		s.calls = make(map[string]uint64)
+	}
+	s.calls[method]++
+}
+
+func (s *clientStats) cache(hit bool) {
+	if hit {
+		atomic.AddUint64(&s.cacheHits, 1)
+	} else {
+		atomic.AddUint64(&s.cacheMisses, 1)
+	}
+}
+
+func (s *clientStats) retry() {
+	atomic.AddUint64(&s.retries, 1)
+}
+
+// Stats returns a copy of the client's counters
+func (c *Client) Stats() Stats {
+	c.stats.mutex.Lock()
+	calls := make(map[string]uint64, len(c.stats.calls))
+	for method, count := range c.stats.calls {
+		calls[method] = count
+	}
+	c.stats.mutex.Unlock()
+	return Stats{
+		Calls:              calls,
+		CacheHits:          atomic.LoadUint64(&c.stats.cacheHits),
+		CacheMisses:        atomic.LoadUint64(&c.stats.cacheMisses),
+		Retries:            atomic.LoadUint64(&c.stats.retries),
+		ResponseMismatches: c.ResponseMismatches(),
+	}
+}
@@ -0,0 +1,128 @@
+package qtum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type timingsKey struct{}
+
+// Timings records the Qtum RPC calls and the time spent handling an ETH request, for diagnosing slow queries
+type Timings struct {
+	mutex  sync.Mutex
+	start  time.Time
+	calls  []timedCall
+	phases []timedPhase
+}
+
+type timedCall struct {
+	method   string
+	duration time.Duration
+	cached   bool
+}
+
+type timedPhase struct {
+	name     string
+	duration time.Duration
+}
+
+func NewTimings() *Timings {
+	return &Timings{start: time.Now()}
+}
+
+// WithTimings makes requests to Qtum made with the returned context record their calls in t
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// TimingsFromContext returns nil when the request isn't timed
+func TimingsFromContext(ctx context.Context) *Timings {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(timingsKey{}).(*Timings)
+	return t
+}
+
+func (t *Timings) recordCall(method string, duration time.Duration, cached bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.calls = append(t.calls, timedCall{method: method, duration: duration, cached: cached})
+}
+
+// Phase records the time spent in a phase of handling the request
+func (t *Timings) Phase(name string, duration time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.phases = append(t.phases, timedPhase{name: name, duration: duration})
+}
+
+type timingsSummary struct {
+	Total         float64            `json:"totalMs"`
+	UpstreamCalls int                `json:"upstreamCalls"`
+	CacheHits     int                `json:"cacheHits"`
+	Upstream      float64            `json:"upstreamMs"`
+	Phases        map[string]float64 `json:"phases"`
+	Calls         []timedCallSummary `json:"calls"`
+}
+
+type timedCallSummary struct {
+	Method   string  `json:"method"`
+	Duration float64 `json:"ms"`
+	Cached   bool    `json:"cached,omitempty"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (t *Timings) summary() timingsSummary {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	summary := timingsSummary{
+		Total:  milliseconds(time.Since(t.start)),
+		Phases: make(map[string]float64, len(t.phases)),
+		Calls:  make([]timedCallSummary, 0, len(t.calls)),
+	}
+	var upstream time.Duration
+	for _, call := range t.calls {
+		if call.cached {
+			summary.CacheHits++
+		} else {
+			summary.UpstreamCalls++
+			upstream += call.duration
+		}
+		summary.Calls = append(summary.Calls, timedCallSummary{Method: call.method, Duration: milliseconds(call.duration), Cached: call.cached})
+	}
+	summary.Upstream = milliseconds(upstream)
+	for _, phase := range t.phases {
+		summary.Phases[phase.name] += milliseconds(phase.duration)
+	}
+	return summary
+}
+
+func (t *Timings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.summary())
+}
+
+// Header is a compact form of the timings for a response header, without the individual calls
+func (t *Timings) Header() string {
+	summary := t.summary()
+	parts := []string{
+		fmt.Sprintf("total=%.3fms", summary.Total),
+		fmt.Sprintf("upstream=%d", summary.UpstreamCalls),
+		fmt.Sprintf("cache=%d", summary.CacheHits),
+		fmt.Sprintf("upstream-time=%.3fms", summary.Upstream),
+	}
+	t.mutex.Lock()
+	for _, phase := range t.phases {
+		parts = append(parts, fmt.Sprintf("%s=%.3fms", phase.name, milliseconds(phase.duration)))
+	}
+	t.mutex.Unlock()
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,78 @@
+package qtum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// blocks back from the tip searched for a transaction when qtumd has no -txindex and the log index doesn't know it
+const defaultTxLookupBlocks = 20
+
+// qtumd started without -txindex only finds transactions in the mempool or in a block it's told about, and says so
+// with "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries"
+const noTxIndexMessage = "-txindex"
+
+// TxIndexError is returned when getrawtransaction fails because qtumd has no -txindex, it is an ErrInvalidAddress like
+// the error for unknown transactions
+type TxIndexError struct {
+	// what qtumd answered
+	Message string
+}
+
+func (e *TxIndexError) Error() string {
+	return ErrInvalidAddress.Error() + ": " + e.Message
+}
+
+func (e *TxIndexError) Cause() error {
+	return ErrInvalidAddress
+}
+
+// txIndexError recognizes qtumd's answer to getrawtransaction without a block hash when it has no -txindex
+func txIndexError(method string, err *JSONRPCError) *TxIndexError {
+	if err == nil || method != MethodGetRawTransaction || !strings.Contains(err.Message, noTxIndexMessage) {
+		return nil
+	}
+	return &TxIndexError{Message: err.Message}
+}
+
+// transactionBlockHash finds the block of a mined transaction for qtumd without -txindex, first in the log index and
+// then in the latest blocks. It is empty when the transaction isn't found
+func (m *Method) transactionBlockHash(ctx context.Context, txID string) (string, error) {
+	if m.logIndex != nil {
+		blockHash, err := m.logIndex.TransactionBlockHash(ctx, txID)
+		if err != nil {
+			m.GetErrorLogger().Log("msg", "couldn't look up transaction in the log index", "txid", txID, "err", err)
+		} else if blockHash != "" {
+			return blockHash, nil
+		}
+	}
+
+	blocks := defaultTxLookupBlocks
+	if configured := m.GetFlagInt(FLAG_TX_LOOKUP_BLOCKS); configured != nil {
+		blocks = *configured
+	}
+	tip, err := m.GetBlockCount(ctx)
+	if err != nil {
+		return "", errors.WithMessage(err, "couldn't get block count")
+	}
+	txID = strings.ToLower(txID)
+	for height := tip.Int64(); height >= 0 && height > tip.Int64()-int64(blocks); height-- {
+		blockHash, err := m.GetBlockHash(ctx, big.NewInt(height))
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't get hash of block %d", height)
+		}
+		block, err := m.GetBlock(ctx, string(blockHash))
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't get block %d", height)
+		}
+		for _, blockTx := range block.Txs {
+			if blockTx == txID {
+				return string(blockHash), nil
+			}
+		}
+	}
+	return "", nil
+}
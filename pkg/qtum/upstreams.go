@@ -0,0 +1,302 @@
+package qtum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// how calls are spread over the qtumd nodes given with SetUpstreams
+const (
+	// always use the first healthy node, the others only take over when it fails
+	BalanceFailover = "failover"
+	// take turns between the healthy nodes
+	BalanceRoundRobin = "round-robin"
+	// use the healthy node that answered fastest lately
+	BalanceLeastLatency = "least-latency"
+)
+
+// unhealthy nodes are checked with getblockcount this often, and get calls again once they answer
+const upstreamCheckInterval = 5 * time.Second
+
+// weight of the latest call in a node's average latency
+const upstreamLatencyWeight = 0.2
+
+// readOnlyMethods only read the chain, so any node serving it answers them the same and they can be balanced and fail
+// over. Every other call, like the wallet's sends and signatures, goes to the first node only: the other nodes have
+// other wallets, and sending a transaction again on another node after a failure that may have come after qtumd took
+// it could send it twice
+var readOnlyMethods = map[string]bool{
+	MethodGetHexAddress:         true,
+	MethodFromHexAddress:        true,
+	MethodGetTransactionReceipt: true,
+	MethodGetPeerInfo:           true,
+	MethodGetNetworkInfo:        true,
+	MethodGetRawTransaction:     true,
+	MethodCallContract:          true,
+	MethodDecodeRawTransaction:  true,
+	MethodGetTransactionOut:     true,
+	MethodGetBlockCount:         true,
+	MethodGetBlockChainInfo:     true,
+	MethodSearchLogs:            true,
+	MethodWaitForLogs:           true,
+	MethodGetBlockHash:          true,
+	MethodGetBlockHeader:        true,
+	MethodGetBlock:              true,
+	MethodGetAccountInfo:        true,
+	MethodGetStorage:            true,
+	MethodTestMempoolAccept:     true,
+	MethodEstimateSmartFee:      true,
+	MethodGetAddressBalance:     true,
+	MethodGetAddressUTXOs:       true,
+	MethodGetAddressDeltas:      true,
+	MethodGetAddressMempool:     true,
+	MethodGetRawMempool:         true,
+	MethodGetMempoolEntry:       true,
+	MethodListContracts:         true,
+}
+
+// isReadOnly tells whether every one of methods can be balanced over the nodes
+func isReadOnly(methods ...string) bool {
+	for _, method := range methods {
+		if !readOnlyMethods[method] {
+			return false
+		}
+	}
+	return true
+}
+
+// UpstreamStatus describes a qtumd node to operators
+type UpstreamStatus struct {
+	URL       string  `json:"url"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latencyMs"`
+	Failures  uint64  `json:"failures"`
+	LastError string  `json:"lastError,omitempty"`
+}
+
+type upstream struct {
+	url string
+	// redacted for logs
+	name string
+
+	healthy   bool
+	latency   time.Duration
+	failures  uint64
+	lastError string
+}
+
+// upstreams are the qtumd nodes calls are balanced over. A node failing a call with a connection error or a
+// temporary HTTP status is taken out until it answers a health check, and the call goes to the next node
+type upstreams struct {
+	balancing string
+	next      uint32
+
+	mutex sync.RWMutex
+	nodes []*upstream
+}
+
+func newUpstreams(urls []string) (*upstreams, error) {
+	u := &upstreams{balancing: BalanceFailover}
+	for _, rawURL := range urls {
+		if err := checkRPCURL(rawURL); err != nil {
+			return nil, err
+		}
+		parsed, _ := url.Parse(rawURL)
+		u.nodes = append(u.nodes, &upstream{url: rawURL, name: redactURL(parsed), healthy: true})
+	}
+	return u, nil
+}
+
+// order lists the nodes to try a call on, the healthy ones as the balancing picks them and then the unhealthy ones
+// as a last resort
+func (u *upstreams) order() []*upstream {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	var healthy, unhealthy []*upstream
+	for _, node := range u.nodes {
+		if node.healthy {
+			healthy = append(healthy, node)
+		} else {
+			unhealthy = append(unhealthy, node)
+		}
+	}
+	switch u.balancing {
+	case BalanceRoundRobin:
+		if len(healthy) > 1 {
+			start := int(atomic.AddUint32(&u.next, 1)-1) % len(healthy)
+			healthy = append(healthy[start:], healthy[:start]...)
+		}
+	case BalanceLeastLatency:
+		sort.SliceStable(healthy, func(i, j int) bool { return healthy[i].latency < healthy[j].latency })
+	}
+	return append(healthy, unhealthy...)
+}
+
+// primary lists the first node alone, the one the client was created with
+func (u *upstreams) primary() []*upstream {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	return u.nodes[:1]
+}
+
+func (u *upstreams) succeeded(node *upstream, latency time.Duration) (recovered bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if node.latency == 0 {
+		node.latency = latency
+	} else {
+		node.latency = time.Duration(upstreamLatencyWeight*float64(latency) + (1-upstreamLatencyWeight)*float64(node.latency))
+	}
+	recovered = !node.healthy
+	node.healthy = true
+	return recovered
+}
+
+func (u *upstreams) failed(node *upstream, err error) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	node.healthy = false
+	node.failures++
+	node.lastError = err.Error()
+}
+
+func (u *upstreams) unhealthy() []*upstream {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	var unhealthy []*upstream
+	for _, node := range u.nodes {
+		if !node.healthy {
+			unhealthy = append(unhealthy, node)
+		}
+	}
+	return unhealthy
+}
+
+func (u *upstreams) statuses() []UpstreamStatus {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	statuses := make([]UpstreamStatus, 0, len(u.nodes))
+	for _, node := range u.nodes {
+		statuses = append(statuses, UpstreamStatus{
+			URL:       node.name,
+			Healthy:   node.healthy,
+			LatencyMs: float64(node.latency.Microseconds()) / 1000,
+			Failures:  node.failures,
+			LastError: node.lastError,
+		})
+	}
+	return statuses
+}
+
+// isUpstreamFailure tells the failures another node may not have, qtumd's own errors would be the same everywhere
+func isUpstreamFailure(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return true
+}
+
+// doUpstreams sends body to the nodes in the balancing's order until one of them answers, or only to the first node
+// unless it calls read only methods
+func (c *Client) doUpstreams(ctx context.Context, body []byte, readOnly bool) ([]byte, error) {
+	nodes := c.upstreams.order()
+	if !readOnly {
+		nodes = c.upstreams.primary()
+	}
+	var err error
+	for _, node := range nodes {
+		start := time.Now()
+		var response []byte
+		response, err = c.doURL(ctx, node.url, bytes.NewReader(body))
+		if err == nil {
+			if c.upstreams.succeeded(node, time.Since(start)) {
+				level.Info(c.GetLogger()).Log("msg", "qtumd node is back", "upstream", node.name)
+			}
+			return response, nil
+		}
+		if (ctx != nil && ctx.Err() != nil) || !isUpstreamFailure(err) {
+			return nil, err
+		}
+		c.upstreams.failed(node, err)
+		level.Warn(c.GetLogger()).Log("msg", "qtumd node failed, trying the next one", "upstream", node.name, "err", err)
+	}
+	return nil, err
+}
+
+// checkUpstreams brings unhealthy nodes back once they answer getblockcount
+func (c *Client) checkUpstreams(ctx context.Context) {
+	ticker := time.NewTicker(upstreamCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, node := range c.upstreams.unhealthy() {
+			req, err := c.NewRPCRequest(MethodGetBlockCount, nil)
+			if err != nil {
+				continue
+			}
+			body, _ := json.Marshal(req)
+			start := time.Now()
+			checkCtx, cancel := context.WithTimeout(ctx, upstreamCheckInterval)
+			_, err = c.doURL(checkCtx, node.url, bytes.NewReader(body))
+			cancel()
+			if err == nil && c.upstreams.succeeded(node, time.Since(start)) {
+				level.Info(c.GetLogger()).Log("msg", "qtumd node is back", "upstream", node.name)
+			}
+		}
+	}
+}
+
+// Upstreams describes the qtumd nodes given with SetUpstreams, nil when there's only one
+func (c *Client) Upstreams() []UpstreamStatus {
+	if c.upstreams == nil {
+		return nil
+	}
+	return c.upstreams.statuses()
+}
+
+// SetUpstreams sends calls to the qtumd nodes at urls besides the one the client was created with, spread over them
+// as SetUpstreamBalancing says. Nodes failing calls with connection errors or temporary HTTP statuses are skipped
+// until they answer again, the call goes to the next node right away
+func SetUpstreams(urls []string) func(*Client) error {
+	return func(c *Client) error {
+		if len(urls) == 0 {
+			c.upstreams = nil
+			return nil
+		}
+		upstreams, err := newUpstreams(append([]string{c.URL}, urls...))
+		if err != nil {
+			return err
+		}
+		c.upstreams = upstreams
+		return nil
+	}
+}
+
+// SetUpstreamBalancing picks how calls are spread over the nodes of SetUpstreams, BalanceFailover by default
+func SetUpstreamBalancing(balancing string) func(*Client) error {
+	return func(c *Client) error {
+		switch balancing {
+		case "":
+			balancing = BalanceFailover
+		case BalanceFailover, BalanceRoundRobin, BalanceLeastLatency:
+		default:
+			return errors.Errorf("unknown upstream balancing %q, expected %s, %s or %s", balancing, BalanceFailover, BalanceRoundRobin, BalanceLeastLatency)
+		}
+		c.upstreamBalancing = balancing
+		return nil
+	}
+}
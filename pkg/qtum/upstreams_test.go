@@ -0,0 +1,140 @@
+package qtum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// hostDoer answers calls to each host with its status, the result 1 when it is 200
+type hostDoer struct {
+	statuses map[string]int
+	calls    []string
+}
+
+func (d *hostDoer) Do(request *http.Request) (*http.Response, error) {
+	var req JSONRPCRequest
+	json.NewDecoder(request.Body).Decode(&req)
+	d.calls = append(d.calls, request.URL.Host)
+	status := d.statuses[request.URL.Host]
+	body := `{"result":1,"error":null,"id":` + string(req.ID) + `}`
+	if status != http.StatusOK {
+		body = http.StatusText(status)
+	}
+	return &http.Response{StatusCode: status, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+}
+
+func TestUpstreamFailover(t *testing.T) {
+	doer := &hostDoer{statuses: map[string]int{"a": http.StatusServiceUnavailable, "b": http.StatusOK}}
+	client, err := NewClient(false, "http://user:pass@a", SetDoer(doer), SetContext(context.Background()), SetUpstreams([]string{"http://user:pass@b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetErrorHandler(func(context.Context, error) error { return nil })
+
+	var count int
+	if err := client.Request(MethodGetBlockCount, nil, &count); err != nil || count != 1 {
+		t.Fatalf("expected the call to fail over, got %d, %v", count, err)
+	}
+	if err := client.Request(MethodGetBlockCount, nil, &count); err != nil {
+		t.Fatal(err)
+	}
+	if len(doer.calls) != 3 || doer.calls[2] != "b" {
+		t.Errorf("expected the failed node to be skipped, got calls to %v", doer.calls)
+	}
+	if upstreams := client.Upstreams(); upstreams[0].Healthy || upstreams[0].Failures != 1 || !upstreams[1].Healthy {
+		t.Errorf("unexpected upstreams %+v", upstreams)
+	}
+
+	// refused credentials aren't a failure of the node
+	doer.statuses["b"] = http.StatusUnauthorized
+	doer.calls = nil
+	if err := client.Request(MethodGetBlockCount, nil, &count); errors.Cause(err) != ErrUnauthorized {
+		t.Errorf("expected refused credentials, got %v", err)
+	}
+	if len(doer.calls) != 1 {
+		t.Errorf("expected no failover, got calls to %v", doer.calls)
+	}
+}
+
+func TestUpstreamWalletCallsStayOnPrimary(t *testing.T) {
+	doer := &hostDoer{statuses: map[string]int{"a": http.StatusOK, "b": http.StatusOK}}
+	client, err := NewClient(false, "http://user:pass@a", SetDoer(doer), SetUpstreams([]string{"http://user:pass@b"}), SetUpstreamBalancing(BalanceRoundRobin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetErrorHandler(func(context.Context, error) error { return nil })
+
+	var result int
+	for i := 0; i < 3; i++ {
+		if err := client.Request(MethodSendToAddress, nil, &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(doer.calls) != 3 || doer.calls[0] != "a" || doer.calls[1] != "a" || doer.calls[2] != "a" {
+		t.Errorf("expected wallet calls not to be balanced, got calls to %v", doer.calls)
+	}
+
+	doer.statuses["a"] = http.StatusServiceUnavailable
+	doer.calls = nil
+	// the client retries temporary failures until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.RequestWithContext(ctx, MethodSendRawTx, nil, &result); err == nil {
+		t.Error("expected a failed send not to be sent again on another node")
+	}
+	if err := client.RequestBatch(ctx, []*BatchCall{{Method: MethodGetBlockCount, Result: &result}, {Method: MethodSendToContract, Result: &result}}); err == nil {
+		t.Error("expected a batch with a wallet call to stay on the failed node")
+	}
+	for _, host := range doer.calls {
+		if host != "a" {
+			t.Fatalf("expected no failover, got calls to %v", doer.calls)
+		}
+	}
+	if err := client.RequestWithContext(context.Background(), MethodGetBlockCount, nil, &result); err != nil {
+		t.Errorf("expected read only calls to fail over, got %v", err)
+	}
+}
+
+func TestUpstreamBalancing(t *testing.T) {
+	doer := &hostDoer{statuses: map[string]int{"a": http.StatusOK, "b": http.StatusOK}}
+	client, err := NewClient(false, "http://user:pass@a", SetDoer(doer), SetUpstreams([]string{"http://user:pass@b"}), SetUpstreamBalancing(BalanceRoundRobin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for i := 0; i < 4; i++ {
+		if err := client.Request(MethodGetBlockCount, nil, &count); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(doer.calls) != 4 || doer.calls[0] == doer.calls[1] || doer.calls[0] != doer.calls[2] {
+		t.Errorf("expected calls to take turns, got %v", doer.calls)
+	}
+
+	upstreams, err := newUpstreams([]string{"http://user:pass@a", "http://user:pass@b", "http://user:pass@c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreams.balancing = BalanceLeastLatency
+	upstreams.succeeded(upstreams.nodes[0], 30*time.Millisecond)
+	upstreams.succeeded(upstreams.nodes[1], 10*time.Millisecond)
+	upstreams.succeeded(upstreams.nodes[2], 20*time.Millisecond)
+	upstreams.failed(upstreams.nodes[1], errors.New("connection refused"))
+	if order := upstreams.order(); order[0].url != "http://user:pass@c" || order[1].url != "http://user:pass@a" || order[2].url != "http://user:pass@b" {
+		t.Errorf("expected the fastest healthy node first and the unhealthy one last, got %s, %s, %s", order[0].url, order[1].url, order[2].url)
+	}
+
+	if _, err := NewClient(false, "http://user:pass@a", SetUpstreamBalancing("random")); err == nil {
+		t.Error("expected an unknown balancing to be refused")
+	}
+	if _, err := NewClient(false, "http://user:pass@a", SetUpstreams([]string{"http://b"})); err == nil {
+		t.Error("expected a node without credentials to be refused")
+	}
+}
@@ -0,0 +1,238 @@
+package qtum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NodeVersion is the version qtumd reports in getnetworkinfo, 220100 for 22.1.0 and 200300 for 0.20.3
+type NodeVersion int64
+
+// NodeVersionUnknown is the version until qtumd has answered getnetworkinfo
+const NodeVersionUnknown NodeVersion = 0
+
+func (v NodeVersion) String() string {
+	if v == NodeVersionUnknown {
+		return "unknown"
+	}
+	major, minor, patch := int64(v)/10000, int64(v)/100%100, int64(v)%100
+	if major < 22 {
+		// releases before 22 were numbered 0.major.minor
+		return fmt.Sprintf("0.%d.%d", major, minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch)
+}
+
+// how long the version isn't asked again after getnetworkinfo failed
+var nodeVersionRetryInterval = 30 * time.Second
+
+// responseMigration rewrites the result of a method from the schema some qtumd versions answer with into the one the
+// types of this package decode. Migrations check the shape of what they rewrite, results already in the expected schema
+// are left as they are
+type responseMigration struct {
+	// versions answering with the other schema, [since, until) with NodeVersionUnknown leaving a side open
+	since, until NodeVersion
+	description  string
+	migrate      func(result map[string]json.RawMessage) error
+	// the result is a list of objects that are each migrated
+	list bool
+}
+
+func (m *responseMigration) appliesTo(version NodeVersion) bool {
+	return (m.since == NodeVersionUnknown || version >= m.since) && (m.until == NodeVersionUnknown || version < m.until)
+}
+
+// responseMigrations are the schema changes between qtumd versions, by method. Fields that were only added or removed
+// need none, unknown fields are ignored and missing ones left empty
+var responseMigrations = map[string][]*responseMigration{
+	MethodGetBlockChainInfo: {
+		{until: 190000, description: "softforks was a list of the buried deployments", migrate: migrateSoftforksList},
+	},
+	MethodGetNetworkInfo: {
+		{since: 280000, description: "warnings became a list", migrate: migrateWarningsList},
+	},
+	MethodGetPeerInfo: {
+		{since: 210000, description: "addnode and whitelisted were replaced by connection_type and permissions", migrate: migratePeerConnectionType, list: true},
+	},
+}
+
+// NodeVersion returns the version of qtumd, NodeVersionUnknown until it answered getnetworkinfo
+func (c *Client) NodeVersion() NodeVersion {
+	return NodeVersion(atomic.LoadInt64(&c.nodeVersion))
+}
+
+// observeNodeVersion keeps the version of any getnetworkinfo response, qtumd may have been upgraded since it was last
+// asked
+func (c *Client) observeNodeVersion(result json.RawMessage) {
+	var info struct {
+		Version int64 `json:"version"`
+	}
+	if json.Unmarshal(result, &info) != nil || info.Version <= 0 {
+		return
+	}
+	if previous := atomic.SwapInt64(&c.nodeVersion, info.Version); previous != info.Version {
+		c.GetLogger().Log("msg", "Detected qtumd version", "version", NodeVersion(info.Version).String())
+	}
+}
+
+// detectNodeVersion asks qtumd for its version if it isn't known yet, it isn't asked again for a while if that fails
+func (c *Client) detectNodeVersion(ctx context.Context) NodeVersion {
+	if version := c.NodeVersion(); version != NodeVersionUnknown {
+		return version
+	}
+	c.nodeVersionMutex.Lock()
+	defer c.nodeVersionMutex.Unlock()
+	if version := c.NodeVersion(); version != NodeVersionUnknown || time.Now().Before(c.nodeVersionRetryAt) {
+		return version
+	}
+
+	var info json.RawMessage
+	if err := c.RequestWithContext(ctx, MethodGetNetworkInfo, []string{}, &info); err != nil {
+		c.GetDebugLogger().Log("msg", "Couldn't detect qtumd version, decoding responses as they come", "error", err)
+		c.nodeVersionRetryAt = time.Now().Add(nodeVersionRetryInterval)
+		return NodeVersionUnknown
+	}
+	return c.NodeVersion()
+}
+
+// decodeResult unmarshals the result of method into result, after migrating it from the schema of the qtumd version
+// answering. When the version isn't known the result is decoded as it is, and only migrated if that fails
+func (c *Client) decodeResult(ctx context.Context, method string, raw json.RawMessage, result interface{}) error {
+	if method == MethodGetNetworkInfo {
+		c.observeNodeVersion(raw)
+	}
+	migrations := responseMigrations[method]
+	if len(migrations) == 0 {
+		return json.Unmarshal(raw, result)
+	}
+
+	version := c.NodeVersion()
+	if version == NodeVersionUnknown && method != MethodGetNetworkInfo {
+		version = c.detectNodeVersion(ctx)
+	}
+	if version != NodeVersionUnknown {
+		migrated, err := migrateResult(raw, migrations, version)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't migrate %s response of qtumd %s", method, version)
+		}
+		return json.Unmarshal(migrated, result)
+	}
+
+	err := json.Unmarshal(raw, result)
+	if _, ok := err.(*json.UnmarshalTypeError); !ok {
+		return err
+	}
+	migrated, migrateErr := migrateResult(raw, migrations, NodeVersionUnknown)
+	if migrateErr != nil {
+		return err
+	}
+	if json.Unmarshal(migrated, result) != nil {
+		return err
+	}
+	return nil
+}
+
+// migrateResult applies the migrations of version to a result, or all of them for NodeVersionUnknown
+func migrateResult(raw json.RawMessage, migrations []*responseMigration, version NodeVersion) (json.RawMessage, error) {
+	migrated := raw
+	for _, migration := range migrations {
+		if version != NodeVersionUnknown && !migration.appliesTo(version) {
+			continue
+		}
+		var err error
+		if migrated, err = migration.apply(migrated); err != nil {
+			return nil, errors.Wrap(err, migration.description)
+		}
+	}
+	return migrated, nil
+}
+
+func (m *responseMigration) apply(raw json.RawMessage) (json.RawMessage, error) {
+	if !m.list {
+		var result map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &result); err != nil || result == nil {
+			// not an object, like null for a missing block
+			return raw, nil
+		}
+		if err := m.migrate(result); err != nil {
+			return nil, err
+		}
+		return json.Marshal(result)
+	}
+
+	var results []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return raw, nil
+	}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if err := m.migrate(result); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(results)
+}
+
+// migrateSoftforksList turns the softforks list of qtumd before 0.19, [{"id": "bip34", "reject": {"status": true}}],
+// into the map by name of later versions
+func migrateSoftforksList(result map[string]json.RawMessage) error {
+	var softforks []struct {
+		ID     string `json:"id"`
+		Reject struct {
+			Status bool `json:"status"`
+		} `json:"reject"`
+	}
+	if json.Unmarshal(result["softforks"], &softforks) != nil {
+		return nil
+	}
+	byName := make(map[string]interface{}, len(softforks))
+	for _, softfork := range softforks {
+		byName[softfork.ID] = map[string]interface{}{"type": "buried", "active": softfork.Reject.Status}
+	}
+	migrated, err := json.Marshal(byName)
+	if err != nil {
+		return err
+	}
+	result["softforks"] = migrated
+	return nil
+}
+
+// migrateWarningsList joins the warnings list of qtumd 28 and later into the single string of earlier versions
+func migrateWarningsList(result map[string]json.RawMessage) error {
+	var warnings []string
+	if json.Unmarshal(result["warnings"], &warnings) != nil {
+		return nil
+	}
+	joined, err := json.Marshal(strings.Join(warnings, " "))
+	if err != nil {
+		return err
+	}
+	result["warnings"] = joined
+	return nil
+}
+
+// migratePeerConnectionType sets addnode and whitelisted of a peer from connection_type and permissions, which replaced
+// them in qtumd 0.21
+func migratePeerConnectionType(result map[string]json.RawMessage) error {
+	if _, ok := result["addnode"]; !ok {
+		var connectionType string
+		if json.Unmarshal(result["connection_type"], &connectionType) == nil {
+			result["addnode"] = json.RawMessage(fmt.Sprint(connectionType == "manual"))
+		}
+	}
+	if _, ok := result["whitelisted"]; !ok {
+		var permissions []string
+		if json.Unmarshal(result["permissions"], &permissions) == nil {
+			result["whitelisted"] = json.RawMessage(fmt.Sprint(len(permissions) > 0))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package qtum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultWalletUnlockTimeout is how long qtumd keeps its wallet unlocked after Janus unlocks it
+const DefaultWalletUnlockTimeout = 60 * time.Second
+
+// PassphraseSource returns the passphrase of qtumd's wallet, it's looked up on every unlock so rotated secrets are
+// picked up
+type PassphraseSource func(ctx context.Context) (string, error)
+
+// unlockWallet unlocks qtumd's wallet with walletpassphrase, unless another request did since failedAt
+func (c *Client) unlockWallet(ctx context.Context, failedAt time.Time) error {
+	c.walletUnlockMutex.Lock()
+	defer c.walletUnlockMutex.Unlock()
+	if c.walletUnlockedAt.After(failedAt) {
+		return nil
+	}
+
+	passphrase, err := c.walletPassphrase(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "couldn't get the wallet passphrase")
+	}
+	req, err := c.NewRPCRequest(MethodWalletPassphrase, []interface{}{passphrase, int64(c.walletUnlockTimeout / time.Second)})
+	if err != nil {
+		return err
+	}
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	// not through Do, which logs request bodies in debug mode
+	respBody, err := c.do(ctx, bytes.NewReader(reqBody), req.Method)
+	if err != nil {
+		return errors.Wrap(err, "Client#do")
+	}
+	if _, err := c.responseBodyToResult(req, respBody); err != nil {
+		return err
+	}
+	c.walletUnlockedAt = time.Now()
+	c.GetLogger().Log("msg", "Unlocked the wallet", "timeout", c.walletUnlockTimeout)
+	return nil
+}
+
+// SetWalletPassphrase unlocks qtumd's wallet for timeout with the passphrase from source whenever a request fails
+// because it is locked, then retries the request. A nil source leaves the wallet to operators
+func SetWalletPassphrase(source PassphraseSource, timeout time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if source != nil && timeout < time.Second {
+			return errors.Errorf("wallet unlock timeout must be at least a second, got %s", timeout)
+		}
+		c.walletPassphrase = source
+		c.walletUnlockTimeout = timeout
+		return nil
+	}
+}
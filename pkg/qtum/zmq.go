@@ -0,0 +1,502 @@
+package qtum
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// qtumd's ZMQ notifications, enabled with -zmqpubhashblock and -zmqpubrawtx
+const (
+	ZMQTopicHashBlock = "hashblock"
+	ZMQTopicRawTx     = "rawtx"
+)
+
+// ZMQPollInterval is how often Janus still polls qtumd while blocks are announced over ZMQ, to catch up on
+// notifications lost when the connection drops
+const ZMQPollInterval = time.Minute
+
+// how long to wait before connecting again to a ZMQ publisher that went away, doubling while it can't be reached
+const zmqMinReconnectDelay = time.Second
+const zmqMaxReconnectDelay = 30 * time.Second
+
+// largest frame accepted from qtumd, above the largest transaction that fits in a block
+const zmqMaxFrameSize = 32 * 1024 * 1024
+
+// ZMQ subscribes to qtumd's ZMQ notifications of new blocks and transactions, so subscriptions and caches don't wait
+// for the next poll. The publishers are plain ZMTP 3 with the NULL mechanism, which is what qtumd speaks
+type ZMQ struct {
+	// topics to subscribe to at each publisher address
+	endpoints map[string][]string
+	logger    func() log.Logger
+
+	mutex sync.Mutex
+	// topics with a connected publisher
+	connected      map[string]bool
+	nextBlock      chan struct{}
+	onBlock        []func(hash string)
+	lastListenerID int
+	txListeners    map[int]func(txid string)
+}
+
+// NewZMQ subscribes to qtumd's hashblock and rawtx notifications at the addresses, tcp://host:port or ipc:///path,
+// either can be empty to keep polling for it
+func NewZMQ(hashBlockAddress string, rawTxAddress string, logger func() log.Logger) (*ZMQ, error) {
+	z := &ZMQ{
+		endpoints:   make(map[string][]string),
+		logger:      logger,
+		connected:   make(map[string]bool),
+		nextBlock:   make(chan struct{}),
+		txListeners: make(map[int]func(string)),
+	}
+	for topic, address := range map[string]string{ZMQTopicHashBlock: hashBlockAddress, ZMQTopicRawTx: rawTxAddress} {
+		if address == "" {
+			continue
+		}
+		if _, _, err := zmqDialAddress(address); err != nil {
+			return nil, err
+		}
+		z.endpoints[address] = append(z.endpoints[address], topic)
+	}
+	return z, nil
+}
+
+// NotifiesBlocks is true while qtumd's new blocks are pushed to Janus
+func (z *ZMQ) NotifiesBlocks() bool {
+	return z.isConnected(ZMQTopicHashBlock)
+}
+
+// NotifiesTransactions is true while the transactions entering qtumd's mempool are pushed to Janus
+func (z *ZMQ) NotifiesTransactions() bool {
+	return z.isConnected(ZMQTopicRawTx)
+}
+
+func (z *ZMQ) isConnected(topic string) bool {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	return z.connected[topic]
+}
+
+// NextBlock returns a channel closed once qtumd announces its next block
+func (z *ZMQ) NextBlock() <-chan struct{} {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	return z.nextBlock
+}
+
+// OnTransaction calls listen with the txid of each transaction entering qtumd's mempool, until the returned function
+// is called. Transactions are announced again once they are mined, coinbase and coinstake transactions aren't
+func (z *ZMQ) OnTransaction(listen func(txid string)) func() {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	z.lastListenerID++
+	id := z.lastListenerID
+	z.txListeners[id] = listen
+	return func() {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		delete(z.txListeners, id)
+	}
+}
+
+func (z *ZMQ) setConnected(topics []string, connected bool) {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+	for _, topic := range topics {
+		z.connected[topic] = connected
+	}
+}
+
+func (z *ZMQ) deliver(topic string, body []byte) {
+	switch topic {
+	case ZMQTopicHashBlock:
+		hash := hex.EncodeToString(body)
+		z.mutex.Lock()
+		close(z.nextBlock)
+		z.nextBlock = make(chan struct{})
+		onBlock := z.onBlock
+		z.mutex.Unlock()
+		for _, f := range onBlock {
+			f(hash)
+		}
+	case ZMQTopicRawTx:
+		txid, pending, err := rawTransactionID(body)
+		if err != nil {
+			level.Warn(z.logger()).Log("msg", "Ignoring undecodable ZMQ rawtx notification", "error", err)
+			return
+		}
+		if !pending {
+			return
+		}
+		z.mutex.Lock()
+		listeners := make([]func(string), 0, len(z.txListeners))
+		for _, listen := range z.txListeners {
+			listeners = append(listeners, listen)
+		}
+		z.mutex.Unlock()
+		for _, listen := range listeners {
+			listen(txid)
+		}
+	}
+}
+
+// run subscribes to every publisher until ctx is done, connecting again whenever a connection drops
+func (z *ZMQ) run(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for address, topics := range z.endpoints {
+		go z.subscribe(ctx, address, topics)
+	}
+}
+
+func (z *ZMQ) subscribe(ctx context.Context, address string, topics []string) {
+	var delay time.Duration
+	for {
+		connected, err := z.receive(ctx, address, topics)
+		z.setConnected(topics, false)
+		if ctx.Err() != nil {
+			return
+		}
+		level.Warn(z.logger()).Log("msg", "ZMQ connection to qtumd lost, polling until it is back", "address", address, "error", err)
+
+		delay = zmqReconnectDelay(delay, connected)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// zmqReconnectDelay is how long to wait before connecting again after waiting previous, which starts over once a
+// connection was made
+func zmqReconnectDelay(previous time.Duration, connected bool) time.Duration {
+	if connected || previous == 0 {
+		return zmqMinReconnectDelay
+	}
+	if previous *= 2; previous > zmqMaxReconnectDelay {
+		return zmqMaxReconnectDelay
+	}
+	return previous
+}
+
+// receive delivers the notifications of the publisher at address until the connection fails, and tells whether it
+// connected
+func (z *ZMQ) receive(ctx context.Context, address string, topics []string) (bool, error) {
+	network, dialAddress, _ := zmqDialAddress(address)
+	dialer := net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, network, dialAddress)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer conn.Close()
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-closed:
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	if err := zmqHandshake(conn, reader, topics); err != nil {
+		return false, err
+	}
+	z.setConnected(topics, true)
+	level.Info(z.logger()).Log("msg", "Receiving qtumd notifications over ZMQ", "address", address, "topics", strings.Join(topics, ","))
+
+	for {
+		parts, err := zmqReadMessage(reader)
+		if err != nil {
+			return true, err
+		}
+		// topic, body and a sequence number
+		if len(parts) < 2 {
+			continue
+		}
+		z.deliver(string(parts[0]), parts[1])
+	}
+}
+
+// zmqDialAddress splits a ZMQ endpoint into the arguments of net.Dial
+func zmqDialAddress(address string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://"), nil
+	case strings.HasPrefix(address, "ipc://"):
+		return "unix", strings.TrimPrefix(address, "ipc://"), nil
+	default:
+		return "", "", errors.Errorf("unsupported ZMQ address %q, expected tcp://host:port or ipc:///path", address)
+	}
+}
+
+// ZMTP frame flags
+const (
+	zmqFlagMore    = 0x01
+	zmqFlagLong    = 0x02
+	zmqFlagCommand = 0x04
+)
+
+// zmqHandshake greets the publisher as a SUB socket of ZMTP 3.0 with the NULL mechanism and subscribes to topics
+func zmqHandshake(w io.Writer, r *bufio.Reader, topics []string) error {
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9] = 0xff, 0x7f
+	greeting[10], greeting[11] = 3, 0
+	copy(greeting[12:32], "NULL")
+	if _, err := w.Write(greeting); err != nil {
+		return errors.WithStack(err)
+	}
+
+	peer := make([]byte, 64)
+	if _, err := io.ReadFull(r, peer); err != nil {
+		return errors.WithStack(err)
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f || peer[10] < 3 {
+		return errors.New("the ZMQ publisher doesn't speak ZMTP 3")
+	}
+	if mechanism := string(bytes.TrimRight(peer[12:32], "\x00")); mechanism != "NULL" {
+		return errors.Errorf("unsupported ZMQ security mechanism %s", mechanism)
+	}
+
+	ready := zmqCommand("READY", map[string]string{"Socket-Type": "SUB"})
+	if err := zmqWriteFrame(w, zmqFlagCommand, ready); err != nil {
+		return err
+	}
+	for {
+		flags, body, err := zmqReadFrame(r)
+		if err != nil {
+			return err
+		}
+		if flags&zmqFlagCommand == 0 {
+			return errors.New("the ZMQ publisher sent a message before READY")
+		}
+		name, data := zmqCommandName(body)
+		if name == "ERROR" {
+			return errors.Errorf("the ZMQ publisher refused the connection: %s", zmqErrorReason(data))
+		}
+		if name == "READY" {
+			break
+		}
+	}
+
+	// ZMTP 3.0 subscriptions are messages starting with 1, followed by the topic prefix
+	for _, topic := range topics {
+		if err := zmqWriteFrame(w, 0, append([]byte{1}, topic...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zmqReadMessage reads the frames of the next message, skipping commands
+func zmqReadMessage(r *bufio.Reader) ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := zmqReadFrame(r)
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmqFlagCommand != 0 {
+			continue
+		}
+		parts = append(parts, body)
+		if flags&zmqFlagMore == 0 {
+			return parts, nil
+		}
+	}
+}
+
+func zmqReadFrame(r *bufio.Reader) (byte, []byte, error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	var size uint64
+	if flags&zmqFlagLong != 0 {
+		var long [8]byte
+		if _, err := io.ReadFull(r, long[:]); err != nil {
+			return 0, nil, errors.WithStack(err)
+		}
+		size = binary.BigEndian.Uint64(long[:])
+	} else {
+		short, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, errors.WithStack(err)
+		}
+		size = uint64(short)
+	}
+	if size > zmqMaxFrameSize {
+		return 0, nil, errors.Errorf("ZMQ frame of %d bytes is too large", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	return flags, body, nil
+}
+
+func zmqWriteFrame(w io.Writer, flags byte, body []byte) error {
+	var frame []byte
+	if len(body) > 255 {
+		frame = make([]byte, 9, 9+len(body))
+		frame[0] = flags | zmqFlagLong
+		binary.BigEndian.PutUint64(frame[1:], uint64(len(body)))
+	} else {
+		frame = []byte{flags, byte(len(body))}
+	}
+	_, err := w.Write(append(frame, body...))
+	return errors.WithStack(err)
+}
+
+func zmqCommand(name string, properties map[string]string) []byte {
+	body := append([]byte{byte(len(name))}, name...)
+	for key, value := range properties {
+		body = append(body, byte(len(key)))
+		body = append(body, key...)
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(value)))
+		body = append(body, size[:]...)
+		body = append(body, value...)
+	}
+	return body
+}
+
+func zmqCommandName(body []byte) (string, []byte) {
+	if len(body) == 0 || 1+int(body[0]) > len(body) {
+		return "", nil
+	}
+	return string(body[1 : 1+int(body[0])]), body[1+int(body[0]):]
+}
+
+func zmqErrorReason(data []byte) string {
+	if len(data) == 0 || 1+int(data[0]) > len(data) {
+		return "unknown reason"
+	}
+	return string(data[1 : 1+int(data[0])])
+}
+
+// rawTransactionID computes the txid of a serialized transaction, which leaves out segwit data, and whether the
+// transaction can be in the mempool, coinbase and coinstake transactions only exist in blocks
+func rawTransactionID(raw []byte) (string, bool, error) {
+	tx := &txReader{raw: raw}
+	tx.skip(4)
+	segwit := len(raw) > 5 && raw[4] == 0 && raw[5] != 0
+	if segwit {
+		tx.skip(2)
+	}
+
+	start := tx.offset
+	inputs := tx.varInt()
+	coinbase := false
+	for i := uint64(0); i < inputs && tx.err == nil; i++ {
+		prevout := tx.read(36)
+		if i == 0 && len(prevout) == 36 {
+			coinbase = bytes.Equal(prevout[:32], make([]byte, 32)) && binary.LittleEndian.Uint32(prevout[32:]) == 0xffffffff
+		}
+		tx.skip(tx.varInt())
+		tx.skip(4)
+	}
+	outputs := tx.varInt()
+	coinstake := false
+	for i := uint64(0); i < outputs && tx.err == nil; i++ {
+		value := tx.read(8)
+		script := tx.varInt()
+		if i == 0 && len(value) == 8 {
+			// the first output of a coinstake transaction is empty
+			coinstake = inputs > 0 && outputs > 1 && binary.LittleEndian.Uint64(value) == 0 && script == 0
+		}
+		tx.skip(script)
+	}
+	end := tx.offset
+	if segwit {
+		for i := uint64(0); i < inputs && tx.err == nil; i++ {
+			items := tx.varInt()
+			for j := uint64(0); j < items && tx.err == nil; j++ {
+				tx.skip(tx.varInt())
+			}
+		}
+	}
+	tx.skip(4)
+	if tx.err != nil {
+		return "", false, tx.err
+	}
+
+	stripped := make([]byte, 0, 8+end-start)
+	stripped = append(stripped, raw[:4]...)
+	stripped = append(stripped, raw[start:end]...)
+	stripped = append(stripped, raw[tx.offset-4:tx.offset]...)
+	first := sha256.Sum256(stripped)
+	hash := sha256.Sum256(first[:])
+	// txids are shown byte reversed
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:]), !coinbase && !coinstake, nil
+}
+
+// txReader reads a serialized transaction, recording the first read past its end
+type txReader struct {
+	raw    []byte
+	offset int
+	err    error
+}
+
+func (r *txReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.offset+n > len(r.raw) {
+		r.err = errors.New("truncated transaction")
+		return nil
+	}
+	b := r.raw[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *txReader) skip(n uint64) {
+	if n > uint64(len(r.raw)) {
+		r.err = errors.New("truncated transaction")
+		return
+	}
+	r.read(int(n))
+}
+
+func (r *txReader) varInt() uint64 {
+	prefix := r.read(1)
+	if prefix == nil {
+		return 0
+	}
+	switch prefix[0] {
+	case 0xfd:
+		if b := r.read(2); b != nil {
+			return uint64(binary.LittleEndian.Uint16(b))
+		}
+	case 0xfe:
+		if b := r.read(4); b != nil {
+			return uint64(binary.LittleEndian.Uint32(b))
+		}
+	case 0xff:
+		if b := r.read(8); b != nil {
+			return binary.LittleEndian.Uint64(b)
+		}
+	default:
+		return uint64(prefix[0])
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+package qtum
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestRawTransactionID(t *testing.T) {
+	// bitcoin's genesis coinbase
+	genesis, _ := hex.DecodeString("01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000")
+	txid, pending, err := rawTransactionID(genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" || pending {
+		t.Errorf("unexpected txid %s, pending %t", txid, pending)
+	}
+
+	legacy, _ := hex.DecodeString("02000000" + "01" + "11111111111111111111111111111111111111111111111111111111111111110000000000ffffffff" + "01" + "e803000000000000" + "0151" + "00000000")
+	segwit, _ := hex.DecodeString("02000000" + "0001" + "01" + "11111111111111111111111111111111111111111111111111111111111111110000000000ffffffff" + "01" + "e803000000000000" + "0151" + "0102aabb" + "00000000")
+	legacyID, pending, err := rawTransactionID(legacy)
+	if err != nil || !pending {
+		t.Fatalf("expected a pending transaction, got %t, %v", pending, err)
+	}
+	segwitID, _, err := rawTransactionID(segwit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacyID != segwitID {
+		t.Errorf("expected witness data to be left out of the txid, got %s and %s", legacyID, segwitID)
+	}
+
+	if _, _, err := rawTransactionID(legacy[:20]); err == nil {
+		t.Error("expected a truncated transaction to fail")
+	}
+}
+
+// servePublisher acts as qtumd's ZMQ publisher for one subscriber, sending messages once it subscribed to topics
+func servePublisher(t *testing.T, listener net.Listener, topics int, messages [][][]byte) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9], greeting[10], greeting[11] = 0xff, 0x7f, 3, 1
+	copy(greeting[12:], "NULL")
+	greeting[32] = 1
+	if _, err := conn.Write(greeting); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := r.Discard(64); err != nil {
+		t.Error(err)
+		return
+	}
+	if flags, body, err := zmqReadFrame(r); err != nil || flags&zmqFlagCommand == 0 {
+		t.Errorf("expected READY, got %x %q %v", flags, body, err)
+		return
+	}
+	if err := zmqWriteFrame(conn, zmqFlagCommand, zmqCommand("READY", map[string]string{"Socket-Type": "PUB"})); err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < topics; i++ {
+		if _, body, err := zmqReadFrame(r); err != nil || body[0] != 1 {
+			t.Errorf("expected a subscription, got %q %v", body, err)
+			return
+		}
+	}
+	for _, message := range messages {
+		for i, part := range message {
+			flags := byte(0)
+			if i < len(message)-1 {
+				flags = zmqFlagMore
+			}
+			if err := zmqWriteFrame(conn, flags, part); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}
+	// hold the connection open until the subscriber goes away
+	r.ReadByte()
+}
+
+func TestZMQ(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	z, err := NewZMQ("tcp://"+listener.Addr().String(), "tcp://"+listener.Addr().String(), func() log.Logger { return log.NewNopLogger() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	txids := make(chan string, 1)
+	z.OnTransaction(func(txid string) { txids <- txid })
+	nextBlock := z.NextBlock()
+
+	tx, _ := hex.DecodeString("02000000" + "01" + "11111111111111111111111111111111111111111111111111111111111111110000000000ffffffff" + "01" + "e803000000000000" + "0151" + "00000000")
+	txid, _, _ := rawTransactionID(tx)
+	hash := make([]byte, 32)
+	go servePublisher(t, listener, 2, [][][]byte{
+		{[]byte(ZMQTopicRawTx), tx, {0, 0, 0, 0}},
+		{[]byte(ZMQTopicHashBlock), hash, {0, 0, 0, 0}},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	z.run(ctx)
+
+	select {
+	case received := <-txids:
+		if received != txid {
+			t.Errorf("expected txid %s, got %s", txid, received)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transaction received")
+	}
+	select {
+	case <-nextBlock:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block received")
+	}
+	if !z.NotifiesBlocks() || !z.NotifiesTransactions() {
+		t.Error("expected blocks and transactions to be pushed")
+	}
+
+	if _, err := NewZMQ("http://localhost:28332", "", nil); err == nil {
+		t.Error("expected an unsupported address to be refused")
+	}
+}
+
+func TestZMQReconnectDelay(t *testing.T) {
+	delay := zmqReconnectDelay(0, false)
+	if delay != zmqMinReconnectDelay {
+		t.Errorf("expected the first delay to be %s, got %s", zmqMinReconnectDelay, delay)
+	}
+	for i := 0; i < 10; i++ {
+		delay = zmqReconnectDelay(delay, false)
+	}
+	if delay != zmqMaxReconnectDelay {
+		t.Errorf("expected the delay to stop growing at %s, got %s", zmqMaxReconnectDelay, delay)
+	}
+	// a publisher that was reached is tried again as soon as the first time it went away
+	if delay = zmqReconnectDelay(delay, true); delay != zmqMinReconnectDelay {
+		t.Errorf("expected the delay to start over after a connection, got %s", delay)
+	}
+}
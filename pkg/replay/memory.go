@@ -0,0 +1,57 @@
+package replay
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// maxMemoryHashes bounds the hashes MemoryStore remembers, the oldest are forgotten first. Their nonces are below the
+// count of their senders by then
+const maxMemoryHashes = 100000
+
+// MemoryStore keeps the hashes in memory, they are forgotten when Janus restarts
+type MemoryStore struct {
+	mutex  sync.Mutex
+	hashes map[string]bool
+	order  []string
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{hashes: make(map[string]bool)}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, hash string) error {
+	hash = strings.ToLower(hash)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.hashes[hash] {
+		return ErrSent
+	}
+	s.hashes[hash] = true
+	s.order = append(s.order, hash)
+	if len(s.order) > maxMemoryHashes {
+		delete(s.hashes, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, hash string) error {
+	hash = strings.ToLower(hash)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.hashes[hash] {
+		return nil
+	}
+	delete(s.hashes, hash)
+	for i, claimed := range s.order {
+		if claimed == hash {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
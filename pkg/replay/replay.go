@@ -0,0 +1,17 @@
+package replay
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrSent = errors.New("transaction already sent")
+
+// Store remembers the hashes of the Ethereum transactions eth_sendRawTransaction had qtumd's wallet send, an Ethereum
+// signature doesn't cover the QTUM transaction so the same signed transaction could otherwise be sent again
+type Store interface {
+	// Claim records hash before its transaction is sent, ErrSent when it was recorded before
+	Claim(ctx context.Context, hash string) error
+	// Release forgets a hash whose transaction couldn't be sent, so it can be sent again
+	Release(ctx context.Context, hash string) error
+}
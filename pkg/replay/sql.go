@@ -0,0 +1,84 @@
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS janus_ethereum_transactions (
+	hash TEXT PRIMARY KEY,
+	sent_at TIMESTAMPTZ NOT NULL
+)`
+
+// SQLStore keeps the hashes in the postgres database Janus is configured with, so they survive restarts and are
+// shared by the Janus instances sending to the same chain
+type SQLStore struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// Open sets up the store, the table is created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open sent Ethereum transactions database")
+	}
+	s := &SQLStore{db: db}
+	// failing here is fine, the next use tries again
+	s.migrate(ctx)
+	return s, nil
+}
+
+func (s *SQLStore) migrate(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.migrated {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, createTable); err != nil {
+		return errors.Wrap(err, "couldn't create sent Ethereum transactions table")
+	}
+	s.migrated = true
+	return nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Claim(ctx context.Context, hash string) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO janus_ethereum_transactions (hash, sent_at) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING",
+		strings.ToLower(hash), time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "couldn't record sent Ethereum transaction")
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+		return ErrSent
+	}
+	return nil
+}
+
+func (s *SQLStore) Release(ctx context.Context, hash string) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "DELETE FROM janus_ethereum_transactions WHERE hash = $1", strings.ToLower(hash))
+	return errors.Wrap(err, "couldn't forget sent Ethereum transaction")
+}
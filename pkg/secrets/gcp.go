@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// GCPSecretManagerURL is Google Cloud Secret Manager's API endpoint
+	GCPSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+	// GCPMetadataTokenURL hands out access tokens of the service account Janus runs as on Google Cloud
+	GCPMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCPSecretManager reads secrets from Google Cloud Secret Manager,
+// gcp:projects/PROJECT/secrets/NAME/versions/latest, authenticated as the service account of the instance or pod
+type GCPSecretManager struct {
+	// GCPSecretManagerURL when empty
+	URL string
+	// GCPMetadataTokenURL when empty
+	TokenURL string
+	Client   *http.Client
+}
+
+var _ Provider = (*GCPSecretManager)(nil)
+
+func (g *GCPSecretManager) Get(ctx context.Context, ref string) (string, error) {
+	token, err := g.token(ctx)
+	if err != nil {
+		return "", errors.WithMessage(err, "couldn't get a Google Cloud access token")
+	}
+	if !strings.Contains(ref, "/versions/") {
+		ref += "/versions/latest"
+	}
+	url := g.URL
+	if url == "" {
+		url = GCPSecretManagerURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(url, "/")+"/"+strings.TrimLeft(ref, "/")+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getJSON(g.Client, req, &response); err != nil {
+		return "", errors.WithMessage(err, "Secret Manager")
+	}
+	value, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", errors.Wrap(err, "unexpected Secret Manager payload")
+	}
+	return string(value), nil
+}
+
+func (g *GCPSecretManager) token(ctx context.Context) (string, error) {
+	url := g.TokenURL
+	if url == "" {
+		url = GCPMetadataTokenURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := getJSON(g.Client, req, &response); err != nil {
+		return "", err
+	}
+	return response.AccessToken, nil
+}
@@ -0,0 +1,125 @@
+// Package secrets resolves references to secrets kept outside Janus' flags and environment, such as in HashiCorp
+// Vault or a cloud secret manager
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// Provider fetches secrets from one store
+type Provider interface {
+	// Get returns the secret ref names, the part of a reference after its scheme
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver resolves values of the form scheme:ref with the provider registered for the scheme, like
+// vault:secret/data/janus#rpc-password, other values are used as they are
+type Resolver struct {
+	mutex     sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver resolves env: and file: references, other providers are registered with Register
+func NewResolver() *Resolver {
+	r := &Resolver{providers: make(map[string]Provider)}
+	r.Register("env", Env{})
+	r.Register("file", File{})
+	return r
+}
+
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.providers[scheme] = provider
+}
+
+func (r *Resolver) provider(value string) (Provider, string) {
+	index := strings.Index(value, ":")
+	if index <= 0 {
+		return nil, ""
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.providers[value[:index]], value[index+1:]
+}
+
+// IsReference tells whether value refers to a secret rather than being one
+func (r *Resolver) IsReference(value string) bool {
+	provider, _ := r.provider(value)
+	return provider != nil
+}
+
+// Resolve fetches the secret value refers to, values that aren't references are returned as they are
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	provider, ref := r.provider(value)
+	if provider == nil {
+		return value, nil
+	}
+	secret, err := provider.Get(ctx, ref)
+	if err != nil {
+		return "", errors.WithMessagef(err, "couldn't resolve secret %s", value)
+	}
+	return secret, nil
+}
+
+// Watch resolves value again every interval until ctx ends and calls onChange with the secret when it was rotated.
+// Failures are logged and the current secret stays in use
+func (r *Resolver) Watch(ctx context.Context, value string, current string, interval time.Duration, logger log.Logger, onChange func(string)) {
+	if !r.IsReference(value) || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			secret, err := r.Resolve(ctx, value)
+			if err != nil {
+				level.Warn(logger).Log("msg", "Failed to refresh secret", "error", err)
+				continue
+			}
+			if secret != current {
+				level.Info(logger).Log("msg", "Secret rotated", "secret", value)
+				current = secret
+				onChange(secret)
+			}
+		}
+	}
+}
+
+// Env reads secrets from environment variables, env:NAME
+type Env struct{}
+
+var _ Provider = Env{}
+
+func (Env) Get(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// File reads secrets from files, file:/run/secrets/name, such as the ones Kubernetes and the secrets store CSI
+// driver mount. The trailing newline is dropped
+type File struct{}
+
+var _ Provider = File{}
+
+func (File) Get(ctx context.Context, path string) (string, error) {
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
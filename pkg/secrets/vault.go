@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Vault reads secrets from HashiCorp Vault's KV secrets engine, vault:secret/data/janus#field. Version 2 paths
+// include /data/ after the mount, the field can be left out of secrets with a single one
+type Vault struct {
+	// like https://vault.example.com:8200
+	Address string
+	Token   string
+	// Vault Enterprise namespace, optional
+	Namespace string
+	Client    *http.Client
+}
+
+var _ Provider = (*Vault)(nil)
+
+func (v *Vault) Get(ctx context.Context, ref string) (string, error) {
+	if v.Address == "" {
+		return "", errors.New("no Vault address configured")
+	}
+	path, field := ref, ""
+	if index := strings.LastIndex(ref, "#"); index != -1 {
+		path, field = ref[:index], ref[index+1:]
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(v.Address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := getJSON(v.Client, req, &response); err != nil {
+		return "", errors.WithMessage(err, "Vault")
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		return "", errors.Wrap(err, "unexpected Vault response")
+	}
+	// version 2 of the KV engine nests the secret next to its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	return vaultField(data, field)
+}
+
+func vaultField(data map[string]interface{}, field string) (string, error) {
+	if field == "" {
+		if len(data) != 1 {
+			fields := make([]string, 0, len(data))
+			for name := range data {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			return "", errors.Errorf("secret has fields %s, pick one with #field", strings.Join(fields, ", "))
+		}
+		for name := range data {
+			field = name
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", errors.Errorf("secret has no field %s", field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// getJSON decodes the JSON response of req, failing on non 2xx statuses
+func getJSON(client *http.Client, req *http.Request, v interface{}) error {
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%s answered %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(v), "couldn't decode response")
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/notifier"
+)
+
+// AdminConnectionsPath lists the open websocket connections, when an admin token is configured
+const AdminConnectionsPath = "/admin/connections"
+
+// connection is an open websocket connection
+type connection struct {
+	id          string
+	remoteAddr  string
+	userAgent   string
+	network     string
+	connectedAt time.Time
+	notifier    *notifier.Notifier
+	close       func()
+	// closes the connection once its requests in flight are answered
+	drain func()
+}
+
+// ConnectionStatus describes a websocket connection to operators
+type ConnectionStatus struct {
+	ID            string    `json:"id"`
+	RemoteAddress string    `json:"remoteAddress"`
+	UserAgent     string    `json:"userAgent,omitempty"`
+	Network       string    `json:"network,omitempty"`
+	ConnectedAt   time.Time `json:"connectedAt"`
+	AgeSeconds    int64     `json:"ageSeconds"`
+	// notifications queued for the connection
+	Backlog       int                  `json:"backlog"`
+	Subscriptions []SubscriptionStatus `json:"subscriptions"`
+}
+
+type SubscriptionStatus struct {
+	notifier.SubscriptionStatus
+	AgeSeconds int64 `json:"ageSeconds"`
+}
+
+// connections keeps track of the open websocket connections
+type connections struct {
+	mutex  sync.Mutex
+	lastID int64
+	open   map[string]*connection
+	// set once the server shuts down, connections opened since are drained right away
+	draining bool
+}
+
+func newConnections() *connections {
+	return &connections{open: make(map[string]*connection)}
+}
+
+func (c *connections) add(conn *connection) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lastID++
+	conn.id = strconv.FormatInt(c.lastID, 10)
+	c.open[conn.id] = conn
+	if c.draining {
+		conn.drain()
+	}
+}
+
+func (c *connections) remove(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.open, id)
+}
+
+func (c *connections) get(id string) *connection {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.open[id]
+}
+
+// list returns the open connections
+func (c *connections) list() []*connection {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	open := make([]*connection, 0, len(c.open))
+	for _, conn := range c.open {
+		open = append(open, conn)
+	}
+	return open
+}
+
+// statuses lists the open connections, oldest first
+func (c *connections) statuses() []ConnectionStatus {
+	open := c.list()
+	now := time.Now()
+	statuses := make([]ConnectionStatus, 0, len(open))
+	for _, conn := range open {
+		status := ConnectionStatus{
+			ID:            conn.id,
+			RemoteAddress: conn.remoteAddr,
+			UserAgent:     conn.userAgent,
+			Network:       conn.network,
+			ConnectedAt:   conn.connectedAt,
+			AgeSeconds:    int64(now.Sub(conn.connectedAt).Seconds()),
+			Backlog:       conn.notifier.Backlog(),
+			Subscriptions: []SubscriptionStatus{},
+		}
+		for _, subscription := range conn.notifier.Subscriptions() {
+			status.Subscriptions = append(status.Subscriptions, SubscriptionStatus{
+				SubscriptionStatus: subscription,
+				AgeSeconds:         int64(now.Sub(subscription.CreatedAt).Seconds()),
+			})
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ConnectedAt.Before(statuses[j].ConnectedAt)
+	})
+	return statuses
+}
+
+// backlog is the number of notifications queued or held back across connections
+func (c *connections) backlog() int {
+	backlog := 0
+	for _, conn := range c.list() {
+		backlog += conn.notifier.Backlog()
+		for _, subscription := range conn.notifier.Subscriptions() {
+			backlog += subscription.Backlog
+		}
+	}
+	return backlog
+}
+
+func (s *Server) requireAdminToken(h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !s.adminToken.authorizes(c) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"message": "invalid admin token"})
+		}
+		return h(c)
+	}
+}
+
+func (s *Server) serveConnections(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.connections.statuses())
+}
+
+// closeConnection force-closes an abusive connection, its subscriptions end with it
+func (s *Server) closeConnection(c echo.Context) error {
+	conn := s.connections.get(c.Param("id"))
+	if conn == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"message": "no open connection " + c.Param("id")})
+	}
+	s.logger.Log("msg", "Closing websocket connection on admin request", "connection", conn.id, "remoteAddress", conn.remoteAddr)
+	conn.close()
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetAdminToken serves the admin API at /admin/ to requests authorized with token, an empty token disables it
+func SetAdminToken(token string) Option {
+	return func(p *Server) error {
+		p.adminToken.set(token)
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func adminRequest(t *testing.T, method, url, token string) *http.Response {
+	return adminRequestWithBody(t, method, url, token, "")
+}
+
+func adminRequestWithBody(t *testing.T, method, url, token, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAdminConnections(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&largeProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetAdminToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), http.Header{"User-Agent": {"test-client"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	// a round trip makes sure the connection is registered
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ws.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := adminRequest(t, http.MethodGet, httpServer.URL+AdminConnectionsPath, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a wrong token to be rejected, got %d", resp.StatusCode)
+	}
+
+	resp := adminRequest(t, http.MethodGet, httpServer.URL+AdminConnectionsPath, "secret")
+	var connections []ConnectionStatus
+	if err := json.NewDecoder(resp.Body).Decode(&connections); err != nil {
+		t.Fatal(err)
+	}
+	if len(connections) != 1 || connections[0].UserAgent != "test-client" || len(connections[0].Subscriptions) != 0 {
+		t.Fatalf("unexpected connections %+v", connections)
+	}
+
+	if resp := adminRequest(t, http.MethodDelete, httpServer.URL+AdminConnectionsPath+"/unknown", "secret"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an unknown connection to be not found, got %d", resp.StatusCode)
+	}
+	if resp := adminRequest(t, http.MethodDelete, httpServer.URL+AdminConnectionsPath+"/"+connections[0].ID, "secret"); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected the connection to be closed, got %d", resp.StatusCode)
+	}
+	if _, _, err := ws.ReadMessage(); err == nil {
+		t.Error("expected the connection to be closed")
+	}
+}
+
+func TestAdminCredentialsRotation(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&largeProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetAdminToken("old-admin"), SetReplicationToken("old-replication"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	if resp := adminRequestWithBody(t, http.MethodPost, httpServer.URL+AdminCredentialsPath, "old-admin", `{"qtumRPCUser":"qtum"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a user without a password to be rejected, got %d", resp.StatusCode)
+	}
+	body := `{"adminToken":"new-admin","replicationToken":"new-replication","qtumRPCUser":"qtum","qtumRPCPassword":"rotated"}`
+	if resp := adminRequestWithBody(t, http.MethodPost, httpServer.URL+AdminCredentialsPath, "old-admin", body); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected the credentials to be rotated, got %d", resp.StatusCode)
+	}
+
+	for _, token := range []string{"new-admin", "old-admin"} {
+		if resp := adminRequest(t, http.MethodGet, httpServer.URL+AdminConnectionsPath, token); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected %s to be accepted during the grace period, got %d", token, resp.StatusCode)
+		}
+	}
+	for _, token := range []string{"new-replication", "old-replication"} {
+		if resp := adminRequest(t, http.MethodGet, httpServer.URL+ReplicationStatePath, token); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected %s to be accepted during the grace period, got %d", token, resp.StatusCode)
+		}
+	}
+
+	s.tokenGracePeriod = 0
+	if err := s.RotateAdminToken("newer-admin"); err != nil {
+		t.Fatal(err)
+	}
+	if resp := adminRequest(t, http.MethodGet, httpServer.URL+AdminConnectionsPath, "new-admin"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the rotated out token to be rejected without a grace period, got %d", resp.StatusCode)
+	}
+	if err := s.RotateAdminToken(""); err == nil {
+		t.Error("expected an empty admin token to be refused")
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/qtumproject/janus/pkg/alerting"
+)
+
+// AlertThresholds are the conditions Janus alerts on, zero disables a condition
+type AlertThresholds struct {
+	// percentage of the last qtumd or client requests failing
+	ErrorRate float64
+	// blocks qtumd is behind the headers it knows of
+	BlockLag int64
+	// notifications queued or held back for websocket subscribers, across connections
+	SubscriptionBacklog int
+}
+
+// SetAlerting sends alerts when a threshold is exceeded for the whole configured window
+func SetAlerting(config alerting.Config, thresholds AlertThresholds) Option {
+	return func(p *Server) error {
+		if len(config.Senders) == 0 {
+			p.alerter = nil
+			return nil
+		}
+		alerter, err := alerting.New(config, p.alertRules(thresholds), func() log.Logger {
+			return p.logger
+		})
+		if err != nil {
+			return err
+		}
+		p.alerter = alerter
+		return nil
+	}
+}
+
+func (s *Server) alertRules(thresholds AlertThresholds) []alerting.Rule {
+	var rules []alerting.Rule
+	if thresholds.ErrorRate != 0 {
+		rules = append(rules,
+			alerting.Rule{
+				Name:        "qtumd-error-rate",
+				Description: "qtumd request error rate (%)",
+				Threshold:   thresholds.ErrorRate,
+				Value: func(ctx context.Context) (float64, error) {
+					if s.qtumRequestAnalytics == nil {
+						return 0, nil
+					}
+					return float64(1-s.qtumRequestAnalytics.GetSuccessRate()) * 100, nil
+				},
+			},
+			alerting.Rule{
+				Name:        "janus-error-rate",
+				Description: "client request error rate (%)",
+				Threshold:   thresholds.ErrorRate,
+				Value: func(ctx context.Context) (float64, error) {
+					return float64(1-s.ethRequestAnalytics.GetSuccessRate()) * 100, nil
+				},
+			},
+		)
+	}
+	if thresholds.BlockLag != 0 {
+		rules = append(rules, alerting.Rule{
+			Name:        "qtumd-block-lag",
+			Description: "qtumd blocks behind its headers",
+			Threshold:   float64(thresholds.BlockLag),
+			Value: func(ctx context.Context) (float64, error) {
+				info, err := s.qtumRPCClient.GetBlockChainInfo(ctx)
+				if err != nil {
+					return 0, err
+				}
+				return float64(info.Headers - info.Blocks), nil
+			},
+		})
+	}
+	if thresholds.SubscriptionBacklog != 0 {
+		rules = append(rules, alerting.Rule{
+			Name:        "subscription-backlog",
+			Description: "websocket notification backlog",
+			Threshold:   float64(thresholds.SubscriptionBacklog),
+			Value: func(ctx context.Context) (float64, error) {
+				return float64(s.connections.backlog()), nil
+			},
+		})
+	}
+	return rules
+}
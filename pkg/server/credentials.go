@@ -0,0 +1,145 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// AdminCredentialsPath rotates the admin and replication tokens and qtumd's RPC credentials while Janus runs
+const AdminCredentialsPath = "/admin/credentials"
+
+// DefaultTokenGracePeriod is how long a rotated out token keeps working, so clients have time to switch to the new one
+const DefaultTokenGracePeriod = 5 * time.Minute
+
+// token is a bearer token that can be rotated while serving, the previous token is accepted until its grace period ends
+type token struct {
+	mutex         sync.RWMutex
+	current       string
+	previous      string
+	previousUntil time.Time
+}
+
+func (t *token) get() string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.current
+}
+
+func (t *token) set(value string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.current = value
+	t.previous = ""
+}
+
+// rotate switches to value, the current token keeps working for gracePeriod
+func (t *token) rotate(value string, gracePeriod time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if value == t.current {
+		return
+	}
+	t.previous = t.current
+	t.previousUntil = time.Now().Add(gracePeriod)
+	t.current = value
+}
+
+// authorizes checks the Authorization header of a request against the token and the previous one
+func (t *token) authorizes(c echo.Context) bool {
+	given := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if t.current != "" && subtle.ConstantTimeCompare([]byte(given), []byte(t.current)) == 1 {
+		return true
+	}
+	return t.previous != "" && time.Now().Before(t.previousUntil) &&
+		subtle.ConstantTimeCompare([]byte(given), []byte(t.previous)) == 1
+}
+
+// CredentialsRotation holds the credentials to switch to, empty fields are left as they are
+type CredentialsRotation struct {
+	AdminToken       string `json:"adminToken,omitempty"`
+	ReplicationToken string `json:"replicationToken,omitempty"`
+	// user and password of qtumd's RPC, qtumd can accept both the old and new ones with two rpcauth entries
+	QtumRPCUser     string `json:"qtumRPCUser,omitempty"`
+	QtumRPCPassword string `json:"qtumRPCPassword,omitempty"`
+}
+
+// RotateAdminToken switches the admin API to a new token, the old one keeps working for the token grace period
+func (s *Server) RotateAdminToken(token string) error {
+	if token == "" {
+		return errors.New("the admin token can't be rotated to an empty token")
+	}
+	if s.adminToken.get() == "" {
+		return errors.New("the admin API is disabled, it needs an admin token at startup")
+	}
+	s.adminToken.rotate(token, s.tokenGracePeriod)
+	return nil
+}
+
+// RotateReplicationToken switches the replication state and the standby syncing from the active instance to a new
+// token, the old one keeps working for the token grace period
+func (s *Server) RotateReplicationToken(token string) error {
+	if token == "" {
+		return errors.New("the replication token can't be rotated to an empty token")
+	}
+	if s.replicationToken.get() == "" && s.standby == nil {
+		return errors.New("replication is disabled, it needs a replication token at startup")
+	}
+	if s.replicationToken.get() != "" {
+		s.replicationToken.rotate(token, s.tokenGracePeriod)
+	}
+	if s.standby != nil {
+		s.standby.setToken(token)
+	}
+	return nil
+}
+
+// rotateCredentials switches to the credentials of the request body, open connections to clients and qtumd stay up
+func (s *Server) rotateCredentials(c echo.Context) error {
+	var rotation CredentialsRotation
+	if err := c.Bind(&rotation); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "invalid credentials: " + err.Error()})
+	}
+	if (rotation.QtumRPCUser == "") != (rotation.QtumRPCPassword == "") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"message": "qtumd's RPC user and password are rotated together"})
+	}
+	if rotation.ReplicationToken != "" {
+		if err := s.RotateReplicationToken(rotation.ReplicationToken); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+	}
+	if rotation.QtumRPCUser != "" {
+		s.qtumRPCClient.SetCredentials(rotation.QtumRPCUser, rotation.QtumRPCPassword)
+	}
+	// last, so a failed request can be retried with the token it was sent with
+	if rotation.AdminToken != "" {
+		if err := s.RotateAdminToken(rotation.AdminToken); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		}
+	}
+	s.logger.Log(
+		"msg", "Rotated credentials on admin request",
+		"adminToken", rotation.AdminToken != "",
+		"replicationToken", rotation.ReplicationToken != "",
+		"qtumRPC", rotation.QtumRPCUser != "",
+	)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetTokenGracePeriod is how long rotated out admin and replication tokens keep working
+func SetTokenGracePeriod(gracePeriod time.Duration) Option {
+	return func(p *Server) error {
+		if gracePeriod < 0 {
+			return errors.New("the token grace period can't be negative")
+		}
+		p.tokenGracePeriod = gracePeriod
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// TimeoutHeader lets clients bound how long Janus spends on a request, as a duration such as "1.5s" or milliseconds,
+// batches share the deadline and over websockets it applies to every message
+const TimeoutHeader = "X-Janus-Timeout"
+
+// deadlines are the server configured timeouts, zero means no timeout
+type deadlines struct {
+	global  time.Duration
+	methods map[string]time.Duration
+}
+
+func (d *deadlines) timeout(method string) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return shortestTimeout(d.global, d.methods[method])
+}
+
+// shortestTimeout picks the strictest of the timeouts that are set
+func shortestTimeout(timeouts ...time.Duration) time.Duration {
+	var shortest time.Duration
+	for _, timeout := range timeouts {
+		if timeout > 0 && (shortest == 0 || timeout < shortest) {
+			shortest = timeout
+		}
+	}
+	return shortest
+}
+
+// ParseTimeout reads a timeout given as a duration such as "1.5s" or a number of milliseconds
+func ParseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var timeout time.Duration
+	if milliseconds, err := strconv.ParseFloat(value, 64); err == nil {
+		timeout = time.Duration(milliseconds * float64(time.Millisecond))
+	} else if timeout, err = time.ParseDuration(value); err != nil {
+		return 0, errors.Errorf("invalid timeout %q", value)
+	}
+	if timeout <= 0 {
+		return 0, errors.Errorf("timeout must be positive, got %q", value)
+	}
+	return timeout, nil
+}
+
+func parseRequestTimeout(raw json.RawMessage) (time.Duration, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		value = string(raw)
+	}
+	timeout, err := ParseTimeout(value)
+	return timeout, errors.WithMessage(err, "janus_timeout")
+}
+
+// withDeadline bounds the translation of a request by the server timeouts for its method, the X-Janus-Timeout
+// header and the request's own janus_timeout
+func (c *myCtx) withDeadline(ctx context.Context, rpcReq *eth.JSONRPCRequest) (context.Context, context.CancelFunc, time.Duration, eth.JSONRPCError) {
+	requestTimeout, err := parseRequestTimeout(rpcReq.Timeout)
+	if err != nil {
+		return ctx, func() {}, 0, eth.NewInvalidRequestError(err.Error())
+	}
+	timeout := shortestTimeout(c.deadlines.timeout(rpcReq.Method), c.headerTimeout, requestTimeout)
+	if timeout == 0 {
+		return ctx, func() {}, 0, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout, nil
+}
+
+// transform runs the transformer within the request's deadline and rate limits, reporting a timeout error when it ran
+// out
+func (c *myCtx) transform(ctx context.Context, rpcReq *eth.JSONRPCRequest, e echo.Context) (result interface{}, jsonErr eth.JSONRPCError) {
+	ctx, cancel, timeout, jsonErr := c.withDeadline(ctx, rpcReq)
+	defer cancel()
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	if c.metrics != nil {
+		start := time.Now()
+		defer func() {
+			method := rpcReq.Method
+			if !c.transformer.Handles(method) {
+				method = unknownMethod
+			}
+			// proxies may return their error as the result
+			failure := jsonErr
+			if explicit, ok := result.(eth.JSONRPCError); ok && failure == nil {
+				failure = explicit
+			}
+			c.metrics.observe(c.network, method, time.Since(start), failure)
+		}()
+	}
+
+	if c.rateLimiter != nil {
+		if jsonErr := c.rateLimiter.allow(c.rateLimiter.clientIP(c.Request()), rpcReq.Method); jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+
+	if jsonErr := c.authorize(rpcReq.Method); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	result, jsonErr = c.transformer.TransformUnchecksummed(ctx, rpcReq, e)
+	if jsonErr != nil && timeout != 0 && ctx.Err() == context.DeadlineExceeded {
+		c.GetDebugLogger().Log("msg", "request timed out", "method", rpcReq.Method, "timeout", timeout, "error", jsonErr.Message())
+		return nil, eth.NewTimeoutError(timeout)
+	}
+	// streamed results are checksummed an entry at a time as they are written, rather than copied as a whole
+	if jsonErr == nil && !isStreamable(result) {
+		result = transformer.ChecksumResult(rpcReq.Method, result)
+	}
+	return result, jsonErr
+}
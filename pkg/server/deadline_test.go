@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// slowProxy answers once its request is cancelled, like a qtumd call backing off until the deadline
+type slowProxy struct{}
+
+func (p *slowProxy) Method() string {
+	return "test_slow"
+}
+
+func (p *slowProxy) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	select {
+	case <-ctx.Done():
+		return nil, eth.NewCallbackError(ctx.Err().Error())
+	case <-time.After(5 * time.Second):
+		return "done", nil
+	}
+}
+
+func newDeadlineTestServer(t *testing.T, opts ...Option) *Server {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&slowProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func postWithTimeout(t *testing.T, s *Server, body string, timeout string) []byte {
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if timeout != "" {
+		request.Header.Set(TimeoutHeader, timeout)
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+	return recorder.Body.Bytes()
+}
+
+func TestRequestDeadlines(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		body   string
+		header string
+	}{
+		{"header", nil, `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[]}`, "20ms"},
+		{"request field", nil, `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[],"janus_timeout":20}`, ""},
+		{"method timeout", []Option{SetTimeouts(0, map[string]time.Duration{"test_slow": 20 * time.Millisecond})}, `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[]}`, ""},
+		{"shortest wins", []Option{SetTimeouts(time.Minute, nil)}, `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[],"janus_timeout":"20ms"}`, "30s"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newDeadlineTestServer(t, test.opts...)
+			start := time.Now()
+			body := postWithTimeout(t, s, test.body, test.header)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("request took %s", elapsed)
+			}
+
+			var response eth.JSONRPCResult
+			if err := json.Unmarshal(body, &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error == nil || response.Error.Code() != eth.TimeoutErrorCode || response.Error.Message() != "request timed out after 20ms" {
+				t.Errorf("expected a timeout error, got %s", body)
+			}
+		})
+	}
+}
+
+func TestRequestDeadlineSharedByBatch(t *testing.T) {
+	s := newDeadlineTestServer(t)
+	start := time.Now()
+	body := postWithTimeout(t, s, `[{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[]},{"jsonrpc":"2.0","id":2,"method":"test_slow","params":[]}]`, "50ms")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("batch took %s", elapsed)
+	}
+
+	var responses []eth.JSONRPCResult
+	if err := json.Unmarshal(body, &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("unexpected responses %s", body)
+	}
+	for _, response := range responses {
+		if response.Error == nil || response.Error.Code() != eth.TimeoutErrorCode {
+			t.Errorf("expected a timeout error, got %s", body)
+		}
+	}
+}
+
+func TestInvalidRequestDeadline(t *testing.T) {
+	s := newDeadlineTestServer(t)
+	body := postWithTimeout(t, s, `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[]}`, "soon")
+
+	var response eth.JSONRPCResult
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Code() != eth.InvalidRequestErrorCode {
+		t.Errorf("expected an invalid request error, got %s", body)
+	}
+}
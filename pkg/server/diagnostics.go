@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/cache"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// HealthPath reports the state of qtumd and of what Janus depends on besides it
+const HealthPath = "/health"
+
+// the overall status of HealthPath
+const (
+	HealthOK = "ok"
+	// something Janus works without is failing, or qtumd isn't synced
+	HealthDegraded = "degraded"
+	// qtumd can't be reached
+	HealthDown = "down"
+)
+
+// healthCheckTimeout bounds the calls HealthPath and the readiness checks make
+const healthCheckTimeout = 5 * time.Second
+
+// maxHeadersAhead is how many blocks qtumd may have the headers of without having connected them and still count as
+// synced, a new block is in that state while it's validated
+const maxHeadersAhead = 2
+
+type healthStatus struct {
+	Status            string                  `json:"status"`
+	Qtumd             QtumdStatus             `json:"qtumd"`
+	BlockHashDatabase BlockHashDatabaseStatus `json:"blockHashDatabase"`
+	Cache             CacheStatus             `json:"cache"`
+	Chain             *ChainStatus            `json:"chain,omitempty"`
+}
+
+// QtumdStatus is what getblockchaininfo returned
+type QtumdStatus struct {
+	Reachable bool `json:"reachable"`
+	// milliseconds getblockchaininfo took
+	Latency              int64   `json:"latency"`
+	Chain                string  `json:"chain,omitempty"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	VerificationProgress float64 `json:"verificationProgress"`
+	InitialBlockDownload bool    `json:"initialBlockDownload"`
+	Synced               bool    `json:"synced"`
+	Error                string  `json:"error,omitempty"`
+}
+
+// BlockHashDatabaseStatus is the state of the database mapping Ethereum block hashes to Qtum block hashes
+type BlockHashDatabaseStatus struct {
+	Configured bool   `json:"configured"`
+	Connected  bool   `json:"connected"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CacheStatus is the state of the cache of qtumd responses
+type CacheStatus struct {
+	// memory, redis or external
+	Backend   string `json:"backend"`
+	Reachable bool   `json:"reachable"`
+	// responses cached in memory, left out for shared backends
+	Entries *int   `json:"entries,omitempty"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Error   string `json:"error,omitempty"`
+}
+
+// cachePinger is a cache backend that can tell whether it's reachable
+type cachePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// serveHealth returns the state of qtumd, the block hash database and the cache, with 503 while qtumd can't be reached
+func (s *Server) serveHealth(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), healthCheckTimeout)
+	defer cancel()
+
+	health := healthStatus{
+		Qtumd:             s.qtumdStatus(ctx),
+		BlockHashDatabase: s.blockHashDatabaseStatus(),
+		Cache:             s.cacheStatus(ctx),
+	}
+	if s.chainWatchdog != nil {
+		status := s.chainWatchdog.getStatus()
+		health.Chain = &status
+	}
+
+	health.Status = HealthOK
+	if !health.Qtumd.Synced || (health.BlockHashDatabase.Configured && !health.BlockHashDatabase.Connected) || !health.Cache.Reachable || (health.Chain != nil && health.Chain.Stale) {
+		health.Status = HealthDegraded
+	}
+	if !health.Qtumd.Reachable {
+		health.Status = HealthDown
+		return c.JSON(http.StatusServiceUnavailable, health)
+	}
+	return c.JSON(http.StatusOK, health)
+}
+
+func (s *Server) qtumdStatus(ctx context.Context) QtumdStatus {
+	start := time.Now()
+	info, err := s.qtumRPCClient.GetBlockChainInfo(ctx)
+	status := QtumdStatus{Latency: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+	status.Chain = info.Chain
+	status.Blocks = info.Blocks
+	status.Headers = info.Headers
+	status.VerificationProgress = info.Verificationprogress
+	status.InitialBlockDownload = info.Initialblockdownload
+	if err := qtumdSyncError(info); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Synced = true
+	}
+	return status
+}
+
+// qtumdSyncError fails while qtumd is catching up with the network, answers would come from an old chain
+func qtumdSyncError(info qtum.GetBlockChainInfoResponse) error {
+	// an idle regtest node is in initial block download until the next block is mined
+	if info.Initialblockdownload && info.Chain != qtum.ChainRegTest {
+		return errors.Errorf("qtumd is in initial block download at height %d of %d", info.Blocks, info.Headers)
+	}
+	if behind := info.Headers - info.Blocks; behind > maxHeadersAhead {
+		return errors.Errorf("qtumd is at height %d, %d blocks behind its headers", info.Blocks, behind)
+	}
+	return nil
+}
+
+// testQtumdSynced is the readiness check of qtumd being synced
+func (s *Server) testQtumdSynced() error {
+	ctx := s.qtumRPCClient.GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	info, err := s.qtumRPCClient.GetBlockChainInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get qtumd's sync status")
+	}
+	return qtumdSyncError(info)
+}
+
+func (s *Server) blockHashDatabaseStatus() BlockHashDatabaseStatus {
+	status := BlockHashDatabaseStatus{
+		Configured: s.qtumRPCClient.DbConfig.String() != "",
+		Connected:  s.blockHash != nil && s.blockHash.Connected(),
+	}
+	if capability, ok := s.qtumRPCClient.GetCapabilities().Statuses()[qtum.CapabilityBlockHashDatabase]; ok && !capability.Available {
+		status.Error = capability.Reason
+	}
+	return status
+}
+
+func (s *Server) cacheStatus(ctx context.Context) CacheStatus {
+	stats := s.qtumRPCClient.Stats()
+	status := CacheStatus{Reachable: true, Hits: stats.CacheHits, Misses: stats.CacheMisses}
+	backend := s.qtumRPCClient.CacheBackend()
+	switch backend.(type) {
+	case nil:
+		status.Backend = "memory"
+		entries := len(s.qtumRPCClient.CachedResponses())
+		status.Entries = &entries
+		return status
+	case *cache.RedisCache:
+		status.Backend = "redis"
+	default:
+		status.Backend = "external"
+	}
+	if pinger, ok := backend.(cachePinger); ok {
+		if err := pinger.Ping(ctx); err != nil {
+			// misses fall through to qtumd meanwhile
+			status.Reachable = false
+			status.Error = err.Error()
+		}
+	}
+	return status
+}
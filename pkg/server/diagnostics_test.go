@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestHealth(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	getHealth := func() (int, healthStatus) {
+		resp, err := http.Get(httpServer.URL + HealthPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var health healthStatus
+		if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, health
+	}
+
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Chain: "main", Blocks: 100, Headers: 500, Initialblockdownload: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Chain: "main", Blocks: 500, Headers: 501}); err != nil {
+		t.Fatal(err)
+	}
+
+	// answered by the last two checks
+	if err := mockedClientDoer.AddError(qtum.MethodGetBlockChainInfo, eth.NewJSONRPCError(-1, "connection refused", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	status, health := getHealth()
+	if status != http.StatusOK || health.Status != HealthDegraded {
+		t.Errorf("expected a syncing qtumd to be degraded, got %d %+v", status, health)
+	}
+	if !health.Qtumd.Reachable || health.Qtumd.Synced || health.Qtumd.Blocks != 100 || health.Qtumd.Headers != 500 || health.Qtumd.Error == "" {
+		t.Errorf("unexpected qtumd status %+v", health.Qtumd)
+	}
+	if health.Cache.Backend != "memory" || !health.Cache.Reachable || health.Cache.Entries == nil {
+		t.Errorf("unexpected cache status %+v", health.Cache)
+	}
+	// the database is only connected by Start
+	if !health.BlockHashDatabase.Configured || health.BlockHashDatabase.Connected {
+		t.Errorf("unexpected block hash database status %+v", health.BlockHashDatabase)
+	}
+
+	if err := s.testQtumdSynced(); err != nil {
+		t.Errorf("expected a qtumd one header ahead to be ready, got %v", err)
+	}
+
+	status, health = getHealth()
+	if status != http.StatusServiceUnavailable || health.Status != HealthDown || health.Qtumd.Reachable {
+		t.Errorf("expected an unreachable qtumd to be down, got %d %+v", status, health)
+	}
+	if err := s.testQtumdSynced(); err == nil {
+		t.Error("expected an unreachable qtumd to fail readiness")
+	}
+}
+
+func TestQtumdSyncError(t *testing.T) {
+	for _, test := range []struct {
+		info   qtum.GetBlockChainInfoResponse
+		synced bool
+	}{
+		{qtum.GetBlockChainInfoResponse{Chain: "main", Blocks: 100, Headers: 100}, true},
+		{qtum.GetBlockChainInfoResponse{Chain: "main", Blocks: 100, Headers: 103}, false},
+		{qtum.GetBlockChainInfoResponse{Chain: "main", Blocks: 100, Headers: 100, Initialblockdownload: true}, false},
+		// regtest stays in initial block download while no blocks are mined
+		{qtum.GetBlockChainInfoResponse{Chain: qtum.ChainRegTest, Blocks: 100, Headers: 100, Initialblockdownload: true}, true},
+	} {
+		if err := qtumdSyncError(test.info); (err == nil) != test.synced {
+			t.Errorf("expected %+v synced to be %v, got %v", test.info, test.synced, err)
+		}
+	}
+}
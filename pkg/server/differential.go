@@ -0,0 +1,279 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// DefaultDifferentialMethods are the read requests mirrored to the reference node by default,
+// methods whose result only depends on chain state that can be reproduced on an Ethereum node
+var DefaultDifferentialMethods = []string{
+	"eth_chainId",
+	"eth_getBlockByNumber",
+	"eth_getBlockByHash",
+	"eth_getTransactionByHash",
+	"eth_getTransactionByBlockHashAndIndex",
+	"eth_getTransactionByBlockNumberAndIndex",
+	"eth_getTransactionReceipt",
+	"eth_getLogs",
+	"eth_call",
+	"eth_estimateGas",
+	"eth_getBalance",
+	"eth_getCode",
+	"eth_getStorageAt",
+	"eth_getTransactionCount",
+}
+
+// at most this many differences are logged per response
+var maxDifferentialDifferences = 20
+
+// differential mirrors read requests to a reference Ethereum node with equivalent state and logs where its responses
+// differ from Janus'. Only encoding Janus' response happens before it is sent, the reference node is asked in the background
+// so clients never wait on it
+type differential struct {
+	url     string
+	methods map[string]bool
+	client  *http.Client
+	// bounds the mirrored requests in flight, requests are not mirrored while it is full
+	slots chan struct{}
+}
+
+func newDifferential(url string, methods []string) *differential {
+	if len(methods) == 0 {
+		methods = DefaultDifferentialMethods
+	}
+	d := &differential{
+		url:     url,
+		methods: make(map[string]bool, len(methods)),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		slots:   make(chan struct{}, 16),
+	}
+	for _, method := range methods {
+		d.methods[strings.TrimSpace(method)] = true
+	}
+	return d
+}
+
+// mirror encodes the response Janus is about to send and compares it with the reference node's in the background,
+// the result is encoded here so the background comparison never shares it with the handler
+func (d *differential) mirror(logger log.Logger, req *eth.JSONRPCRequest, result interface{}, jsonErr eth.JSONRPCError) {
+	if !d.methods[req.Method] {
+		return
+	}
+	logger = log.With(logger, "component", "differential")
+
+	// transformers can return an explicit JSON error as their result
+	if explicitErr, ok := result.(eth.JSONRPCError); ok && jsonErr == nil {
+		jsonErr = explicitErr
+	}
+
+	janus, err := json.Marshal(&eth.JSONRPCResult{JSONRPC: eth.RPCVersion, ID: req.ID, Error: jsonErr})
+	if jsonErr == nil && isStreamable(result) {
+		// checksummed while it is encoded
+		var buf bytes.Buffer
+		err = writeJSONRPCResult(&buf, req.ID, req.Method, result)
+		janus = buf.Bytes()
+	} else if jsonErr == nil {
+		var response *eth.JSONRPCResult
+		response, err = eth.NewJSONRPCResult(req.ID, result)
+		if err == nil {
+			janus, err = json.Marshal(response)
+		}
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "couldn't encode Janus response", "method", req.Method, "error", err)
+		return
+	}
+
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		level.Debug(logger).Log("msg", "too many mirrored requests in flight, skipping", "method", req.Method)
+		return
+	}
+
+	go func() {
+		defer func() { <-d.slots }()
+		d.compare(logger, req, janus)
+	}()
+}
+
+func (d *differential) compare(logger log.Logger, req *eth.JSONRPCRequest, janus []byte) {
+	reference, err := d.request(req)
+	if err != nil {
+		level.Warn(logger).Log("msg", "reference node request failed", "method", req.Method, "error", err)
+		return
+	}
+
+	differences, err := diffResponses(janus, reference)
+	if err != nil {
+		level.Warn(logger).Log("msg", "couldn't compare responses", "method", req.Method, "error", err)
+		return
+	}
+	if len(differences) == 0 {
+		level.Debug(logger).Log("msg", "responses match", "method", req.Method)
+		return
+	}
+
+	level.Warn(logger).Log(
+		"msg", "response differs from reference node",
+		"method", req.Method,
+		"params", string(req.Params),
+		"differences", strings.Join(differences, "; "),
+	)
+}
+
+func (d *differential) request(req *eth.JSONRPCRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.client.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("reference node returned %s", resp.Status)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// diffResponses lists where two JSON-RPC responses differ, error messages aren't compared since every client words them differently
+func diffResponses(janus, reference []byte) ([]string, error) {
+	var a, b struct {
+		Result interface{} `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(janus, &a); err != nil {
+		return nil, errors.Wrap(err, "janus response")
+	}
+	if err := json.Unmarshal(reference, &b); err != nil {
+		return nil, errors.Wrap(err, "reference response")
+	}
+
+	switch {
+	case a.Error != nil && b.Error != nil:
+		if a.Error.Code != b.Error.Code {
+			return []string{fmt.Sprintf("error code: janus %d, reference %d", a.Error.Code, b.Error.Code)}, nil
+		}
+		return nil, nil
+	case a.Error != nil:
+		return []string{fmt.Sprintf("error: janus returned error %d, reference returned a result", a.Error.Code)}, nil
+	case b.Error != nil:
+		return []string{fmt.Sprintf("error: janus returned a result, reference returned error %d", b.Error.Code)}, nil
+	}
+
+	var differences []string
+	diffJSON("result", a.Result, b.Result, &differences)
+	return differences, nil
+}
+
+func diffJSON(path string, janus, reference interface{}, differences *[]string) {
+	if len(*differences) >= maxDifferentialDifferences {
+		return
+	}
+
+	switch reference := reference.(type) {
+	case map[string]interface{}:
+		janus, ok := janus.(map[string]interface{})
+		if !ok {
+			*differences = append(*differences, fmt.Sprintf("%s: janus %s, reference %s", path, describeJSON(janus), describeJSON(reference)))
+			return
+		}
+		keys := make([]string, 0, len(reference)+len(janus))
+		for key := range reference {
+			keys = append(keys, key)
+		}
+		for key := range janus {
+			if _, ok := reference[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			janusValue, inJanus := janus[key]
+			referenceValue, inReference := reference[key]
+			switch {
+			case !inJanus:
+				*differences = append(*differences, fmt.Sprintf("%s.%s: missing from janus", path, key))
+			case !inReference:
+				*differences = append(*differences, fmt.Sprintf("%s.%s: missing from reference", path, key))
+			default:
+				diffJSON(path+"."+key, janusValue, referenceValue, differences)
+			}
+		}
+
+	case []interface{}:
+		janus, ok := janus.([]interface{})
+		if !ok || len(janus) != len(reference) {
+			*differences = append(*differences, fmt.Sprintf("%s: janus %s, reference %s", path, describeJSON(janus), describeJSON(reference)))
+			return
+		}
+		for i := range reference {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), janus[i], reference[i], differences)
+		}
+
+	case string:
+		// hex values are equal regardless of case
+		if janus, ok := janus.(string); !ok || !strings.EqualFold(janus, reference) {
+			*differences = append(*differences, fmt.Sprintf("%s: janus %s, reference %s", path, describeJSON(janus), describeJSON(reference)))
+		}
+
+	default:
+		if describeJSON(janus) != describeJSON(reference) {
+			*differences = append(*differences, fmt.Sprintf("%s: janus %s, reference %s", path, describeJSON(janus), describeJSON(reference)))
+		}
+	}
+}
+
+func describeJSON(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return fmt.Sprintf("array of %d", len(v))
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// SetDifferentialReference mirrors read requests to a reference Ethereum node and logs differences from its responses,
+// methods defaults to DefaultDifferentialMethods
+func SetDifferentialReference(url string, methods []string) Option {
+	return func(p *Server) error {
+		if url == "" {
+			p.differential = nil
+			return nil
+		}
+		p.differential = newDifferential(url, methods)
+		return nil
+	}
+}
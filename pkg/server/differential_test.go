@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+func TestDiffResponses(t *testing.T) {
+	tests := []struct {
+		janus     string
+		reference string
+		want      []string
+	}{
+		{`{"result":"0xABCD"}`, `{"result":"0xabcd"}`, nil},
+		{`{"result":null}`, `{"result":null}`, nil},
+		{`{"result":"0x1"}`, `{"result":"0x2"}`, []string{`result: janus "0x1", reference "0x2"`}},
+		{`{"error":{"code":-32000,"message":"a"}}`, `{"error":{"code":-32000,"message":"b"}}`, nil},
+		{`{"error":{"code":-32000}}`, `{"error":{"code":-32602}}`, []string{"error code: janus -32000, reference -32602"}},
+		{`{"error":{"code":-32000}}`, `{"result":null}`, []string{"error: janus returned error -32000, reference returned a result"}},
+		{
+			`{"result":{"number":"0x1","extra":true,"logs":[{"topics":["0xaa"]}]}}`,
+			`{"result":{"number":"0x1","nonce":"0x0","logs":[{"topics":["0xbb"]}]}}`,
+			[]string{
+				"result.extra: missing from reference",
+				`result.logs[0].topics[0]: janus "0xaa", reference "0xbb"`,
+				"result.nonce: missing from janus",
+			},
+		},
+		{`{"result":[1,2]}`, `{"result":[1]}`, []string{"result: janus array of 2, reference array of 1"}},
+	}
+
+	for _, test := range tests {
+		got, err := diffResponses([]byte(test.janus), []byte(test.reference))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, "; ") != strings.Join(test.want, "; ") {
+			t.Errorf("diffResponses(%s, %s) = %q, want %q", test.janus, test.reference, got, test.want)
+		}
+	}
+}
+
+func TestDifferentialMirror(t *testing.T) {
+	requests := make(chan string, 2)
+	reference := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		requests <- body.String()
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x2"}`))
+	}))
+	defer reference.Close()
+
+	var logs syncBuffer
+	d := newDifferential(reference.URL, []string{"eth_blockNumber"})
+
+	d.mirror(log.NewLogfmtLogger(&logs), &eth.JSONRPCRequest{JSONRPC: "2.0", ID: []byte("1"), Method: "eth_chainId"}, "0x1", nil)
+	d.mirror(log.NewLogfmtLogger(&logs), &eth.JSONRPCRequest{JSONRPC: "2.0", ID: []byte("1"), Method: "eth_blockNumber"}, "0x1", nil)
+
+	select {
+	case request := <-requests:
+		if !strings.Contains(request, "eth_blockNumber") {
+			t.Errorf("unexpected mirrored request %s", request)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request wasn't mirrored")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "response differs from reference node") {
+		if time.Now().After(deadline) {
+			t.Fatalf("difference wasn't logged: %s", logs.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(requests) != 0 {
+		t.Errorf("eth_chainId shouldn't be mirrored")
+	}
+}
+
+// syncBuffer is written to by the goroutines comparing responses
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
@@ -27,6 +27,7 @@ func httpHandler(c echo.Context) error {
 		return errors.New("Could not find myctx")
 	}
 
+	start := time.Now()
 	var rpcReq *eth.JSONRPCRequest
 	decoder := json.NewDecoder(c.Request().Body)
 	if err := decoder.Decode(&rpcReq); err != nil {
@@ -37,13 +38,24 @@ func httpHandler(c echo.Context) error {
 	}
 
 	cc.rpcReq = rpcReq
+	if cc.timings != nil {
+		cc.timings.Phase("decode", time.Since(start))
+	}
 
 	cc.GetLogger().Log("msg", "proxy RPC", "method", rpcReq.Method)
 
 	// level.Debug(cc.logger).Log("msg", "before call transformer#Transform")
-	result, err := cc.transformer.Transform(rpcReq, c)
+	start = time.Now()
+	result, err := cc.transform(c.Request().Context(), rpcReq, c)
+	if cc.timings != nil {
+		cc.timings.Phase("transform", time.Since(start))
+	}
 	// level.Debug(cc.logger).Log("msg", "after call transformer#Transform")
 
+	if cc.differential != nil {
+		cc.differential.mirror(cc.logger, rpcReq, result, err)
+	}
+
 	if err != nil {
 		if cc.ethAnalytics != nil {
 			defer cc.ethAnalytics.Failure()
@@ -216,15 +228,6 @@ const (
 	writeWait = 10 * time.Second
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:    4096,
-	WriteBufferSize:   4096,
-	EnableCompression: false,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
 func isBatchRequests(msg json.RawMessage) bool {
 	return len(msg) != 0 && msg[0] == '['
 }
@@ -234,7 +237,7 @@ func getRpcResponses(c echo.Context, cc *myCtx, rpcReqs []eth.JSONRPCRequest) ([
 	for _, rpcReq := range rpcReqs {
 		cc.rpcReq = &rpcReq
 
-		result, jsonError := cc.transformer.Transform(&rpcReq, c)
+		result, jsonError := cc.transform(c.Request().Context(), &rpcReq, c)
 
 		response := result
 
@@ -248,6 +251,8 @@ func getRpcResponses(c echo.Context, cc *myCtx, rpcReqs []eth.JSONRPCRequest) ([
 		// Allow transformer to return an explicit JSON error
 		if jerr, isJSONErr := response.(eth.JSONRPCError); isJSONErr {
 			response = cc.GetJSONRPCError(jerr)
+		} else if isStreamable(response) {
+			response = &streamedResponse{id: rpcReq.ID, method: rpcReq.Method, result: response}
 		} else {
 			var err error
 			response, err = cc.GetJSONRPCResult(response)
@@ -262,7 +267,18 @@ func getRpcResponses(c echo.Context, cc *myCtx, rpcReqs []eth.JSONRPCRequest) ([
 	return responses, nil
 }
 
+// websocketHandler serves JSON-RPC over websockets one message at a time, answering in the order requests came in
 func websocketHandler(c echo.Context) error {
+	return serveWebsocket(c, false)
+}
+
+// multiplexedWebsocketHandler serves the requests of a connection concurrently, each response is sent as soon as it
+// is ready and clients match it to its request by id
+func multiplexedWebsocketHandler(c echo.Context) error {
+	return serveWebsocket(c, true)
+}
+
+func serveWebsocket(c echo.Context, multiplexed bool) error {
 	myctx := c.Get("myctx")
 	cc, ok := myctx.(*myCtx)
 	if !ok {
@@ -275,12 +291,26 @@ func websocketHandler(c echo.Context) error {
 		h.Set("Sec-Websocket-Protocol", sub)
 		break
 	}
-	ws, err := upgrader.Upgrade(c.Response(), c.Request(), h)
+	ws, err := cc.upgrader.Upgrade(c.Response(), c.Request(), h)
 	if err != nil {
 		return err
 	} else {
 		cc.GetDebugLogger().Log("msg", "Got websocket request")
 	}
+	if err := cc.websocket.configure(ws); err != nil {
+		ws.Close()
+		return err
+	}
+	// draining stops reading requests, the connection is closed with a close frame once those in flight are answered
+	draining := make(chan struct{})
+	drainOnce := sync.Once{}
+	drain := func() {
+		drainOnce.Do(func() {
+			close(draining)
+			// fails the read of the next request
+			ws.SetReadDeadline(time.Now())
+		})
+	}
 	closeOnce := sync.Once{}
 	close := func() {
 		closeOnce.Do(func() {
@@ -293,12 +323,38 @@ func websocketHandler(c echo.Context) error {
 	stopPingPong := pingPong(ctx, ws, &writeMutex)
 	send := func(value []byte) error {
 		writeMutex.Lock()
+		defer writeMutex.Unlock()
 		ws.SetWriteDeadline(time.Now().Add(writeWait))
-		err := ws.WriteMessage(websocket.TextMessage, value)
-		writeMutex.Unlock()
-		return err
+		ws.EnableWriteCompression(cc.websocket.compress(len(value)))
+		// WriteMessage sends uncompressed messages as a single frame whatever their size
+		w, err := ws.NextWriter(websocket.TextMessage)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(value); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}
+	stream := func(response *streamedResponse) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		ws.SetWriteDeadline(time.Now().Add(writeWait))
+		ws.EnableWriteCompression(cc.websocket.compress(-1))
+		w, err := ws.NextWriter(websocket.TextMessage)
+		if err != nil {
+			return err
+		}
+		if err := writeJSONRPCResult(w, response.id, response.method, response.result); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
 	}
 
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
 	defer func() {
 		stopPingPong()
 		close()
@@ -315,27 +371,51 @@ func websocketHandler(c echo.Context) error {
 	)
 	c.Set("notifier", notifier)
 
-	for {
-		cc.GetDebugLogger().Log("msg", "reading websocket request")
-		_, req, err := ws.ReadMessage()
-		if err != nil {
-			cc.GetLogger().Log("msg", "Failed to read websocket message", "err", err)
-			return nil
+	if cc.connections != nil {
+		conn := &connection{
+			remoteAddr:  c.RealIP(),
+			userAgent:   c.Request().UserAgent(),
+			network:     cc.network,
+			connectedAt: time.Now(),
+			notifier:    notifier,
+			close:       close,
+			drain:       drain,
 		}
+		cc.connections.add(conn)
+		defer cc.connections.remove(conn.id)
+	}
+
+	handle := func(cc *myCtx, req []byte) error {
 		var rpcReqs []eth.JSONRPCRequest
 
 		isBatchedRequest := isBatchRequests(req)
 		if !isBatchedRequest {
 			var rpcReq eth.JSONRPCRequest
-			json.Unmarshal(req, &rpcReq)
+			err := json.Unmarshal(req, &rpcReq)
+			if err != nil && multiplexed {
+				// there's no id to match the response to, tell the client rather than leaving it waiting
+				return sendJSON(send, cc.GetJSONRPCError(eth.NewInvalidMessageError(err.Error())))
+			}
 			rpcReqs = append(rpcReqs, rpcReq)
-		} else {
-			json.Unmarshal(req, &rpcReqs)
+		} else if err := json.Unmarshal(req, &rpcReqs); err != nil && multiplexed {
+			return sendJSON(send, cc.GetJSONRPCError(eth.NewInvalidMessageError(err.Error())))
 		}
 
 		responses, err := getRpcResponses(c, cc, rpcReqs)
 		if err != nil {
-			cc.GetErrorLogger().Log("err", err.Error())
+			return err
+		}
+		// concurrent responses must not release the notifications of a subscription before its id is sent
+		responseSent := notifier.ResponseSent
+		if multiplexed && !hasMethod(rpcReqs, "eth_subscribe") {
+			responseSent = func() {}
+		}
+
+		if streamed, ok := singleStreamedResponse(isBatchedRequest, responses); ok && !cc.IsDebugEnabled() {
+			if err := stream(streamed); err != nil {
+				return err
+			}
+			responseSent()
 			return nil
 		}
 
@@ -351,31 +431,96 @@ func websocketHandler(c echo.Context) error {
 		responseBytes, err := json.Marshal(response)
 
 		if err != nil {
-			cc.GetErrorLogger().Log("err", err.Error())
-			return nil
+			return err
 		}
 
 		cc.GetDebugLogger().Log("response", string(responseBytes))
 
-		err = send(responseBytes)
-		if err == nil {
-			notifier.ResponseSent()
-
-			if cc.IsDebugEnabled() {
-				reqBody, err := qtum.ReformatJSON(req)
-				resBody, err := qtum.ReformatJSON(responseBytes)
-				if err == nil {
-					cc.GetDebugLogger().Log("msg", "ETH WEBSOCKET RPC")
-					fmt.Fprintf(cc.GetLogWriter(), "=> ETH request\n%s\n", reqBody)
-					fmt.Fprintf(cc.GetLogWriter(), "<= ETH response\n%s\n", resBody)
-				}
+		if err := send(responseBytes); err != nil {
+			return err
+		}
+		responseSent()
+
+		if cc.IsDebugEnabled() {
+			reqBody, err := qtum.ReformatJSON(redactRequestBody(req))
+			resBody, err := qtum.ReformatJSON(responseBytes)
+			if err == nil {
+				cc.GetDebugLogger().Log("msg", "ETH WEBSOCKET RPC")
+				fmt.Fprintf(cc.GetLogWriter(), "=> ETH request\n%s\n", reqBody)
+				fmt.Fprintf(cc.GetLogWriter(), "<= ETH response\n%s\n", resBody)
 			}
+		}
+		return nil
+	}
 
-		} else {
-			cc.GetErrorLogger().Log("err", err.Error())
+	slots := make(chan struct{}, cc.websocket.MaxConcurrentRequests)
+	for {
+		cc.GetDebugLogger().Log("msg", "reading websocket request")
+		_, req, err := ws.ReadMessage()
+		if err != nil {
+			select {
+			case <-draining:
+				inFlight.Wait()
+				writeMutex.Lock()
+				ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(writeWait))
+				writeMutex.Unlock()
+				cc.GetDebugLogger().Log("msg", "Websocket connection drained")
+			default:
+				cc.GetLogger().Log("msg", "Failed to read websocket message", "err", err)
+			}
 			return nil
 		}
+
+		if !multiplexed {
+			if err := handle(cc, req); err != nil {
+				cc.GetErrorLogger().Log("err", err.Error())
+				return nil
+			}
+			continue
+		}
+
+		// reading waits while the connection has as many requests in flight as it may
+		slots <- struct{}{}
+		inFlight.Add(1)
+		go func(req []byte) {
+			defer func() {
+				<-slots
+				inFlight.Done()
+			}()
+			// each request gets its own context, the connection's one keeps track of a single request
+			requestCtx := *cc
+			requestCtx.rpcReq = nil
+			if err := handle(&requestCtx, req); err != nil {
+				cc.GetErrorLogger().Log("err", err.Error())
+				close()
+			}
+		}(req)
+	}
+}
+
+func sendJSON(send func([]byte) error, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return send(bytes)
+}
+
+func hasMethod(rpcReqs []eth.JSONRPCRequest, method string) bool {
+	for _, rpcReq := range rpcReqs {
+		if rpcReq.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func singleStreamedResponse(isBatchedRequest bool, responses []interface{}) (*streamedResponse, bool) {
+	if isBatchedRequest || len(responses) != 1 {
+		return nil, false
 	}
+	streamed, ok := responses[0].(*streamedResponse)
+	return streamed, ok
 }
 
 func errorHandler(err error, c echo.Context) {
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// longest a keep-alive probe waits for qtumd
+var maximumKeepAliveTimeout = 10 * time.Second
+
+// keepAlive pings qtumd with getblockcount at a fixed interval, keeping pooled connections warm through idle periods
+// and noticing connections a NAT or load balancer dropped silently before user requests run into them
+type keepAlive struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mutex    sync.RWMutex
+	lastErr  error
+	failures int
+}
+
+func newKeepAlive(interval time.Duration) *keepAlive {
+	timeout := interval
+	if timeout > maximumKeepAliveTimeout {
+		timeout = maximumKeepAliveTimeout
+	}
+	return &keepAlive{interval: interval, timeout: timeout}
+}
+
+func (k *keepAlive) run(ctx context.Context, s *Server) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k.probe(ctx, s)
+		}
+	}
+}
+
+func (k *keepAlive) probe(ctx context.Context, s *Server) error {
+	ctx, cancel := context.WithTimeout(ctx, k.timeout)
+	defer cancel()
+	_, err := s.qtumRPCClient.GetBlockCount(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "qtumd keep-alive probe failed")
+		// pooled connections may all be dead, the next requests dial new ones
+		s.qtumRPCClient.CloseIdleConnections()
+	}
+
+	k.mutex.Lock()
+	failures := k.failures
+	if err != nil {
+		k.failures++
+	} else {
+		k.failures = 0
+	}
+	k.lastErr = err
+	k.mutex.Unlock()
+
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "qtumd didn't answer the keep-alive probe", "failures", failures+1, "error", err)
+	} else if failures != 0 {
+		level.Info(s.logger).Log("msg", "qtumd answers keep-alive probes again", "after", failures)
+	}
+	return err
+}
+
+// status is the outcome of the last probe, for the liveness check
+func (k *keepAlive) status() error {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.lastErr
+}
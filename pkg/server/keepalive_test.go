@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestKeepAliveProbe(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetKeepAlive(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.keepAlive.status(); err != nil {
+		t.Errorf("expected no failure before the first probe, got %v", err)
+	}
+
+	if err := mockedClientDoer.AddError(qtum.MethodGetBlockCount, eth.NewJSONRPCError(-1, "connection reset", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.keepAlive.probe(context.Background(), s); err == nil {
+		t.Fatal("expected the probe to fail")
+	}
+	if s.keepAlive.status() == nil {
+		t.Error("expected the liveness check to fail after a failed probe")
+	}
+
+	if err := s.keepAlive.probe(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.keepAlive.status(); err != nil {
+		t.Errorf("expected the liveness check to recover, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// MetricsPath serves Prometheus metrics, when enabled with SetMetrics
+const MetricsPath = "/metrics"
+
+// unknownMethod labels the requests for methods no transformer handles, so clients can't add labels at will
+const unknownMethod = "unknown"
+
+// metrics of the eth requests served, the qtumd clients and the websocket connections
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+func newMetrics(s *Server) *metrics {
+	labels := []string{"network", "method"}
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "janus_eth_requests_total",
+			Help: "eth JSON-RPC requests served, by method",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "janus_eth_request_errors_total",
+			Help: "eth JSON-RPC requests answered with an error, by method and error code",
+		}, append(labels, "code")),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "janus_eth_request_duration_seconds",
+			Help:    "time spent translating eth JSON-RPC requests, by method",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.errors,
+		m.latency,
+		&qtumCollector{server: s},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observe records a request translated by the transformer in the network named network
+func (m *metrics) observe(network string, method string, duration time.Duration, jsonErr eth.JSONRPCError) {
+	m.requests.WithLabelValues(network, method).Inc()
+	m.latency.WithLabelValues(network, method).Observe(duration.Seconds())
+	if jsonErr != nil {
+		m.errors.WithLabelValues(network, method, strconv.Itoa(jsonErr.Code())).Inc()
+	}
+}
+
+func (m *metrics) handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+var (
+	qtumdCallsDesc = prometheus.NewDesc(
+		"janus_qtumd_calls_total", "RPC calls sent to qtumd, by method", []string{"network", "method"}, nil)
+	cacheHitsDesc = prometheus.NewDesc(
+		"janus_qtumd_cache_hits_total", "qtumd responses served from the cache", []string{"network"}, nil)
+	cacheMissesDesc = prometheus.NewDesc(
+		"janus_qtumd_cache_misses_total", "qtumd responses of cachable methods that weren't in the cache", []string{"network"}, nil)
+	cacheHitRatioDesc = prometheus.NewDesc(
+		"janus_qtumd_cache_hit_ratio", "share of cachable qtumd responses served from the cache", []string{"network"}, nil)
+	retriesDesc = prometheus.NewDesc(
+		"janus_qtumd_retries_total", "qtumd calls sent again after backing off", []string{"network"}, nil)
+	mismatchesDesc = prometheus.NewDesc(
+		"janus_qtumd_response_mismatches_total", "qtumd responses refused as not answering their request", []string{"network"}, nil)
+	upstreamHealthyDesc = prometheus.NewDesc(
+		"janus_qtumd_upstream_healthy", "whether a qtumd node of --qtum-rpc-upstreams gets calls", []string{"network", "upstream"}, nil)
+	connectionsDesc = prometheus.NewDesc(
+		"janus_websocket_connections", "open websocket connections", nil, nil)
+	subscriptionsDesc = prometheus.NewDesc(
+		"janus_websocket_subscriptions", "eth_subscribe subscriptions of the open websocket connections", nil, nil)
+)
+
+// qtumCollector reads the counters of the qtumd clients and the websocket connections when metrics are scraped
+type qtumCollector struct {
+	server *Server
+}
+
+func (c *qtumCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{qtumdCallsDesc, cacheHitsDesc, cacheMissesDesc, cacheHitRatioDesc, retriesDesc, mismatchesDesc, upstreamHealthyDesc, connectionsDesc, subscriptionsDesc} {
+		ch <- desc
+	}
+}
+
+func (c *qtumCollector) Collect(ch chan<- prometheus.Metric) {
+	collectStats(ch, "", c.server.qtumRPCClient)
+	for _, network := range c.server.networks {
+		collectStats(ch, network.Name, network.QtumClient)
+	}
+
+	statuses := c.server.connections.statuses()
+	subscriptions := 0
+	for _, status := range statuses {
+		subscriptions += len(status.Subscriptions)
+	}
+	ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, float64(len(statuses)))
+	ch <- prometheus.MustNewConstMetric(subscriptionsDesc, prometheus.GaugeValue, float64(subscriptions))
+}
+
+func collectStats(ch chan<- prometheus.Metric, network string, client *qtum.Qtum) {
+	if client == nil {
+		return
+	}
+	stats := client.Stats()
+	for method, calls := range stats.Calls {
+		ch <- prometheus.MustNewConstMetric(qtumdCallsDesc, prometheus.CounterValue, float64(calls), network, method)
+	}
+	ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.CacheHits), network)
+	ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.CacheMisses), network)
+	ratio := 0.0
+	if lookups := stats.CacheHits + stats.CacheMisses; lookups != 0 {
+		ratio = float64(stats.CacheHits) / float64(lookups)
+	}
+	ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, ratio, network)
+	ch <- prometheus.MustNewConstMetric(retriesDesc, prometheus.CounterValue, float64(stats.Retries), network)
+	ch <- prometheus.MustNewConstMetric(mismatchesDesc, prometheus.CounterValue, float64(stats.ResponseMismatches), network)
+	for _, upstream := range client.Upstreams() {
+		healthy := 0.0
+		if upstream.Healthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(upstreamHealthyDesc, prometheus.GaugeValue, healthy, network, upstream.URL)
+	}
+}
+
+// SetMetrics serves Prometheus metrics of the requests, qtumd and websocket connections at MetricsPath
+func SetMetrics(enabled bool) Option {
+	return func(p *Server) error {
+		if enabled {
+			p.metrics = newMetrics(p)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestMetrics(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, 11284900); err != nil {
+		t.Fatal(err)
+	}
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&transformer.ProxyETHBlockNumber{Qtum: qtumClient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(qtumClient, proxyTransformer, SetMetrics(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{"eth_blockNumber", "eth_blockNumber", "made_up"} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, expected := range []string{
+		`janus_eth_requests_total{method="eth_blockNumber",network=""} 2`,
+		`janus_eth_requests_total{method="unknown",network=""} 1`,
+		`janus_eth_request_errors_total{code="-32601",method="unknown",network=""} 1`,
+		`janus_eth_request_duration_seconds_count{method="eth_blockNumber",network=""} 2`,
+		`janus_qtumd_calls_total{method="getblockcount",network=""} 2`,
+		`janus_websocket_connections 0`,
+	} {
+		if !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("expected %s in metrics\n%s", expected, rec.Body.String())
+		}
+	}
+}
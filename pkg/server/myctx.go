@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/analytics"
 	"github.com/qtumproject/janus/pkg/blockhash"
 	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
 	"github.com/qtumproject/janus/pkg/transformer"
 )
 
@@ -23,6 +27,41 @@ type myCtx struct {
 	blockHash     *blockhash.BlockHash
 	qtumAnalytics *analytics.Analytics
 	ethAnalytics  *analytics.Analytics
+	differential  *differential
+	// set when the janus_timings debug extension is enabled
+	timings *qtum.Timings
+	// server configured timeouts and the one from the X-Janus-Timeout header
+	deadlines     *deadlines
+	headerTimeout time.Duration
+	// compression and framing of websocket connections
+	websocket WebsocketConfig
+	upgrader  *websocket.Upgrader
+	// open websocket connections listed by the admin API
+	connections *connections
+	// name of the additional network served, empty for the default one
+	network string
+	// nil unless metrics are served
+	metrics *metrics
+	// nil unless requests are rate limited
+	rateLimiter *rateLimiter
+	// whether the request can call the protected methods
+	authenticated bool
+}
+
+// TimingsHeader carries the janus_timings extension of a response
+const TimingsHeader = "X-Janus-Timings"
+
+type timedJSONRPCResult struct {
+	*eth.JSONRPCResult
+	Timings *qtum.Timings `json:"janus_timings"`
+}
+
+func (c *myCtx) jsonWithTimings(response *eth.JSONRPCResult) error {
+	if c.timings == nil {
+		return c.JSON(http.StatusOK, response)
+	}
+	c.Response().Header().Set(TimingsHeader, c.timings.Header())
+	return c.JSON(http.StatusOK, &timedJSONRPCResult{JSONRPCResult: response, Timings: c.timings})
 }
 
 func (c *myCtx) GetJSONRPCResult(result interface{}) (*eth.JSONRPCResult, error) {
@@ -30,12 +69,26 @@ func (c *myCtx) GetJSONRPCResult(result interface{}) (*eth.JSONRPCResult, error)
 }
 
 func (c *myCtx) JSONRPCResult(result interface{}) error {
+	if isStreamable(result) {
+		return c.streamJSONRPCResult(result)
+	}
+
 	response, err := c.GetJSONRPCResult(result)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.jsonWithTimings(response)
+}
+
+func (c *myCtx) streamJSONRPCResult(result interface{}) error {
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	if c.timings != nil {
+		response.Header().Set(TimingsHeader, c.timings.Header())
+	}
+	response.WriteHeader(http.StatusOK)
+	return writeJSONRPCResult(response, c.rpcReq.ID, c.rpcReq.Method, result)
 }
 
 func (c *myCtx) GetJSONRPCError(err eth.JSONRPCError) *eth.JSONRPCResult {
@@ -54,7 +107,8 @@ func (c *myCtx) JSONRPCError(err eth.JSONRPCError) error {
 	resp := c.GetJSONRPCError(err)
 
 	if !c.Response().Committed {
-		err := c.JSON(http.StatusOK, resp)
+		setRetryAfter(c.Response().Header(), err)
+		err := c.jsonWithTimings(resp)
 		c.logger.Log("Internal server error", err)
 		return err
 	}
@@ -92,3 +146,22 @@ func (c *myCtx) GetErrorLogger() log.Logger {
 func (c *myCtx) IsDebugEnabled() bool {
 	return c.transformer.IsDebugEnabled()
 }
+
+// setRetryAfter sets the Retry-After header of limit exceeded and starting up errors, for http clients that honour it
+func setRetryAfter(header http.Header, err eth.JSONRPCError) {
+	withData, ok := err.(interface{ Data() interface{} })
+	if !ok {
+		return
+	}
+	var retryAfterMs int64
+	switch data := withData.Data().(type) {
+	case eth.LimitExceededData:
+		retryAfterMs = data.RetryAfterMs
+	case eth.StartingData:
+		retryAfterMs = data.RetryAfterMs
+	default:
+		return
+	}
+	seconds := (retryAfterMs + 999) / 1000
+	header.Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
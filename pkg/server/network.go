@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// Network is an additional qtum network served by the same process
+// Requests are routed to a network by the first path segment (/<name>) or by the Host header
+type Network struct {
+	Name        string
+	Hosts       []string
+	QtumClient  *qtum.Qtum
+	Transformer *transformer.Transformer
+}
+
+func (n *Network) matchesPath(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	if index := strings.Index(path, "/"); index != -1 {
+		path = path[:index]
+	}
+	return path != "" && path == n.Name
+}
+
+func (n *Network) matchesHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, h := range n.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolves the network a request is for, nil means the default network
+func (s *Server) resolveNetwork(host string, path string) *Network {
+	for _, network := range s.networks {
+		if network.matchesPath(path) {
+			return network
+		}
+	}
+	for _, network := range s.networks {
+		if network.matchesHost(host) {
+			return network
+		}
+	}
+	return nil
+}
+
+func SetNetworks(networks ...*Network) Option {
+	return func(p *Server) error {
+		names := make(map[string]bool)
+		for _, network := range networks {
+			if network.Name == "" {
+				return errors.New("network name cannot be empty")
+			}
+			if network.QtumClient == nil || network.Transformer == nil {
+				return errors.Errorf("network %s must have a qtum client and a transformer", network.Name)
+			}
+			if names[network.Name] {
+				return errors.Errorf("network %s configured more than once", network.Name)
+			}
+			names[network.Name] = true
+		}
+		p.networks = networks
+		return nil
+	}
+}
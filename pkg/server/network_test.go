@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestResolveNetwork(t *testing.T) {
+	testnet := &Network{Name: "testnet", Hosts: []string{"testnet.example.com"}}
+	regtest := &Network{Name: "regtest"}
+	s := &Server{networks: []*Network{testnet, regtest}}
+
+	tests := []struct {
+		host string
+		path string
+		want *Network
+	}{
+		{"localhost:23889", "/", nil},
+		{"localhost:23889", "/testnet", testnet},
+		{"localhost:23889", "/testnet/", testnet},
+		{"localhost:23889", "/regtest/api", regtest},
+		{"localhost:23889", "/testnetwork", nil},
+		{"testnet.example.com", "/", testnet},
+		{"TESTNET.example.com:443", "/", testnet},
+		{"testnet.example.com", "/regtest", regtest},
+	}
+
+	for _, test := range tests {
+		got := s.resolveNetwork(test.host, test.path)
+		if got != test.want {
+			t.Errorf("resolveNetwork(%q, %q) = %v, want %v", test.host, test.path, got, test.want)
+		}
+	}
+}
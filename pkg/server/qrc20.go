@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// QRC20Path serves the metadata and balances of QRC20 tokens over REST, when enabled
+const QRC20Path = "/qrc20"
+
+// the selectors of the QRC20 functions the API calls
+var (
+	qrc20Name        = "06fdde03"
+	qrc20Symbol      = "95d89b41"
+	qrc20Decimals    = "313ce567"
+	qrc20TotalSupply = "18160ddd"
+	qrc20BalanceOf   = "70a08231"
+)
+
+var errNoContract = errors.New("no contract at address")
+
+var qrc20StringArguments = func() abi.Arguments {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Type: stringType}}
+}()
+
+// QRC20Info is the metadata of a token, name, symbol and decimals are optional in the standard and left out when the
+// contract doesn't have them
+type QRC20Info struct {
+	Address  string `json:"address"`
+	Name     string `json:"name,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals *int   `json:"decimals,omitempty"`
+	// in the token's smallest unit, as a decimal string
+	TotalSupply string `json:"totalSupply"`
+}
+
+// QRC20Balance is the balance of an address in a token
+type QRC20Balance struct {
+	Contract string `json:"contract"`
+	Address  string `json:"address"`
+	// in the token's smallest unit, as a decimal string
+	Balance string `json:"balance"`
+}
+
+func qrc20Error(c echo.Context, status int, message string) error {
+	return c.JSON(status, map[string]string{"message": message})
+}
+
+// qrc20Failed turns the error of a call to the token into a response, errors of qtumd not caused by the request are
+// logged rather than passed on
+func qrc20Failed(c echo.Context, err error) error {
+	switch errors.Cause(err) {
+	case errNoContract:
+		return qrc20Error(c, http.StatusNotFound, err.Error())
+	case qtum.ErrInvalidAddress, qtum.ErrInvalidParameter, qtum.ErrTypeError:
+		return qrc20Error(c, http.StatusBadRequest, "invalid request")
+	}
+	if c.Request().Context().Err() == context.DeadlineExceeded {
+		return qrc20Error(c, http.StatusGatewayTimeout, "request timed out")
+	}
+	if cc, ok := c.Get("myctx").(*myCtx); ok {
+		cc.GetErrorLogger().Log("msg", "token API call failed", "path", c.Request().URL.Path, "error", err)
+	}
+	return qrc20Error(c, http.StatusBadGateway, "qtumd failed to answer")
+}
+
+// tokenAPI serves an endpoint of the token APIs within the rate limits and timeouts of RPC requests, method names the
+// endpoint in --method-rate-limit and --method-timeout
+func tokenAPI(method string, handler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cc, ok := c.Get("myctx").(*myCtx)
+		if !ok {
+			return handler(c)
+		}
+		if cc.rateLimiter != nil {
+			if jsonErr := cc.rateLimiter.allow(cc.rateLimiter.clientIP(c.Request()), method); jsonErr != nil {
+				setRetryAfter(c.Response().Header(), jsonErr)
+				return qrc20Error(c, http.StatusTooManyRequests, jsonErr.Message())
+			}
+		}
+		if jsonErr := cc.authorize(method); jsonErr != nil {
+			return qrc20Error(c, http.StatusUnauthorized, jsonErr.Message())
+		}
+		ctx, cancel, _, jsonErr := cc.withDeadline(c.Request().Context(), &eth.JSONRPCRequest{Method: method})
+		defer cancel()
+		if jsonErr != nil {
+			return qrc20Error(c, http.StatusBadRequest, jsonErr.Message())
+		}
+		c.SetRequest(c.Request().WithContext(ctx))
+		return handler(c)
+	}
+}
+
+func (s *Server) serveQRC20Info(c echo.Context) error {
+	contract, ok := qrc20Contract(c.Param("contract"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid contract address "+c.Param("contract"))
+	}
+	ctx := c.Request().Context()
+
+	totalSupply, reverted, err := s.qrc20Call(ctx, contract, qrc20TotalSupply)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	if reverted || len(totalSupply) < 32 {
+		return qrc20Error(c, http.StatusNotFound, "contract "+utils.AddHexPrefix(contract)+" isn't a QRC20 token")
+	}
+	info := QRC20Info{
+		Address:     utils.AddHexPrefix(contract),
+		TotalSupply: new(big.Int).SetBytes(totalSupply[:32]).String(),
+	}
+
+	for _, field := range []struct {
+		selector string
+		value    *string
+	}{{qrc20Name, &info.Name}, {qrc20Symbol, &info.Symbol}} {
+		output, reverted, err := s.qrc20Call(ctx, contract, field.selector)
+		if err != nil {
+			return qrc20Failed(c, err)
+		}
+		if !reverted {
+			*field.value = decodeQRC20String(output)
+		}
+	}
+
+	output, reverted, err := s.qrc20Call(ctx, contract, qrc20Decimals)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	if !reverted && len(output) >= 32 {
+		if decimals := new(big.Int).SetBytes(output[:32]); decimals.IsUint64() && decimals.Uint64() <= 255 {
+			value := int(decimals.Uint64())
+			info.Decimals = &value
+		}
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+func (s *Server) serveQRC20Balance(c echo.Context) error {
+	contract, ok := qrc20Contract(c.Param("contract"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid contract address "+c.Param("contract"))
+	}
+	address, ok := s.holderAddress(c.Param("address"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid address "+c.Param("address"))
+	}
+
+	output, reverted, err := s.qrc20Call(c.Request().Context(), contract, qrc20BalanceOf+strings.Repeat("0", 24)+address)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	if reverted || len(output) < 32 {
+		return qrc20Error(c, http.StatusNotFound, "contract "+utils.AddHexPrefix(contract)+" isn't a QRC20 token")
+	}
+	return c.JSON(http.StatusOK, QRC20Balance{
+		Contract: utils.AddHexPrefix(contract),
+		Address:  utils.AddHexPrefix(address),
+		Balance:  new(big.Int).SetBytes(output[:32]).String(),
+	})
+}
+
+// qrc20Contract is the hex address of a contract, lower case without 0x
+func qrc20Contract(address string) (string, bool) {
+	if !common.IsHexAddress(address) {
+		return "", false
+	}
+	return strings.ToLower(utils.RemoveHexPrefix(address)), true
+}
+
+// holderAddress is the hex address of a token holder given as hex or as the base58 address of a QTUM holder, lower
+// case without 0x
+func (s *Server) holderAddress(address string) (string, bool) {
+	if !common.IsHexAddress(address) {
+		hexAddress, err := s.qtumRPCClient.Base58AddressToHex(address)
+		if err != nil {
+			return "", false
+		}
+		address = hexAddress
+	}
+	return strings.ToLower(utils.RemoveHexPrefix(address)), true
+}
+
+// qrc20Call runs callcontract with data on the contract and returns its output, or whether the call reverted
+func (s *Server) qrc20Call(ctx context.Context, contract string, data string) ([]byte, bool, error) {
+	resp, err := s.qtumRPCClient.CallContract(ctx, &qtum.CallContractRequest{To: contract, Data: data})
+	return decodeCallContract(resp, err)
+}
+
+// decodeCallContract is the output of a callcontract call, or whether it reverted
+func decodeCallContract(resp *qtum.CallContractResponse, err error) ([]byte, bool, error) {
+	if err != nil {
+		if errors.Cause(err) == qtum.ErrInvalidAddress {
+			return nil, false, errNoContract
+		}
+		return nil, false, err
+	}
+	if resp.ExecutionResult.Excepted != "None" {
+		return nil, true, nil
+	}
+	output, err := hex.DecodeString(resp.ExecutionResult.Output)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "invalid output")
+	}
+	return output, false, nil
+}
+
+// decodeQRC20String decodes the name or symbol of a token, an ABI encoded string or a bytes32 for tokens older than
+// the standard
+func decodeQRC20String(output []byte) string {
+	if values, err := qrc20StringArguments.Unpack(output); err == nil {
+		return values[0].(string)
+	}
+	if len(output) == 32 {
+		return string(bytes.TrimRight(output, "\x00"))
+	}
+	return ""
+}
+
+// SetQRC20API serves the metadata and balances of QRC20 tokens at QRC20Path
+func SetQRC20API(enabled bool) Option {
+	return func(p *Server) error {
+		p.qrc20 = enabled
+		return nil
+	}
+}
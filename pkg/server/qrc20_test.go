@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+const qrc20TestContract = "0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c"
+
+func callContractOutput(excepted string, output string) json.RawMessage {
+	return json.RawMessage(`{"address": "6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c", "executionResult": {"excepted": "` + excepted + `", "output": "` + output + `"}}`)
+}
+
+func newTokenTestServer(t *testing.T) (*httptest.Server, func(string, interface{})) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetQRC20API(true), SetQRC721API(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+	addResponse := func(method string, result interface{}) {
+		if err := mockedClientDoer.AddResponse(method, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return httpServer, addResponse
+}
+
+func getJSON(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestQRC20Info(t *testing.T) {
+	httpServer, addResponse := newTokenTestServer(t)
+	// totalSupply, name as a string, symbol as a bytes32 and decimals
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "00000000000000000000000000000000000000000000000000000000000f4240"))
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000a5465737420546f6b656e00000000000000000000000000000000000000000000"))
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "5454000000000000000000000000000000000000000000000000000000000000"))
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "0000000000000000000000000000000000000000000000000000000000000008"))
+
+	var info QRC20Info
+	if status := getJSON(t, httpServer.URL+QRC20Path+"/"+qrc20TestContract+"/info", &info); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if info.Address != qrc20TestContract || info.Name != "Test Token" || info.Symbol != "TT" || info.TotalSupply != "1000000" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.Decimals == nil || *info.Decimals != 8 {
+		t.Errorf("expected 8 decimals, got %v", info.Decimals)
+	}
+
+	if status := getJSON(t, httpServer.URL+QRC20Path+"/notanaddress/info", nil); status != http.StatusBadRequest {
+		t.Errorf("expected an invalid contract address to be rejected, got %d", status)
+	}
+}
+
+func TestQRC20Balance(t *testing.T) {
+	httpServer, addResponse := newTokenTestServer(t)
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "00000000000000000000000000000000000000000000000000000000000003e8"))
+
+	var balance QRC20Balance
+	url := httpServer.URL + QRC20Path + "/" + qrc20TestContract + "/balanceOf/0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960"
+	if status := getJSON(t, url, &balance); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	want := QRC20Balance{Contract: qrc20TestContract, Address: "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", Balance: "1000"}
+	if balance != want {
+		t.Errorf("expected %+v, got %+v", want, balance)
+	}
+}
+
+func TestQRC20NotAToken(t *testing.T) {
+	httpServer, addResponse := newTokenTestServer(t)
+	addResponse(qtum.MethodCallContract, callContractOutput("Revert", ""))
+
+	if status := getJSON(t, httpServer.URL+QRC20Path+"/"+qrc20TestContract+"/info", nil); status != http.StatusNotFound {
+		t.Errorf("expected a contract without totalSupply to be not found, got %d", status)
+	}
+	url := httpServer.URL + QRC20Path + "/" + qrc20TestContract + "/balanceOf/0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	if status := getJSON(t, url, nil); status != http.StatusNotFound {
+		t.Errorf("expected a contract without balanceOf to be not found, got %d", status)
+	}
+}
+
+func TestQRC20Failures(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetQRC20API(true), SetRateLimits(RateLimits{Methods: map[string]float64{"qrc20_info": 0.001}, Burst: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+	url := httpServer.URL + QRC20Path + "/" + qrc20TestContract + "/info"
+
+	if err := mockedClientDoer.AddError(qtum.MethodCallContract, eth.NewJSONRPCError(-8, "Invalid data (data not hex)", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddError(qtum.MethodCallContract, eth.NewCallbackError("internal qtumd failure at /home/qtum/.qtum")); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := getJSON(t, url, nil); status != http.StatusBadRequest {
+		t.Errorf("expected qtumd refusing the parameters to be a bad request, got %d", status)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadGateway || strings.Contains(body["message"], "qtum/.qtum") {
+		t.Errorf("expected a generic 502 hiding qtumd's error, got %d %q", resp.StatusCode, body["message"])
+	}
+
+	if status := getJSON(t, url, nil); status != http.StatusTooManyRequests {
+		t.Errorf("expected the endpoint's rate limit to apply, got %d", status)
+	}
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// QRC721Path serves the tokens of QRC721 contracts over REST, when enabled
+const QRC721Path = "/qrc721"
+
+// the selectors of the QRC721 functions the API calls
+var (
+	qrc721OwnerOf  = "6352211e"
+	qrc721TokenURI = "c87b56dd"
+)
+
+// qrc721TransferTopic is the topic of Transfer(address,address,uint256), QRC20 tokens log it too but without the
+// value indexed
+const qrc721TransferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// qrc721BatchSize bounds the callcontract calls sent to qtumd in one batch
+const qrc721BatchSize = 100
+
+// qrc721MaxBlocks bounds the blocks whose Transfer logs one request searches, longer histories are paged through with
+// fromBlock and toBlock
+const qrc721MaxBlocks = 100000
+
+// QRC721Token is a token of a contract, tokenURI is left out when the contract doesn't have it
+type QRC721Token struct {
+	// a decimal string
+	TokenID  string `json:"tokenId"`
+	Owner    string `json:"owner,omitempty"`
+	TokenURI string `json:"tokenURI,omitempty"`
+}
+
+// QRC721Tokens are the tokens of a contract an address owns, out of the ones it received in the blocks searched
+type QRC721Tokens struct {
+	Contract  string        `json:"contract"`
+	Owner     string        `json:"owner"`
+	FromBlock int64         `json:"fromBlock"`
+	ToBlock   int64         `json:"toBlock"`
+	Tokens    []QRC721Token `json:"tokens"`
+}
+
+// qrc721Result is the output of a call to a token contract
+type qrc721Result struct {
+	output   []byte
+	reverted bool
+}
+
+func (s *Server) serveQRC721Token(c echo.Context) error {
+	contract, ok := qrc20Contract(c.Param("contract"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid contract address "+c.Param("contract"))
+	}
+	tokenID, ok := new(big.Int).SetString(c.Param("tokenId"), 0)
+	if !ok || tokenID.Sign() < 0 || tokenID.BitLen() > 256 {
+		return qrc20Error(c, http.StatusBadRequest, "invalid token id "+c.Param("tokenId"))
+	}
+	ctx := c.Request().Context()
+
+	owners, err := s.qrc721Calls(ctx, contract, qrc721OwnerOf, []*big.Int{tokenID})
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	if owners[0].reverted || len(owners[0].output) < 32 {
+		return qrc20Error(c, http.StatusNotFound, fmt.Sprintf("token %s of %s doesn't exist", tokenID, utils.AddHexPrefix(contract)))
+	}
+	token := QRC721Token{
+		TokenID: tokenID.String(),
+		Owner:   utils.AddHexPrefix(qrc721Address(owners[0].output)),
+	}
+	uris, err := s.qrc721Calls(ctx, contract, qrc721TokenURI, []*big.Int{tokenID})
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	if !uris[0].reverted {
+		token.TokenURI = decodeQRC20String(uris[0].output)
+	}
+	return c.JSON(http.StatusOK, token)
+}
+
+// serveQRC721Tokens lists the tokens an address owns, the tokens it received between fromBlock and toBlock according
+// to Transfer logs that it still owns according to ownerOf
+func (s *Server) serveQRC721Tokens(c echo.Context) error {
+	contract, ok := qrc20Contract(c.Param("contract"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid contract address "+c.Param("contract"))
+	}
+	owner, ok := s.holderAddress(c.Param("address"))
+	if !ok {
+		return qrc20Error(c, http.StatusBadRequest, "invalid address "+c.Param("address"))
+	}
+	param := c.QueryParam("fromBlock")
+	if param == "" {
+		return qrc20Error(c, http.StatusBadRequest, "fromBlock is required, pass the block the contract was deployed in")
+	}
+	fromBlock, ok := new(big.Int).SetString(param, 0)
+	if !ok || fromBlock.Sign() < 0 || !fromBlock.IsInt64() {
+		return qrc20Error(c, http.StatusBadRequest, "invalid fromBlock "+param)
+	}
+	ctx := c.Request().Context()
+
+	blockCount, err := s.qtumRPCClient.GetBlockCount(ctx)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	toBlock := blockCount.Int
+	if param := c.QueryParam("toBlock"); param != "" {
+		if toBlock, ok = new(big.Int).SetString(param, 0); !ok || toBlock.Sign() < 0 || toBlock.Cmp(blockCount.Int) > 0 {
+			return qrc20Error(c, http.StatusBadRequest, "invalid toBlock "+param)
+		}
+	}
+	if toBlock.Cmp(fromBlock) < 0 {
+		return qrc20Error(c, http.StatusBadRequest, "toBlock is before fromBlock")
+	}
+	if new(big.Int).Sub(toBlock, fromBlock).Cmp(big.NewInt(qrc721MaxBlocks)) >= 0 {
+		return qrc20Error(c, http.StatusBadRequest, fmt.Sprintf("can't search more than %d blocks at once, page through them with toBlock", qrc721MaxBlocks))
+	}
+	logs, jsonErr := transformer.GetLogs(ctx, s.qtumRPCClient, &qtum.SearchLogsRequest{
+		Addresses: []string{contract},
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Topics:    qtum.NewSearchLogsTopics([][]string{{qrc721TransferTopic}, nil, {strings.Repeat("0", 24) + owner}}),
+	})
+	if jsonErr != nil {
+		if jsonErr.Code() == eth.LimitExceededErrorCode {
+			return qrc20Error(c, http.StatusBadRequest, jsonErr.Message()+", search fewer blocks")
+		}
+		return qrc20Failed(c, errors.New(jsonErr.Message()))
+	}
+
+	var tokenIDs []*big.Int
+	seen := map[string]bool{}
+	for _, log := range *logs {
+		// QRC20 transfers have no token id
+		if len(log.Topics) != 4 || seen[log.Topics[3]] {
+			continue
+		}
+		seen[log.Topics[3]] = true
+		tokenIDs = append(tokenIDs, common.HexToHash(log.Topics[3]).Big())
+	}
+
+	owners, err := s.qrc721Calls(ctx, contract, qrc721OwnerOf, tokenIDs)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+	var owned []*big.Int
+	for i, result := range owners {
+		// tokens sent on since or burned
+		if !result.reverted && len(result.output) >= 32 && qrc721Address(result.output) == owner {
+			owned = append(owned, tokenIDs[i])
+		}
+	}
+	uris, err := s.qrc721Calls(ctx, contract, qrc721TokenURI, owned)
+	if err != nil {
+		return qrc20Failed(c, err)
+	}
+
+	tokens := QRC721Tokens{
+		Contract:  utils.AddHexPrefix(contract),
+		Owner:     utils.AddHexPrefix(owner),
+		FromBlock: fromBlock.Int64(),
+		ToBlock:   toBlock.Int64(),
+		Tokens:    make([]QRC721Token, len(owned)),
+	}
+	for i, tokenID := range owned {
+		tokens.Tokens[i].TokenID = tokenID.String()
+		if !uris[i].reverted {
+			tokens.Tokens[i].TokenURI = decodeQRC20String(uris[i].output)
+		}
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// qrc721Calls calls the function of selector with each token id as batches of callcontract calls, one by one when
+// qtumd doesn't take batches
+func (s *Server) qrc721Calls(ctx context.Context, contract string, selector string, tokenIDs []*big.Int) ([]qrc721Result, error) {
+	responses := make([]*qtum.CallContractResponse, len(tokenIDs))
+	calls := make([]*qtum.BatchCall, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		calls[i] = &qtum.BatchCall{
+			Method: qtum.MethodCallContract,
+			Params: &qtum.CallContractRequest{To: contract, Data: fmt.Sprintf("%s%064x", selector, tokenID)},
+			Result: &responses[i],
+		}
+	}
+	for start := 0; start < len(calls); start += qrc721BatchSize {
+		end := start + qrc721BatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		if err := s.qtumRPCClient.RequestBatch(ctx, calls[start:end]); err != nil {
+			s.qtumRPCClient.GetDebugLogger().Log("function", "qrc721Calls", "msg", "batch failed, calling one by one", "err", err)
+			for _, call := range calls[start:end] {
+				call.Err = s.qtumRPCClient.RequestWithContext(ctx, call.Method, call.Params, call.Result)
+			}
+		}
+	}
+
+	results := make([]qrc721Result, len(calls))
+	for i, call := range calls {
+		output, reverted, err := decodeCallContract(responses[i], call.Err)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = qrc721Result{output: output, reverted: reverted}
+	}
+	return results, nil
+}
+
+// qrc721Address is the address returned by ownerOf, lower case without 0x
+func qrc721Address(output []byte) string {
+	return fmt.Sprintf("%x", output[12:32])
+}
+
+// SetQRC721API serves the tokens of QRC721 contracts at QRC721Path
+func SetQRC721API(enabled bool) Option {
+	return func(p *Server) error {
+		p.qrc721 = enabled
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+const qrc721TestOwner = "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+
+func qrc721TransferLog(to string, tokenID int) qtum.TransactionReceipt {
+	return qtum.TransactionReceipt{
+		BlockHash:       "975326b65c20d0b8500f00a59f76b08a98513fff7ce0484382534a47b55f8985",
+		BlockNumber:     4063,
+		TransactionHash: "c1816e5fbdd4d1cc62394be83c7c7130ccd2aadefcd91e789c1a0b33ec093fef",
+		ContractAddress: qrc20TestContract[2:],
+		Log: []qtum.Log{{
+			Address: qrc20TestContract[2:],
+			Topics: []string{
+				qrc721TransferTopic,
+				"0000000000000000000000000000000000000000000000000000000000000000",
+				"000000000000000000000000" + to,
+				fmt.Sprintf("%064x", tokenID),
+			},
+		}},
+		Excepted: "None",
+	}
+}
+
+func TestQRC721Tokens(t *testing.T) {
+	httpServer, addResponse := newTokenTestServer(t)
+	addResponse(qtum.MethodGetBlockCount, 4100)
+	// token 7 was received twice, token 9 was sent on since
+	addResponse(qtum.MethodSearchLogs, qtum.SearchLogsResponse{
+		qrc721TransferLog(qrc721TestOwner, 7),
+		qrc721TransferLog(qrc721TestOwner, 9),
+		qrc721TransferLog(qrc721TestOwner, 7),
+	})
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "000000000000000000000000"+qrc721TestOwner))
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712"))
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000010697066733a2f2f516d546f6b656e2f3700000000000000000000000000000000"))
+
+	url := httpServer.URL + QRC721Path + "/" + qrc20TestContract + "/tokensOf/0x" + qrc721TestOwner
+	var tokens QRC721Tokens
+	if status := getJSON(t, url+"?fromBlock=4000", &tokens); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if tokens.Contract != qrc20TestContract || tokens.Owner != "0x"+qrc721TestOwner || tokens.FromBlock != 4000 || tokens.ToBlock != 4100 {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+	if len(tokens.Tokens) != 1 || tokens.Tokens[0] != (QRC721Token{TokenID: "7", TokenURI: "ipfs://QmToken/7"}) {
+		t.Errorf("expected token 7 only, got %+v", tokens.Tokens)
+	}
+
+	for _, query := range []string{"", "?fromBlock=latest", "?fromBlock=4000&toBlock=3999", "?fromBlock=4000&toBlock=4101"} {
+		if status := getJSON(t, url+query, nil); status != http.StatusBadRequest {
+			t.Errorf("expected the range %q to be rejected, got %d", query, status)
+		}
+	}
+}
+
+func TestQRC721Token(t *testing.T) {
+	httpServer, addResponse := newTokenTestServer(t)
+	addResponse(qtum.MethodCallContract, callContractOutput("None", "000000000000000000000000"+qrc721TestOwner))
+	// no tokenURI
+	addResponse(qtum.MethodCallContract, callContractOutput("Revert", ""))
+
+	var token QRC721Token
+	if status := getJSON(t, httpServer.URL+QRC721Path+"/"+qrc20TestContract+"/token/0x7", &token); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if token != (QRC721Token{TokenID: "7", Owner: "0x" + qrc721TestOwner}) {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	// the last response sticks, ownerOf reverts for tokens that don't exist
+	if status := getJSON(t, httpServer.URL+QRC721Path+"/"+qrc20TestContract+"/token/8", nil); status != http.StatusNotFound {
+		t.Errorf("expected a token that doesn't exist to be not found, got %d", status)
+	}
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// RateLimits caps the requests served per second, requests over a limit fail with -32005 "limit exceeded"
+type RateLimits struct {
+	// requests per second of all methods together, 0 leaves them unlimited
+	Global float64
+	// requests per second of a method, also counted against Global
+	Methods map[string]float64
+	// requests let through at once before the rates apply
+	Burst int
+	// give each client IP its own limits instead of sharing them between all clients
+	PerIP bool
+	// proxies, as IPs or CIDRs, whose X-Forwarded-For header tells the client IP. Other clients are told apart by the
+	// address they connect from
+	TrustedProxies []string
+}
+
+func (limits RateLimits) validate() error {
+	if limits.Global < 0 {
+		return errors.New("rate limit can't be negative")
+	}
+	for method, rate := range limits.Methods {
+		if rate <= 0 {
+			return errors.Errorf("rate limit of %s must be positive", method)
+		}
+	}
+	if limits.Burst < 0 {
+		return errors.New("rate limit burst can't be negative")
+	}
+	_, err := parseTrustedProxies(limits.TrustedProxies)
+	return err
+}
+
+// parseTrustedProxies parses proxies given as IPs or CIDRs
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %q", proxy)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+// full buckets, like the ones of clients gone away, are dropped this often
+const rateLimitSweepInterval = time.Minute
+
+// buckets left alone this long are dropped even before they fill up again, so slow rates don't keep the buckets of
+// every client ever seen
+const rateLimitIdleTimeout = 10 * time.Minute
+
+// rateBucket is a token bucket holding up to burst requests, refilled at rate per second
+type rateBucket struct {
+	rate    float64
+	tokens  float64
+	updated time.Time
+}
+
+// available is how many tokens the bucket holds at now
+func (b *rateBucket) available(now time.Time, burst float64) float64 {
+	return math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+}
+
+// wait is how long until the bucket holds a token
+func (b *rateBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+type rateLimiter struct {
+	limits  RateLimits
+	burst   float64
+	proxies []*net.IPNet
+
+	mutex     sync.Mutex
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	burst := float64(limits.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	// the limits are validated before
+	proxies, _ := parseTrustedProxies(limits.TrustedProxies)
+	return &rateLimiter{
+		limits:    limits,
+		burst:     burst,
+		proxies:   proxies,
+		buckets:   make(map[string]*rateBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// clientIP is the IP request comes from. Headers naming the client are only believed from trusted proxies, then the
+// client is the last X-Forwarded-For entry that isn't a trusted proxy itself
+func (l *rateLimiter) clientIP(request *http.Request) string {
+	ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		ip = request.RemoteAddr
+	}
+	if !l.trusted(ip) {
+		return ip
+	}
+	var forwarded []string
+	for _, header := range request.Header.Values(echo.HeaderXForwardedFor) {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !l.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (l *rateLimiter) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range l.proxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow takes a token for a request of method from ip, failing with the time until one is available when the global
+// or the method's limit is used up. A request turned away doesn't use up the other limit
+func (l *rateLimiter) allow(ip string, method string) eth.JSONRPCError {
+	if !l.limits.PerIP {
+		ip = ""
+	}
+	now := time.Now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.sweep(now)
+
+	var taken []*rateBucket
+	var retryAfter time.Duration
+	check := func(key string, rate float64) {
+		if rate <= 0 {
+			return
+		}
+		bucket := l.bucket(key, rate, now)
+		bucket.tokens, bucket.updated = bucket.available(now, l.burst), now
+		if wait := bucket.wait(); wait > retryAfter {
+			retryAfter = wait
+		}
+		taken = append(taken, bucket)
+	}
+	check(ip, l.limits.Global)
+	check(ip+"|"+method, l.limits.Methods[method])
+
+	if retryAfter > 0 {
+		return eth.NewRateLimitedError(retryAfter)
+	}
+	for _, bucket := range taken {
+		bucket.tokens--
+	}
+	return nil
+}
+
+func (l *rateLimiter) bucket(key string, rate float64, now time.Time) *rateBucket {
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &rateBucket{rate: rate, tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// sweep drops the buckets that filled up again, they start out full anyway, and the ones left alone for long
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if bucket.available(now, l.burst) >= l.burst || now.Sub(bucket.updated) >= rateLimitIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// SetRateLimits limits the requests served per second, globally and by method, for all clients together or for each
+// client IP. Zero limits leave requests unlimited
+func SetRateLimits(limits RateLimits) Option {
+	return func(p *Server) error {
+		if err := limits.validate(); err != nil {
+			return err
+		}
+		p.rateLimiter = rateLimiterOf(limits)
+		return nil
+	}
+}
+
+// UpdateRateLimits switches a running server to other rate limits, the requests counted against the previous ones are
+// forgotten
+func (s *Server) UpdateRateLimits(limits RateLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	s.rateLimiterMutex.Lock()
+	defer s.rateLimiterMutex.Unlock()
+	s.rateLimiter = rateLimiterOf(limits)
+	return nil
+}
+
+func (s *Server) currentRateLimiter() *rateLimiter {
+	s.rateLimiterMutex.RLock()
+	defer s.rateLimiterMutex.RUnlock()
+	return s.rateLimiter
+}
+
+// rateLimiterOf is nil when the limits leave requests unlimited
+func rateLimiterOf(limits RateLimits) *rateLimiter {
+	if limits.Global == 0 && len(limits.Methods) == 0 {
+		return nil
+	}
+	return newRateLimiter(limits)
+}
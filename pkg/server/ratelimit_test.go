@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(RateLimits{Global: 0.001, Methods: map[string]float64{"eth_getLogs": 0.001}, Burst: 1, PerIP: true})
+
+	if err := limiter.allow("10.0.0.1", "eth_getLogs"); err != nil {
+		t.Fatalf("expected the first request to be allowed, got %v", err.Message())
+	}
+	err := limiter.allow("10.0.0.1", "eth_getLogs")
+	if err == nil || err.Code() != eth.LimitExceededErrorCode {
+		t.Fatalf("expected eth_getLogs to be limited, got %v", err)
+	}
+	if err := limiter.allow("10.0.0.1", "eth_blockNumber"); err == nil {
+		t.Error("expected the global limit to be used up")
+	}
+	if err := limiter.allow("10.0.0.2", "eth_getLogs"); err != nil {
+		t.Errorf("expected another IP to have its own limits, got %v", err.Message())
+	}
+
+	shared := newRateLimiter(RateLimits{Methods: map[string]float64{"eth_getLogs": 0.001}})
+	if err := shared.allow("10.0.0.1", "eth_getLogs"); err != nil {
+		t.Fatalf("expected the first request to be allowed, got %v", err.Message())
+	}
+	if err := shared.allow("10.0.0.1", "eth_blockNumber"); err != nil {
+		t.Errorf("expected methods without a limit to be allowed, got %v", err.Message())
+	}
+	if err := shared.allow("10.0.0.2", "eth_getLogs"); err == nil {
+		t.Error("expected clients to share the limits")
+	}
+
+	if err := SetRateLimits(RateLimits{Methods: map[string]float64{"eth_call": 0}})(&Server{}); err == nil {
+		t.Error("expected a zero method rate to be refused")
+	}
+}
+
+func TestRateLimitedRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, 11284900); err != nil {
+		t.Fatal(err)
+	}
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&transformer.ProxyETHBlockNumber{Qtum: qtumClient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(qtumClient, proxyTransformer, SetRateLimits(RateLimits{Methods: map[string]float64{"eth_blockNumber": 0.001}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []eth.JSONRPCResult
+	for i := 0; i < 2; i++ {
+		body := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var result eth.JSONRPCResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+
+	if results[0].Error != nil {
+		t.Errorf("unexpected error %v", results[0].Error)
+	}
+	if results[1].Error == nil || results[1].Error.Code() != eth.LimitExceededErrorCode {
+		t.Errorf("expected the second request to be limited, got %+v", results[1])
+	}
+}
+
+func TestUpdateRateLimits(t *testing.T) {
+	s := &Server{}
+	if err := s.UpdateRateLimits(RateLimits{Global: 0.001, Burst: 1}); err != nil {
+		t.Fatal(err)
+	}
+	limiter := s.currentRateLimiter()
+	if limiter == nil {
+		t.Fatal("expected the requests to be limited")
+	}
+	if err := limiter.allow("10.0.0.1", "eth_call"); err != nil {
+		t.Fatalf("expected the first request to be allowed, got %v", err.Message())
+	}
+	if err := limiter.allow("10.0.0.1", "eth_call"); err == nil {
+		t.Error("expected the global limit to be used up")
+	}
+
+	if err := s.UpdateRateLimits(RateLimits{Global: -1}); err == nil {
+		t.Error("expected a negative rate to be refused")
+	}
+	if s.currentRateLimiter() != limiter {
+		t.Error("expected refused limits to leave the previous ones in place")
+	}
+	if err := s.UpdateRateLimits(RateLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	if s.currentRateLimiter() != nil {
+		t.Error("expected zero limits to leave requests unlimited")
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	limiter := newRateLimiter(RateLimits{Global: 1, PerIP: true, TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16"}})
+
+	for _, test := range []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"untrusted client forging the header", "203.0.113.7:4000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:4000", "198.51.100.1", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.1:4000", "198.51.100.1, 192.168.1.5", "198.51.100.1"},
+		{"client prepending a forged entry", "10.0.0.1:4000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"trusted proxy without the header", "10.0.0.1:4000", "", "10.0.0.1"},
+		{"garbage in the header", "10.0.0.1:4000", "not-an-ip", "10.0.0.1"},
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.RemoteAddr = test.remoteAddr
+		if test.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if got := limiter.clientIP(request); got != test.want {
+			t.Errorf("%s: expected client IP %s, got %s", test.name, test.want, got)
+		}
+	}
+
+	if err := SetRateLimits(RateLimits{Global: 1, TrustedProxies: []string{"proxy"}})(&Server{}); err == nil {
+		t.Error("expected an invalid trusted proxy to be refused")
+	}
+}
+
+func TestRateLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter := newRateLimiter(RateLimits{Global: 0.0001, Burst: 1, PerIP: true})
+	if err := limiter.allow("10.0.0.1", "eth_blockNumber"); err != nil {
+		t.Fatal(err.Message())
+	}
+
+	limiter.sweep(time.Now().Add(rateLimitSweepInterval))
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("expected the used bucket to be kept, got %d buckets", len(limiter.buckets))
+	}
+	limiter.sweep(time.Now().Add(rateLimitIdleTimeout + rateLimitSweepInterval))
+	if len(limiter.buckets) != 0 {
+		t.Errorf("expected the idle bucket to be dropped, got %d buckets", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// ReplicationStatePath serves the state a standby needs to take over, when a replication token is configured
+const ReplicationStatePath = "/replication/state"
+
+// how often a standby pulls the state of the active instance
+var standbySyncInterval = 2 * time.Second
+
+// replicationState is what a standby copies from the active instance
+type replicationState struct {
+	// nil when the filter methods aren't registered
+	Filters *eth.FilterSnapshot `json:"filters,omitempty"`
+	Cache   []qtum.CacheEntry   `json:"cache"`
+}
+
+func (s *Server) replicationState() (*replicationState, error) {
+	state := &replicationState{Cache: s.qtumRPCClient.CachedResponses()}
+	// shared filters are already available to the standby
+	if filters := s.transformer.Filters(); filters != nil && !filters.Shared() {
+		snapshot, err := filters.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		state.Filters = &snapshot
+	}
+	return state, nil
+}
+
+func (s *Server) serveReplicationState(c echo.Context) error {
+	if !s.replicationToken.authorizes(c) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"message": "invalid replication token"})
+	}
+	state, err := s.replicationState()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, state)
+}
+
+// standby keeps a copy of the active instance's filters and cache until it serves its first request,
+// from then on it is the active instance and its own state diverges
+type standby struct {
+	url    string
+	client *http.Client
+
+	tokenMutex sync.RWMutex
+	token      string
+
+	mutex     sync.Mutex
+	takenOver bool
+	failing   bool
+}
+
+func newStandby(url string, token string) *standby {
+	return &standby{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// sync pulls the active instance's state until this instance takes over or ctx is done
+func (st *standby) sync(ctx context.Context, s *Server) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := time.NewTicker(standbySyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := st.pull(ctx, s); err != nil {
+			if !st.failing {
+				level.Warn(s.logger).Log("msg", "couldn't sync state from the active instance", "active", st.url, "error", err)
+			}
+			st.failing = true
+		} else if st.failing {
+			level.Info(s.logger).Log("msg", "syncing state from the active instance again", "active", st.url)
+			st.failing = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if st.isTakenOver() {
+			return
+		}
+	}
+}
+
+func (st *standby) pull(ctx context.Context, s *Server) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, st.url+ReplicationStatePath, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	st.tokenMutex.RLock()
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+st.token)
+	st.tokenMutex.RUnlock()
+
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("active instance responded with %s", resp.Status)
+	}
+
+	var state replicationState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return errors.Wrap(err, "couldn't decode the active instance's state")
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	// a request may have arrived while pulling, this instance's own state wins from then on
+	if st.takenOver {
+		return nil
+	}
+	if filters := s.transformer.Filters(); filters != nil && !filters.Shared() && state.Filters != nil {
+		if err := filters.Restore(*state.Filters); err != nil {
+			return err
+		}
+	}
+	s.qtumRPCClient.WarmCache(state.Cache)
+	return nil
+}
+
+// takeOver stops syncing, called when the first request reaches the standby
+func (st *standby) takeOver(logger log.Logger) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if st.takenOver {
+		return
+	}
+	st.takenOver = true
+	level.Info(logger).Log("msg", "standby received a request, taking over from the active instance", "active", st.url)
+}
+
+func (st *standby) setToken(token string) {
+	st.tokenMutex.Lock()
+	defer st.tokenMutex.Unlock()
+	st.token = token
+}
+
+func (st *standby) isTakenOver() bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.takenOver
+}
+
+// serving wraps the JSON-RPC handlers so a standby takes over on its first request for the default network
+func (s *Server) serving(h echo.HandlerFunc) echo.HandlerFunc {
+	if s.standby == nil {
+		return h
+	}
+	return func(c echo.Context) error {
+		// plain GETs, like load balancer probes, aren't clients moving over
+		isRequest := c.Request().Method != http.MethodGet || strings.EqualFold(c.Request().Header.Get("Upgrade"), "websocket")
+		if cc, ok := c.Get("myctx").(*myCtx); ok && isRequest && cc.transformer == s.transformer {
+			s.standby.takeOver(s.logger)
+		}
+		return h(c)
+	}
+}
+
+// SetReplicationToken serves the state a standby needs at ReplicationStatePath to requests authorized with token,
+// an empty token disables it
+func SetReplicationToken(token string) Option {
+	return func(p *Server) error {
+		p.replicationToken.set(token)
+		return nil
+	}
+}
+
+// SetStandbyOf runs this instance as the standby of the Janus instance at url, copying its filters and cache
+// until the first JSON-RPC request is served here, token is the active instance's replication token
+func SetStandbyOf(url string, token string) Option {
+	return func(p *Server) error {
+		if url == "" {
+			p.standby = nil
+			return nil
+		}
+		if token == "" {
+			return errors.New("a replication token is needed to run as a standby")
+		}
+		p.standby = newStandby(url, token)
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func newReplicationTestServer(t *testing.T, opts ...Option) *Server {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, 100); err != nil {
+		t.Fatal(err)
+	}
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, transformer.DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestStandbyTakesOverFilters(t *testing.T) {
+	active := newReplicationTestServer(t, SetReplicationToken("secret"))
+	filters := active.transformer.Filters()
+	blockFilter, err := filters.New(context.Background(), eth.NewBlockFilterTy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockFilter.Data.Store("lastBlockNumber", uint64(90))
+	logFilter, err := filters.New(context.Background(), eth.NewFilterTy, &eth.NewFilterRequest{
+		Address: json.RawMessage(`"0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"`),
+		Topics:  []interface{}{"0xd78a0cb8bb633d06981248b816e7bd33c2a35a6089241d099fa519e361cab902"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFilter.Data.Store("lastBlockNumber", uint64(95))
+	logFilter.Data.Store("toBlock", uint64(200))
+	for _, filter := range []*eth.Filter{blockFilter, logFilter} {
+		if err := filters.Save(context.Background(), filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activeServer := httptest.NewServer(active.Handler())
+	defer activeServer.Close()
+
+	unauthorized := newReplicationTestServer(t, SetStandbyOf(activeServer.URL, "wrong"))
+	if err := unauthorized.standby.pull(context.Background(), unauthorized); err == nil {
+		t.Error("expected pulling with the wrong token to fail")
+	}
+
+	standby := newReplicationTestServer(t, SetStandbyOf(activeServer.URL, "secret"))
+	if err := standby.standby.pull(context.Background(), standby); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, ok, err := standby.transformer.Filters().Filter(context.Background(), logFilter.ID)
+	if err != nil || !ok {
+		t.Fatalf("log filter %d wasn't handed over", logFilter.ID)
+	}
+	request := restored.Request.(*eth.NewFilterRequest)
+	if string(request.Address) != `"0x8320fe7702b96808f7bbc0d4a888ed1468216cfd"` || len(request.Topics) != 1 {
+		t.Errorf("unexpected log filter request %+v", request)
+	}
+	if lastBlockNumber, _ := restored.Data.Load("lastBlockNumber"); lastBlockNumber != uint64(95) {
+		t.Errorf("unexpected last block number %v", lastBlockNumber)
+	}
+	if _, ok, _ := standby.transformer.Filters().Filter(context.Background(), blockFilter.ID); !ok {
+		t.Errorf("block filter %d wasn't handed over", blockFilter.ID)
+	}
+
+	// the first request takes over, new filter IDs continue after the active instance's
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_newBlockFilter","params":[]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	standby.ServeHTTP(rec, req)
+
+	var result eth.JSONRPCResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if string(result.RawResult) != `"0x3"` {
+		t.Fatalf("unexpected filter id %s, error %v", result.RawResult, result.Error)
+	}
+	if !standby.standby.isTakenOver() {
+		t.Fatal("standby didn't take over on its first request")
+	}
+
+	// state pulled after taking over is ignored
+	if err := standby.standby.pull(context.Background(), standby); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := standby.transformer.Filters().Filter(context.Background(), 3); !ok {
+		t.Error("filter created after taking over was replaced")
+	}
+}
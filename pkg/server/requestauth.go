@@ -0,0 +1,184 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// Headers of requests signed with the request signing key. The signature is the hex encoded HMAC-SHA256 of the
+// timestamp, the nonce and the body joined by newlines, so a captured request can't be sent again or changed
+const (
+	RequestTimestampHeader = "X-Janus-Request-Timestamp"
+	RequestNonceHeader     = "X-Janus-Request-Nonce"
+	RequestSignatureHeader = "X-Janus-Request-Signature"
+)
+
+// DefaultRequestSigningWindow is how far the timestamp of a signed request can be from the clock of Janus
+const DefaultRequestSigningWindow = 5 * time.Minute
+
+// longer nonces are refused so clients can't fill the memory of seen nonces
+const maxRequestNonceLength = 128
+
+// protectedMethods mine blocks, change the clock of the chain, change or list what Janus keeps, or sign with the
+// accounts of Janus, they are only served to authenticated requests
+var protectedMethods = map[string]bool{
+	"dev_generatetoaddress":        true,
+	"dev_mineBlocks":               true,
+	"dev_setIntervalMining":        true,
+	"dev_pauseMining":              true,
+	"dev_resumeMining":             true,
+	"dev_setMockTime":              true,
+	"dev_setBlockTime":             true,
+	"dev_loadState":                true,
+	"janus_deployContract":         true,
+	"janus_rebroadcastTransaction": true,
+	"janus_listFailedTransactions": true,
+	"janus_verifyContract":         true,
+	"janus_registerABI":            true,
+	"janus_removeABI":              true,
+	"personal_unlockAccount":       true,
+	"personal_sendTransaction":     true,
+	"personal_sign":                true,
+	"eth_sign":                     true,
+	"eth_signTypedData_v4":         true,
+}
+
+// requestVerifier checks the signatures of requests and refuses the ones it has seen before
+type requestVerifier struct {
+	key    []byte
+	window time.Duration
+
+	mutex sync.Mutex
+	// nonces of the requests within the window by their timestamp, older ones fail the timestamp check anyway
+	nonces map[string]time.Time
+	swept  time.Time
+}
+
+func newRequestVerifier(key string, window time.Duration) *requestVerifier {
+	return &requestVerifier{
+		key:    []byte(key),
+		window: window,
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// sign is the signature of a request, clients compute the same
+func (v *requestVerifier) sign(timestamp string, nonce string, body []byte) []byte {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// verify checks the signature headers of a request and remembers its nonce
+func (v *requestVerifier) verify(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get(RequestTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Errorf("%s must be the unix time in seconds", RequestTimestampHeader)
+	}
+	sent := time.Unix(seconds, 0)
+	if sent.Before(now.Add(-v.window)) || sent.After(now.Add(v.window)) {
+		return errors.Errorf("%s is more than %s away from the server time", RequestTimestampHeader, v.window)
+	}
+	nonce := header.Get(RequestNonceHeader)
+	if nonce == "" || len(nonce) > maxRequestNonceLength {
+		return errors.Errorf("%s must be 1 to %d characters", RequestNonceHeader, maxRequestNonceLength)
+	}
+	signature, err := hex.DecodeString(header.Get(RequestSignatureHeader))
+	if err != nil || !hmac.Equal(signature, v.sign(timestamp, nonce, body)) {
+		return errors.New("invalid request signature")
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if now.Sub(v.swept) > v.window {
+		for seen, at := range v.nonces {
+			if now.Sub(at) > v.window {
+				delete(v.nonces, seen)
+			}
+		}
+		v.swept = now
+	}
+	if _, seen := v.nonces[nonce]; seen {
+		return errors.New("the request was already served, sign it again with a new nonce")
+	}
+	v.nonces[nonce] = sent
+	return nil
+}
+
+// authenticate lets a request call the protected methods when it is signed with the request signing key, or when it
+// comes from the host Janus runs on and local requests are trusted. Batches are signed as a whole and websocket
+// connections by their upgrade request
+func (s *Server) authenticate(cc *myCtx) eth.JSONRPCError {
+	r := cc.Request()
+	if s.trustLocalRequests {
+		// the address of the connection, headers like X-Forwarded-For are set by the client
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		if err == nil && ip != nil && ip.IsLoopback() {
+			cc.authenticated = true
+			return nil
+		}
+	}
+	if s.requestVerifier == nil || r.Header.Get(RequestSignatureHeader) == "" {
+		return nil
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return eth.NewInvalidRequestError(err.Error())
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if err := s.requestVerifier.verify(r.Header, body, time.Now()); err != nil {
+		return eth.NewUnauthorizedError(err.Error())
+	}
+	cc.authenticated = true
+	return nil
+}
+
+// authorize refuses protected methods to requests that weren't authenticated
+func (c *myCtx) authorize(method string) eth.JSONRPCError {
+	if !protectedMethods[method] || c.authenticated {
+		return nil
+	}
+	return eth.NewUnauthorizedError(method + " needs a request signed with the request signing key")
+}
+
+// SetRequestSigningKey serves the protected methods to requests signed with key within window of their timestamp, an
+// empty key serves them to no one unless local requests are trusted
+func SetRequestSigningKey(key string, window time.Duration) Option {
+	return func(p *Server) error {
+		if key == "" {
+			p.requestVerifier = nil
+			return nil
+		}
+		if window <= 0 {
+			return errors.New("the request signing window must be positive")
+		}
+		p.requestVerifier = newRequestVerifier(key, window)
+		return nil
+	}
+}
+
+// SetTrustLocalRequests serves the protected methods to every client connecting from the host Janus runs on, which
+// includes the clients of a reverse proxy on that host
+func SetTrustLocalRequests(trust bool) Option {
+	return func(p *Server) error {
+		p.trustLocalRequests = trust
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+type mineBlocksProxy struct{}
+
+func (p *mineBlocksProxy) Method() string {
+	return "dev_mineBlocks"
+}
+
+func (p *mineBlocksProxy) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return []string{}, nil
+}
+
+func newRequestAuthHandler(t *testing.T, opts ...Option) http.Handler {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&mineBlocksProxy{}, &largeProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := NewHandler(qtumClient, proxyTransformer, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+// serveSigned sends body from remoteAddr, signed with key unless it is empty, and returns the error code of the
+// response or 0
+func serveSigned(t *testing.T, handler http.Handler, remoteAddr string, body string, key string, timestamp time.Time, nonce string) int {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		signature := newRequestVerifier(key, time.Minute).sign(strconv.FormatInt(timestamp.Unix(), 10), nonce, []byte(body))
+		req.Header.Set(RequestTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set(RequestNonceHeader, nonce)
+		req.Header.Set(RequestSignatureHeader, hex.EncodeToString(signature))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if strings.HasPrefix(rec.Body.String(), "[") {
+		var results []eth.JSONRPCResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			if result.Error != nil {
+				return result.Error.Code()
+			}
+		}
+		return 0
+	}
+	var result eth.JSONRPCResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error != nil {
+		return result.Error.Code()
+	}
+	return 0
+}
+
+func TestProtectedMethodsWithoutKey(t *testing.T) {
+	mine := `{"jsonrpc":"2.0","id":1,"method":"dev_mineBlocks","params":[]}`
+	if code := serveSigned(t, newRequestAuthHandler(t), "127.0.0.1:1234", mine, "", time.Now(), ""); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected local clients to be refused unless trusted, got %d", code)
+	}
+
+	handler := newRequestAuthHandler(t, SetTrustLocalRequests(true))
+	if code := serveSigned(t, handler, "192.0.2.1:1234", mine, "", time.Now(), ""); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected remote clients to be refused, got %d", code)
+	}
+	if code := serveSigned(t, handler, "127.0.0.1:1234", mine, "", time.Now(), ""); code != 0 {
+		t.Errorf("expected local clients to be served, got %d", code)
+	}
+	if code := serveSigned(t, handler, "[::1]:1234", "["+mine+"]", "", time.Now(), ""); code != 0 {
+		t.Errorf("expected batches of local clients to be served, got %d", code)
+	}
+	if code := serveSigned(t, handler, "192.0.2.1:1234", `{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}`, "", time.Now(), ""); code != 0 {
+		t.Errorf("expected other methods to be served to remote clients, got %d", code)
+	}
+}
+
+func TestProtectedMethodsWithKey(t *testing.T) {
+	handler := newRequestAuthHandler(t, SetRequestSigningKey("secret", time.Minute))
+	mine := `{"jsonrpc":"2.0","id":1,"method":"dev_mineBlocks","params":[]}`
+	now := time.Now()
+
+	if code := serveSigned(t, handler, "127.0.0.1:1234", mine, "", now, ""); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected unsigned requests to be refused with a key, got %d", code)
+	}
+	sign := `{"jsonrpc":"2.0","id":1,"method":"eth_sign","params":["0x6d358cf96533189dd5a602d0937fddf0888ad3ae","0x00"]}`
+	if code := serveSigned(t, handler, "192.0.2.1:1234", sign, "", now, ""); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected unsigned requests signing with the accounts of Janus to be refused, got %d", code)
+	}
+	if code := serveSigned(t, handler, "192.0.2.1:1234", mine, "secret", now, "a"); code != 0 {
+		t.Errorf("expected a signed request to be served, got %d", code)
+	}
+	if code := serveSigned(t, handler, "192.0.2.1:1234", mine, "secret", now, "a"); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected a replayed request to be refused, got %d", code)
+	}
+	if code := serveSigned(t, handler, "192.0.2.1:1234", mine, "other", now, "b"); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected a request signed with another key to be refused, got %d", code)
+	}
+	if code := serveSigned(t, handler, "192.0.2.1:1234", mine, "secret", now.Add(-2*time.Minute), "c"); code != eth.UnauthorizedErrorCode {
+		t.Errorf("expected a stale request to be refused, got %d", code)
+	}
+	batch := "[" + `{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]},` + mine + "]"
+	if code := serveSigned(t, handler, "192.0.2.1:1234", batch, "secret", now, "d"); code != 0 {
+		t.Errorf("expected a signed batch to be served, got %d", code)
+	}
+}
+
+func TestRequestVerifierForgetsOldNonces(t *testing.T) {
+	verifier := newRequestVerifier("secret", time.Minute)
+	sign := func(sent time.Time, nonce string) http.Header {
+		header := http.Header{}
+		timestamp := strconv.FormatInt(sent.Unix(), 10)
+		header.Set(RequestTimestampHeader, timestamp)
+		header.Set(RequestNonceHeader, nonce)
+		header.Set(RequestSignatureHeader, hex.EncodeToString(verifier.sign(timestamp, nonce, nil)))
+		return header
+	}
+
+	start := time.Now()
+	if err := verifier.verify(sign(start, "a"), nil, start); err != nil {
+		t.Fatal(err)
+	}
+	later := start.Add(2 * time.Minute)
+	if err := verifier.verify(sign(later, "b"), nil, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, seen := verifier.nonces["a"]; seen {
+		t.Error("expected the nonce of a request outside the window to be forgotten")
+	}
+	if err := verifier.verify(sign(start, "a"), nil, later); err == nil {
+		t.Error("expected the forgotten request to fail the timestamp check")
+	}
+}
@@ -9,15 +9,18 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/websocket"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/alerting"
 	"github.com/qtumproject/janus/pkg/analytics"
 	"github.com/qtumproject/janus/pkg/blockhash"
 	"github.com/qtumproject/janus/pkg/eth"
@@ -25,6 +28,15 @@ import (
 	"github.com/qtumproject/janus/pkg/transformer"
 )
 
+// Frontend serves the transformer to eth clients over http and websockets
+type Frontend interface {
+	http.Handler
+	Handler() http.Handler
+	Start() error
+}
+
+var _ Frontend = (*Server)(nil)
+
 type Server struct {
 	address       string
 	transformer   *transformer.Transformer
@@ -37,10 +49,36 @@ type Server struct {
 	mutex         *sync.Mutex
 	echo          *echo.Echo
 	blockHash     *blockhash.BlockHash
+	networks      []*Network
+	setupOnce     sync.Once
 
 	healthCheckPercent   *int
+	differential         *differential
+	replicationToken     token
+	adminToken           token
+	requestVerifier      *requestVerifier
+	trustLocalRequests   bool
+	tokenGracePeriod     time.Duration
+	signer               ResponseSigner
+	connections          *connections
+	standby              *standby
+	keepAlive            *keepAlive
+	alerter              *alerting.Alerter
+	chainWatchdog        *chainWatchdog
+	timings              bool
+	deadlines            *deadlines
+	websocket            WebsocketConfig
+	upgrader             *websocket.Upgrader
 	qtumRequestAnalytics *analytics.Analytics
 	ethRequestAnalytics  *analytics.Analytics
+	metrics              *metrics
+	rateLimiter          *rateLimiter
+	rateLimiterMutex     sync.RWMutex
+	qrc20                bool
+	qrc721               bool
+	shutdownGracePeriod  time.Duration
+	shutdownMutex        sync.Mutex
+	shutdown             *shutdown
 
 	blocksMutex     sync.RWMutex
 	lastBlock       int64
@@ -63,6 +101,11 @@ func New(
 		qtumRPCClient:       qtumRPCClient,
 		transformer:         transformer,
 		ethRequestAnalytics: analytics.NewAnalytics(requests),
+		websocket:           DefaultWebsocketConfig(),
+		connections:         newConnections(),
+		tokenGracePeriod:    DefaultTokenGracePeriod,
+		shutdownGracePeriod: DefaultShutdownGracePeriod,
+		shutdown:            newShutdown(),
 	}
 
 	blockHashProcessor, err := blockhash.NewBlockHash(
@@ -86,9 +129,35 @@ func New(
 	return p, nil
 }
 
-func (s *Server) Start() error {
+// NewHandler creates a http.Handler for embedding janus into another server,
+// the block hash database is not started, use Start for the standalone proxy
+func NewHandler(
+	qtumRPCClient *qtum.Qtum,
+	transformer *transformer.Transformer,
+	opts ...Option,
+) (http.Handler, error) {
+	s, err := New(qtumRPCClient, transformer, "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s.Handler(), nil
+}
+
+// Handler returns the http.Handler serving eth JSON-RPC over http and websockets,
+// it can be mounted into any net/http compatible router instead of calling Start
+func (s *Server) Handler() http.Handler {
+	s.setupOnce.Do(s.setup)
+	return s.echo
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Handler().ServeHTTP(w, r)
+}
+
+func (s *Server) setup() {
 	logWriter := s.logWriter
 	e := s.echo
+	s.upgrader = s.websocket.upgrader()
 
 	health := healthcheck.NewHandler()
 	health.AddLivenessCheck("qtumd-connection", func() error { return s.testConnectionToQtumd() })
@@ -96,28 +165,54 @@ func (s *Server) Start() error {
 	health.AddLivenessCheck("qtumd-blocks-syncing", func() error { return s.testBlocksSyncing() })
 	health.AddLivenessCheck("qtumd-error-rate", func() error { return s.testQtumdErrorRate() })
 	health.AddLivenessCheck("janus-error-rate", func() error { return s.testJanusErrorRate() })
-
-	e.Use(middleware.CORS())
-	e.Use(middleware.BodyDump(func(c echo.Context, req []byte, res []byte) {
-		myctx := c.Get("myctx")
-		cc, ok := myctx.(*myCtx)
-		if !ok {
-			return
+	health.AddReadinessCheck("qtumd-synced", s.testQtumdSynced)
+	if s.keepAlive != nil {
+		health.AddLivenessCheck("qtumd-keepalive", s.keepAlive.status)
+		go s.keepAlive.run(s.qtumRPCClient.GetContext(), s)
+	}
+	if s.chainWatchdog != nil {
+		if s.chainWatchdog.failReadiness {
+			health.AddReadinessCheck("qtumd-chain-stale", s.chainWatchdog.ready)
 		}
+		go s.chainWatchdog.run(s.qtumRPCClient.GetContext(), s)
+	}
+	if s.alerter != nil {
+		go s.alerter.Run(s.qtumRPCClient.GetContext())
+	}
 
-		if s.debug {
-			reqBody, reqErr := qtum.ReformatJSON(req)
-			resBody, resErr := qtum.ReformatJSON(res)
-			if reqErr == nil && resErr == nil {
-				cc.GetDebugLogger().Log("msg", "ETH RPC")
-				fmt.Fprintf(logWriter, "=> ETH request\n%s\n", reqBody)
-				fmt.Fprintf(logWriter, "<= ETH response\n%s\n", resBody)
-			} else if reqErr != nil {
-				cc.GetErrorLogger().Log("msg", "Error reformatting request json", "error", reqErr, "body", string(req))
-			} else {
-				cc.GetErrorLogger().Log("msg", "Error reformatting response json", "error", resErr, "body", string(res))
+	corsConfig := middleware.DefaultCORSConfig
+	if s.signer != nil {
+		corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, SignatureHeader)
+	}
+	e.Use(middleware.CORSWithConfig(corsConfig))
+	if s.signer != nil {
+		e.Use(s.signResponses)
+	}
+	e.Use(middleware.BodyDumpWithConfig(middleware.BodyDumpConfig{
+		// dumping buffers the whole response, which defeats streaming large results
+		Skipper: func(echo.Context) bool { return !s.debug },
+		Handler: func(c echo.Context, req []byte, res []byte) {
+			myctx := c.Get("myctx")
+			cc, ok := myctx.(*myCtx)
+			if !ok {
+				return
 			}
-		}
+
+			if s.debug {
+				req = redactRequestBody(req)
+				reqBody, reqErr := qtum.ReformatJSON(req)
+				resBody, resErr := qtum.ReformatJSON(res)
+				if reqErr == nil && resErr == nil {
+					cc.GetDebugLogger().Log("msg", "ETH RPC")
+					fmt.Fprintf(logWriter, "=> ETH request\n%s\n", reqBody)
+					fmt.Fprintf(logWriter, "<= ETH response\n%s\n", resBody)
+				} else if reqErr != nil {
+					cc.GetErrorLogger().Log("msg", "Error reformatting request json", "error", reqErr, "body", string(req))
+				} else {
+					cc.GetErrorLogger().Log("msg", "Error reformatting response json", "error", resErr, "body", string(res))
+				}
+			}
+		},
 	}))
 
 	e.Use(func(h echo.HandlerFunc) echo.HandlerFunc {
@@ -130,6 +225,37 @@ func (s *Server) Start() error {
 				blockHash:     s.blockHash,
 				qtumAnalytics: s.qtumRequestAnalytics,
 				ethAnalytics:  s.ethRequestAnalytics,
+				differential:  s.differential,
+				deadlines:     s.deadlines,
+				websocket:     s.websocket,
+				upgrader:      s.upgrader,
+				connections:   s.connections,
+				metrics:       s.metrics,
+				rateLimiter:   s.currentRateLimiter(),
+			}
+			if header := c.Request().Header.Get(TimeoutHeader); header != "" {
+				timeout, err := ParseTimeout(header)
+				if err != nil {
+					return cc.JSONRPCError(eth.NewInvalidRequestError(errors.WithMessage(err, TimeoutHeader).Error()))
+				}
+				cc.headerTimeout = timeout
+			}
+			if jsonErr := s.authenticate(cc); jsonErr != nil {
+				return cc.JSONRPCError(jsonErr)
+			}
+			if s.timings {
+				cc.timings = qtum.NewTimings()
+				c.SetRequest(c.Request().WithContext(qtum.WithTimings(c.Request().Context(), cc.timings)))
+			}
+
+			if network := s.resolveNetwork(c.Request().Host, c.Request().URL.Path); network != nil {
+				cc.logger = log.With(s.logger, "network", network.Name)
+				cc.network = network.Name
+				cc.transformer = network.Transformer
+				// block hash conversion is only backed by the default network's database
+				cc.blockHash = nil
+				// the reference node mirrors the default network's chain
+				cc.differential = nil
 			}
 
 			c.Set("myctx", cc)
@@ -150,30 +276,76 @@ func (s *Server) Start() error {
 			return nil
 		})
 		e.GET("/ready", func(c echo.Context) error {
+			// report every check for probes and operators, not just the status code
+			query := c.Request().URL.Query()
+			query.Set("full", "1")
+			c.Request().URL.RawQuery = query.Encode()
 			health.ReadyEndpoint(c.Response(), c.Request())
 			return nil
 		})
+		e.GET(HealthPath, s.serveHealth)
+	}
+
+	if s.metrics != nil {
+		e.GET(MetricsPath, s.metrics.handler())
 	}
 
+	if s.qrc20 {
+		e.GET(QRC20Path+"/:contract/info", tokenAPI("qrc20_info", s.serveQRC20Info))
+		e.GET(QRC20Path+"/:contract/balanceOf/:address", tokenAPI("qrc20_balanceOf", s.serveQRC20Balance))
+	}
+	if s.qrc721 {
+		e.GET(QRC721Path+"/:contract/tokensOf/:address", tokenAPI("qrc721_tokensOf", s.serveQRC721Tokens))
+		e.GET(QRC721Path+"/:contract/token/:tokenId", tokenAPI("qrc721_token", s.serveQRC721Token))
+	}
+
+	if s.replicationToken.get() != "" {
+		e.GET(ReplicationStatePath, s.serveReplicationState)
+	}
+	if s.adminToken.get() != "" {
+		e.GET(AdminConnectionsPath, s.requireAdminToken(s.serveConnections))
+		e.DELETE(AdminConnectionsPath+"/:id", s.requireAdminToken(s.closeConnection))
+		e.POST(AdminCredentialsPath, s.requireAdminToken(s.rotateCredentials))
+	}
+	if s.standby != nil {
+		go s.standby.sync(s.qtumRPCClient.GetContext(), s)
+	}
+
+	e.GET(WebsocketPath, s.serving(multiplexedWebsocketHandler))
+	if len(s.networks) > 0 {
+		e.GET("/:network"+WebsocketPath, s.serving(multiplexedWebsocketHandler))
+	}
 	if s.mutex == nil {
-		e.POST("/*", httpHandler)
-		e.GET("/*", websocketHandler)
+		e.POST("/*", s.serving(httpHandler))
+		e.GET("/*", s.serving(websocketHandler))
 	} else {
 		level.Info(s.logger).Log("msg", "Processing RPC requests single threaded")
-		e.POST("/*", func(c echo.Context) error {
+		e.POST("/*", s.serving(func(c echo.Context) error {
 			s.mutex.Lock()
 			defer s.mutex.Unlock()
 			return httpHandler(c)
-		})
-		e.GET("/*", websocketHandler)
+		}))
+		e.GET("/*", s.serving(websocketHandler))
 	}
+}
+
+func (s *Server) Start() error {
+	e := s.echo
+	s.Handler()
 
 	https := (s.httpsKey != "" && s.httpsCert != "")
-	url := s.qtumRPCClient.GetURL().Redacted()
+	url := s.qtumRPCClient.RedactedURL()
 	level.Info(s.logger).Log("listen", s.address, "qtum_rpc", url, "msg", "proxy started", "https", https)
+	for _, network := range s.networks {
+		level.Info(s.logger).Log("network", network.Name, "hosts", strings.Join(network.Hosts, ","), "qtum_rpc", network.QtumClient.RedactedURL(), "msg", "serving additional network")
+	}
 
 	var err error
 
+	stopSignals := make(chan struct{})
+	defer close(stopSignals)
+	go s.handleSignals(stopSignals)
+
 	// shutdown echo server when context ends
 	go func(ctx context.Context, e *echo.Echo) {
 		<-ctx.Done()
@@ -187,6 +359,8 @@ func (s *Server) Start() error {
 		err := s.blockHash.Start(&s.qtumRPCClient.DbConfig, chainIdChan)
 		if err != nil {
 			level.Error(s.logger).Log("msg", "Failed to launch block hash converter", "error", err)
+			// eth_getBlockByHash falls back to Qtum block hashes, everything else keeps working
+			s.qtumRPCClient.GetCapabilities().MarkUnavailable(qtum.CapabilityBlockHashDatabase, err.Error())
 			/*
 				level.Error(s.logger).Log("msg", "Failed to connect to database, quitting")
 				e.Close()
@@ -206,6 +380,11 @@ func (s *Server) Start() error {
 		err = e.Start(s.address)
 	}
 
+	if err == http.ErrServerClosed && s.shutdown.isStarted() {
+		// Start returns as soon as the listener is closed, the connections are still draining
+		<-s.shutdown.done
+		return s.shutdown.err
+	}
 	return err
 }
 
@@ -232,6 +411,48 @@ func SetDebug(debug bool) Option {
 	}
 }
 
+// SetTimeouts bounds how long requests may take, globally and per method, zero leaves them unbounded. Clients can
+// ask for shorter deadlines with the X-Janus-Timeout header or a janus_timeout request field
+func SetTimeouts(global time.Duration, methods map[string]time.Duration) Option {
+	return func(p *Server) error {
+		for method, timeout := range methods {
+			if timeout <= 0 {
+				return errors.Errorf("timeout for %s must be positive", method)
+			}
+		}
+		if global < 0 {
+			return errors.New("request timeout can't be negative")
+		}
+		p.deadlines = &deadlines{global: global, methods: methods}
+		return nil
+	}
+}
+
+// SetTimings adds a janus_timings object with the Qtum RPC calls made and the time spent handling the request to
+// responses, as well as a X-Janus-Timings header
+func SetTimings(timings bool) Option {
+	return func(p *Server) error {
+		p.timings = timings
+		return nil
+	}
+}
+
+// SetKeepAlive pings qtumd at this interval to keep connections to it alive while Janus is idle, failed pings fail
+// the qtumd-keepalive liveness check. Zero disables it
+func SetKeepAlive(interval time.Duration) Option {
+	return func(p *Server) error {
+		if interval < 0 {
+			return errors.New("keep-alive interval can't be negative")
+		}
+		if interval == 0 {
+			p.keepAlive = nil
+		} else {
+			p.keepAlive = newKeepAlive(interval)
+		}
+		return nil
+	}
+}
+
 func SetSingleThreaded(singleThreaded bool) Option {
 	return func(p *Server) error {
 		if singleThreaded {
@@ -258,6 +479,14 @@ func SetQtumAnalytics(analytics *analytics.Analytics) Option {
 	}
 }
 
+// SetEthAnalytics counts client requests in analytics, e.g. restored from before a restart
+func SetEthAnalytics(analytics *analytics.Analytics) Option {
+	return func(p *Server) error {
+		p.ethRequestAnalytics = analytics
+		return nil
+	}
+}
+
 func SetHealthCheckPercent(percent *int) Option {
 	return func(p *Server) error {
 		p.healthCheckPercent = percent
@@ -297,6 +526,13 @@ func batchRequestsMiddleware(h echo.HandlerFunc) echo.HandlerFunc {
 			return err
 		}
 
+		// the header bounds the whole batch rather than each request in it
+		if cc.headerTimeout != 0 {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), cc.headerTimeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+		}
+
 		results := make([]*eth.JSONRPCResult, 0, len(rpcReqs))
 
 		for _, req := range rpcReqs {
@@ -319,6 +555,7 @@ func callHttpHandler(cc *myCtx, req *eth.JSONRPCRequest) (*eth.JSONRPCResult, er
 	}
 
 	httpreq := httptest.NewRequest(echo.POST, "/", ioutil.NopCloser(bytes.NewReader(reqBytes)))
+	httpreq = httpreq.WithContext(cc.Request().Context())
 	httpreq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 
@@ -331,6 +568,10 @@ func callHttpHandler(cc *myCtx, req *eth.JSONRPCRequest) (*eth.JSONRPCResult, er
 		blockHash:     cc.blockHash,
 		qtumAnalytics: cc.qtumAnalytics,
 		ethAnalytics:  cc.ethAnalytics,
+		differential:  cc.differential,
+		deadlines:     cc.deadlines,
+		headerTimeout: cc.headerTimeout,
+		authenticated: cc.authenticated,
 	}
 	newCtx.Set("myctx", myCtx)
 	if err = httpHandler(myCtx); err != nil {
@@ -344,3 +585,41 @@ func callHttpHandler(cc *myCtx, req *eth.JSONRPCRequest) (*eth.JSONRPCResult, er
 
 	return result, nil
 }
+
+// redactedParams replaces the params of personal_ requests in debug dumps, they carry account passphrases
+var redactedParams = json.RawMessage(`"[redacted]"`)
+
+// redactRequestBody returns body, a single or batch request, with the params of personal_ requests redacted so the
+// debug output doesn't leak passphrases. A body that can't be parsed is redacted whole if it mentions them
+func redactRequestBody(body []byte) []byte {
+	if !bytes.Contains(body, []byte("personal_")) {
+		return body
+	}
+	var batch []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &batch); err == nil {
+		for _, req := range batch {
+			redactRequest(req)
+		}
+		if redacted, err := json.Marshal(batch); err == nil {
+			return redacted
+		}
+		return redactedParams
+	}
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err == nil {
+		redactRequest(req)
+		if redacted, err := json.Marshal(req); err == nil {
+			return redacted
+		}
+	}
+	return redactedParams
+}
+
+func redactRequest(req map[string]json.RawMessage) {
+	var method string
+	if err := json.Unmarshal(req["method"], &method); err != nil || strings.HasPrefix(method, "personal_") {
+		if _, ok := req["params"]; ok {
+			req["params"] = redactedParams
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestNewHandlerMountedInMux(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&transformer.Web3ClientVersion{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(qtumClient, proxyTransformer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/rpc/", http.StripPrefix("/rpc", handler))
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"web3_clientVersion","params":[]}`
+	req := httptest.NewRequest(http.MethodPost, "/rpc/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result eth.JSONRPCResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	var version string
+	if err := json.Unmarshal(result.RawResult, &version); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(version, "Janus/") {
+		t.Errorf("unexpected client version %q", version)
+	}
+}
+
+func TestTimingsExtension(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, 11284900); err != nil {
+		t.Fatal(err)
+	}
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&transformer.ProxyETHBlockNumber{Qtum: qtumClient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := NewHandler(qtumClient, proxyTransformer, SetTimings(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if header := rec.Header().Get(TimingsHeader); !strings.Contains(header, "upstream=1") {
+		t.Errorf("unexpected %s header %q", TimingsHeader, header)
+	}
+
+	var result struct {
+		Result  string `json:"result"`
+		Timings struct {
+			UpstreamCalls int                `json:"upstreamCalls"`
+			Phases        map[string]float64 `json:"phases"`
+			Calls         []struct {
+				Method string `json:"method"`
+			} `json:"calls"`
+		} `json:"janus_timings"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Result != "0xac31a4" {
+		t.Errorf("unexpected result %q", result.Result)
+	}
+	if result.Timings.UpstreamCalls != 1 || len(result.Timings.Calls) != 1 || result.Timings.Calls[0].Method != qtum.MethodGetBlockCount {
+		t.Errorf("unexpected timings %s", rec.Body.String())
+	}
+	if _, ok := result.Timings.Phases["transform"]; !ok {
+		t.Errorf("missing transform phase %s", rec.Body.String())
+	}
+}
+
+func TestRedactRequestBody(t *testing.T) {
+	tests := []struct {
+		body     string
+		redacted bool
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"personal_unlockAccount","params":["0x6d358cf96533189dd5a602d0937fddf0888ad3ae","secret"]}`, true},
+		{`[{"id":1,"method":"eth_blockNumber","params":[]},{"id":2,"method":"personal_sendTransaction","params":[{},"secret"]}]`, true},
+		{`{"id":1,"method":"personal_unlockAccount","params":["secret"`, true},
+		{`{"id":1,"method":"eth_getBalance","params":["0x6d358cf96533189dd5a602d0937fddf0888ad3ae","latest"]}`, false},
+	}
+	for _, test := range tests {
+		redacted := string(redactRequestBody([]byte(test.body)))
+		if strings.Contains(redacted, "secret") {
+			t.Errorf("expected the passphrase to be redacted from %s, got %s", test.body, redacted)
+		}
+		if !test.redacted && redacted != test.body {
+			t.Errorf("expected %s to be left as is, got %s", test.body, redacted)
+		}
+	}
+	batch := string(redactRequestBody([]byte(tests[1].body)))
+	if !strings.Contains(batch, "eth_blockNumber") || !strings.Contains(batch, `"params":[]`) {
+		t.Errorf("expected the other requests of a batch to be kept, got %s", batch)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// DefaultShutdownGracePeriod is how long requests in flight get to complete when the server shuts down
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// how often draining checks whether the websocket connections are closed
+const drainPollInterval = 50 * time.Millisecond
+
+// shutdown tracks a graceful shutdown, it only happens once
+type shutdown struct {
+	started chan struct{}
+	done    chan struct{}
+	err     error
+}
+
+func newShutdown() *shutdown {
+	return &shutdown{started: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (s *shutdown) isStarted() bool {
+	select {
+	case <-s.started:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown stops accepting connections, waits for the HTTP requests in flight to complete and closes websocket
+// connections with a close frame once their requests in flight are answered. Connections still open when ctx is done
+// are closed as they are and ctx's error is returned
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownMutex.Lock()
+	if s.shutdown.isStarted() {
+		s.shutdownMutex.Unlock()
+		<-s.shutdown.done
+		return s.shutdown.err
+	}
+	close(s.shutdown.started)
+	s.shutdownMutex.Unlock()
+	defer close(s.shutdown.done)
+
+	level.Info(s.logger).Log("msg", "Shutting down, draining connections", "websockets", len(s.connections.list()))
+	drained := make(chan struct{})
+	go func() {
+		s.connections.drain(ctx)
+		close(drained)
+	}()
+	err := s.echo.Shutdown(ctx)
+	<-drained
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "Grace period over, closing the remaining connections", "err", err)
+		s.echo.Close()
+		s.shutdown.err = errors.Wrap(err, "couldn't drain connections")
+		return s.shutdown.err
+	}
+	level.Info(s.logger).Log("msg", "Connections drained")
+	return nil
+}
+
+// handleSignals shuts the server down gracefully on SIGINT or SIGTERM until stop is closed
+func (s *Server) handleSignals(stop <-chan struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		level.Info(s.logger).Log("msg", "Received signal", "signal", sig, "gracePeriod", s.shutdownGracePeriod)
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownGracePeriod)
+		defer cancel()
+		s.Shutdown(ctx)
+	case <-stop:
+	}
+}
+
+// drain has the connections close once their requests in flight are answered and waits for them until ctx is done,
+// closing those left then
+func (c *connections) drain(ctx context.Context) {
+	c.mutex.Lock()
+	c.draining = true
+	c.mutex.Unlock()
+	for _, conn := range c.list() {
+		conn.drain()
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for len(c.list()) > 0 {
+		select {
+		case <-ctx.Done():
+			for _, conn := range c.list() {
+				conn.close()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetShutdownGracePeriod bounds how long requests in flight get to complete once the server is told to shut down
+func SetShutdownGracePeriod(gracePeriod time.Duration) Option {
+	return func(p *Server) error {
+		if gracePeriod < 0 {
+			return errors.New("shutdown grace period can't be negative")
+		}
+		p.shutdownGracePeriod = gracePeriod
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// blockingProxy answers once released
+type blockingProxy struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProxy) Method() string {
+	return "test_blocking"
+}
+
+func (p *blockingProxy) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	close(p.started)
+	<-p.release
+	return "done", nil
+}
+
+func newShutdownTestServer(t *testing.T) (*Server, *blockingProxy, *websocket.Conn) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &blockingProxy{started: make(chan struct{}), release: make(chan struct{})}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{proxy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_blocking","params":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	<-proxy.started
+	return s, proxy, ws
+}
+
+func TestShutdownDrainsWebsockets(t *testing.T) {
+	s, proxy, ws := newShutdownTestServer(t)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- s.Shutdown(ctx)
+	}()
+	// the request in flight is still answered
+	time.Sleep(100 * time.Millisecond)
+	close(proxy.release)
+
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(message), `"result":"done"`) {
+		t.Errorf("expected the request in flight to be answered, got %s", message)
+	}
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("expected a going away close frame, got %v", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("expected a clean shutdown, got %v", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected shutting down again to return the first outcome, got %v", err)
+	}
+}
+
+func TestShutdownGracePeriodOver(t *testing.T) {
+	s, proxy, ws := newShutdownTestServer(t)
+	defer close(proxy.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err == nil {
+		t.Error("expected a request still in flight to fail the shutdown")
+	}
+	if _, _, err := ws.ReadMessage(); err == nil {
+		t.Error("expected the connection to be closed")
+	}
+}
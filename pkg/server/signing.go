@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// SignatureHeader carries the signature of a response body as algorithm=signature, so consumers can check that
+// proxies between them and Janus didn't change it
+const SignatureHeader = "X-Janus-Signature"
+
+// response signing algorithms
+const (
+	SigningHMACSHA256 = "hmac-sha256"
+	SigningEd25519    = "ed25519"
+)
+
+// ResponseSigner signs response bodies
+type ResponseSigner interface {
+	Algorithm() string
+	Sign(body []byte) string
+}
+
+// NewResponseSigner signs with an HMAC-SHA256 shared secret, or an Ed25519 private key given as the hex or base64 of
+// its 32 byte seed or 64 byte private key
+func NewResponseSigner(algorithm string, key string) (ResponseSigner, error) {
+	if key == "" {
+		return nil, errors.New("a response signing key is needed")
+	}
+	switch algorithm {
+	case SigningHMACSHA256:
+		return &hmacSigner{key: []byte(key)}, nil
+	case SigningEd25519:
+		decoded, err := hex.DecodeString(key)
+		if err != nil {
+			if decoded, err = base64.StdEncoding.DecodeString(key); err != nil {
+				return nil, errors.New("the Ed25519 signing key must be hex or base64")
+			}
+		}
+		switch len(decoded) {
+		case ed25519.SeedSize:
+			return &ed25519Signer{key: ed25519.NewKeyFromSeed(decoded)}, nil
+		case ed25519.PrivateKeySize:
+			return &ed25519Signer{key: ed25519.PrivateKey(decoded)}, nil
+		default:
+			return nil, errors.Errorf("the Ed25519 signing key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(decoded))
+		}
+	default:
+		return nil, errors.Errorf("unknown response signing algorithm %q, expected %s or %s", algorithm, SigningHMACSHA256, SigningEd25519)
+	}
+}
+
+type hmacSigner struct {
+	key []byte
+}
+
+func (s *hmacSigner) Algorithm() string {
+	return SigningHMACSHA256
+}
+
+// Sign returns the hex encoded HMAC of body
+func (s *hmacSigner) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Algorithm() string {
+	return SigningEd25519
+}
+
+// Sign returns the base64 encoded signature of body
+func (s *ed25519Signer) Sign(body []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, body))
+}
+
+// PublicKey is the base64 encoded key consumers verify signatures with
+func (s *ed25519Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// signingWriter holds a response back until its body can be signed
+type signingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signingWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *signingWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// Flush does nothing, the response is only sent once it is signed
+func (w *signingWriter) Flush() {}
+
+// signResponses buffers responses to add their signature, websocket messages aren't signed
+func (s *Server) signResponses(h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if websocket.IsWebSocketUpgrade(c.Request()) {
+			return h(c)
+		}
+
+		res := c.Response()
+		original := res.Writer
+		writer := &signingWriter{ResponseWriter: original, status: http.StatusOK}
+		res.Writer = writer
+		if err := h(c); err != nil {
+			// error responses are signed too
+			c.Error(err)
+		}
+		res.Writer = original
+
+		original.Header().Set(SignatureHeader, s.signer.Algorithm()+"="+s.signer.Sign(writer.body.Bytes()))
+		original.WriteHeader(writer.status)
+		_, err := original.Write(writer.body.Bytes())
+		return err
+	}
+}
+
+// SetResponseSigner adds the signature of each HTTP response body to its SignatureHeader, nil leaves them unsigned
+func SetResponseSigner(signer ResponseSigner) Option {
+	return func(p *Server) error {
+		p.signer = signer
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func signedRequest(t *testing.T, signer ResponseSigner, body string) (string, []byte) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&largeProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetResponseSigner(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	resp, err := http.Post(httpServer.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Header.Get(SignatureHeader), responseBody
+}
+
+func TestHMACSignedResponses(t *testing.T) {
+	signer, err := NewResponseSigner(SigningHMACSHA256, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}`,
+		// errors are signed too
+		`{"jsonrpc":"2.0","id":1,"method":"eth_unknown","params":[]}`,
+		`[{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}]`,
+	} {
+		signature, responseBody := signedRequest(t, signer, body)
+		if len(responseBody) == 0 {
+			t.Fatalf("expected a response to %s", body)
+		}
+		if want := SigningHMACSHA256 + "=" + signer.Sign(responseBody); signature != want {
+			t.Errorf("expected signature %s for %s, got %q", want, body, signature)
+		}
+	}
+}
+
+func TestEd25519SignedResponses(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+	signer, err := NewResponseSigner(SigningEd25519, base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, responseBody := signedRequest(t, signer, `{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}`)
+
+	if !strings.HasPrefix(signature, SigningEd25519+"=") {
+		t.Fatalf("unexpected signature %q", signature)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(signature, SigningEd25519+"="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	if !ed25519.Verify(publicKey, responseBody, decoded) {
+		t.Error("expected the signature to verify with the public key")
+	}
+
+	if _, err := NewResponseSigner(SigningEd25519, "abcd"); err == nil {
+		t.Error("expected a short key to be refused")
+	}
+	if _, err := NewResponseSigner("rsa", "key"); err == nil {
+		t.Error("expected an unknown algorithm to be refused")
+	}
+}
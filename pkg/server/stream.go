@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// lists with at least this many entries are encoded entry by entry straight to the client
+// instead of being marshalled into memory as a whole (eth_getLogs, blocks with full transactions)
+const streamMinEntries = 100
+
+const streamBufferSize = 32 * 1024
+
+var nullTransactions = []byte(`"transactions":null`)
+
+// isStreamable reports whether a result is large enough to be worth streaming
+func isStreamable(result interface{}) bool {
+	if block, ok := result.(*eth.GetBlockByHashResponse); ok {
+		return block != nil && len(block.Transactions) >= streamMinEntries
+	}
+
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Slice && v.Len() >= streamMinEntries
+}
+
+// streamedResponse defers encoding a large result until it is written to the client
+type streamedResponse struct {
+	id     json.RawMessage
+	method string
+	result interface{}
+}
+
+func (r *streamedResponse) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSONRPCResult(&buf, r.id, r.method, r.result); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONRPCResult encodes a JSON-RPC response to w, only one list entry is held in memory at a time. The addresses
+// of the result of method are checksummed as it is encoded
+func writeJSONRPCResult(w io.Writer, id json.RawMessage, method string, result interface{}) error {
+	bw := bufio.NewWriterSize(w, streamBufferSize)
+
+	bw.WriteString(`{"jsonrpc":"` + eth.RPCVersion + `",`)
+	if len(id) != 0 {
+		bw.WriteString(`"id":`)
+		bw.Write(id)
+		bw.WriteString(`,`)
+	}
+	bw.WriteString(`"result":`)
+
+	if err := writeResult(bw, method, result); err != nil {
+		return err
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func writeResult(w *bufio.Writer, method string, result interface{}) error {
+	if block, ok := result.(*eth.GetBlockByHashResponse); ok && block != nil {
+		return writeBlock(w, method, block)
+	}
+
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		encoded, err := json.Marshal(transformer.ChecksumResult(method, result))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(encoded)
+		return err
+	}
+
+	return writeList(w, method, v)
+}
+
+func writeList(w *bufio.Writer, method string, v reflect.Value) error {
+	w.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i != 0 {
+			w.WriteByte(',')
+		}
+		encoded, err := json.Marshal(transformer.ChecksumResult(method, v.Index(i).Interface()))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte(']')
+}
+
+// writeBlock encodes the block header in memory and streams the transactions into it
+func writeBlock(w *bufio.Writer, method string, block *eth.GetBlockByHashResponse) error {
+	header := *block
+	header.Transactions = nil
+	encoded, err := json.Marshal(transformer.ChecksumResult(method, &header))
+	if err != nil {
+		return err
+	}
+
+	index := bytes.Index(encoded, nullTransactions)
+	if index == -1 {
+		return errors.New("couldn't find transactions in encoded block")
+	}
+	split := index + len(nullTransactions) - len("null")
+
+	w.Write(encoded[:split])
+	if err := writeList(w, method, reflect.ValueOf(block.Transactions)); err != nil {
+		return err
+	}
+	_, err = w.Write(encoded[index+len(nullTransactions):])
+	return err
+}
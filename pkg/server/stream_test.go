@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/transformer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONRPCResultMatchesMarshal(t *testing.T) {
+	logs := make(eth.GetLogsResponse, streamMinEntries)
+	for i := range logs {
+		logs[i] = eth.Log{
+			LogIndex:    fmt.Sprintf("0x%x", i),
+			BlockNumber: "0x1",
+			Address:     "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			Topics:      []string{"0x1234"},
+		}
+	}
+
+	transactions := make([]interface{}, streamMinEntries)
+	for i := range transactions {
+		transactions[i] = eth.GetTransactionByHashResponse{Hash: fmt.Sprintf("0x%064x", i), From: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	}
+	block := &eth.GetBlockByHashResponse{
+		Number:       "0x1",
+		Hash:         "0xabcd",
+		Miner:        "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+		Transactions: transactions,
+		Uncles:       []string{},
+	}
+
+	tests := []struct {
+		name       string
+		result     interface{}
+		streamable bool
+	}{
+		{"logs", &logs, true},
+		{"block", block, true},
+		{"small list", logs[:2], false},
+		{"string", "0x1", false},
+		{"nil", nil, false},
+	}
+
+	id := json.RawMessage(`1`)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.streamable, isStreamable(test.result))
+
+			var buf bytes.Buffer
+			require.NoError(t, writeJSONRPCResult(&buf, id, "eth_getLogs", test.result))
+
+			// addresses are checksummed as the result is written
+			want, err := eth.NewJSONRPCResult(id, transformer.ChecksumResult("eth_getLogs", test.result))
+			require.NoError(t, err)
+			wantJSON, err := json.Marshal(want)
+			require.NoError(t, err)
+
+			require.JSONEq(t, string(wantJSON), buf.String())
+		})
+	}
+
+	require.Contains(t, func() string {
+		var buf bytes.Buffer
+		require.NoError(t, writeJSONRPCResult(&buf, id, "eth_getLogs", &logs))
+		return buf.String()
+	}(), `"address":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"`)
+	// the result itself is left as it is, it may be cached
+	require.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", logs[0].Address)
+}
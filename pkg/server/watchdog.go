@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// DefaultBlockInterval is Qtum's target block spacing
+const DefaultBlockInterval = 32 * time.Second
+
+// chainWatchdog compares qtumd's tip against the wall clock, a node that stops receiving blocks keeps answering
+// requests with an ever older chain otherwise
+type chainWatchdog struct {
+	blockInterval time.Duration
+	staleAfter    time.Duration
+	failReadiness bool
+
+	mutex  sync.RWMutex
+	status ChainStatus
+}
+
+// ChainStatus is the tip qtumd was last seen at
+type ChainStatus struct {
+	Height  int64     `json:"height"`
+	TipHash string    `json:"tipHash,omitempty"`
+	TipTime time.Time `json:"tipTime"`
+	// seconds since the tip was mined
+	TipAge float64 `json:"tipAge"`
+	// blocks that should have been mined since the tip at the expected block interval
+	MissedBlocks int64     `json:"missedBlocks"`
+	Stale        bool      `json:"stale"`
+	CheckedAt    time.Time `json:"checkedAt"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func newChainWatchdog(blockInterval time.Duration, staleAfter time.Duration, failReadiness bool) *chainWatchdog {
+	return &chainWatchdog{
+		blockInterval: blockInterval,
+		staleAfter:    staleAfter,
+		failReadiness: failReadiness,
+	}
+}
+
+func (w *chainWatchdog) run(ctx context.Context, s *Server) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	w.check(ctx, s, time.Now())
+	ticker := time.NewTicker(w.blockInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.check(ctx, s, now)
+		}
+	}
+}
+
+func (w *chainWatchdog) check(ctx context.Context, s *Server, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, w.blockInterval)
+	defer cancel()
+
+	status := ChainStatus{CheckedAt: now}
+	info, err := s.qtumRPCClient.GetBlockChainInfo(ctx)
+	if err == nil {
+		status.Height = info.Blocks
+		status.TipHash = info.Bestblockhash
+		header, headerErr := s.qtumRPCClient.GetBlockHeader(ctx, info.Bestblockhash)
+		if headerErr != nil {
+			err = headerErr
+		} else {
+			status.TipTime = time.Unix(int64(header.Time), 0).UTC()
+		}
+	}
+	if err != nil {
+		// qtumd being unreachable is up to the other checks, the last known tip keeps aging meanwhile
+		w.mutex.RLock()
+		last := w.status
+		w.mutex.RUnlock()
+		status.Height = last.Height
+		status.TipHash = last.TipHash
+		status.TipTime = last.TipTime
+		status.Error = errors.Wrap(err, "couldn't get qtumd's tip").Error()
+	}
+
+	if !status.TipTime.IsZero() {
+		age := now.Sub(status.TipTime)
+		status.TipAge = age.Seconds()
+		status.MissedBlocks = int64(age / w.blockInterval)
+		status.Stale = age > w.staleAfter
+	}
+
+	w.mutex.Lock()
+	wasStale := w.status.Stale
+	w.status = status
+	w.mutex.Unlock()
+
+	if status.Stale && !wasStale {
+		level.Warn(s.logger).Log("msg", "qtumd stopped receiving blocks", "height", status.Height, "tipTime", status.TipTime, "missedBlocks", status.MissedBlocks)
+	} else if !status.Stale && wasStale {
+		level.Info(s.logger).Log("msg", "qtumd receives blocks again", "height", status.Height)
+	}
+}
+
+func (w *chainWatchdog) getStatus() ChainStatus {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.status
+}
+
+// ready fails the readiness check while the chain is stale
+func (w *chainWatchdog) ready() error {
+	status := w.getStatus()
+	if status.Stale {
+		return errors.Errorf("qtumd's tip at height %d is %s old, %d blocks missed", status.Height, time.Duration(status.TipAge*float64(time.Second)).Round(time.Second), status.MissedBlocks)
+	}
+	return nil
+}
+
+// SetChainWatchdog reports the chain as stale in /health when qtumd's tip is older than staleAfter, with
+// blockInterval the expected time between blocks. With failReadiness a stale chain fails the readiness check as
+// well. A zero staleAfter disables it
+func SetChainWatchdog(blockInterval time.Duration, staleAfter time.Duration, failReadiness bool) Option {
+	return func(p *Server) error {
+		if staleAfter < 0 {
+			return errors.New("stale chain threshold can't be negative")
+		}
+		if staleAfter == 0 {
+			p.chainWatchdog = nil
+			return nil
+		}
+		if blockInterval <= 0 {
+			return errors.New("block interval must be positive")
+		}
+		p.chainWatchdog = newChainWatchdog(blockInterval, staleAfter, failReadiness)
+		return nil
+	}
+}
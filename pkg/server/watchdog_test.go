@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+func TestChainWatchdog(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", SetChainWatchdog(32*time.Second, 10*time.Minute, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tipTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 100, Bestblockhash: "tip"}); err != nil {
+		t.Fatal(err)
+	}
+	// answered by the second check
+	if err := mockedClientDoer.AddError(qtum.MethodGetBlockChainInfo, eth.NewJSONRPCError(-1, "connection reset", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{Hash: "tip", Height: 100, Time: uint64(tipTime.Unix())}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.chainWatchdog.check(context.Background(), s, tipTime.Add(time.Minute))
+	if status := s.chainWatchdog.getStatus(); status.Stale || status.Height != 100 || status.MissedBlocks != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if err := s.chainWatchdog.ready(); err != nil {
+		t.Errorf("expected a recent tip to be ready, got %v", err)
+	}
+
+	// qtumd failing doesn't stop the known tip from aging
+	s.chainWatchdog.check(context.Background(), s, tipTime.Add(time.Hour))
+	status := s.chainWatchdog.getStatus()
+	if !status.Stale || status.Height != 100 || status.MissedBlocks != 112 || status.Error == "" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if err := s.chainWatchdog.ready(); err == nil {
+		t.Error("expected a stale chain to fail readiness")
+	}
+}
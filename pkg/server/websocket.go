@@ -0,0 +1,97 @@
+package server
+
+import (
+	"compress/flate"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// WebsocketPath serves JSON-RPC over websockets with the requests of a connection processed concurrently, prefixed
+// with the network name for additional networks
+const WebsocketPath = "/ws"
+
+// WebsocketConfig controls how websocket messages are compressed and framed
+type WebsocketConfig struct {
+	// negotiate permessage-deflate (RFC 7692) with clients offering it
+	Compression bool
+	// flate level from 1, fastest, to 9, smallest
+	CompressionLevel int
+	// smaller messages are sent uncompressed, compressing them costs more CPU than it saves bandwidth
+	CompressionThreshold int
+	// outgoing messages are split into frames of at most this many bytes
+	MaxFrameSize int
+	// connections sending larger messages are closed, 0 accepts any size
+	MaxMessageSize int64
+	// requests of a connection to WebsocketPath processed at the same time, further messages wait to be read
+	MaxConcurrentRequests int
+}
+
+func DefaultWebsocketConfig() WebsocketConfig {
+	return WebsocketConfig{
+		CompressionLevel:      flate.BestSpeed,
+		CompressionThreshold:  512,
+		MaxFrameSize:          4096,
+		MaxConcurrentRequests: 16,
+	}
+}
+
+func (config WebsocketConfig) validate() error {
+	if config.CompressionLevel < flate.BestSpeed || config.CompressionLevel > flate.BestCompression {
+		return errors.Errorf("websocket compression level must be between %d and %d", flate.BestSpeed, flate.BestCompression)
+	}
+	if config.CompressionThreshold < 0 {
+		return errors.New("websocket compression threshold can't be negative")
+	}
+	if config.MaxFrameSize <= 0 {
+		return errors.New("websocket frame size must be positive")
+	}
+	if config.MaxMessageSize < 0 {
+		return errors.New("websocket message size can't be negative")
+	}
+	if config.MaxConcurrentRequests <= 0 {
+		return errors.New("websocket concurrent requests must be positive")
+	}
+	return nil
+}
+
+func (config WebsocketConfig) upgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize: 4096,
+		// the write buffer is flushed as a frame whenever it fills up
+		WriteBufferSize:   config.MaxFrameSize,
+		EnableCompression: config.Compression,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+}
+
+// configure applies the per connection settings to a new connection
+func (config WebsocketConfig) configure(ws *websocket.Conn) error {
+	if config.MaxMessageSize != 0 {
+		ws.SetReadLimit(config.MaxMessageSize)
+	}
+	if config.Compression {
+		return ws.SetCompressionLevel(config.CompressionLevel)
+	}
+	return nil
+}
+
+// compress tells whether a message of this size is worth compressing, unknown sizes of streamed responses are
+// passed as -1 and always are
+func (config WebsocketConfig) compress(size int) bool {
+	return config.Compression && (size < 0 || size >= config.CompressionThreshold)
+}
+
+// SetWebsocketConfig configures compression and message size limits of websocket connections
+func SetWebsocketConfig(config WebsocketConfig) Option {
+	return func(p *Server) error {
+		if err := config.validate(); err != nil {
+			return err
+		}
+		p.websocket = config
+		return nil
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/transformer"
+)
+
+// largeProxy answers with a large, well compressible result like a batch of logs
+type largeProxy struct{}
+
+func (p *largeProxy) Method() string {
+	return "test_large"
+}
+
+func (p *largeProxy) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return strings.Repeat("0x0000000000000000000000000000000000000000", 1000), nil
+}
+
+func dialWebsocketTestServer(t *testing.T, compression bool, opts ...Option) *websocket.Conn {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&largeProxy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+
+	dialer := websocket.Dialer{EnableCompression: compression}
+	ws, resp, err := dialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	if negotiated := strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate"); negotiated != compression {
+		t.Errorf("permessage-deflate negotiated: %v", negotiated)
+	}
+	return ws
+}
+
+func TestWebsocketCompression(t *testing.T) {
+	config := DefaultWebsocketConfig()
+	config.Compression = true
+	config.MaxFrameSize = 1024
+	ws := dialWebsocketTestServer(t, true, SetWebsocketConfig(config))
+
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_large","params":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	var response eth.JSONRPCResult
+	if err := ws.ReadJSON(&response); err != nil {
+		t.Fatal(err)
+	}
+	var result string
+	if err := json.Unmarshal(response.RawResult, &result); err != nil || len(result) != 42000 {
+		t.Errorf("unexpected result of %d bytes, %v", len(result), err)
+	}
+}
+
+func TestWebsocketMaxMessageSize(t *testing.T) {
+	config := DefaultWebsocketConfig()
+	config.MaxMessageSize = 128
+	ws := dialWebsocketTestServer(t, false, SetWebsocketConfig(config))
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"test_large","params":["` + strings.Repeat("a", 200) + `"]}`
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(request)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+}
+
+// blockedProxy answers once released, like an eth_getLogs over a large range
+type blockedProxy struct {
+	release chan struct{}
+}
+
+func (p *blockedProxy) Method() string {
+	return "test_blocked"
+}
+
+func (p *blockedProxy) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	<-p.release
+	return "slow", nil
+}
+
+func TestMultiplexedWebsocket(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := &blockedProxy{release: make(chan struct{})}
+	proxyTransformer, err := transformer.New(qtumClient, []transformer.ETHProxy{&largeProxy{}, slow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(qtumClient, proxyTransformer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+WebsocketPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	for _, request := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"test_blocked","params":[]}`,
+		`{"jsonrpc":"2.0","id":2,"method":"test_large","params":[]}`,
+		`{"jsonrpc":"2.0","id":3,"method":`,
+	} {
+		if err := ws.WriteMessage(websocket.TextMessage, []byte(request)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the requests after the slow one are answered without waiting for it
+	answered := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		var response eth.JSONRPCResult
+		if err := ws.ReadJSON(&response); err != nil {
+			t.Fatal(err)
+		}
+		switch string(response.ID) {
+		case "2":
+			answered["large"] = true
+		case "":
+			if response.Error == nil || response.Error.Code() != eth.InvalidMessageErrorCode {
+				t.Errorf("expected a parse error, got %+v", response.Error)
+			}
+			answered["invalid"] = true
+		default:
+			t.Fatalf("unexpected response to request %s", response.ID)
+		}
+	}
+	if !answered["large"] || !answered["invalid"] {
+		t.Fatalf("unexpected responses %v", answered)
+	}
+
+	close(slow.release)
+	var response eth.JSONRPCResult
+	if err := ws.ReadJSON(&response); err != nil {
+		t.Fatal(err)
+	}
+	if string(response.ID) != "1" || string(response.RawResult) != `"slow"` {
+		t.Errorf("unexpected response %s: %s", response.ID, response.RawResult)
+	}
+}
+
+func TestWebsocketConfigValidation(t *testing.T) {
+	config := DefaultWebsocketConfig()
+	config.CompressionLevel = 10
+	if err := SetWebsocketConfig(config)(&Server{}); err == nil {
+		t.Error("expected an invalid compression level to be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+package transformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// parseABI accepts the ABI as a JSON array or as a string containing one
+func parseABI(raw json.RawMessage) (abi.ABI, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) != 0 && raw[0] == '"' {
+		var inner string
+		if err := json.Unmarshal(raw, &inner); err != nil {
+			return abi.ABI{}, err
+		}
+		raw = json.RawMessage(inner)
+	}
+	return abi.JSON(bytes.NewReader(raw))
+}
+
+// packArguments ABI encodes arguments given as JSON
+func packArguments(arguments abi.Arguments, args []json.RawMessage) ([]byte, error) {
+	if len(args) != len(arguments) {
+		return nil, errors.Errorf("expected %d arguments, got %d", len(arguments), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, argument := range arguments {
+		value, err := abiValue(argument.Type, args[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %d (%s)", i, argument.Type.String())
+		}
+		values[i] = value.Interface()
+	}
+
+	return arguments.Pack(values...)
+}
+
+// abiValue converts a JSON value to the go type the abi package encodes t from
+func abiValue(t abi.Type, raw json.RawMessage) (reflect.Value, error) {
+	goType := t.GetType()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := abiInteger(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.T == abi.UintTy && n.Sign() < 0 {
+			return reflect.Value{}, errors.New("negative value for an unsigned integer")
+		}
+		if !abiIntegerFits(n, t) {
+			return reflect.Value{}, errors.New("value out of range")
+		}
+		if goType == reflect.TypeOf(&big.Int{}) {
+			return reflect.ValueOf(n), nil
+		}
+		v := reflect.New(goType).Elem()
+		if t.T == abi.IntTy {
+			v.SetInt(n.Int64())
+		} else {
+			v.SetUint(n.Uint64())
+		}
+		return v, nil
+
+	case abi.BoolTy:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return reflect.ValueOf(b), err
+
+	case abi.StringTy:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return reflect.ValueOf(s), err
+
+	case abi.AddressTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		if !common.IsHexAddress(s) {
+			return reflect.Value{}, errors.Errorf("invalid address %s", s)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BytesTy, abi.FixedBytesTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.T == abi.BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		if len(b) != t.Size {
+			return reflect.Value{}, errors.Errorf("expected %d bytes, got %d", t.Size, len(b))
+		}
+		v := reflect.New(goType).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return reflect.Value{}, err
+		}
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, errors.Errorf("expected %d items, got %d", t.Size, len(items))
+			}
+			v = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			elem, err := abiValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, errors.Wrapf(err, "item %d", i)
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+	}
+
+	return reflect.Value{}, errors.Errorf("unsupported type %s", t.String())
+}
+
+// abiInteger accepts a JSON number, or a decimal or 0x prefixed hex string
+func abiInteger(raw json.RawMessage) (*big.Int, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return nil, err
+		}
+		s = number.String()
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, ok = n.SetString(s[2:], 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, errors.Errorf("invalid integer %s", string(raw))
+	}
+
+	if negative {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+func abiIntegerFits(n *big.Int, t abi.Type) bool {
+	if t.T == abi.UintTy {
+		return n.BitLen() <= t.Size
+	}
+	// two's complement range of a signed integer, -2^(size-1) to 2^(size-1)-1
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Sign() < 0 {
+		return n.CmpAbs(limit) <= 0
+	}
+	return n.Cmp(limit) < 0
+}
@@ -0,0 +1,72 @@
+package transformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testConstructorABI = `[{"type":"constructor","inputs":[{"name":"supply","type":"uint256"},{"name":"owner","type":"address"},{"name":"name","type":"string"},{"name":"decimals","type":"uint8"},{"name":"salt","type":"bytes32"},{"name":"ids","type":"int64[]"},{"name":"enabled","type":"bool"}]}]`
+
+func TestPackArguments(t *testing.T) {
+	contractABI, err := parseABI(json.RawMessage(testConstructorABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []json.RawMessage{
+		[]byte(`"1000000000000000000000"`),
+		[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`),
+		[]byte(`"Token"`),
+		[]byte(`18`),
+		[]byte(`"0x0000000000000000000000000000000000000000000000000000000000000001"`),
+		[]byte(`[1, "-2", "0x3"]`),
+		[]byte(`true`),
+	}
+	got, err := packArguments(contractABI.Constructor.Inputs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supply, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	want, err := contractABI.Constructor.Inputs.Pack(
+		supply,
+		common.HexToAddress("0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"),
+		"Token",
+		uint8(18),
+		[32]byte{31: 1},
+		[]int64{1, -2, 3},
+		true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestPackArgumentsErrors(t *testing.T) {
+	contractABI, err := parseABI(json.RawMessage(`"[{\"type\":\"constructor\",\"inputs\":[{\"name\":\"decimals\",\"type\":\"uint8\"}]}]"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]json.RawMessage{
+		"out of range": {[]byte(`256`)},
+		"negative":     {[]byte(`-1`)},
+		"not a number": {[]byte(`"abc"`)},
+		"too many":     {[]byte(`1`), []byte(`2`)},
+		"missing":      {},
+	}
+
+	for name, args := range tests {
+		if _, err := packArguments(contractABI.Constructor.Inputs, args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/names"
+)
+
+var (
+	transactionAddressParams = []dataParam{{index: 0, fields: []string{"from", "to"}}}
+	filterAddressParams      = []dataParam{{index: 0, fields: []string{"address"}}}
+	addressParam             = []dataParam{{index: 0}}
+	secondAddressParam       = []dataParam{{index: 1}}
+)
+
+// the address parameters of each method, names in them are resolved before the method sees them. Filter addresses can
+// be arrays
+var addressParams = map[string][]dataParam{
+	"eth_call":                 transactionAddressParams,
+	"eth_estimateGas":          transactionAddressParams,
+	"eth_sendTransaction":      transactionAddressParams,
+	"personal_sendTransaction": transactionAddressParams,
+	"eth_signTransaction":      transactionAddressParams,
+	"eth_createAccessList":     transactionAddressParams,
+	"debug_traceCall":          transactionAddressParams,
+	"eth_getLogs":              filterAddressParams,
+	"eth_newFilter":            filterAddressParams,
+	"eth_getBalance":           addressParam,
+	"eth_getCode":              addressParam,
+	"eth_getTransactionCount":  addressParam,
+	"eth_getStorageAt":         addressParam,
+	"eth_getProof":             addressParam,
+	"eth_sign":                 addressParam,
+	"eth_signTypedData_v4":     addressParam,
+	"janus_getBalanceDetail":   addressParam,
+	"personal_unlockAccount":   addressParam,
+	"personal_sign":            secondAddressParam,
+}
+
+// resolveAddressParams replaces the names in the address parameters of a request with the addresses resolver returns
+// for them. Malformed params are left for the method to report
+func resolveAddressParams(ctx context.Context, resolver names.Resolver, method string, rawParams json.RawMessage) (json.RawMessage, eth.JSONRPCError) {
+	locations, ok := addressParams[method]
+	if resolver == nil || !ok || len(rawParams) == 0 {
+		return rawParams, nil
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return rawParams, nil
+	}
+
+	changed := false
+	for _, location := range locations {
+		if location.index >= len(params) {
+			continue
+		}
+		if len(location.fields) == 0 {
+			resolved, err := resolveAddressValue(ctx, resolver, params[location.index])
+			if err != nil {
+				return nil, err
+			}
+			if resolved != nil {
+				params[location.index] = resolved
+				changed = true
+			}
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(params[location.index], &object); err != nil || object == nil {
+			continue
+		}
+		objectChanged := false
+		for _, field := range location.fields {
+			value, ok := object[field]
+			if !ok {
+				continue
+			}
+			resolved, err := resolveAddressValue(ctx, resolver, value)
+			if err != nil {
+				return nil, err
+			}
+			if resolved != nil {
+				object[field] = resolved
+				objectChanged = true
+			}
+		}
+		if objectChanged {
+			encoded, err := json.Marshal(object)
+			if err != nil {
+				return nil, eth.NewInvalidParamsError(err.Error())
+			}
+			params[location.index] = encoded
+			changed = true
+		}
+	}
+
+	if !changed {
+		return rawParams, nil
+	}
+	resolved, err := json.Marshal(params)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return resolved, nil
+}
+
+// resolveAddressValue returns the value with its names resolved when it had any, nil when it has none
+func resolveAddressValue(ctx context.Context, resolver names.Resolver, raw json.RawMessage) (json.RawMessage, eth.JSONRPCError) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		if !names.IsName(value) {
+			return nil, nil
+		}
+		address, jsonErr := resolveName(ctx, resolver, value)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		resolved, err := json.Marshal(address)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+		return resolved, nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, nil
+	}
+	changed := false
+	for i, value := range values {
+		if !names.IsName(value) {
+			continue
+		}
+		address, jsonErr := resolveName(ctx, resolver, value)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		values[i] = address
+		changed = true
+	}
+	if !changed {
+		return nil, nil
+	}
+	resolved, err := json.Marshal(values)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return resolved, nil
+}
+
+func resolveName(ctx context.Context, resolver names.Resolver, name string) (string, eth.JSONRPCError) {
+	address, err := resolver.Resolve(ctx, name)
+	if err == names.ErrNotFound {
+		return "", eth.NewInvalidParamsError(fmt.Sprintf("unknown name %s", name))
+	}
+	if err != nil {
+		return "", eth.NewCallbackError(fmt.Sprintf("couldn't resolve %s: %s", name, err))
+	}
+	return address, nil
+}
+
+// validateAddressChecksums checks the EIP-55 checksums of the addresses in the address parameters of a request, other
+// params like the message eth_sign signs can look like addresses. Malformed params are left for the method to report
+func validateAddressChecksums(method string, rawParams json.RawMessage) error {
+	locations, ok := addressParams[method]
+	if !ok || len(rawParams) == 0 {
+		return nil
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return nil
+	}
+
+	for _, location := range locations {
+		if location.index >= len(params) {
+			continue
+		}
+		values := []json.RawMessage{params[location.index]}
+		if len(location.fields) > 0 {
+			var object map[string]json.RawMessage
+			if err := json.Unmarshal(params[location.index], &object); err != nil {
+				continue
+			}
+			values = values[:0]
+			for _, field := range location.fields {
+				if value, ok := object[field]; ok {
+					values = append(values, value)
+				}
+			}
+		}
+		for _, value := range values {
+			if err := eth.ValidateParamsChecksums(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/names"
+)
+
+func TestResolveAddressParams(t *testing.T) {
+	resolver, err := names.NewStatic(map[string]string{
+		"alice.qtum": "0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960",
+		"token.qtum": "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		params string
+		want   string
+		err    string
+	}{
+		{"eth_getBalance", `["Alice.qtum","latest"]`, `["0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","latest"]`, ""},
+		{"eth_call", `[{"from":"alice.qtum","to":"token.qtum","data":"0x70a08231"},"latest"]`, `[{"data":"0x70a08231","from":"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},"latest"]`, ""},
+		{"eth_getLogs", `[{"address":["token.qtum","0x0000000000000000000000000000000000000001"]}]`, `[{"address":["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","0x0000000000000000000000000000000000000001"]}]`, ""},
+		{"eth_getBalance", `["bob.qtum","latest"]`, "", "unknown name bob.qtum"},
+		// addresses, and names outside of address params, are left alone
+		{"eth_getBalance", `["0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","latest"]`, `["0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","latest"]`, ""},
+		{"eth_sign", `["alice.qtum","0x616c6963652e7174756d"]`, `["0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","0x616c6963652e7174756d"]`, ""},
+		{"personal_sign", `["0x616c6963652e7174756d","alice.qtum","secret"]`, `["0x616c6963652e7174756d","0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","secret"]`, ""},
+		{"personal_sendTransaction", `[{"from":"alice.qtum","to":"token.qtum"},"secret"]`, `[{"from":"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},"secret"]`, ""},
+		{"personal_unlockAccount", `["alice.qtum","secret",60]`, `["0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","secret",60]`, ""},
+		{"web3_sha3", `["alice.qtum"]`, `["alice.qtum"]`, ""},
+	}
+
+	for _, test := range tests {
+		got, jsonErr := resolveAddressParams(context.Background(), resolver, test.method, json.RawMessage(test.params))
+		if test.err != "" {
+			if jsonErr == nil || jsonErr.Message() != test.err {
+				t.Errorf("%s %s: expected error %q, got %v", test.method, test.params, test.err, jsonErr)
+			}
+			continue
+		}
+		if jsonErr != nil {
+			t.Errorf("%s %s: %s", test.method, test.params, jsonErr.Message())
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s %s: got %s, expected %s", test.method, test.params, got, test.want)
+		}
+	}
+
+	// without a resolver names are passed on as they are
+	if got, _ := resolveAddressParams(context.Background(), nil, "eth_getBalance", json.RawMessage(`["alice.qtum"]`)); string(got) != `["alice.qtum"]` {
+		t.Errorf("expected the params to be left alone, got %s", got)
+	}
+}
+
+func TestValidateAddressChecksums(t *testing.T) {
+	const badChecksum = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"
+	tests := []struct {
+		method string
+		params string
+		valid  bool
+	}{
+		{"eth_getBalance", `["` + badChecksum + `","latest"]`, false},
+		{"eth_getLogs", `[{"address":["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","` + badChecksum + `"]}]`, false},
+		{"eth_sign", `["` + badChecksum + `","0x00"]`, false},
+		// data that only looks like an address isn't checked
+		{"eth_sign", `["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed","` + badChecksum + `"]`, true},
+		{"eth_call", `[{"to":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed","data":"` + badChecksum + `"},"latest"]`, true},
+		{"web3_sha3", `["` + badChecksum + `"]`, true},
+	}
+	for _, test := range tests {
+		if err := validateAddressChecksums(test.method, json.RawMessage(test.params)); (err == nil) != test.valid {
+			t.Errorf("%s %s: expected valid to be %v, got %v", test.method, test.params, test.valid, err)
+		}
+	}
+}
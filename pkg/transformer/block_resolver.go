@@ -0,0 +1,181 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// qtumd doesn't reorganize the chain deeper than this many blocks, so "finalized" is this far below "latest"
+const finalizedDepth = 500
+
+// resolvedBlock is the Qtum block a block parameter refers to
+type resolvedBlock struct {
+	Number *big.Int
+	// without 0x prefix
+	Hash string
+}
+
+// Resolves a raw block parameter to a block number without checking the block exists.
+// Numbers past the chain tip are returned as is, which eth_getLogs and eth_newFilter rely on.
+// Accepts everything eth.BlockParam does:
+//   - integer or hex string representation of a number of a specific block
+//   - string "latest" - for the latest mined block, see getLatestBlockNumber
+//   - string "earliest" for the genesis block
+//   - string "pending" - Qtum has no pending block, this is the same as "latest"
+//   - string "safe" - Qtum has no separate safe head, this is the same as "latest"
+//   - string "finalized" - the block 500 blocks below "latest", qtumd doesn't reorganize deeper
+//   - a block hash, or an EIP-1898 {blockNumber}/{blockHash, requireCanonical} object
+//
+// Uses defaultLatest to differentiate a missing parameter of eth_getLogs/eth_newFilter from a required one
+func resolveBlockNumber(ctx context.Context, p *qtum.Qtum, rawParam json.RawMessage, defaultLatest bool) (*big.Int, eth.JSONRPCError) {
+	param, err := eth.ParseBlockParam(rawParam)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return resolveBlockParamNumber(ctx, p, param, defaultLatest)
+}
+
+// Resolves a raw block parameter to the hash and number of an existing block, returns nil when no block has the requested number
+// A block hash that qtumd doesn't know about is an error like in geth
+func resolveBlock(ctx context.Context, p *qtum.Qtum, rawParam json.RawMessage, defaultLatest bool) (*resolvedBlock, eth.JSONRPCError) {
+	param, err := eth.ParseBlockParam(rawParam)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	if param.Hash != "" {
+		header, jsonErr := getCanonicalBlockHeader(ctx, p, param)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		return &resolvedBlock{
+			Number: big.NewInt(int64(header.Height)),
+			Hash:   param.Hash,
+		}, nil
+	}
+
+	number, jsonErr := resolveBlockParamNumber(ctx, p, param, defaultLatest)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	hash, qtumErr := p.GetBlockHash(ctx, number)
+	if qtumErr != nil {
+		if qtumErr == qtum.ErrInvalidParameter {
+			// block doesn't exist, ETH rpc returns null
+			p.GetDebugLogger().Log("function", "resolveBlock", "request", number.String(), "msg", "Unknown block")
+			return nil, nil
+		}
+		return nil, eth.NewCallbackError("couldn't get block hash")
+	}
+
+	return &resolvedBlock{
+		Number: number,
+		Hash:   string(hash),
+	}, nil
+}
+
+func resolveBlockParamNumber(ctx context.Context, p *qtum.Qtum, param *eth.BlockParam, defaultLatest bool) (*big.Int, eth.JSONRPCError) {
+	switch {
+	case param.IsEmpty():
+		if !defaultLatest {
+			return nil, eth.NewInvalidParamsError("empty parameter value")
+		}
+		latest, err := getLatestBlockNumber(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		p.GetDebugLogger().Log("function", "resolveBlockNumber", "msg", "returning default value ("+latest.String()+")")
+		return latest, nil
+
+	case param.Number != nil:
+		return param.Number, nil
+
+	case param.Hash != "":
+		header, err := getCanonicalBlockHeader(ctx, p, param)
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(int64(header.Height)), nil
+	}
+
+	switch param.Tag {
+	case eth.BlockTagEarliest:
+		return big.NewInt(0), nil
+
+	case eth.BlockTagFinalized:
+		latest, err := getLatestBlockNumber(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		finalized := new(big.Int).Sub(latest, big.NewInt(finalizedDepth))
+		if finalized.Sign() < 0 {
+			finalized.SetInt64(0)
+		}
+		return finalized, nil
+
+	default: // latest, pending, safe
+		latest, err := getLatestBlockNumber(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		p.GetDebugLogger().Log("latest", latest, "msg", "Got latest block")
+		return latest, nil
+	}
+}
+
+// checkLatestBlock rejects the block parameters of methods qtumd only answers with the state of the chain as it is,
+// which are blocks before the one "latest" refers to and blocks past the chain tip. Blocks between "latest" and the tip,
+// which --latest-confirmations leaves out of "latest", are answered with the same state
+func checkLatestBlock(ctx context.Context, p *qtum.Qtum, rawParam json.RawMessage, method string) eth.JSONRPCError {
+	param, err := eth.ParseBlockParam(rawParam)
+	if err != nil {
+		return eth.NewInvalidParamsError(err.Error())
+	}
+	switch {
+	case param.IsEmpty(), param.Tag == eth.BlockTagLatest, param.Tag == eth.BlockTagPending, param.Tag == eth.BlockTagSafe:
+		return nil
+	}
+
+	number, jsonErr := resolveBlockParamNumber(ctx, p, param, true)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	latest, jsonErr := getLatestBlockNumber(ctx, p)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	if number.Cmp(latest) < 0 {
+		return eth.NewInvalidParamsError(method + " only answers at the latest block, qtumd doesn't return the state of contracts at earlier ones")
+	}
+	tip, qtumErr := p.GetBlockCount(ctx)
+	if qtumErr != nil {
+		return eth.NewCallbackError(qtumErr.Error())
+	}
+	if number.Cmp(tip.Int) > 0 {
+		return eth.NewCallbackError("header not found")
+	}
+	return nil
+}
+
+// Looks up the header of a block parameter's hash, enforcing requireCanonical
+func getCanonicalBlockHeader(ctx context.Context, p *qtum.Qtum, param *eth.BlockParam) (*qtum.GetBlockHeaderResponse, eth.JSONRPCError) {
+	header, err := p.GetBlockHeader(ctx, param.Hash)
+	if err != nil {
+		if err == qtum.ErrInvalidAddress {
+			p.GetDebugLogger().Log("function", "resolveBlock", "msg", "Unknown block hash", "blockHash", param.Hash)
+			return nil, eth.NewCallbackError("header for hash not found")
+		}
+		p.GetDebugLogger().Log("function", "resolveBlock", "msg", "couldn't get block header", "blockHash", param.Hash, "error", err)
+		return nil, eth.NewCallbackError("couldn't get block header")
+	}
+	// qtumd reports -1 confirmations for blocks that are not on the main chain
+	if param.RequireCanonical && header.Confirmations < 0 {
+		return nil, eth.NewCallbackError("hash is not currently canonical")
+	}
+	return header, nil
+}
@@ -0,0 +1,127 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestResolveBlockNumber(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 4000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal.SetupGetBlockByHashResponses(t, mockedClientDoer)
+
+	tests := map[string]int64{
+		`"0xf8f"`:               3983,
+		`3983`:                  3983,
+		`"latest"`:              4000,
+		`"pending"`:             4000,
+		`"safe"`:                4000,
+		`"finalized"`:           3500,
+		`"earliest"`:            0,
+		`{"blockNumber":"0x1"}`: 1,
+		`"` + internal.GetTransactionByHashBlockHexHash + `"`:               3983,
+		`{"blockHash":"` + internal.GetTransactionByHashBlockHexHash + `"}`: 3983,
+	}
+
+	for param, want := range tests {
+		got, jsonErr := resolveBlockNumber(context.Background(), qtumClient, json.RawMessage(param), false)
+		if jsonErr != nil {
+			t.Fatalf("%s: %s", param, jsonErr)
+		}
+		if got.Int64() != want {
+			t.Errorf("%s: got %d, want %d", param, got.Int64(), want)
+		}
+	}
+
+	for _, param := range []string{`""`, `"f8f"`, `"safe block"`, `{}`, `{"blockNumber":"0x1","blockHash":"` + internal.GetTransactionByHashBlockHexHash + `"}`} {
+		_, jsonErr := resolveBlockNumber(context.Background(), qtumClient, json.RawMessage(param), false)
+		if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+			t.Errorf("%s: expected an invalid params error, got %v", param, jsonErr)
+		}
+	}
+}
+
+func TestResolveBlockRequiresCanonical(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// qtumd reports -1 confirmations for a block that was reorganized away
+	err = mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{
+		Hash:          internal.GetTransactionByHashBlockHash,
+		Confirmations: -1,
+		Height:        3983,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	param := json.RawMessage(`{"blockHash":"` + internal.GetTransactionByHashBlockHexHash + `","requireCanonical":true}`)
+	if _, jsonErr := resolveBlock(context.Background(), qtumClient, param, false); jsonErr == nil {
+		t.Fatal("expected an error for a block that isn't canonical")
+	}
+
+	param = json.RawMessage(`{"blockHash":"` + internal.GetTransactionByHashBlockHexHash + `"}`)
+	block, jsonErr := resolveBlock(context.Background(), qtumClient, param, false)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if block.Hash != internal.GetTransactionByHashBlockHash || block.Number.Int64() != 3983 {
+		t.Errorf("unexpected block %s at %d", block.Hash, block.Number.Int64())
+	}
+}
+
+func TestCheckLatestBlock(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "latest" is 6 blocks below the chain tip
+	qtumClient.SetFlag(qtum.FLAG_LATEST_CONFIRMATIONS, 6)
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 4000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(4000)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int{
+		``:            0,
+		`"latest"`:    0,
+		`"safe"`:      0,
+		`"0xf9a"`:     0,
+		`"0xfa0"`:     0,
+		`"0xf99"`:     eth.InvalidParamsErrorCode,
+		`"earliest"`:  eth.InvalidParamsErrorCode,
+		`"finalized"`: eth.InvalidParamsErrorCode,
+		`"0xfa1"`:     eth.NewCallbackError("").Code(),
+		`"latest!"`:   eth.InvalidParamsErrorCode,
+	}
+	for param, want := range tests {
+		jsonErr := checkLatestBlock(context.Background(), qtumClient, json.RawMessage(param), "eth_getProof")
+		got := 0
+		if jsonErr != nil {
+			got = jsonErr.Code()
+		}
+		if got != want {
+			t.Errorf("%s: expected code %d, got %v", param, want, jsonErr)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package transformer
+
+import (
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// requireCapability fails a request right away while an optional dependency it needs is down
+func requireCapability(q *qtum.Qtum, capability qtum.Capability) eth.JSONRPCError {
+	if err := q.GetCapabilities().Check(capability); err != nil {
+		return qtumCallError(err)
+	}
+	return nil
+}
+
+// qtumCallError converts the error of a call needing a capability, a missing capability is reported as
+// temporarily unavailable instead of as a failure of the request
+func qtumCallError(err error) eth.JSONRPCError {
+	var unavailable *qtum.CapabilityUnavailableError
+	if errors.As(err, &unavailable) {
+		return eth.NewUnavailableError(eth.UnavailableData{
+			Capability: string(unavailable.Capability),
+			Reason:     unavailable.Reason,
+		})
+	}
+	return eth.NewCallbackError(err.Error())
+}
+
+// journalError reports a failed operation of the transaction journal, whose database is then considered down
+func journalError(q *qtum.Qtum, err error) eth.JSONRPCError {
+	q.GetCapabilities().MarkUnavailable(qtum.CapabilityTransactionJournal, err.Error())
+	return qtumCallError(&qtum.CapabilityUnavailableError{Capability: qtum.CapabilityTransactionJournal, Reason: err.Error()})
+}
+
+// verifiedContractsError reports a failed operation of the verified contracts store, whose database is then considered
+// down
+func verifiedContractsError(q *qtum.Qtum, err error) eth.JSONRPCError {
+	q.GetCapabilities().MarkUnavailable(qtum.CapabilityContractVerification, err.Error())
+	return qtumCallError(&qtum.CapabilityUnavailableError{Capability: qtum.CapabilityContractVerification, Reason: err.Error()})
+}
+
+// abiRegistryError reports a failed operation of the ABI registry, whose database is then considered down
+func abiRegistryError(q *qtum.Qtum, err error) eth.JSONRPCError {
+	q.GetCapabilities().MarkUnavailable(qtum.CapabilityABIRegistry, err.Error())
+	return qtumCallError(&qtum.CapabilityUnavailableError{Capability: qtum.CapabilityABIRegistry, Reason: err.Error()})
+}
@@ -0,0 +1,158 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+)
+
+// dataParam locates a DATA parameter of a method, the param itself or fields of an object param
+type dataParam struct {
+	index  int
+	fields []string
+	// size in bytes, 0 for any size
+	size int
+}
+
+var (
+	transactionDataParams = []dataParam{{index: 0, fields: []string{"data", "input"}}}
+	hashParam             = []dataParam{{index: 0, size: 32}}
+)
+
+// the DATA parameters of each method, checked and normalized before the method sees them
+var dataParams = map[string][]dataParam{
+	"eth_call":                              transactionDataParams,
+	"eth_estimateGas":                       transactionDataParams,
+	"eth_sendTransaction":                   transactionDataParams,
+	"eth_signTransaction":                   transactionDataParams,
+	"eth_sendRawTransaction":                {{index: 0}},
+	"eth_sign":                              {{index: 1}},
+	"web3_sha3":                             {{index: 0}},
+	"eth_getBlockByHash":                    hashParam,
+	"eth_getTransactionByHash":              hashParam,
+	"eth_getTransactionReceipt":             hashParam,
+	"eth_getTransactionByBlockHashAndIndex": hashParam,
+	"eth_getUncleByBlockHashAndIndex":       hashParam,
+	"eth_getUncleCountByBlockHash":          hashParam,
+}
+
+// normalizeDataParams checks that the DATA parameters of a request are 0x prefixed hex strings of whole bytes,
+// returning the params with the hex lowercased. Malformed params are left for the method to report
+func normalizeDataParams(method string, rawParams json.RawMessage) (json.RawMessage, eth.JSONRPCError) {
+	locations, ok := dataParams[method]
+	if !ok || len(rawParams) == 0 {
+		return rawParams, nil
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return rawParams, nil
+	}
+
+	changed := false
+	for _, location := range locations {
+		if location.index >= len(params) {
+			continue
+		}
+		if len(location.fields) == 0 {
+			normalized, err := normalizeDataValue(params[location.index], location.size)
+			if err != nil {
+				return nil, eth.NewInvalidParamsError(fmt.Sprintf("invalid params[%d]: %s", location.index, err))
+			}
+			if normalized != nil {
+				params[location.index] = normalized
+				changed = true
+			}
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(params[location.index], &object); err != nil || object == nil {
+			continue
+		}
+		objectChanged := false
+		for _, field := range location.fields {
+			value, ok := object[field]
+			if !ok {
+				continue
+			}
+			normalized, err := normalizeDataValue(value, location.size)
+			if err != nil {
+				return nil, eth.NewInvalidParamsError(fmt.Sprintf("invalid params[%d].%s: %s", location.index, field, err))
+			}
+			if normalized != nil {
+				object[field] = normalized
+				objectChanged = true
+			}
+		}
+		if objectChanged {
+			encoded, err := json.Marshal(object)
+			if err != nil {
+				return nil, eth.NewInvalidParamsError(err.Error())
+			}
+			params[location.index] = encoded
+			changed = true
+		}
+	}
+
+	if !changed {
+		return rawParams, nil
+	}
+	normalized, err := json.Marshal(params)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return normalized, nil
+}
+
+// normalizeDataValue returns the lowercased value when it had to change, nil when it is fine as it is
+func normalizeDataValue(raw json.RawMessage, size int) (json.RawMessage, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		if string(raw) == "null" {
+			return nil, nil
+		}
+		return nil, errors.New("expected a 0x prefixed hex string")
+	}
+	if value == "" {
+		// treated as no data by the methods
+		return nil, nil
+	}
+	normalized, err := normalizeData(value, size)
+	if err != nil {
+		return nil, err
+	}
+	if normalized == value {
+		return nil, nil
+	}
+	return json.Marshal(normalized)
+}
+
+func normalizeData(value string, size int) (string, error) {
+	if len(value) < 2 || strings.ToLower(value[:2]) != "0x" {
+		return "", errors.Errorf("%q is missing the 0x prefix", abbreviate(value))
+	}
+	hex := value[2:]
+	for i, c := range hex {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return "", errors.Errorf("invalid hex character %q at position %d", c, i+2)
+		}
+	}
+	if len(hex)%2 != 0 {
+		return "", errors.Errorf("hex string has an odd length %d", len(hex))
+	}
+	if size != 0 && len(hex) != 2*size {
+		return "", errors.Errorf("expected %d bytes, got %d", size, len(hex)/2)
+	}
+	return "0x" + strings.ToLower(hex), nil
+}
+
+// abbreviate keeps error messages short for long params like raw transactions
+func abbreviate(value string) string {
+	if len(value) <= 20 {
+		return value
+	}
+	return value[:17] + "..."
+}
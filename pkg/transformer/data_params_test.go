@@ -0,0 +1,45 @@
+package transformer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeDataParams(t *testing.T) {
+	tests := []struct {
+		method string
+		params string
+		want   string
+		err    string
+	}{
+		{"eth_sendRawTransaction", `["0x0200000001"]`, `["0x0200000001"]`, ""},
+		{"eth_sendRawTransaction", `["0X02000000AB"]`, `["0x02000000ab"]`, ""},
+		{"eth_sendRawTransaction", `["0x020000001"]`, "", "invalid params[0]: hex string has an odd length 9"},
+		{"eth_sendRawTransaction", `["0200000001"]`, "", `invalid params[0]: "0200000001" is missing the 0x prefix`},
+		{"eth_sign", `["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","0xzz"]`, "", "invalid params[1]: invalid hex character 'z' at position 2"},
+		{"eth_call", `[{"to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","data":"0xABCD"},"latest"]`, `[{"data":"0xabcd","to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},"latest"]`, ""},
+		{"eth_call", `[{"to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","data":""},"latest"]`, `[{"to":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","data":""},"latest"]`, ""},
+		{"eth_sendTransaction", `[{"from":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","input":"0x123"}]`, "", "invalid params[0].input: hex string has an odd length 3"},
+		{"eth_getTransactionReceipt", `["0x1234"]`, "", "invalid params[0]: expected 32 bytes, got 2"},
+		{"eth_getTransactionReceipt", `[1]`, "", "invalid params[0]: expected a 0x prefixed hex string"},
+		// methods without DATA params are left alone
+		{"eth_getBalance", `["5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","latest"]`, `["5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","latest"]`, ""},
+	}
+
+	for _, test := range tests {
+		got, jsonErr := normalizeDataParams(test.method, json.RawMessage(test.params))
+		if test.err != "" {
+			if jsonErr == nil || jsonErr.Message() != test.err {
+				t.Errorf("%s %s: expected error %q, got %v", test.method, test.params, test.err, jsonErr)
+			}
+			continue
+		}
+		if jsonErr != nil {
+			t.Errorf("%s %s: %s", test.method, test.params, jsonErr.Message())
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s %s: got %s, expected %s", test.method, test.params, got, test.want)
+		}
+	}
+}
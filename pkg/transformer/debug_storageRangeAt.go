@@ -0,0 +1,121 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyDebugStorageRangeAt implements ETHProxy
+type ProxyDebugStorageRangeAt struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyDebugStorageRangeAt) Method() string {
+	return "debug_storageRangeAt"
+}
+
+func (p *ProxyDebugStorageRangeAt) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.StorageRangeAtRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	start, err := storageRangeStart(req.KeyStart)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	block, jsonErr := resolveBlock(ctx, p.Qtum, req.Block, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if block == nil {
+		return nil, nil
+	}
+	blockNumber, jsonErr := p.stateBlockNumber(ctx, block, req.TxIndex)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if blockNumber.Sign() < 0 {
+		// nothing is stored before the genesis block
+		return storageRange(qtum.GetStorageResponse{}, start, req.MaxResult), nil
+	}
+
+	storage, err := p.GetStorage(ctx, &qtum.GetStorageRequest{
+		Address:     utils.RemoveHexPrefix(req.Address),
+		BlockNumber: blockNumber,
+	})
+	if err != nil {
+		if errors.Cause(err) == qtum.ErrInvalidAddress {
+			// like geth, an account without a contract has empty storage
+			return storageRange(qtum.GetStorageResponse{}, start, req.MaxResult), nil
+		}
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return storageRange(*storage, start, req.MaxResult), nil
+}
+
+// stateBlockNumber is the block whose state stands in for the state before the transaction at txIndex. qtumd only
+// keeps the state at the end of blocks, so the state before a block's transactions is the end of the previous block and
+// the state after its last one the end of the block itself
+func (p *ProxyDebugStorageRangeAt) stateBlockNumber(ctx context.Context, block *resolvedBlock, txIndex int) (*big.Int, eth.JSONRPCError) {
+	qtumBlock, err := p.GetBlock(ctx, block.Hash)
+	if err != nil {
+		p.GetDebugLogger().Log("function", "stateBlockNumber", "msg", "couldn't get block", "hash", block.Hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block")
+	}
+	if txIndex >= len(qtumBlock.Txs) {
+		return block.Number, nil
+	}
+	return new(big.Int).Sub(block.Number, big.NewInt(1)), nil
+}
+
+// storageRangeStart turns keyStart into the hashed key entries are returned from, lower case hex without 0x. Like geth
+// it is a position in the order of the hashes, so shorter ones are padded with zeros on the right
+func storageRangeStart(keyStart string) (string, error) {
+	start := strings.ToLower(utils.RemoveHexPrefix(strings.TrimPrefix(keyStart, "0X")))
+	if len(start) > 64 {
+		return "", errors.Errorf("keyStart %s is longer than 32 bytes", keyStart)
+	}
+	if start != "" {
+		if _, ok := new(big.Int).SetString(start, 16); !ok {
+			return "", errors.Errorf("invalid keyStart %s, expected hex", keyStart)
+		}
+	}
+	return start + strings.Repeat("0", 64-len(start)), nil
+}
+
+// storageRange returns up to maxResult entries of storage from the hashed key start on, in the order of the hashes
+func storageRange(storage qtum.GetStorageResponse, start string, maxResult int) *eth.StorageRangeAtResponse {
+	hashes := make([]string, 0, len(storage))
+	for hash := range storage {
+		if hash >= start {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	result := &eth.StorageRangeAtResponse{Storage: map[string]eth.StorageEntry{}}
+	for i, hash := range hashes {
+		if i == maxResult {
+			nextKey := utils.AddHexPrefix(hash)
+			result.NextKey = &nextKey
+			break
+		}
+		// qtumd returns each hash with the one key it is the hash of
+		for key, value := range storage[hash] {
+			result.Storage[utils.AddHexPrefix(hash)] = eth.StorageEntry{
+				Key:   utils.AddHexPrefix(leftPadStringWithZerosTo64Bytes(key)),
+				Value: utils.AddHexPrefix(leftPadStringWithZerosTo64Bytes(utils.RemoveHexPrefix(value))),
+			}
+		}
+	}
+	return result
+}
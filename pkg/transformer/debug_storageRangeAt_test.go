@@ -0,0 +1,123 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// the keccak256 hashes of slots 0, 1 and 2 in hash order
+const (
+	slot2Hash = "405787fa12a823e0f2b7631cc41b3ba8828b3321ca811111fa75cd3aa3bb5ace"
+	slot0Hash = "290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563"
+	slot1Hash = "b10e2d527612073b26eecdfd717e6a320cf44b4afac2b0732d9fcbe2b7fa0cf6"
+)
+
+func testStorage() qtum.GetStorageResponse {
+	return qtum.GetStorageResponse{
+		slot0Hash: {leftPadStringWithZerosTo64Bytes("0"): leftPadStringWithZerosTo64Bytes("a")},
+		slot1Hash: {leftPadStringWithZerosTo64Bytes("1"): leftPadStringWithZerosTo64Bytes("b")},
+		slot2Hash: {leftPadStringWithZerosTo64Bytes("2"): leftPadStringWithZerosTo64Bytes("c")},
+	}
+}
+
+func TestDebugStorageRangeAtRequest(t *testing.T) {
+	requestParams := []json.RawMessage{
+		[]byte(`"0xf8f"`),
+		[]byte(`2`),
+		[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`),
+		[]byte(`"0x"`),
+		[]byte(`2`),
+	}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(internal.GetTransactionByHashBlockHash))
+	block := internal.GetBlockResponse
+	block.Txs = []string{"aa", "bb", "cc"}
+	mockedClientDoer.AddResponse(qtum.MethodGetBlock, block)
+	mockedClientDoer.AddResponse(qtum.MethodGetStorage, testStorage())
+
+	proxyEth := ProxyDebugStorageRangeAt{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	nextKey := "0x" + slot1Hash
+	want := &eth.StorageRangeAtResponse{
+		Storage: map[string]eth.StorageEntry{
+			"0x" + slot0Hash: {
+				Key:   "0x0000000000000000000000000000000000000000000000000000000000000000",
+				Value: "0x000000000000000000000000000000000000000000000000000000000000000a",
+			},
+			"0x" + slot2Hash: {
+				Key:   "0x0000000000000000000000000000000000000000000000000000000000000002",
+				Value: "0x000000000000000000000000000000000000000000000000000000000000000c",
+			},
+		},
+		NextKey: &nextKey,
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestStorageRange(t *testing.T) {
+	start, err := storageRangeStart("0x41")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := storageRange(testStorage(), start, 10)
+	if len(result.Storage) != 1 || result.NextKey != nil {
+		t.Fatalf("expected only the last entry from 0x41 on, got %+v", result)
+	}
+	if _, ok := result.Storage["0x"+slot1Hash]; !ok {
+		t.Errorf("expected slot 1, got %+v", result.Storage)
+	}
+
+	// the next key continues where a range stopped
+	result = storageRange(testStorage(), slot2Hash, 1)
+	if _, ok := result.Storage["0x"+slot2Hash]; !ok || len(result.Storage) != 1 || *result.NextKey != "0x"+slot1Hash {
+		t.Errorf("unexpected range %+v", result)
+	}
+
+	for _, keyStart := range []string{"0xzz", "0x" + slot0Hash + "00"} {
+		if _, err := storageRangeStart(keyStart); err == nil {
+			t.Errorf("expected %s to be rejected", keyStart)
+		}
+	}
+}
+
+func TestStorageRangeStateBlock(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := internal.GetBlockResponse
+	block.Txs = []string{"aa", "bb"}
+	mockedClientDoer.AddResponse(qtum.MethodGetBlock, block)
+
+	proxyEth := ProxyDebugStorageRangeAt{qtumClient}
+	resolved := &resolvedBlock{Number: big.NewInt(3983), Hash: internal.GetTransactionByHashBlockHash}
+	for txIndex, expected := range map[int]int64{0: 3982, 1: 3982, 2: 3983} {
+		number, jsonErr := proxyEth.stateBlockNumber(context.Background(), resolved, txIndex)
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if number.Int64() != expected {
+			t.Errorf("expected the state of block %d before transaction %d, got %d", expected, txIndex, number)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package transformer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// defaultTraceConcurrency bounds the transactions of a block traced at the same time without --trace-concurrency,
+// each one takes several qtumd calls
+const defaultTraceConcurrency = 4
+
+// ProxyDebugTraceBlockByNumber implements ETHProxy
+type ProxyDebugTraceBlockByNumber struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyDebugTraceBlockByNumber) Method() string {
+	return "debug_traceBlockByNumber"
+}
+
+func (p *ProxyDebugTraceBlockByNumber) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.TraceBlockRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if jsonErr := checkTracer(req.Config); jsonErr != nil {
+		return nil, jsonErr
+	}
+	return traceBlock(ctx, p.Qtum, &req)
+}
+
+// ProxyDebugTraceBlockByHash implements ETHProxy
+type ProxyDebugTraceBlockByHash struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyDebugTraceBlockByHash) Method() string {
+	return "debug_traceBlockByHash"
+}
+
+func (p *ProxyDebugTraceBlockByHash) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.TraceBlockRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	param, err := eth.ParseBlockParam(req.Block)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if param.Hash == "" {
+		return nil, eth.NewInvalidParamsError("expected a block hash")
+	}
+	if jsonErr := checkTracer(req.Config); jsonErr != nil {
+		return nil, jsonErr
+	}
+	return traceBlock(ctx, p.Qtum, &req)
+}
+
+// traceBlock traces the transactions of a block a few at a time, a transaction that can't be traced gets an error
+// instead of failing the whole block
+func traceBlock(ctx context.Context, p *qtum.Qtum, req *eth.TraceBlockRequest) (eth.TraceBlockResponse, eth.JSONRPCError) {
+	block, jsonErr := resolveBlock(ctx, p, req.Block, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if block == nil {
+		return nil, nil
+	}
+	qtumBlock, err := p.GetBlock(ctx, block.Hash)
+	if err != nil {
+		p.GetDebugLogger().Log("function", "traceBlock", "msg", "couldn't get block", "hash", block.Hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block")
+	}
+
+	concurrency := defaultTraceConcurrency
+	if configured := p.GetFlagInt(qtum.FLAG_TRACE_CONCURRENCY); configured != nil {
+		concurrency = *configured
+	}
+
+	results := make(eth.TraceBlockResponse, len(qtumBlock.Txs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, txid := range qtumBlock.Txs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, txid string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i].TxHash = utils.AddHexPrefix(txid)
+			frame, jsonErr := traceTransaction(ctx, p, txid, req.Config)
+			if jsonErr != nil {
+				results[i].Error = jsonErr.Message()
+				return
+			}
+			results[i].Result = frame
+		}(i, txid)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		// the traces were cut short by the request's deadline
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return results, nil
+}
@@ -0,0 +1,75 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestTraceBlockByHashRequest(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal.SetupGetBlockByHashResponses(t, mockedClientDoer)
+
+	// a plain transfer, qtumd has no receipt for it
+	delete(mockedClientDoer.Responses, qtum.MethodGetTransactionReceipt)
+	mockedClientDoer.AddRawResponse(qtum.MethodGetTransactionReceipt, []byte(`{"result":[],"error":null,"id":0}`))
+	block := internal.GetBlockResponse
+	block.Txs = []string{"11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"}
+	delete(mockedClientDoer.Responses, qtum.MethodGetBlock)
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, block); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyDebugTraceBlockByHash{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	tx := internal.GetTransactionByHashResponseData
+	want := eth.TraceBlockResponse{{
+		TxHash: tx.Hash,
+		Result: &eth.CallFrame{
+			Type:    "CALL",
+			From:    tx.From,
+			To:      tx.To,
+			Value:   tx.Value,
+			Gas:     tx.Gas,
+			GasUsed: NonContractVMGasLimit,
+			Input:   tx.Input,
+		},
+	}}
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestTraceBlockByHashRequiresHash(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0xf8f"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyDebugTraceBlockByHash{qtumClient}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil {
+		t.Error("expected a block number to be refused")
+	}
+}
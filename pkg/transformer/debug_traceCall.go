@@ -0,0 +1,51 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/evm"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// ProxyDebugTraceCall implements ETHProxy
+type ProxyDebugTraceCall struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyDebugTraceCall) Method() string {
+	return "debug_traceCall"
+}
+
+func (p *ProxyDebugTraceCall) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	// qtumd can't run a call opcode by opcode
+	if !p.GetFlagBool(qtum.FLAG_LOCAL_EVM) {
+		return nil, localEVMDisabledError(p.Method())
+	}
+
+	var req eth.TraceCallRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if jsonErr := checkTracer(req.Config.TraceConfig); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	call, jsonErr := newLocalCall(ctx, p.Qtum, &req.Call)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	tracer := evm.NewCallTracer(req.Config.TracerConfig.OnlyTopCall)
+	result, jsonErr := call.run(ctx, tracer)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	// like geth the top call reports the gas of the whole call, intrinsic gas included
+	frame := tracer.Frame()
+	frame.Gas = hexutil.EncodeUint64(call.msg.Gas)
+	frame.GasUsed = hexutil.EncodeUint64(result.GasUsed)
+	return frame, nil
+}
@@ -0,0 +1,177 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// CallTracer is the only tracer supported, qtumd can't replay a transaction opcode by opcode so the frames are rebuilt
+// from the transaction, its receipt and the condensing transaction that pays out the QTUM contracts sent
+const CallTracer = "callTracer"
+
+// ProxyDebugTraceTransaction implements ETHProxy
+type ProxyDebugTraceTransaction struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyDebugTraceTransaction) Method() string {
+	return "debug_traceTransaction"
+}
+
+func (p *ProxyDebugTraceTransaction) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.TraceTransactionRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if req.Hash == "" {
+		return nil, eth.NewInvalidParamsError("empty transaction hash")
+	}
+	if jsonErr := checkTracer(req.Config); jsonErr != nil {
+		return nil, jsonErr
+	}
+	return traceTransaction(ctx, p.Qtum, utils.RemoveHexPrefix(req.Hash), req.Config)
+}
+
+func checkTracer(config eth.TraceConfig) eth.JSONRPCError {
+	if config.Tracer != "" && config.Tracer != CallTracer {
+		return eth.NewInvalidParamsError("only the " + CallTracer + " tracer is supported")
+	}
+	return nil
+}
+
+// traceTransaction builds the callTracer frame of a mined transaction, with a nested call for each QTUM transfer its
+// contract made. Calls between contracts that don't move QTUM leave nothing on chain and can't be traced
+func traceTransaction(ctx context.Context, p *qtum.Qtum, hash string, config eth.TraceConfig) (*eth.CallFrame, eth.JSONRPCError) {
+	tx, jsonErr := getTransactionByHash(ctx, p, hash)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if tx == nil || tx.BlockHash == "" {
+		return nil, eth.NewInvalidParamsError("transaction " + utils.AddHexPrefix(hash) + " isn't mined")
+	}
+
+	frame := &eth.CallFrame{
+		Type:    "CALL",
+		From:    tx.From,
+		To:      tx.To,
+		Value:   tx.Value,
+		Gas:     tx.Gas,
+		GasUsed: NonContractVMGasLimit,
+		Input:   tx.Input,
+	}
+	if frame.Input == "" {
+		frame.Input = "0x"
+	}
+
+	receipt, err := p.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		if errors.Cause(err) != qtum.EmptyResponseErr {
+			p.GetDebugLogger().Log("msg", "couldn't get transaction receipt", "hash", hash, "err", err)
+			return nil, eth.NewCallbackError("couldn't get transaction receipt")
+		}
+		// not a contract transaction, a plain transfer has nothing more to trace
+		return frame, nil
+	}
+
+	frame.GasUsed = hexutil.EncodeUint64(receipt.GasUsed)
+	if tx.To == "" && receipt.ContractAddress != "" {
+		frame.Type = "CREATE"
+		frame.To = utils.AddHexPrefix(receipt.ContractAddress)
+	}
+	if receipt.Excepted != "None" {
+		frame.Error = tracedError(receipt.Excepted)
+	}
+
+	if config.TracerConfig.OnlyTopCall || frame.Error != "" {
+		return frame, nil
+	}
+	calls, err := tracedTransfers(ctx, p, hash, strings.TrimPrefix(tx.BlockHash, "0x"), utils.RemoveHexPrefix(frame.To))
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't trace QTUM transfers", "hash", hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't trace QTUM transfers")
+	}
+	frame.Calls = calls
+	return frame, nil
+}
+
+// tracedError names qtumd's exceptions the way geth reports them
+func tracedError(excepted string) string {
+	switch {
+	case excepted == "Revert":
+		return "execution reverted"
+	case strings.HasPrefix(excepted, "OutOfGas"):
+		return "out of gas"
+	default:
+		return excepted
+	}
+}
+
+// tracedTransfers finds the QTUM contract sent in the condensing transaction qtumd puts right after the transaction
+// that executed it. Outputs back to the called contract hold its remaining balance and aren't transfers
+func tracedTransfers(ctx context.Context, p *qtum.Qtum, hash string, blockHash string, contract string) ([]eth.CallFrame, error) {
+	block, err := p.GetBlock(ctx, blockHash)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get block")
+	}
+	next := -1
+	for i, blockTx := range block.Txs {
+		if blockTx == hash {
+			next = i + 1
+			break
+		}
+	}
+	if next <= 0 || next >= len(block.Txs) {
+		return nil, nil
+	}
+
+	condensing, err := p.GetRawTransaction(ctx, block.Txs[next], false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get raw transaction")
+	}
+	if len(condensing.Vins) == 0 || condensing.Vins[0].ScriptSig.Asm != "OP_SPEND" {
+		return nil, nil
+	}
+
+	var calls []eth.CallFrame
+	for _, vout := range condensing.Vouts {
+		to, err := transferRecipient(vout)
+		if err != nil {
+			return nil, err
+		}
+		if to == "" || strings.EqualFold(to, contract) {
+			continue
+		}
+		calls = append(calls, eth.CallFrame{
+			Type:    "CALL",
+			From:    utils.AddHexPrefix(contract),
+			To:      utils.AddHexPrefix(to),
+			Value:   hexutil.EncodeBig(satoshisToWei(big.NewInt(vout.AmountSatoshi))),
+			Gas:     "0x0",
+			GasUsed: "0x0",
+			Input:   "0x",
+		})
+	}
+	return calls, nil
+}
+
+// transferRecipient is the hex address an output of a condensing transaction pays, a contract or a public key hash
+func transferRecipient(vout qtum.RawTransactionVout) (string, error) {
+	// condensing outputs to contracts are "<version> 0 0 00 <address> OP_CALL", qtumd shows the zeros as "0" which
+	// ParseCallASM can't read as gas
+	if script := strings.Fields(vout.Details.Asm); len(script) > 1 && script[len(script)-1] == "OP_CALL" {
+		return script[len(script)-2], nil
+	}
+	addresses := vout.Details.GetAddresses()
+	if len(addresses) == 0 || addresses[0] == "" {
+		return "", nil
+	}
+	return utils.ConvertQtumAddress(addresses[0])
+}
@@ -0,0 +1,102 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestTraceTransactionRequest(t *testing.T) {
+	requestParams := []json.RawMessage{
+		[]byte(`"0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"`),
+		[]byte(`{"tracer":"callTracer"}`),
+	}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal.SetupGetBlockByHashResponses(t, mockedClientDoer)
+
+	receipt := qtum.TransactionReceipt{
+		BlockHash:       internal.GetTransactionByHashBlockHash,
+		BlockNumber:     3983,
+		TransactionHash: "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5",
+		GasUsed:         21000,
+		Excepted:        "None",
+	}
+	delete(mockedClientDoer.Responses, qtum.MethodGetTransactionReceipt)
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetTransactionReceipt, []qtum.TransactionReceipt{receipt}); err != nil {
+		t.Fatal(err)
+	}
+	block := internal.GetBlockResponse
+	block.Txs = []string{"11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5", "aa"}
+	delete(mockedClientDoer.Responses, qtum.MethodGetBlock)
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, block); err != nil {
+		t.Fatal(err)
+	}
+	// the condensing transaction pays 1 QTUM to a contract and 0.5 QTUM to an address, keeping the rest in the callee
+	condensing := qtum.GetRawTransactionResponse{
+		Vins: []qtum.RawTransactionVin{{ID: "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5", ScriptSig: qtum.DecodedRawTransactionScriptSig{Asm: "OP_SPEND"}}},
+		Vouts: []qtum.RawTransactionVout{
+			{AmountSatoshi: 100000000, Details: qtum.RawTransactionVoutDetails{Asm: "0 0 0 00 be528c8378ff082e4ba43cb1baa363dbf3f577bf OP_CALL"}},
+			{AmountSatoshi: 50000000, Details: qtum.RawTransactionVoutDetails{Address: "QXeZZ5MsAF5pPrPy47ZFMmtCpg7RExT4mi"}},
+			{AmountSatoshi: 10000, Details: qtum.RawTransactionVoutDetails{Asm: "0 0 0 00 0000000000000000000000000000000000000000 OP_CALL"}},
+		},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, &condensing); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyDebugTraceTransaction{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	tx := internal.GetTransactionByHashResponseData
+	want := eth.CallFrame{
+		Type:    "CALL",
+		From:    tx.From,
+		To:      tx.To,
+		Value:   tx.Value,
+		Gas:     tx.Gas,
+		GasUsed: "0x5208",
+		Input:   tx.Input,
+		Calls: []eth.CallFrame{
+			{Type: "CALL", From: tx.To, To: "0xbe528c8378ff082e4ba43cb1baa363dbf3f577bf", Value: "0xde0b6b3a7640000", Gas: "0x0", GasUsed: "0x0", Input: "0x"},
+			{Type: "CALL", From: tx.To, To: "0x7926223070547d2d15b2ef5e7383e541c338ffe9", Value: "0x6f05b59d3b20000", Gas: "0x0", GasUsed: "0x0", Input: "0x"},
+		},
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
+
+func TestTraceTransactionUnsupportedTracer(t *testing.T) {
+	requestParams := []json.RawMessage{
+		[]byte(`"0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"`),
+		[]byte(`{"tracer":"prestateTracer"}`),
+	}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyDebugTraceTransaction{qtumClient}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil {
+		t.Error("expected an unsupported tracer to be refused")
+	}
+}
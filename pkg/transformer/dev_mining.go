@@ -0,0 +1,176 @@
+package transformer
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// ProxyDevMineBlocks implements ETHProxy
+// mines blocks on regtest, like dev_generatetoaddress with both params optional
+type ProxyDevMineBlocks struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevMineBlocks)(nil)
+
+func (p *ProxyDevMineBlocks) Method() string {
+	return "dev_mineBlocks"
+}
+
+func (p *ProxyDevMineBlocks) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only generate on regtest")
+	}
+
+	var params []interface{}
+	if len(req.Params) != 0 {
+		if err := unmarshalRequest(req.Params, &params); err != nil {
+			return nil, eth.NewInvalidParamsError("couldn't unmarshal request parameters")
+		}
+	}
+	if len(params) > 2 {
+		return nil, eth.NewInvalidParamsError("takes at most 2 arguments: blocks, the base58/hex address to mine rewards to")
+	}
+
+	blocks := int64(1)
+	if len(params) > 0 && params[0] != nil {
+		var err error
+		if blocks, err = parseDevNumber(params[0]); err != nil {
+			return nil, eth.NewInvalidParamsError("Couldn't parse blocks: " + err.Error())
+		}
+		if blocks <= 0 {
+			return nil, eth.NewInvalidParamsError("Blocks to generate must be > 0")
+		}
+	}
+
+	if len(params) == 2 && params[1] != nil {
+		generateToAddress := &ProxyQTUMGenerateToAddress{Qtum: p.Qtum}
+		return generateToAddress.request(ctx, []interface{}{float64(blocks), params[1]})
+	}
+
+	// mine to the same address as blocks mined after transactions
+	response, err := p.Generate(ctx, int(blocks), nil)
+	if err != nil {
+		return nil, eth.NewInvalidRequestError(err.Error())
+	}
+	return response, nil
+}
+
+// ProxyDevSetIntervalMining implements ETHProxy
+// takes [seconds, blocks] and mines blocks at that interval, 0 seconds stops interval mining
+type ProxyDevSetIntervalMining struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevSetIntervalMining)(nil)
+
+func (p *ProxyDevSetIntervalMining) Method() string {
+	return "dev_setIntervalMining"
+}
+
+func (p *ProxyDevSetIntervalMining) Request(_ context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only generate on regtest")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil {
+		return nil, eth.NewInvalidParamsError("couldn't unmarshal request parameters")
+	}
+	if len(params) < 1 || len(params) > 2 {
+		return nil, eth.NewInvalidParamsError("require 1 or 2 arguments: seconds between blocks, blocks to mine each time")
+	}
+
+	seconds, ok := params[0].(float64)
+	if !ok {
+		number, err := parseDevNumber(params[0])
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("Couldn't parse seconds: " + err.Error())
+		}
+		seconds = float64(number)
+	}
+	blocks := int64(1)
+	if len(params) == 2 && params[1] != nil {
+		var err error
+		if blocks, err = parseDevNumber(params[1]); err != nil {
+			return nil, eth.NewInvalidParamsError("Couldn't parse blocks: " + err.Error())
+		}
+	}
+
+	status, err := p.SetIntervalMining(time.Duration(seconds*float64(time.Second)), int(blocks))
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return toMiningStatusResponse(status), nil
+}
+
+// ProxyDevPauseMining implements ETHProxy
+// stops blocks being mined on an interval and after transactions until dev_resumeMining
+type ProxyDevPauseMining struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevPauseMining)(nil)
+
+func (p *ProxyDevPauseMining) Method() string {
+	return "dev_pauseMining"
+}
+
+func (p *ProxyDevPauseMining) Request(_ context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only generate on regtest")
+	}
+	return toMiningStatusResponse(p.PauseMining()), nil
+}
+
+// ProxyDevResumeMining implements ETHProxy
+type ProxyDevResumeMining struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevResumeMining)(nil)
+
+func (p *ProxyDevResumeMining) Method() string {
+	return "dev_resumeMining"
+}
+
+func (p *ProxyDevResumeMining) Request(_ context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only generate on regtest")
+	}
+	return toMiningStatusResponse(p.ResumeMining()), nil
+}
+
+func toMiningStatusResponse(status qtum.MiningStatus) *eth.MiningStatusResponse {
+	return &eth.MiningStatusResponse{
+		Interval: status.Interval.Seconds(),
+		Blocks:   status.Blocks,
+		Paused:   status.Paused,
+	}
+}
+
+// parseDevNumber reads a whole number given as a JSON number, a decimal string or a hex quantity
+func parseDevNumber(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, errors.Errorf("%v is not a whole number", value)
+		}
+		return int64(value), nil
+	case string:
+		if strings.HasPrefix(value, "0x") {
+			return strconv.ParseInt(value[2:], 16, 64)
+		}
+		return strconv.ParseInt(value, 10, 64)
+	default:
+		return 0, errors.Errorf("unexpected %T", value)
+	}
+}
@@ -0,0 +1,104 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestDevMineBlocksRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGenerateToAddress, qtum.GenerateResponse{"6e4ea2d2a5c8e9fd8b1e7e2e29c3a5b4d3f1e2c3b4a5968778695a4b3c2d1e0f", "1e4ea2d2a5c8e9fd8b1e7e2e29c3a5b4d3f1e2c3b4a5968778695a4b3c2d1e0f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyDevMineBlocks{qtumClient}
+	for _, params := range [][]json.RawMessage{
+		{[]byte(`"0x2"`)},
+		{[]byte(`2`), []byte(`"qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"`)},
+	} {
+		request, err := internal.PrepareEthRPCRequest(1, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if blocks := marshalledLength(t, got); blocks != 2 {
+			t.Errorf("expected 2 block hashes, got %d", blocks)
+		}
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`1.5`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected an invalid params error, got %v", jsonErr)
+	}
+}
+
+func TestDevMiningScheduleRequests(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClientForNetwork(internal.NewDoerMappedMock(), qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`0`), []byte(`"0x3"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyDevSetIntervalMining{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultDefault(&eth.MiningStatusResponse{Interval: 0, Blocks: 3}, got, t, false)
+
+	got, jsonErr = (&ProxyDevPauseMining{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultDefault(&eth.MiningStatusResponse{Interval: 0, Blocks: 3, Paused: true}, got, t, false)
+
+	got, jsonErr = (&ProxyDevResumeMining{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultDefault(&eth.MiningStatusResponse{Interval: 0, Blocks: 3}, got, t, false)
+}
+
+func TestDevMiningOnlyOnRegtest(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyDevPauseMining{qtumClient}).Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil {
+		t.Error("expected mining to be unavailable outside regtest")
+	}
+}
+
+func marshalledLength(t *testing.T, v interface{}) int {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return len(decoded)
+}
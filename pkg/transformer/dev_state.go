@@ -0,0 +1,392 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/shopspring/decimal"
+)
+
+// contracts listed per listcontracts call
+var listContractsPageSize = 100
+
+// gas of setting a storage slot that was zero
+var sstoreSetGas = int64(20000)
+
+// gas of calling a contract that self destructs to restore the balance of another one
+var contractFundingGas = int64(100000)
+
+// ProxyDevDumpState implements ETHProxy
+// exports the block height, the balances of the accounts Janus holds and the code and storage of every contract
+type ProxyDevDumpState struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevDumpState)(nil)
+
+func (p *ProxyDevDumpState) Method() string {
+	return "dev_dumpState"
+}
+
+func (p *ProxyDevDumpState) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only dump the state of regtest")
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityAddressIndex); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	height, err := p.GetBlockCount(ctx)
+	if err != nil {
+		return nil, qtumCallError(err)
+	}
+	fixture := &eth.StateFixture{
+		ChainID:     hexutil.EncodeUint64(uint64(p.ChainId())),
+		BlockNumber: hexutil.EncodeBig(height.Int),
+		Accounts:    []eth.StateAccount{},
+		Contracts:   []eth.StateContract{},
+	}
+
+	for _, hexAddr := range p.Keystore.Addresses() {
+		base58Addr, err := qtum.HexToBase58Address(hexAddr, p.IsMain())
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		balance, err := p.GetAddressBalance(ctx, &qtum.GetAddressBalanceRequest{Address: base58Addr})
+		if err != nil {
+			return nil, qtumCallError(err)
+		}
+		fixture.Accounts = append(fixture.Accounts, eth.StateAccount{
+			Address: utils.AddHexPrefix(hexAddr),
+			Balance: hexutil.EncodeBig(satoshisToWei(new(big.Int).SetUint64(balance.Balance))),
+		})
+	}
+
+	contracts, err := p.listContracts(ctx)
+	if err != nil {
+		return nil, qtumCallError(err)
+	}
+	addresses := make([]string, 0, len(contracts))
+	for address := range contracts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		req := qtum.GetAccountInfoRequest(address)
+		info, err := p.GetAccountInfo(ctx, &req)
+		if err != nil {
+			return nil, qtumCallError(err)
+		}
+		storage, err := parseAccountStorage(info.Storage)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		balance := convertFromQtumToSatoshis(contracts[address]).BigInt()
+		fixture.Contracts = append(fixture.Contracts, eth.StateContract{
+			Address: utils.AddHexPrefix(address),
+			Balance: hexutil.EncodeBig(satoshisToWei(balance)),
+			Code:    utils.AddHexPrefix(info.Code),
+			Storage: storage,
+		})
+	}
+
+	return fixture, nil
+}
+
+func (p *ProxyDevDumpState) listContracts(ctx context.Context) (map[string]decimal.Decimal, error) {
+	contracts := map[string]decimal.Decimal{}
+	for start := 1; ; start += listContractsPageSize {
+		page, err := p.ListContracts(ctx, &qtum.ListContractsRequest{Start: start, MaxDisplay: listContractsPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for address, balance := range page {
+			contracts[address] = balance
+		}
+		if len(page) < listContractsPageSize {
+			return contracts, nil
+		}
+	}
+}
+
+// parseAccountStorage reads getaccountinfo's storage, {"<hash of slot>": {"<slot>": "<value>"}}
+func parseAccountStorage(raw json.RawMessage) (map[string]string, error) {
+	storage := map[string]string{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return storage, nil
+	}
+	var hashed map[string]map[string]string
+	if err := json.Unmarshal(raw, &hashed); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse contract storage")
+	}
+	for _, slots := range hashed {
+		for slot, value := range slots {
+			storage[utils.AddHexPrefix(slot)] = utils.AddHexPrefix(value)
+		}
+	}
+	return storage, nil
+}
+
+// ProxyDevLoadState implements ETHProxy
+// takes [fixture] from dev_dumpState and recreates it on a regtest chain: accounts Janus holds are mined to until they
+// have their balance, contracts are deployed again with their storage and balance and blocks are mined up to the
+// fixture's height
+type ProxyDevLoadState struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevLoadState)(nil)
+
+func (p *ProxyDevLoadState) Method() string {
+	return "dev_loadState"
+}
+
+func (p *ProxyDevLoadState) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only load state into regtest")
+	}
+	var req eth.LoadStateRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, &req.Fixture)
+}
+
+func (p *ProxyDevLoadState) request(ctx context.Context, fixture *eth.StateFixture) (*eth.LoadStateResponse, eth.JSONRPCError) {
+	response := &eth.LoadStateResponse{
+		FundedAccounts:  []string{},
+		SkippedAccounts: []string{},
+		Contracts:       []eth.LoadedContract{},
+	}
+
+	amounts := map[string]int64{}
+	for _, account := range fixture.Accounts {
+		balance, err := hexutil.DecodeBig(account.Balance)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid balance of " + account.Address + ": " + err.Error())
+		}
+		if !p.Keystore.Has(account.Address) {
+			response.SkippedAccounts = append(response.SkippedAccounts, account.Address)
+			continue
+		}
+		base58Addr, err := qtum.HexToBase58Address(strings.ToLower(utils.RemoveHexPrefix(account.Address)), p.IsMain())
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		response.FundedAccounts = append(response.FundedAccounts, account.Address)
+		if satoshis := new(big.Int).Div(balance, big.NewInt(1e10)); satoshis.Sign() > 0 {
+			amounts[base58Addr] = satoshis.Int64()
+		}
+	}
+
+	var from string
+	if addresses := p.Keystore.Addresses(); len(addresses) != 0 {
+		from = utils.AddHexPrefix(addresses[0])
+		// the contract balances are paid by the account deploying the contracts, on top of its own
+		contractBalances := int64(0)
+		for _, contract := range fixture.Contracts {
+			if balance, err := hexutil.DecodeBig(contract.Balance); err == nil {
+				contractBalances += new(big.Int).Div(balance, big.NewInt(1e10)).Int64()
+			}
+		}
+		if contractBalances > 0 {
+			base58Addr, err := qtum.HexToBase58Address(addresses[0], p.IsMain())
+			if err != nil {
+				return nil, eth.NewCallbackError(err.Error())
+			}
+			amounts[base58Addr] += contractBalances
+		}
+	}
+	if len(amounts) != 0 {
+		if err := p.FundAccounts(ctx, amounts); err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+	}
+	sendProxy := &ProxyETHSendTransaction{p.Qtum}
+	// Qtum burns the coins sent with a contract creation, so balances are restored by contracts that self destruct
+	// to the copies once they are paid them, deployed next to the copies
+	var funders []string
+	for i, contract := range fixture.Contracts {
+		code, err := contractInitCode(contract)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid contract " + contract.Address + ": " + err.Error())
+		}
+		gas := estimateDeployGas(code)
+		gas.Add(gas, big.NewInt(sstoreSetGas*int64(len(contract.Storage))))
+		if gas.Int64() > deployMaximumGas {
+			gas.SetInt64(deployMaximumGas)
+		}
+
+		resp, jsonErr := deployCode(sendProxy, from, code, gas)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		response.Contracts = append(response.Contracts, eth.LoadedContract{
+			Address:         contract.Address,
+			NewAddress:      utils.AddHexPrefix(resp.Address),
+			TransactionHash: utils.AddHexPrefix(resp.Txid),
+		})
+
+		funders = append(funders, "")
+		if contract.Balance == "" {
+			continue
+		}
+		balance, err := hexutil.DecodeBig(contract.Balance)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid balance of " + contract.Address + ": " + err.Error())
+		}
+		if new(big.Int).Div(balance, big.NewInt(1e10)).Sign() == 0 {
+			continue
+		}
+		code, err = funderInitCode(resp.Address)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		funder, jsonErr := deployCode(sendProxy, from, code, estimateDeployGas(code))
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		funders[i] = funder.Address
+	}
+	if len(fixture.Contracts) != 0 {
+		if _, err := p.Generate(ctx, 1, nil); err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+	}
+
+	funded := false
+	for i, funder := range funders {
+		if funder == "" {
+			continue
+		}
+		funded = true
+		txid, jsonErr := sendProxy.requestSendToContract(&eth.SendTransactionRequest{
+			From: from,
+			To:   utils.AddHexPrefix(funder),
+			// sendtocontract needs some data, the funder ignores it
+			Data:     "0x00",
+			Value:    fixture.Contracts[i].Balance,
+			Gas:      &eth.ETHInt{Int: big.NewInt(contractFundingGas)},
+			GasPrice: &eth.ETHInt{Int: eth.DefaultGasPriceInWei},
+		})
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		response.Contracts[i].BalanceTransactionHash = string(*txid)
+	}
+	if funded {
+		if _, err := p.Generate(ctx, 1, nil); err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+	}
+
+	height, err := p.GetBlockCount(ctx)
+	if err != nil {
+		return nil, qtumCallError(err)
+	}
+	if target, err := hexutil.DecodeBig(fixture.BlockNumber); err == nil && height.Cmp(target) < 0 {
+		if _, err := p.Generate(ctx, int(new(big.Int).Sub(target, height.Int).Int64()), nil); err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		height.Int = target
+	}
+	response.BlockNumber = hexutil.EncodeBig(height.Int)
+
+	return response, nil
+}
+
+func deployCode(sendProxy *ProxyETHSendTransaction, from string, code []byte, gas *big.Int) (*qtum.CreateContractResponse, eth.JSONRPCError) {
+	return sendProxy.createContract(&eth.SendTransactionRequest{
+		From:     from,
+		Data:     hexutil.Encode(code),
+		Gas:      &eth.ETHInt{Int: gas},
+		GasPrice: &eth.ETHInt{Int: eth.DefaultGasPriceInWei},
+	})
+}
+
+// funderInitCode returns creation code of a contract that self destructs to target when it is called, sending it
+// whatever it was paid
+func funderInitCode(target string) ([]byte, error) {
+	address, err := hexutil.Decode(utils.AddHexPrefix(target))
+	if err != nil || len(address) != common.AddressLength {
+		return nil, errors.Errorf("invalid contract address %s", target)
+	}
+	// PUSH20 target SELFDESTRUCT
+	runtime := append(append([]byte{0x73}, address...), 0xff)
+	return contractInitCode(eth.StateContract{Code: hexutil.Encode(runtime)})
+}
+
+// contractInitCode returns creation code that fills a contract's storage and returns its runtime code
+func contractInitCode(contract eth.StateContract) ([]byte, error) {
+	runtime, err := hexutil.Decode(utils.AddHexPrefix(contract.Code))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid code")
+	}
+
+	slots := make([]string, 0, len(contract.Storage))
+	for slot := range contract.Storage {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+
+	var code []byte
+	for _, slot := range slots {
+		key, err := storageWord(slot)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid storage slot %s", slot)
+		}
+		value, err := storageWord(contract.Storage[slot])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid storage value of slot %s", slot)
+		}
+		if value == (common.Hash{}) {
+			continue
+		}
+		// PUSH32 value PUSH32 slot SSTORE
+		code = append(code, 0x7f)
+		code = append(code, value.Bytes()...)
+		code = append(code, 0x7f)
+		code = append(code, key.Bytes()...)
+		code = append(code, 0x55)
+	}
+
+	// PUSH4 size DUP1 PUSH4 offset PUSH1 0 CODECOPY PUSH1 0 RETURN, followed by the runtime code
+	offset := len(code) + 17
+	code = append(code, 0x63)
+	code = append(code, uint32Bytes(len(runtime))...)
+	code = append(code, 0x80, 0x63)
+	code = append(code, uint32Bytes(offset)...)
+	code = append(code, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3)
+	return append(code, runtime...), nil
+}
+
+func storageWord(value string) (common.Hash, error) {
+	value = utils.RemoveHexPrefix(value)
+	if len(value)%2 != 0 {
+		value = "0" + value
+	}
+	decoded, err := hexutil.Decode(utils.AddHexPrefix(value))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(decoded) > common.HashLength {
+		return common.Hash{}, errors.Errorf("longer than %d bytes", common.HashLength)
+	}
+	return common.BytesToHash(decoded), nil
+}
+
+func uint32Bytes(n int) []byte {
+	return []byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
@@ -0,0 +1,186 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+func TestDevDumpStateRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exampleAcc, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(exampleAcc)
+
+	responses := map[string]interface{}{
+		qtum.MethodGetBlockCount:     qtum.GetBlockCountResponse{Int: big.NewInt(600)},
+		qtum.MethodGetAddressBalance: qtum.GetAddressBalanceResponse{Balance: 300000000},
+		qtum.MethodListContracts:     qtum.ListContractsResponse{"c89a5d225f578d84a94741490c1b40889b4f7a00": decimal.RequireFromString("0.5")},
+		qtum.MethodGetAccountInfo: qtum.GetAccountInfoResponse{
+			Address: "c89a5d225f578d84a94741490c1b40889b4f7a00",
+			Balance: 50000000,
+			Storage: json.RawMessage(`{"290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563": {"0000000000000000000000000000000000000000000000000000000000000000": "000000000000000000000000000000000000000000000000000000000000002a"}}`),
+			Code:    "6080604052",
+		},
+	}
+	for method, response := range responses {
+		if err := mockedClientDoer.AddResponse(method, response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyDevDumpState{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := &eth.StateFixture{
+		ChainID:     "0x22ba",
+		BlockNumber: "0x258",
+		Accounts: []eth.StateAccount{
+			{Address: "0x6d358cf96533189dd5a602d0937fddf0888ad3ae", Balance: "0x29a2241af62c0000"},
+		},
+		Contracts: []eth.StateContract{{
+			Address: "0xc89a5d225f578d84a94741490c1b40889b4f7a00",
+			Balance: "0x6f05b59d3b20000",
+			Code:    "0x6080604052",
+			Storage: map[string]string{
+				"0x0000000000000000000000000000000000000000000000000000000000000000": "0x000000000000000000000000000000000000000000000000000000000000002a",
+			},
+		}},
+	}
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestDevLoadStateRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exampleAcc, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(exampleAcc)
+
+	responses := map[string]interface{}{
+		// the account already has its balance
+		qtum.MethodGetAddressBalance: qtum.GetAddressBalanceResponse{Balance: 500000000},
+		qtum.MethodFromHexAddress:    qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"),
+		qtum.MethodCreateContract: qtum.CreateContractResponse{
+			Txid:    "d0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f",
+			Address: "1286595f8683ae074bc026cf0e587177b36842e2",
+		},
+		qtum.MethodGenerateToAddress: qtum.GenerateResponse{"6e4ea2d2a5c8e9fd8b1e7e2e29c3a5b4d3f1e2c3b4a5968778695a4b3c2d1e0f"},
+		qtum.MethodGetBlockCount:     qtum.GetBlockCountResponse{Int: big.NewInt(700)},
+		// paying the contract deployed to restore the balance of the copy
+		qtum.MethodSendToContract: qtum.SendToContractResponse{Txid: "3b1f2a4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708"},
+	}
+	for method, response := range responses {
+		if err := mockedClientDoer.AddResponse(method, response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixture := eth.StateFixture{
+		ChainID:     "0x22ba",
+		BlockNumber: "0x258",
+		Accounts: []eth.StateAccount{
+			{Address: "0x6d358cf96533189dd5a602d0937fddf0888ad3ae", Balance: "0x29a2241af62c0000"},
+			{Address: "0x7e22630f90e6db16283af2c6b04f688117a55db4", Balance: "0x29a2241af62c0000"},
+		},
+		Contracts: []eth.StateContract{{
+			Address: "0xc89a5d225f578d84a94741490c1b40889b4f7a00",
+			Balance: "0x6f05b59d3b20000",
+			Code:    "0x6080604052",
+			Storage: map[string]string{"0x0": "0x2a"},
+		}},
+	}
+	rawFixture, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{rawFixture})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyDevLoadState{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := &eth.LoadStateResponse{
+		BlockNumber:     "0x2bc",
+		FundedAccounts:  []string{"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"},
+		SkippedAccounts: []string{"0x7e22630f90e6db16283af2c6b04f688117a55db4"},
+		Contracts: []eth.LoadedContract{{
+			Address:         "0xc89a5d225f578d84a94741490c1b40889b4f7a00",
+			NewAddress:      "0x1286595f8683ae074bc026cf0e587177b36842e2",
+			TransactionHash: "0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f",
+
+			BalanceTransactionHash: "0x3b1f2a4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708",
+		}},
+	}
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestContractInitCode(t *testing.T) {
+	code, err := contractInitCode(eth.StateContract{
+		Code:    "0x6080",
+		Storage: map[string]string{"0x1": "0x2a", "0x2": "0x0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "0x" +
+		// PUSH32 0x2a PUSH32 0x1 SSTORE, the zero slot is left out
+		"7f000000000000000000000000000000000000000000000000000000000000002a" +
+		"7f0000000000000000000000000000000000000000000000000000000000000001" +
+		"55" +
+		// PUSH4 2 DUP1 PUSH4 84 PUSH1 0 CODECOPY PUSH1 0 RETURN
+		"6300000002" + "80" + "6300000054" + "6000" + "39" + "6000" + "f3" +
+		"6080"
+	if got := hexutil.Encode(code); got != want {
+		t.Errorf("got %s, expected %s", got, want)
+	}
+}
+
+func TestFunderInitCode(t *testing.T) {
+	code, err := funderInitCode("1286595f8683ae074bc026cf0e587177b36842e2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "0x" +
+		// PUSH4 22 DUP1 PUSH4 17 PUSH1 0 CODECOPY PUSH1 0 RETURN
+		"6300000016" + "80" + "6300000011" + "6000" + "39" + "6000" + "f3" +
+		// PUSH20 target SELFDESTRUCT
+		"73" + "1286595f8683ae074bc026cf0e587177b36842e2" + "ff"
+	if got := hexutil.Encode(code); got != want {
+		t.Errorf("got %s, expected %s", got, want)
+	}
+
+	if _, err := funderInitCode("1286"); err == nil {
+		t.Error("expected a short address to be refused")
+	}
+}
@@ -0,0 +1,101 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyDevSetMockTime implements ETHProxy
+// takes [timestamp] and makes regtest qtumd use it as the current time, 0 goes back to the clock
+type ProxyDevSetMockTime struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevSetMockTime)(nil)
+
+func (p *ProxyDevSetMockTime) Method() string {
+	return "dev_setMockTime"
+}
+
+func (p *ProxyDevSetMockTime) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	timestamp, jsonErr := parseDevTimestamp(p.Qtum, req)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if err := p.SetMockTime(ctx, timestamp); err != nil {
+		return nil, eth.NewInvalidRequestError(err.Error())
+	}
+	return hexutil.EncodeUint64(uint64(timestamp)), nil
+}
+
+// ProxyDevSetBlockTime implements ETHProxy
+// takes [timestamp] and mines a block at that time. qtumd keeps using the timestamp as the current time afterwards,
+// until dev_setMockTime changes it, so later blocks don't jump back to the clock
+type ProxyDevSetBlockTime struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyDevSetBlockTime)(nil)
+
+func (p *ProxyDevSetBlockTime) Method() string {
+	return "dev_setBlockTime"
+}
+
+func (p *ProxyDevSetBlockTime) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	timestamp, jsonErr := parseDevTimestamp(p.Qtum, req)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if timestamp == 0 {
+		return nil, eth.NewInvalidParamsError("a block can't be mined at timestamp 0")
+	}
+	if err := p.SetMockTime(ctx, timestamp); err != nil {
+		return nil, eth.NewInvalidRequestError(err.Error())
+	}
+
+	hashes, err := p.Generate(ctx, 1, nil)
+	if err != nil {
+		return nil, eth.NewInvalidRequestError(err.Error())
+	}
+	if len(hashes) == 0 {
+		return nil, eth.NewCallbackError("qtumd didn't mine a block")
+	}
+
+	// qtumd moves the timestamp past the median time of the previous blocks
+	header, err := p.GetBlockHeader(ctx, hashes[0])
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return &eth.SetBlockTimeResponse{
+		BlockHash:   utils.AddHexPrefix(hashes[0]),
+		BlockNumber: hexutil.EncodeUint64(uint64(header.Height)),
+		Timestamp:   hexutil.EncodeUint64(header.Time),
+	}, nil
+}
+
+func parseDevTimestamp(p *qtum.Qtum, req *eth.JSONRPCRequest) (int64, eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return 0, eth.NewInvalidRequestError("Can only set the time on regtest")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil {
+		return 0, eth.NewInvalidParamsError("couldn't unmarshal request parameters")
+	}
+	if len(params) != 1 {
+		return 0, eth.NewInvalidParamsError("require 1 argument: the timestamp in seconds")
+	}
+	timestamp, err := parseDevNumber(params[0])
+	if err != nil {
+		return 0, eth.NewInvalidParamsError("Couldn't parse timestamp: " + err.Error())
+	}
+	if timestamp < 0 {
+		return 0, eth.NewInvalidParamsError("timestamp must be >= 0")
+	}
+	return timestamp, nil
+}
@@ -0,0 +1,80 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestDevSetBlockTimeRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := "6e4ea2d2a5c8e9fd8b1e7e2e29c3a5b4d3f1e2c3b4a5968778695a4b3c2d1e0f"
+	mockedClientDoer.AddRawResponse(qtum.MethodSetMockTime, []byte(`{"result":null,"error":null,"id":1}`))
+	err = mockedClientDoer.AddResponse(qtum.MethodGenerateToAddress, qtum.GenerateResponse{blockHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{Hash: blockHash, Height: 601, Time: 1893456000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`1893456000`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyDevSetBlockTime{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := &eth.SetBlockTimeResponse{
+		BlockHash:   "0x" + blockHash,
+		BlockNumber: "0x259",
+		Timestamp:   "0x70dbd880",
+	}
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestDevSetMockTimeRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, qtum.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddRawResponse(qtum.MethodSetMockTime, []byte(`{"result":null,"error":null,"id":1}`))
+
+	for params, want := range map[string]interface{}{
+		`["0x70dbd880"]`: "0x70dbd880",
+		`[0]`:            "0x0",
+		`[-1]`:           nil,
+		`[]`:             nil,
+	} {
+		request, err := internal.PrepareEthRPCRequest(1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Params = json.RawMessage(params)
+		got, jsonErr := (&ProxyDevSetMockTime{qtumClient}).Request(context.Background(), request, internal.NewEchoContext())
+		if want == nil {
+			if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+				t.Errorf("%s: expected an invalid params error, got %v", params, jsonErr)
+			}
+			continue
+		}
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if got != want {
+			t.Errorf("%s: got %v, expected %v", params, got, want)
+		}
+	}
+}
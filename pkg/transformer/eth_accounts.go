@@ -1,6 +1,10 @@
 package transformer
 
 import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -16,20 +20,67 @@ func (p *ProxyETHAccounts) Method() string {
 	return "eth_accounts"
 }
 
-func (p *ProxyETHAccounts) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request()
+func (p *ProxyETHAccounts) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
 }
 
-func (p *ProxyETHAccounts) request() (eth.AccountsResponse, eth.JSONRPCError) {
+func (p *ProxyETHAccounts) request(ctx context.Context) (eth.AccountsResponse, eth.JSONRPCError) {
 	var accounts eth.AccountsResponse
 
-	for _, acc := range p.Accounts {
-		acc := qtum.Account{acc}
-		addr := acc.ToHexAddress()
-
+	for _, addr := range p.Keystore.Addresses() {
 		accounts = append(accounts, utils.AddHexPrefix(addr))
 	}
 
+	if !p.GetFlagBool(qtum.FLAG_WALLET_ACCOUNTS) {
+		return accounts, nil
+	}
+
+	walletAccounts, err := p.walletAccounts(ctx)
+	if err != nil {
+		if err == qtum.ErrMethodNotFound || err == qtum.ErrWalletNotFound {
+			// qtumd runs without a wallet, only the configured accounts are available
+			level.Warn(p.GetLogger()).Log("msg", "Couldn't list qtumd wallet addresses for eth_accounts", "error", err)
+			return accounts, nil
+		}
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	seen := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		seen[account] = true
+	}
+	for _, account := range walletAccounts {
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+
+	return accounts, nil
+}
+
+// walletAccounts lists the pubkey hash addresses of qtumd's wallet as hex addresses, including addresses that haven't
+// received anything yet
+func (p *ProxyETHAccounts) walletAccounts(ctx context.Context) ([]string, error) {
+	received, err := p.ListReceivedByAddress(ctx, &qtum.ListReceivedByAddressRequest{
+		MinimumConfirmations: 0,
+		IncludeEmpty:         true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []string
+	for _, entry := range received {
+		if !qtum.IsPubKeyHashAddress(entry.Address, p.IsMain()) {
+			continue
+		}
+		hexAddress, err := utils.ConvertQtumAddress(entry.Address)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, utils.AddHexPrefix(strings.ToLower(hexAddress)))
+	}
 	return accounts, nil
 }
 
@@ -1,10 +1,12 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/internal"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -32,11 +34,11 @@ func TestAccountRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	qtumClient.Accounts = append(qtumClient.Accounts, exampleAcc1, exampleAcc2)
+	qtumClient.Keystore.Add(exampleAcc1, exampleAcc2)
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHAccounts{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr.Error())
 	}
@@ -46,6 +48,91 @@ func TestAccountRequest(t *testing.T) {
 	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
 }
 
+func TestAccountRequestWithWallet(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_WALLET_ACCOUNTS, true)
+
+	exampleAcc1, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exampleAcc2, err := btcutil.DecodeWIF("5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(exampleAcc1)
+
+	// the wallet holds a configured account, another account and a script hash address
+	walletAcc1, err := (&qtum.Account{WIF: exampleAcc1}).ToBase58Address(qtumClient.IsMain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletAcc2, err := (&qtum.Account{WIF: exampleAcc2}).ToBase58Address(qtumClient.IsMain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptHash := base58.CheckEncode(make([]byte, 20), 50)
+	err = mockedClientDoer.AddResponse(qtum.MethodListReceivedByAddress, qtum.ListReceivedByAddressResponse{
+		{Address: walletAcc1},
+		{Address: scriptHash},
+		{Address: walletAcc2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHAccounts{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Error())
+	}
+
+	want := eth.AccountsResponse{"0x6d358cf96533189dd5a602d0937fddf0888ad3ae", "0x7e22630f90e6db16283af2c6b04f688117a55db4"}
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
+func TestAccountRequestWithoutWallet(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_WALLET_ACCOUNTS, true)
+	// qtumd started with -disablewallet
+	mockedClientDoer.AddError(qtum.MethodListReceivedByAddress, eth.NewJSONRPCError(-32601, "Method not found", nil))
+
+	exampleAcc1, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(exampleAcc1)
+
+	proxyEth := ProxyETHAccounts{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Error())
+	}
+
+	want := eth.AccountsResponse{"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"}
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
+
 func TestAccountMethod(t *testing.T) {
 	mockedClientDoer := internal.NewDoerMappedMock()
 	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
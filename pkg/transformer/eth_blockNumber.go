@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"strings"
 	"time"
 
@@ -19,15 +20,14 @@ func (p *ProxyETHBlockNumber) Method() string {
 	return "eth_blockNumber"
 }
 
-func (p *ProxyETHBlockNumber) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request(c, 5)
+func (p *ProxyETHBlockNumber) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx, 5)
 }
 
-func (p *ProxyETHBlockNumber) request(c echo.Context, retries int) (*eth.BlockNumberResponse, eth.JSONRPCError) {
-	qtumresp, err := p.Qtum.GetBlockCount(c.Request().Context())
+func (p *ProxyETHBlockNumber) request(ctx context.Context, retries int) (*eth.BlockNumberResponse, eth.JSONRPCError) {
+	qtumresp, err := p.Qtum.GetBlockCount(ctx)
 	if err != nil {
 		if retries > 0 && strings.Contains(err.Error(), qtum.ErrTryAgain.Error()) {
-			ctx := c.Request().Context()
 			t := time.NewTimer(500 * time.Millisecond)
 			select {
 			case <-ctx.Done():
@@ -35,7 +35,7 @@ func (p *ProxyETHBlockNumber) request(c echo.Context, retries int) (*eth.BlockNu
 			case <-t.C:
 				// fallthrough
 			}
-			return p.request(c, retries-1)
+			return p.request(ctx, retries-1)
 		}
 		return nil, eth.NewCallbackError(err.Error())
 	}
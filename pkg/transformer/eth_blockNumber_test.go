@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"math/big"
 	"testing"
@@ -33,7 +34,7 @@ func TestBlockNumberRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHBlockNumber{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
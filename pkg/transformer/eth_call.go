@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -19,17 +20,28 @@ func (p *ProxyETHCall) Method() string {
 	return "eth_call"
 }
 
-func (p *ProxyETHCall) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHCall) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.CallRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Is this correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(ctx, &req)
 }
 
 func (p *ProxyETHCall) request(ctx context.Context, ethreq *eth.CallRequest) (interface{}, eth.JSONRPCError) {
+	if jsonErr := checkStateOverride(ethreq); jsonErr != nil {
+		if !p.GetFlagBool(qtum.FLAG_LOCAL_EVM) {
+			return nil, jsonErr
+		}
+		return p.localRequest(ctx, ethreq)
+	}
+	// callcontract runs against the state of the chain as it is, whatever the block
+	if isMulticall(p.Qtum, ethreq) {
+		return p.multicallRequest(ctx, ethreq)
+	}
+
 	// eth req -> qtum req
 	qtumreq, jsonErr := p.ToRequest(ethreq)
 	if jsonErr != nil {
@@ -96,3 +108,35 @@ func (p *ProxyETHCall) ToResponse(qresp *qtum.CallContractResponse) interface{}
 	return &qtumresp
 
 }
+
+// localRequest runs a call with overrides qtumd can't apply in the local EVM
+func (p *ProxyETHCall) localRequest(ctx context.Context, ethreq *eth.CallRequest) (interface{}, eth.JSONRPCError) {
+	call, jsonErr := newLocalCall(ctx, p.Qtum, ethreq)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	result, jsonErr := call.run(ctx, nil)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if result.Err != nil {
+		return nil, localCallError(result)
+	}
+	resp := eth.CallResponse(hexutil.Encode(result.ReturnData))
+	return &resp, nil
+}
+
+// checkStateOverride rejects the state overrides qtumd can't apply, callcontract runs against the state of the chain as
+// it is. Qtum contracts don't see nonces, so overriding them changes nothing. Contracts reading a balance, the sender's
+// too, would see the real one, so balance overrides are rejected rather than ignored
+func checkStateOverride(ethreq *eth.CallRequest) eth.JSONRPCError {
+	for address, account := range ethreq.Overrides {
+		if account.Code != nil || account.State != nil || account.StateDiff != nil {
+			return eth.NewInvalidParamsError("overriding the code or storage of " + address + " isn't supported, qtumd runs calls against the state of the chain. Janus can run them itself with --local-evm")
+		}
+		if account.Balance != nil {
+			return eth.NewInvalidParamsError("overriding the balance of " + address + " isn't supported, qtumd runs calls against the state of the chain. Janus can run them itself with --local-evm")
+		}
+	}
+	return nil
+}
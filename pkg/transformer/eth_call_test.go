@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
@@ -45,12 +46,7 @@ func TestEthCallRequest(t *testing.T) {
 			NewAddress: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960",
 			Output:     "0000000000000000000000000000000000000000000000000000000000000001",
 		},
-		TransactionReceipt: struct {
-			StateRoot string        `json:"stateRoot"`
-			GasUsed   int           `json:"gasUsed"`
-			Bloom     string        `json:"bloom"`
-			Log       []interface{} `json:"log"`
-		}{
+		TransactionReceipt: qtum.CallContractTransactionReceipt{
 			StateRoot: "d44fc5ad43bae52f01ff7eb4a7bba904ee52aea6c41f337aa29754e57c73fba6",
 			GasUsed:   21678,
 			Bloom:     "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
@@ -73,7 +69,7 @@ func TestEthCallRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -118,12 +114,7 @@ func TestRetry(t *testing.T) {
 			NewAddress: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960",
 			Output:     "0000000000000000000000000000000000000000000000000000000000000001",
 		},
-		TransactionReceipt: struct {
-			StateRoot string        `json:"stateRoot"`
-			GasUsed   int           `json:"gasUsed"`
-			Bloom     string        `json:"bloom"`
-			Log       []interface{} `json:"log"`
-		}{
+		TransactionReceipt: qtum.CallContractTransactionReceipt{
 			StateRoot: "d44fc5ad43bae52f01ff7eb4a7bba904ee52aea6c41f337aa29754e57c73fba6",
 			GasUsed:   21678,
 			Bloom:     "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
@@ -151,7 +142,7 @@ func TestRetry(t *testing.T) {
 
 	before := time.Now()
 
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -202,7 +193,7 @@ func TestEthCallRequestOnUnknownContract(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -211,3 +202,37 @@ func TestEthCallRequestOnUnknownContract(t *testing.T) {
 
 	internal.CheckTestResultEthRequestCall(request, &want, got, t, false)
 }
+
+func TestEthCallStateOverride(t *testing.T) {
+	const sender = "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	tests := []struct {
+		overrides string
+		supported bool
+	}{
+		{`null`, true},
+		{`{}`, true},
+		{`{"` + sender + `": {"balance": "0xde0b6b3a7640000", "nonce": "0x5"}}`, false},
+		{`{"0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960": {"balance": "0x1"}}`, false},
+		{`{"` + sender + `": {"nonce": "0x5"}}`, true},
+		{`{"0x2e6f89d7399081b4f8f8aa1ae2805a5efff2f960": {"nonce": "0x1"}}`, true},
+		{`{"0x2e6f89d7399081b4f8f8aa1ae2805a5efff2f960": {"balance": "0x1"}}`, false},
+		{`{"` + sender + `": {"code": "0x6000"}}`, false},
+		{`{"` + sender + `": {"stateDiff": {"0x0": "0x1"}}}`, false},
+	}
+	for _, test := range tests {
+		params := []byte(`[{"from": "` + sender + `", "to": "` + sender + `"}, "latest", ` + test.overrides + `]`)
+		var request eth.CallRequest
+		if err := json.Unmarshal(params, &request); err != nil {
+			t.Fatalf("%s: %v", test.overrides, err)
+		}
+		if jsonErr := checkStateOverride(&request); (jsonErr == nil) != test.supported {
+			t.Errorf("%s: expected supported to be %v, got %v", test.overrides, test.supported, jsonErr)
+		}
+	}
+
+	var request eth.CallRequest
+	params := []byte(`[{"to": "` + sender + `"}, "latest", {"` + sender + `": {"state": {}, "stateDiff": {}}}]`)
+	if err := json.Unmarshal(params, &request); err == nil {
+		t.Error("expected state and stateDiff together to be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -17,7 +18,7 @@ func (p *ProxyETHChainId) Method() string {
 	return "eth_chainId"
 }
 
-func (p *ProxyETHChainId) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHChainId) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	chainId, err := getChainId(p.Qtum)
 	if err != nil {
 		return nil, err
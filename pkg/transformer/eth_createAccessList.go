@@ -0,0 +1,90 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/evm"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// ProxyETHCreateAccessList implements ETHProxy
+type ProxyETHCreateAccessList struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHCreateAccessList) Method() string {
+	return "eth_createAccessList"
+}
+
+func (p *ProxyETHCreateAccessList) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	// qtumd doesn't report the accounts and slots a call touches
+	if !p.GetFlagBool(qtum.FLAG_LOCAL_EVM) {
+		return nil, localEVMDisabledError(p.Method())
+	}
+
+	var req eth.CallRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	req.Overrides = nil
+
+	// QTUM derives the address of a new contract from the transaction creating it, which isn't known before it's
+	// signed, while the local EVM would create it at the address Ethereum derives from the sender
+	if req.To == "" {
+		return nil, eth.NewInvalidParamsError("can't create an access list for a contract creation, QTUM derives the contract's address from the transaction creating it")
+	}
+
+	call, jsonErr := newLocalCall(ctx, p.Qtum, &req)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	to := *call.msg.To
+	precompiles := vm.ActivePrecompiles(evm.ChainConfig(call.chainID).Rules(call.block.Number, false))
+
+	// like geth, the call runs with the access list of the previous run until it stops changing, the accounts and
+	// slots it touches can depend on the gas it has left
+	previous := logger.NewAccessListTracer(nil, call.msg.From, to, precompiles)
+	for {
+		accessList := previous.AccessList()
+		call.msg.AccessList = accessList
+		tracer := logger.NewAccessListTracer(accessList, call.msg.From, to, precompiles)
+		result, jsonErr := call.run(ctx, tracer)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		if tracer.Equal(previous) {
+			resp := &eth.CreateAccessListResponse{
+				AccessList: toEthAccessList(accessList),
+				GasUsed:    hexutil.EncodeUint64(result.GasUsed),
+			}
+			if result.Err != nil {
+				resp.Error = result.Err.Error()
+			}
+			return resp, nil
+		}
+		previous = tracer
+	}
+}
+
+func toEthAccessList(accessList types.AccessList) []eth.AccessListEntry {
+	entries := make([]eth.AccessListEntry, 0, len(accessList))
+	for _, tuple := range accessList {
+		entry := eth.AccessListEntry{
+			Address:     strings.ToLower(tuple.Address.Hex()),
+			StorageKeys: make([]string, 0, len(tuple.StorageKeys)),
+		}
+		for _, key := range tuple.StorageKeys {
+			entry.StorageKeys = append(entry.StorageKeys, key.Hex())
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
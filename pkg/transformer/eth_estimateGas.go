@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
@@ -24,13 +25,20 @@ func (p *ProxyETHEstimateGas) Method() string {
 	return "eth_estimateGas"
 }
 
-func (p *ProxyETHEstimateGas) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHEstimateGas) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var ethreq eth.CallRequest
 	if jsonErr := unmarshalRequest(rawreq.Params, &ethreq); jsonErr != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(jsonErr.Error())
 	}
 
+	if jsonErr := checkStateOverride(&ethreq); jsonErr != nil {
+		if !p.GetFlagBool(qtum.FLAG_LOCAL_EVM) {
+			return nil, jsonErr
+		}
+		return p.localEstimate(ctx, &ethreq)
+	}
+
 	if ethreq.Data == "" {
 		response := eth.EstimateGasResponse(NonContractVMGasLimit)
 		return &response, nil
@@ -52,7 +60,7 @@ func (p *ProxyETHEstimateGas) Request(rawreq *eth.JSONRPCRequest, c echo.Context
 	}
 
 	// qtum [code: -5] Incorrect address occurs here
-	qtumresp, err := p.CallContract(c.Request().Context(), qtumreq)
+	qtumresp, err := p.CallContract(ctx, qtumreq)
 	if err != nil {
 		return nil, eth.NewCallbackError(err.Error())
 	}
@@ -60,6 +68,25 @@ func (p *ProxyETHEstimateGas) Request(rawreq *eth.JSONRPCRequest, c echo.Context
 	return p.toResp(qtumresp)
 }
 
+// localEstimate estimates the gas of a call with overrides qtumd can't apply in the local EVM
+func (p *ProxyETHEstimateGas) localEstimate(ctx context.Context, ethreq *eth.CallRequest) (*eth.EstimateGasResponse, eth.JSONRPCError) {
+	// like callcontract, the call gets all the gas it can use
+	ethreq.Gas = nil
+	call, jsonErr := newLocalCall(ctx, p.Qtum, ethreq)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	result, jsonErr := call.run(ctx, nil)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if result.Err != nil {
+		return nil, localCallError(result)
+	}
+	gas := eth.EstimateGasResponse(hexutil.EncodeUint64(uint64(float64(result.GasUsed) * GAS_BUFFER)))
+	return &gas, nil
+}
+
 func (p *ProxyETHEstimateGas) toResp(qtumresp *qtum.CallContractResponse) (*eth.EstimateGasResponse, eth.JSONRPCError) {
 	if qtumresp.ExecutionResult.Excepted != "None" {
 		return nil, eth.NewCallbackError(ErrExecutionReverted.Error())
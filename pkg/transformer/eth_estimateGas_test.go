@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -64,7 +65,7 @@ func TestEstimateGasRequest(t *testing.T) {
 	//preparing proxy & executing request
 	proxyEth := ProxyETHCall{qtumClient}
 	proxyEthEstimateGas := ProxyETHEstimateGas{&proxyEth}
-	got, jsonErr := proxyEthEstimateGas.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEthEstimateGas.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -130,7 +131,7 @@ func TestEstimateGasRequestExecutionReverted(t *testing.T) {
 	proxyEth := ProxyETHCall{qtumClient}
 	proxyEthEstimateGas := ProxyETHEstimateGas{&proxyEth}
 
-	_, got := proxyEthEstimateGas.Request(requestRPC, internal.NewEchoContext())
+	_, got := proxyEthEstimateGas.Request(context.Background(), requestRPC, internal.NewEchoContext())
 
 	want := eth.NewCallbackError(ErrExecutionReverted.Error())
 
@@ -191,7 +192,7 @@ func TestEstimateGasNonVMRequest(t *testing.T) {
 	//preparing proxy & executing request
 	proxyEth := ProxyETHCall{qtumClient}
 	proxyEthEstimateGas := ProxyETHEstimateGas{&proxyEth}
-	got, jsonErr := proxyEthEstimateGas.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEthEstimateGas.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -0,0 +1,211 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// maxFeeHistoryBlocks caps the blocks of a single eth_feeHistory request, like geth
+const maxFeeHistoryBlocks = 1024
+
+// feeHistoryConcurrency bounds the blocks fetched at once for one request
+var feeHistoryConcurrency = 8
+
+// ProxyETHFeeHistory implements ETHProxy
+type ProxyETHFeeHistory struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyETHFeeHistory)(nil)
+
+func (p *ProxyETHFeeHistory) Method() string {
+	return "eth_feeHistory"
+}
+
+func (p *ProxyETHFeeHistory) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.FeeHistoryRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return p.request(ctx, &req)
+}
+
+// blockFees is what eth_feeHistory reports of a block
+type blockFees struct {
+	gasUsedRatio float64
+	rewards      []*big.Int
+}
+
+// Qtum has no base fee, the minimum gas price stands in for it so wallets never offer less. Rewards are what
+// contract transactions paid over it, transactions not running in the EVM don't pay for gas
+func (p *ProxyETHFeeHistory) request(ctx context.Context, req *eth.FeeHistoryRequest) (*eth.FeeHistoryResponse, eth.JSONRPCError) {
+	if req.BlockCount == 0 {
+		return &eth.FeeHistoryResponse{
+			OldestBlock:   "0x0",
+			BaseFeePerGas: []string{},
+			GasUsedRatio:  []float64{},
+		}, nil
+	}
+	blockCount := req.BlockCount
+	if blockCount > maxFeeHistoryBlocks {
+		blockCount = maxFeeHistoryBlocks
+	}
+
+	newest, jsonErr := resolveBlock(ctx, p.Qtum, req.NewestBlock, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if newest == nil {
+		return nil, eth.NewInvalidParamsError("unknown block")
+	}
+	if newest.Number.Uint64()+1 < blockCount {
+		blockCount = newest.Number.Uint64() + 1
+	}
+	oldest := newest.Number.Uint64() + 1 - blockCount
+
+	minimumGasPrice, err := p.GetGasPrice(ctx)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	baseFee := satoshisToWei(minimumGasPrice)
+	blockGasLimit, _ := new(big.Int).SetString(qtum.DefaultBlockGasLimit, 16)
+	// blocks are as full as their weight is of the maximum in the weight block gas mode, like eth_getBlockByNumber reports
+	weightGas := blockFieldMode(p.Qtum, qtum.FLAG_BLOCK_GAS) == qtum.BlockGasWeight
+	if weightGas {
+		blockGasLimit = big.NewInt(qtum.MaxBlockWeight)
+	}
+
+	fees := make([]*blockFees, blockCount)
+	errs := make([]error, blockCount)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, feeHistoryConcurrency)
+	for i := uint64(0); i < blockCount; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i uint64) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fees[i], errs[i] = p.blockFees(ctx, new(big.Int).SetUint64(oldest+i), baseFee, blockGasLimit, weightGas, req.RewardPercentiles)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	resp := &eth.FeeHistoryResponse{
+		OldestBlock:   hexutil.EncodeUint64(oldest),
+		BaseFeePerGas: make([]string, 0, blockCount+1),
+		GasUsedRatio:  make([]float64, 0, blockCount),
+	}
+	if len(req.RewardPercentiles) != 0 {
+		resp.Reward = make([][]string, 0, blockCount)
+	}
+	for i, blockFees := range fees {
+		if errs[i] != nil {
+			p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block fees", "block", oldest+uint64(i), "err", errs[i])
+			return nil, eth.NewCallbackError(errs[i].Error())
+		}
+		resp.BaseFeePerGas = append(resp.BaseFeePerGas, hexutil.EncodeBig(baseFee))
+		resp.GasUsedRatio = append(resp.GasUsedRatio, blockFees.gasUsedRatio)
+		if resp.Reward != nil {
+			rewards := make([]string, 0, len(blockFees.rewards))
+			for _, reward := range blockFees.rewards {
+				rewards = append(rewards, hexutil.EncodeBig(reward))
+			}
+			resp.Reward = append(resp.Reward, rewards)
+		}
+	}
+	// the base fee of the block after the newest one
+	resp.BaseFeePerGas = append(resp.BaseFeePerGas, hexutil.EncodeBig(baseFee))
+
+	return resp, nil
+}
+
+type transactionReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+func (p *ProxyETHFeeHistory) blockFees(ctx context.Context, number *big.Int, baseFee *big.Int, blockGasLimit *big.Int, weightGas bool, percentiles []float64) (*blockFees, error) {
+	hash, err := p.GetBlockHash(ctx, number)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get block hash")
+	}
+	block, err := p.GetBlockWithTransactions(ctx, string(hash))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get block")
+	}
+
+	var gasUsed uint64
+	var rewards []transactionReward
+	for _, tx := range block.Txs {
+		// parsing errors are discarded like in getTransactionCost, a transaction without a valid contract output has no gas
+		contractInfo, isContractTx, _ := tx.ExtractContractInfo()
+		if !isContractTx {
+			continue
+		}
+		receipt, err := p.GetTransactionReceipt(ctx, utils.RemoveHexPrefix(tx.ID))
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't get transaction receipt %s", tx.ID)
+		}
+		gasPrice, err := decodeScriptNumber(contractInfo.GasPrice)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse gas price of %s", tx.ID)
+		}
+		reward := new(big.Int).Sub(satoshisToWei(gasPrice), baseFee)
+		if reward.Sign() < 0 {
+			reward.SetInt64(0)
+		}
+		gasUsed += receipt.GasUsed
+		rewards = append(rewards, transactionReward{gasUsed: receipt.GasUsed, reward: reward})
+	}
+
+	fees := &blockFees{}
+	blockGasUsed := gasUsed
+	if weightGas {
+		blockGasUsed = uint64(block.Weight)
+	}
+	fees.gasUsedRatio, _ = new(big.Float).Quo(new(big.Float).SetUint64(blockGasUsed), new(big.Float).SetInt(blockGasLimit)).Float64()
+	fees.rewards = rewardPercentiles(rewards, gasUsed, percentiles)
+	return fees, nil
+}
+
+// rewardPercentiles weighs the rewards of transactions by the gas they used, like geth
+func rewardPercentiles(rewards []transactionReward, gasUsed uint64, percentiles []float64) []*big.Int {
+	result := make([]*big.Int, len(percentiles))
+	if len(rewards) == 0 {
+		for i := range result {
+			result[i] = big.NewInt(0)
+		}
+		return result
+	}
+	sort.SliceStable(rewards, func(i, j int) bool {
+		return rewards[i].reward.Cmp(rewards[j].reward) < 0
+	})
+	tx := 0
+	sumGasUsed := rewards[0].gasUsed
+	for i, percentile := range percentiles {
+		threshold := uint64(float64(gasUsed) * percentile / 100)
+		for sumGasUsed < threshold && tx < len(rewards)-1 {
+			tx++
+			sumGasUsed += rewards[tx].gasUsed
+		}
+		result[i] = rewards[tx].reward
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestFeeHistory(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x1"`), []byte(`"0xf8f"`), []byte(`[10, 90]`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(internal.GetTransactionByHashBlockHash)); err != nil {
+		t.Fatal(err)
+	}
+	block := qtum.GetBlockWithTransactionsResponse{
+		Hash:   internal.GetTransactionByHashBlockHash,
+		Height: 3983,
+		// a quarter of the maximum block weight
+		Weight: 2000000,
+		Txs: []*qtum.DecodedRawTransactionResponse{
+			// coinstake
+			{ID: "3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"},
+			// a call paying 50 satoshis per gas, 10 over the minimum
+			{
+				ID: "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950",
+				Vouts: []*qtum.DecodedRawTransactionOutV{{
+					ScriptPubKey: qtum.DecodedRawTransactionScriptPubKey{
+						Hex: "01011493594441cb5de8b497ad8467d55412c2a0ef36594c6b6a4730440220396b30b7a2f2af482e585473b7575dd2f989f3f3d7cdee55fa34e93f23d5254d022055326cdcab38c58dc3e65c458bfb656cca8340f59534c00ad98b4d4d3303f459012103379c39b6fb2c705db608f98a8fc064f94c66faf894996ca88595487f9ef04a6ec401040390d0030132043d666e8b140000000000000000000000000000000000000086c2",
+					},
+				}},
+			},
+		},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, block); err != nil {
+		t.Fatal(err)
+	}
+	receipts := []qtum.TransactionReceipt{{
+		TransactionHash: "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950",
+		GasUsed:         4000000,
+		Excepted:        "None",
+	}}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetTransactionReceipt, receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHFeeHistory{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := eth.FeeHistoryResponse{
+		OldestBlock: "0xf8f",
+		// 40 satoshis per gas
+		BaseFeePerGas: []string{"0x5d21dba000", "0x5d21dba000"},
+		GasUsedRatio:  []float64{0.1},
+		Reward:        [][]string{{"0x174876e800", "0x174876e800"}},
+	}
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+
+	// with the weight block gas mode blocks are as full as eth_getBlockByNumber says
+	if err := qtum.SetBlockFields(qtum.BlockGasWeight, "", "")(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr = proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want.GasUsedRatio = []float64{0.25}
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
+
+func TestFeeHistoryRequestValidation(t *testing.T) {
+	var req eth.FeeHistoryRequest
+	if err := json.Unmarshal([]byte(`[4, "latest", [50, 10]]`), &req); err == nil {
+		t.Error("expected percentiles out of order to be rejected")
+	}
+	if err := json.Unmarshal([]byte(`[4, "latest"]`), &req); err != nil || req.BlockCount != 4 {
+		t.Errorf("expected a plain block count to be accepted, got %d, %v", req.BlockCount, err)
+	}
+}
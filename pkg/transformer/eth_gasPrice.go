@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -18,8 +19,8 @@ func (p *ProxyETHGasPrice) Method() string {
 	return "eth_gasPrice"
 }
 
-func (p *ProxyETHGasPrice) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	qtumresp, err := p.Qtum.GetGasPrice(c.Request().Context())
+func (p *ProxyETHGasPrice) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	qtumresp, err := p.Qtum.GetGasPrice(ctx)
 	if err != nil {
 		return nil, eth.NewCallbackError(err.Error())
 	}
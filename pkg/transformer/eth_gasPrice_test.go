@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -23,7 +24,7 @@ func TestGasPriceRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGasPrice{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
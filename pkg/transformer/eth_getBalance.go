@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -19,21 +20,35 @@ func (p *ProxyETHGetBalance) Method() string {
 	return "eth_getBalance"
 }
 
-func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetBalance) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetBalanceRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
+	param, err := eth.ParseBlockParam(req.Block)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
 	addr := utils.RemoveHexPrefix(req.Address)
 	{
 		// is address a contract or an account?
 		qtumreq := qtum.GetAccountInfoRequest(addr)
-		qtumresp, err := p.GetAccountInfo(c.Request().Context(), &qtumreq)
+		qtumresp, err := p.GetAccountInfo(ctx, &qtumreq)
 
 		// the address is a contract
 		if err == nil {
+			// getaccountinfo only returns the latest balance
+			if jsonErr := checkLatestBlock(ctx, p.Qtum, req.Block, p.Method()); jsonErr != nil {
+				return nil, jsonErr
+			}
+			// which is the balance at the chain tip, --latest-confirmations keeps it out of "latest"
+			confirmed := param.IsEmpty() || param.Tag == eth.BlockTagLatest || param.Tag == eth.BlockTagSafe
+			if confirmed && p.GetFlagInt(qtum.FLAG_LATEST_CONFIRMATIONS) != nil {
+				return nil, eth.NewInvalidParamsError(p.Method() + " of a contract is only known at the chain tip, which isn't confirmed yet, ask for \"pending\" to get it")
+			}
 			// the unit of the balance Satoshi
 			p.GetDebugLogger().Log("method", p.Method(), "address", req.Address, "msg", "is a contract")
 			return hexutil.EncodeUint64(uint64(qtumresp.Balance)), nil
@@ -48,23 +63,101 @@ func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context)
 			return nil, eth.NewCallbackError(err.Error())
 		}
 
+		// contract balances don't need the address index, so only account balances are unavailable without it
+		if jsonErr := requireCapability(p.Qtum, qtum.CapabilityAddressIndex); jsonErr != nil {
+			return nil, jsonErr
+		}
+
+		// getaddressbalance is the balance at the chain tip, the balance at other blocks is summed up from the deltas
+		tip := param.IsEmpty() || param.Tag == eth.BlockTagLatest || param.Tag == eth.BlockTagPending || param.Tag == eth.BlockTagSafe
+		if !tip || p.GetFlagInt(qtum.FLAG_LATEST_CONFIRMATIONS) != nil {
+			blockNumber, jsonErr := resolveBlockParamNumber(ctx, p.Qtum, param, true)
+			if jsonErr != nil {
+				return nil, jsonErr
+			}
+			return p.requestBalanceAt(ctx, base58Addr, blockNumber)
+		}
+
 		qtumreq := qtum.GetAddressBalanceRequest{Address: base58Addr}
-		qtumresp, err := p.GetAddressBalance(c.Request().Context(), &qtumreq)
+		qtumresp, err := p.GetAddressBalance(ctx, &qtumreq)
 		if err != nil {
 			if err == qtum.ErrInvalidAddress {
 				// invalid address should return 0x0
 				return "0x0", nil
 			}
 			p.GetDebugLogger().Log("method", p.Method(), "address", req.Address, "msg", "error getting address balance", "error", err)
-			return nil, eth.NewCallbackError(err.Error())
+			return nil, qtumCallError(err)
 		}
 
 		// 1 QTUM = 10 ^ 8 Satoshi
 		balance := new(big.Int).SetUint64(qtumresp.Balance)
+		if isSpendableBalanceMode(p.Qtum) {
+			balance = spendableBalance(balance, qtumresp.Immature)
+		}
+
+		return hexutil.EncodeBig(satoshisToWei(balance)), nil
+	}
+}
 
-		//Balance for ETH response is represented in Weis (1 QTUM Satoshi = 10 ^ 10 Wei)
-		balance = balance.Mul(balance, big.NewInt(10000000000))
+// requestBalanceAt sums the balance changes of an address up to the block blockNumber
+func (p *ProxyETHGetBalance) requestBalanceAt(ctx context.Context, base58Addr string, blockNumber *big.Int) (interface{}, eth.JSONRPCError) {
+	if blockNumber.Sign() == 0 {
+		return "0x0", nil
+	}
 
-		return hexutil.EncodeBig(balance), nil
+	deltas, err := p.GetAddressDeltas(ctx, &qtum.GetAddressDeltasRequest{
+		Addresses: []string{base58Addr},
+		Start:     1,
+		End:       blockNumber.Int64(),
+	})
+	if err != nil {
+		if err == qtum.ErrInvalidAddress {
+			return "0x0", nil
+		}
+		p.GetDebugLogger().Log("method", p.Method(), "address", base58Addr, "msg", "error getting address deltas", "error", err)
+		return nil, qtumCallError(err)
 	}
+
+	balance := big.NewInt(0)
+	for _, delta := range deltas {
+		balance.Add(balance, big.NewInt(delta.Satoshis))
+	}
+
+	if isSpendableBalanceMode(p.Qtum) {
+		// qtumd only reports immature rewards at the tip, so they are taken from the deltas of the block
+		balance = spendableBalance(balance, immatureAt(deltas, blockNumber.Int64(), int64(p.GetMatureBlockHeight())))
+	}
+
+	return hexutil.EncodeBig(satoshisToWei(balance)), nil
+}
+
+// immatureAt sums the outputs of coinbase and coinstake transactions, the first two of a block, that are not yet
+// mature at the block height. Before the last proof of work block the second transaction of a block is an ordinary one
+func immatureAt(deltas qtum.GetAddressDeltasResponse, height int64, matureBlockHeight int64) int64 {
+	var immature int64
+	for _, delta := range deltas {
+		if delta.Satoshis > 0 && delta.BlockIndex <= 1 && height-delta.Height < matureBlockHeight {
+			immature += delta.Satoshis
+		}
+	}
+	return immature
+}
+
+func isSpendableBalanceMode(q *qtum.Qtum) bool {
+	mode := q.GetFlagString(qtum.FLAG_BALANCE_MODE)
+	return mode != nil && *mode == qtum.BalanceModeSpendable
+}
+
+// spendableBalance removes immature staking rewards from a balance in satoshis
+func spendableBalance(balance *big.Int, immature int64) *big.Int {
+	spendable := new(big.Int).Sub(balance, big.NewInt(immature))
+	if spendable.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return spendable
+}
+
+// Balance for ETH response is represented in Weis (1 QTUM Satoshi = 10 ^ 10 Wei)
+func satoshisToWei(satoshis *big.Int) *big.Int {
+	return new(big.Int).Mul(satoshis, big.NewInt(10000000000))
 }
@@ -1,17 +1,19 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/btcsuite/btcutil"
+	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/internal"
 	"github.com/qtumproject/janus/pkg/qtum"
 )
 
 func TestGetBalanceRequestAccount(t *testing.T) {
 	//prepare request
-	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"123"`)}
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
 	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
 	if err != nil {
 		t.Fatal(err)
@@ -28,7 +30,7 @@ func TestGetBalanceRequestAccount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	qtumClient.Accounts = append(qtumClient.Accounts, account)
+	qtumClient.Keystore.Add(account)
 
 	//prepare responses
 	fromHexAddressResponse := qtum.FromHexAddressResponse("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
@@ -49,7 +51,7 @@ func TestGetBalanceRequestAccount(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetBalance{qtumClient}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -61,7 +63,7 @@ func TestGetBalanceRequestAccount(t *testing.T) {
 
 func TestGetBalanceRequestContract(t *testing.T) {
 	//prepare request
-	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"123"`)}
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
 	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
 	if err != nil {
 		t.Fatal(err)
@@ -78,7 +80,7 @@ func TestGetBalanceRequestContract(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	qtumClient.Accounts = append(qtumClient.Accounts, account)
+	qtumClient.Keystore.Add(account)
 
 	//prepare responses
 	getAccountInfoResponse := qtum.GetAccountInfoResponse{
@@ -94,7 +96,7 @@ func TestGetBalanceRequestContract(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetBalance{qtumClient}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -103,3 +105,218 @@ func TestGetBalanceRequestContract(t *testing.T) {
 
 	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
 }
+
+func TestGetBalanceRequestAccountWithConfirmations(t *testing.T) {
+	//prepare request
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//prepare client
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_LATEST_CONFIRMATIONS, 6)
+
+	//prepare responses
+	fromHexAddressResponse := qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, fromHexAddressResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 106})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only deltas up to block 100 are requested, the sum is 1 QTUM
+	getAddressDeltasResponse := qtum.GetAddressDeltasResponse{
+		{Satoshis: 150000000, Height: 10},
+		{Satoshis: -50000000, Height: 100},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, getAddressDeltasResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//preparing proxy & executing request
+	proxyEth := ProxyETHGetBalance{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := string("0xde0b6b3a7640000") //1 Qtum represented in Wei
+
+	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
+}
+
+func TestGetBalanceWithoutAddressIndex(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromHexAddressResponse := qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")
+	for i := 0; i < 2; i++ {
+		if err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, fromHexAddressResponse); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = mockedClientDoer.AddError(qtum.MethodGetAddressBalance, eth.NewJSONRPCError(-5, "No information available for address", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetBalance{qtumClient}
+	for i := 0; i < 2; i++ {
+		// the second request fails without calling getaddressbalance
+		_, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+		if jsonErr == nil || jsonErr.Code() != eth.ResourceUnavailableErrorCode {
+			t.Fatalf("request %d: expected a temporarily unavailable error, got %v", i, jsonErr)
+		}
+	}
+
+	status := qtumClient.GetCapabilities().Statuses()[qtum.CapabilityAddressIndex]
+	if status.Available || status.Reason != "No information available for address" {
+		t.Errorf("unexpected address index status %+v", status)
+	}
+}
+
+func TestGetBalanceRequestAtBlock(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"0x64"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the balance of an address is summed up from its deltas to the block
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")); err != nil {
+		t.Fatal(err)
+	}
+	getAddressDeltasResponse := qtum.GetAddressDeltasResponse{
+		{Satoshis: 150000000, Height: 10},
+		{Satoshis: -50000000, Height: 100},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, getAddressDeltasResponse); err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyETHGetBalance{qtumClient}).Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultEthRequestRPC(*requestRPC, "0xde0b6b3a7640000", got, t, false)
+
+	// qtumd only returns the latest balance of a contract
+	mockedClientDoer = internal.NewDoerMappedMock()
+	qtumClient, err = internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{Balance: 12431243}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 106}); err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyETHGetBalance{qtumClient}).Request(context.Background(), requestRPC, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected the balance of a contract at an earlier block to be rejected, got %v", jsonErr)
+	}
+}
+
+func TestGetBalanceContractWithConfirmations(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := qtum.SetLatestConfirmations(6)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{Balance: 12431243}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(block string) (interface{}, eth.JSONRPCError) {
+		requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"` + block + `"`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (&ProxyETHGetBalance{qtumClient}).Request(context.Background(), requestRPC, internal.NewEchoContext())
+	}
+
+	// getaccountinfo only returns the unconfirmed balance at the chain tip
+	for _, block := range []string{"latest", "safe"} {
+		if _, jsonErr := request(block); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+			t.Errorf("expected the confirmed balance of a contract at %s to be rejected, got %v", block, jsonErr)
+		}
+	}
+	got, jsonErr := request("pending")
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got != "0xbdaf8b" {
+		t.Errorf("expected the balance at the chain tip, got %v", got)
+	}
+}
+
+func TestGetBalanceRequestAtBlockSpendableMode(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"0x64"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = qtum.SetBalanceMode(qtum.BalanceModeSpendable)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	matureBlockHeight := 50
+	if err = qtum.SetMatureBlockHeight(&matureBlockHeight)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")); err != nil {
+		t.Fatal(err)
+	}
+	// the coinstake at height 10 has matured by block 100, the one at height 60 hasn't, the transfer at height 80 isn't a reward
+	getAddressDeltasResponse := qtum.GetAddressDeltasResponse{
+		{Satoshis: 100000000, Height: 10, BlockIndex: 1},
+		{Satoshis: -100000000, Height: 60, BlockIndex: 1},
+		{Satoshis: 200000000, Height: 60, BlockIndex: 1},
+		{Satoshis: 50000000, Height: 80, BlockIndex: 3},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, getAddressDeltasResponse); err != nil {
+		t.Fatal(err)
+	}
+	// a later reward qtumd reports as immature at the tip must not be taken from the balance at block 100
+	getAddressBalanceResponse := qtum.GetAddressBalanceResponse{Balance: uint64(900000000), Immature: int64(800000000)}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressBalance, getAddressBalanceResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	got, jsonErr := (&ProxyETHGetBalance{qtumClient}).Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	// 2.5 QTUM less the 2 QTUM of the immature coinstake
+	internal.CheckTestResultEthRequestRPC(*requestRPC, "0x6f05b59d3b20000", got, t, false)
+}
@@ -3,6 +3,7 @@ package transformer
 import (
 	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
@@ -25,7 +26,7 @@ func (p *ProxyETHGetBlockByHash) Method() string {
 	return "eth_getBlockByHash"
 }
 
-func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetBlockByHash) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	req := new(eth.GetBlockByHashRequest)
 	if err := unmarshalRequest(rawreq.Params, req); err != nil {
 		// TODO: Correct error code?
@@ -43,7 +44,7 @@ func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Cont
 	resultChan := make(chan *eth.GetBlockByHashResponse, 2)
 	errorChan := make(chan eth.JSONRPCError, 1)
 	qtumBlockErrorChan := make(chan error, 1)
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
 
 	go func() {
@@ -56,7 +57,7 @@ func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Cont
 		resultChan <- result
 	}()
 
-	if bh == nil {
+	if bh == nil || p.GetCapabilities().Check(qtum.CapabilityBlockHashDatabase) != nil {
 		qtumBlockErrorChan <- ErrBlockHashNotConfigured
 	} else {
 		go func() {
@@ -127,6 +128,9 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 	}
 	block, err := p.GetBlock(ctx, req.BlockHash)
 	if err != nil {
+		if errors.Cause(err) == qtum.ErrBlockPruned {
+			return nil, blockPrunedError(ctx, p.Qtum)
+		}
 		p.GetDebugLogger().Log("msg", "couldn't get block", "blockHash", req.BlockHash)
 		return nil, eth.NewCallbackError("couldn't get block")
 	}
@@ -178,6 +182,18 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 		Timestamp:        hexutil.EncodeUint64(blockHeader.Time),
 	}
 
+	if blockFieldMode(p.Qtum, qtum.FLAG_BLOCK_DIFFICULTY) == qtum.BlockDifficultyGeth {
+		resp.Difficulty = "0x0"
+		// geth's proof of stake blocks keep the total difficulty the chain had when it stopped mining, which isn't 0, the
+		// chain work is what comes closest and never decreases either
+		if chainwork, ok := new(big.Int).SetString(blockHeader.Chainwork, 16); ok {
+			resp.TotalDifficulty = hexutil.EncodeBig(chainwork)
+		}
+	}
+	if blockFieldMode(p.Qtum, qtum.FLAG_BLOCK_TIMESTAMP) == qtum.BlockTimestampMedian {
+		resp.Timestamp = hexutil.EncodeUint64(uint64(blockHeader.Mediantime))
+	}
+
 	if blockHeader.IsGenesisBlock() {
 		resp.ParentHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
 		resp.Miner = utils.AddHexPrefix(qtum.ZeroAddress)
@@ -201,6 +217,11 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 	// gas limit value equalling to default gas limit of a block
 	resp.GasLimit = utils.AddHexPrefix(qtum.DefaultBlockGasLimit)
 	resp.GasUsed = "0x0"
+	weightGas := blockFieldMode(p.Qtum, qtum.FLAG_BLOCK_GAS) == qtum.BlockGasWeight
+	if weightGas {
+		resp.GasLimit = hexutil.EncodeUint64(qtum.MaxBlockWeight)
+		resp.GasUsed = hexutil.EncodeUint64(uint64(block.Weight))
+	}
 
 	// TODO: Future improvement: If getBlock is called with verbosity 2 it also returns full tx info as if getRawTransaction was called for each,
 	// so using that from the start instead of requesting each tx individually as done here would save a lot of back-and-forth
@@ -226,7 +247,6 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 			} else {
 				resp.Transactions = append(resp.Transactions, *tx)
 			}
-			// TODO: fill gas used
 			// TODO: fill gas limit?
 		}
 	} else {
@@ -240,5 +260,36 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 		}
 	}
 
+	// gas used is the cumulative gas used of the last transaction, the same whether or not the transactions are full
+	// the receipts are kept for eth_getBlockReceipts and eth_getLogs, which indexers ask for the same block next
+	if len(block.Txs) > 0 && (!weightGas || req.FullTransaction) {
+		blockReceipts, err := p.GetBlockReceipts(ctx, block)
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "couldn't get block receipts, leaving gas used out", "blockHash", req.BlockHash, "err", err)
+		} else if !weightGas {
+			cumulative := cumulativeGasUsed(block.Txs, blockReceipts)
+			resp.GasUsed = hexutil.EncodeUint64(cumulative[len(cumulative)-1])
+		}
+	}
+
 	return resp, nil
 }
+
+// blockPrunedError tells indexers the block is gone from qtumd rather than unknown, with the height it keeps blocks from.
+// Without that height the indexer would be told qtumd keeps every block, so qtumd's failure is returned instead
+func blockPrunedError(ctx context.Context, p *qtum.Qtum) eth.JSONRPCError {
+	info, err := p.GetBlockChainInfo(ctx)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't get prune height", "err", err)
+		return eth.NewCallbackError("couldn't get prune height: " + err.Error())
+	}
+	return eth.NewBlockPrunedError(info.PruneHeight)
+}
+
+// blockFieldMode is how a block field is synthesized, empty for the default
+func blockFieldMode(q *qtum.Qtum, flag string) string {
+	if mode := q.GetFlagString(flag); mode != nil {
+		return *mode
+	}
+	return ""
+}
@@ -1,9 +1,11 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/internal"
 	"github.com/qtumproject/janus/pkg/qtum"
 	"github.com/qtumproject/janus/pkg/utils"
@@ -36,3 +38,117 @@ func TestGetBlockByHashTransactionsRequest(t *testing.T) {
 		&internal.GetTransactionByHashResponseWithTransactions,
 	)
 }
+
+func TestGetBlockByHashPrunedBlock(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`), []byte(`false`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{Hash: internal.GetTransactionByHashBlockHash, Height: 3983})
+	// headers are kept when qtumd prunes blocks
+	mockedClientDoer.AddError(qtum.MethodGetBlock, eth.NewJSONRPCError(-1, "Block not available (pruned data)", nil))
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Pruned: true, PruneHeight: 120000})
+
+	proxyEth := initializeProxyETHGetBlockByHash(qtumClient)
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.BlockPrunedErrorCode {
+		t.Fatalf("expected a block pruned error, got %v", jsonErr)
+	}
+	data := jsonErr.(*eth.GenericJSONRPCError).Data().(eth.BlockPrunedData)
+	if data.PruneHeight != 120000 {
+		t.Errorf("expected prune height 120000, got %d", data.PruneHeight)
+	}
+}
+
+func TestGetBlockByHashPrunedBlockWithoutPruneHeight(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`), []byte(`false`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{Hash: internal.GetTransactionByHashBlockHash, Height: 3983})
+	mockedClientDoer.AddError(qtum.MethodGetBlock, eth.NewJSONRPCError(-1, "Block not available (pruned data)", nil))
+	mockedClientDoer.AddError(qtum.MethodGetBlockChainInfo, eth.NewJSONRPCError(-1, "couldn't connect", nil))
+
+	// a prune height of 0 would tell indexers qtumd keeps every block
+	proxyEth := initializeProxyETHGetBlockByHash(qtumClient)
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.CallbackErrorCode {
+		t.Fatalf("expected a callback error, got %v", jsonErr)
+	}
+}
+
+func TestGetBlockByHashBlockFields(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`), []byte(`false`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal.SetupGetBlockByHashResponses(t, mockedClientDoer)
+	if err := qtum.SetBlockFields(qtum.BlockGasWeight, qtum.BlockDifficultyGeth, qtum.BlockTimestampMedian)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+
+	got, jsonErr := initializeProxyETHGetBlockByHash(qtumClient).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want := internal.CreateTransactionByHashResponse()
+	// the block weighs 2372, its chain work is 0x1f20 and the median time of the blocks before it is 1536551728
+	want.GasLimit = "0x7a1200"
+	want.GasUsed = "0x944"
+	want.Difficulty = "0x0"
+	want.TotalDifficulty = "0x1f20"
+	want.Timestamp = "0x5b95eb30"
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+
+	if err := qtum.SetBlockFields("gas", "", "")(qtumClient.Client); err == nil {
+		t.Error("expected an unknown block gas mode to be refused")
+	}
+}
+
+func TestGetBlockByHashGasUsed(t *testing.T) {
+	// one receipt at a time keeps the mocked responses in order
+	defer func(concurrency int) { blockReceiptsConcurrency = concurrency }(blockReceiptsConcurrency)
+	blockReceiptsConcurrency = 1
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`), []byte(`false`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{Hash: internal.GetTransactionByHashBlockHash, Height: 3983}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, internal.GetBlockResponse); err != nil {
+		t.Fatal(err)
+	}
+	// neither transaction ran in the EVM
+	mockedClientDoer.AddRawResponse(qtum.MethodGetTransactionReceipt, []byte(`{"jsonrpc":"2.0","result":[],"id":1}`))
+
+	got, jsonErr := initializeProxyETHGetBlockByHash(qtumClient).Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	// the block's gas used is the cumulative gas used of its last transaction, as eth_getBlockReceipts reports it
+	if gasUsed := got.(*eth.GetBlockByHashResponse).GasUsed; gasUsed != "0xabe0" {
+		t.Errorf("expected the gas used of the transactions without transactions being full, got %s", gasUsed)
+	}
+}
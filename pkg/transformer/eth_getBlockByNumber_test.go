@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -31,6 +32,15 @@ func TestGetBlockByNumberWithTransactionsRequest(t *testing.T) {
 	)
 }
 
+func TestGetBlockByNumberWithBlockHashObjectRequest(t *testing.T) {
+	testETHProxyRequest(
+		t,
+		initializeProxyETHGetBlockByNumber,
+		[]json.RawMessage{[]byte(`{"blockHash":"` + internal.GetTransactionByHashBlockHexHash + `","requireCanonical":true}`), []byte(`false`)},
+		&internal.GetTransactionByHashResponse,
+	)
+}
+
 func TestGetBlockByNumberUnknownBlockRequest(t *testing.T) {
 	requestParams := []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockNumberHex + `"`), []byte(`true`)}
 	request, err := internal.PrepareEthRPCRequest(1, requestParams)
@@ -49,7 +59,7 @@ func TestGetBlockByNumberUnknownBlockRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetBlockByNumber{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -0,0 +1,108 @@
+package transformer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// blockReceiptsConcurrency bounds the receipts of one block translated at the same time
+var blockReceiptsConcurrency = 8
+
+// ProxyETHGetBlockReceipts implements ETHProxy
+type ProxyETHGetBlockReceipts struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyETHGetBlockReceipts)(nil)
+
+func (p *ProxyETHGetBlockReceipts) Method() string {
+	return "eth_getBlockReceipts"
+}
+
+func (p *ProxyETHGetBlockReceipts) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetBlockReceiptsRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return p.request(ctx, &req)
+}
+
+func (p *ProxyETHGetBlockReceipts) request(ctx context.Context, req *eth.GetBlockReceiptsRequest) (eth.GetBlockReceiptsResponse, eth.JSONRPCError) {
+	block, jsonErr := resolveBlock(ctx, p.Qtum, req.Block, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if block == nil {
+		return nil, nil
+	}
+	// blocks above "latest" are treated as not mined yet, like their receipts
+	confirmed, jsonErr := isBlockConfirmed(ctx, p.Qtum, block.Number)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if !confirmed {
+		return nil, nil
+	}
+
+	qtumBlock, err := p.GetBlock(ctx, block.Hash)
+	if err != nil {
+		p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block", "hash", block.Hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block")
+	}
+
+	blockReceipts, err := p.GetBlockReceipts(ctx, qtumBlock)
+	if err != nil {
+		p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block receipts", "hash", block.Hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get transaction receipts")
+	}
+
+	receipts := make(eth.GetBlockReceiptsResponse, len(qtumBlock.Txs))
+	errs := make([]eth.JSONRPCError, len(qtumBlock.Txs))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, blockReceiptsConcurrency)
+	proxy := &ProxyETHGetTransactionReceipt{Qtum: p.Qtum}
+	for i, txid := range qtumBlock.Txs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, txid string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			// like gettransactionreceipt, only transactions with a single contract output have a receipt
+			if txReceipts := blockReceipts.Receipts[txid]; len(txReceipts) == 1 {
+				qtumReceipt := qtum.GetTransactionReceiptResponse(txReceipts[0])
+				receipts[i], errs[i] = proxy.translate(ctx, &qtumReceipt)
+			} else {
+				receipts[i], errs[i] = proxy.receiptWithoutContract(ctx, txid, qtum.EmptyResponseErr)
+			}
+			if errs[i] != nil {
+				// the other receipts aren't of any use anymore
+				cancel()
+			}
+		}(i, txid)
+	}
+	wg.Wait()
+
+	cumulative := cumulativeGasUsed(qtumBlock.Txs, blockReceipts)
+	for i, jsonErr := range errs {
+		if jsonErr != nil {
+			p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get transaction receipt", "txid", qtumBlock.Txs[i], "err", jsonErr)
+			return nil, jsonErr
+		}
+		if receipts[i] == nil {
+			// qtumd dropped the block while its receipts were fetched, e.g. in a reorg
+			return nil, eth.NewCallbackError("couldn't get transaction receipt " + qtumBlock.Txs[i])
+		}
+		receipts[i].CumulativeGasUsed = hexutil.EncodeUint64(cumulative[i])
+	}
+	return receipts, nil
+}
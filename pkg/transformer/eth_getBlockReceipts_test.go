@@ -0,0 +1,92 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestGetBlockReceipts(t *testing.T) {
+	// one receipt at a time keeps the mocked responses in order
+	defer func(concurrency int) { blockReceiptsConcurrency = concurrency }(blockReceiptsConcurrency)
+	blockReceiptsConcurrency = 1
+
+	requestParams := []json.RawMessage{[]byte(`"0xf8f"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(internal.GetTransactionByHashBlockHash)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, internal.GetBlockResponse); err != nil {
+		t.Fatal(err)
+	}
+	// neither transaction ran in the EVM
+	mockedClientDoer.AddRawResponse(qtum.MethodGetTransactionReceipt, []byte(`{"jsonrpc":"2.0","result":[],"id":1}`))
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, &qtum.GetRawTransactionResponse{BlockHash: internal.GetTransactionByHashBlockHash}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodDecodeRawTransaction, &qtum.DecodedRawTransactionResponse{Vins: []*qtum.DecodedRawTransactionInV{{}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetBlockReceipts{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	receipts, ok := got.(eth.GetBlockReceiptsResponse)
+	if !ok || len(receipts) != len(internal.GetBlockResponse.Txs) {
+		t.Fatalf("expected a receipt for each transaction of the block, got %#v", got)
+	}
+	for i, receipt := range receipts {
+		if want := "0x" + internal.GetBlockResponse.Txs[i]; receipt.TransactionHash != want {
+			t.Errorf("expected receipt %d to be of %s, got %s", i, want, receipt.TransactionHash)
+		}
+		if receipt.BlockNumber != "0xf8f" || receipt.Status != STATUS_SUCCESS || receipt.Type != LEGACY_TRANSACTION_TYPE {
+			t.Errorf("unexpected receipt %+v", receipt)
+		}
+	}
+	if receipts[0].CumulativeGasUsed != "0x55f0" || receipts[1].CumulativeGasUsed != "0xabe0" {
+		t.Errorf("expected the gas used to add up over the block, got %s and %s", receipts[0].CumulativeGasUsed, receipts[1].CumulativeGasUsed)
+	}
+}
+
+func TestGetBlockReceiptsUnknownBlock(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0xffff"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddError(qtum.MethodGetBlockHash, eth.NewJSONRPCError(-8, "Block height out of range", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetBlockReceipts{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if receipts, ok := got.(eth.GetBlockReceiptsResponse); !ok || receipts != nil {
+		t.Errorf("expected no receipts for an unknown block, got %#v", got)
+	}
+}
@@ -18,14 +18,15 @@ func (p *ProxyETHGetCode) Method() string {
 	return "eth_getCode"
 }
 
-func (p *ProxyETHGetCode) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetCode) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetCodeRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
+	// getaccountinfo only returns the latest code, the block is ignored
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(ctx, &req)
 }
 
 func (p *ProxyETHGetCode) request(ctx context.Context, ethreq *eth.GetCodeRequest) (eth.GetCodeResponse, eth.JSONRPCError) {
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -29,7 +30,7 @@ func TestGetAccountInfoRequest(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	qtumClient.Accounts = append(qtumClient.Accounts, account)
+	qtumClient.Keystore.Add(account)
 
 	//prepare responses
 	getAccountInfoResponse := qtum.GetAccountInfoResponse{
@@ -45,7 +46,7 @@ func TestGetAccountInfoRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetCode{qtumClient}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -81,7 +82,7 @@ func TestGetCodeInvalidAddressRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetCode{qtumClient}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
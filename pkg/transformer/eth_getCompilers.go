@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 )
@@ -12,7 +13,7 @@ func (p *ETHGetCompilers) Method() string {
 	return "eth_getCompilers"
 }
 
-func (p *ETHGetCompilers) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHGetCompilers) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	// hardcoded to empty
 	return []string{}, nil
 }
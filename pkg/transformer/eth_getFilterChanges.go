@@ -2,7 +2,6 @@ package transformer
 
 import (
 	"context"
-	"encoding/json"
 	"math/big"
 
 	"github.com/labstack/echo"
@@ -23,18 +22,18 @@ func (p *ProxyETHGetFilterChanges) Method() string {
 	return "eth_getFilterChanges"
 }
 
-func (p *ProxyETHGetFilterChanges) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetFilterChanges) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 
-	filter, err := processFilter(p, rawreq)
+	filter, err := processFilter(ctx, p, rawreq)
 	if err != nil {
 		return nil, err
 	}
 
 	switch filter.Type {
 	case eth.NewFilterTy:
-		return p.requestFilter(c.Request().Context(), filter)
+		return p.requestFilter(ctx, filter)
 	case eth.NewBlockFilterTy:
-		return p.requestBlockFilter(c.Request().Context(), filter)
+		return p.requestBlockFilter(ctx, filter)
 	case eth.NewPendingTransactionFilterTy:
 		fallthrough
 	default:
@@ -71,8 +70,11 @@ func (p *ProxyETHGetFilterChanges) requestBlockFilter(ctx context.Context, filte
 		hashes[i] = utils.AddHexPrefix(string(resp))
 	}
 
-	qtumresp = hashes
 	filter.Data.Store("lastBlockNumber", blockCount)
+	if saveErr := p.filter.Save(ctx, filter); saveErr != nil {
+		return qtumresp, eth.NewCallbackError(saveErr.Error())
+	}
+	qtumresp = hashes
 	return
 }
 
@@ -130,25 +132,15 @@ func (p *ProxyETHGetFilterChanges) doSearchLogs(ctx context.Context, req *qtum.S
 
 func (p *ProxyETHGetFilterChanges) toSearchLogsReq(filter *eth.Filter, from, to *big.Int) (*qtum.SearchLogsRequest, eth.JSONRPCError) {
 	ethreq := filter.Request.(*eth.NewFilterRequest)
-	var err error
-	var addresses []string
-	if ethreq.Address != nil {
-		if isBytesOfString(ethreq.Address) {
-			var addr string
-			if err = json.Unmarshal(ethreq.Address, &addr); err != nil {
-				// TODO: Correct error code?
-				return nil, eth.NewInvalidParamsError(err.Error())
-			}
-			addresses = append(addresses, addr)
-		} else {
-			if err = json.Unmarshal(ethreq.Address, &addresses); err != nil {
-				// TODO: Correct error code?
-				return nil, eth.NewInvalidParamsError(err.Error())
-			}
-		}
-		for i := range addresses {
-			addresses[i] = utils.RemoveHexPrefix(addresses[i])
-		}
+	addresses, err := eth.ParseFilterAddressesJSON(ethreq.Address)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	// translated on every poll rather than stored so filters survive being handed over to a standby
+	topics, topicsErr := eth.TranslateTopics(ethreq.Topics)
+	if topicsErr != nil {
+		return nil, eth.NewInvalidParamsError(topicsErr.Error())
 	}
 
 	qtumreq := &qtum.SearchLogsRequest{
@@ -156,10 +148,8 @@ func (p *ProxyETHGetFilterChanges) toSearchLogsReq(filter *eth.Filter, from, to
 		FromBlock: from,
 		ToBlock:   to,
 	}
-
-	topics, ok := filter.Data.Load("topics")
-	if ok {
-		qtumreq.Topics = topics.([]qtum.SearchLogsTopic)
+	if len(topics) > 0 {
+		qtumreq.Topics = qtum.NewSearchLogsTopics(topics)
 	}
 
 	return qtumreq, nil
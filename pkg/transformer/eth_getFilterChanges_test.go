@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"math/big"
 	"testing"
@@ -42,14 +43,18 @@ func TestGetFilterChangesRequest_EmptyResult(t *testing.T) {
 	//preparing filter
 	filterSimulator := eth.NewFilterSimulator()
 	filterRequest := eth.NewFilterRequest{}
-	filterSimulator.New(eth.NewFilterTy, &filterRequest)
-	_filter, _ := filterSimulator.Filter(1)
-	filter := _filter.(*eth.Filter)
+	filter, err := filterSimulator.New(context.Background(), eth.NewFilterTy, &filterRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
 	filter.Data.Store("lastBlockNumber", uint64(657655))
+	if err = filterSimulator.Save(context.Background(), filter); err != nil {
+		t.Fatal(err)
+	}
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetFilterChanges{qtumClient, filterSimulator}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -82,14 +87,18 @@ func TestGetFilterChangesRequest_NoNewBlocks(t *testing.T) {
 
 	//preparing filter
 	filterSimulator := eth.NewFilterSimulator()
-	filterSimulator.New(eth.NewFilterTy, nil)
-	_filter, _ := filterSimulator.Filter(1)
-	filter := _filter.(*eth.Filter)
+	filter, err := filterSimulator.New(context.Background(), eth.NewFilterTy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	filter.Data.Store("lastBlockNumber", uint64(657655))
+	if err = filterSimulator.Save(context.Background(), filter); err != nil {
+		t.Fatal(err)
+	}
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetFilterChanges{qtumClient, filterSimulator}
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -116,7 +125,7 @@ func TestGetFilterChangesRequest_NoSuchFilter(t *testing.T) {
 	//preparing proxy & executing request
 	filterSimulator := eth.NewFilterSimulator()
 	proxyEth := ProxyETHGetFilterChanges{qtumClient, filterSimulator}
-	_, got := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	_, got := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 
 	want := eth.NewCallbackError("Invalid filter id")
 
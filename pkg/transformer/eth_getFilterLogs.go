@@ -17,16 +17,16 @@ func (p *ProxyETHGetFilterLogs) Method() string {
 	return "eth_getFilterLogs"
 }
 
-func (p *ProxyETHGetFilterLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetFilterLogs) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 
-	filter, err := processFilter(p.ProxyETHGetFilterChanges, rawreq)
+	filter, err := processFilter(ctx, p.ProxyETHGetFilterChanges, rawreq)
 	if err != nil {
 		return nil, err
 	}
 
 	switch filter.Type {
 	case eth.NewFilterTy:
-		return p.request(c.Request().Context(), filter)
+		return p.request(ctx, filter)
 	default:
 		return nil, eth.NewInvalidParamsError("filter not found")
 	}
@@ -2,15 +2,21 @@ package transformer
 
 import (
 	"context"
-	"encoding/json"
+	"math/big"
 
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/conversion"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
-	"github.com/qtumproject/janus/pkg/utils"
 )
 
+// defaultLogsBlockRange bounds the blocks a single searchlogs call covers without --logs-block-range, wider ranges are
+// searched in parts so qtumd answers each of them before the request times out
+const defaultLogsBlockRange = 1000
+
+// defaultLogsMaxResults caps the logs eth_getLogs returns without --logs-max-results
+const defaultLogsMaxResults = 10000
+
 // ProxyETHGetLogs implements ETHProxy
 type ProxyETHGetLogs struct {
 	*qtum.Qtum
@@ -20,7 +26,7 @@ func (p *ProxyETHGetLogs) Method() string {
 	return "eth_getLogs"
 }
 
-func (p *ProxyETHGetLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetLogs) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetLogsRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
@@ -33,66 +39,97 @@ func (p *ProxyETHGetLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (i
 	// }
 
 	// Calls ToRequest in order transform ETH-Request to a Qtum-Request
-	qtumreq, err := p.ToRequest(c.Request().Context(), &req)
+	qtumreq, err := p.ToRequest(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
 
-	return p.request(c.Request().Context(), qtumreq)
+	return p.request(ctx, qtumreq)
 }
 
 func (p *ProxyETHGetLogs) request(ctx context.Context, req *qtum.SearchLogsRequest) (*eth.GetLogsResponse, eth.JSONRPCError) {
-	receipts, err := conversion.SearchLogsAndFilterExtraTopics(ctx, p.Qtum, req)
-	if err != nil {
-		return nil, err
+	blockRange := int64(defaultLogsBlockRange)
+	if configured := p.GetFlagInt(qtum.FLAG_LOGS_BLOCK_RANGE); configured != nil {
+		blockRange = int64(*configured)
+	}
+	maxResults := defaultLogsMaxResults
+	if configured := p.GetFlagInt(qtum.FLAG_LOGS_MAX_RESULTS); configured != nil {
+		maxResults = *configured
 	}
 
 	logs := make([]eth.Log, 0)
-	for _, receipt := range receipts {
-		r := qtum.TransactionReceipt(receipt)
-		logs = append(logs, conversion.ExtractETHLogsFromTransactionReceipt(r, r.Log)...)
+	for _, part := range splitSearchLogsRequest(req, blockRange) {
+		receipts, err := conversion.SearchLogsAndFilterExtraTopics(ctx, p.Qtum, part)
+		if err != nil {
+			return nil, err
+		}
+		for _, receipt := range receipts {
+			r := qtum.TransactionReceipt(receipt)
+			logs = append(logs, conversion.ExtractETHLogsFromTransactionReceipt(r, r.Log)...)
+		}
+		// the remaining parts aren't searched once there are too many logs
+		if len(logs) > maxResults {
+			return nil, eth.NewTooManyResultsError(maxResults)
+		}
 	}
 
 	resp := eth.GetLogsResponse(logs)
 	return &resp, nil
 }
 
+// GetLogs searches logs like eth_getLogs, in parts of --logs-block-range blocks and failing with more logs than
+// --logs-max-results
+func GetLogs(ctx context.Context, p *qtum.Qtum, req *qtum.SearchLogsRequest) (*eth.GetLogsResponse, eth.JSONRPCError) {
+	return (&ProxyETHGetLogs{p}).request(ctx, req)
+}
+
+// splitSearchLogsRequest divides the block range of req into consecutive ranges of at most blockRange blocks, in
+// ascending order so the logs of the parts can be appended to each other
+func splitSearchLogsRequest(req *qtum.SearchLogsRequest, blockRange int64) []*qtum.SearchLogsRequest {
+	if req.FromBlock == nil || req.ToBlock == nil {
+		return []*qtum.SearchLogsRequest{req}
+	}
+	from, to := req.FromBlock.Int64(), req.ToBlock.Int64()
+	if to-from < blockRange {
+		return []*qtum.SearchLogsRequest{req}
+	}
+	var parts []*qtum.SearchLogsRequest
+	for start := from; start <= to; start += blockRange {
+		end := start + blockRange - 1
+		if end > to {
+			end = to
+		}
+		part := *req
+		part.FromBlock = big.NewInt(start)
+		part.ToBlock = big.NewInt(end)
+		parts = append(parts, &part)
+	}
+	return parts
+}
+
 func (p *ProxyETHGetLogs) ToRequest(ctx context.Context, ethreq *eth.GetLogsRequest) (*qtum.SearchLogsRequest, eth.JSONRPCError) {
 	//transform EthRequest fromBlock to QtumReq fromBlock:
-	from, err := getBlockNumberByRawParam(ctx, p.Qtum, ethreq.FromBlock, true)
+	from, err := resolveBlockNumber(ctx, p.Qtum, ethreq.FromBlock, true)
 	if err != nil {
 		return nil, err
 	}
 
 	//transform EthRequest toBlock to QtumReq toBlock:
-	to, err := getBlockNumberByRawParam(ctx, p.Qtum, ethreq.ToBlock, true)
+	to, err := resolveBlockNumber(ctx, p.Qtum, ethreq.ToBlock, true)
 	if err != nil {
 		return nil, err
 	}
 
 	//transform EthReq address to QtumReq address:
-	var addresses []string
-	if ethreq.Address != nil {
-		if isBytesOfString(ethreq.Address) {
-			var addr string
-			if jsonErr := json.Unmarshal(ethreq.Address, &addr); jsonErr != nil {
-				return nil, eth.NewInvalidParamsError(jsonErr.Error())
-			}
-			addresses = append(addresses, addr)
-		} else {
-			if jsonErr := json.Unmarshal(ethreq.Address, &addresses); jsonErr != nil {
-				return nil, eth.NewInvalidParamsError(jsonErr.Error())
-			}
-		}
-		for i := range addresses {
-			addresses[i] = utils.RemoveHexPrefix(addresses[i])
-		}
+	addresses, addressesErr := eth.ParseFilterAddressesJSON(ethreq.Address)
+	if addressesErr != nil {
+		return nil, eth.NewInvalidParamsError(addressesErr.Error())
 	}
 
 	//transform EthReq topics to QtumReq topics:
 	topics, topicsErr := eth.TranslateTopics(ethreq.Topics)
 	if topicsErr != nil {
-		return nil, eth.NewCallbackError(topicsErr.Error())
+		return nil, eth.NewInvalidParamsError(topicsErr.Error())
 	}
 
 	return &qtum.SearchLogsRequest{
@@ -3,8 +3,10 @@ package transformer
 import (
 	"context"
 	"encoding/json"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/internal"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -116,13 +118,91 @@ func TestGetLogsFiltersByTopic(t *testing.T) {
 	testGetLogsWithTopics(
 		t,
 		[]interface{}{
-			"a topic",
-			"another topic",
+			"0x0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885",
+			"0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3713",
 		},
 		eth.GetLogsResponse{},
 	)
 }
 
+func TestGetLogsFiltersWithNullInOR(t *testing.T) {
+	testGetLogsWithTopics(
+		t,
+		[]interface{}{
+			[]interface{}{
+				"0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3713",
+				nil,
+			},
+			"0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712",
+		},
+		eth.GetLogsResponse{
+			{
+				LogIndex:         "0x0",
+				TransactionIndex: "0x2",
+				TransactionHash:  "0xc1816e5fbdd4d1cc62394be83c7c7130ccd2aadefcd91e789c1a0b33ec093fef",
+				BlockHash:        "0x975326b65c20d0b8500f00a59f76b08a98513fff7ce0484382534a47b55f8985",
+				BlockNumber:      "0xfdf",
+				Address:          "0xdb46f738bf32cdafb9a4a70eb8b44c76646bcaf0",
+				Data:             "0x0000000000000000000000000000000000000000000000000000000000000001",
+				Topics: []string{
+					"0x0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885",
+					"0x0000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712",
+				},
+			},
+		},
+	)
+}
+
+func TestGetLogsFiltersMorePositionsThanTopics(t *testing.T) {
+	testGetLogsWithTopics(
+		t,
+		[]interface{}{
+			nil,
+			nil,
+			nil,
+		},
+		eth.GetLogsResponse{},
+	)
+}
+
+func TestGetLogsRejectsInvalidTopics(t *testing.T) {
+	tests := map[string][]interface{}{
+		"not hex":         {"a topic", "another topic"},
+		"short topic":     {"0x0f6798a5"},
+		"too many topics": {nil, nil, nil, nil, nil},
+		"nested list":     {[]interface{}{[]interface{}{}}},
+	}
+
+	for name, topics := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := eth.GetLogsRequest{
+				FromBlock: json.RawMessage(`"0xfde"`),
+				ToBlock:   json.RawMessage(`"0xfde"`),
+				Topics:    topics,
+			}
+			requestRaw, err := json.Marshal(&request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{requestRaw})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proxyEth := ProxyETHGetLogs{qtumClient}
+			_, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+			if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+				t.Fatalf("expected an invalid params error, got %v", jsonErr)
+			}
+		})
+	}
+}
+
 func TestMultipleLogsWithORdTopics(t *testing.T) {
 	rawResponse := `
 	{
@@ -264,7 +344,7 @@ func TestMultipleLogsWithORdTopics(t *testing.T) {
 	//preparing proxy & executing
 	proxyEth := ProxyETHGetLogs{qtumClient}
 
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -393,7 +473,7 @@ func testGetLogsWithTopics(t *testing.T, topics []interface{}, want eth.GetLogsR
 	//preparing proxy & executing
 	proxyEth := ProxyETHGetLogs{qtumClient}
 
-	got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -451,3 +531,203 @@ func TestGetLogsTranslateTopicWorksWithNil(t *testing.T) {
 
 	internal.CheckTestResultEthRequestLog(request, expectedRawRequest, string(qtumRawRequest), t, false)
 }
+
+func TestSplitSearchLogsRequest(t *testing.T) {
+	tests := []struct {
+		from, to   int64
+		blockRange int64
+		want       [][2]int64
+	}{
+		{from: 100, to: 100, blockRange: 1000, want: [][2]int64{{100, 100}}},
+		{from: 100, to: 1099, blockRange: 1000, want: [][2]int64{{100, 1099}}},
+		{from: 100, to: 1100, blockRange: 1000, want: [][2]int64{{100, 1099}, {1100, 1100}}},
+		{from: 0, to: 2500, blockRange: 1000, want: [][2]int64{{0, 999}, {1000, 1999}, {2000, 2500}}},
+	}
+	for _, test := range tests {
+		req := &qtum.SearchLogsRequest{FromBlock: big.NewInt(test.from), ToBlock: big.NewInt(test.to), Addresses: []string{"db46f738bf32cdafb9a4a70eb8b44c76646bcaf0"}}
+		parts := splitSearchLogsRequest(req, test.blockRange)
+		if len(parts) != len(test.want) {
+			t.Fatalf("%d-%d: expected %d parts, got %d", test.from, test.to, len(test.want), len(parts))
+		}
+		for i, part := range parts {
+			if part.FromBlock.Int64() != test.want[i][0] || part.ToBlock.Int64() != test.want[i][1] {
+				t.Errorf("%d-%d: expected part %d to be %v, got %d-%d", test.from, test.to, i, test.want[i], part.FromBlock, part.ToBlock)
+			}
+			if len(part.Addresses) != 1 {
+				t.Errorf("%d-%d: expected part %d to keep the addresses", test.from, test.to, i)
+			}
+		}
+	}
+}
+
+func TestGetLogsSearchesWideRangesInParts(t *testing.T) {
+	fromBlock, _ := json.Marshal("0xfde")
+	toBlock, _ := json.Marshal("0xfe0")
+	requestRaw, err := json.Marshal(&eth.GetLogsRequest{FromBlock: fromBlock, ToBlock: toBlock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{requestRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setup := func(maxResults int) ProxyETHGetLogs {
+		clientDoerMock := internal.NewDoerMappedMock()
+		qtumClient, err := internal.CreateMockedClient(clientDoerMock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qtumClient.SetFlag(qtum.FLAG_LOGS_BLOCK_RANGE, 1)
+		qtumClient.SetFlag(qtum.FLAG_LOGS_MAX_RESULTS, maxResults)
+		for block := 4062; block <= 4064; block++ {
+			clientDoerMock.AddResponse(qtum.MethodSearchLogs, qtum.SearchLogsResponse{{
+				BlockHash:       "975326b65c20d0b8500f00a59f76b08a98513fff7ce0484382534a47b55f8985",
+				BlockNumber:     uint64(block),
+				TransactionHash: "c1816e5fbdd4d1cc62394be83c7c7130ccd2aadefcd91e789c1a0b33ec093fef",
+				Log:             []qtum.Log{{Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0", Data: "00"}},
+			}})
+		}
+		return ProxyETHGetLogs{qtumClient}
+	}
+
+	proxyEth := setup(3)
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	logs := *got.(*eth.GetLogsResponse)
+	if len(logs) != 3 {
+		t.Fatalf("expected the logs of 3 parts, got %d", len(logs))
+	}
+	for i, log := range logs {
+		if want := hexutil.EncodeUint64(uint64(4062 + i)); log.BlockNumber != want {
+			t.Errorf("expected log %d in block %s, got %s", i, want, log.BlockNumber)
+		}
+	}
+
+	proxyEth = setup(2)
+	_, jsonErr = proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.LimitExceededErrorCode || jsonErr.Message() != "query returned more than 2 results" {
+		t.Fatalf("expected too many results, got %v", jsonErr)
+	}
+}
+
+type fakeLogIndex struct {
+	from, to int64
+	receipts qtum.SearchLogsResponse
+}
+
+func (i *fakeLogIndex) SearchLogs(ctx context.Context, req *qtum.SearchLogsRequest) (qtum.SearchLogsResponse, bool, error) {
+	if req.FromBlock.Int64() < i.from || req.ToBlock.Int64() > i.to {
+		return nil, false, nil
+	}
+	return i.receipts, true, nil
+}
+
+func (i *fakeLogIndex) TransactionBlockHash(ctx context.Context, txID string) (string, error) {
+	for _, receipt := range i.receipts {
+		if receipt.TransactionHash == txID {
+			return receipt.BlockHash, nil
+		}
+	}
+	return "", nil
+}
+
+func TestGetLogsFromLogIndex(t *testing.T) {
+	receipt := qtum.TransactionReceipt{
+		BlockHash:       "975326b65c20d0b8500f00a59f76b08a98513fff7ce0484382534a47b55f8985",
+		BlockNumber:     4062,
+		TransactionHash: "c1816e5fbdd4d1cc62394be83c7c7130ccd2aadefcd91e789c1a0b33ec093fef",
+		Log:             []qtum.Log{{Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0", Data: "00"}},
+	}
+	request := func(from, to string) *eth.JSONRPCRequest {
+		fromBlock, _ := json.Marshal(from)
+		toBlock, _ := json.Marshal(to)
+		requestRaw, err := json.Marshal(&eth.GetLogsRequest{FromBlock: fromBlock, ToBlock: toBlock})
+		if err != nil {
+			t.Fatal(err)
+		}
+		requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{requestRaw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return requestRPC
+	}
+
+	clientDoerMock := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetLogIndex(&fakeLogIndex{from: 4000, to: 4062, receipts: qtum.SearchLogsResponse{receipt}})
+	proxyEth := ProxyETHGetLogs{qtumClient}
+
+	// indexed blocks don't need qtumd, no searchlogs response is mocked
+	got, jsonErr := proxyEth.Request(context.Background(), request("0xfa0", "0xfde"), internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if logs := *got.(*eth.GetLogsResponse); len(logs) != 1 || logs[0].BlockNumber != "0xfde" {
+		t.Fatalf("expected the indexed log, got %v", logs)
+	}
+
+	// blocks past the index are searched by qtumd
+	receipt.BlockNumber = 4063
+	clientDoerMock.AddResponse(qtum.MethodSearchLogs, qtum.SearchLogsResponse{receipt})
+	got, jsonErr = proxyEth.Request(context.Background(), request("0xfdf", "0xfdf"), internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if logs := *got.(*eth.GetLogsResponse); len(logs) != 1 || logs[0].BlockNumber != "0xfdf" {
+		t.Fatalf("expected the log searched by qtumd, got %v", logs)
+	}
+}
+
+func TestGetLogsOrder(t *testing.T) {
+	// qtumd and the log index may return the transactions of a block in any order
+	receipts := qtum.SearchLogsResponse{
+		{BlockNumber: 4063, TransactionIndex: 1, TransactionHash: "d", Log: []qtum.Log{{Data: "05"}}},
+		{BlockNumber: 4062, TransactionIndex: 3, TransactionHash: "c", Log: []qtum.Log{{Data: "03"}, {Data: "04"}}},
+		{BlockNumber: 4062, TransactionIndex: 1, OutputIndex: 1, TransactionHash: "b", Log: []qtum.Log{{Data: "02"}}},
+		{BlockNumber: 4062, TransactionIndex: 1, OutputIndex: 0, TransactionHash: "a", Log: []qtum.Log{{Data: "01"}}},
+	}
+	fromBlock, _ := json.Marshal("0xfde")
+	toBlock, _ := json.Marshal("0xfdf")
+	requestRaw, err := json.Marshal(&eth.GetLogsRequest{FromBlock: fromBlock, ToBlock: toBlock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{requestRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searched := func(qtumClient *qtum.Qtum) string {
+		got, jsonErr := (&ProxyETHGetLogs{qtumClient}).Request(context.Background(), requestRPC, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		var order string
+		for _, log := range *got.(*eth.GetLogsResponse) {
+			order += log.Data[2:] + log.LogIndex[2:] + " "
+		}
+		return order
+	}
+	expected := "010 020 030 041 050 "
+
+	clientDoerMock := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientDoerMock.AddResponse(qtum.MethodSearchLogs, receipts)
+	if order := searched(qtumClient); order != expected {
+		t.Errorf("expected the logs searched by qtumd in chain order %q, got %q", expected, order)
+	}
+
+	qtumClient.SetLogIndex(&fakeLogIndex{from: 4000, to: 4063, receipts: receipts})
+	if order := searched(qtumClient); order != expected {
+		t.Errorf("expected the indexed logs in chain order %q, got %q", expected, order)
+	}
+}
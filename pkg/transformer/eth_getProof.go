@@ -0,0 +1,130 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// the storage root reported for every account, qtumd doesn't compute one
+const unsupportedStorageHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+// ProxyETHGetProof implements ETHProxy, returning the account and storage values of eth_getProof without proofs, as
+// qtumd keeps its state in a trie Janus can't prove against
+type ProxyETHGetProof struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHGetProof) Method() string {
+	return "eth_getProof"
+}
+
+func (p *ProxyETHGetProof) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetProofRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return p.request(ctx, &req, c)
+}
+
+func (p *ProxyETHGetProof) request(ctx context.Context, req *eth.GetProofRequest, c echo.Context) (*eth.GetProofResponse, eth.JSONRPCError) {
+	// qtumd only returns the latest code and balance of contracts, so the account is only known at the latest block
+	if jsonErr := checkLatestBlock(ctx, p.Qtum, req.BlockNumber, p.Method()); jsonErr != nil {
+		return nil, jsonErr
+	}
+	blockNumber, jsonErr := resolveBlockNumber(ctx, p.Qtum, req.BlockNumber, true)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	balanceParams, err := json.Marshal([]interface{}{req.Address, hexutil.EncodeBig(blockNumber)})
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	result, jsonErr := (&ProxyETHGetBalance{p.Qtum}).Request(ctx, &eth.JSONRPCRequest{Params: balanceParams}, c)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	balance, _ := result.(string)
+
+	nonce, err := p.GetTransactionCount(ctx, utils.RemoveHexPrefix(req.Address), "")
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	code, jsonErr := (&ProxyETHGetCode{p.Qtum}).request(ctx, &eth.GetCodeRequest{Address: req.Address})
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	codeBytes, err := hexutil.Decode(string(code))
+	if err != nil {
+		codeBytes = nil
+	}
+
+	storageProof := make([]eth.StorageProof, 0, len(req.StorageKeys))
+	if len(req.StorageKeys) != 0 {
+		values, jsonErr := p.storageValues(ctx, req, blockNumber, len(codeBytes) != 0)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		for i, key := range req.StorageKeys {
+			storageProof = append(storageProof, eth.StorageProof{Key: key, Value: values[i], Proof: []string{}})
+		}
+	}
+
+	response := &eth.GetProofResponse{
+		Address:      req.Address,
+		AccountProof: []string{},
+		Balance:      balance,
+		CodeHash:     crypto.Keccak256Hash(codeBytes).Hex(),
+		Nonce:        hexutil.EncodeBig(nonce),
+		StorageHash:  unsupportedStorageHash,
+		StorageProof: storageProof,
+	}
+	if p.ResponseExtensions() {
+		proofsSupported := false
+		response.ProofsSupported = &proofsSupported
+	}
+	return response, nil
+}
+
+// storageValues looks up the requested storage keys as quantities, accounts without code have empty storage
+func (p *ProxyETHGetProof) storageValues(ctx context.Context, req *eth.GetProofRequest, blockNumber *big.Int, contract bool) ([]string, eth.JSONRPCError) {
+	values := make([]string, len(req.StorageKeys))
+	for i := range values {
+		values[i] = "0x0"
+	}
+	if !contract {
+		return values, nil
+	}
+
+	storage, err := p.GetStorage(ctx, &qtum.GetStorageRequest{
+		Address:     utils.RemoveHexPrefix(req.Address),
+		BlockNumber: blockNumber,
+	})
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	getStorageAt := &ProxyETHGetStorageAt{p.Qtum}
+	for i, key := range req.StorageKeys {
+		slot, err := storageKey(key)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+		value := getStorageAt.ToResponse(storage, slot)
+		quantity, ok := new(big.Int).SetString(utils.RemoveHexPrefix(string(*value)), 16)
+		if !ok {
+			return nil, eth.NewCallbackError("invalid storage value " + string(*value))
+		}
+		values[i] = hexutil.EncodeBig(quantity)
+	}
+	return values, nil
+}
@@ -0,0 +1,101 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestGetProofRequest(t *testing.T) {
+	address := "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	requestParams := []json.RawMessage{[]byte(`"` + address + `"`), []byte(`["0x0","0x1"]`), []byte(`"0x1234"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{
+		Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960",
+		Balance: 100,
+		Code:    "6060",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	getStorageResponse := qtum.GetStorageResponse{}
+	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")] = map[string]string{
+		leftPadStringWithZerosTo64Bytes("1"): leftPadStringWithZerosTo64Bytes("2a"),
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetStorage, getStorageResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	// the requested block is the latest
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 0x1234}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(0x1234)}); err != nil {
+		t.Fatal(err)
+	}
+	// the nonce counts the transactions spending from the address
+	if err := mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, qtum.GetAddressDeltasResponse{{TXID: "aa", Satoshis: -100000}}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetProof{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := &eth.GetProofResponse{
+		Address:      address,
+		AccountProof: []string{},
+		Balance:      "0x64",
+		CodeHash:     crypto.Keccak256Hash([]byte{0x60, 0x60}).Hex(),
+		Nonce:        "0x1",
+		StorageHash:  unsupportedStorageHash,
+		StorageProof: []eth.StorageProof{
+			{Key: "0x0", Value: "0x0", Proof: []string{}},
+			{Key: "0x1", Value: "0x2a", Proof: []string{}},
+		},
+		ProofsSupported: new(bool),
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+
+	// strict consumers get the fields of the spec only
+	if err := qtum.SetResponseExtensions(false)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr = proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want.ProofsSupported = nil
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+
+	// the code and balance of contracts are only known at the latest block
+	earlier, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"` + address + `"`), []byte(`["0x0"]`), []byte(`"0x1233"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := proxyEth.Request(context.Background(), earlier, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Errorf("expected an earlier block to be rejected, got %v", jsonErr)
+	}
+}
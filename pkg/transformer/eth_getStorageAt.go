@@ -2,9 +2,14 @@ package transformer
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/labstack/echo"
+	"github.com/pkg/errors"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
 	"github.com/qtumproject/janus/pkg/utils"
@@ -19,27 +24,32 @@ func (p *ProxyETHGetStorageAt) Method() string {
 	return "eth_getStorageAt"
 }
 
-func (p *ProxyETHGetStorageAt) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetStorageAt) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetStorageRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	qtumAddress := utils.RemoveHexPrefix(req.Address)
-	blockNumber, err := getBlockNumberByParam(c.Request().Context(), p.Qtum, req.BlockNumber, false)
+	slot, err := storageKey(req.Index)
 	if err != nil {
-		p.GetDebugLogger().Log("msg", fmt.Sprintf("Failed to get block number by param for '%s'", req.BlockNumber), "err", err)
-		return nil, err
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	qtumAddress := utils.RemoveHexPrefix(req.Address)
+	blockNumber, jsonErr := resolveBlockNumber(ctx, p.Qtum, req.BlockNumber, false)
+	if jsonErr != nil {
+		p.GetDebugLogger().Log("msg", fmt.Sprintf("Failed to get block number by param for '%s'", string(req.BlockNumber)), "err", jsonErr)
+		return nil, jsonErr
 	}
 
 	return p.request(
-		c.Request().Context(),
+		ctx,
 		&qtum.GetStorageRequest{
 			Address:     qtumAddress,
 			BlockNumber: blockNumber,
 		},
-		utils.RemoveHexPrefix(req.Index),
+		slot,
 	)
 }
 
@@ -53,12 +63,18 @@ func (p *ProxyETHGetStorageAt) request(ctx context.Context, ethreq *qtum.GetStor
 	return p.ToResponse(qtumresp, index), nil
 }
 
+// ToResponse looks up slot, a key from storageKey, in the storage of a contract. qtumd returns the storage by the
+// keccak256 hash of each key like the state trie stores it, with the key itself inside
 func (p *ProxyETHGetStorageAt) ToResponse(qtumresp *qtum.GetStorageResponse, slot string) *eth.GetStorageResponse {
 	// the value for unknown anything
 	storageData := eth.GetStorageResponse("0x0000000000000000000000000000000000000000000000000000000000000000")
-	if len(slot) != 64 {
-		slot = leftPadStringWithZerosTo64Bytes(slot)
+	if key, err := hex.DecodeString(slot); err == nil {
+		if qtumStorageData, ok := (*qtumresp)[hex.EncodeToString(crypto.Keccak256(key))][slot]; ok {
+			storageData = eth.GetStorageResponse(utils.AddHexPrefix(qtumStorageData))
+			return &storageData
+		}
 	}
+	// older qtumd versions may have hashed keys differently
 	for _, outerValue := range *qtumresp {
 		qtumStorageData, ok := outerValue[slot]
 		if ok {
@@ -70,6 +86,24 @@ func (p *ProxyETHGetStorageAt) ToResponse(qtumresp *qtum.GetStorageResponse, slo
 	return &storageData
 }
 
+// storageKey turns a storage position into the 32 byte key qtumd returns storage by, lower case hex without 0x. Like
+// geth positions are hex with or without 0x and up to 32 bytes, shorter ones are left padded with zeros
+func storageKey(position string) (string, error) {
+	key := strings.ToLower(utils.RemoveHexPrefix(strings.TrimPrefix(position, "0X")))
+	if key == "" {
+		return "", errors.New("empty storage position")
+	}
+	if _, ok := new(big.Int).SetString(key, 16); !ok {
+		return "", errors.Errorf("invalid storage position %s, expected hex", position)
+	}
+	if trimmed := strings.TrimLeft(key, "0"); len(trimmed) > 64 {
+		return "", errors.Errorf("storage position %s is longer than 32 bytes", position)
+	} else if len(key) > 64 {
+		key = trimmed
+	}
+	return leftPadStringWithZerosTo64Bytes(key), nil
+}
+
 // left pad a string with leading zeros to fit 64 bytes
 func leftPadStringWithZerosTo64Bytes(hex string) string {
 	return fmt.Sprintf("%064v", hex)
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -33,7 +34,7 @@ func TestGetStorageAtRequestWithNoLeadingZeros(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetStorageAt{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -67,7 +68,7 @@ func TestGetStorageAtRequestWithLeadingZeros(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetStorageAt{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -102,7 +103,7 @@ func TestGetStorageAtUnknownFieldRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetStorageAt{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -128,3 +129,81 @@ func TestLeftPadStringWithZerosTo64Bytes(t *testing.T) {
 		internal.CheckTestResultUnspecifiedInput(input, expected, result, t, false)
 	}
 }
+
+func TestStorageKey(t *testing.T) {
+	tests := map[string]string{
+		"0x1":   "0000000000000000000000000000000000000000000000000000000000000001",
+		"0X0aB": "00000000000000000000000000000000000000000000000000000000000000ab",
+		"abc":   "0000000000000000000000000000000000000000000000000000000000000abc",
+		"0x000000000000000000000000000000000000000000000000000000000000000000ff": "00000000000000000000000000000000000000000000000000000000000000ff",
+		"0xfedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210":     "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210",
+	}
+	for input, expected := range tests {
+		result, err := storageKey(input)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		internal.CheckTestResultUnspecifiedInput(input, expected, result, t, false)
+	}
+
+	for _, input := range []string{"", "0x", "0xzz", "0x1fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"} {
+		if _, err := storageKey(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestGetStorageAtDecimalPositionAndHashedKey(t *testing.T) {
+	// 10 as a JSON number is slot 0xa, returned under the keccak256 of its key
+	requestParams := []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockNumberHex + `"`), []byte(`10`), []byte(`"0x1234"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := leftPadStringWithZerosTo64Bytes("a")
+	value := "0x00000000000000000000000000000000000000000000000000000000000000ff"
+	getStorageResponse := qtum.GetStorageResponse{
+		// another contract's layout may reuse the inner key, the hashed outer key decides
+		leftPadStringWithZerosTo64Bytes("12345"):                           {key: "0x01"},
+		"c65a7bb8d6351c1cf70c95a316cc6a92839c986682d98bc35f958f4883f9d2a8": {key: value},
+	}
+	err = mockedClientDoer.AddResponseWithRequestID(2, qtum.MethodGetStorage, getStorageResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetStorageAt{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := eth.GetStorageResponse(value)
+
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
+
+func TestGetStorageAtInvalidPosition(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockNumberHex + `"`), []byte(`"0xnothex"`), []byte(`"latest"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetStorageAt{qtumClient}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Fatalf("expected an invalid params error, got %v", jsonErr)
+	}
+}
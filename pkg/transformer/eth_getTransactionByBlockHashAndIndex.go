@@ -19,7 +19,7 @@ func (p *ProxyETHGetTransactionByBlockHashAndIndex) Method() string {
 	return "eth_getTransactionByBlockHashAndIndex"
 }
 
-func (p *ProxyETHGetTransactionByBlockHashAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetTransactionByBlockHashAndIndex) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetTransactionByBlockHashAndIndex
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
@@ -30,7 +30,7 @@ func (p *ProxyETHGetTransactionByBlockHashAndIndex) Request(rawreq *eth.JSONRPCR
 		return nil, eth.NewInvalidParamsError("invalid argument 0: empty hex string")
 	}
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(ctx, &req)
 }
 
 func (p *ProxyETHGetTransactionByBlockHashAndIndex) request(ctx context.Context, req *eth.GetTransactionByBlockHashAndIndex) (interface{}, eth.JSONRPCError) {
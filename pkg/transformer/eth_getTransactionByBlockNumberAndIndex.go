@@ -19,18 +19,18 @@ func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Method() string {
 	return "eth_getTransactionByBlockNumberAndIndex"
 }
 
-func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetTransactionByBlockNumberAndIndex
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError("couldn't unmarshal request")
 	}
-	if req.BlockNumber == "" {
+	if string(req.BlockNumber) == `""` {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError("invalid argument 0: empty hex string")
 	}
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(ctx, &req)
 }
 
 func (p *ProxyETHGetTransactionByBlockNumberAndIndex) request(ctx context.Context, req *eth.GetTransactionByBlockNumberAndIndex) (interface{}, eth.JSONRPCError) {
@@ -40,22 +40,17 @@ func (p *ProxyETHGetTransactionByBlockNumberAndIndex) request(ctx context.Contex
 		return nil, eth.NewInvalidParamsError("invalid argument 1")
 	}
 
-	blockNum, err := getBlockNumberByParam(ctx, p.Qtum, req.BlockNumber, false)
-	if err != nil {
-		return nil, eth.NewCallbackError("couldn't get block number by parameter")
-	}
-
-	blockHash, err := proxyETHGetBlockByHash(ctx, p, p.Qtum, blockNum)
+	block, err := resolveBlock(ctx, p.Qtum, req.BlockNumber, false)
 	if err != nil {
 		return nil, err
 	}
-	if blockHash == nil {
+	if block == nil {
 		return nil, nil
 	}
 
 	var (
 		getBlockByHashReq = &eth.GetTransactionByBlockHashAndIndex{
-			BlockHash:        string(*blockHash),
+			BlockHash:        block.Hash,
 			TransactionIndex: req.TransactionIndex,
 		}
 		proxy = &ProxyETHGetTransactionByBlockHashAndIndex{Qtum: p.Qtum}
@@ -1,9 +1,12 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
+	"math/big"
 	"testing"
 
+	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/internal"
 	"github.com/qtumproject/janus/pkg/qtum"
 	"github.com/shopspring/decimal"
@@ -33,7 +36,7 @@ func TestGetTransactionByHashRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetTransactionByHash{qtumClient}
-	got, JsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, JsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if JsonErr != nil {
 		t.Fatal(JsonErr)
 	}
@@ -89,7 +92,7 @@ func TestGetTransactionByHashRequestWithContractVout(t *testing.T) {
 			mockedClientDoer,
 		)
 		proxyEth := ProxyETHGetTransactionByHash{qtumClient}
-		got, JsonErr := proxyEth.Request(request, internal.NewEchoContext())
+		got, JsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 		if JsonErr != nil {
 			t.Fatal(JsonErr)
 		}
@@ -140,7 +143,7 @@ func TestGetTransactionByHashRequestWithOpSender(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetTransactionByHash{qtumClient}
-	got, JsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, JsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if JsonErr != nil {
 		t.Fatal(JsonErr)
 	}
@@ -275,7 +278,7 @@ func TestGetTransactionByHashRequest_PrecisionOverflow(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetTransactionByHash{qtumClient}
-	_, err = proxyEth.Request(request, internal.NewEchoContext())
+	_, err = proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 
 	want := string("decimal.BigInt() was not a success")
 	if err.Error() != want {
@@ -288,3 +291,145 @@ func TestGetTransactionByHashRequest_PrecisionOverflow(t *testing.T) {
 	}
 }
 */
+
+func TestGetRawTransactionWithoutTxIndex(t *testing.T) {
+	const (
+		txID      = "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"
+		blockHash = "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	)
+	noTxIndex := eth.NewJSONRPCError(-5, "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries. Use gettransaction for wallet transactions.", nil)
+
+	t.Run("latest blocks", func(t *testing.T) {
+		mockedClientDoer := internal.NewDoerMappedMock()
+		qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mockedClientDoer.AddError(qtum.MethodGetRawTransaction, noTxIndex)
+		mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, qtum.GetRawTransactionResponse{ID: txID, BlockHash: blockHash})
+		mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(4062)})
+		mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(blockHash))
+		mockedClientDoer.AddResponse(qtum.MethodGetBlock, qtum.GetBlockResponse{Hash: blockHash, Height: 4062, Txs: []string{txID}})
+
+		rawTx, err := qtumClient.GetRawTransaction(context.Background(), txID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rawTx.BlockHash != blockHash {
+			t.Errorf("got block hash %q, want %q", rawTx.BlockHash, blockHash)
+		}
+	})
+
+	t.Run("log index", func(t *testing.T) {
+		mockedClientDoer := internal.NewDoerMappedMock()
+		qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qtumClient.SetLogIndex(&fakeLogIndex{receipts: qtum.SearchLogsResponse{{BlockHash: blockHash, TransactionHash: txID}}})
+		// no blocks are mocked, the log index knows the transaction
+		mockedClientDoer.AddError(qtum.MethodGetRawTransaction, noTxIndex)
+		mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, qtum.GetRawTransactionResponse{ID: txID, BlockHash: blockHash})
+
+		rawTx, err := qtumClient.GetRawTransaction(context.Background(), txID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rawTx.BlockHash != blockHash {
+			t.Errorf("got block hash %q, want %q", rawTx.BlockHash, blockHash)
+		}
+	})
+
+	t.Run("unknown transaction", func(t *testing.T) {
+		mockedClientDoer := internal.NewDoerMappedMock()
+		qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mockedClientDoer.AddError(qtum.MethodGetRawTransaction, noTxIndex)
+		mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(4062)})
+		mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(blockHash))
+		mockedClientDoer.AddResponse(qtum.MethodGetBlock, qtum.GetBlockResponse{Hash: blockHash, Height: 4062})
+
+		_, err = qtumClient.GetRawTransaction(context.Background(), txID, false)
+		if err != qtum.ErrInvalidAddress {
+			t.Errorf("got %v, want %v", err, qtum.ErrInvalidAddress)
+		}
+	})
+}
+
+func TestGetTransactionByHashPending(t *testing.T) {
+	const txID = "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"
+	requestParams := []json.RawMessage{[]byte(`"0x` + txID + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the wallet, and broadcast right after qtumd was asked for it
+	notFound := eth.NewJSONRPCError(-5, "No such mempool or blockchain transaction", nil)
+	mockedClientDoer.AddError(qtum.MethodGetTransaction, notFound)
+	mockedClientDoer.AddError(qtum.MethodGetRawTransaction, notFound)
+	internal.SetupGetBlockByHashResponses(t, mockedClientDoer)
+	mockedClientDoer.AddResponse(qtum.MethodGetMempoolEntry, qtum.GetMempoolEntryResponse{Vsize: 400, Weight: 1600})
+
+	proxyEth := ProxyETHGetTransactionByHash{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := internal.GetTransactionByHashResponseData
+	want.BlockHash = ""
+	want.BlockNumber = ""
+	want.TransactionIndex = ""
+	// the mocked getrawtransaction response has no hex
+	want.Input = "0x"
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+
+	marshaled, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(marshaled, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"blockHash", "blockNumber", "transactionIndex"} {
+		if value, ok := fields[field]; !ok || value != nil {
+			t.Errorf("expected %s of a pending transaction to be null, got %v", field, value)
+		}
+	}
+}
+
+func TestGetTransactionByHashNotInMempool(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notFound := eth.NewJSONRPCError(-5, "No such mempool or blockchain transaction", nil)
+	mockedClientDoer.AddError(qtum.MethodGetTransaction, notFound)
+	mockedClientDoer.AddError(qtum.MethodGetRawTransaction, notFound)
+	mockedClientDoer.AddError(qtum.MethodGetMempoolEntry, eth.NewJSONRPCError(-5, "Transaction not in mempool", nil))
+
+	proxyEth := ProxyETHGetTransactionByHash{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got != (*eth.GetTransactionByHashResponse)(nil) {
+		t.Errorf("expected no transaction, got %+v", got)
+	}
+}
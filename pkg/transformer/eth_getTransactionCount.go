@@ -1,12 +1,15 @@
 package transformer
 
 import (
+	"context"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
 )
 
 // ProxyETHEstimateGas implements ETHProxy
@@ -18,14 +21,17 @@ func (p *ProxyETHTxCount) Method() string {
 	return "eth_getTransactionCount"
 }
 
-func (p *ProxyETHTxCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-
-	/* not sure we need this. Need to figure out how to best unmarshal this in the future. For now this will work.
+func (p *ProxyETHTxCount) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetTransactionCountRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
-		return nil, err
-	}*/
-	qtumresp, err := p.Qtum.GetTransactionCount(c.Request().Context(), "", "")
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	param, err := eth.ParseBlockParam(req.Block)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	qtumresp, err := p.transactionCount(ctx, req.Address, param.Tag == eth.BlockTagPending)
 	if err != nil {
 		return nil, eth.NewCallbackError(err.Error())
 	}
@@ -34,6 +40,58 @@ func (p *ProxyETHTxCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (i
 	return p.response(qtumresp), nil
 }
 
+// transactionCount is the nonce of the next transaction from address, counting the ones not mined yet with pending
+func (p *ProxyETHTxCount) transactionCount(ctx context.Context, address string, pending bool) (*big.Int, error) {
+	count, err := p.Qtum.GetTransactionCount(ctx, address, "")
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		// wallets take the next nonce from the pending count, it goes up with each transaction not mined yet
+		count = new(big.Int).Add(count, big.NewInt(int64(p.pendingTransactions(ctx, address))))
+	}
+	return count, nil
+}
+
+// pendingTransactions counts the transactions from address in qtumd's mempool, the ones sent through Janus and with
+// -addressindex the ones qtumd knows spend from the address
+func (p *ProxyETHTxCount) pendingTransactions(ctx context.Context, address string) int {
+	if !utils.IsEthHexAddress(address) {
+		return 0
+	}
+	pending := make(map[string]bool)
+	if p.HasSentTransactions(address) {
+		mempool, err := p.GetRawMempool(ctx)
+		if err != nil {
+			p.GetDebugLogger().Log("method", p.Method(), "msg", "couldn't get mempool", "error", err)
+		} else {
+			for _, txid := range p.SentTransactions(address, mempool) {
+				pending[txid] = true
+			}
+		}
+	}
+
+	if p.GetCapabilities().Check(qtum.CapabilityAddressIndex) == nil {
+		base58Addr, err := p.FromHexAddress(utils.RemoveHexPrefix(address))
+		if err != nil {
+			p.GetDebugLogger().Log("method", p.Method(), "address", address, "msg", "error parsing address", "error", err)
+			return len(pending)
+		}
+		deltas, err := p.GetAddressMempool(ctx, &qtum.GetAddressMempoolRequest{Addresses: []string{base58Addr}})
+		if err != nil {
+			p.GetDebugLogger().Log("method", p.Method(), "address", base58Addr, "msg", "error getting address mempool", "error", err)
+			return len(pending)
+		}
+		for _, delta := range deltas {
+			// the inputs of a transaction spend from its sender
+			if delta.Satoshis < 0 {
+				pending[strings.ToLower(delta.TXID)] = true
+			}
+		}
+	}
+	return len(pending)
+}
+
 func (p *ProxyETHTxCount) response(qtumresp *big.Int) string {
 	return hexutil.EncodeBig(qtumresp)
 }
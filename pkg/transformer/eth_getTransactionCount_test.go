@@ -1,10 +1,12 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
 )
 
 func TestGetTransactionCountRequest(t *testing.T) {
@@ -23,7 +25,7 @@ func TestGetTransactionCountRequest(t *testing.T) {
 
 	//preparing proxy & executing request
 	proxyEth := ProxyETHTxCount{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -32,3 +34,49 @@ func TestGetTransactionCountRequest(t *testing.T) {
 
 	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
 }
+
+func TestGetTransactionCountPending(t *testing.T) {
+	const address = "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sent through Janus, one of them was mined since
+	qtumClient.TrackSentTransaction(address, "0xaa")
+	qtumClient.TrackSentTransaction(address, "0xbb")
+	mockedClientDoer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{"aa", "cc", "dd"})
+	// qtumd knows of another one spending from the address, and one paying to it
+	mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"))
+	// a mined transaction spending two outputs of the address, and one paying to it
+	mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, qtum.GetAddressDeltasResponse{
+		{TXID: "ee", Satoshis: -100000},
+		{TXID: "ee", Satoshis: -50000, Index: 1},
+		{TXID: "ff", Satoshis: 200000},
+	})
+	mockedClientDoer.AddResponse(qtum.MethodGetAddressMempool, qtum.GetAddressMempoolResponse{
+		{TXID: "aa", Satoshis: -100000},
+		{TXID: "cc", Satoshis: -200000},
+		{TXID: "dd", Satoshis: 300000},
+	})
+
+	proxyEth := ProxyETHTxCount{qtumClient}
+	for tag, want := range map[string]string{"latest": "0x1", "pending": "0x3"} {
+		requestParams := []json.RawMessage{[]byte(`"` + address + `"`), []byte(`"` + tag + `"`)}
+		request, err := internal.PrepareEthRPCRequest(1, requestParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+	}
+
+	// the mined transaction is forgotten
+	if sent := qtumClient.SentTransactions(address, []string{"aa", "bb"}); len(sent) != 1 || sent[0] != "aa" {
+		t.Errorf("expected only the transaction still in the mempool to be remembered, got %v", sent)
+	}
+}
@@ -15,6 +15,9 @@ import (
 var STATUS_SUCCESS = "0x1"
 var STATUS_FAILURE = "0x0"
 
+// QTUM transactions have no EIP-2718 type, they are reported as legacy transactions
+var LEGACY_TRANSACTION_TYPE = "0x0"
+
 // ProxyETHGetTransactionReceipt implements ETHProxy
 type ProxyETHGetTransactionReceipt struct {
 	*qtum.Qtum
@@ -24,7 +27,7 @@ func (p *ProxyETHGetTransactionReceipt) Method() string {
 	return "eth_getTransactionReceipt"
 }
 
-func (p *ProxyETHGetTransactionReceipt) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetTransactionReceipt) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.GetTransactionReceiptRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
@@ -38,43 +41,78 @@ func (p *ProxyETHGetTransactionReceipt) Request(rawreq *eth.JSONRPCRequest, c ec
 		txHash  = utils.RemoveHexPrefix(string(req))
 		qtumReq = qtum.GetTransactionReceiptRequest(txHash)
 	)
-	return p.request(c.Request().Context(), &qtumReq)
+	receipt, jsonErr := p.request(ctx, &qtumReq)
+	if jsonErr != nil || receipt == nil {
+		return receipt, jsonErr
+	}
+	p.setCumulativeGasUsed(ctx, receipt)
+
+	// receipts in blocks above "latest" are treated as not mined yet
+	blockNumber, err := utils.DecodeBig(receipt.BlockNumber)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	confirmed, jsonErr := isBlockConfirmed(ctx, p.Qtum, blockNumber)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if !confirmed {
+		return nil, nil
+	}
+
+	return receipt, nil
 }
 
 func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *qtum.GetTransactionReceiptRequest) (*eth.GetTransactionReceiptResponse, eth.JSONRPCError) {
 	qtumReceipt, err := p.Qtum.GetTransactionReceipt(ctx, string(*req))
 	if err != nil {
-		ethTx, _, getRewardTransactionErr := getRewardTransactionByHash(ctx, p.Qtum, string(*req))
-		if getRewardTransactionErr != nil {
-			errCause := errors.Cause(err)
-			if errCause == qtum.EmptyResponseErr {
-				return nil, nil
-			}
-			p.Qtum.GetDebugLogger().Log("msg", "Transaction does not exist", "txid", string(*req))
-			return nil, eth.NewCallbackError(err.Error())
-		}
-		if ethTx == nil {
-			// unconfirmed tx, return nil
-			// https://github.com/openethereum/parity-ethereum/issues/3482
+		return p.receiptWithoutContract(ctx, string(*req), err)
+	}
+	return p.translate(ctx, qtumReceipt)
+}
+
+// receiptWithoutContract is the receipt of a transaction gettransactionreceipt failed for with err, staking rewards
+// and transfers don't run a contract and have no receipt in qtumd
+func (p *ProxyETHGetTransactionReceipt) receiptWithoutContract(ctx context.Context, txid string, err error) (*eth.GetTransactionReceiptResponse, eth.JSONRPCError) {
+	tx, findErr := findChainTransaction(ctx, p.Qtum, txid)
+	if findErr != nil {
+		errCause := errors.Cause(err)
+		if errCause == qtum.EmptyResponseErr {
 			return nil, nil
 		}
-		return &eth.GetTransactionReceiptResponse{
-			TransactionHash:  ethTx.Hash,
-			TransactionIndex: ethTx.TransactionIndex,
-			BlockHash:        ethTx.BlockHash,
-			BlockNumber:      ethTx.BlockNumber,
-			// TODO: This is higher than GasUsed in geth but does it matter?
-			CumulativeGasUsed: NonContractVMGasLimit,
-			EffectiveGasPrice: "0x0",
-			GasUsed:           NonContractVMGasLimit,
-			From:              ethTx.From,
-			To:                ethTx.To,
-			Logs:              []eth.Log{},
-			LogsBloom:         eth.EmptyLogsBloom,
-			Status:            STATUS_SUCCESS,
-		}, nil
+		p.Qtum.GetDebugLogger().Log("msg", "Transaction does not exist", "txid", txid)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	if tx.blockHash == "" {
+		// unconfirmed tx, return nil
+		// https://github.com/openethereum/parity-ethereum/issues/3482
+		return nil, nil
+	}
+	ethTx, jsonErr := translateTransaction(ctx, p.Qtum, tx)
+	if jsonErr != nil {
+		return nil, jsonErr
 	}
+	return &eth.GetTransactionReceiptResponse{
+		TransactionHash:  utils.AddHexPrefix(txid),
+		TransactionIndex: ethTx.TransactionIndex,
+		BlockHash:        ethTx.BlockHash,
+		BlockNumber:      ethTx.BlockNumber,
+		// TODO: This is higher than GasUsed in geth but does it matter?
+		CumulativeGasUsed: NonContractVMGasLimit,
+		// transactions without a contract pay no gas
+		EffectiveGasPrice: "0x0",
+		GasUsed:           NonContractVMGasLimit,
+		From:              ethTx.From,
+		To:                ethTx.To,
+		Logs:              []eth.Log{},
+		LogsBloom:         eth.EmptyLogsBloom,
+		Status:            STATUS_SUCCESS,
+		Type:              LEGACY_TRANSACTION_TYPE,
+	}, nil
+}
 
+// translate turns the receipt of a contract transaction into an eth receipt
+func (p *ProxyETHGetTransactionReceipt) translate(ctx context.Context, qtumReceipt *qtum.GetTransactionReceiptResponse) (*eth.GetTransactionReceiptResponse, eth.JSONRPCError) {
 	ethReceipt := &eth.GetTransactionReceiptResponse{
 		TransactionHash:   utils.AddHexPrefix(qtumReceipt.TransactionHash),
 		TransactionIndex:  hexutil.EncodeUint64(qtumReceipt.TransactionIndex),
@@ -86,6 +124,7 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *qtum.G
 		GasUsed:           hexutil.EncodeUint64(qtumReceipt.GasUsed),
 		From:              utils.AddHexPrefixIfNotEmpty(qtumReceipt.From),
 		To:                utils.AddHexPrefixIfNotEmpty(qtumReceipt.To),
+		Type:              LEGACY_TRANSACTION_TYPE,
 
 		// TODO: researching
 		// ! Temporary accept this value to be always zero, as it is at eth logs
@@ -119,6 +158,27 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *qtum.G
 		ethReceipt.ContractAddress = ""
 	}
 
+	// QTUM charges the gas price of the transaction's script, there is no base fee to pay instead
+	if contractInfo, isContract, err := decodedRawQtumTx.ExtractContractInfo(); err == nil && isContract {
+		if gasPrice, err := contractGasPrice(contractInfo); err == nil {
+			ethReceipt.EffectiveGasPrice = hexutil.EncodeBig(satoshisToWei(gasPrice))
+		} else {
+			p.GetDebugLogger().Log("msg", "couldn't parse gas price", "gasPrice", contractInfo.GasPrice, "err", err)
+		}
+	}
+
+	// the standard receipt can't express the refund of unused gas, strict consumers get it without the extension
+	if p.ResponseExtensions() {
+		cost, err := getTransactionCost(qtumTx, decodedRawQtumTx, qtumReceipt.GasUsed)
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "couldn't compute transaction cost", "err", err)
+		} else {
+			ethReceipt.QtumFee = hexutil.EncodeBig(satoshisToWei(cost.fee))
+			ethReceipt.QtumRefund = hexutil.EncodeBig(satoshisToWei(cost.refund()))
+			ethReceipt.QtumCost = hexutil.EncodeBig(satoshisToWei(cost.cost()))
+		}
+	}
+
 	// TODO: researching
 	// - The following code reason is unknown (see original comment)
 	// - Code temporary commented, until an error occures
@@ -131,3 +191,61 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *qtum.G
 
 	return ethReceipt, nil
 }
+
+// setCumulativeGasUsed replaces qtumd's cumulative gas used, which only counts contract transactions, with the gas
+// the receipts of the block's transactions up to the receipt's report. Only the receipts up to it are fetched, unless
+// the block's receipts are cached. When they can't be fetched qtumd's value is kept
+func (p *ProxyETHGetTransactionReceipt) setCumulativeGasUsed(ctx context.Context, receipt *eth.GetTransactionReceiptResponse) {
+	cumulative, err := p.cumulativeGasUsed(ctx, receipt)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't compute cumulative gas used, keeping qtumd's", "hash", receipt.TransactionHash, "err", err)
+		return
+	}
+	receipt.CumulativeGasUsed = hexutil.EncodeUint64(cumulative)
+}
+
+func (p *ProxyETHGetTransactionReceipt) cumulativeGasUsed(ctx context.Context, receipt *eth.GetTransactionReceiptResponse) (uint64, error) {
+	index, err := hexutil.DecodeUint64(receipt.TransactionIndex)
+	if err != nil {
+		return 0, err
+	}
+	blockHash := utils.RemoveHexPrefix(receipt.BlockHash)
+	block, err := p.GetBlock(ctx, blockHash)
+	if err != nil {
+		return 0, errors.WithMessage(err, "couldn't get block")
+	}
+	if index >= uint64(len(block.Txs)) {
+		return 0, errors.New("transaction index out of the block")
+	}
+	txids := block.Txs[:index+1]
+	blockReceipts := p.CachedBlockReceipts(blockHash)
+	if blockReceipts == nil {
+		receipts, err := p.GetTransactionReceipts(ctx, blockHash, txids)
+		if err != nil {
+			return 0, errors.WithMessage(err, "couldn't get transaction receipts")
+		}
+		blockReceipts = &qtum.BlockReceipts{Hash: blockHash, Receipts: receipts}
+	}
+	cumulative := cumulativeGasUsed(txids, blockReceipts)
+	return cumulative[index], nil
+}
+
+// receiptGasUsed is the gas used the receipt of a transaction with txReceipts reports, transactions without a single
+// contract output get the gas of a transfer
+func receiptGasUsed(txReceipts []qtum.TransactionReceipt) uint64 {
+	if len(txReceipts) == 1 {
+		return txReceipts[0].GasUsed
+	}
+	return hexutil.MustDecodeUint64(NonContractVMGasLimit)
+}
+
+// cumulativeGasUsed is the gas used by the transactions of a block up to each of them, in block order
+func cumulativeGasUsed(txids []string, blockReceipts *qtum.BlockReceipts) []uint64 {
+	cumulative := make([]uint64, len(txids))
+	var gasUsed uint64
+	for i, txid := range txids {
+		gasUsed += receiptGasUsed(blockReceipts.Receipts[txid])
+		cumulative[i] = gasUsed
+	}
+	return cumulative
+}
@@ -1,7 +1,9 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/qtumproject/janus/pkg/eth"
@@ -43,9 +45,17 @@ func TestGetTransactionReceiptForNonVMTransaction(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// a coinbase transaction spends no output
+	err = mockedClientDoer.AddResponse(qtum.MethodDecodeRawTransaction, &qtum.DecodedRawTransactionResponse{
+		Vins: []*qtum.DecodedRawTransactionInV{{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	//preparing proxy & executing request
 	proxyEth := ProxyETHGetTransactionReceipt{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
@@ -58,12 +68,50 @@ func TestGetTransactionReceiptForNonVMTransaction(t *testing.T) {
 		GasUsed:           NonContractVMGasLimit,
 		Logs:              []eth.Log{},
 		EffectiveGasPrice: "0x0",
-		CumulativeGasUsed: NonContractVMGasLimit,
+		// the coinbase before it has no contract either
+		CumulativeGasUsed: "0xabe0",
 		To:                utils.AddHexPrefix(qtum.ZeroAddress),
 		From:              utils.AddHexPrefix(qtum.ZeroAddress),
 		LogsBloom:         eth.EmptyLogsBloom,
 		Status:            STATUS_SUCCESS,
+		Type:              LEGACY_TRANSACTION_TYPE,
 	}
 
 	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
 }
+
+func TestCumulativeGasUsed(t *testing.T) {
+	blockReceipts := &qtum.BlockReceipts{Receipts: map[string][]qtum.TransactionReceipt{
+		// qtumd counts only the contract transactions of the block
+		"call": {{GasUsed: 30000, CumulativeGasUsed: 30000}},
+		// several contract outputs have no single receipt, like a transfer
+		"calls": {{GasUsed: 40000, CumulativeGasUsed: 70000}, {GasUsed: 50000, CumulativeGasUsed: 120000}},
+	}}
+	got := cumulativeGasUsed([]string{"coinbase", "coinstake", "call", "transfer", "calls"}, blockReceipts)
+	want := []uint64{22000, 44000, 74000, 96000, 118000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCumulativeGasUsedFallback(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddError(qtum.MethodGetBlock, eth.NewCallbackError("Block not available (pruned data)")); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt := &eth.GetTransactionReceiptResponse{
+		TransactionIndex:  "0x2",
+		BlockHash:         "0xbba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5",
+		CumulativeGasUsed: "0x7530",
+	}
+	proxyEth := ProxyETHGetTransactionReceipt{qtumClient}
+	proxyEth.setCumulativeGasUsed(context.Background(), receipt)
+	if receipt.CumulativeGasUsed != "0x7530" {
+		t.Errorf("expected qtumd's cumulative gas used to be kept, got %s", receipt.CumulativeGasUsed)
+	}
+}
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 )
@@ -12,7 +13,7 @@ func (p *ETHGetUncleByBlockHashAndIndex) Method() string {
 	return "eth_getUncleByBlockHashAndIndex"
 }
 
-func (p *ETHGetUncleByBlockHashAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHGetUncleByBlockHashAndIndex) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	// hardcoded to nil
 	return nil, nil
 }
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 )
@@ -12,7 +13,7 @@ func (p *ETHGetUncleCountByBlockHash) Method() string {
 	return "eth_getUncleCountByBlockHash"
 }
 
-func (p *ETHGetUncleCountByBlockHash) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHGetUncleCountByBlockHash) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	// hardcoded to 0
 	return 0, nil
 }
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 )
@@ -12,7 +13,7 @@ func (p *ETHGetUncleCountByBlockNumber) Method() string {
 	return "eth_getUncleCountByBlockNumber"
 }
 
-func (p *ETHGetUncleCountByBlockNumber) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHGetUncleCountByBlockNumber) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	// hardcoded to 0
 	return "0x0", nil
 }
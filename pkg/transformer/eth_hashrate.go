@@ -2,7 +2,7 @@ package transformer
 
 import (
 	"context"
-	"math"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
@@ -11,6 +11,8 @@ import (
 )
 
 //ProxyETHGetHashrate implements ETHProxy
+// Qtum is proof of stake, the hashrate is the staking weight of the node in satoshis (the amount of
+// mature coins it is staking with), 0 when the node isn't staking
 type ProxyETHHashrate struct {
 	*qtum.Qtum
 }
@@ -19,8 +21,8 @@ func (p *ProxyETHHashrate) Method() string {
 	return "eth_hashrate"
 }
 
-func (p *ProxyETHHashrate) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request(c.Request().Context())
+func (p *ProxyETHHashrate) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
 }
 
 func (p *ProxyETHHashrate) request(ctx context.Context) (*eth.HashrateResponse, eth.JSONRPCError) {
@@ -34,7 +36,10 @@ func (p *ProxyETHHashrate) request(ctx context.Context) (*eth.HashrateResponse,
 }
 
 func (p *ProxyETHHashrate) ToResponse(qtumresp *qtum.GetHashrateResponse) *eth.HashrateResponse {
-	hexVal := hexutil.EncodeUint64(math.Float64bits(qtumresp.Difficulty))
-	ethresp := eth.HashrateResponse(hexVal)
+	weight := big.NewInt(0)
+	if qtumresp.Staking && qtumresp.Weight != nil {
+		weight = qtumresp.Weight
+	}
+	ethresp := eth.HashrateResponse(hexutil.EncodeBig(weight))
 	return &ethresp
 }
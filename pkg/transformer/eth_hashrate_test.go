@@ -1,8 +1,8 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
-	"math"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -12,6 +12,16 @@ import (
 )
 
 func TestHashrateRequest(t *testing.T) {
+	exampleResponse := `{"enabled": true, "staking": true, "errors": "", "currentblocktx": 0, "pooledtx": 0, "difficulty": 4.656542373906925e-010, "search-interval": 0, "weight": 150000000000, "netstakeweight": 0, "expectedtime": 0}`
+	testHashrateRequest(t, exampleResponse, hexutil.EncodeUint64(150000000000))
+}
+
+func TestHashrateRequestNotStaking(t *testing.T) {
+	exampleResponse := `{"enabled": true, "staking": false, "errors": "", "currentblocktx": 0, "pooledtx": 0, "difficulty": 4.656542373906925e-010, "search-interval": 0, "weight": 150000000000, "netstakeweight": 0, "expectedtime": 0}`
+	testHashrateRequest(t, exampleResponse, "0x0")
+}
+
+func testHashrateRequest(t *testing.T, stakingInfo string, expected string) {
 	//preparing the request
 	requestParams := []json.RawMessage{} //eth_hashrate has no params
 	request, err := internal.PrepareEthRPCRequest(1, requestParams)
@@ -25,9 +35,10 @@ func TestHashrateRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	exampleResponse := `{"enabled": true, "staking": false, "errors": "", "currentblocktx": 0, "pooledtx": 0, "difficulty": 4.656542373906925e-010, "search-interval": 0, "weight": 0, "netstakeweight": 0, "expectedtime": 0}`
 	getHashrateResponse := qtum.GetHashrateResponse{}
-	unmarshalRequest([]byte(exampleResponse), &getHashrateResponse)
+	if err = json.Unmarshal([]byte(stakingInfo), &getHashrateResponse); err != nil {
+		t.Fatal(err)
+	}
 
 	err = mockedClientDoer.AddResponse(qtum.MethodGetStakingInfo, getHashrateResponse)
 	if err != nil {
@@ -35,12 +46,11 @@ func TestHashrateRequest(t *testing.T) {
 	}
 
 	proxyEth := ProxyETHHashrate{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
 
-	expected := hexutil.EncodeUint64(math.Float64bits(4.656542373906925e-010))
 	want := eth.HashrateResponse(expected)
 
 	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
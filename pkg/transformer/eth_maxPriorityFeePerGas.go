@@ -0,0 +1,71 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+// priorityFeeConfirmationTarget is the blocks within which the suggested tip should get a transaction mined
+const priorityFeeConfirmationTarget = 2
+
+// maxPriorityFeeMultiple caps the suggested tip at this many times the minimum gas price
+const maxPriorityFeeMultiple = 10
+
+// ProxyETHMaxPriorityFeePerGas implements ETHProxy
+type ProxyETHMaxPriorityFeePerGas struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyETHMaxPriorityFeePerGas)(nil)
+
+func (p *ProxyETHMaxPriorityFeePerGas) Method() string {
+	return "eth_maxPriorityFeePerGas"
+}
+
+func (p *ProxyETHMaxPriorityFeePerGas) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
+}
+
+// QTUM has no base fee, the minimum gas price stands in for it like in eth_feeHistory and the tip is paid over it.
+// The tip grows with how much qtumd's fee estimate is above the minimum relay fee, so it is 0 unless blocks are full
+func (p *ProxyETHMaxPriorityFeePerGas) request(ctx context.Context) (string, eth.JSONRPCError) {
+	minimumGasPrice, err := p.GetGasPrice(ctx)
+	if err != nil {
+		return "", eth.NewCallbackError(err.Error())
+	}
+
+	// without an estimate the minimum gas price gets transactions mined, wallets shouldn't fail to send over it
+	estimate, err := p.EstimateSmartFee(ctx, priorityFeeConfirmationTarget)
+	if err != nil || estimate.FeeRate == nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "No fee estimate, suggesting no tip", "error", err)
+		return hexutil.EncodeBig(big.NewInt(0)), nil
+	}
+	networkInfo, err := p.GetNetworkInfo(ctx)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "No minimum relay fee, suggesting no tip", "error", err)
+		return hexutil.EncodeBig(big.NewInt(0)), nil
+	}
+
+	tip := priorityFee(minimumGasPrice, *estimate.FeeRate, networkInfo.RelayFee)
+	return hexutil.EncodeBig(satoshisToWei(tip)), nil
+}
+
+// priorityFee is the tip in satoshi per gas, the minimum gas price scaled by how far feeRate is above relayFee and
+// rounded up to a whole satoshi
+func priorityFee(minimumGasPrice *big.Int, feeRate decimal.Decimal, relayFee decimal.Decimal) *big.Int {
+	if !relayFee.IsPositive() || feeRate.LessThanOrEqual(relayFee) {
+		return big.NewInt(0)
+	}
+	minimum := decimal.NewFromBigInt(minimumGasPrice, 0)
+	tip := minimum.Mul(feeRate.Div(relayFee).Sub(decimal.NewFromInt(1))).Ceil()
+	if max := minimum.Mul(decimal.NewFromInt(maxPriorityFeeMultiple)); tip.GreaterThan(max) {
+		tip = max
+	}
+	return tip.BigInt()
+}
@@ -0,0 +1,84 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+func TestMaxPriorityFeePerGasRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		estimate *qtum.EstimateSmartFeeResponse
+		want     string
+	}{
+		{
+			name:     "no estimate",
+			estimate: &qtum.EstimateSmartFeeResponse{Errors: []string{"Insufficient data or no feerate found"}},
+			want:     "0x0",
+		},
+		{
+			name:     "at the relay fee",
+			estimate: &qtum.EstimateSmartFeeResponse{FeeRate: decimalPointer("0.004"), Blocks: 2},
+			want:     "0x0",
+		},
+		{
+			name:     "twice the relay fee",
+			estimate: &qtum.EstimateSmartFeeResponse{FeeRate: decimalPointer("0.008"), Blocks: 2},
+			// 40 satoshi
+			want: "0x5d21dba000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mockedClientDoer := internal.NewDoerMappedMock()
+			qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mockedClientDoer.AddResponseWithRequestID(1, qtum.MethodEstimateSmartFee, test.estimate); err != nil {
+				t.Fatal(err)
+			}
+			if err := mockedClientDoer.AddResponseWithRequestID(1, qtum.MethodGetNetworkInfo, qtum.NetworkInfoResponse{Version: 220100, RelayFee: decimal.RequireFromString("0.004")}); err != nil {
+				t.Fatal(err)
+			}
+
+			proxyEth := ProxyETHMaxPriorityFeePerGas{qtumClient}
+			got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+			if jsonErr != nil {
+				t.Fatal(jsonErr)
+			}
+			internal.CheckTestResultDefault(test.want, got, t, false)
+		})
+	}
+}
+
+func TestPriorityFee(t *testing.T) {
+	relayFee := decimal.RequireFromString("0.004")
+	for feeRate, want := range map[string]int64{
+		"0.001":  0,
+		"0.004":  0,
+		"0.0041": 1,
+		"0.006":  20,
+		"1":      400,
+	} {
+		if got := priorityFee(big.NewInt(40), decimal.RequireFromString(feeRate), relayFee); got.Int64() != want {
+			t.Errorf("expected a tip of %d satoshi at %s QTUM/kB, got %s", want, feeRate, got)
+		}
+	}
+}
+
+func decimalPointer(value string) *decimal.Decimal {
+	d := decimal.RequireFromString(value)
+	return &d
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/qtumproject/janus/pkg/qtum"
 )
 
-//ProxyETHGetHashrate implements ETHProxy
+//ProxyETHMining implements ETHProxy
+// Qtum is proof of stake, the node is mining when it is actively staking
 type ProxyETHMining struct {
 	*qtum.Qtum
 }
@@ -17,8 +18,8 @@ func (p *ProxyETHMining) Method() string {
 	return "eth_mining"
 }
 
-func (p *ProxyETHMining) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request(c.Request().Context())
+func (p *ProxyETHMining) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
 }
 
 func (p *ProxyETHMining) request(ctx context.Context) (*eth.MiningResponse, eth.JSONRPCError) {
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -15,8 +16,8 @@ func (p *ProxyNetListening) Method() string {
 	return "net_listening"
 }
 
-func (p *ProxyNetListening) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	networkInfo, err := p.GetNetworkInfo(c.Request().Context())
+func (p *ProxyNetListening) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	networkInfo, err := p.GetNetworkInfo(ctx)
 	if err != nil {
 		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to query network info", "err", err)
 		return false, eth.NewCallbackError(err.Error())
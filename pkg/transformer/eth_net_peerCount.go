@@ -18,8 +18,8 @@ func (p *ProxyNetPeerCount) Method() string {
 	return "net_peerCount"
 }
 
-func (p *ProxyNetPeerCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request(c.Request().Context())
+func (p *ProxyNetPeerCount) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
 }
 
 func (p *ProxyNetPeerCount) request(ctx context.Context) (*eth.NetPeerCountResponse, eth.JSONRPCError) {
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -44,7 +45,7 @@ func testPeerCountRequest(t *testing.T, clients int) {
 	}
 
 	proxyEth := ProxyNetPeerCount{qtumClient}
-	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
 	if jsonErr != nil {
 		t.Fatal(jsonErr)
 	}
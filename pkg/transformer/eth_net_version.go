@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
@@ -16,7 +17,7 @@ func (p *ProxyETHNetVersion) Method() string {
 	return "net_version"
 }
 
-func (p *ProxyETHNetVersion) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHNetVersion) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	return p.request()
 }
 
@@ -19,8 +19,12 @@ func (p *ProxyETHNewBlockFilter) Method() string {
 	return "eth_newBlockFilter"
 }
 
-func (p *ProxyETHNewBlockFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
-	return p.request(c.Request().Context())
+func (p *ProxyETHNewBlockFilter) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
+}
+
+func (p *ProxyETHNewBlockFilter) filterSimulator() *eth.FilterSimulator {
+	return p.filter
 }
 
 func (p *ProxyETHNewBlockFilter) request(ctx context.Context) (eth.NewBlockFilterResponse, eth.JSONRPCError) {
@@ -29,8 +33,14 @@ func (p *ProxyETHNewBlockFilter) request(ctx context.Context) (eth.NewBlockFilte
 		return "", eth.NewCallbackError(err.Error())
 	}
 
-	filter := p.filter.New(eth.NewBlockFilterTy)
+	filter, err := p.filter.New(ctx, eth.NewBlockFilterTy)
+	if err != nil {
+		return "", eth.NewCallbackError(err.Error())
+	}
 	filter.Data.Store("lastBlockNumber", blockCount.Uint64())
+	if err := p.filter.Save(ctx, filter); err != nil {
+		return "", eth.NewCallbackError(err.Error())
+	}
 
 	p.GenerateIfPossible()
 
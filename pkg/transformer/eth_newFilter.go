@@ -20,39 +20,50 @@ func (p *ProxyETHNewFilter) Method() string {
 	return "eth_newFilter"
 }
 
-func (p *ProxyETHNewFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHNewFilter) filterSimulator() *eth.FilterSimulator {
+	return p.filter
+}
+
+func (p *ProxyETHNewFilter) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.NewFilterRequest
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(ctx, &req)
 }
 
 func (p *ProxyETHNewFilter) request(ctx context.Context, ethreq *eth.NewFilterRequest) (*eth.NewFilterResponse, eth.JSONRPCError) {
 
-	from, err := getBlockNumberByRawParam(ctx, p.Qtum, ethreq.FromBlock, true)
+	from, err := resolveBlockNumber(ctx, p.Qtum, ethreq.FromBlock, true)
 	if err != nil {
 		return nil, err
 	}
 
-	to, err := getBlockNumberByRawParam(ctx, p.Qtum, ethreq.ToBlock, true)
+	to, err := resolveBlockNumber(ctx, p.Qtum, ethreq.ToBlock, true)
 	if err != nil {
 		return nil, err
 	}
 
-	filter := p.filter.New(eth.NewFilterTy, ethreq)
+	// reject filters that would fail on every eth_getFilterChanges
+	if _, err := eth.ParseFilterAddressesJSON(ethreq.Address); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if _, topicsErr := eth.TranslateTopics(ethreq.Topics); topicsErr != nil {
+		return nil, eth.NewInvalidParamsError(topicsErr.Error())
+	}
+
+	filter, filterErr := p.filter.New(ctx, eth.NewFilterTy, ethreq)
+	if filterErr != nil {
+		return nil, eth.NewCallbackError(filterErr.Error())
+	}
 	filter.Data.Store("lastBlockNumber", from.Uint64())
 
 	filter.Data.Store("toBlock", to.Uint64())
 
-	if len(ethreq.Topics) > 0 {
-		topics, err := eth.TranslateTopics(ethreq.Topics)
-		if err != nil {
-			return nil, eth.NewCallbackError(err.Error())
-		}
-		filter.Data.Store("topics", qtum.NewSearchLogsTopics(topics))
+	if filterErr := p.filter.Save(ctx, filter); filterErr != nil {
+		return nil, eth.NewCallbackError(filterErr.Error())
 	}
 	resp := eth.NewFilterResponse(hexutil.EncodeUint64(filter.ID))
 	return &resp, nil
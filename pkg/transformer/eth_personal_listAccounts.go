@@ -0,0 +1,28 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyETHPersonalListAccounts implements ETHProxy
+type ProxyETHPersonalListAccounts struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHPersonalListAccounts) Method() string {
+	return "personal_listAccounts"
+}
+
+// Request lists the accounts Janus holds the keys of, locked or not, without the addresses of qtumd's wallet
+func (p *ProxyETHPersonalListAccounts) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	accounts := eth.PersonalListAccountsResponse{}
+	for _, addr := range p.Keystore.Addresses() {
+		accounts = append(accounts, utils.AddHexPrefix(addr))
+	}
+	return accounts, nil
+}
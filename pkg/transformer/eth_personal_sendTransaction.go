@@ -0,0 +1,42 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyETHPersonalSendTransaction implements ETHProxy
+type ProxyETHPersonalSendTransaction struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHPersonalSendTransaction) Method() string {
+	return "personal_sendTransaction"
+}
+
+func (p *ProxyETHPersonalSendTransaction) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.PersonalSendTransactionRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	// accounts outside the key files are sent from by the wallet as they are by eth_sendTransaction
+	from := strings.ToLower(utils.RemoveHexPrefix(req.Transaction.From))
+	if !p.Keystore.IsEncrypted(from) {
+		return (&ProxyETHSendTransaction{p.Qtum}).send(ctx, &req.Transaction)
+	}
+
+	acc, err := p.Keystore.Decrypt(from, req.Passphrase)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "account", from, "msg", "Failed to decrypt account", "error", err)
+		return nil, accountError(from, err)
+	}
+
+	// the key signs here and the transaction is broadcast, qtumd's wallet never holds it
+	return (&ProxyETHSendTransaction{p.Qtum}).sendWithKey(ctx, &req.Transaction, acc)
+}
@@ -0,0 +1,135 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+// unsignedTestTransaction spends an output of the account of key back to it
+func unsignedTestTransaction(t *testing.T, key *btcutil.WIF) (string, []byte) {
+	pkScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(key.SerializePubKey())).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(99000000, pkScript))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(buf.Bytes()), pkScript
+}
+
+func TestSignWithKey(t *testing.T) {
+	for _, wif := range []string{"5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89", "cMbgxCJrTYUqgcmiC1berh5DFrtY1KeU4PXZ6NZxgenniF1mXCRk"} {
+		key, err := btcutil.DecodeWIF(wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsigned, pkScript := unsignedTestTransaction(t, key)
+		signed, err := signWithKey(unsigned, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := hex.DecodeString(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		engine, err := txscript.NewEngine(pkScript, &tx, 0, txscript.StandardVerifyFlags, nil, nil, 100000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.Execute(); err != nil {
+			t.Errorf("%s: expected the signature to spend the output, got %v", wif, err)
+		}
+	}
+}
+
+func TestPersonalSendTransactionRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient := newKeystoreClient(t, mockedClientDoer)
+
+	key, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, _ := unsignedTestTransaction(t, key)
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressUTXOs, qtum.GetAddressUTXOsResponse{
+		{TXID: "0100000000000000000000000000000000000000000000000000000000000000", Satoshis: decimal.NewFromInt(100000000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodCreateRawTx, unsigned); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodSendRawTx, "6b7f70d8520e1ec87ba7f1ee559b491cc3028b77ae166e789be882b5d370eac9"); err != nil {
+		t.Fatal(err)
+	}
+	// the key never reaches qtumd's wallet
+	if err := mockedClientDoer.AddError(qtum.MethodImportPrivKey, eth.NewCallbackError("key imported")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddError(qtum.MethodSignRawTx, eth.NewCallbackError("signed by the wallet")); err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := []byte(`{"from":"0x6d358cf96533189dd5a602d0937fddf0888ad3ae","to":"0x2e6f89d7399081b4f8f8aa1ae2805a5efff2f960","data":"0xa9059cbb"}`)
+	proxy := &ProxyETHPersonalSendTransaction{Qtum: qtumClient}
+	prepare := func(passphrase string) *eth.JSONRPCRequest {
+		request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{transaction, []byte(`"` + passphrase + `"`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return request
+	}
+
+	if _, jsonErr := proxy.Request(context.Background(), prepare("wrong"), nil); jsonErr == nil {
+		t.Error("expected a wrong passphrase to be refused")
+	}
+	want := eth.SendTransactionResponse("0x6b7f70d8520e1ec87ba7f1ee559b491cc3028b77ae166e789be882b5d370eac9")
+	request := prepare("janus")
+	got, jsonErr := proxy.Request(context.Background(), request, nil)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+
+	// the passphrase was for that transaction only
+	sendRequest, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{transaction})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, jsonErr = (&ProxyETHSendTransaction{qtumClient}).Request(context.Background(), sendRequest, nil)
+	if jsonErr == nil || jsonErr.Code() != eth.UnauthorizedErrorCode {
+		t.Errorf("expected eth_sendTransaction to need the account unlocked, got %v", jsonErr)
+	}
+}
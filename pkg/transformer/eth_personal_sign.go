@@ -0,0 +1,64 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyETHPersonalSign implements ETHProxy
+type ProxyETHPersonalSign struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHPersonalSign) Method() string {
+	return "personal_sign"
+}
+
+func (p *ProxyETHPersonalSign) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.PersonalSignRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	addr := utils.RemoveHexPrefix(req.Account)
+	acc, err := p.Keystore.Decrypt(addr, req.Passphrase)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "account", addr, "msg", "Failed to decrypt account", "error", err)
+		return nil, accountError(addr, err)
+	}
+
+	sig, err := signTextHash(acc.PrivKey, req.Message)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to sign message", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return eth.SignResponse(hexutil.Encode(sig)), nil
+}
+
+// signTextHash signs msg as geth's personal_sign does, EIP-191 version 0x45 with the signature as r, s and a v of 27
+// or 28. The address recovered from it is the Ethereum address of the key, not its Qtum hex address
+func signTextHash(key *btcec.PrivateKey, msg []byte) ([]byte, error) {
+	return signHash(key, textHash(msg))
+}
+
+// signHash signs a 32 byte hash as Ethereum wallets do, r, s and a v of 27 or 28
+func signHash(key *btcec.PrivateKey, hash []byte) ([]byte, error) {
+	sig, err := crypto.Sign(hash, key.ToECDSA())
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func textHash(msg []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))), msg)
+}
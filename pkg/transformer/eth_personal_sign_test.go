@@ -0,0 +1,87 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+)
+
+func TestTextHash(t *testing.T) {
+	// hashMessage("hello world") of ethers
+	want := "0xd9eba16ed0ecae432b71fe008c98cc872bb4cc214d3220a36f365326cf807d68"
+	if got := hexutil.Encode(textHash([]byte("hello world"))); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestPersonalSignRequest(t *testing.T) {
+	qtumClient := newKeystoreClient(t, internal.NewDoerMappedMock())
+	exampleAcc, err := btcutil.DecodeWIF("5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(exampleAcc)
+
+	accounts, jsonErr := (&ProxyETHPersonalListAccounts{qtumClient}).Request(context.Background(), nil, nil)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	wantAccounts := eth.PersonalListAccountsResponse{"0x7e22630f90e6db16283af2c6b04f688117a55db4", "0x6d358cf96533189dd5a602d0937fddf0888ad3ae"}
+	internal.CheckTestResultDefault(wantAccounts, accounts, t, false)
+
+	sign := func(params ...string) (interface{}, eth.JSONRPCError) {
+		rawParams := make([]json.RawMessage, 0, len(params))
+		for _, param := range params {
+			rawParams = append(rawParams, json.RawMessage(param))
+		}
+		request, err := internal.PrepareEthRPCRequest(1, rawParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (&ProxyETHPersonalSign{qtumClient}).Request(context.Background(), request, nil)
+	}
+
+	// the encrypted account is decrypted for the signature without being unlocked
+	if _, jsonErr := sign(`"0x68656c6c6f20776f726c64"`, `"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`, `"wrong"`); jsonErr == nil {
+		t.Error("expected a wrong passphrase to be refused")
+	}
+	keys := map[string]string{
+		"0x6d358cf96533189dd5a602d0937fddf0888ad3ae": "5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89",
+		"0x7e22630f90e6db16283af2c6b04f688117a55db4": "5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec",
+	}
+	for address, key := range keys {
+		result, jsonErr := sign(`"0x68656c6c6f20776f726c64"`, `"`+address+`"`, `"janus"`)
+		if jsonErr != nil {
+			t.Fatalf("%s: %v", address, jsonErr)
+		}
+		sig, err := hexutil.Decode(string(result.(eth.SignResponse)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+			t.Fatalf("%s: expected a 65 byte signature with a v of 27 or 28, got %x", address, sig)
+		}
+		sig[64] -= 27
+		pubKey, err := crypto.Ecrecover(textHash([]byte("hello world")), sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wif, err := btcutil.DecodeWIF(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pubKey, wif.PrivKey.PubKey().SerializeUncompressed()) {
+			t.Errorf("%s: expected the signature to recover the account's public key", address)
+		}
+	}
+	if _, err := qtumClient.Keystore.Find("0x6d358cf96533189dd5a602d0937fddf0888ad3ae"); err == nil {
+		t.Error("expected the encrypted account to stay locked")
+	}
+}
@@ -1,17 +1,51 @@
 package transformer
 
 import (
+	"context"
+	"time"
+
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
 )
 
 // ProxyETHPersonalUnlockAccount implements ETHProxy
-type ProxyETHPersonalUnlockAccount struct{}
+type ProxyETHPersonalUnlockAccount struct {
+	*qtum.Qtum
+}
 
 func (p *ProxyETHPersonalUnlockAccount) Method() string {
 	return "personal_unlockAccount"
 }
 
-func (p *ProxyETHPersonalUnlockAccount) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHPersonalUnlockAccount) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.PersonalUnlockAccountRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	if !p.GetFlagBool(qtum.FLAG_ALLOW_INSECURE_UNLOCK) {
+		return nil, eth.NewUnauthorizedError("account unlock with HTTP access is forbidden, start Janus with --allow-insecure-unlock")
+	}
+	// accounts outside the keystore are signed for by qtumd's wallet, which is unlocked with its own passphrase
+	if !p.Keystore.Has(req.Account) {
+		return nil, accountError(req.Account, qtum.ErrUnknownAccount)
+	}
+
+	var duration *time.Duration
+	if req.Duration != nil {
+		if *req.Duration > uint64(maxUnlockDuration/time.Second) {
+			return nil, eth.NewInvalidParamsError("unlock duration too large")
+		}
+		seconds := time.Duration(*req.Duration) * time.Second
+		duration = &seconds
+	}
+	if err := p.Keystore.Unlock(req.Account, req.Passphrase, duration); err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "account", req.Account, "msg", "Failed to unlock account", "error", err)
+		return nil, accountError(req.Account, err)
+	}
 	return eth.PersonalUnlockAccountResponse(true), nil
 }
+
+// the longest duration that doesn't overflow
+const maxUnlockDuration = time.Duration(1<<63 - 1)
@@ -0,0 +1,98 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// the key of 0x6d358cf96533189dd5a602d0937fddf0888ad3ae encrypted with the passphrase "janus"
+const exampleKeyFile = `{"address":"6d358cf96533189dd5a602d0937fddf0888ad3ae","crypto":{"cipher":"aes-128-ctr","ciphertext":"83b07da022ee8508d58b9282d47778b0fcac8a5eae901cea3f7796a2617c5b3d","cipherparams":{"iv":"30313233343536373839616263646566"},"kdf":"scrypt","kdfparams":{"n":16,"r":8,"p":1,"dklen":32,"salt":"3031323334353637383961626364656630313233343536373839616263646566"},"mac":"dac2204ce5a35c4cbdca16bea7052662a2a7ae4bb53b41f1f8ba1a3e185a597e"},"version":3}`
+
+// newKeystoreClient is a mocked client with exampleKeyFile in its keystore
+func newKeystoreClient(t *testing.T, doer internal.Doer) *qtum.Qtum {
+	qtumClient, err := internal.CreateMockedClient(doer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key.json"), []byte(exampleKeyFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := qtum.SetKeystore(dir, 0, qtum.DefaultUnlockTimeout)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	if err := qtumClient.Keystore.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return qtumClient
+}
+
+func TestPersonalUnlockAccountRequest(t *testing.T) {
+	qtumClient := newKeystoreClient(t, internal.NewDoerMappedMock())
+
+	accounts, jsonErr := (&ProxyETHAccounts{qtumClient}).request(context.Background())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if len(accounts) != 1 || accounts[0] != "0x6d358cf96533189dd5a602d0937fddf0888ad3ae" {
+		t.Errorf("expected the locked account to be listed, got %v", accounts)
+	}
+
+	sign := func() eth.JSONRPCError {
+		request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+			[]byte(`"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`),
+			[]byte(`"0x00"`),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, jsonErr := (&ProxyETHSign{qtumClient}).Request(context.Background(), request, nil)
+		return jsonErr
+	}
+	unlock := func(params ...string) (interface{}, eth.JSONRPCError) {
+		rawParams := make([]json.RawMessage, 0, len(params))
+		for _, param := range params {
+			rawParams = append(rawParams, json.RawMessage(param))
+		}
+		request, err := internal.PrepareEthRPCRequest(1, rawParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (&ProxyETHPersonalUnlockAccount{qtumClient}).Request(context.Background(), request, nil)
+	}
+
+	if jsonErr := sign(); jsonErr == nil || jsonErr.Code() != eth.UnauthorizedErrorCode {
+		t.Errorf("expected signing for a locked account to need authentication, got %v", jsonErr)
+	}
+	if _, jsonErr := unlock(`"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`, `"janus"`); jsonErr == nil || jsonErr.Code() != eth.UnauthorizedErrorCode {
+		t.Errorf("expected unlocking to be forbidden without --allow-insecure-unlock, got %v", jsonErr)
+	}
+	if err := qtum.SetAllowInsecureUnlock(true)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := unlock(`"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`, `"wrong"`); jsonErr == nil {
+		t.Error("expected a wrong passphrase to be refused")
+	}
+	result, jsonErr := unlock(`"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`, `"janus"`, `0`)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if result != eth.PersonalUnlockAccountResponse(true) {
+		t.Errorf("expected the account to be unlocked, got %v", result)
+	}
+	if jsonErr := sign(); jsonErr != nil {
+		t.Errorf("expected the unlocked account to sign, got %v", jsonErr)
+	}
+
+	if _, jsonErr := unlock(`"0x7e22630f90e6db16283af2c6b04f688117a55db4"`, `"any"`); jsonErr == nil || !strings.Contains(jsonErr.Message(), "No such account") {
+		t.Errorf("expected accounts outside the keystore to be unknown, got %v", jsonErr)
+	}
+}
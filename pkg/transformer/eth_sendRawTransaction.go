@@ -2,6 +2,7 @@ package transformer
 
 import (
 	"context"
+	"strings"
 
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
@@ -20,7 +21,7 @@ func (p *ProxyETHSendRawTransaction) Method() string {
 	return "eth_sendRawTransaction"
 }
 
-func (p *ProxyETHSendRawTransaction) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHSendRawTransaction) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var params eth.SendRawTransactionRequest
 	if err := unmarshalRequest(req.Params, &params); err != nil {
 		// TODO: Correct error code?
@@ -31,7 +32,7 @@ func (p *ProxyETHSendRawTransaction) Request(req *eth.JSONRPCRequest, c echo.Con
 		return nil, eth.NewInvalidParamsError("invalid parameter: raw transaction hexed string is empty")
 	}
 
-	return p.request(c.Request().Context(), params)
+	return p.request(ctx, params)
 }
 
 func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.SendRawTransactionRequest) (eth.SendRawTransactionResponse, eth.JSONRPCError) {
@@ -40,6 +41,16 @@ func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.Sen
 		req            = qtum.SendRawTransactionRequest([1]string{qtumHexedRawTx})
 	)
 
+	if raw, ok := ethereumTransactionHex(qtumHexedRawTx); ok {
+		return p.sendEthereumTransaction(ctx, raw)
+	}
+
+	if p.GetFlagBool(qtum.FLAG_MEMPOOL_PRECHECK) {
+		if jsonErr := p.testMempoolAccept(ctx, qtumHexedRawTx); jsonErr != nil {
+			return eth.SendRawTransactionResponse(""), jsonErr
+		}
+	}
+
 	qtumresp, err := p.Qtum.SendRawTransaction(ctx, &req)
 	if err != nil {
 		if err == qtum.ErrVerifyAlreadyInChain {
@@ -52,9 +63,11 @@ func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.Sen
 			}
 			qtumresp = &qtum.SendRawTransactionResponse{Result: rawTx.Hash}
 		} else {
+			p.recordFailure(ctx, qtumHexedRawTx, err)
 			return eth.SendRawTransactionResponse(""), eth.NewCallbackError(err.Error())
 		}
 	} else {
+		p.trackSender(ctx, qtumHexedRawTx, qtumresp.Result)
 		p.GenerateIfPossible()
 	}
 
@@ -62,3 +75,83 @@ func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.Sen
 	ethHexedTxHash := utils.AddHexPrefix(resp.Result)
 	return eth.SendRawTransactionResponse(ethHexedTxHash), nil
 }
+
+// trackSender remembers the sender of a raw transaction sent, so the pending transaction count of the sender goes up
+// before it's mined. With -addressindex qtumd counts the transactions in its mempool already
+func (p *ProxyETHSendRawTransaction) trackSender(ctx context.Context, qtumHexedRawTx string, txid string) {
+	if p.GetCapabilities().Check(qtum.CapabilityAddressIndex) == nil {
+		return
+	}
+	decoded, err := p.Qtum.DecodeRawTransaction(ctx, qtumHexedRawTx)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "Couldn't decode sent raw transaction to track its sender", "txid", txid, "err", err)
+		return
+	}
+	from := ""
+	if info, isContractTx, _ := decoded.ExtractContractInfo(); isContractTx && info.From != "" {
+		from = utils.AddHexPrefix(info.From)
+	} else if from, err = getNonContractTxSenderAddress(ctx, p.Qtum, decoded); err != nil {
+		p.GetDebugLogger().Log("msg", "Couldn't find the sender of sent raw transaction", "txid", txid, "err", err)
+		return
+	}
+	p.TrackSentTransaction(from, txid)
+}
+
+// recordFailure adds the transaction to the journal, so it can be broadcast again with janus_rebroadcastTransaction
+func (p *ProxyETHSendRawTransaction) recordFailure(ctx context.Context, qtumHexedRawTx string, err error) {
+	journal := p.GetJournal()
+	if journal == nil {
+		return
+	}
+	if journalErr := journal.Record(ctx, qtumHexedRawTx, err.Error()); journalErr != nil {
+		p.GetErrorLogger().Log("msg", "Error recording failed raw transaction", "err", journalErr)
+		p.GetCapabilities().MarkUnavailable(qtum.CapabilityTransactionJournal, journalErr.Error())
+		return
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityTransactionJournal)
+}
+
+// testMempoolAccept asks qtumd if it would accept the transaction, so the precise rejection reason can be returned
+func (p *ProxyETHSendRawTransaction) testMempoolAccept(ctx context.Context, qtumHexedRawTx string) eth.JSONRPCError {
+	results, err := p.Qtum.TestMempoolAccept(ctx, &qtum.TestMempoolAcceptRequest{RawTransactions: []string{qtumHexedRawTx}})
+	if err != nil {
+		// older qtumd versions don't have testmempoolaccept, let sendrawtransaction report any problem
+		p.GetDebugLogger().Log("msg", "testmempoolaccept failed, broadcasting without pre-check", "err", err)
+		return nil
+	}
+
+	for _, result := range results {
+		if result.Allowed {
+			continue
+		}
+		// already in the chain is handled by the normal broadcast path
+		if result.RejectReason == "txn-already-known" || result.RejectReason == "txn-already-in-mempool" {
+			continue
+		}
+		return eth.NewTransactionRejectedError(eth.TransactionRejectedData{
+			TxID:     utils.AddHexPrefix(result.TXID),
+			Reason:   result.RejectReason,
+			Category: rejectionCategory(result.RejectReason),
+		})
+	}
+
+	return nil
+}
+
+func rejectionCategory(reason string) string {
+	reason = strings.ToLower(reason)
+	switch {
+	case strings.Contains(reason, "fee"):
+		return "fee"
+	case strings.Contains(reason, "script"):
+		return "script"
+	case strings.Contains(reason, "size") || strings.Contains(reason, "oversize"):
+		return "size"
+	case strings.Contains(reason, "missing-inputs") || strings.Contains(reason, "missingorspent"):
+		return "inputs"
+	case strings.Contains(reason, "conflict"):
+		return "conflict"
+	default:
+		return "other"
+	}
+}
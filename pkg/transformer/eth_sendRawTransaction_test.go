@@ -0,0 +1,342 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestSendRawTransactionMempoolPrecheckRejected(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x0200000001"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_MEMPOOL_PRECHECK, true)
+
+	testMempoolAcceptResponse := qtum.TestMempoolAcceptResponse{
+		{TXID: "7c40b5e4d3b4f8f4aa4c5e6b0d2a6d14c89cbd70ec5b4b5b1f2c2b1b4f8a2d11", Allowed: false, RejectReason: "min relay fee not met"},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodTestMempoolAccept, testMempoolAcceptResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHSendRawTransaction{qtumClient}
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil {
+		t.Fatal("expected the transaction to be rejected")
+	}
+
+	if jsonErr.Code() != eth.TransactionRejectedErrorCode {
+		t.Errorf("unexpected error code %d", jsonErr.Code())
+	}
+
+	got, err := json.Marshal(jsonErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":-32003,"message":"transaction rejected: min relay fee not met","data":{"txid":"0x7c40b5e4d3b4f8f4aa4c5e6b0d2a6d14c89cbd70ec5b4b5b1f2c2b1b4f8a2d11","reason":"min relay fee not met","category":"fee"}}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSendRawTransactionTracksSender(t *testing.T) {
+	const txid = "7c40b5e4d3b4f8f4aa4c5e6b0d2a6d14c89cbd70ec5b4b5b1f2c2b1b4f8a2d11"
+	requestParams := []json.RawMessage{[]byte(`"0x0200000001"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without -addressindex qtumd doesn't count the transactions of an address in its mempool
+	qtumClient.GetCapabilities().MarkUnavailable(qtum.CapabilityAddressIndex, "-addressindex is off")
+	for method, response := range map[string]interface{}{
+		qtum.MethodSendRawTx:            txid,
+		qtum.MethodDecodeRawTransaction: qtum.DecodedRawTransactionResponse{ID: txid},
+		qtum.MethodGetRawTransaction:    qtum.GetRawTransactionResponse{ID: txid, Vins: []qtum.RawTransactionVin{{Address: "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"}}},
+	} {
+		if err := mockedClientDoer.AddResponse(method, response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proxyEth := ProxyETHSendRawTransaction{qtumClient}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if sent := qtumClient.SentTransactions("0x7926223070547d2d15b2ef5e7383e541c338ffe9", []string{txid}); len(sent) != 1 {
+		t.Errorf("expected the raw transaction to be tracked for its sender, got %v", sent)
+	}
+}
+
+func TestRejectionCategory(t *testing.T) {
+	tests := map[string]string{
+		"min relay fee not met":                         "fee",
+		"mandatory-script-verify-flag-failed":           "script",
+		"tx-size":                                       "size",
+		"bad-txns-inputs-missingorspent":                "inputs",
+		"txn-mempool-conflict":                          "conflict",
+		"bad-txns-vout-negative":                        "other",
+		"non-mandatory-script-verify-flag (No message)": "script",
+	}
+	for reason, want := range tests {
+		if got := rejectionCategory(reason); got != want {
+			t.Errorf("rejectionCategory(%q) = %s, want %s", reason, got, want)
+		}
+	}
+}
+
+// signedEthereumTransaction is a contract call signed for the regtest chain id with nonce, as an
+// eth_sendRawTransaction request
+func signedEthereumTransaction(t *testing.T, nonce uint64) *eth.JSONRPCRequest {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := common.HexToAddress("0x54fefdb5b31164f66ddb68becd7bdd864cacd65b")
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(8889)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(8889),
+		Nonce:     nonce,
+		Gas:       250000,
+		GasFeeCap: big.NewInt(1000000000000),
+		GasTipCap: big.NewInt(100000000000),
+		To:        &contract,
+		Data:      hexutil.MustDecode("0x095ea7b3"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestParams := []json.RawMessage{[]byte(`"` + hexutil.Encode(raw) + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestSendRawTransactionEthereum(t *testing.T) {
+	request := signedEthereumTransaction(t, 1)
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")); err != nil {
+		t.Fatal(err)
+	}
+	// the sender spent from the address once before, its next nonce is 1
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, qtum.GetAddressDeltasResponse{{TXID: "aa", Satoshis: -100000}}); err != nil {
+		t.Fatal(err)
+	}
+	txid := "6da97e6fe1a9d4d0a7f3d2d8e1c4b5a6978877665544332211ffeeddccbbaa99"
+	if err := mockedClientDoer.AddResponse(qtum.MethodSendToContract, qtum.SendToContractResponse{Txid: txid}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHSendRawTransaction{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got != eth.SendRawTransactionResponse("0x"+txid) {
+		t.Errorf("expected the hash of the QTUM transaction, got %v", got)
+	}
+}
+
+func TestSendRawTransactionEthereumReplay(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")); err != nil {
+		t.Fatal(err)
+	}
+	// the sender spent from the address once before, its next nonce is 1
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAddressDeltas, qtum.GetAddressDeltasResponse{{TXID: "aa", Satoshis: -100000}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddError(qtum.MethodSendToContract, eth.NewCallbackError("insufficient funds")); err != nil {
+		t.Fatal(err)
+	}
+	txid := "6da97e6fe1a9d4d0a7f3d2d8e1c4b5a6978877665544332211ffeeddccbbaa99"
+	if err := mockedClientDoer.AddResponse(qtum.MethodSendToContract, qtum.SendToContractResponse{Txid: txid}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetRawMempool, qtum.GetRawMempoolResponse{txid}); err != nil {
+		t.Fatal(err)
+	}
+	proxyEth := ProxyETHSendRawTransaction{qtumClient}
+	send := func(nonce uint64) (interface{}, eth.JSONRPCError) {
+		return proxyEth.Request(context.Background(), signedEthereumTransaction(t, nonce), internal.NewEchoContext())
+	}
+
+	if _, jsonErr := send(1); jsonErr == nil {
+		t.Fatal("expected the failed send to fail")
+	}
+	// a transaction that couldn't be sent can be sent again
+	if _, jsonErr := send(1); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if _, jsonErr := send(1); jsonErr == nil || !strings.Contains(jsonErr.Message(), "already known") {
+		t.Errorf("expected the replayed transaction to be refused, got %v", jsonErr)
+	}
+	if _, jsonErr := send(0); jsonErr == nil || !strings.Contains(jsonErr.Message(), "nonce too low") {
+		t.Errorf("expected a used nonce to be refused, got %v", jsonErr)
+	}
+	if _, jsonErr := send(5); jsonErr == nil || !strings.Contains(jsonErr.Message(), "nonce too high") {
+		t.Errorf("expected a skipped nonce to be refused, got %v", jsonErr)
+	}
+	// the sent transaction is pending, the next nonce is free
+	if _, jsonErr := send(2); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+}
+
+func TestEthereumTransactionRequest(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := hexutil.Encode(btcutil.Hash160(crypto.CompressPubkey(&key.PublicKey)))
+	chainID := big.NewInt(8889)
+	// 40 satoshi
+	minimumGasPrice := big.NewInt(400000000000)
+	to := common.HexToAddress("0x54fefdb5b31164f66ddb68becd7bdd864cacd65b")
+
+	tests := []struct {
+		name     string
+		tx       types.TxData
+		signer   types.Signer
+		gasPrice int64
+		err      string
+	}{
+		{
+			name:     "dynamic fee pays the minimum and the tip",
+			tx:       &types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasFeeCap: big.NewInt(1000000000000), GasTipCap: big.NewInt(100000000000), To: &to, Value: big.NewInt(10000000000)},
+			gasPrice: 500000000000,
+		},
+		{
+			name:     "dynamic fee capped",
+			tx:       &types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasFeeCap: big.NewInt(450000000000), GasTipCap: big.NewInt(100000000000), To: &to, Value: big.NewInt(10000000000)},
+			gasPrice: 450000000000,
+		},
+		{
+			name: "dynamic fee below the minimum",
+			tx:   &types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasFeeCap: big.NewInt(30000000000), GasTipCap: big.NewInt(1000000000), To: &to},
+			err:  "below QTUM's minimum gas price",
+		},
+		{
+			name:     "access list",
+			tx:       &types.AccessListTx{ChainID: chainID, Gas: 21000, GasPrice: big.NewInt(600000000000), To: &to, Value: big.NewInt(10000000000)},
+			gasPrice: 600000000000,
+		},
+		{
+			name:     "legacy",
+			tx:       &types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(400000000000), To: &to, Value: big.NewInt(10000000000)},
+			signer:   types.NewEIP155Signer(chainID),
+			gasPrice: 400000000000,
+		},
+		{
+			name:   "legacy without chain id",
+			tx:     &types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(400000000000), To: &to, Value: big.NewInt(10000000000)},
+			signer: types.HomesteadSigner{},
+			err:    "isn't signed with a chain id",
+		},
+		{
+			name:   "other chain",
+			tx:     &types.DynamicFeeTx{ChainID: big.NewInt(1), Gas: 21000, GasFeeCap: big.NewInt(1000000000000), To: &to},
+			signer: types.LatestSignerForChainID(big.NewInt(1)),
+			err:    "signed for chain id 1",
+		},
+		{
+			name: "fraction of a satoshi",
+			tx:   &types.DynamicFeeTx{ChainID: chainID, Gas: 21000, GasFeeCap: big.NewInt(1000000000000), To: &to, Value: big.NewInt(1)},
+			err:  "fraction of a satoshi",
+		},
+		{
+			name: "value with a contract creation",
+			tx:   &types.DynamicFeeTx{ChainID: chainID, Gas: 100000, GasFeeCap: big.NewInt(1000000000000), Value: big.NewInt(10000000000), Data: []byte{0x60}},
+			err:  "contract creation",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signer := test.signer
+			if signer == nil {
+				signer = types.LatestSignerForChainID(chainID)
+			}
+			tx, err := types.SignNewTx(key, signer, test.tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isEthereumTransaction(raw) {
+				t.Fatal("expected an Ethereum transaction")
+			}
+			var decoded types.Transaction
+			if err := decoded.UnmarshalBinary(raw); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := ethereumTransactionRequest(&decoded, chainID, minimumGasPrice)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.From != from {
+				t.Errorf("expected the QTUM address of the signer %s, got %s", from, req.From)
+			}
+			if req.GasPrice.Int64() != test.gasPrice {
+				t.Errorf("expected gas price %d, got %s", test.gasPrice, req.GasPrice)
+			}
+			if !req.IsSendEther() {
+				t.Errorf("expected a transfer, got %+v", req)
+			}
+		})
+	}
+}
+
+func TestIsEthereumTransaction(t *testing.T) {
+	qtumTx := hexutil.MustDecode("0x020000000159c0514feea50f915854d9ec45bc6458bb14419c78b17e7be3f7fd5f563475b501000000")
+	if isEthereumTransaction(qtumTx) {
+		t.Error("expected a QTUM transaction not to be taken for an Ethereum one")
+	}
+}
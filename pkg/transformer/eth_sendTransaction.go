@@ -1,6 +1,11 @@
 package transformer
 
 import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -19,7 +24,7 @@ func (p *ProxyETHSendTransaction) Method() string {
 	return "eth_sendTransaction"
 }
 
-func (p *ProxyETHSendTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHSendTransaction) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.SendTransactionRequest
 	err := unmarshalRequest(rawreq.Params, &req)
 	if err != nil {
@@ -27,30 +32,114 @@ func (p *ProxyETHSendTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Con
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
+	return p.send(ctx, &req)
+}
+
+// send makes qtumd's wallet send the transaction, eth_sendRawTransaction sends Ethereum transactions with it too.
+// Accounts of key files are signed for here once unlocked, qtumd's wallet doesn't hold their keys
+func (p *ProxyETHSendTransaction) send(ctx context.Context, req *eth.SendTransactionRequest) (*eth.SendTransactionResponse, eth.JSONRPCError) {
+	if from := strings.ToLower(utils.RemoveHexPrefix(req.From)); p.Keystore.IsEncrypted(from) {
+		key, err := p.Keystore.Find(from)
+		if err != nil {
+			return nil, accountError(from, err)
+		}
+		return p.sendWithKey(ctx, req, key)
+	}
+
 	if req.Gas != nil && req.Gas.Int64() < MinimumGasLimit {
 		p.GetLogger().Log("msg", "Gas limit is too low", "gasLimit", req.Gas.String())
 	}
 
-	var result interface{}
+	var result *eth.SendTransactionResponse
 	var jsonErr eth.JSONRPCError
 
 	if req.IsCreateContract() {
-		result, jsonErr = p.requestCreateContract(&req)
+		result, jsonErr = p.requestCreateContract(req)
 	} else if req.IsSendEther() {
-		result, jsonErr = p.requestSendToAddress(&req)
+		result, jsonErr = p.requestSendToAddress(req)
 	} else if req.IsCallContract() {
-		result, jsonErr = p.requestSendToContract(&req)
+		if p.GetFlagBool(qtum.FLAG_SIMULATE_BEFORE_SEND) {
+			if jsonErr := p.simulate(ctx, req); jsonErr != nil {
+				return nil, jsonErr
+			}
+		}
+		result, jsonErr = p.requestSendToContract(req)
 	} else {
 		return nil, eth.NewInvalidParamsError("Unknown operation")
 	}
 
-	if err == nil {
+	if jsonErr == nil {
+		if utils.IsEthHexAddress(req.From) {
+			p.TrackSentTransaction(req.From, string(*result))
+		}
 		p.GenerateIfPossible()
 	}
 
 	return result, jsonErr
 }
 
+// simulate runs the contract call through callcontract so a transaction that is going to revert
+// is rejected with its revert reason instead of being mined and paying for the gas
+func (p *ProxyETHSendTransaction) simulate(ctx context.Context, ethtx *eth.SendTransactionRequest) eth.JSONRPCError {
+	// callcontract can't send value along, a payable call would be simulated without it
+	if ethtx.Value != "" {
+		if value, err := utils.DecodeBig(ethtx.Value); err != nil || value.Sign() != 0 {
+			return nil
+		}
+	}
+
+	callProxy := &ProxyETHCall{p.Qtum}
+	qtumreq, jsonErr := callProxy.ToRequest(&eth.CallRequest{
+		From: ethtx.From,
+		To:   ethtx.To,
+		Data: ethtx.Data,
+		Gas:  ethtx.Gas,
+	})
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	qtumresp, err := p.CallContract(ctx, qtumreq)
+	if err != nil {
+		// the transaction itself will fail with a proper error if the call is malformed
+		p.GetDebugLogger().Log("msg", "Failed to simulate transaction, sending anyway", "error", err)
+		return nil
+	}
+
+	result := qtumresp.ExecutionResult
+	if result.Excepted == "None" {
+		return nil
+	}
+
+	data := utils.AddHexPrefix(result.Output)
+	reason := decodeRevertReason(result.Output)
+	if reason == "" && result.Excepted != "Revert" {
+		reason = result.Excepted
+	}
+	if reason == "" {
+		reason = result.ExceptedMessage
+	}
+
+	p.GetDebugLogger().Log("msg", "Simulated transaction reverted", "excepted", result.Excepted, "reason", reason)
+	return eth.NewExecutionRevertedError(reason, data)
+}
+
+// decodeRevertReason decodes the message of a revert with Error(string) output, empty if there is none
+func decodeRevertReason(output string) string {
+	if output == "" {
+		return ""
+	}
+	data, err := hexutil.Decode(utils.AddHexPrefix(output))
+	if err != nil {
+		return ""
+	}
+	reason, err := abi.UnpackRevert(data)
+	if err != nil {
+		return ""
+	}
+	return reason
+}
+
 func (p *ProxyETHSendTransaction) requestSendToContract(ethtx *eth.SendTransactionRequest) (*eth.SendTransactionResponse, eth.JSONRPCError) {
 	gasLimit, gasPrice, err := EthGasToQtum(ethtx)
 	if err != nil {
@@ -140,6 +229,18 @@ func (p *ProxyETHSendTransaction) requestSendToAddress(req *eth.SendTransactionR
 }
 
 func (p *ProxyETHSendTransaction) requestCreateContract(req *eth.SendTransactionRequest) (*eth.SendTransactionResponse, eth.JSONRPCError) {
+	resp, jsonErr := p.createContract(req)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	ethresp := eth.SendTransactionResponse(utils.AddHexPrefix(string(resp.Txid)))
+
+	return &ethresp, nil
+}
+
+// createContract sends a contract creation, the response includes the address the contract will have
+func (p *ProxyETHSendTransaction) createContract(req *eth.SendTransactionRequest) (*qtum.CreateContractResponse, eth.JSONRPCError) {
 	gasLimit, gasPrice, err := EthGasToQtum(req)
 	if err != nil {
 		return nil, eth.NewInvalidParamsError(err.Error())
@@ -168,7 +269,5 @@ func (p *ProxyETHSendTransaction) requestCreateContract(req *eth.SendTransaction
 		return nil, eth.NewCallbackError(err.Error())
 	}
 
-	ethresp := eth.SendTransactionResponse(utils.AddHexPrefix(string(resp.Txid)))
-
-	return &ethresp, nil
+	return resp, nil
 }
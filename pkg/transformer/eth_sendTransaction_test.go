@@ -0,0 +1,77 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+const notOwnerRevertOutput = "08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000096e6f74206f776e65720000000000000000000000000000000000000000000000"
+
+func TestSendTransactionSimulationReverted(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`{"from":"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","to":"0x2e6f89d7399081b4f8f8aa1ae2805a5efff2f960","data":"0xa9059cbb"}`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_SIMULATE_BEFORE_SEND, true)
+
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var callContractResponse qtum.CallContractResponse
+	err = json.Unmarshal([]byte(`{"address":"2e6f89d7399081b4f8f8aa1ae2805a5efff2f960","executionResult":{"gasUsed":23000,"excepted":"Revert","exceptedMessage":"","output":"`+notOwnerRevertOutput+`"}}`), &callContractResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodCallContract, callContractResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHSendTransaction{qtumClient}
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil {
+		t.Fatal("expected the simulated transaction to be rejected")
+	}
+
+	if jsonErr.Code() != eth.ExecutionRevertedErrorCode {
+		t.Errorf("unexpected error code %d", jsonErr.Code())
+	}
+
+	got, err := json.Marshal(jsonErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":3,"message":"execution reverted: not owner","data":"0x` + notOwnerRevertOutput + `"}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	tests := map[string]string{
+		notOwnerRevertOutput: "not owner",
+		"":                   "",
+		"4e487b710000000000000000000000000000000000000000000000000000000000000001": "",
+		"zz": "",
+	}
+
+	for output, want := range tests {
+		if got := decodeRevertReason(output); got != want {
+			t.Errorf("decodeRevertReason(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
@@ -2,9 +2,9 @@ package transformer
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
-	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -23,7 +23,7 @@ func (p *ProxyETHSign) Method() string {
 	return "eth_sign"
 }
 
-func (p *ProxyETHSign) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHSign) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.SignRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		p.GetDebugLogger().Log("method", p.Method(), "error", err)
@@ -33,10 +33,10 @@ func (p *ProxyETHSign) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (inte
 
 	addr := utils.RemoveHexPrefix(req.Account)
 
-	acc := p.Qtum.Accounts.FindByHexAddress(addr)
-	if acc == nil {
-		p.GetDebugLogger().Log("method", p.Method(), "account", addr, "msg", "Unknown account")
-		return nil, eth.NewInvalidParamsError(fmt.Sprintf("No such account: %s", addr))
+	acc, err := p.Keystore.Find(addr)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "account", addr, "msg", "Unknown or locked account", "error", err)
+		return nil, accountError(addr, err)
 	}
 
 	sig, err := signMessage(acc.PrivKey, req.Message)
@@ -3,7 +3,6 @@ package transformer
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
@@ -21,29 +20,63 @@ func (p *ProxyETHSignTransaction) Method() string {
 	return "eth_signTransaction"
 }
 
-func (p *ProxyETHSignTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHSignTransaction) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.SendTransactionRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	ctx := c.Request().Context()
+	if req.IsCallContract() {
+		fromAddr := utils.RemoveHexPrefix(req.From)
+		if _, err := p.Keystore.Find(fromAddr); err != nil {
+			return nil, accountError(fromAddr, err)
+		}
+	}
+
+	rawTx, jsonErr := p.buildTransaction(ctx, &req, true)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	return p.signRawTransaction(ctx, rawTx)
+}
 
+// buildTransaction creates the raw transaction of req spending the outputs of its sender. With senderOutputs the
+// contract outputs name the sender, which only qtumd's wallet can sign, without it the sender of a contract output is
+// the owner of the first input
+func (p *ProxyETHSignTransaction) buildTransaction(ctx context.Context, req *eth.SendTransactionRequest, senderOutputs bool) (*qtum.CreateRawTransactionRequest, eth.JSONRPCError) {
+	var (
+		rawTx   *qtum.CreateRawTransactionRequest
+		jsonErr eth.JSONRPCError
+	)
 	if req.IsCreateContract() {
 		p.GetDebugLogger().Log("method", p.Method(), "msg", "transaction is a create contract request")
-		return p.requestCreateContract(ctx, &req)
+		rawTx, jsonErr = p.requestCreateContract(ctx, req)
 	} else if req.IsSendEther() {
 		p.GetDebugLogger().Log("method", p.Method(), "msg", "transaction is a send ether request")
-		return p.requestSendToAddress(ctx, &req)
+		rawTx, jsonErr = p.requestSendToAddress(ctx, req)
 	} else if req.IsCallContract() {
 		p.GetDebugLogger().Log("method", p.Method(), "msg", "transaction is a call contract request")
-		return p.requestSendToContract(ctx, &req)
+		rawTx, jsonErr = p.requestSendToContract(ctx, req)
 	} else {
 		p.GetDebugLogger().Log("method", p.Method(), "msg", "transaction is an unknown request")
+		return nil, eth.NewInvalidParamsError("Unknown operation")
+	}
+	if jsonErr != nil {
+		return nil, jsonErr
 	}
 
-	return nil, eth.NewInvalidParamsError("Unknown operation")
+	if !senderOutputs {
+		for _, output := range rawTx.Outputs {
+			if output.Call != nil {
+				output.Call.SenderAddress = ""
+			}
+			if output.Create != nil {
+				output.Create.SenderAddress = ""
+			}
+		}
+	}
+	return rawTx, nil
 }
 
 func (p *ProxyETHSignTransaction) getRequiredUtxos(ctx context.Context, from string, neededAmount decimal.Decimal) ([]qtum.RawTxInputs, decimal.Decimal, error) {
@@ -82,14 +115,18 @@ func calculateChange(balance, neededAmount decimal.Decimal) (decimal.Decimal, er
 	return balance.Sub(neededAmount), nil
 }
 
+// rawTransactionFee is paid on top of the gas of the transactions built here, it covers qtumd's minimum relay fee for
+// transactions spending a few dozen outputs
+var rawTransactionFee = decimal.New(1, -2)
+
 func calculateNeededAmount(value, gasLimit, gasPrice decimal.Decimal) decimal.Decimal {
-	return value.Add(gasLimit.Mul(gasPrice))
+	return value.Add(gasLimit.Mul(gasPrice)).Add(rawTransactionFee)
 }
 
-func (p *ProxyETHSignTransaction) requestSendToContract(ctx context.Context, ethtx *eth.SendTransactionRequest) (string, eth.JSONRPCError) {
+func (p *ProxyETHSignTransaction) requestSendToContract(ctx context.Context, ethtx *eth.SendTransactionRequest) (*qtum.CreateRawTransactionRequest, eth.JSONRPCError) {
 	gasLimit, gasPrice, err := EthGasToQtum(ethtx)
 	if err != nil {
-		return "", eth.NewInvalidParamsError(err.Error())
+		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
 	amount := decimal.NewFromFloat(0.0)
@@ -97,24 +134,24 @@ func (p *ProxyETHSignTransaction) requestSendToContract(ctx context.Context, eth
 		var err error
 		amount, err = EthValueToQtumAmount(ethtx.Value, ZeroSatoshi)
 		if err != nil {
-			return "", eth.NewInvalidParamsError(err.Error())
+			return nil, eth.NewInvalidParamsError(err.Error())
 		}
 	}
 
 	newGasPrice, err := decimal.NewFromString(gasPrice)
 	if err != nil {
-		return "", eth.NewInvalidParamsError(err.Error())
+		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 	neededAmount := calculateNeededAmount(amount, decimal.NewFromBigInt(gasLimit, 0), newGasPrice)
 
 	inputs, balance, err := p.getRequiredUtxos(ctx, ethtx.From, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, qtumCallError(err)
 	}
 
 	change, err := calculateChange(balance, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
 	contractInteractTx := &qtum.SendToContractRawRequest{
@@ -128,35 +165,21 @@ func (p *ProxyETHSignTransaction) requestSendToContract(ctx context.Context, eth
 	if from := ethtx.From; from != "" && utils.IsEthHexAddress(from) {
 		from, err = p.FromHexAddress(from)
 		if err != nil {
-			return "", eth.NewInvalidParamsError(err.Error())
+			return nil, eth.NewInvalidParamsError(err.Error())
 		}
 		contractInteractTx.SenderAddress = from
 	}
 
-	fromAddr := utils.RemoveHexPrefix(ethtx.From)
-
-	acc := p.Qtum.Accounts.FindByHexAddress(strings.ToLower(fromAddr))
-	if acc == nil {
-		return "", eth.NewInvalidParamsError(fmt.Sprintf("No such account: %s", fromAddr))
-	}
-
-	rawtxreq := []interface{}{inputs, []interface{}{map[string]*qtum.SendToContractRawRequest{"contract": contractInteractTx}, map[string]decimal.Decimal{contractInteractTx.SenderAddress: change}}}
-	var rawTx string
-	if err := p.Qtum.Request(qtum.MethodCreateRawTx, rawtxreq, &rawTx); err != nil {
-		return "", eth.NewCallbackError(err.Error())
-	}
-
-	var resp *qtum.SignRawTxResponse
-	if err := p.Qtum.Request(qtum.MethodSignRawTx, []interface{}{rawTx}, &resp); err != nil {
-		return "", eth.NewCallbackError(err.Error())
-	}
-	if !resp.Complete {
-		return "", eth.NewCallbackError("something went wrong with signing the transaction; transaction incomplete")
-	}
-	return utils.AddHexPrefix(resp.Hex), nil
+	return &qtum.CreateRawTransactionRequest{
+		Inputs: inputs,
+		Outputs: []qtum.RawTxOutput{
+			{Call: contractInteractTx},
+			{Address: contractInteractTx.SenderAddress, Amount: change},
+		},
+	}, nil
 }
 
-func (p *ProxyETHSignTransaction) requestSendToAddress(ctx context.Context, req *eth.SendTransactionRequest) (string, eth.JSONRPCError) {
+func (p *ProxyETHSignTransaction) requestSendToAddress(ctx context.Context, req *eth.SendTransactionRequest) (*qtum.CreateRawTransactionRequest, eth.JSONRPCError) {
 	getQtumWalletAddress := func(addr string) (string, error) {
 		if utils.IsEthHexAddress(addr) {
 			return p.FromHexAddress(utils.RemoveHexPrefix(addr))
@@ -166,58 +189,50 @@ func (p *ProxyETHSignTransaction) requestSendToAddress(ctx context.Context, req
 
 	to, err := getQtumWalletAddress(req.To)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
 	from, err := getQtumWalletAddress(req.From)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
 	amount, err := EthValueToQtumAmount(req.Value, ZeroSatoshi)
 	if err != nil {
-		return "", eth.NewInvalidParamsError(err.Error())
+		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	inputs, balance, err := p.getRequiredUtxos(ctx, req.From, amount)
+	neededAmount := amount.Add(rawTransactionFee)
+	inputs, balance, err := p.getRequiredUtxos(ctx, req.From, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, qtumCallError(err)
 	}
 
-	change, err := calculateChange(balance, amount)
+	change, err := calculateChange(balance, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
-	var addressValMap = map[string]decimal.Decimal{to: amount, from: change}
-	rawtxreq := []interface{}{inputs, addressValMap}
-	var rawTx string
-	if err := p.Qtum.Request(qtum.MethodCreateRawTx, rawtxreq, &rawTx); err != nil {
-		return "", eth.NewCallbackError(err.Error())
-	}
-
-	var resp *qtum.SignRawTxResponse
-	signrawtxreq := []interface{}{rawTx}
-	if err := p.Qtum.Request(qtum.MethodSignRawTx, signrawtxreq, &resp); err != nil {
-		return "", eth.NewCallbackError(err.Error())
-	}
-	if !resp.Complete {
-		return "", eth.NewCallbackError("something went wrong with signing the transaction; transaction incomplete")
-	}
-	return utils.AddHexPrefix(resp.Hex), nil
+	return &qtum.CreateRawTransactionRequest{
+		Inputs: inputs,
+		Outputs: []qtum.RawTxOutput{
+			{Address: to, Amount: amount},
+			{Address: from, Amount: change},
+		},
+	}, nil
 }
 
-func (p *ProxyETHSignTransaction) requestCreateContract(ctx context.Context, req *eth.SendTransactionRequest) (string, eth.JSONRPCError) {
+func (p *ProxyETHSignTransaction) requestCreateContract(ctx context.Context, req *eth.SendTransactionRequest) (*qtum.CreateRawTransactionRequest, eth.JSONRPCError) {
 	gasLimit, gasPrice, err := EthGasToQtum(req)
 	if err != nil {
-		return "", eth.NewInvalidParamsError(err.Error())
+		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
 	from := req.From
 	if utils.IsEthHexAddress(from) {
 		from, err = p.FromHexAddress(from)
 		if err != nil {
-			return "", eth.NewInvalidParamsError(err.Error())
+			return nil, eth.NewInvalidParamsError(err.Error())
 		}
 	}
 
@@ -230,33 +245,42 @@ func (p *ProxyETHSignTransaction) requestCreateContract(ctx context.Context, req
 
 	newGasPrice, err := decimal.NewFromString(gasPrice)
 	if err != nil {
-		return "", eth.NewInvalidParamsError(err.Error())
+		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 	neededAmount := calculateNeededAmount(decimal.NewFromFloat(0.0), decimal.NewFromBigInt(gasLimit, 0), newGasPrice)
 
 	inputs, balance, err := p.getRequiredUtxos(ctx, req.From, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, qtumCallError(err)
 	}
 
 	change, err := calculateChange(balance, neededAmount)
 	if err != nil {
-		return "", eth.NewCallbackError(err.Error())
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
-	rawtxreq := []interface{}{inputs, []interface{}{map[string]*qtum.CreateContractRawRequest{"contract": contractDeploymentTx}, map[string]decimal.Decimal{from: change}}}
-	var rawTx string
-	if err := p.Qtum.Request(qtum.MethodCreateRawTx, rawtxreq, &rawTx); err != nil {
+	return &qtum.CreateRawTransactionRequest{
+		Inputs: inputs,
+		Outputs: []qtum.RawTxOutput{
+			{Create: contractDeploymentTx},
+			{Address: from, Amount: change},
+		},
+	}, nil
+}
+
+// signRawTransaction creates the raw transaction and has the wallet sign it
+func (p *ProxyETHSignTransaction) signRawTransaction(ctx context.Context, req *qtum.CreateRawTransactionRequest) (string, eth.JSONRPCError) {
+	rawTx, err := p.CreateRawTransaction(ctx, req)
+	if err != nil {
 		return "", eth.NewCallbackError(err.Error())
 	}
 
-	var resp *qtum.SignRawTxResponse
-	signrawtxreq := []interface{}{rawTx}
-	if err := p.Qtum.Request(qtum.MethodSignRawTx, signrawtxreq, &resp); err != nil {
+	resp, err := p.SignRawTransactionWithWallet(ctx, rawTx)
+	if err != nil {
 		return "", eth.NewCallbackError(err.Error())
 	}
-	if !resp.Complete {
-		return "", eth.NewCallbackError("something went wrong with signing the transaction; transaction incomplete")
+	if err := resp.Err(); err != nil {
+		return "", eth.NewCallbackError("something went wrong with signing the transaction; " + err.Error())
 	}
 	return utils.AddHexPrefix(resp.Hex), nil
 }
@@ -0,0 +1,97 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyETHSignTypedDataV4 implements ETHProxy
+type ProxyETHSignTypedDataV4 struct {
+	*qtum.Qtum
+}
+
+func (p *ProxyETHSignTypedDataV4) Method() string {
+	return "eth_signTypedData_v4"
+}
+
+func (p *ProxyETHSignTypedDataV4) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.SignTypedDataRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	// a signature for another chain could be replayed there
+	if chainId := req.TypedData.Domain.ChainId; chainId != nil && (*big.Int)(chainId).Cmp(big.NewInt(int64(p.ChainId()))) != 0 {
+		return nil, eth.NewInvalidParamsError(fmt.Sprintf("chainId %s of the domain doesn't match the chain id %d", (*big.Int)(chainId), p.ChainId()))
+	}
+	hash, err := typedDataHash(&req.TypedData)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	addr := utils.RemoveHexPrefix(req.Account)
+	acc, err := p.Keystore.Find(addr)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "account", addr, "msg", "Unknown or locked account", "error", err)
+		return nil, accountError(addr, err)
+	}
+
+	sig, err := signHash(acc.PrivKey, hash)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to sign typed data", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return eth.SignResponse(hexutil.Encode(sig)), nil
+}
+
+// typedDataHash is the EIP-712 hash of typedData, keccak256 of 0x1901, the hash of the domain and the hash of the
+// message. The EIP712Domain type is made of the fields the domain sets when clients leave it out of the types
+func typedDataHash(typedData *apitypes.TypedData) ([]byte, error) {
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		types := make(apitypes.Types, len(typedData.Types)+1)
+		for name, fields := range typedData.Types {
+			types[name] = fields
+		}
+		types["EIP712Domain"] = domainType(typedData.Domain)
+		typedData.Types = types
+	}
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("domain: %v", err)
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), domainSeparator, messageHash), nil
+}
+
+// domainType lists the fields domain sets in the order EIP-712 defines
+func domainType(domain apitypes.TypedDataDomain) []apitypes.Type {
+	var fields []apitypes.Type
+	if domain.Name != "" {
+		fields = append(fields, apitypes.Type{Name: "name", Type: "string"})
+	}
+	if domain.Version != "" {
+		fields = append(fields, apitypes.Type{Name: "version", Type: "string"})
+	}
+	if domain.ChainId != nil {
+		fields = append(fields, apitypes.Type{Name: "chainId", Type: "uint256"})
+	}
+	if domain.VerifyingContract != "" {
+		fields = append(fields, apitypes.Type{Name: "verifyingContract", Type: "address"})
+	}
+	if domain.Salt != "" {
+		fields = append(fields, apitypes.Type{Name: "salt", Type: "bytes32"})
+	}
+	return fields
+}
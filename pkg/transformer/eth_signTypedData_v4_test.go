@@ -0,0 +1,140 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+)
+
+// the example of EIP-712
+const exampleTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": %s,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	want := "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+	withDomainType := fmt.Sprintf(exampleTypedData, "1")
+	withoutDomainType := strings.Replace(withDomainType, `"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],`, "", 1)
+
+	for _, data := range []string{withDomainType, withoutDomainType} {
+		var typedData apitypes.TypedData
+		if err := json.Unmarshal([]byte(strings.Replace(data, `"chainId": 1`, `"chainId": "1"`, 1)), &typedData); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := typedDataHash(&typedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hexutil.Encode(hash); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestSignTypedDataV4Request(t *testing.T) {
+	qtumClient := newKeystoreClient(t, internal.NewDoerMappedMock())
+	key := "5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec"
+	wif, err := btcutil.DecodeWIF(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.Keystore.Add(wif)
+
+	sign := func(account string, typedData string) (interface{}, eth.JSONRPCError) {
+		request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+			[]byte(`"` + account + `"`),
+			[]byte(typedData),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (&ProxyETHSignTypedDataV4{qtumClient}).Request(context.Background(), request, nil)
+	}
+
+	typedData := fmt.Sprintf(exampleTypedData, fmt.Sprint(qtumClient.ChainId()))
+	// MetaMask sends the typed data as a JSON string
+	encoded, err := json.Marshal(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range []string{typedData, string(encoded)} {
+		result, jsonErr := sign("0x7e22630f90e6db16283af2c6b04f688117a55db4", param)
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		sig, err := hexutil.Decode(string(result.(eth.SignResponse)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+			t.Fatalf("expected a 65 byte signature with a v of 27 or 28, got %x", sig)
+		}
+
+		var decoded apitypes.TypedData
+		if err := json.Unmarshal([]byte(strings.Replace(typedData, fmt.Sprintf(`"chainId": %d`, qtumClient.ChainId()), fmt.Sprintf(`"chainId": "%d"`, qtumClient.ChainId()), 1)), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := typedDataHash(&decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig[64] -= 27
+		pubKey, err := crypto.Ecrecover(hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pubKey, wif.PrivKey.PubKey().SerializeUncompressed()) {
+			t.Error("expected the signature to recover the account's public key")
+		}
+	}
+
+	if _, jsonErr := sign("0x7e22630f90e6db16283af2c6b04f688117a55db4", fmt.Sprintf(exampleTypedData, "1")); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected the typed data of another chain to be refused, got %v", jsonErr)
+	}
+	if _, jsonErr := sign("0x6d358cf96533189dd5a602d0937fddf0888ad3ae", typedData); jsonErr == nil || jsonErr.Code() != eth.UnauthorizedErrorCode {
+		t.Errorf("expected a locked account to need authentication, got %v", jsonErr)
+	}
+}
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/notifier"
@@ -17,7 +18,7 @@ func (p *ETHSubscribe) Method() string {
 	return "eth_subscribe"
 }
 
-func (p *ETHSubscribe) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHSubscribe) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	notifier := getNotifier(c)
 	if notifier == nil {
 		p.GetLogger().Log("msg", "eth_subscribe only supported over websocket")
@@ -41,6 +42,16 @@ func (p *ETHSubscribe) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (inte
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
+	if req.Params != nil {
+		// the subscription only reports errors to the log once it's running
+		if _, err := eth.TranslateTopics(req.Params.Topics); err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+		if _, err := eth.ParseFilterAddresses(req.Params.Address); err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+	}
+
 	return p.request(&req, notifier)
 }
 
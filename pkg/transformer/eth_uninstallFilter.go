@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
@@ -17,24 +18,26 @@ func (p *ProxyETHUninstallFilter) Method() string {
 	return "eth_uninstallFilter"
 }
 
-func (p *ProxyETHUninstallFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHUninstallFilter) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var req eth.UninstallFilterRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	return p.request(&req)
+	return p.request(ctx, &req)
 }
 
-func (p *ProxyETHUninstallFilter) request(ethreq *eth.UninstallFilterRequest) (eth.UninstallFilterResponse, eth.JSONRPCError) {
+func (p *ProxyETHUninstallFilter) request(ctx context.Context, ethreq *eth.UninstallFilterRequest) (eth.UninstallFilterResponse, eth.JSONRPCError) {
 	id, err := hexutil.DecodeUint64(string(*ethreq))
 	if err != nil {
 		return false, eth.NewInvalidParamsError(err.Error())
 	}
 
 	// uninstall
-	p.filter.Uninstall(id)
+	if err := p.filter.Uninstall(ctx, id); err != nil {
+		return false, eth.NewCallbackError(err.Error())
+	}
 
 	return true, nil
 }
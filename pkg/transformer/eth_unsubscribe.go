@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/notifier"
@@ -17,7 +18,7 @@ func (p *ETHUnsubscribe) Method() string {
 	return "eth_unsubscribe"
 }
 
-func (p *ETHUnsubscribe) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ETHUnsubscribe) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	notifier := getNotifier(c)
 	if notifier == nil {
 		p.GetLogger().Log("msg", "eth_unsubscribe only supported over websocket")
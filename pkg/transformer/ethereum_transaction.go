@@ -0,0 +1,201 @@
+package transformer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/replay"
+)
+
+// Wallets like Metamask sign Ethereum transactions, which qtumd can't take. eth_sendRawTransaction has qtumd's wallet
+// send the same transfer, call or contract creation instead, from the QTUM address of the key that signed it
+
+// weiPerSatoshi is the smallest amount of wei QTUM can send
+var weiPerSatoshi = big.NewInt(10000000000)
+
+// isEthereumTransaction tells an Ethereum transaction from a QTUM one. A legacy Ethereum transaction is an RLP list,
+// whose first byte is at least 0xc0, and a typed one is a type byte followed by an RLP list. A QTUM transaction starts
+// with its little endian version, 1 or 2, followed by zeros
+func isEthereumTransaction(raw []byte) bool {
+	if len(raw) < 2 {
+		return false
+	}
+	if raw[0] >= 0xc0 {
+		return true
+	}
+	return raw[0] <= 0x7f && raw[1] >= 0xc0
+}
+
+// ethereumTransactionHex decodes the hex of a raw transaction if it is an Ethereum one
+func ethereumTransactionHex(hexedRawTx string) ([]byte, bool) {
+	raw, err := hex.DecodeString(hexedRawTx)
+	if err != nil || !isEthereumTransaction(raw) {
+		return nil, false
+	}
+	return raw, true
+}
+
+// sendEthereumTransaction sends a transaction signed by an Ethereum wallet with eth_sendTransaction, the response is
+// the hash of the QTUM transaction
+func (p *ProxyETHSendRawTransaction) sendEthereumTransaction(ctx context.Context, raw []byte) (eth.SendRawTransactionResponse, eth.JSONRPCError) {
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return eth.SendRawTransactionResponse(""), eth.NewInvalidParamsError("couldn't decode Ethereum transaction: " + err.Error())
+	}
+
+	chainID, jsonErr := getChainId(p.Qtum)
+	if jsonErr != nil {
+		return eth.SendRawTransactionResponse(""), jsonErr
+	}
+	minimumGasPrice, err := p.GetGasPrice(ctx)
+	if err != nil {
+		return eth.SendRawTransactionResponse(""), eth.NewCallbackError(err.Error())
+	}
+
+	req, err := ethereumTransactionRequest(&tx, chainID, satoshisToWei(minimumGasPrice))
+	if err != nil {
+		return eth.SendRawTransactionResponse(""), eth.NewInvalidParamsError(err.Error())
+	}
+	if jsonErr := p.checkNonce(ctx, &tx, req.From); jsonErr != nil {
+		return eth.SendRawTransactionResponse(""), jsonErr
+	}
+
+	// the signature doesn't cover the QTUM transaction, the hash of the signed transaction is what keeps it from being
+	// sent twice
+	hash := tx.Hash().Hex()
+	sent := p.GetEthereumTransactions()
+	if err := sent.Claim(ctx, hash); err != nil {
+		if err == replay.ErrSent {
+			return eth.SendRawTransactionResponse(""), eth.NewInvalidParamsError("already known: Ethereum transaction " + hash + " was sent before")
+		}
+		return eth.SendRawTransactionResponse(""), eth.NewCallbackError(err.Error())
+	}
+	p.GetDebugLogger().Log("msg", "Sending Ethereum transaction with qtumd's wallet", "hash", hash, "type", tx.Type(), "from", req.From, "gasPrice", req.GasPrice.String())
+
+	sendProxy := &ProxyETHSendTransaction{p.Qtum}
+	resp, jsonErr := sendProxy.send(ctx, req)
+	if jsonErr != nil {
+		if err := sent.Release(ctx, hash); err != nil {
+			p.GetErrorLogger().Log("msg", "couldn't forget unsent Ethereum transaction", "hash", hash, "error", err)
+		}
+		return eth.SendRawTransactionResponse(""), jsonErr
+	}
+	return eth.SendRawTransactionResponse(*resp), nil
+}
+
+// checkNonce refuses a transaction whose nonce was used by a transaction of its sender already, or that skips nonces.
+// QTUM has no nonces, so the count eth_getTransactionCount reports is what wallets sign with
+func (p *ProxyETHSendRawTransaction) checkNonce(ctx context.Context, tx *types.Transaction, from string) eth.JSONRPCError {
+	countProxy := &ProxyETHTxCount{p.Qtum}
+	mined, err := countProxy.transactionCount(ctx, from, false)
+	if err != nil {
+		return eth.NewCallbackError(err.Error())
+	}
+	if tx.Nonce() < mined.Uint64() {
+		return eth.NewInvalidParamsError(fmt.Sprintf("nonce too low: next nonce %s, tx nonce %d", mined, tx.Nonce()))
+	}
+	pending, err := countProxy.transactionCount(ctx, from, true)
+	if err != nil {
+		return eth.NewCallbackError(err.Error())
+	}
+	if tx.Nonce() > pending.Uint64() {
+		return eth.NewInvalidParamsError(fmt.Sprintf("nonce too high: next nonce %s, tx nonce %d", pending, tx.Nonce()))
+	}
+	return nil
+}
+
+// ethereumTransactionRequest is the eth_sendTransaction request sending what tx does, from the QTUM address of its
+// signer and at a QTUM gas price the signer agreed to pay. It fails when QTUM can't send tx as it was signed
+func ethereumTransactionRequest(tx *types.Transaction, chainID *big.Int, minimumGasPrice *big.Int) (*eth.SendTransactionRequest, error) {
+	// without a chain id the signature is valid on every chain, anyone could send the transaction again elsewhere
+	if !tx.Protected() {
+		return nil, errors.Errorf("transaction isn't signed with a chain id, sign it for chain id %s", chainID)
+	}
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return nil, errors.Errorf("transaction is signed for chain id %s instead of %s", tx.ChainId(), chainID)
+	}
+	publicKey, err := signerPublicKey(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, err := ethereumGasPrice(tx, minimumGasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	value := tx.Value()
+	if new(big.Int).Mod(value, weiPerSatoshi).Sign() != 0 {
+		return nil, errors.Errorf("value of %s wei has a fraction of a satoshi, QTUM amounts are whole multiples of %s wei", value, weiPerSatoshi)
+	}
+	if tx.To() == nil && value.Sign() != 0 {
+		return nil, errors.New("QTUM doesn't send value along with a contract creation, the coins would be lost")
+	}
+
+	req := &eth.SendTransactionRequest{
+		From:     hexutil.Encode(btcutil.Hash160(crypto.CompressPubkey(publicKey))),
+		Gas:      &eth.ETHInt{Int: new(big.Int).SetUint64(tx.Gas())},
+		GasPrice: &eth.ETHInt{Int: gasPrice},
+		Value:    hexutil.EncodeBig(value),
+	}
+	if tx.To() != nil {
+		req.To = strings.ToLower(tx.To().Hex())
+	}
+	if len(tx.Data()) > 0 {
+		req.Data = hexutil.Encode(tx.Data())
+	}
+	return req, nil
+}
+
+// ethereumGasPrice maps the fees of tx onto QTUM's single gas price, in wei. QTUM has no base fee, the minimum gas
+// price takes its place like in eth_feeHistory, so a dynamic fee transaction pays the minimum and its tip up to its fee
+// cap. A legacy transaction pays its gas price
+func ethereumGasPrice(tx *types.Transaction, minimumGasPrice *big.Int) (*big.Int, error) {
+	feeCap := tx.GasFeeCap()
+	if feeCap.Cmp(minimumGasPrice) < 0 {
+		return nil, errors.Errorf("gas price of at most %s wei is below QTUM's minimum gas price of %s wei", feeCap, minimumGasPrice)
+	}
+	gasPrice := new(big.Int).Add(minimumGasPrice, tx.GasTipCap())
+	if gasPrice.Cmp(feeCap) > 0 {
+		gasPrice.Set(feeCap)
+	}
+	return gasPrice, nil
+}
+
+// signerPublicKey recovers the public key that signed tx, QTUM addresses are the hash160 of the compressed key
+// rather than the end of its keccak hash
+func signerPublicKey(signer types.Signer, tx *types.Transaction) (*ecdsa.PublicKey, error) {
+	v, r, s := tx.RawSignatureValues()
+	recoveryID := new(big.Int).Set(v)
+	switch {
+	case tx.Type() != types.LegacyTxType:
+		// typed transactions sign with the recovery id itself
+	case tx.Protected():
+		recoveryID.Sub(v, new(big.Int).Add(new(big.Int).Mul(tx.ChainId(), big.NewInt(2)), big.NewInt(35)))
+	default:
+		recoveryID.Sub(v, big.NewInt(27))
+	}
+	if recoveryID.Sign() < 0 || recoveryID.Cmp(big.NewInt(1)) > 0 || !crypto.ValidateSignatureValues(byte(recoveryID.Uint64()), r, s, true) {
+		return nil, errors.New("invalid transaction signature")
+	}
+
+	signature := make([]byte, crypto.SignatureLength)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:64])
+	signature[64] = byte(recoveryID.Uint64())
+	publicKey, err := crypto.SigToPub(signer.Hash(tx).Bytes(), signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't recover the signer of the transaction")
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,38 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// JanusComputeContractAddress implements ETHProxy
+// Qtum derives contract addresses from the outpoint of the OP_CREATE output instead of the sender and nonce like Ethereum's CREATE
+type JanusComputeContractAddress struct{}
+
+var _ ETHProxy = (*JanusComputeContractAddress)(nil)
+
+func (p *JanusComputeContractAddress) Method() string {
+	return "janus_computeContractAddress"
+}
+
+func (p *JanusComputeContractAddress) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.ComputeContractAddressRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	if utils.IsEthHexAddress(req.TxID) && len(utils.RemoveHexPrefix(req.TxID)) == 40 {
+		return nil, eth.NewInvalidParamsError("expected a txid, Qtum contract addresses depend on the txid and output index of the creating transaction, not on the sender and nonce")
+	}
+
+	address, err := utils.ComputeContractAddress(req.TxID, req.Vout)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	resp := eth.ComputeContractAddressResponse(utils.AddHexPrefix(address))
+	return &resp, nil
+}
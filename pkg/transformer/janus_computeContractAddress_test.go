@@ -0,0 +1,58 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+)
+
+func TestComputeContractAddressRequest(t *testing.T) {
+	tests := []struct {
+		params []json.RawMessage
+		want   eth.ComputeContractAddressResponse
+	}{
+		{
+			[]json.RawMessage{[]byte(`"0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f"`)},
+			"0xc89a5d225f578d84a94741490c1b40889b4f7a00",
+		},
+		{
+			[]json.RawMessage{[]byte(`"0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f"`), []byte(`1`)},
+			"0xdb62733c2b77ea9440db15f901032b4c233d5abf",
+		},
+		{
+			[]json.RawMessage{[]byte(`"0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f"`), []byte(`"0x1"`)},
+			"0xdb62733c2b77ea9440db15f901032b4c233d5abf",
+		},
+	}
+
+	proxyEth := JanusComputeContractAddress{}
+	for _, tt := range tests {
+		request, err := internal.PrepareEthRPCRequest(1, tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+
+		internal.CheckTestResultEthRequestRPC(*request, &tt.want, got, t, false)
+	}
+}
+
+func TestComputeContractAddressRejectsSender(t *testing.T) {
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`5`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := JanusComputeContractAddress{}
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Fatalf("expected an invalid params error, got %v", jsonErr)
+	}
+}
@@ -0,0 +1,112 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// intrinsic gas of a contract creation
+var deployIntrinsicGas = int64(53000)
+
+// gas set aside for running the constructor
+var deployConstructorGas = int64(200000)
+
+var deployMaximumGas = int64(40000000)
+
+// ProxyJanusDeployContract implements ETHProxy
+// encodes the constructor arguments and sends a contract creation through eth_sendTransaction
+type ProxyJanusDeployContract struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusDeployContract)(nil)
+
+func (p *ProxyJanusDeployContract) Method() string {
+	return "janus_deployContract"
+}
+
+func (p *ProxyJanusDeployContract) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.DeployContractRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, &req)
+}
+
+func (p *ProxyJanusDeployContract) request(ctx context.Context, req *eth.DeployContractRequest) (*eth.DeployContractResponse, eth.JSONRPCError) {
+	code, err := hexutil.Decode(utils.AddHexPrefix(req.Bytecode))
+	if err != nil {
+		return nil, eth.NewInvalidParamsError("invalid bytecode: " + err.Error())
+	}
+
+	if len(req.ABI) != 0 && string(req.ABI) != "null" {
+		contractABI, err := parseABI(req.ABI)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid abi: " + err.Error())
+		}
+		arguments, err := packArguments(contractABI.Constructor.Inputs, req.Args)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("couldn't encode constructor arguments: " + err.Error())
+		}
+		code = append(code, arguments...)
+	} else if len(req.Args) != 0 {
+		return nil, eth.NewInvalidParamsError("constructor arguments need an abi")
+	}
+
+	gas := req.Options.Gas
+	if gas == nil {
+		gas = &eth.ETHInt{Int: estimateDeployGas(code)}
+	}
+	gasPrice := req.Options.GasPrice
+	if gasPrice == nil {
+		gasPrice = &eth.ETHInt{Int: eth.DefaultGasPriceInWei}
+	}
+
+	sendProxy := &ProxyETHSendTransaction{p.Qtum}
+	resp, jsonErr := sendProxy.createContract(&eth.SendTransactionRequest{
+		From:     req.Options.From,
+		Data:     hexutil.Encode(code),
+		Gas:      gas,
+		GasPrice: gasPrice,
+	})
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	p.GenerateIfPossible()
+
+	return &eth.DeployContractResponse{
+		TransactionHash: utils.AddHexPrefix(resp.Txid),
+		ContractAddress: utils.AddHexPrefix(resp.Address),
+		Gas:             hexutil.EncodeBig(gas.Int),
+	}, nil
+}
+
+// estimateDeployGas guesses the gas of creating a contract from code, callcontract can't run a contract creation.
+// It covers the calldata, storing code as long as all of the creation code and deployConstructorGas for the constructor,
+// plus GAS_BUFFER and up to deployMaximumGas. A constructor that needs more runs out of gas, unused gas is refunded
+func estimateDeployGas(code []byte) *big.Int {
+	gas := deployIntrinsicGas + deployConstructorGas
+	for _, b := range code {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	// code deposit, constructors usually return part of the creation code
+	gas += 200 * int64(len(code))
+
+	gas = int64(float64(gas) * GAS_BUFFER)
+	if gas > deployMaximumGas {
+		gas = deployMaximumGas
+	}
+	return big.NewInt(gas)
+}
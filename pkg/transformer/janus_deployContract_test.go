@@ -0,0 +1,87 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestDeployContractRequest(t *testing.T) {
+	requestParams := []json.RawMessage{
+		[]byte(`"0x6080604052"`),
+		[]byte(testConstructorABI),
+		[]byte(`["1000", "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", "Token", 18, "0x0000000000000000000000000000000000000000000000000000000000000001", [], false]`),
+		[]byte(`{"from":"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960","gas":"0x30d40"}`),
+	}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qTKrsHUrzutdCVu3qi3iV1upzB2QpuRsRb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createContractResponse := qtum.CreateContractResponse{
+		Txid:    "d0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f",
+		Sender:  "qTKrsHUrzutdCVu3qi3iV1upzB2QpuRsRb",
+		Hash160: "6b22910b1e302cf74803ffd1691c2ecb858d3712",
+		Address: "c89a5d225f578d84a94741490c1b40889b4f7a00",
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodCreateContract, createContractResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusDeployContract{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := eth.DeployContractResponse{
+		TransactionHash: "0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f",
+		ContractAddress: "0xc89a5d225f578d84a94741490c1b40889b4f7a00",
+		Gas:             "0x30d40",
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
+
+func TestDeployContractRequiresABIForArguments(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x6080604052"`), []byte(`null`), []byte(`[1]`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusDeployContract{qtumClient}
+	_, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Fatalf("expected an invalid params error, got %v", jsonErr)
+	}
+}
+
+func TestEstimateDeployGas(t *testing.T) {
+	// 53000 intrinsic + 200000 constructor + 3 * 16 + 1 * 4 calldata + 4 * 200 deposit, plus the buffer
+	got := estimateDeployGas([]byte{0x60, 0x80, 0x60, 0x00})
+	if want := int64(float64(253852) * GAS_BUFFER); got.Int64() != want {
+		t.Errorf("got %d, want %d", got.Int64(), want)
+	}
+}
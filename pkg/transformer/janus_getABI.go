@@ -0,0 +1,85 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/abiregistry"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/qtumproject/janus/pkg/verification"
+)
+
+// ProxyJanusGetABI implements ETHProxy
+// returns the ABI registered for a contract, or the ABI it was verified with
+type ProxyJanusGetABI struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetABI)(nil)
+
+func (p *ProxyJanusGetABI) Method() string {
+	return "janus_getABI"
+}
+
+func (p *ProxyJanusGetABI) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetABIRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, string(req))
+}
+
+func (p *ProxyJanusGetABI) request(ctx context.Context, address string) (eth.GetABIResponse, eth.JSONRPCError) {
+	if p.GetABIRegistry() == nil {
+		return nil, eth.NewMethodNotFoundError(p.Method())
+	}
+	if !common.IsHexAddress(address) {
+		return nil, eth.NewInvalidParamsError("invalid contract address " + address)
+	}
+	return resolveABI(ctx, p.Qtum, strings.ToLower(utils.RemoveHexPrefix(address)))
+}
+
+// resolveABI looks up the ABI of a contract, the registry wins over contract verification so operators can correct
+// ABIs. nil when neither knows the contract
+func resolveABI(ctx context.Context, q *qtum.Qtum, address string) (*eth.ContractABI, eth.JSONRPCError) {
+	if registry := q.GetABIRegistry(); registry != nil {
+		if jsonErr := requireCapability(q, qtum.CapabilityABIRegistry); jsonErr != nil {
+			return nil, jsonErr
+		}
+		entry, err := registry.Get(ctx, address)
+		if err == nil {
+			q.GetCapabilities().MarkAvailable(qtum.CapabilityABIRegistry)
+			return toContractABI(entry), nil
+		}
+		if err != abiregistry.ErrNotFound {
+			return nil, abiRegistryError(q, err)
+		}
+	}
+
+	if verifier := q.GetVerifier(); verifier != nil {
+		if jsonErr := requireCapability(q, qtum.CapabilityContractVerification); jsonErr != nil {
+			return nil, jsonErr
+		}
+		contract, err := verifier.Store.Get(ctx, address)
+		if err == nil {
+			q.GetCapabilities().MarkAvailable(qtum.CapabilityContractVerification)
+			return &eth.ContractABI{
+				Address:      utils.AddHexPrefix(contract.Address),
+				Name:         contract.ContractName,
+				ABI:          contract.ABI,
+				Source:       "verification",
+				RegisteredAt: contract.VerifiedAt.Format(time.RFC3339),
+			}, nil
+		}
+		if err != verification.ErrNotFound {
+			return nil, verifiedContractsError(q, err)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusGetBalanceDetail implements ETHProxy
+// splits the balance at the chain tip into spendable, immature and unconfirmed amounts
+type ProxyJanusGetBalanceDetail struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetBalanceDetail)(nil)
+
+func (p *ProxyJanusGetBalanceDetail) Method() string {
+	return "janus_getBalanceDetail"
+}
+
+func (p *ProxyJanusGetBalanceDetail) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetBalanceDetailRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		// TODO: Correct error code?
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	addr := utils.RemoveHexPrefix(req.Address)
+	{
+		// contracts don't stake and have no mempool index, their balance is in satoshis like eth_getBalance returns it
+		qtumreq := qtum.GetAccountInfoRequest(addr)
+		qtumresp, err := p.GetAccountInfo(ctx, &qtumreq)
+		if err == nil {
+			balance := hexutil.EncodeUint64(uint64(qtumresp.Balance))
+			return &eth.GetBalanceDetailResponse{
+				Total:               balance,
+				Spendable:           balance,
+				Immature:            "0x0",
+				UnconfirmedIncoming: "0x0",
+				UnconfirmedOutgoing: "0x0",
+			}, nil
+		}
+	}
+
+	base58Addr, err := p.FromHexAddress(addr)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "address", req.Address, "msg", "error parsing address", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return p.request(ctx, base58Addr)
+}
+
+func (p *ProxyJanusGetBalanceDetail) request(ctx context.Context, base58Addr string) (*eth.GetBalanceDetailResponse, eth.JSONRPCError) {
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityAddressIndex); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	balance, err := p.GetAddressBalance(ctx, &qtum.GetAddressBalanceRequest{Address: base58Addr})
+	if err != nil {
+		if err == qtum.ErrInvalidAddress {
+			return &eth.GetBalanceDetailResponse{Total: "0x0", Spendable: "0x0", Immature: "0x0", UnconfirmedIncoming: "0x0", UnconfirmedOutgoing: "0x0"}, nil
+		}
+		p.GetDebugLogger().Log("method", p.Method(), "address", base58Addr, "msg", "error getting address balance", "error", err)
+		return nil, qtumCallError(err)
+	}
+
+	mempool, err := p.GetAddressMempool(ctx, &qtum.GetAddressMempoolRequest{Addresses: []string{base58Addr}})
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "address", base58Addr, "msg", "error getting address mempool", "error", err)
+		return nil, qtumCallError(err)
+	}
+
+	// the amounts are kept apart so neither is negative
+	incoming, outgoing := big.NewInt(0), big.NewInt(0)
+	for _, delta := range mempool {
+		if delta.Satoshis < 0 {
+			outgoing.Sub(outgoing, big.NewInt(delta.Satoshis))
+		} else {
+			incoming.Add(incoming, big.NewInt(delta.Satoshis))
+		}
+	}
+
+	total := new(big.Int).SetUint64(balance.Balance)
+
+	return &eth.GetBalanceDetailResponse{
+		Total:               hexutil.EncodeBig(satoshisToWei(total)),
+		Spendable:           hexutil.EncodeBig(satoshisToWei(spendableBalance(total, balance.Immature))),
+		Immature:            hexutil.EncodeBig(satoshisToWei(big.NewInt(balance.Immature))),
+		UnconfirmedIncoming: hexutil.EncodeBig(satoshisToWei(incoming)),
+		UnconfirmedOutgoing: hexutil.EncodeBig(satoshisToWei(outgoing)),
+	}, nil
+}
@@ -0,0 +1,139 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestGetBalanceDetailRequest(t *testing.T) {
+	//prepare request
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//prepare client
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//prepare responses
+	fromHexAddressResponse := qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, fromHexAddressResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getAddressBalanceResponse := qtum.GetAddressBalanceResponse{Balance: uint64(300000000), Received: uint64(300000000), Immature: int64(200000000)}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressBalance, getAddressBalanceResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getAddressMempoolResponse := qtum.GetAddressMempoolResponse{
+		{Satoshis: -100000000},
+		{Satoshis: 50000000},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressMempool, getAddressMempoolResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//preparing proxy & executing request
+	proxyEth := ProxyJanusGetBalanceDetail{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := &eth.GetBalanceDetailResponse{
+		Total:               "0x29a2241af62c0000", // 3 QTUM
+		Spendable:           "0xde0b6b3a7640000",  // 1 QTUM
+		Immature:            "0x1bc16d674ec80000", // 2 QTUM
+		UnconfirmedIncoming: "0x6f05b59d3b20000",  // 0.5 QTUM
+		UnconfirmedOutgoing: "0xde0b6b3a7640000",  // 1 QTUM
+	}
+
+	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
+}
+
+func TestGetBalanceRequestSpendableMode(t *testing.T) {
+	//prepare request
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//prepare client
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = qtum.SetBalanceMode(qtum.BalanceModeSpendable)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+
+	//prepare responses
+	fromHexAddressResponse := qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW")
+	err = mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, fromHexAddressResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getAddressBalanceResponse := qtum.GetAddressBalanceResponse{Balance: uint64(300000000), Received: uint64(300000000), Immature: int64(200000000)}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressBalance, getAddressBalanceResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//preparing proxy & executing request
+	proxyEth := ProxyETHGetBalance{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := string("0xde0b6b3a7640000") //1 Qtum represented in Wei
+
+	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
+}
+
+func TestGetBalanceDetailContract(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`)}
+	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{Balance: 1500}); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusGetBalanceDetail{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	// in satoshis, like eth_getBalance returns the balance of contracts
+	want := &eth.GetBalanceDetailResponse{
+		Total:               "0x5dc",
+		Spendable:           "0x5dc",
+		Immature:            "0x0",
+		UnconfirmedIncoming: "0x0",
+		UnconfirmedOutgoing: "0x0",
+	}
+	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
+}
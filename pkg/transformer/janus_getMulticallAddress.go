@@ -0,0 +1,30 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusGetMulticallAddress implements ETHProxy
+// returns the address of the multicall contract Janus was started with or deployed, null without one
+type ProxyJanusGetMulticallAddress struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetMulticallAddress)(nil)
+
+func (p *ProxyJanusGetMulticallAddress) Method() string {
+	return "janus_getMulticallAddress"
+}
+
+func (p *ProxyJanusGetMulticallAddress) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	address := multicallAddress(p.Qtum)
+	if address == "" {
+		return nil, nil
+	}
+	return utils.AddHexPrefix(address), nil
+}
@@ -0,0 +1,37 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusGetNetworkContracts implements ETHProxy
+// returns the well-known contracts registered for the chain qtumd is on, so front-ends don't hardcode them
+type ProxyJanusGetNetworkContracts struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetNetworkContracts)(nil)
+
+func (p *ProxyJanusGetNetworkContracts) Method() string {
+	return "janus_getNetworkContracts"
+}
+
+func (p *ProxyJanusGetNetworkContracts) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	chain := p.Chain()
+	contracts := map[string]string{}
+	for name, address := range p.NetworkContracts[chain] {
+		contracts[name] = utils.AddHexPrefix(address)
+	}
+	// the multicall contract Janus was started with or deployed, unless the registry names another one
+	if address := multicallAddress(p.Qtum); address != "" {
+		if _, ok := contracts["multicall"]; !ok {
+			contracts["multicall"] = utils.AddHexPrefix(address)
+		}
+	}
+	return &eth.GetNetworkContractsResponse{Chain: chain, Contracts: contracts}, nil
+}
@@ -0,0 +1,47 @@
+package transformer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestJanusGetNetworkContracts(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = qtum.SetNetworkContracts(map[string]map[string]string{
+		"main": {"wqtum": "0x0000000000000000000000000000000000000001"},
+		"test": {"wqtum": "0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960", "router": "7926223070547d2d15b2ef5e7383e541c338ffe9"},
+	})(qtumClient.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_MULTICALL_ADDRESS, testMulticallAddress[2:])
+
+	proxy := ProxyJanusGetNetworkContracts{qtumClient}
+	got, jsonErr := proxy.Request(context.Background(), nil, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want := &eth.GetNetworkContractsResponse{
+		Chain: "test",
+		Contracts: map[string]string{
+			"wqtum":     "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960",
+			"router":    "0x7926223070547d2d15b2ef5e7383e541c338ffe9",
+			"multicall": testMulticallAddress,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if err := qtum.SetNetworkContracts(map[string]map[string]string{"test": {"wqtum": "qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"}})(qtumClient.Client); err == nil {
+		t.Error("expected a base58 address to be rejected")
+	}
+}
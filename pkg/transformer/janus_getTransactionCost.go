@@ -0,0 +1,181 @@
+package transformer
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusGetTransactionCost implements ETHProxy
+// reports what a mined transaction cost its sender, taking the refund of unused gas into account
+type ProxyJanusGetTransactionCost struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetTransactionCost)(nil)
+
+func (p *ProxyJanusGetTransactionCost) Method() string {
+	return "janus_getTransactionCost"
+}
+
+func (p *ProxyJanusGetTransactionCost) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetTransactionCostRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		// TODO: Correct error code?
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if req == "" {
+		return nil, eth.NewInvalidParamsError("empty transaction hash")
+	}
+
+	return p.request(ctx, utils.RemoveHexPrefix(string(req)))
+}
+
+func (p *ProxyJanusGetTransactionCost) request(ctx context.Context, txHash string) (*eth.GetTransactionCostResponse, eth.JSONRPCError) {
+	rawTx, err := p.GetRawTransaction(ctx, txHash, false)
+	if err != nil {
+		if errors.Cause(err) == qtum.ErrInvalidAddress {
+			return nil, nil
+		}
+		p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "couldn't get transaction", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	if rawTx.IsPending() {
+		// the refund is only known once the transaction is mined
+		return nil, nil
+	}
+
+	blockNumber, err := getBlockNumberByHash(ctx, p.Qtum, rawTx.BlockHash)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "couldn't get block number", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	decodedTx, err := p.DecodeRawTransaction(ctx, rawTx.Hex)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "couldn't decode transaction", "error", err)
+		return nil, eth.NewCallbackError("couldn't decode raw transaction")
+	}
+
+	var gasUsed uint64
+	receipt, err := p.GetTransactionReceipt(ctx, txHash)
+	if err != nil {
+		if errors.Cause(err) != qtum.EmptyResponseErr {
+			p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "couldn't get transaction receipt", "error", err)
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		// transactions that don't call a contract have no receipt and no refund, a contract transaction without one
+		// would look like it got its whole gas limit back
+		if _, isContractTx, _ := decodedTx.ExtractContractInfo(); isContractTx {
+			p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "contract transaction without receipt")
+			return nil, eth.NewCallbackError("couldn't get the receipt of the contract transaction, is qtumd running with -logevents?")
+		}
+	} else {
+		gasUsed = receipt.GasUsed
+	}
+
+	cost, err := getTransactionCost(rawTx, decodedTx, gasUsed)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "txid", txHash, "msg", "couldn't compute transaction cost", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return &eth.GetTransactionCostResponse{
+		TransactionHash: utils.AddHexPrefix(txHash),
+		BlockNumber:     hexutil.EncodeUint64(blockNumber),
+		GasLimit:        hexutil.EncodeBig(cost.gasLimit),
+		GasPrice:        hexutil.EncodeBig(satoshisToWei(cost.gasPrice)),
+		GasUsed:         hexutil.EncodeBig(cost.gasUsed),
+		GasRefunded:     hexutil.EncodeBig(cost.gasRefunded()),
+		Fee:             hexutil.EncodeBig(satoshisToWei(cost.fee)),
+		Refund:          hexutil.EncodeBig(satoshisToWei(cost.refund())),
+		Cost:            hexutil.EncodeBig(satoshisToWei(cost.cost())),
+	}, nil
+}
+
+// transactionCost holds the amounts a transaction is charged, gas price and fees are in satoshis
+type transactionCost struct {
+	gasLimit *big.Int
+	gasPrice *big.Int
+	gasUsed  *big.Int
+	fee      *big.Int
+}
+
+func (c *transactionCost) gasRefunded() *big.Int {
+	refunded := new(big.Int).Sub(c.gasLimit, c.gasUsed)
+	if refunded.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return refunded
+}
+
+// refund is paid to the sender by the coinstake (or coinbase) of the block the transaction is in
+func (c *transactionCost) refund() *big.Int {
+	return new(big.Int).Mul(c.gasRefunded(), c.gasPrice)
+}
+
+func (c *transactionCost) cost() *big.Int {
+	cost := new(big.Int).Sub(c.fee, c.refund())
+	if cost.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return cost
+}
+
+// getTransactionCost works out the fee and gas refund of a transaction
+// rawTx has to be verbose so the inputs include their amounts, gasUsed comes from the receipt
+func getTransactionCost(rawTx *qtum.GetRawTransactionResponse, decodedTx *qtum.DecodedRawTransactionResponse, gasUsed uint64) (*transactionCost, error) {
+	fee := rawTx.GetMiningFeeInSatoshis()
+	if fee < 0 {
+		// coinbase/coinstake txs have no fees since they are a part of making a block
+		fee = 0
+	}
+
+	cost := &transactionCost{
+		gasLimit: big.NewInt(0),
+		gasPrice: big.NewInt(0),
+		gasUsed:  new(big.Int).SetUint64(gasUsed),
+		fee:      big.NewInt(fee),
+	}
+
+	// parsing errors are discarded like in getTransactionByHash, a transaction without a valid contract output has no gas
+	contractInfo, isContractTx, _ := decodedTx.ExtractContractInfo()
+	if !isContractTx {
+		return cost, nil
+	}
+
+	if contractInfo.GasLimit != "" {
+		gasLimit, err := utils.DecodeBig(contractInfo.GasLimit)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse gas limit")
+		}
+		cost.gasLimit = gasLimit
+	}
+
+	gasPrice, err := decodeScriptNumber(contractInfo.GasPrice)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse gas price")
+	}
+	cost.gasPrice = gasPrice
+
+	return cost, nil
+}
+
+// decodeScriptNumber decodes a little endian number pushed by a script
+func decodeScriptNumber(number string) (*big.Int, error) {
+	b, err := hex.DecodeString(number)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,161 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+// OP_CALL with a gas limit of 200000 and a gas price of 100 satoshis
+const transactionCostCallScript = "010403400d0301644440c10f190000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712000000000000000000000000000000000000000000000000000000000000000a14be528c8378ff082e4ba43cb1baa363dbf3f577bfc2"
+
+func TestGetTransactionCostRequest(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1 QTUM in, 0.79 QTUM change out, the fee covers the whole gas limit
+	rawTransactionResponse := qtum.GetRawTransactionResponse{
+		Hex:       "0200",
+		BlockHash: internal.GetTransactionByHashBlockHash,
+		Vins: []qtum.RawTransactionVin{
+			{AmountSatoshi: 100000000, Address: "QXeZZ5MsAF5pPrPy47ZFMmtCpg7RExT4mi"},
+		},
+		Vouts: []qtum.RawTransactionVout{
+			{AmountSatoshi: 0},
+			{AmountSatoshi: 79000000},
+		},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, &rawTransactionResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mockedClientDoer.AddResponse(qtum.MethodGetBlock, internal.GetBlockResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipts := []qtum.TransactionReceipt{{
+		TransactionHash: "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5",
+		GasUsed:         21678,
+		Excepted:        "None",
+	}}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetTransactionReceipt, receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedRawTransactionResponse := qtum.DecodedRawTransactionResponse{
+		ID: "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5",
+		Vouts: []*qtum.DecodedRawTransactionOutV{
+			{ScriptPubKey: qtum.DecodedRawTransactionScriptPubKey{Hex: transactionCostCallScript}},
+		},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodDecodeRawTransaction, decodedRawTransactionResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusGetTransactionCost{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	// fee: 21000000 satoshis, refund: (200000 - 21678) * 100 satoshis
+	want := eth.GetTransactionCostResponse{
+		TransactionHash: "0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5",
+		BlockNumber:     "0xf8f",
+		GasLimit:        "0x30d40",
+		GasPrice:        "0xe8d4a51000",
+		GasUsed:         "0x54ae",
+		GasRefunded:     "0x2b892",
+		Fee:             "0x2ea11e32ad50000",
+		Refund:          "0x27986ea09a32000",
+		Cost:            "0x708af92131e000",
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
+
+func TestGetTransactionCostWithoutReceipt(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"0x11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawTransactionResponse := qtum.GetRawTransactionResponse{
+		Hex:       "0200",
+		BlockHash: internal.GetTransactionByHashBlockHash,
+		Vins: []qtum.RawTransactionVin{
+			{AmountSatoshi: 100000000, Address: "QXeZZ5MsAF5pPrPy47ZFMmtCpg7RExT4mi"},
+		},
+		Vouts: []qtum.RawTransactionVout{
+			{AmountSatoshi: 0},
+			{AmountSatoshi: 79000000},
+		},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetRawTransaction, &rawTransactionResponse); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetBlock, internal.GetBlockResponse); err != nil {
+		t.Fatal(err)
+	}
+	// qtumd without -logevents has no receipts
+	if err := mockedClientDoer.AddResponse(qtum.MethodGetTransactionReceipt, []qtum.TransactionReceipt{}); err != nil {
+		t.Fatal(err)
+	}
+	decodedRawTransactionResponse := qtum.DecodedRawTransactionResponse{
+		ID: "11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5",
+		Vouts: []*qtum.DecodedRawTransactionOutV{
+			{ScriptPubKey: qtum.DecodedRawTransactionScriptPubKey{Hex: transactionCostCallScript}},
+		},
+	}
+	if err := mockedClientDoer.AddResponse(qtum.MethodDecodeRawTransaction, decodedRawTransactionResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusGetTransactionCost{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.CallbackErrorCode {
+		t.Fatalf("expected a contract transaction without receipt to fail instead of refunding its whole gas limit, got %v, %v", got, jsonErr)
+	}
+}
+
+func TestDecodeScriptNumber(t *testing.T) {
+	tests := map[string]int64{
+		"28":     40,
+		"9001":   400,
+		"400d03": 200000,
+		"":       0,
+	}
+
+	for number, want := range tests {
+		got, err := decodeScriptNumber(number)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Int64() != want {
+			t.Errorf("decodeScriptNumber(%q) = %d, want %d", number, got.Int64(), want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/qtumproject/janus/pkg/verification"
+)
+
+// ProxyJanusGetVerifiedContract implements ETHProxy
+// returns the source of a contract verified with janus_verifyContract
+type ProxyJanusGetVerifiedContract struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusGetVerifiedContract)(nil)
+
+func (p *ProxyJanusGetVerifiedContract) Method() string {
+	return "janus_getVerifiedContract"
+}
+
+func (p *ProxyJanusGetVerifiedContract) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.GetVerifiedContractRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, string(req))
+}
+
+func (p *ProxyJanusGetVerifiedContract) request(ctx context.Context, address string) (eth.GetVerifiedContractResponse, eth.JSONRPCError) {
+	verifier := p.GetVerifier()
+	if verifier == nil {
+		return nil, eth.NewMethodNotFoundError(p.Method())
+	}
+	if !common.IsHexAddress(address) {
+		return nil, eth.NewInvalidParamsError("invalid contract address " + address)
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityContractVerification); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	contract, err := verifier.Store.Get(ctx, strings.ToLower(utils.RemoveHexPrefix(address)))
+	if err == verification.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, verifiedContractsError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityContractVerification)
+
+	return &eth.VerifiedContract{
+		Address:         utils.AddHexPrefix(contract.Address),
+		ContractName:    contract.ContractName,
+		CompilerVersion: contract.CompilerVersion,
+		Optimize:        contract.Optimize,
+		Runs:            contract.Runs,
+		EVMVersion:      contract.EVMVersion,
+		Sources:         contract.Sources,
+		ABI:             contract.ABI,
+		Metadata:        contract.Metadata,
+		ExactMatch:      contract.ExactMatch,
+		VerifiedAt:      contract.VerifiedAt.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusListAccountsDetailed implements ETHProxy
+// describes the accounts loaded with --accounts, with their balance and label
+type ProxyJanusListAccountsDetailed struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusListAccountsDetailed)(nil)
+
+func (p *ProxyJanusListAccountsDetailed) Method() string {
+	return "janus_listAccountsDetailed"
+}
+
+func (p *ProxyJanusListAccountsDetailed) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
+}
+
+func (p *ProxyJanusListAccountsDetailed) request(ctx context.Context) (eth.ListAccountsDetailedResponse, eth.JSONRPCError) {
+	accounts := eth.ListAccountsDetailedResponse{}
+	addresses := p.Keystore.Addresses()
+	if len(addresses) == 0 {
+		return accounts, nil
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityAddressIndex); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	base58Addresses := make([]string, 0, len(addresses))
+	for _, hexAddr := range addresses {
+		base58Addr, err := qtum.HexToBase58Address(hexAddr, p.IsMain())
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+
+		base58Addresses = append(base58Addresses, base58Addr)
+		accounts = append(accounts, eth.AccountDetail{
+			Address:       utils.AddHexPrefix(hexAddr),
+			Base58Address: base58Addr,
+			Label:         p.AccountLabels[hexAddr],
+		})
+	}
+
+	// one call for every account, split up by address below
+	utxos, err := p.GetAddressUTXOs(ctx, &qtum.GetAddressUTXOsRequest{Addresses: base58Addresses})
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "error getting account UTXOs", "error", err)
+		return nil, qtumCallError(err)
+	}
+
+	balances := make(map[string]*big.Int, len(base58Addresses))
+	counts := make(map[string]uint64, len(base58Addresses))
+	for _, utxo := range *utxos {
+		balance, ok := balances[utxo.Address]
+		if !ok {
+			balance = big.NewInt(0)
+			balances[utxo.Address] = balance
+		}
+		balance.Add(balance, utxo.Satoshis.BigInt())
+		counts[utxo.Address]++
+	}
+
+	for i := range accounts {
+		balance, ok := balances[accounts[i].Base58Address]
+		if !ok {
+			balance = big.NewInt(0)
+		}
+		accounts[i].Balance = hexutil.EncodeBig(satoshisToWei(balance))
+		accounts[i].UTXOCount = hexutil.EncodeUint64(counts[accounts[i].Base58Address])
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,84 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+func TestListAccountsDetailedRequest(t *testing.T) {
+	requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exampleAcc1, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exampleAcc2, err := btcutil.DecodeWIF("5JwvXtv6YCa17XNDHJ6CJaveg4mrpqFvcjdrh9FZWZEvGFpUxec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = qtum.SetAccounts(qtum.Accounts{exampleAcc1, exampleAcc2})(qtumClient.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = qtum.SetAccountLabels(map[string]string{"0x6D358CF96533189DD5A602D0937FDDF0888AD3AE": "deployer"})(qtumClient.Client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base58Acc1, err := (&qtum.Account{WIF: exampleAcc1}).ToBase58Address(qtumClient.IsMain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base58Acc2, err := (&qtum.Account{WIF: exampleAcc2}).ToBase58Address(qtumClient.IsMain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second account has no unspent outputs
+	err = mockedClientDoer.AddResponse(qtum.MethodGetAddressUTXOs, qtum.GetAddressUTXOsResponse{
+		{Address: base58Acc1, Satoshis: decimal.NewFromInt(100000000)},
+		{Address: base58Acc1, Satoshis: decimal.NewFromInt(200000000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyJanusListAccountsDetailed{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), requestRPC, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := eth.ListAccountsDetailedResponse{
+		{
+			Address:       "0x6d358cf96533189dd5a602d0937fddf0888ad3ae",
+			Base58Address: base58Acc1,
+			Label:         "deployer",
+			Balance:       "0x29a2241af62c0000", // 3 QTUM
+			UTXOCount:     "0x2",
+		},
+		{
+			Address:       "0x7e22630f90e6db16283af2c6b04f688117a55db4",
+			Base58Address: base58Acc2,
+			Balance:       "0x0",
+			UTXOCount:     "0x0",
+		},
+	}
+
+	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
+}
@@ -0,0 +1,73 @@
+package transformer
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/journal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusListFailedTransactions implements ETHProxy
+// lists the raw transactions qtumd failed to broadcast, recorded in the transaction journal
+type ProxyJanusListFailedTransactions struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusListFailedTransactions)(nil)
+
+func (p *ProxyJanusListFailedTransactions) Method() string {
+	return "janus_listFailedTransactions"
+}
+
+func (p *ProxyJanusListFailedTransactions) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.ListFailedTransactionsRequest
+	if len(rawreq.Params) != 0 {
+		if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+	}
+
+	return p.request(ctx, req.All)
+}
+
+func (p *ProxyJanusListFailedTransactions) request(ctx context.Context, all bool) (eth.ListFailedTransactionsResponse, eth.JSONRPCError) {
+	txJournal := p.GetJournal()
+	if txJournal == nil {
+		return nil, eth.NewMethodNotFoundError(p.Method())
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityTransactionJournal); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	entries, err := txJournal.List(ctx, all)
+	if err != nil {
+		return nil, journalError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityTransactionJournal)
+
+	response := make(eth.ListFailedTransactionsResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, toFailedTransaction(entry))
+	}
+	return response, nil
+}
+
+func toFailedTransaction(entry journal.Entry) eth.FailedTransaction {
+	failed := eth.FailedTransaction{
+		ID:             entry.ID,
+		RawTransaction: utils.AddHexPrefix(entry.RawTransaction),
+		Error:          entry.Error,
+		Attempts:       entry.Attempts,
+		FirstFailedAt:  entry.FirstFailedAt.Format(time.RFC3339),
+		LastFailedAt:   entry.LastFailedAt.Format(time.RFC3339),
+	}
+	if entry.BroadcastAt != nil {
+		failed.BroadcastAt = entry.BroadcastAt.Format(time.RFC3339)
+		failed.TransactionHash = utils.AddHexPrefix(entry.TransactionHash)
+	}
+	return failed
+}
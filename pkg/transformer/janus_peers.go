@@ -0,0 +1,78 @@
+package transformer
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+// ProxyJanusPeers implements ETHProxy
+// maps qtumd getpeerinfo into geth admin_peers style peer objects
+type ProxyJanusPeers struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusPeers)(nil)
+
+func (p *ProxyJanusPeers) Method() string {
+	return "janus_peers"
+}
+
+func (p *ProxyJanusPeers) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	return p.request(ctx)
+}
+
+func (p *ProxyJanusPeers) request(ctx context.Context) (*eth.PeersResponse, eth.JSONRPCError) {
+	peerInfos, err := p.GetPeerInfo(ctx)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return p.ToResponse(peerInfos), nil
+}
+
+func (p *ProxyJanusPeers) ToResponse(peerInfos []qtum.GetPeerInfoResponse) *eth.PeersResponse {
+	peers := make(eth.PeersResponse, 0, len(peerInfos))
+	for _, peerInfo := range peerInfos {
+		peers = append(peers, eth.Peer{
+			ID:   strconv.Itoa(peerInfo.Id),
+			Name: peerInfo.Subversion,
+			Network: eth.PeerNetwork{
+				LocalAddress:  peerInfo.AddressBind,
+				RemoteAddress: peerInfo.Address,
+				Inbound:       peerInfo.Inbound,
+				Static:        peerInfo.Addnode,
+				Trusted:       peerInfo.Whitelisted,
+			},
+			Protocols: eth.PeerProtocols{
+				Qtum: eth.PeerQtumProtocol{
+					Version:        peerInfo.Version,
+					StartingHeight: peerInfo.StartingHeight,
+					SyncedHeaders:  peerInfo.SyncedHeaders,
+					SyncedBlocks:   peerInfo.SyncedBlocks,
+					Latency:        peerInfo.PingTime.Mul(decimal.NewFromInt(1000)).IntPart(),
+					ConnectionTime: peerInfo.ConnectionTime,
+					BytesSent:      peerInfo.BytesSent,
+					BytesReceived:  peerInfo.BytesReceived,
+				},
+			},
+		})
+	}
+	return &peers
+}
+
+// ProxyAdminPeers implements ETHProxy
+// admin_peers is an alias of janus_peers for tooling written against geth
+type ProxyAdminPeers struct {
+	ProxyJanusPeers
+}
+
+var _ ETHProxy = (*ProxyAdminPeers)(nil)
+
+func (p *ProxyAdminPeers) Method() string {
+	return "admin_peers"
+}
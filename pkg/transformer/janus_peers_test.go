@@ -0,0 +1,75 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/shopspring/decimal"
+)
+
+func TestPeersRequest(t *testing.T) {
+	//preparing the request
+	requestParams := []json.RawMessage{} //janus_peers has no params
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getPeerInfoResponse := []qtum.GetPeerInfoResponse{
+		{
+			Id:             3,
+			Address:        "203.0.113.5:3888",
+			AddressBind:    "192.168.1.2:51234",
+			PingTime:       decimal.NewFromFloat(0.045),
+			Version:        70017,
+			Subversion:     "/Satoshi:0.20.1/",
+			Addnode:        true,
+			StartingHeight: 1500000,
+			SyncedHeaders:  1500010,
+			SyncedBlocks:   1500009,
+		},
+	}
+	err = mockedClientDoer.AddResponse(qtum.MethodGetPeerInfo, getPeerInfoResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyAdminPeers{ProxyJanusPeers{qtumClient}}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	want := eth.PeersResponse{
+		{
+			ID:   "3",
+			Name: "/Satoshi:0.20.1/",
+			Network: eth.PeerNetwork{
+				LocalAddress:  "192.168.1.2:51234",
+				RemoteAddress: "203.0.113.5:3888",
+				Static:        true,
+			},
+			Protocols: eth.PeerProtocols{
+				Qtum: eth.PeerQtumProtocol{
+					Version:        70017,
+					StartingHeight: 1500000,
+					SyncedHeaders:  1500010,
+					SyncedBlocks:   1500009,
+					Latency:        45,
+				},
+			},
+		},
+	}
+
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+}
@@ -0,0 +1,63 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/journal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusRebroadcastTransaction implements ETHProxy
+// broadcasts a raw transaction from the transaction journal again, for recovering after an upstream incident
+type ProxyJanusRebroadcastTransaction struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusRebroadcastTransaction)(nil)
+
+func (p *ProxyJanusRebroadcastTransaction) Method() string {
+	return "janus_rebroadcastTransaction"
+}
+
+func (p *ProxyJanusRebroadcastTransaction) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.RebroadcastTransactionRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, int64(req))
+}
+
+func (p *ProxyJanusRebroadcastTransaction) request(ctx context.Context, id int64) (eth.RebroadcastTransactionResponse, eth.JSONRPCError) {
+	txJournal := p.GetJournal()
+	if txJournal == nil {
+		return "", eth.NewMethodNotFoundError(p.Method())
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityTransactionJournal); jsonErr != nil {
+		return "", jsonErr
+	}
+
+	entry, err := txJournal.Get(ctx, id)
+	if err != nil {
+		if err == journal.ErrNotFound {
+			return "", eth.NewInvalidParamsError(err.Error())
+		}
+		return "", journalError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityTransactionJournal)
+
+	// a failure is recorded in the journal again by eth_sendRawTransaction
+	sendRawTransaction := &ProxyETHSendRawTransaction{Qtum: p.Qtum}
+	txHash, jsonErr := sendRawTransaction.request(ctx, eth.SendRawTransactionRequest{utils.AddHexPrefix(entry.RawTransaction)})
+	if jsonErr != nil {
+		return "", jsonErr
+	}
+
+	if err := txJournal.MarkBroadcast(ctx, id, utils.RemoveHexPrefix(string(txHash))); err != nil {
+		p.GetErrorLogger().Log("msg", "Error marking journaled transaction as broadcast", "id", id, "err", err)
+	}
+	return txHash, nil
+}
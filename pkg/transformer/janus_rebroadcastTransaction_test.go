@@ -0,0 +1,122 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/journal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestRebroadcastFailedTransaction(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failedTransactions := journal.NewMemoryJournal()
+	qtumClient.SetJournal(failedTransactions)
+
+	err = mockedClientDoer.AddError(qtum.MethodSendRawTx, eth.NewJSONRPCError(-25, "bad-txns-inputs-missingorspent", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := "7c40b5e4d3b4f8f4aa4c5e6b0d2a6d14c89cbd70ec5b4b5b1f2c2b1b4f8a2d11"
+	if err = mockedClientDoer.AddResponse(qtum.MethodSendRawTx, txid); err != nil {
+		t.Fatal(err)
+	}
+
+	sendRequest, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x0200000001"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendRawTransaction := ProxyETHSendRawTransaction{qtumClient}
+	if _, jsonErr := sendRawTransaction.Request(context.Background(), sendRequest, internal.NewEchoContext()); jsonErr == nil {
+		t.Fatal("expected the broadcast to fail")
+	}
+
+	listRequest, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := ProxyJanusListFailedTransactions{qtumClient}
+	got, jsonErr := list.Request(context.Background(), listRequest, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	failed := got.(eth.ListFailedTransactionsResponse)
+	if len(failed) != 1 || failed[0].RawTransaction != "0x0200000001" || failed[0].Attempts != 1 || failed[0].ID != 1 {
+		t.Fatalf("unexpected failed transactions %+v", failed)
+	}
+
+	rebroadcastRequest, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`1`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebroadcast := ProxyJanusRebroadcastTransaction{qtumClient}
+	got, jsonErr = rebroadcast.Request(context.Background(), rebroadcastRequest, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultDefault(eth.RebroadcastTransactionResponse("0x"+txid), got, t, false)
+
+	got, jsonErr = list.Request(context.Background(), listRequest, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if failed := got.(eth.ListFailedTransactionsResponse); len(failed) != 0 {
+		t.Errorf("rebroadcast transaction is still listed %+v", failed)
+	}
+
+	entry, err := failedTransactions.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.BroadcastAt == nil || entry.TransactionHash != txid {
+		t.Errorf("transaction not marked as broadcast %+v", entry)
+	}
+}
+
+func TestFailedTransactionsWithoutJournal(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := ProxyJanusListFailedTransactions{qtumClient}
+	_, jsonErr := list.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.MethodNotFoundErrorCode {
+		t.Errorf("expected a method not found error, got %v", jsonErr)
+	}
+}
+
+func TestFailedTransactionsBounded(t *testing.T) {
+	ctx := context.Background()
+	failedTransactions := journal.NewMemoryJournal()
+	for i := 0; i < journal.MaxEntries+2; i++ {
+		if err := failedTransactions.Record(ctx, fmt.Sprintf("02000000%04x", i), "bad-txns-inputs-missingorspent"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := failedTransactions.List(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != journal.MaxEntries || entries[0].ID != 3 {
+		t.Fatalf("expected the %d newest entries, got %d from id %d", journal.MaxEntries, len(entries), entries[0].ID)
+	}
+	if _, err := failedTransactions.Get(ctx, 1); err != journal.ErrNotFound {
+		t.Errorf("expected the oldest entry to be dropped, got %v", err)
+	}
+	if entry, err := failedTransactions.Get(ctx, journal.MaxEntries+2); err != nil || entry.RawTransaction != fmt.Sprintf("02000000%04x", journal.MaxEntries+1) {
+		t.Errorf("expected the newest entry, got %+v, %v", entry, err)
+	}
+}
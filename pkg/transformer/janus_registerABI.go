@@ -0,0 +1,92 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/abiregistry"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusRegisterABI implements ETHProxy
+// keeps the ABI of a contract in the ABI registry
+type ProxyJanusRegisterABI struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusRegisterABI)(nil)
+
+func (p *ProxyJanusRegisterABI) Method() string {
+	return "janus_registerABI"
+}
+
+func (p *ProxyJanusRegisterABI) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.RegisterABIRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, &req)
+}
+
+func (p *ProxyJanusRegisterABI) request(ctx context.Context, req *eth.RegisterABIRequest) (*eth.RegisterABIResponse, eth.JSONRPCError) {
+	registry := p.GetABIRegistry()
+	if registry == nil {
+		return nil, eth.NewMethodNotFoundError(p.Method())
+	}
+	if !common.IsHexAddress(req.Address) {
+		return nil, eth.NewInvalidParamsError("invalid contract address " + req.Address)
+	}
+	contractABI, err := normalizeABI(req.ABI)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError("invalid ABI: " + err.Error())
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityABIRegistry); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	entry, err := registry.Register(ctx, strings.ToLower(utils.RemoveHexPrefix(req.Address)), req.Name, contractABI)
+	if err != nil {
+		return nil, abiRegistryError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityABIRegistry)
+	return toContractABI(entry), nil
+}
+
+// normalizeABI checks an ABI given as a JSON array or as a string containing one, returning the compacted array
+func normalizeABI(raw json.RawMessage) (json.RawMessage, error) {
+	if _, err := parseABI(raw); err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) != 0 && raw[0] == '"' {
+		var inner string
+		if err := json.Unmarshal(raw, &inner); err != nil {
+			return nil, err
+		}
+		raw = json.RawMessage(inner)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, raw); err != nil {
+		return nil, err
+	}
+	return compacted.Bytes(), nil
+}
+
+func toContractABI(entry *abiregistry.Entry) *eth.ContractABI {
+	return &eth.ContractABI{
+		Address:      utils.AddHexPrefix(entry.Address),
+		Name:         entry.Name,
+		ABI:          entry.ABI,
+		Source:       "registry",
+		RegisteredAt: entry.RegisteredAt.Format(time.RFC3339),
+		UpdatedAt:    entry.UpdatedAt.Format(time.RFC3339),
+	}
+}
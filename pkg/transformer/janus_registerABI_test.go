@@ -0,0 +1,81 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/abiregistry"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/verification"
+)
+
+func TestRegisterABI(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetABIRegistry(abiregistry.NewMemoryRegistry())
+	verifiedContracts := verification.NewMemoryStore()
+	qtumClient.SetVerifier(&verification.Verifier{Store: verifiedContracts})
+
+	const address = "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	call := func(proxy ETHProxy, params ...string) (interface{}, eth.JSONRPCError) {
+		rawParams := make([]json.RawMessage, len(params))
+		for i, param := range params {
+			rawParams[i] = json.RawMessage(param)
+		}
+		request, err := internal.PrepareEthRPCRequest(1, rawParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return proxy.Request(context.Background(), request, internal.NewEchoContext())
+	}
+	abiOf := func(result interface{}) string {
+		contractABI, ok := result.(eth.GetABIResponse)
+		if !ok || contractABI == nil {
+			return ""
+		}
+		return contractABI.Source + " " + string(contractABI.ABI)
+	}
+
+	// the ABI of a verified contract is found without registering it
+	verifiedContracts.Save(context.Background(), verification.Contract{Address: address[2:], ABI: json.RawMessage(`[]`)})
+	got, jsonErr := call(&ProxyJanusGetABI{qtumClient}, `"`+address+`"`)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if abiOf(got) != "verification []" {
+		t.Errorf("expected the verified ABI, got %q", abiOf(got))
+	}
+
+	if _, jsonErr := call(&ProxyJanusRegisterABI{qtumClient}, `"`+address+`"`, `"not an abi"`); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected an invalid params error, got %v", jsonErr)
+	}
+
+	// given as a string like solc prints it
+	registered, jsonErr := call(&ProxyJanusRegisterABI{qtumClient}, `"`+address+`"`, `"[ {\"type\": \"fallback\"} ]"`, `"Token"`)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if registered.(*eth.RegisterABIResponse).Name != "Token" {
+		t.Errorf("unexpected registered ABI %+v", registered)
+	}
+	got, jsonErr = call(&ProxyJanusGetABI{qtumClient}, `"`+address+`"`)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if abiOf(got) != `registry [{"type":"fallback"}]` {
+		t.Errorf("expected the registered ABI, got %q", abiOf(got))
+	}
+
+	removed, jsonErr := call(&ProxyJanusRemoveABI{qtumClient}, `"`+address+`"`)
+	if jsonErr != nil || removed != eth.RemoveABIResponse(true) {
+		t.Fatalf("expected the ABI to be removed, got %v %v", removed, jsonErr)
+	}
+	removed, jsonErr = call(&ProxyJanusRemoveABI{qtumClient}, `"`+address+`"`)
+	if jsonErr != nil || removed != eth.RemoveABIResponse(false) {
+		t.Fatalf("expected nothing left to remove, got %v %v", removed, jsonErr)
+	}
+}
@@ -0,0 +1,54 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/qtumproject/janus/pkg/abiregistry"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// ProxyJanusRemoveABI implements ETHProxy
+// removes the ABI registered for a contract
+type ProxyJanusRemoveABI struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusRemoveABI)(nil)
+
+func (p *ProxyJanusRemoveABI) Method() string {
+	return "janus_removeABI"
+}
+
+func (p *ProxyJanusRemoveABI) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.RemoveABIRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, string(req))
+}
+
+func (p *ProxyJanusRemoveABI) request(ctx context.Context, address string) (eth.RemoveABIResponse, eth.JSONRPCError) {
+	registry := p.GetABIRegistry()
+	if registry == nil {
+		return false, eth.NewMethodNotFoundError(p.Method())
+	}
+	if !common.IsHexAddress(address) {
+		return false, eth.NewInvalidParamsError("invalid contract address " + address)
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityABIRegistry); jsonErr != nil {
+		return false, jsonErr
+	}
+
+	err := registry.Remove(ctx, strings.ToLower(utils.RemoveHexPrefix(address)))
+	if err != nil && err != abiregistry.ErrNotFound {
+		return false, abiRegistryError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityABIRegistry)
+	return err == nil, nil
+}
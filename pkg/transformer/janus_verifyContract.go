@@ -0,0 +1,115 @@
+package transformer
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/qtumproject/janus/pkg/verification"
+)
+
+// file name single file sources are compiled as
+const verifyContractSourceName = "contract.sol"
+
+// ProxyJanusVerifyContract implements ETHProxy
+// compiles a contract's source and compares it with the code deployed at an address, keeping the source of contracts
+// that match for explorers
+type ProxyJanusVerifyContract struct {
+	*qtum.Qtum
+}
+
+var _ ETHProxy = (*ProxyJanusVerifyContract)(nil)
+
+func (p *ProxyJanusVerifyContract) Method() string {
+	return "janus_verifyContract"
+}
+
+func (p *ProxyJanusVerifyContract) Request(ctx context.Context, rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	var req eth.VerifyContractRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(ctx, &req)
+}
+
+func (p *ProxyJanusVerifyContract) request(ctx context.Context, req *eth.VerifyContractRequest) (*eth.VerifyContractResponse, eth.JSONRPCError) {
+	verifier := p.GetVerifier()
+	if verifier == nil {
+		return nil, eth.NewMethodNotFoundError(p.Method())
+	}
+	if !common.IsHexAddress(req.Address) {
+		return nil, eth.NewInvalidParamsError("invalid contract address " + req.Address)
+	}
+	address := strings.ToLower(utils.RemoveHexPrefix(req.Address))
+	options := req.Options
+	if options.ContractName == "" {
+		return nil, eth.NewInvalidParamsError("missing contractName")
+	}
+	sources := options.Sources
+	switch {
+	case options.Source != "" && len(sources) != 0:
+		return nil, eth.NewInvalidParamsError("pass either source or sources")
+	case options.Source != "":
+		sources = map[string]string{verifyContractSourceName: options.Source}
+	case len(sources) == 0:
+		return nil, eth.NewInvalidParamsError("missing source")
+	}
+	if jsonErr := requireCapability(p.Qtum, qtum.CapabilityContractVerification); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	qtumreq := qtum.GetAccountInfoRequest(address)
+	account, err := p.GetAccountInfo(ctx, &qtumreq)
+	if err == qtum.ErrInvalidAddress {
+		return nil, eth.NewInvalidParamsError("no contract at " + req.Address)
+	}
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	onChain, err := hex.DecodeString(account.Code)
+	if err != nil || len(onChain) == 0 {
+		return nil, eth.NewInvalidParamsError("no contract at " + req.Address)
+	}
+
+	result, err := verifier.Verify(ctx, address, onChain, verification.Input{
+		Sources:         sources,
+		ContractName:    options.ContractName,
+		CompilerVersion: options.CompilerVersion,
+		Optimize:        options.Optimize,
+		Runs:            options.Runs,
+		EVMVersion:      options.EVMVersion,
+	})
+	if err != nil {
+		if inputErr, ok := errors.Cause(err).(*verification.InputError); ok {
+			return nil, eth.NewInvalidParamsError(inputErr.Error())
+		}
+		// what went wrong with the compiler is for the operator, it can tell about the host
+		p.GetErrorLogger().Log("method", p.Method(), "address", address, "msg", "compilation failed", "error", err)
+		return nil, eth.NewCallbackError("compilation failed")
+	}
+
+	response := &eth.VerifyContractResponse{
+		Address:         utils.AddHexPrefix(address),
+		ContractName:    result.Output.ContractName,
+		CompilerVersion: result.Output.CompilerVersion,
+	}
+	if result.Contract == nil {
+		return response, nil
+	}
+	response.Verified = true
+	response.ExactMatch = result.Contract.ExactMatch
+	response.ABI = result.Output.ABI
+
+	if err := verifier.Store.Save(ctx, *result.Contract); err != nil {
+		return nil, verifiedContractsError(p.Qtum, err)
+	}
+	p.GetCapabilities().MarkAvailable(qtum.CapabilityContractVerification)
+	return response, nil
+}
@@ -0,0 +1,124 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/verification"
+)
+
+// fakeCompiler returns the same runtime code whatever it is given
+type fakeCompiler struct {
+	runtimeBytecode string
+	input           verification.Input
+}
+
+func (c *fakeCompiler) Compile(ctx context.Context, input verification.Input) (*verification.Output, error) {
+	c.input = input
+	return &verification.Output{
+		ContractName:    "contract.sol:" + input.ContractName,
+		CompilerVersion: "v0.8.17+commit.8df45f5f",
+		RuntimeBytecode: c.runtimeBytecode,
+		ABI:             json.RawMessage(`[]`),
+		Metadata:        `{"language":"Solidity"}`,
+	}, nil
+}
+
+func TestVerifyContract(t *testing.T) {
+	const (
+		address = "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+		code    = "6080604052600080fd"
+		// a CBOR map followed by its length
+		metadata      = "a1626161010005"
+		otherMetadata = "a1626161020005"
+	)
+	tests := []struct {
+		name     string
+		compiled string
+		verified bool
+		exact    bool
+	}{
+		{"same code", code + metadata, true, true},
+		// comments and file names change the metadata hash
+		{"different metadata", code + otherMetadata, true, false},
+		{"different code", "6080604052600180fd" + metadata, false, false},
+	}
+
+	for _, test := range tests {
+		mockedClientDoer := internal.NewDoerMappedMock()
+		qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compiler := &fakeCompiler{runtimeBytecode: test.compiled}
+		store := verification.NewMemoryStore()
+		qtumClient.SetVerifier(&verification.Verifier{Compiler: compiler, Store: store})
+
+		err = mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{Address: address, Code: code + metadata})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+			[]byte(`"0x` + address + `"`),
+			[]byte(`{"source":"contract Token {}","contractName":"Token","optimize":true,"runs":200}`),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxy := ProxyJanusVerifyContract{qtumClient}
+		got, jsonErr := proxy.Request(context.Background(), request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatalf("%s: %v", test.name, jsonErr)
+		}
+		if compiler.input.Sources[verifyContractSourceName] != "contract Token {}" || !compiler.input.Optimize {
+			t.Errorf("%s: unexpected compiler input %+v", test.name, compiler.input)
+		}
+
+		want := &eth.VerifyContractResponse{
+			Address:         "0x" + address,
+			Verified:        test.verified,
+			ExactMatch:      test.exact,
+			ContractName:    "contract.sol:Token",
+			CompilerVersion: "v0.8.17+commit.8df45f5f",
+		}
+		if test.verified {
+			want.ABI = json.RawMessage(`[]`)
+		}
+		internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+
+		getRequest, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x` + address + `"`)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, jsonErr := (&ProxyJanusGetVerifiedContract{qtumClient}).Request(context.Background(), getRequest, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatalf("%s: %v", test.name, jsonErr)
+		}
+		encoded, err := json.Marshal(stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.verified == (string(encoded) == "null") {
+			t.Errorf("%s: unexpected verified contract %s", test.name, encoded)
+		}
+	}
+}
+
+func TestVerifyContractDisabled(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyJanusGetVerifiedContract{qtumClient}).Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil {
+		t.Error("expected janus_getVerifiedContract to be unavailable without --verify-contracts")
+	}
+}
@@ -0,0 +1,89 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// accountError converts the error of finding or unlocking a keystore account
+func accountError(address string, err error) eth.JSONRPCError {
+	switch err {
+	case qtum.ErrUnknownAccount:
+		return eth.NewInvalidParamsError(fmt.Sprintf("No such account: %s", utils.RemoveHexPrefix(address)))
+	case qtum.ErrAccountLocked:
+		return eth.NewAccountLockedError()
+	default:
+		return eth.NewCallbackError(err.Error())
+	}
+}
+
+// signWithKey signs the inputs of a raw transaction spending the pay to pubkey hash outputs of key, so keys of the
+// keystore never reach qtumd
+func signWithKey(rawTx string, key *btcutil.WIF) (string, error) {
+	data, err := hex.DecodeString(utils.RemoveHexPrefix(rawTx))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid raw transaction")
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", errors.Wrap(err, "invalid raw transaction")
+	}
+
+	pubKey := key.SerializePubKey()
+	subscript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(pubKey)).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return "", err
+	}
+	for i := range tx.TxIn {
+		sigScript, err := txscript.SignatureScript(&tx, i, subscript, txscript.SigHashAll, key.PrivKey, key.CompressPubKey)
+		if err != nil {
+			return "", errors.Wrapf(err, "signing input %d", i)
+		}
+		tx.TxIn[i].SignatureScript = sigScript
+	}
+
+	var signed bytes.Buffer
+	if err := tx.Serialize(&signed); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signed.Bytes()), nil
+}
+
+// sendWithKey builds the transaction from the outputs of the account of key, signs it with key and broadcasts it
+func (p *ProxyETHSendTransaction) sendWithKey(ctx context.Context, req *eth.SendTransactionRequest, key *btcutil.WIF) (*eth.SendTransactionResponse, eth.JSONRPCError) {
+	rawTx, jsonErr := (&ProxyETHSignTransaction{p.Qtum}).buildTransaction(ctx, req, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	unsigned, err := p.CreateRawTransaction(ctx, rawTx)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	signed, err := signWithKey(unsigned, key)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	// the raw transaction's sender is tracked when it's sent
+	txHash, jsonErr := (&ProxyETHSendRawTransaction{p.Qtum}).request(ctx, eth.SendRawTransactionRequest{signed})
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	result := eth.SendTransactionResponse(txHash)
+	return &result, nil
+}
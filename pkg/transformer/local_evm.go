@@ -0,0 +1,216 @@
+package transformer
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/evm"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// localCallGasLimit is the gas of calls to the local EVM that don't set one, the most callcontract allows
+const localCallGasLimit = 40000000
+
+// qtumState is the state of the chain the local EVM reads from qtumd, once per account. getaccountinfo only returns
+// the latest code and balance of contracts, so blockNumber is the latest block and storage is read at its end.
+// getstorage can only return the whole storage of a contract, qtumd's responses are cached until the next block so
+// calls in a row share them
+type qtumState struct {
+	p           *qtum.Qtum
+	blockNumber *big.Int
+
+	mutex    sync.Mutex
+	accounts map[common.Address]*qtum.GetAccountInfoResponse
+	storage  map[common.Address]map[common.Hash]common.Hash
+}
+
+func newQtumState(p *qtum.Qtum, blockNumber *big.Int) *qtumState {
+	return &qtumState{
+		p:           p,
+		blockNumber: blockNumber,
+		accounts:    make(map[common.Address]*qtum.GetAccountInfoResponse),
+		storage:     make(map[common.Address]map[common.Hash]common.Hash),
+	}
+}
+
+func (s *qtumState) Account(ctx context.Context, address common.Address) (*big.Int, []byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	info, ok := s.accounts[address]
+	if !ok {
+		req := qtum.GetAccountInfoRequest(hex.EncodeToString(address.Bytes()))
+		resp, err := s.p.GetAccountInfo(ctx, &req)
+		if err != nil {
+			if errors.Cause(err) != qtum.ErrInvalidAddress {
+				return nil, nil, err
+			}
+			// not a contract, the balance of other addresses is in UTXOs the EVM can't see
+			resp = &qtum.GetAccountInfoResponse{}
+		}
+		info = resp
+		s.accounts[address] = info
+	}
+	code, err := hex.DecodeString(info.Code)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid code")
+	}
+	return satoshisToWei(big.NewInt(int64(info.Balance))), code, nil
+}
+
+func (s *qtumState) Storage(ctx context.Context, address common.Address) (map[common.Hash]common.Hash, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if storage, ok := s.storage[address]; ok {
+		return storage, nil
+	}
+	storage := map[common.Hash]common.Hash{}
+	if s.blockNumber.Sign() >= 0 {
+		resp, err := s.p.GetStorage(ctx, &qtum.GetStorageRequest{
+			Address:     hex.EncodeToString(address.Bytes()),
+			BlockNumber: s.blockNumber,
+		})
+		if err != nil && errors.Cause(err) != qtum.ErrInvalidAddress {
+			return nil, err
+		}
+		if resp != nil {
+			// qtumd returns each hashed key with the one key it is the hash of
+			for _, slots := range *resp {
+				for key, value := range slots {
+					storage[common.HexToHash(key)] = common.HexToHash(value)
+				}
+			}
+		}
+	}
+	s.storage[address] = storage
+	return storage, nil
+}
+
+// localCall is a call to run in the local EVM, against the state at the end of its block with its overrides applied
+type localCall struct {
+	state     *qtumState
+	overrides eth.StateOverride
+	chainID   *big.Int
+	block     evm.Block
+	msg       evm.Message
+}
+
+func newLocalCall(ctx context.Context, p *qtum.Qtum, ethreq *eth.CallRequest) (*localCall, eth.JSONRPCError) {
+	if jsonErr := checkLatestBlock(ctx, p, ethreq.Block, "the local EVM"); jsonErr != nil {
+		return nil, jsonErr
+	}
+	block, jsonErr := resolveBlock(ctx, p, ethreq.Block, true)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if block == nil {
+		return nil, eth.NewCallbackError("header not found")
+	}
+	header, err := p.GetBlockHeader(ctx, block.Hash)
+	if err != nil {
+		p.GetDebugLogger().Log("function", "newLocalCall", "msg", "couldn't get block header", "hash", block.Hash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block header")
+	}
+	chainID, jsonErr := getChainId(p)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	msg, jsonErr := localCallMessage(p, ethreq)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	return &localCall{
+		state:     newQtumState(p, block.Number),
+		overrides: ethreq.Overrides,
+		chainID:   chainID,
+		block: evm.Block{
+			Number:   block.Number,
+			Time:     header.Time,
+			GasLimit: localCallGasLimit,
+			GetHash: func(number uint64) common.Hash {
+				hash, err := p.GetBlockHash(ctx, new(big.Int).SetUint64(number))
+				if err != nil {
+					return common.Hash{}
+				}
+				return common.HexToHash(string(hash))
+			},
+		},
+		msg: *msg,
+	}, nil
+}
+
+func localCallMessage(p *qtum.Qtum, ethreq *eth.CallRequest) (*evm.Message, eth.JSONRPCError) {
+	msg := &evm.Message{Gas: localCallGasLimit, Value: new(big.Int)}
+
+	from := ethreq.From
+	if from != "" && !utils.IsEthHexAddress(from) {
+		hexFrom, err := p.Base58AddressToHex(from)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid from address " + from)
+		}
+		from = hexFrom
+	}
+	msg.From = common.HexToAddress(from)
+
+	if ethreq.To != "" {
+		if !common.IsHexAddress(ethreq.To) {
+			return nil, eth.NewInvalidParamsError("invalid to address " + ethreq.To)
+		}
+		to := common.HexToAddress(ethreq.To)
+		msg.To = &to
+	}
+	if ethreq.Gas != nil && ethreq.Gas.Int != nil && ethreq.Gas.IsUint64() && ethreq.Gas.Uint64() < localCallGasLimit {
+		msg.Gas = ethreq.Gas.Uint64()
+	}
+	if ethreq.Value != "" {
+		value, err := utils.DecodeBig(ethreq.Value)
+		if err != nil {
+			return nil, eth.NewInvalidParamsError("invalid value " + ethreq.Value)
+		}
+		msg.Value = value
+	}
+	data, err := hex.DecodeString(utils.RemoveHexPrefix(ethreq.Data))
+	if err != nil {
+		return nil, eth.NewInvalidParamsError("invalid data, expected hex")
+	}
+	msg.Data = data
+	return msg, nil
+}
+
+// run runs the call against a fresh copy of the state, the reads of earlier runs are reused
+func (c *localCall) run(ctx context.Context, tracer vm.EVMLogger) (*evm.Result, eth.JSONRPCError) {
+	state := evm.NewStateDB(ctx, c.state)
+	if err := state.Override(c.overrides); err != nil {
+		if state.Error() != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	result, err := evm.Run(state, c.chainID, c.block, c.msg, tracer)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return result, nil
+}
+
+// localCallError is the error of a call that failed in the local EVM, a revert carries its reason and data like geth
+func localCallError(result *evm.Result) eth.JSONRPCError {
+	if result.Err == vm.ErrExecutionReverted {
+		data := hexutil.Encode(result.ReturnData)
+		return eth.NewExecutionRevertedError(decodeRevertReason(data), data)
+	}
+	return eth.NewCallbackError(result.Err.Error())
+}
+
+// localEVMDisabledError is the error of the methods that only the local EVM can serve, when it isn't enabled
+func localEVMDisabledError(method string) eth.JSONRPCError {
+	return eth.NewJSONRPCError(eth.MethodNotFoundErrorCode, "the method "+method+" needs Janus to run with --local-evm", nil)
+}
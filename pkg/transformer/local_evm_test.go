@@ -0,0 +1,145 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+const (
+	localEVMSender   = "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"
+	localEVMContract = "0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c"
+	// returns the sum of slots 0 and 1
+	localEVMCode = "6000546001540160005260206000f3"
+)
+
+// setupLocalEVM mocks the block the local EVM runs in and the state of a contract with localEVMCode, which every
+// address gets from getaccountinfo, or of none when only overrides give it code
+func setupLocalEVM(t *testing.T, contractCode bool) *qtum.Qtum {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_LOCAL_EVM, true)
+
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 3983})
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(3983)})
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockHash, qtum.GetBlockHashResponse(internal.GetTransactionByHashBlockHash))
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockHeader, qtum.GetBlockHeaderResponse{
+		Hash:   internal.GetTransactionByHashBlockHash,
+		Height: 3983,
+		Time:   1536551888,
+	})
+	if contractCode {
+		mockedClientDoer.AddResponse(qtum.MethodGetAccountInfo, qtum.GetAccountInfoResponse{Code: localEVMCode})
+	} else {
+		mockedClientDoer.AddError(qtum.MethodGetAccountInfo, qtum.GetErrorResponse(qtum.ErrInvalidAddress))
+	}
+	mockedClientDoer.AddResponse(qtum.MethodGetStorage, testStorage())
+	return qtumClient
+}
+
+func TestEthCallLocalEVM(t *testing.T) {
+	params := []byte(`[{"from": "` + localEVMSender + `", "to": "` + localEVMContract + `"}, "0xf8f", {"` + localEVMContract + `": {"code": "0x` + localEVMCode + `", "stateDiff": {"0x0": "0x9"}}}]`)
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Params = params
+
+	qtumClient := setupLocalEVM(t, false)
+	proxyEth := ProxyETHCall{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	// slot 0 is overridden, slot 1 is read from qtumd
+	want := eth.CallResponse("0x0000000000000000000000000000000000000000000000000000000000000014")
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+
+	qtumClient.SetFlag(qtum.FLAG_LOCAL_EVM, false)
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Errorf("expected code overrides to be rejected without the local EVM, got %v", jsonErr)
+	}
+}
+
+func TestDebugTraceCall(t *testing.T) {
+	params := []byte(`[{"from": "` + localEVMSender + `", "to": "` + localEVMContract + `", "gas": "0x186a0"}, "0xf8f", {"tracer": "callTracer"}]`)
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Params = params
+
+	qtumClient := setupLocalEVM(t, true)
+	proxyEth := ProxyDebugTraceCall{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	frame := got.(*eth.CallFrame)
+	if frame.Type != "CALL" || frame.From != localEVMSender || frame.To != localEVMContract || frame.Gas != "0x186a0" {
+		t.Errorf("unexpected frame %+v", frame)
+	}
+	if frame.Output != "0x0000000000000000000000000000000000000000000000000000000000000015" || frame.Error != "" {
+		t.Errorf("expected the sum of slots 0 and 1, got %+v", frame)
+	}
+
+	qtumClient.SetFlag(qtum.FLAG_LOCAL_EVM, false)
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.MethodNotFoundErrorCode {
+		t.Errorf("expected debug_traceCall not to be available without the local EVM, got %v", jsonErr)
+	}
+}
+
+func TestEthCreateAccessList(t *testing.T) {
+	params := []byte(`[{"from": "` + localEVMSender + `", "to": "` + localEVMContract + `"}, "0xf8f"]`)
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Params = params
+
+	proxyEth := ProxyETHCreateAccessList{setupLocalEVM(t, true)}
+	got, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	resp := got.(*eth.CreateAccessListResponse)
+	if len(resp.AccessList) != 1 || resp.AccessList[0].Address != localEVMContract || resp.Error != "" {
+		t.Fatalf("expected the contract in the access list, got %+v", resp)
+	}
+	keys := resp.AccessList[0].StorageKeys
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "0x"+leftPadStringWithZerosTo64Bytes("0") || keys[1] != "0x"+leftPadStringWithZerosTo64Bytes("1") {
+		t.Errorf("expected slots 0 and 1, got %v", keys)
+	}
+	if resp.GasUsed == "" || resp.GasUsed == "0x0" {
+		t.Errorf("expected the gas used, got %s", resp.GasUsed)
+	}
+
+	request.Params = []byte(`[{"from": "` + localEVMSender + `", "data": "0x` + localEVMCode + `"}, "0xf8f"]`)
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Errorf("expected a contract creation to be rejected, got %v", jsonErr)
+	}
+}
+
+func TestLocalEVMLatestBlockOnly(t *testing.T) {
+	params := []byte(`[{"from": "` + localEVMSender + `", "to": "` + localEVMContract + `"}, "0xf8e", {"` + localEVMContract + `": {"stateDiff": {"0x0": "0x9"}}}]`)
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Params = params
+
+	proxyEth := ProxyETHCall{setupLocalEVM(t, true)}
+	if _, jsonErr := proxyEth.Request(context.Background(), request, internal.NewEchoContext()); jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Errorf("expected a call at an earlier block to be rejected, got %v", jsonErr)
+	}
+}
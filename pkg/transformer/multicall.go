@@ -0,0 +1,126 @@
+package transformer
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/multicall"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+)
+
+// multicallBatchSize bounds the callcontract calls of an aggregate3 sent to qtumd in one batch
+const multicallBatchSize = 100
+
+// multicallAddress is the hex address of the multicall contract without 0x, empty without one
+func multicallAddress(p *qtum.Qtum) string {
+	address := p.GetFlagString(qtum.FLAG_MULTICALL_ADDRESS)
+	if address == nil {
+		return ""
+	}
+	return *address
+}
+
+// isMulticall reports whether ethreq calls aggregate3 of the multicall contract
+func isMulticall(p *qtum.Qtum, ethreq *eth.CallRequest) bool {
+	address := multicallAddress(p)
+	if address == "" || !strings.EqualFold(utils.RemoveHexPrefix(ethreq.To), address) {
+		return false
+	}
+	data, err := hex.DecodeString(utils.RemoveHexPrefix(ethreq.Data))
+	return err == nil && multicall.IsAggregate3(data)
+}
+
+// multicallRequest runs the calls of an aggregate3 as batches of callcontract calls instead of one callcontract
+// running them all, qtumd runs the calls of a batch in parallel. The calls come from the multicall contract, like they
+// would when it runs them
+func (p *ProxyETHCall) multicallRequest(ctx context.Context, ethreq *eth.CallRequest) (interface{}, eth.JSONRPCError) {
+	data, err := hex.DecodeString(utils.RemoveHexPrefix(ethreq.Data))
+	if err != nil {
+		return nil, eth.NewInvalidParamsError("invalid data, expected hex")
+	}
+	calls, err := multicall.DecodeAggregate3(data)
+	if err != nil {
+		// the contract reverts calldata it can't decode
+		return nil, eth.NewExecutionRevertedError("", "")
+	}
+	sender, err := p.FromHexAddress(multicallAddress(p.Qtum))
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	responses := make([]*qtum.CallContractResponse, len(calls))
+	batch := make([]*qtum.BatchCall, len(calls))
+	for i, call := range calls {
+		req := &qtum.CallContractRequest{
+			To:   hex.EncodeToString(call.Target.Bytes()),
+			From: sender,
+			Data: hex.EncodeToString(call.CallData),
+		}
+		if ethreq.Gas != nil {
+			req.GasLimit = ethreq.Gas.Int
+		}
+		batch[i] = &qtum.BatchCall{Method: qtum.MethodCallContract, Params: req, Result: &responses[i]}
+	}
+	for start := 0; start < len(batch); start += multicallBatchSize {
+		end := start + multicallBatchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := p.RequestBatch(ctx, batch[start:end]); err != nil {
+			// qtumd behind a proxy that doesn't pass batches on
+			p.GetDebugLogger().Log("function", "multicallRequest", "msg", "batch failed, calling one by one", "err", err)
+			for _, call := range batch[start:end] {
+				call.Err = p.RequestWithContext(ctx, call.Method, call.Params, call.Result)
+			}
+		}
+	}
+
+	results := make([]multicall.Result, len(calls))
+	for i, call := range batch {
+		if call.Err != nil {
+			if errors.Cause(call.Err) == qtum.ErrInvalidAddress {
+				// calling an address without code succeeds without returning anything
+				results[i] = multicall.Result{Success: true}
+				continue
+			}
+			return nil, eth.NewCallbackError(call.Err.Error())
+		}
+		output, err := hex.DecodeString(responses[i].ExecutionResult.Output)
+		if err != nil {
+			return nil, eth.NewCallbackError("invalid output of call " + call.Params.(*qtum.CallContractRequest).To)
+		}
+		results[i] = multicall.Result{Success: responses[i].ExecutionResult.Excepted == "None", ReturnData: output}
+		if !results[i].Success && !calls[i].AllowFailure {
+			return nil, eth.NewExecutionRevertedError(multicall.CallFailed, hexutil.Encode(multicall.CallFailedRevert()))
+		}
+	}
+
+	output, err := multicall.EncodeResults(results)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	resp := eth.CallResponse(hexutil.Encode(output))
+	return &resp, nil
+}
+
+// DeployMulticall deploys the multicall contract from qtumd's wallet and returns its hex address, it can be called
+// once the transaction is mined
+func DeployMulticall(ctx context.Context, p *qtum.Qtum) (string, error) {
+	code := multicall.CreationCode()
+	sendProxy := &ProxyETHSendTransaction{p}
+	resp, jsonErr := sendProxy.createContract(&eth.SendTransactionRequest{
+		Data:     hexutil.Encode(code),
+		Gas:      &eth.ETHInt{Int: estimateDeployGas(code)},
+		GasPrice: &eth.ETHInt{Int: eth.DefaultGasPriceInWei},
+	})
+	if jsonErr != nil {
+		return "", errors.New(jsonErr.Message())
+	}
+	p.GenerateIfPossible()
+	return resp.Address, nil
+}
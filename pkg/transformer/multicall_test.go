@@ -0,0 +1,106 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/multicall"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+const testMulticallAddress = "0x6e5a7e6d4f5e0b1a1f3c8d3a6b7c9d0e1f2a3b4c"
+
+func multicallOutput(excepted string, output string) json.RawMessage {
+	return json.RawMessage(`{"executionResult": {"excepted": "` + excepted + `", "output": "` + output + `"}}`)
+}
+
+func multicallRequest(t *testing.T, calls []multicall.Call3) *eth.JSONRPCRequest {
+	data, err := multicall.EncodeAggregate3(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := json.Marshal([]interface{}{map[string]string{"to": testMulticallAddress, "data": hexutil.Encode(data)}, "latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Params = params
+	return request
+}
+
+func TestEthCallMulticall(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_MULTICALL_ADDRESS, testMulticallAddress[2:])
+	mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"))
+
+	// the mock doesn't answer batches, so the calls are made one by one
+	mockedClientDoer.AddResponse(qtum.MethodCallContract, multicallOutput("None", "000000000000000000000000000000000000000000000000000000000000002a"))
+	mockedClientDoer.AddResponse(qtum.MethodCallContract, multicallOutput("Revert", "0badc0de"))
+	mockedClientDoer.AddError(qtum.MethodCallContract, qtum.GetErrorResponse(qtum.ErrInvalidAddress))
+
+	calls := []multicall.Call3{
+		{Target: common.HexToAddress("0xa0a"), CallData: common.FromHex("01020304")},
+		{Target: common.HexToAddress("0xb0b"), AllowFailure: true},
+		{Target: common.HexToAddress("0xc0c")},
+	}
+	proxyEth := ProxyETHCall{qtumClient}
+	got, jsonErr := proxyEth.Request(context.Background(), multicallRequest(t, calls), internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	output, err := multicall.EncodeResults([]multicall.Result{
+		{Success: true, ReturnData: common.BigToHash(big.NewInt(42)).Bytes()},
+		{Success: false, ReturnData: common.FromHex("0badc0de")},
+		{Success: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := eth.CallResponse(hexutil.Encode(output))
+	internal.CheckTestResultEthRequestRPC(*multicallRequest(t, calls), &want, got, t, false)
+}
+
+func TestEthCallMulticallCallFailed(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qtumClient.SetFlag(qtum.FLAG_MULTICALL_ADDRESS, testMulticallAddress[2:])
+	mockedClientDoer.AddResponse(qtum.MethodFromHexAddress, qtum.FromHexAddressResponse("qUbxboqjBRp96j3La8D1RYkyqx5uQbJPoW"))
+	mockedClientDoer.AddResponse(qtum.MethodCallContract, multicallOutput("Revert", ""))
+
+	proxyEth := ProxyETHCall{qtumClient}
+	_, jsonErr := proxyEth.Request(context.Background(), multicallRequest(t, []multicall.Call3{{Target: common.HexToAddress("0xa0a")}}), internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.ExecutionRevertedErrorCode || jsonErr.Message() != "execution reverted: "+multicall.CallFailed {
+		t.Errorf("expected aggregate3 to revert like Multicall3, got %v", jsonErr)
+	}
+}
+
+func TestJanusGetMulticallAddress(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := ProxyJanusGetMulticallAddress{qtumClient}
+	if got, jsonErr := proxy.Request(context.Background(), nil, internal.NewEchoContext()); jsonErr != nil || got != nil {
+		t.Errorf("expected no address, got %v %v", got, jsonErr)
+	}
+	qtumClient.SetFlag(qtum.FLAG_MULTICALL_ADDRESS, testMulticallAddress[2:])
+	if got, jsonErr := proxy.Request(context.Background(), nil, internal.NewEchoContext()); jsonErr != nil || got != testMulticallAddress {
+		t.Errorf("expected %s, got %v %v", testMulticallAddress, got, jsonErr)
+	}
+}
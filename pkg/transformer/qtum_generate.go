@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"reflect"
 	"strconv"
 
@@ -20,7 +21,7 @@ func (p *ProxyQTUMGenerateToAddress) Method() string {
 	return "dev_generatetoaddress"
 }
 
-func (p *ProxyQTUMGenerateToAddress) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyQTUMGenerateToAddress) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	if !p.CanGenerate() {
 		return nil, eth.NewInvalidRequestError("Can only generate on regtest")
 	}
@@ -35,10 +36,10 @@ func (p *ProxyQTUMGenerateToAddress) Request(req *eth.JSONRPCRequest, c echo.Con
 		return nil, eth.NewInvalidParamsError("require 2 arguments: blocks, the base58/hex address to mine rewards to")
 	}
 
-	return p.request(params)
+	return p.request(ctx, params)
 }
 
-func (p *ProxyQTUMGenerateToAddress) request(params []interface{}) (*[]string, eth.JSONRPCError) {
+func (p *ProxyQTUMGenerateToAddress) request(ctx context.Context, params []interface{}) (*[]string, eth.JSONRPCError) {
 	blocks := params[0]
 	generateTo, ok := params[1].(string)
 	if !ok {
@@ -70,11 +71,11 @@ func (p *ProxyQTUMGenerateToAddress) request(params []interface{}) (*[]string, e
 		base58Address = generateTo
 	}
 
-	var response []string
-	err = p.Client.Request(qtum.MethodGenerateToAddress, []interface{}{blocksInteger, base58Address}, &response)
+	response, err := p.GenerateToAddress(ctx, int(blocksInteger), base58Address)
 	if err != nil {
 		return nil, eth.NewInvalidRequestError(err.Error())
 	}
 
-	return &response, nil
+	hashes := []string(response)
+	return &hashes, nil
 }
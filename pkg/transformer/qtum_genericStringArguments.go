@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"github.com/labstack/echo"
 	"github.com/qtumproject/janus/pkg/eth"
 	"github.com/qtumproject/janus/pkg/qtum"
@@ -18,7 +19,7 @@ func (p *ProxyQTUMGenericStringArguments) Method() string {
 	return p.prefix + "_" + p.method
 }
 
-func (p *ProxyQTUMGenericStringArguments) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyQTUMGenericStringArguments) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var params eth.StringsArguments
 	if err := unmarshalRequest(req.Params, &params); err != nil {
 		// TODO: Correct error code?
@@ -22,7 +22,7 @@ func (p *ProxyQTUMGetUTXOs) Method() string {
 	return "qtum_getUTXOs"
 }
 
-func (p *ProxyQTUMGetUTXOs) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyQTUMGetUTXOs) Request(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	var params eth.GetUTXOsRequest
 	if err := unmarshalRequest(req.Params, &params); err != nil {
 		// TODO: Correct error code?
@@ -35,7 +35,7 @@ func (p *ProxyQTUMGetUTXOs) Request(req *eth.JSONRPCRequest, c echo.Context) (in
 		return nil, eth.NewInvalidParamsError("couldn't validate parameters value")
 	}
 
-	return p.request(c.Request().Context(), params)
+	return p.request(ctx, params)
 }
 
 func (p *ProxyQTUMGetUTXOs) request(ctx context.Context, params eth.GetUTXOsRequest) (*eth.GetUTXOsResponse, eth.JSONRPCError) {
@@ -50,7 +50,7 @@ func (p *ProxyQTUMGetUTXOs) request(ctx context.Context, params eth.GetUTXOsRequ
 
 	resp, err := p.Qtum.GetAddressUTXOs(ctx, &req)
 	if err != nil {
-		return nil, eth.NewCallbackError(err.Error())
+		return nil, qtumCallError(err)
 	}
 
 	blockCount, err := p.Qtum.GetBlockCount(ctx)
@@ -0,0 +1,301 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/qtum"
+	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/shopspring/decimal"
+)
+
+// Every method returning transactions translates them here, eth_getTransactionByHash, full blocks,
+// eth_getTransactionByBlock*AndIndex, traces and the receipts of transactions without a contract. A transaction is
+// translated from the chain's data only, so it looks the same whichever method returns it and whether qtumd found it
+// in its wallet or not
+
+// qtumTransaction is a transaction as qtumd found it
+type qtumTransaction struct {
+	hash string
+	hex  string
+	// empty while the transaction is in the mempool
+	blockHash string
+	// position in the block, -1 when it has to be looked up
+	blockIndex int64
+	// set by the wallet for coinbase and coinstake transactions
+	generated bool
+	// the transaction with the addresses and amounts of its inputs, nil when qtumd can't give it
+	raw *qtum.GetRawTransactionResponse
+}
+
+// getTransactionByHash translates the transaction with hash, nil when qtumd doesn't know it
+func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.GetTransactionByHashResponse, eth.JSONRPCError) {
+	tx, jsonErr := findTransaction(ctx, p, hash)
+	if jsonErr != nil || tx == nil {
+		return nil, jsonErr
+	}
+	return translateTransaction(ctx, p, tx)
+}
+
+// findTransaction asks the wallet first, it knows its own transactions even without -txindex
+func findTransaction(ctx context.Context, p *qtum.Qtum, hash string) (*qtumTransaction, eth.JSONRPCError) {
+	walletTx, err := p.GetTransaction(ctx, hash)
+	if err == nil {
+		tx := &qtumTransaction{
+			hash:       hash,
+			hex:        walletTx.Hex,
+			blockHash:  walletTx.BlockHash,
+			blockIndex: walletTx.BlockIndex,
+			generated:  walletTx.Generated,
+		}
+		if tx.raw, err = p.GetRawTransaction(ctx, hash, false); err != nil {
+			p.GetDebugLogger().Log("msg", "couldn't get raw transaction of wallet transaction", "hash", hash, "err", err)
+		}
+		return tx, nil
+	}
+	if errors.Cause(err) != qtum.ErrInvalidAddress {
+		p.GetDebugLogger().Log("msg", "Failed to GetTransaction", "hash", hash, "err", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	tx, err := findChainTransaction(ctx, p, hash)
+	if errors.Cause(err) == qtum.ErrInvalidAddress {
+		tx, err = findMempoolTransaction(ctx, p, hash)
+	}
+	if err != nil {
+		if errors.Cause(err) == qtum.ErrInvalidAddress {
+			return nil, nil
+		}
+		p.GetDebugLogger().Log("msg", "Failed to GetRawTransaction", "hash", hash, "err", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return tx, nil
+}
+
+// findChainTransaction looks the transaction up outside of the wallet
+func findChainTransaction(ctx context.Context, p *qtum.Qtum, hash string) (*qtumTransaction, error) {
+	rawTx, err := p.GetRawTransaction(ctx, hash, false)
+	if err != nil {
+		return nil, err
+	}
+	return &qtumTransaction{hash: hash, hex: rawTx.Hex, blockHash: rawTx.BlockHash, blockIndex: -1, raw: rawTx}, nil
+}
+
+// findMempoolTransaction finds a transaction that reached the mempool after qtumd was asked for it, like one broadcast
+// while the latest blocks were searched for it without -txindex. Pending transactions are returned without a block
+func findMempoolTransaction(ctx context.Context, p *qtum.Qtum, hash string) (*qtumTransaction, error) {
+	if _, err := p.GetMempoolEntry(ctx, hash); err != nil {
+		return nil, err
+	}
+	// qtumd looks in its mempool before the chain
+	return findChainTransaction(ctx, p, hash)
+}
+
+func translateTransaction(ctx context.Context, p *qtum.Qtum, tx *qtumTransaction) (*eth.GetTransactionByHashResponse, eth.JSONRPCError) {
+	decoded, err := p.DecodeRawTransaction(ctx, tx.hex)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "Failed to DecodeRawTransaction", "hex", tx.hex, "err", err)
+		return nil, eth.NewCallbackError("couldn't get raw transaction")
+	}
+
+	ethTx := &eth.GetTransactionByHashResponse{
+		Hash:  utils.AddHexPrefix(decoded.ID),
+		Nonce: "0x0",
+
+		// Added for go-ethereum client and graph-node support
+		R: "0xf000000000000000000000000000000000000000000000000000000000000000",
+		S: "0xf000000000000000000000000000000000000000000000000000000000000000",
+		V: "0x25",
+
+		Gas:      "0x0",
+		GasPrice: "0x0",
+	}
+
+	// otherwise, the block values must be nulls
+	if tx.blockHash != "" {
+		blockNumber, err := getBlockNumberByHash(ctx, p, tx.blockHash)
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "Failed to get block number by hash", "hash", tx.blockHash, "err", err)
+			return nil, eth.NewCallbackError("couldn't get block number by hash")
+		}
+		blockIndex := tx.blockIndex
+		if blockIndex < 0 {
+			if blockIndex, err = getTransactionIndexInBlock(ctx, p, tx.hash, tx.blockHash); err != nil {
+				p.GetDebugLogger().Log("msg", "Failed to get transaction index in block", "hash", tx.hash, "err", err)
+				return nil, eth.NewCallbackError("couldn't get transaction index in block")
+			}
+		}
+		ethTx.BlockNumber = hexutil.EncodeUint64(blockNumber)
+		ethTx.BlockHash = utils.AddHexPrefix(tx.blockHash)
+		ethTx.TransactionIndex = hexutil.EncodeUint64(uint64(blockIndex))
+	}
+
+	qtumTxContractInfo, isContractTx, _ := decoded.ExtractContractInfo()
+	// parsing err is discarded because it's not an error if the transaction is not a valid contract call
+	// https://testnet.qtum.info/tx/24ed3749022ed21e53d8924764bb0303a4b6fa469f26922bfa64ba44507c4c4a
+	if isContractTx {
+		// the value is what the contract output sends, the other outputs are change
+		ethTx.Value, err = formatQtumAmount(contractValue(decoded))
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "Couldn't format qtum amount", "qtum", contractValue(decoded).String(), "err", err)
+			return nil, eth.NewInvalidParamsError("couldn't format amount")
+		}
+		if qtumTxContractInfo.UserInput == "" {
+			ethTx.Input = "0x"
+		} else {
+			ethTx.Input = utils.AddHexPrefix(qtumTxContractInfo.UserInput)
+		}
+		if qtumTxContractInfo.From != "" {
+			ethTx.From = utils.AddHexPrefix(qtumTxContractInfo.From)
+		} else {
+			// It seems that ExtractContractInfo only looks for OP_SENDER address when assigning From field, so if none is present we handle it like for a non-contract TX
+			ethTx.From, err = getNonContractTxSenderAddress(ctx, p, decoded)
+			if err != nil {
+				p.GetDebugLogger().Log("msg", "Contract tx parsing found no sender address", "tx", decoded, "err", err)
+				return nil, eth.NewCallbackError("Contract tx parsing found no sender address, and the fallback function also failed: " + err.Error())
+			}
+		}
+		//TODO: research if 'To' adress could be other than zero address when 'isContractTx == TRUE'
+		if len(qtumTxContractInfo.To) == 0 {
+			ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+		} else {
+			ethTx.To = utils.AddHexPrefix(qtumTxContractInfo.To)
+		}
+
+		// gasLimit
+		if len(qtumTxContractInfo.GasLimit) == 0 {
+			qtumTxContractInfo.GasLimit = "0"
+		}
+		ethTx.Gas = utils.AddHexPrefix(qtumTxContractInfo.GasLimit)
+
+		gasPriceInSatoshis, err := contractGasPrice(qtumTxContractInfo)
+		if err != nil {
+			p.GetErrorLogger().Log("msg", "Failed to parse gasPrice: "+qtumTxContractInfo.GasPrice, "error", err.Error())
+			return ethTx, eth.NewCallbackError("Failed to parse gasPrice")
+		}
+		ethTx.GasPrice = hexutil.EncodeBig(satoshisToWei(gasPriceInSatoshis))
+
+		return ethTx, nil
+	}
+
+	if tx.generated || isGeneratedTransaction(decoded) {
+		// coinbase and coinstake transactions make the block, nobody sent them
+		ethTx.From = utils.AddHexPrefix(qtum.ZeroAddress)
+	} else {
+		ethTx.From, _ = getNonContractTxSenderAddress(ctx, p, decoded)
+		// TODO: discuss
+		// ? Does func above return incorrect address for graph-node (len is < 40)
+		// ! Temporary solution
+		if ethTx.From == "" {
+			ethTx.From = utils.AddHexPrefix(qtum.ZeroAddress)
+		}
+	}
+
+	value, to := transferValueAndRecipient(tx, decoded)
+	ethTx.Value = hexutil.EncodeBig(satoshisToWei(value))
+	ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+	if to != "" {
+		ethTx.To = recipientHexAddress(p, to)
+	}
+
+	ethTx.Input = utils.AddHexPrefix(tx.hex)
+
+	return ethTx, nil
+}
+
+// isGeneratedTransaction tells coinbase transactions, which spend no output, and coinstake transactions, whose first
+// output is empty, for transactions that aren't in the wallet
+func isGeneratedTransaction(decoded *qtum.DecodedRawTransactionResponse) bool {
+	if len(decoded.Vins) > 0 && decoded.Vins[0].TxID == "" {
+		return true
+	}
+	return len(decoded.Vins) > 0 && len(decoded.Vouts) > 1 &&
+		decoded.Vouts[0].Value.IsZero() && decoded.Vouts[0].ScriptPubKey.Hex == ""
+}
+
+// contractValue is the QTUM sent with the OP_CALL and OP_CREATE outputs of a transaction
+func contractValue(decoded *qtum.DecodedRawTransactionResponse) decimal.Decimal {
+	var value decimal.Decimal
+	for _, vout := range decoded.Vouts {
+		asm, err := qtum.DisasmScript(vout.ScriptPubKey.Hex)
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(asm, "OP_CALL") || strings.HasSuffix(asm, "OP_CREATE") {
+			value = value.Add(vout.Value)
+		}
+	}
+	return value
+}
+
+// transferValueAndRecipient is what a transaction without a contract sends, in satoshis, and the base58 address it
+// sends it to: the outputs to the first address besides the sender's. Outputs back to the sender are change
+func transferValueAndRecipient(tx *qtumTransaction, decoded *qtum.DecodedRawTransactionResponse) (*big.Int, string) {
+	type output struct {
+		address string
+		amount  int64
+	}
+	var sender string
+	var outputs []output
+	if tx.raw != nil {
+		if len(tx.raw.Vins) > 0 {
+			sender = tx.raw.Vins[0].Address
+		}
+		for _, vout := range tx.raw.Vouts {
+			var address string
+			if addresses := vout.Details.GetAddresses(); len(addresses) > 0 {
+				address = addresses[0]
+			}
+			outputs = append(outputs, output{address, vout.AmountSatoshi})
+		}
+	} else {
+		// without the inputs change can't be told apart, the first output with an address is taken
+		for _, vout := range decoded.Vouts {
+			var address string
+			if addresses := vout.ScriptPubKey.GetAddresses(); len(addresses) > 0 {
+				address = addresses[0]
+			}
+			outputs = append(outputs, output{address, vout.Value.Shift(8).IntPart()})
+		}
+	}
+
+	var to string
+	value := new(big.Int)
+	for _, out := range outputs {
+		if out.address == "" || out.address == sender {
+			continue
+		}
+		if to == "" {
+			to = out.address
+		}
+		if out.address == to {
+			value.Add(value, big.NewInt(out.amount))
+		}
+	}
+	return value, to
+}
+
+// recipientHexAddress converts the base58 address of a recipient, qtumd knows the address types Janus can't convert
+func recipientHexAddress(p *qtum.Qtum, address string) string {
+	hex, err := utils.ConvertQtumAddress(address)
+	if err != nil {
+		if hex, err = p.Base58AddressToHex(address); err != nil {
+			p.GetDebugLogger().Log("msg", "couldn't convert recipient address", "address", address, "err", err)
+			return utils.AddHexPrefix(qtum.ZeroAddress)
+		}
+	}
+	return utils.AddHexPrefix(hex)
+}
+
+// contractGasPrice is the gas price in satoshi a contract transaction's script offers, in hex with leading zeros
+func contractGasPrice(info qtum.ContractInfo) (*big.Int, error) {
+	gasPrice := strings.TrimLeft(info.GasPrice, "0")
+	if len(gasPrice) == 0 {
+		gasPrice = "0"
+	}
+	return utils.DecodeBig(gasPrice)
+}
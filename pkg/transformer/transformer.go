@@ -1,19 +1,31 @@
 package transformer
 
 import (
+	"context"
+	"strings"
+
 	"github.com/go-kit/kit/log"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
 	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/names"
 	"github.com/qtumproject/janus/pkg/notifier"
 	"github.com/qtumproject/janus/pkg/qtum"
 )
 
+// methods whose results are nothing but addresses
+var addressResultMethods = map[string]bool{
+	"eth_accounts": true,
+	"eth_coinbase": true,
+}
+
 type Transformer struct {
 	qtumClient   *qtum.Qtum
 	debugMode    bool
 	logger       log.Logger
 	transformers map[string]ETHProxy
+	// resolves names passed as addresses, nil when names aren't accepted
+	nameResolver names.Resolver
 }
 
 // New creates a new Transformer
@@ -60,18 +72,84 @@ func (t *Transformer) Register(p ETHProxy) error {
 }
 
 // Transform takes a Transformer and transforms the request from ETH request and returns the proxy request
-func (t *Transformer) Transform(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (t *Transformer) Transform(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+	resp, err := t.TransformUnchecksummed(ctx, req, c)
+	if err != nil {
+		return nil, err
+	}
+	return ChecksumResult(req.Method, resp), nil
+}
+
+// TransformUnchecksummed is Transform leaving the addresses of the result as the proxy returned them, for results that
+// are checksummed with ChecksumResult one part at a time while they are encoded
+func (t *Transformer) TransformUnchecksummed(ctx context.Context, req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	proxy, err := t.getProxy(req.Method)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := proxy.Request(req, c)
+	params, err := normalizeDataParams(req.Method, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	params, err = resolveAddressParams(ctx, t.nameResolver, req.Method, params)
 	if err != nil {
 		return nil, err
 	}
+	req.Params = params
+	if err := validateAddressChecksums(req.Method, req.Params); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	resp, err := proxy.Request(ctx, req, c)
+	if err != nil {
+		return nil, t.walletLockedError(t.startingError(ctx, t.busyError(err)))
+	}
 	return resp, nil
 }
 
+// ChecksumResult checksums the addresses of the result of method, or of a part of it like an entry of a list
+func ChecksumResult(method string, result interface{}) interface{} {
+	return eth.ChecksumResultAddresses(result, addressResultMethods[method])
+}
+
+// busyError tells clients to back off when the request failed because qtumd stayed busy through all retries
+func (t *Transformer) busyError(err eth.JSONRPCError) eth.JSONRPCError {
+	if !strings.Contains(err.Message(), qtum.ErrQtumWorkQueueDepth.Error()) {
+		return err
+	}
+	return eth.NewLimitExceededError(t.qtumClient.RetryAfter())
+}
+
+// startingError tells clients to come back later when the request failed because qtumd is still loading, rather
+// than passing on whatever error the proxy made of it. Only errors of qtumd failing are rewritten, invalid requests
+// stay invalid
+func (t *Transformer) startingError(ctx context.Context, err eth.JSONRPCError) eth.JSONRPCError {
+	if i := strings.Index(err.Message(), qtum.ErrQtumStarting.Error()+": "); i >= 0 {
+		status := err.Message()[i+len(qtum.ErrQtumStarting.Error())+2:]
+		return eth.NewStartingError(status, qtum.StartingRetryAfter)
+	}
+	if err.Code() != eth.CallbackErrorCode {
+		return err
+	}
+	// proxies that replaced the error with their own may still have failed because of it. qtumd may have loaded since
+	// it last answered that it is starting up, asking for its status clears the flag once it answers normally
+	if _, starting := t.qtumClient.Starting(); !starting {
+		return err
+	}
+	t.qtumClient.GetBlockChainInfo(ctx)
+	if status, starting := t.qtumClient.Starting(); starting {
+		return eth.NewStartingError(status, qtum.StartingRetryAfter)
+	}
+	return err
+}
+
+// walletLockedError tells clients that signing failed because qtumd's wallet is locked, whichever method it failed in
+func (t *Transformer) walletLockedError(err eth.JSONRPCError) eth.JSONRPCError {
+	if !strings.Contains(err.Message(), qtum.ErrWalletUnlockNeeded.Error()) {
+		return err
+	}
+	return eth.NewWalletLockedError()
+}
+
 func (t *Transformer) getProxy(method string) (ETHProxy, eth.JSONRPCError) {
 	proxy, ok := t.transformers[method]
 	if !ok {
@@ -80,6 +158,22 @@ func (t *Transformer) getProxy(method string) (ETHProxy, eth.JSONRPCError) {
 	return proxy, nil
 }
 
+// Handles reports whether method is registered
+func (t *Transformer) Handles(method string) bool {
+	_, ok := t.transformers[method]
+	return ok
+}
+
+// Filters returns the filters installed through eth_newFilter and eth_newBlockFilter, nil when those aren't registered
+func (t *Transformer) Filters() *eth.FilterSimulator {
+	for _, proxy := range t.transformers {
+		if p, ok := proxy.(interface{ filterSimulator() *eth.FilterSimulator }); ok {
+			return p.filterSimulator()
+		}
+	}
+	return nil
+}
+
 func (t *Transformer) IsDebugEnabled() bool {
 	return t.debugMode
 }
@@ -87,13 +181,19 @@ func (t *Transformer) IsDebugEnabled() bool {
 // DefaultProxies are the default proxy methods made available
 func DefaultProxies(qtumRPCClient *qtum.Qtum, agent *notifier.Agent) []ETHProxy {
 	filter := eth.NewFilterSimulator()
+	if store := qtumRPCClient.GetFilterStore(); store != nil {
+		filter = eth.NewSharedFilterSimulator(store)
+	}
 	getFilterChanges := &ProxyETHGetFilterChanges{Qtum: qtumRPCClient, filter: filter}
 	ethCall := &ProxyETHCall{Qtum: qtumRPCClient}
 
 	ethProxies := []ETHProxy{
 		ethCall,
 		&ProxyNetListening{Qtum: qtumRPCClient},
-		&ProxyETHPersonalUnlockAccount{},
+		&ProxyETHPersonalUnlockAccount{qtumRPCClient},
+		&ProxyETHPersonalSign{Qtum: qtumRPCClient},
+		&ProxyETHPersonalSendTransaction{Qtum: qtumRPCClient},
+		&ProxyETHPersonalListAccounts{Qtum: qtumRPCClient},
 		&ProxyETHChainId{Qtum: qtumRPCClient},
 		&ProxyETHBlockNumber{Qtum: qtumRPCClient},
 		&ProxyETHHashrate{Qtum: qtumRPCClient},
@@ -103,6 +203,7 @@ func DefaultProxies(qtumRPCClient *qtum.Qtum, agent *notifier.Agent) []ETHProxy
 		&ProxyETHGetTransactionByBlockNumberAndIndex{Qtum: qtumRPCClient},
 		&ProxyETHGetLogs{Qtum: qtumRPCClient},
 		&ProxyETHGetTransactionReceipt{Qtum: qtumRPCClient},
+		&ProxyETHGetBlockReceipts{Qtum: qtumRPCClient},
 		&ProxyETHSendTransaction{Qtum: qtumRPCClient},
 		&ProxyETHAccounts{Qtum: qtumRPCClient},
 		&ProxyETHGetCode{Qtum: qtumRPCClient},
@@ -118,6 +219,7 @@ func DefaultProxies(qtumRPCClient *qtum.Qtum, agent *notifier.Agent) []ETHProxy
 		&ProxyETHGetBlockByHash{Qtum: qtumRPCClient},
 		&ProxyETHGetBalance{Qtum: qtumRPCClient},
 		&ProxyETHGetStorageAt{Qtum: qtumRPCClient},
+		&ProxyETHGetProof{Qtum: qtumRPCClient},
 		&ETHGetCompilers{},
 		&ETHProtocolVersion{},
 		&ETHGetUncleByBlockHashAndIndex{},
@@ -126,7 +228,10 @@ func DefaultProxies(qtumRPCClient *qtum.Qtum, agent *notifier.Agent) []ETHProxy
 		&Web3ClientVersion{},
 		&Web3Sha3{},
 		&ProxyETHSign{Qtum: qtumRPCClient},
+		&ProxyETHSignTypedDataV4{Qtum: qtumRPCClient},
 		&ProxyETHGasPrice{Qtum: qtumRPCClient},
+		&ProxyETHFeeHistory{Qtum: qtumRPCClient},
+		&ProxyETHMaxPriorityFeePerGas{Qtum: qtumRPCClient},
 		&ProxyETHTxCount{Qtum: qtumRPCClient},
 		&ProxyETHSignTransaction{Qtum: qtumRPCClient},
 		&ProxyETHSendRawTransaction{Qtum: qtumRPCClient},
@@ -135,9 +240,40 @@ func DefaultProxies(qtumRPCClient *qtum.Qtum, agent *notifier.Agent) []ETHProxy
 		&ETHUnsubscribe{Qtum: qtumRPCClient, Agent: agent},
 
 		&ProxyQTUMGetUTXOs{Qtum: qtumRPCClient},
+		&ProxyJanusGetBalanceDetail{Qtum: qtumRPCClient},
+		&ProxyJanusListAccountsDetailed{Qtum: qtumRPCClient},
+		&ProxyJanusGetTransactionCost{Qtum: qtumRPCClient},
+		&ProxyJanusDeployContract{Qtum: qtumRPCClient},
+		&JanusComputeContractAddress{},
+		&ProxyJanusListFailedTransactions{Qtum: qtumRPCClient},
+		&ProxyJanusRebroadcastTransaction{Qtum: qtumRPCClient},
+		&ProxyJanusVerifyContract{Qtum: qtumRPCClient},
+		&ProxyJanusGetVerifiedContract{Qtum: qtumRPCClient},
+		&ProxyJanusRegisterABI{Qtum: qtumRPCClient},
+		&ProxyJanusGetABI{Qtum: qtumRPCClient},
+		&ProxyJanusRemoveABI{Qtum: qtumRPCClient},
+		&ProxyJanusGetMulticallAddress{Qtum: qtumRPCClient},
+		&ProxyJanusGetNetworkContracts{Qtum: qtumRPCClient},
 		&ProxyQTUMGenerateToAddress{Qtum: qtumRPCClient},
+		&ProxyDevMineBlocks{Qtum: qtumRPCClient},
+		&ProxyDevSetIntervalMining{Qtum: qtumRPCClient},
+		&ProxyDevPauseMining{Qtum: qtumRPCClient},
+		&ProxyDevResumeMining{Qtum: qtumRPCClient},
+		&ProxyDevSetMockTime{Qtum: qtumRPCClient},
+		&ProxyDevSetBlockTime{Qtum: qtumRPCClient},
+		&ProxyDevDumpState{Qtum: qtumRPCClient},
+		&ProxyDevLoadState{Qtum: qtumRPCClient},
+
+		&ProxyDebugTraceTransaction{Qtum: qtumRPCClient},
+		&ProxyDebugTraceBlockByNumber{Qtum: qtumRPCClient},
+		&ProxyDebugTraceBlockByHash{Qtum: qtumRPCClient},
+		&ProxyDebugStorageRangeAt{Qtum: qtumRPCClient},
+		&ProxyDebugTraceCall{Qtum: qtumRPCClient},
+		&ProxyETHCreateAccessList{Qtum: qtumRPCClient},
 
 		&ProxyNetPeerCount{Qtum: qtumRPCClient},
+		&ProxyJanusPeers{Qtum: qtumRPCClient},
+		&ProxyAdminPeers{ProxyJanusPeers{Qtum: qtumRPCClient}},
 	}
 
 	permittedQtumCalls := []string{
@@ -172,3 +308,11 @@ func SetLogger(l log.Logger) func(*Transformer) error {
 		return nil
 	}
 }
+
+// SetNameResolver resolves the names clients pass instead of addresses to the methods taking addresses
+func SetNameResolver(resolver names.Resolver) func(*Transformer) error {
+	return func(t *Transformer) error {
+		t.nameResolver = resolver
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/qtumproject/janus/pkg/eth"
+	"github.com/qtumproject/janus/pkg/internal"
+	"github.com/qtumproject/janus/pkg/qtum"
+)
+
+func TestBusyQtumdReturnsLimitExceeded(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// qtumd stays busy through every retry
+	mockedClientDoer.AddRawResponse(qtum.MethodGetBlockCount, []byte(qtum.ErrQtumWorkQueueDepth.Error()))
+
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "eth_blockNumber"
+
+	_, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.LimitExceededErrorCode {
+		t.Fatalf("expected a limit exceeded error, got %v", jsonErr)
+	}
+	data := jsonErr.(*eth.GenericJSONRPCError).Data().(eth.LimitExceededData)
+	if data.RetryAfterMs != qtumClient.RetryAfter().Milliseconds() || data.RetryAfterMs <= 0 {
+		t.Errorf("unexpected retry after %dms", data.RetryAfterMs)
+	}
+}
+
+func TestStartingQtumdReturnsStartingError(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// qtumd answers with its loading status while it reindexes
+	mockedClientDoer.AddError(qtum.MethodGetBlockCount, eth.NewJSONRPCError(-28, "Loading block index...", nil))
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(11284900)})
+
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "eth_blockNumber"
+
+	_, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.ResourceUnavailableErrorCode {
+		t.Fatalf("expected a starting up error, got %v", jsonErr)
+	}
+	data := jsonErr.(*eth.GenericJSONRPCError).Data().(eth.StartingData)
+	if data.Status != "Loading block index..." || data.RetryAfterMs != qtum.StartingRetryAfter.Milliseconds() {
+		t.Errorf("unexpected starting data %+v", data)
+	}
+	if status, starting := qtumClient.Starting(); !starting || status != data.Status {
+		t.Errorf("expected the client to report qtumd starting up, got %q", status)
+	}
+
+	// once qtumd has loaded requests go through again
+	if _, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext()); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if _, starting := qtumClient.Starting(); starting {
+		t.Error("expected the client to stop reporting qtumd starting up")
+	}
+}
+
+func TestStartingErrorOnlyForQtumdFailures(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddError(qtum.MethodGetBlockCount, eth.NewJSONRPCError(-28, "Loading block index...", nil))
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockChainInfo, qtum.GetBlockChainInfoResponse{Blocks: 4000})
+
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform := func(method string, params string) eth.JSONRPCError {
+		request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Method = method
+		request.Params = json.RawMessage(params)
+		_, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+		return jsonErr
+	}
+
+	if jsonErr := transform("eth_blockNumber", `[]`); jsonErr == nil || jsonErr.Code() != eth.ResourceUnavailableErrorCode {
+		t.Fatalf("expected a starting up error, got %v", jsonErr)
+	}
+	// invalid requests stay invalid while qtumd is starting up
+	if jsonErr := transform("eth_getBlockByNumber", `["f8f", false]`); jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected an invalid params error, got %v", jsonErr)
+	}
+	// a proxy's own error for qtumd failing is checked against qtumd's status, which it answers normally again
+	if _, starting := qtumClient.Starting(); !starting {
+		t.Fatal("expected the client to report qtumd starting up")
+	}
+	if jsonErr := proxyTransformer.startingError(context.Background(), eth.NewCallbackError("couldn't get block header")); jsonErr.Code() != eth.CallbackErrorCode {
+		t.Errorf("expected the proxy's error, got %v", jsonErr)
+	}
+	if _, starting := qtumClient.Starting(); starting {
+		t.Error("expected the client to stop reporting qtumd starting up")
+	}
+}
+
+func TestLockedWalletReturnsWalletLockedError(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddError(qtum.MethodGetBlockCount, eth.NewJSONRPCError(-13, "Error: Please enter the wallet passphrase with walletpassphrase first.", nil))
+
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "eth_blockNumber"
+
+	_, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.UnauthorizedErrorCode {
+		t.Errorf("expected a wallet locked error, got %v", jsonErr)
+	}
+}
+
+func TestLockedWalletIsUnlocked(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	qtumClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookups := 0
+	passphrase := func(ctx context.Context) (string, error) {
+		lookups++
+		return "secret", nil
+	}
+	if err := qtum.SetWalletPassphrase(passphrase, time.Minute)(qtumClient.Client); err != nil {
+		t.Fatal(err)
+	}
+	mockedClientDoer.AddError(qtum.MethodGetBlockCount, eth.NewJSONRPCError(-13, "Error: Please enter the wallet passphrase with walletpassphrase first.", nil))
+	mockedClientDoer.AddResponse(qtum.MethodGetBlockCount, qtum.GetBlockCountResponse{Int: big.NewInt(11284900)})
+	mockedClientDoer.AddRawResponse(qtum.MethodWalletPassphrase, []byte(`{"result":null,"error":null,"id":1}`))
+
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "eth_blockNumber"
+
+	got, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want := eth.BlockNumberResponse("0xac31a4")
+	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
+	if lookups != 1 {
+		t.Errorf("expected the passphrase to be looked up once, got %d lookups", lookups)
+	}
+}
+
+func TestTransformRejectsBadAddressChecksums(t *testing.T) {
+	qtumClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyTransformer, err := New(qtumClient, DefaultProxies(qtumClient, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"`), []byte(`"latest"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "eth_getBalance"
+
+	_, jsonErr := proxyTransformer.Transform(context.Background(), request, internal.NewEchoContext())
+	if jsonErr == nil || jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Errorf("expected an invalid params error, got %v", jsonErr)
+	}
+}
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"errors"
 
 	"github.com/labstack/echo"
@@ -11,7 +12,8 @@ var UnmarshalRequestErr = errors.New("Input is invalid")
 
 type Option func(*Transformer) error
 
+// ETHProxy handles a single eth method, ctx is cancelled when the client goes away or the request times out
 type ETHProxy interface {
-	Request(*eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError)
+	Request(context.Context, *eth.JSONRPCRequest, echo.Context) (interface{}, eth.JSONRPCError)
 	Method() string
 }
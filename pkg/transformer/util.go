@@ -236,30 +236,6 @@ func searchSenderAddressInPreviousTransactions(ctx context.Context, p *qtum.Qtum
 	return "", errors.New("couldn't find sender address")
 }
 
-// NOTE:
-//
-//   - is not for reward transactions
-//
-//   - returning address already has 0x prefix
-//
-//     TODO: researching
-//
-//   - Vout[0].Addresses[i] != "" - temporary solution
-func findNonContractTxReceiverAddress(vouts []*qtum.DecodedRawTransactionOutV) (string, error) {
-	for _, vout := range vouts {
-		for _, address := range vout.ScriptPubKey.Addresses {
-			if address != "" {
-				hex, err := utils.ConvertQtumAddress(address)
-				if err != nil {
-					return "", err
-				}
-				return utils.AddHexPrefix(hex), nil
-			}
-		}
-	}
-	return "", errors.New("not found")
-}
-
 func getBlockNumberByHash(ctx context.Context, p *qtum.Qtum, hash string) (uint64, error) {
 	block, err := p.GetBlock(ctx, hash)
 	if err != nil {
@@ -295,75 +271,32 @@ func formatQtumNonce(nonce int) string {
 	return "0x" + hexedNonce
 }
 
-// Returns Qtum block number. Result depends on a passed raw param. Raw param's slice of bytes should
-// has one of the following values:
-//   - hex string representation of a number of a specific block
-//   - integer - returns the value
-//   - string "latest" - for the latest mined block
-//   - string "earliest" for the genesis block
-//   - string "pending" - for the pending state/transactions
-//
-// Uses defaultVal to differntiate from a eth_getBlockByNumber req and eth_getLogs/eth_newFilter
-func getBlockNumberByRawParam(ctx context.Context, p *qtum.Qtum, rawParam json.RawMessage, defaultVal bool) (*big.Int, eth.JSONRPCError) {
-	var param string
-	if isBytesOfString(rawParam) {
-		param = string(rawParam[1 : len(rawParam)-1]) // trim \" runes
-	} else {
-		integer, err := strconv.ParseInt(string(rawParam), 10, 64)
-		if err == nil {
-			return big.NewInt(integer), nil
+// Returns the block "latest" refers to, which is the chain tip minus the configured number of confirmations
+func getLatestBlockNumber(ctx context.Context, p *qtum.Qtum) (*big.Int, eth.JSONRPCError) {
+	res, err := p.GetBlockChainInfo(ctx)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	latest := res.Blocks
+	if confirmations := p.GetFlagInt(qtum.FLAG_LATEST_CONFIRMATIONS); confirmations != nil {
+		latest -= int64(*confirmations)
+		if latest < 0 {
+			latest = 0
 		}
-		return nil, eth.NewInvalidParamsError("invalid parameter format - string or integer is expected")
 	}
-
-	return getBlockNumberByParam(ctx, p, param, defaultVal)
+	return big.NewInt(latest), nil
 }
 
-func getBlockNumberByParam(ctx context.Context, p *qtum.Qtum, param string, defaultVal bool) (*big.Int, eth.JSONRPCError) {
-	if len(param) < 1 {
-		if defaultVal {
-			res, err := p.GetBlockChainInfo(ctx)
-			if err != nil {
-				return nil, eth.NewCallbackError(err.Error())
-			}
-			p.GetDebugLogger().Log("function", "getBlockNumberByParam", "msg", "returning default value ("+strconv.Itoa(int(res.Blocks))+")")
-			return big.NewInt(res.Blocks), nil
-		} else {
-			return nil, eth.NewInvalidParamsError("empty parameter value")
-		}
-
+// Reports whether a block is deep enough in the chain to be visible through "latest"
+func isBlockConfirmed(ctx context.Context, p *qtum.Qtum, blockNumber *big.Int) (bool, eth.JSONRPCError) {
+	if p.GetFlagInt(qtum.FLAG_LATEST_CONFIRMATIONS) == nil {
+		return true, nil
 	}
-
-	switch param {
-	case "latest":
-		res, err := p.GetBlockChainInfo(ctx)
-		if err != nil {
-			return nil, eth.NewCallbackError(err.Error())
-		}
-		p.GetDebugLogger().Log("latest", res.Blocks, "msg", "Got latest block")
-		return big.NewInt(res.Blocks), nil
-
-	case "earliest":
-		// TODO: discuss
-		// ! Genesis block cannot be retreived
-		return big.NewInt(0), nil
-
-	case "pending":
-		// TODO: discuss
-		// 	! Researching
-		return nil, eth.NewInvalidRequestError("TODO: tag is in implementation")
-
-	default: // hex number
-		if !strings.HasPrefix(param, "0x") {
-			return nil, eth.NewInvalidParamsError("quantity values must start with 0x")
-		}
-		n, err := utils.DecodeBig(param)
-		if err != nil {
-			p.GetDebugLogger().Log("function", "getBlockNumberByParam", "msg", "Failed to decode hex parameter", "value", param)
-			return nil, eth.NewInvalidParamsError("couldn't decode hex number to big int")
-		}
-		return n, nil
+	latest, err := getLatestBlockNumber(ctx, p)
+	if err != nil {
+		return false, err
 	}
+	return blockNumber.Cmp(latest) <= 0, nil
 }
 
 func isBytesOfString(v json.RawMessage) bool {
@@ -416,7 +349,7 @@ func convertETHAddress(address string, chain string) (qtumAddress string, _ erro
 	return base58.Encode(qtumAddressBytes), nil
 }
 
-func processFilter(p *ProxyETHGetFilterChanges, rawreq *eth.JSONRPCRequest) (*eth.Filter, eth.JSONRPCError) {
+func processFilter(ctx context.Context, p *ProxyETHGetFilterChanges, rawreq *eth.JSONRPCRequest) (*eth.Filter, eth.JSONRPCError) {
 	var req eth.GetFilterChangesRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
 		// TODO: Correct error code?
@@ -428,11 +361,13 @@ func processFilter(p *ProxyETHGetFilterChanges, rawreq *eth.JSONRPCRequest) (*et
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	_filter, ok := p.filter.Filter(filterID)
+	filter, ok, err := p.filter.Filter(ctx, filterID)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
 	if !ok {
 		return nil, eth.NewCallbackError("Invalid filter id")
 	}
-	filter := _filter.(*eth.Filter)
 
 	return filter, nil
 }
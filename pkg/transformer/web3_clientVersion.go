@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"runtime"
 
 	"github.com/labstack/echo"
@@ -17,7 +18,7 @@ func (p *Web3ClientVersion) Method() string {
 	return "web3_clientVersion"
 }
 
-func (p *Web3ClientVersion) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *Web3ClientVersion) Request(ctx context.Context, _ *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
 	return "Janus/" + params.VersionWithGitSha + "/" + runtime.GOOS + "-" + runtime.GOARCH + "/" + runtime.Version(), nil
 }
 
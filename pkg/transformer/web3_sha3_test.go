@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -21,7 +22,7 @@ func TestWeb3Sha3Request(t *testing.T) {
 		}
 
 		web3Sha3 := Web3Sha3{}
-		got, jsonErr := web3Sha3.Request(request, nil)
+		got, jsonErr := web3Sha3.Request(context.Background(), request, nil)
 		if jsonErr != nil {
 			t.Fatal(jsonErr)
 		}
@@ -43,7 +44,7 @@ func testWeb3Sha3Errors(t *testing.T, input []json.RawMessage, want string) {
 	}
 
 	web3Sha3 := Web3Sha3{}
-	_, jsonErr := web3Sha3.Request(request, nil)
+	_, jsonErr := web3Sha3.Request(context.Background(), request, nil)
 	got := jsonErr.Message()
 
 	// TODO: Expand to also check for correct error code?
@@ -1,10 +1,12 @@
 package utils
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"math/big"
 	"strings"
 
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/base58"
 	// "github.com/decred/base58"
 	"github.com/ethereum/go-ethereum/common"
@@ -62,3 +64,23 @@ func ConvertQtumAddress(address string) (ethAddress string, _ error) {
 
 	return hex.EncodeToString(ethAddrBytes), nil
 }
+
+// ComputeContractAddress returns the address Qtum assigns to a contract created by output vout of transaction txid
+// it is hash160 of the txid (in its serialized, little endian byte order) followed by vout as a little endian uint32
+func ComputeContractAddress(txid string, vout uint32) (string, error) {
+	txidBytes, err := hex.DecodeString(RemoveHexPrefix(txid))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid txid")
+	}
+	if len(txidBytes) != 32 {
+		return "", errors.Errorf("invalid txid: length is %d bytes instead of 32", len(txidBytes))
+	}
+
+	outpoint := make([]byte, 36)
+	for i, b := range txidBytes {
+		outpoint[31-i] = b
+	}
+	binary.LittleEndian.PutUint32(outpoint[32:], vout)
+
+	return hex.EncodeToString(btcutil.Hash160(outpoint)), nil
+}
@@ -33,3 +33,29 @@ func TestConvertQtumAddress(t *testing.T) {
 	}
 
 }
+
+func TestComputeContractAddress(t *testing.T) {
+	var tests = []struct {
+		txid string
+		vout uint32
+		want string
+	}{
+		// createcontract example from the Qtum RPC documentation
+		{"d0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f", 0, "c89a5d225f578d84a94741490c1b40889b4f7a00"},
+		{"0xd0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f", 1, "db62733c2b77ea9440db15f901032b4c233d5abf"},
+	}
+
+	for _, tt := range tests {
+		got, err := ComputeContractAddress(tt.txid, tt.vout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+
+	if _, err := ComputeContractAddress("d0fe0caa", 0); err == nil {
+		t.Error("expected an error for a short txid")
+	}
+}
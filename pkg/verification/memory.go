@@ -0,0 +1,42 @@
+package verification
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps verified contracts in memory, for tests and embedders without a database
+type MemoryStore struct {
+	mutex     sync.Mutex
+	contracts map[string]Contract
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{contracts: make(map[string]Contract)}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, contract Contract) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	address := strings.ToLower(contract.Address)
+	if saved, ok := s.contracts[address]; ok && saved.ExactMatch && !contract.ExactMatch {
+		return nil
+	}
+	s.contracts[address] = contract
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, address string) (*Contract, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	contract, ok := s.contracts[strings.ToLower(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &contract, nil
+}
@@ -0,0 +1,256 @@
+package verification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// solc's standard JSON interface, https://docs.soliditylang.org/en/latest/using-the-compiler.html#compiler-input-and-output-json-description
+type standardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]standardSource `json:"sources"`
+	Settings standardSettings          `json:"settings"`
+}
+
+type standardSource struct {
+	Content string `json:"content"`
+}
+
+type standardSettings struct {
+	Optimizer       standardOptimizer              `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+type standardOptimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+type standardOutput struct {
+	Errors    []standardError                                `json:"errors"`
+	Contracts map[string]map[string]standardCompiledContract `json:"contracts"`
+	// only set by compiler APIs
+	Version string `json:"version"`
+}
+
+type standardError struct {
+	Type             string `json:"type"`
+	Severity         string `json:"severity"`
+	Message          string `json:"message"`
+	FormattedMessage string `json:"formattedMessage"`
+}
+
+type standardCompiledContract struct {
+	ABI      json.RawMessage `json:"abi"`
+	Metadata string          `json:"metadata"`
+	EVM      struct {
+		DeployedBytecode struct {
+			Object              string             `json:"object"`
+			ImmutableReferences map[string][]Range `json:"immutableReferences"`
+		} `json:"deployedBytecode"`
+	} `json:"evm"`
+}
+
+func newStandardInput(input Input) standardInput {
+	sources := make(map[string]standardSource, len(input.Sources))
+	for name, content := range input.Sources {
+		sources[name] = standardSource{Content: content}
+	}
+	runs := input.Runs
+	if runs == 0 {
+		runs = 200
+	}
+	return standardInput{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: standardSettings{
+			Optimizer:  standardOptimizer{Enabled: input.Optimize, Runs: runs},
+			EVMVersion: input.EVMVersion,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": {"abi", "metadata", "evm.deployedBytecode.object", "evm.deployedBytecode.immutableReferences"}},
+			},
+		},
+	}
+}
+
+// pick finds the contract to compare in the compiler output
+func (output *standardOutput) pick(contractName string) (*Output, error) {
+	// only the messages are passed on, the formatted ones quote the sources as the compiler sees them
+	var messages []string
+	for _, compileErr := range output.Errors {
+		if compileErr.Severity == "error" {
+			messages = append(messages, strings.TrimSpace(compileErr.Type+": "+compileErr.Message))
+		}
+	}
+	if len(messages) != 0 {
+		return nil, inputErrorf("compilation failed: %s", strings.Join(messages, "; "))
+	}
+
+	file, name := "", contractName
+	if i := strings.LastIndex(contractName, ":"); i != -1 {
+		file, name = contractName[:i], contractName[i+1:]
+	}
+	var matches []string
+	for sourceName, contracts := range output.Contracts {
+		if _, ok := contracts[name]; ok && (file == "" || file == sourceName) {
+			matches = append(matches, sourceName)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, inputErrorf("contract %s not found in the sources", contractName)
+	case 1:
+	default:
+		sort.Strings(matches)
+		return nil, inputErrorf("contract %s is declared in %s, name one with file:%s", name, strings.Join(matches, ", "), name)
+	}
+
+	compiled := output.Contracts[matches[0]][name]
+	bytecode := compiled.EVM.DeployedBytecode.Object
+	if strings.Contains(bytecode, "__") {
+		return nil, inputErrorf("contract %s uses external libraries, which aren't supported", contractName)
+	}
+	var immutables []Range
+	for _, references := range compiled.EVM.DeployedBytecode.ImmutableReferences {
+		immutables = append(immutables, references...)
+	}
+	return &Output{
+		ContractName:        matches[0] + ":" + name,
+		CompilerVersion:     output.Version,
+		RuntimeBytecode:     bytecode,
+		ImmutableReferences: immutables,
+		ABI:                 compiled.ABI,
+		Metadata:            compiled.Metadata,
+	}, nil
+}
+
+var solcVersionPattern = regexp.MustCompile(`Version: ([0-9]+\.[0-9]+\.[0-9]+(\+commit\.[0-9a-f]+)?)`)
+
+// SolcBinary compiles with a solc binary installed next to Janus, which only offers its own version
+type SolcBinary struct {
+	Path string
+}
+
+var _ Compiler = (*SolcBinary)(nil)
+
+func (s *SolcBinary) Compile(ctx context.Context, input Input) (*Output, error) {
+	version, err := s.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if input.CompilerVersion != "" && !strings.HasPrefix(version, strings.TrimPrefix(input.CompilerVersion, "v")) {
+		return nil, inputErrorf("compiler version %s requested, solc is %s", input.CompilerVersion, version)
+	}
+
+	encoded, err := json.Marshal(newStandardInput(input))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// solc resolves imports missing from the sources from its base path, an empty directory keeps it from reading the
+	// files of the host
+	dir, err := ioutil.TempDir("", "janus-solc")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create solc directory")
+	}
+	defer os.RemoveAll(dir)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Path, "--standard-json", "--base-path", dir)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(encoded)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "solc failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var output standardOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode solc output")
+	}
+	output.Version = "v" + version
+	return output.pick(input.ContractName)
+}
+
+func (s *SolcBinary) version(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, s.Path, "--version").Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "couldn't run %s", s.Path)
+	}
+	match := solcVersionPattern.FindStringSubmatch(string(out))
+	if match == nil {
+		return "", errors.Errorf("unexpected solc --version output %q", strings.TrimSpace(string(out)))
+	}
+	return match[1], nil
+}
+
+// SolcAPI compiles with a compilation service, which can offer every solc version. The service is POSTed
+// {"version": "0.8.17", "input": <standard JSON input>} and answers with solc's standard JSON output, with the
+// exact compiler version used in a "version" field
+type SolcAPI struct {
+	URL    string
+	Client *http.Client
+}
+
+var _ Compiler = (*SolcAPI)(nil)
+
+type solcAPIRequest struct {
+	Version string        `json:"version,omitempty"`
+	Input   standardInput `json:"input"`
+}
+
+func (s *SolcAPI) Compile(ctx context.Context, input Input) (*Output, error) {
+	encoded, err := json.Marshal(solcAPIRequest{Version: input.CompilerVersion, Input: newStandardInput(input)})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't reach the compiler API")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read the compiler API response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("compiler API answered %s: %s", resp.Status, abbreviate(strings.TrimSpace(string(body))))
+	}
+
+	var output standardOutput
+	if err := json.Unmarshal(body, &output); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode the compiler API response")
+	}
+	if output.Version == "" {
+		output.Version = input.CompilerVersion
+	}
+	return output.pick(input.ContractName)
+}
+
+// keeps error messages short for services answering with whole HTML pages
+func abbreviate(body string) string {
+	if len(body) <= 200 {
+		return body
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:200], len(body))
+}
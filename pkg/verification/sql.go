@@ -0,0 +1,127 @@
+package verification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS janus_verified_contracts (
+	address TEXT PRIMARY KEY,
+	contract_name TEXT NOT NULL,
+	compiler_version TEXT NOT NULL,
+	optimize BOOLEAN NOT NULL,
+	runs INTEGER NOT NULL,
+	evm_version TEXT NOT NULL,
+	sources TEXT NOT NULL,
+	abi TEXT NOT NULL,
+	metadata TEXT NOT NULL,
+	exact_match BOOLEAN NOT NULL,
+	verified_at TIMESTAMPTZ NOT NULL
+)`
+
+// SQLStore keeps verified contracts in the postgres database Janus is configured with
+type SQLStore struct {
+	db *sql.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+var _ Store = (*SQLStore)(nil)
+
+// Open sets up the store, the table is created on first use so Janus can start while the database is down
+func Open(ctx context.Context, connectionString string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open verified contracts database")
+	}
+	s := &SQLStore{db: db}
+	// failing here is fine, the next use tries again
+	s.migrate(ctx)
+	return s, nil
+}
+
+func (s *SQLStore) migrate(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.migrated {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, createTable); err != nil {
+		return errors.Wrap(err, "couldn't create verified contracts table")
+	}
+	s.migrated = true
+	return nil
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLStore) Save(ctx context.Context, contract Contract) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+	sources, err := json.Marshal(contract.Sources)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO janus_verified_contracts (address, contract_name, compiler_version, optimize, runs, evm_version, sources, abi, metadata, exact_match, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (address) DO UPDATE SET
+			contract_name = EXCLUDED.contract_name,
+			compiler_version = EXCLUDED.compiler_version,
+			optimize = EXCLUDED.optimize,
+			runs = EXCLUDED.runs,
+			evm_version = EXCLUDED.evm_version,
+			sources = EXCLUDED.sources,
+			abi = EXCLUDED.abi,
+			metadata = EXCLUDED.metadata,
+			exact_match = EXCLUDED.exact_match,
+			verified_at = EXCLUDED.verified_at
+		WHERE EXCLUDED.exact_match OR NOT janus_verified_contracts.exact_match`,
+		strings.ToLower(contract.Address), contract.ContractName, contract.CompilerVersion, contract.Optimize, contract.Runs,
+		contract.EVMVersion, string(sources), string(contract.ABI), contract.Metadata, contract.ExactMatch, contract.VerifiedAt,
+	)
+	return errors.Wrap(err, "couldn't save verified contract")
+}
+
+func (s *SQLStore) Get(ctx context.Context, address string) (*Contract, error) {
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+	var (
+		contract Contract
+		sources  string
+		abi      string
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT address, contract_name, compiler_version, optimize, runs, evm_version, sources, abi, metadata, exact_match, verified_at
+		FROM janus_verified_contracts WHERE address = $1`,
+		strings.ToLower(address),
+	).Scan(
+		&contract.Address, &contract.ContractName, &contract.CompilerVersion, &contract.Optimize, &contract.Runs,
+		&contract.EVMVersion, &sources, &abi, &contract.Metadata, &contract.ExactMatch, &contract.VerifiedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get verified contract")
+	}
+	if err := json.Unmarshal([]byte(sources), &contract.Sources); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode verified contract sources")
+	}
+	contract.ABI = json.RawMessage(abi)
+	return &contract, nil
+}
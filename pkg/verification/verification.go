@@ -0,0 +1,169 @@
+package verification
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("contract hasn't been verified")
+
+// InputError is a problem with what was asked to be compiled, like an error in the sources or a contract missing from
+// them, that can be shown to whoever asked. Other errors of a compiler are about the compiler itself
+type InputError struct {
+	message string
+}
+
+func (e *InputError) Error() string {
+	return e.message
+}
+
+func inputErrorf(format string, args ...interface{}) error {
+	return &InputError{message: fmt.Sprintf(format, args...)}
+}
+
+// Contract is the verified source of a deployed contract, as explorers show it
+type Contract struct {
+	// hex address without 0x, lowercase
+	Address         string `json:"address"`
+	ContractName    string `json:"contractName"`
+	CompilerVersion string `json:"compilerVersion"`
+	Optimize        bool   `json:"optimize"`
+	Runs            int    `json:"runs"`
+	EVMVersion      string `json:"evmVersion,omitempty"`
+	// source file name -> content
+	Sources  map[string]string `json:"sources"`
+	ABI      json.RawMessage   `json:"abi"`
+	Metadata string            `json:"metadata,omitempty"`
+	// the metadata hash matched as well, not only the code
+	ExactMatch bool      `json:"exactMatch"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// Store keeps verified contracts for later retrieval
+type Store interface {
+	// Save adds a verified contract, replacing an earlier verification of the same address unless that one matched
+	// exactly and this one doesn't
+	Save(ctx context.Context, contract Contract) error
+	Get(ctx context.Context, address string) (*Contract, error)
+}
+
+// Input describes what to compile
+type Input struct {
+	// source file name -> content
+	Sources map[string]string
+	// the contract to compare, "Name" or "file.sol:Name" when several files declare the name
+	ContractName string
+	// solc version such as 0.8.17 or v0.8.17+commit.8df45f5f, empty for whatever the compiler is
+	CompilerVersion string
+	Optimize        bool
+	Runs            int
+	EVMVersion      string
+}
+
+// Output is the compiled contract picked by Input.ContractName
+type Output struct {
+	// file.sol:Name
+	ContractName    string
+	CompilerVersion string
+	// hex without 0x
+	RuntimeBytecode string
+	// byte ranges of the runtime bytecode set by the constructor
+	ImmutableReferences []Range
+	ABI                 json.RawMessage
+	Metadata            string
+}
+
+type Range struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Compiler compiles Solidity sources
+type Compiler interface {
+	Compile(ctx context.Context, input Input) (*Output, error)
+}
+
+// Verifier is what janus_verifyContract needs, the compiler and where to keep the results
+type Verifier struct {
+	Compiler Compiler
+	Store    Store
+}
+
+// Result is what Verify found out about a deployed contract
+type Result struct {
+	Output *Output
+	// the contract to keep, nil when the compiled code doesn't match the deployed code
+	Contract *Contract
+}
+
+// Verify compiles input and compares it with onChain, the runtime code deployed at the hex address
+func (v *Verifier) Verify(ctx context.Context, address string, onChain []byte, input Input) (*Result, error) {
+	compiled, err := v.Compiler.Compile(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	compiledCode, err := hex.DecodeString(strings.TrimPrefix(compiled.RuntimeBytecode, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode compiled bytecode: %w", err)
+	}
+
+	result := &Result{Output: compiled}
+	matches, exact := Match(onChain, compiledCode, compiled.ImmutableReferences)
+	if !matches {
+		return result, nil
+	}
+	result.Contract = &Contract{
+		Address:         strings.ToLower(strings.TrimPrefix(address, "0x")),
+		ContractName:    compiled.ContractName,
+		CompilerVersion: compiled.CompilerVersion,
+		Optimize:        input.Optimize,
+		Runs:            input.Runs,
+		EVMVersion:      input.EVMVersion,
+		Sources:         input.Sources,
+		ABI:             compiled.ABI,
+		Metadata:        compiled.Metadata,
+		ExactMatch:      exact,
+		VerifiedAt:      time.Now().UTC(),
+	}
+	return result, nil
+}
+
+// Match compares on-chain runtime code with compiled runtime code. Solidity appends a CBOR encoded metadata hash to
+// the code, which changes with comments and file names, so the code matches when everything but the metadata does.
+// exact tells whether the metadata matched as well
+func Match(onChain []byte, compiled []byte, immutables []Range) (matches bool, exact bool) {
+	if len(onChain) != len(compiled) || len(onChain) == 0 {
+		return false, false
+	}
+	// immutable values are only known once the constructor ran, solc leaves zeros in their place
+	onChain = append([]byte{}, onChain...)
+	for _, immutable := range immutables {
+		if immutable.Start < 0 || immutable.Start+immutable.Length > len(onChain) {
+			return false, false
+		}
+		copy(onChain[immutable.Start:immutable.Start+immutable.Length], compiled[immutable.Start:immutable.Start+immutable.Length])
+	}
+	if bytes.Equal(onChain, compiled) {
+		return true, true
+	}
+	return bytes.Equal(stripMetadata(onChain), stripMetadata(compiled)), false
+}
+
+// stripMetadata removes the CBOR metadata, whose length is in the last two bytes of the code
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	// a CBOR map of one to fifteen items starts with 0xa1 to 0xaf
+	if length+2 > len(code) || length == 0 || code[len(code)-2-length]&0xf0 != 0xa0 {
+		return code
+	}
+	return code[:len(code)-2-length]
+}
@@ -0,0 +1,142 @@
+package verification
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubCompiler returns the same runtime code whatever it is given
+type stubCompiler struct {
+	runtimeBytecode string
+	err             error
+}
+
+func (c *stubCompiler) Compile(ctx context.Context, input Input) (*Output, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Output{
+		ContractName:    "contract.sol:" + input.ContractName,
+		CompilerVersion: "v0.8.17+commit.8df45f5f",
+		RuntimeBytecode: c.runtimeBytecode,
+		ABI:             json.RawMessage(`[]`),
+	}, nil
+}
+
+const (
+	testCode = "6080604052600080fd"
+	// a CBOR map followed by its length
+	testMetadata      = "a1626161010005"
+	otherTestMetadata = "a1626161020005"
+)
+
+func TestVerify(t *testing.T) {
+	onChain := mustDecodeHex(t, testCode+testMetadata)
+	tests := []struct {
+		name     string
+		compiled string
+		verified bool
+		exact    bool
+	}{
+		{"exact", testCode + testMetadata, true, true},
+		// comments and file names change the metadata hash
+		{"partial", testCode + otherTestMetadata, true, false},
+		{"mismatch", "6080604052600180fd" + testMetadata, false, false},
+	}
+	for _, test := range tests {
+		verifier := &Verifier{Compiler: &stubCompiler{runtimeBytecode: test.compiled}, Store: NewMemoryStore()}
+		result, err := verifier.Verify(context.Background(), "0x1E6F89D7399081B4F8F8AA1AE2805A5EFFF2F960", onChain, Input{ContractName: "Token", Optimize: true})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if result.Output.ContractName != "contract.sol:Token" {
+			t.Errorf("%s: expected the compiled contract, got %+v", test.name, result.Output)
+		}
+		if (result.Contract != nil) != test.verified {
+			t.Fatalf("%s: expected verified %v, got %+v", test.name, test.verified, result.Contract)
+		}
+		if !test.verified {
+			continue
+		}
+		if result.Contract.ExactMatch != test.exact {
+			t.Errorf("%s: expected exact match %v", test.name, test.exact)
+		}
+		if result.Contract.Address != "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960" || !result.Contract.Optimize {
+			t.Errorf("%s: unexpected contract %+v", test.name, result.Contract)
+		}
+	}
+
+	verifier := &Verifier{Compiler: &stubCompiler{err: inputErrorf("compilation failed: ParserError: Expected a semicolon")}}
+	if _, err := verifier.Verify(context.Background(), "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", onChain, Input{}); err == nil {
+		t.Error("expected the compilation error")
+	} else if _, ok := err.(*InputError); !ok {
+		t.Errorf("expected an input error, got %v", err)
+	}
+}
+
+func TestMemoryStoreKeepsExactMatch(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Save(ctx, Contract{Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", ContractName: "exact", ExactMatch: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, Contract{Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", ContractName: "partial"}); err != nil {
+		t.Fatal(err)
+	}
+	contract, err := store.Get(ctx, "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.ContractName != "exact" {
+		t.Errorf("expected a partial match not to replace the exact one, got %s", contract.ContractName)
+	}
+	if err := store.Save(ctx, Contract{Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", ContractName: "exact again", ExactMatch: true}); err != nil {
+		t.Fatal(err)
+	}
+	if contract, _ := store.Get(ctx, "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"); contract.ContractName != "exact again" {
+		t.Errorf("expected an exact match to replace the exact one, got %s", contract.ContractName)
+	}
+}
+
+// TestSolcBinaryBasePath runs a stand-in for solc that checks it runs in an empty directory set as its base path
+func TestSolcBinaryBasePath(t *testing.T) {
+	solc := filepath.Join(t.TempDir(), "solc")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "solc, the solidity compiler commandline interface"
+	echo "Version: 0.8.17+commit.8df45f5f.Linux.g++"
+	exit 0
+fi
+if [ "$1" != "--standard-json" ] || [ "$2" != "--base-path" ] || [ "$3" != "$(pwd)" ] || [ -n "$(ls -A)" ]; then
+	echo "unexpected arguments $* in $(pwd)" >&2
+	exit 1
+fi
+cat > /dev/null
+echo '{"errors":[{"type":"ParserError","severity":"error","message":"Expected a semicolon","formattedMessage":"ParserError: Expected a semicolon at /home/janus/contract.sol"}]}'
+`
+	if err := os.WriteFile(solc, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := (&SolcBinary{Path: solc}).Compile(context.Background(), Input{Sources: map[string]string{"contract.sol": "contract Token {"}, ContractName: "Token"})
+	inputErr, ok := err.(*InputError)
+	if !ok {
+		t.Fatalf("expected the compilation error of the sources, got %v", err)
+	}
+	if inputErr.Error() != "compilation failed: ParserError: Expected a semicolon" || strings.Contains(inputErr.Error(), "/home") {
+		t.Errorf("expected only the message of the compilation error, got %q", inputErr.Error())
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}